@@ -19,7 +19,6 @@ import (
 	"io"
 	"log"
 	"net/http"
-	"strings"
 
 	"github.com/cloudbase/garm/apiserver/params"
 	"github.com/cloudbase/garm/auth"
@@ -30,6 +29,7 @@ import (
 	"github.com/cloudbase/garm/util"
 	wsWriter "github.com/cloudbase/garm/websocket"
 
+	"github.com/gorilla/mux"
 	"github.com/gorilla/websocket"
 	"github.com/pkg/errors"
 )
@@ -110,6 +110,9 @@ func (a *APIController) handleWorkflowJobEvent(w http.ResponseWriter, r *http.Re
 
 	signature := r.Header.Get("X-Hub-Signature-256")
 	hookType := r.Header.Get("X-Github-Hook-Installation-Target-Type")
+	// The entity ID is only set if the webhook was sent to the webhook URL of an entity.
+	entityID := mux.Vars(r)["entityID"]
+	deliveryID := r.Header.Get("X-Github-Delivery")
 
 	var labelValues []string
 	defer func() {
@@ -121,21 +124,140 @@ func (a *APIController) handleWorkflowJobEvent(w http.ResponseWriter, r *http.Re
 		}
 	}()
 
-	if err := a.r.DispatchWorkflowJob(hookType, signature, body); err != nil {
+	// The job is recorded and processed asynchronously, so we can reply to github
+	// right away, instead of risking a timeout.
+	if err := a.r.EnqueueWorkflowJob(entityID, deliveryID, hookType, signature, body); err != nil {
+		labelValues = a.webhookErrorLabelValues(err)
 		if errors.Is(err, gErrors.ErrNotFound) {
-			labelValues = a.webhookMetricLabelValues("false", "owner_unknown")
-			log.Printf("got not found error from DispatchWorkflowJob. webhook not meant for us?: %q", err)
+			log.Printf("got not found error from EnqueueWorkflowJob. webhook not meant for us?: %q", err)
 			return
-		} else if strings.Contains(err.Error(), "signature") { // TODO: check error type
-			labelValues = a.webhookMetricLabelValues("false", "signature_invalid")
-		} else {
-			labelValues = a.webhookMetricLabelValues("false", "unknown")
 		}
 
 		handleError(w, err)
 		return
 	}
 	labelValues = a.webhookMetricLabelValues("true", "")
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// webhookErrorLabelValues returns the webhook metric label values for a webhook
+// we failed to handle.
+func (a *APIController) webhookErrorLabelValues(err error) []string {
+	if errors.Is(err, gErrors.ErrNotFound) {
+		return a.webhookMetricLabelValues("false", "owner_unknown")
+	}
+
+	// Webhooks with a missing or wrong signature, and webhooks for entities without
+	// a secret, fail with one of these errors.
+	switch errors.Cause(err).(type) {
+	case *gErrors.UnauthorizedError, *gErrors.MissingSecretError:
+		return a.webhookMetricLabelValues("false", "signature_invalid")
+	}
+	return a.webhookMetricLabelValues("false", "unknown")
+}
+
+func (a *APIController) handlePingEvent(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		handleError(w, gErrors.NewBadRequestError("invalid post body: %s", err))
+		return
+	}
+
+	signature := r.Header.Get("X-Hub-Signature-256")
+	hookType := r.Header.Get("X-Github-Hook-Installation-Target-Type")
+	// The entity ID is only set if the webhook was sent to the webhook URL of an entity.
+	entityID := mux.Vars(r)["entityID"]
+
+	var labelValues []string
+	defer func() {
+		if len(labelValues) == 0 {
+			return
+		}
+		if err := metrics.RecordWebhookWithLabels(labelValues...); err != nil {
+			log.Printf("failed to record metric: %s", err)
+		}
+	}()
+
+	// Unlike other events, we reply with an error if the ping does not match any entity,
+	// so the problem is visible in the recent deliveries of the webhook.
+	entity, err := a.r.HandlePing(entityID, hookType, signature, body)
+	if err != nil {
+		labelValues = a.webhookErrorLabelValues(err)
+		log.Printf("failed to verify ping: %s", err)
+		handleError(w, err)
+		return
+	}
+	labelValues = a.webhookMetricLabelValues("true", "")
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(entity); err != nil {
+		log.Printf("failed to encode response: %q", err)
+	}
+}
+
+func (a *APIController) handleWorkflowRunEvent(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		handleError(w, gErrors.NewBadRequestError("invalid post body: %s", err))
+		return
+	}
+
+	signature := r.Header.Get("X-Hub-Signature-256")
+	hookType := r.Header.Get("X-Github-Hook-Installation-Target-Type")
+	// The entity ID is only set if the webhook was sent to the webhook URL of an entity.
+	entityID := mux.Vars(r)["entityID"]
+
+	var labelValues []string
+	defer func() {
+		if len(labelValues) == 0 {
+			return
+		}
+		if err := metrics.RecordWebhookWithLabels(labelValues...); err != nil {
+			log.Printf("failed to record metric: %s", err)
+		}
+	}()
+
+	run, entity, err := a.r.HandleWorkflowRun(entityID, hookType, signature, body)
+	if err != nil {
+		labelValues = a.webhookErrorLabelValues(err)
+		if errors.Is(err, gErrors.ErrNotFound) {
+			log.Printf("got not found error from HandleWorkflowRun. webhook not meant for us?: %q", err)
+			return
+		}
+
+		handleError(w, err)
+		return
+	}
+	labelValues = a.webhookMetricLabelValues("true", "")
+
+	controllerInfo, err := a.r.GetControllerInfo(auth.GetAdminContext())
+	if err != nil {
+		log.Printf("failed to get controller info: %s", err)
+		return
+	}
+	if err := metrics.RecordWorkflowRunWithLabels(
+		run.Action, run.WorkflowRun.Conclusion, entity.Name, string(entity.Type),
+		controllerInfo.Hostname, controllerInfo.ControllerID.String()); err != nil {
+		log.Printf("failed to record metric: %s", err)
+	}
+}
+
+func (a *APIController) handleInstallationEvent(w http.ResponseWriter, r *http.Request, event runnerParams.Event) {
+	defer r.Body.Close()
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		handleError(w, gErrors.NewBadRequestError("invalid post body: %s", err))
+		return
+	}
+
+	signature := r.Header.Get("X-Hub-Signature-256")
+	if err := a.r.HandleInstallationEvent(event, signature, body); err != nil {
+		log.Printf("failed to handle %s event: %s", event, err)
+		handleError(w, err)
+		return
+	}
 }
 
 func (a *APIController) CatchAll(w http.ResponseWriter, r *http.Request) {
@@ -145,6 +267,12 @@ func (a *APIController) CatchAll(w http.ResponseWriter, r *http.Request) {
 	switch event {
 	case runnerParams.WorkflowJobEvent:
 		a.handleWorkflowJobEvent(w, r)
+	case runnerParams.PingEvent:
+		a.handlePingEvent(w, r)
+	case runnerParams.WorkflowRunEvent:
+		a.handleWorkflowRunEvent(w, r)
+	case runnerParams.InstallationEvent, runnerParams.InstallationRepositoriesEvent:
+		a.handleInstallationEvent(w, r, event)
 	default:
 		log.Printf("ignoring unknown event %s", util.SanitizeLogEntry(string(event)))
 		return
@@ -350,6 +478,135 @@ func (a *APIController) ListProviders(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// swagger:route POST /providers/{providerName}/drain providers StartProviderDrain
+//
+// Start draining all pools that use a provider.
+//
+//	Parameters:
+//	  + name: providerName
+//	    description: Name of the provider to drain.
+//	    type: string
+//	    in: path
+//	    required: true
+//
+//	Responses:
+//	  200: Provider
+//	  default: APIErrorResponse
+func (a *APIController) StartProviderDrainHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	vars := mux.Vars(r)
+	providerName, ok := vars["providerName"]
+	if !ok {
+		w.WriteHeader(http.StatusBadRequest)
+		if err := json.NewEncoder(w).Encode(params.APIErrorResponse{
+			Error:   "Bad Request",
+			Details: "No provider name specified",
+		}); err != nil {
+			log.Printf("failed to encode response: %q", err)
+		}
+		return
+	}
+
+	provider, err := a.r.StartProviderDrain(ctx, providerName)
+	if err != nil {
+		log.Printf("starting provider drain: %s", err)
+		handleError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(provider); err != nil {
+		log.Printf("failed to encode response: %q", err)
+	}
+}
+
+// swagger:route DELETE /providers/{providerName}/drain providers StopProviderDrain
+//
+// Stop draining a provider.
+//
+//	Parameters:
+//	  + name: providerName
+//	    description: Name of the provider.
+//	    type: string
+//	    in: path
+//	    required: true
+//
+//	Responses:
+//	  200: Provider
+//	  default: APIErrorResponse
+func (a *APIController) StopProviderDrainHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	vars := mux.Vars(r)
+	providerName, ok := vars["providerName"]
+	if !ok {
+		w.WriteHeader(http.StatusBadRequest)
+		if err := json.NewEncoder(w).Encode(params.APIErrorResponse{
+			Error:   "Bad Request",
+			Details: "No provider name specified",
+		}); err != nil {
+			log.Printf("failed to encode response: %q", err)
+		}
+		return
+	}
+
+	provider, err := a.r.StopProviderDrain(ctx, providerName)
+	if err != nil {
+		log.Printf("stopping provider drain: %s", err)
+		handleError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(provider); err != nil {
+		log.Printf("failed to encode response: %q", err)
+	}
+}
+
+// swagger:route GET /providers/{providerName}/drain providers GetProviderDrainStatus
+//
+// Get the drain status of a provider, across all pools that use it.
+//
+//	Parameters:
+//	  + name: providerName
+//	    description: Name of the provider.
+//	    type: string
+//	    in: path
+//	    required: true
+//
+//	Responses:
+//	  200: DrainStatus
+//	  default: APIErrorResponse
+func (a *APIController) GetProviderDrainStatusHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	vars := mux.Vars(r)
+	providerName, ok := vars["providerName"]
+	if !ok {
+		w.WriteHeader(http.StatusBadRequest)
+		if err := json.NewEncoder(w).Encode(params.APIErrorResponse{
+			Error:   "Bad Request",
+			Details: "No provider name specified",
+		}); err != nil {
+			log.Printf("failed to encode response: %q", err)
+		}
+		return
+	}
+
+	status, err := a.r.GetProviderDrainStatus(ctx, providerName)
+	if err != nil {
+		log.Printf("fetching provider drain status: %s", err)
+		handleError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(status); err != nil {
+		log.Printf("failed to encode response: %q", err)
+	}
+}
+
 func (a *APIController) ListAllJobs(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	jobs, err := a.r.ListAllJobs(ctx)
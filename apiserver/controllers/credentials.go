@@ -0,0 +1,157 @@
+// Copyright 2023 Cloudbase Solutions SRL
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package controllers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/cloudbase/garm/apiserver/params"
+	gErrors "github.com/cloudbase/garm/errors"
+	runnerParams "github.com/cloudbase/garm/params"
+
+	"github.com/gorilla/mux"
+)
+
+// swagger:route POST /credentials credentials CreateCredentials
+//
+// Create github credentials with the parameters given.
+//
+//	Parameters:
+//	  + name: Body
+//	    description: Parameters used when creating the github credentials.
+//	    type: CreateGithubCredentialsParams
+//	    in: body
+//	    required: true
+//
+//	Responses:
+//	  200: GithubCredentials
+//	  default: APIErrorResponse
+func (a *APIController) CreateCredentialsHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var credsData runnerParams.CreateGithubCredentialsParams
+	if err := json.NewDecoder(r.Body).Decode(&credsData); err != nil {
+		handleError(w, gErrors.ErrBadRequest)
+		return
+	}
+
+	creds, err := a.r.CreateGithubCredentials(ctx, credsData)
+	if err != nil {
+		log.Printf("error creating github credentials: %s", err)
+		handleError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(creds); err != nil {
+		log.Printf("failed to encode response: %q", err)
+	}
+}
+
+// swagger:route PUT /credentials/{credentialsName} credentials UpdateCredentials
+//
+// Update github credentials with the parameters given.
+//
+//	Parameters:
+//	  + name: credentialsName
+//	    description: Name of the github credentials to update.
+//	    type: string
+//	    in: path
+//	    required: true
+//
+//	  + name: Body
+//	    description: Parameters used when updating the github credentials.
+//	    type: UpdateGithubCredentialsParams
+//	    in: body
+//	    required: true
+//
+//	Responses:
+//	  200: GithubCredentials
+//	  default: APIErrorResponse
+func (a *APIController) UpdateCredentialsHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	vars := mux.Vars(r)
+	credsName, ok := vars["credentialsName"]
+	if !ok {
+		w.WriteHeader(http.StatusBadRequest)
+		if err := json.NewEncoder(w).Encode(params.APIErrorResponse{
+			Error:   "Bad Request",
+			Details: "No credentials name specified",
+		}); err != nil {
+			log.Printf("failed to encode response: %q", err)
+		}
+		return
+	}
+
+	var updatePayload runnerParams.UpdateGithubCredentialsParams
+	if err := json.NewDecoder(r.Body).Decode(&updatePayload); err != nil {
+		handleError(w, gErrors.ErrBadRequest)
+		return
+	}
+
+	creds, err := a.r.UpdateGithubCredentials(ctx, credsName, updatePayload)
+	if err != nil {
+		log.Printf("error updating github credentials: %s", err)
+		handleError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(creds); err != nil {
+		log.Printf("failed to encode response: %q", err)
+	}
+}
+
+// swagger:route DELETE /credentials/{credentialsName} credentials DeleteCredentials
+//
+// Delete github credentials by name.
+//
+//	Parameters:
+//	  + name: credentialsName
+//	    description: Name of the github credentials to delete.
+//	    type: string
+//	    in: path
+//	    required: true
+//
+//	Responses:
+//	  default: APIErrorResponse
+func (a *APIController) DeleteCredentialsHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	vars := mux.Vars(r)
+	credsName, ok := vars["credentialsName"]
+	if !ok {
+		w.WriteHeader(http.StatusBadRequest)
+		if err := json.NewEncoder(w).Encode(params.APIErrorResponse{
+			Error:   "Bad Request",
+			Details: "No credentials name specified",
+		}); err != nil {
+			log.Printf("failed to encode response: %q", err)
+		}
+		return
+	}
+
+	if err := a.r.DeleteGithubCredentials(ctx, credsName); err != nil {
+		log.Printf("error deleting github credentials: %s", err)
+		handleError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+}
@@ -318,3 +318,19 @@ func (a *APIController) InstanceGithubRegistrationTokenHandler(w http.ResponseWr
 		log.Printf("failed to encode response: %q", err)
 	}
 }
+
+func (a *APIController) InstanceGithubJITConfigHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	jitConfig, err := a.r.GetInstanceGithubJITConfig(ctx)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write([]byte(jitConfig)); err != nil {
+		log.Printf("failed to encode response: %q", err)
+	}
+}
@@ -189,3 +189,218 @@ func (a *APIController) UpdatePoolByIDHandler(w http.ResponseWriter, r *http.Req
 		log.Printf("failed to encode response: %q", err)
 	}
 }
+
+// swagger:route POST /pools/{poolID}/drain pools StartPoolDrain
+//
+// Start draining a pool. No new runners are created in the pool, and its idle runners are removed.
+//
+//	Parameters:
+//	  + name: poolID
+//	    description: ID of the pool to drain.
+//	    type: string
+//	    in: path
+//	    required: true
+//
+//	Responses:
+//	  200: Pool
+//	  default: APIErrorResponse
+func (a *APIController) StartPoolDrainHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	vars := mux.Vars(r)
+	poolID, ok := vars["poolID"]
+	if !ok {
+		w.WriteHeader(http.StatusBadRequest)
+		if err := json.NewEncoder(w).Encode(params.APIErrorResponse{
+			Error:   "Bad Request",
+			Details: "No pool ID specified",
+		}); err != nil {
+			log.Printf("failed to encode response: %q", err)
+		}
+		return
+	}
+
+	pool, err := a.r.StartPoolDrain(ctx, poolID)
+	if err != nil {
+		log.Printf("starting pool drain: %s", err)
+		handleError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(pool); err != nil {
+		log.Printf("failed to encode response: %q", err)
+	}
+}
+
+// swagger:route DELETE /pools/{poolID}/drain pools StopPoolDrain
+//
+// Stop draining a pool.
+//
+//	Parameters:
+//	  + name: poolID
+//	    description: ID of the pool.
+//	    type: string
+//	    in: path
+//	    required: true
+//
+//	Responses:
+//	  200: Pool
+//	  default: APIErrorResponse
+func (a *APIController) StopPoolDrainHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	vars := mux.Vars(r)
+	poolID, ok := vars["poolID"]
+	if !ok {
+		w.WriteHeader(http.StatusBadRequest)
+		if err := json.NewEncoder(w).Encode(params.APIErrorResponse{
+			Error:   "Bad Request",
+			Details: "No pool ID specified",
+		}); err != nil {
+			log.Printf("failed to encode response: %q", err)
+		}
+		return
+	}
+
+	pool, err := a.r.StopPoolDrain(ctx, poolID)
+	if err != nil {
+		log.Printf("stopping pool drain: %s", err)
+		handleError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(pool); err != nil {
+		log.Printf("failed to encode response: %q", err)
+	}
+}
+
+// swagger:route GET /pools/{poolID}/drain pools GetPoolDrainStatus
+//
+// Get the drain status of a pool.
+//
+//	Parameters:
+//	  + name: poolID
+//	    description: ID of the pool.
+//	    type: string
+//	    in: path
+//	    required: true
+//
+//	Responses:
+//	  200: DrainStatus
+//	  default: APIErrorResponse
+func (a *APIController) GetPoolDrainStatusHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	vars := mux.Vars(r)
+	poolID, ok := vars["poolID"]
+	if !ok {
+		w.WriteHeader(http.StatusBadRequest)
+		if err := json.NewEncoder(w).Encode(params.APIErrorResponse{
+			Error:   "Bad Request",
+			Details: "No pool ID specified",
+		}); err != nil {
+			log.Printf("failed to encode response: %q", err)
+		}
+		return
+	}
+
+	status, err := a.r.GetPoolDrainStatus(ctx, poolID)
+	if err != nil {
+		log.Printf("fetching pool drain status: %s", err)
+		handleError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(status); err != nil {
+		log.Printf("failed to encode response: %q", err)
+	}
+}
+
+// swagger:route GET /pools/{poolID}/quota pools GetPoolQuota
+//
+// Get the number of runners of a pool used by each repository.
+//
+//	Parameters:
+//	  + name: poolID
+//	    description: ID of the pool.
+//	    type: string
+//	    in: path
+//	    required: true
+//
+//	Responses:
+//	  200: PoolQuota
+//	  default: APIErrorResponse
+func (a *APIController) GetPoolQuotaHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	vars := mux.Vars(r)
+	poolID, ok := vars["poolID"]
+	if !ok {
+		w.WriteHeader(http.StatusBadRequest)
+		if err := json.NewEncoder(w).Encode(params.APIErrorResponse{
+			Error:   "Bad Request",
+			Details: "No pool ID specified",
+		}); err != nil {
+			log.Printf("failed to encode response: %q", err)
+		}
+		return
+	}
+
+	quota, err := a.r.GetPoolQuota(ctx, poolID)
+	if err != nil {
+		log.Printf("fetching pool quota: %s", err)
+		handleError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(quota); err != nil {
+		log.Printf("failed to encode response: %q", err)
+	}
+}
+
+// swagger:route POST /pools/{poolID}/circuit-breaker/reset pools ResetPoolCircuitBreaker
+//
+// Reset the circuit breaker of a pool. The pool starts creating runners right away.
+//
+//	Parameters:
+//	  + name: poolID
+//	    description: ID of the pool.
+//	    type: string
+//	    in: path
+//	    required: true
+//
+//	Responses:
+//	  200: Pool
+//	  default: APIErrorResponse
+func (a *APIController) ResetPoolCircuitBreakerHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	vars := mux.Vars(r)
+	poolID, ok := vars["poolID"]
+	if !ok {
+		w.WriteHeader(http.StatusBadRequest)
+		if err := json.NewEncoder(w).Encode(params.APIErrorResponse{
+			Error:   "Bad Request",
+			Details: "No pool ID specified",
+		}); err != nil {
+			log.Printf("failed to encode response: %q", err)
+		}
+		return
+	}
+
+	pool, err := a.r.ResetPoolCircuitBreaker(ctx, poolID)
+	if err != nil {
+		log.Printf("resetting pool circuit breaker: %s", err)
+		handleError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(pool); err != nil {
+		log.Printf("failed to encode response: %q", err)
+	}
+}
@@ -0,0 +1,134 @@
+// Copyright 2023 Cloudbase Solutions SRL
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package controllers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/cloudbase/garm/apiserver/params"
+
+	"github.com/gorilla/mux"
+)
+
+// swagger:route GET /webhook-deliveries hooks ListWebhookDeliveries
+//
+// List webhook deliveries received from github. Payloads are not included.
+//
+//	Responses:
+//	  200: WebhookDeliveries
+//	  default: APIErrorResponse
+func (a *APIController) ListWebhookDeliveriesHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	deliveries, err := a.r.ListWebhookDeliveries(ctx)
+	if err != nil {
+		log.Printf("listing webhook deliveries: %s", err)
+		handleError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(deliveries); err != nil {
+		log.Printf("failed to encode response: %q", err)
+	}
+}
+
+// swagger:route GET /webhook-deliveries/{deliveryID} hooks GetWebhookDelivery
+//
+// Get a webhook delivery, including its payload.
+//
+//	Parameters:
+//	  + name: deliveryID
+//	    description: The ID of the webhook delivery.
+//	    type: string
+//	    in: path
+//	    required: true
+//
+//	Responses:
+//	  200: WebhookDelivery
+//	  default: APIErrorResponse
+func (a *APIController) GetWebhookDeliveryHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	vars := mux.Vars(r)
+	deliveryID, ok := vars["deliveryID"]
+	if !ok {
+		w.WriteHeader(http.StatusBadRequest)
+		if err := json.NewEncoder(w).Encode(params.APIErrorResponse{
+			Error:   "Bad Request",
+			Details: "No delivery ID specified",
+		}); err != nil {
+			log.Printf("failed to encode response: %q", err)
+		}
+		return
+	}
+
+	delivery, err := a.r.GetWebhookDelivery(ctx, deliveryID)
+	if err != nil {
+		log.Printf("fetching webhook delivery: %s", err)
+		handleError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(delivery); err != nil {
+		log.Printf("failed to encode response: %q", err)
+	}
+}
+
+// swagger:route POST /webhook-deliveries/{deliveryID}/replay hooks ReplayWebhookDelivery
+//
+// Process a webhook delivery again.
+//
+//	Parameters:
+//	  + name: deliveryID
+//	    description: The ID of the webhook delivery.
+//	    type: string
+//	    in: path
+//	    required: true
+//
+//	Responses:
+//	  200: WebhookDelivery
+//	  default: APIErrorResponse
+func (a *APIController) ReplayWebhookDeliveryHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	vars := mux.Vars(r)
+	deliveryID, ok := vars["deliveryID"]
+	if !ok {
+		w.WriteHeader(http.StatusBadRequest)
+		if err := json.NewEncoder(w).Encode(params.APIErrorResponse{
+			Error:   "Bad Request",
+			Details: "No delivery ID specified",
+		}); err != nil {
+			log.Printf("failed to encode response: %q", err)
+		}
+		return
+	}
+
+	delivery, err := a.r.ReplayWebhookDelivery(ctx, deliveryID)
+	if err != nil {
+		log.Printf("replaying webhook delivery: %s", err)
+		handleError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(delivery); err != nil {
+		log.Printf("failed to encode response: %q", err)
+	}
+}
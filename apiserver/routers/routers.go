@@ -89,6 +89,9 @@ func NewAPIRouter(han *controllers.APIController, logWriter io.Writer, authMiddl
 
 	// Handles github webhooks
 	webhookRouter := router.PathPrefix("/webhooks").Subrouter()
+	// Webhooks sent to the webhook URL of a repository, organization or enterprise.
+	webhookRouter.Handle("/{entityID}/", http.HandlerFunc(han.CatchAll))
+	webhookRouter.Handle("/{entityID}", http.HandlerFunc(han.CatchAll))
 	webhookRouter.PathPrefix("/").Handler(http.HandlerFunc(han.CatchAll))
 	webhookRouter.PathPrefix("").Handler(http.HandlerFunc(han.CatchAll))
 
@@ -108,6 +111,8 @@ func NewAPIRouter(han *controllers.APIController, logWriter io.Writer, authMiddl
 	metadataRouter := apiSubRouter.PathPrefix("/metadata").Subrouter()
 	metadataRouter.Handle("/runner-registration-token/", http.HandlerFunc(han.InstanceGithubRegistrationTokenHandler)).Methods("GET", "OPTIONS")
 	metadataRouter.Handle("/runner-registration-token", http.HandlerFunc(han.InstanceGithubRegistrationTokenHandler)).Methods("GET", "OPTIONS")
+	metadataRouter.Handle("/runner-jit-config/", http.HandlerFunc(han.InstanceGithubJITConfigHandler)).Methods("GET", "OPTIONS")
+	metadataRouter.Handle("/runner-jit-config", http.HandlerFunc(han.InstanceGithubJITConfigHandler)).Methods("GET", "OPTIONS")
 	metadataRouter.Use(instanceMiddleware.Middleware)
 	// Login
 	authRouter := apiSubRouter.PathPrefix("/auth").Subrouter()
@@ -147,6 +152,21 @@ func NewAPIRouter(han *controllers.APIController, logWriter io.Writer, authMiddl
 	// List pool instances
 	apiRouter.Handle("/pools/{poolID}/instances/", http.HandlerFunc(han.ListPoolInstancesHandler)).Methods("GET", "OPTIONS")
 	apiRouter.Handle("/pools/{poolID}/instances", http.HandlerFunc(han.ListPoolInstancesHandler)).Methods("GET", "OPTIONS")
+	// Start pool drain
+	apiRouter.Handle("/pools/{poolID}/drain/", http.HandlerFunc(han.StartPoolDrainHandler)).Methods("POST", "OPTIONS")
+	apiRouter.Handle("/pools/{poolID}/drain", http.HandlerFunc(han.StartPoolDrainHandler)).Methods("POST", "OPTIONS")
+	// Stop pool drain
+	apiRouter.Handle("/pools/{poolID}/drain/", http.HandlerFunc(han.StopPoolDrainHandler)).Methods("DELETE", "OPTIONS")
+	apiRouter.Handle("/pools/{poolID}/drain", http.HandlerFunc(han.StopPoolDrainHandler)).Methods("DELETE", "OPTIONS")
+	// Get pool drain status
+	apiRouter.Handle("/pools/{poolID}/drain/", http.HandlerFunc(han.GetPoolDrainStatusHandler)).Methods("GET", "OPTIONS")
+	apiRouter.Handle("/pools/{poolID}/drain", http.HandlerFunc(han.GetPoolDrainStatusHandler)).Methods("GET", "OPTIONS")
+	// Reset pool circuit breaker
+	apiRouter.Handle("/pools/{poolID}/circuit-breaker/reset/", http.HandlerFunc(han.ResetPoolCircuitBreakerHandler)).Methods("POST", "OPTIONS")
+	apiRouter.Handle("/pools/{poolID}/circuit-breaker/reset", http.HandlerFunc(han.ResetPoolCircuitBreakerHandler)).Methods("POST", "OPTIONS")
+	// Get pool quota usage
+	apiRouter.Handle("/pools/{poolID}/quota/", http.HandlerFunc(han.GetPoolQuotaHandler)).Methods("GET", "OPTIONS")
+	apiRouter.Handle("/pools/{poolID}/quota", http.HandlerFunc(han.GetPoolQuotaHandler)).Methods("GET", "OPTIONS")
 
 	/////////////
 	// Runners //
@@ -180,6 +200,16 @@ func NewAPIRouter(han *controllers.APIController, logWriter io.Writer, authMiddl
 	apiRouter.Handle("/repositories/{repoID}/pools/", http.HandlerFunc(han.CreateRepoPoolHandler)).Methods("POST", "OPTIONS")
 	apiRouter.Handle("/repositories/{repoID}/pools", http.HandlerFunc(han.CreateRepoPoolHandler)).Methods("POST", "OPTIONS")
 
+	// Install Webhook
+	apiRouter.Handle("/repositories/{repoID}/webhook/", http.HandlerFunc(han.InstallRepoWebhookHandler)).Methods("POST", "OPTIONS")
+	apiRouter.Handle("/repositories/{repoID}/webhook", http.HandlerFunc(han.InstallRepoWebhookHandler)).Methods("POST", "OPTIONS")
+	// Uninstall Webhook
+	apiRouter.Handle("/repositories/{repoID}/webhook/", http.HandlerFunc(han.UninstallRepoWebhookHandler)).Methods("DELETE", "OPTIONS")
+	apiRouter.Handle("/repositories/{repoID}/webhook", http.HandlerFunc(han.UninstallRepoWebhookHandler)).Methods("DELETE", "OPTIONS")
+	// Get webhook info
+	apiRouter.Handle("/repositories/{repoID}/webhook/", http.HandlerFunc(han.GetRepoWebhookInfoHandler)).Methods("GET", "OPTIONS")
+	apiRouter.Handle("/repositories/{repoID}/webhook", http.HandlerFunc(han.GetRepoWebhookInfoHandler)).Methods("GET", "OPTIONS")
+
 	// Repo instances list
 	apiRouter.Handle("/repositories/{repoID}/instances/", http.HandlerFunc(han.ListRepoInstancesHandler)).Methods("GET", "OPTIONS")
 	apiRouter.Handle("/repositories/{repoID}/instances", http.HandlerFunc(han.ListRepoInstancesHandler)).Methods("GET", "OPTIONS")
@@ -219,6 +249,16 @@ func NewAPIRouter(han *controllers.APIController, logWriter io.Writer, authMiddl
 	apiRouter.Handle("/organizations/{orgID}/pools/", http.HandlerFunc(han.CreateOrgPoolHandler)).Methods("POST", "OPTIONS")
 	apiRouter.Handle("/organizations/{orgID}/pools", http.HandlerFunc(han.CreateOrgPoolHandler)).Methods("POST", "OPTIONS")
 
+	// Install Webhook
+	apiRouter.Handle("/organizations/{orgID}/webhook/", http.HandlerFunc(han.InstallOrgWebhookHandler)).Methods("POST", "OPTIONS")
+	apiRouter.Handle("/organizations/{orgID}/webhook", http.HandlerFunc(han.InstallOrgWebhookHandler)).Methods("POST", "OPTIONS")
+	// Uninstall Webhook
+	apiRouter.Handle("/organizations/{orgID}/webhook/", http.HandlerFunc(han.UninstallOrgWebhookHandler)).Methods("DELETE", "OPTIONS")
+	apiRouter.Handle("/organizations/{orgID}/webhook", http.HandlerFunc(han.UninstallOrgWebhookHandler)).Methods("DELETE", "OPTIONS")
+	// Get webhook info
+	apiRouter.Handle("/organizations/{orgID}/webhook/", http.HandlerFunc(han.GetOrgWebhookInfoHandler)).Methods("GET", "OPTIONS")
+	apiRouter.Handle("/organizations/{orgID}/webhook", http.HandlerFunc(han.GetOrgWebhookInfoHandler)).Methods("GET", "OPTIONS")
+
 	// Org instances list
 	apiRouter.Handle("/organizations/{orgID}/instances/", http.HandlerFunc(han.ListOrgInstancesHandler)).Methods("GET", "OPTIONS")
 	apiRouter.Handle("/organizations/{orgID}/instances", http.HandlerFunc(han.ListOrgInstancesHandler)).Methods("GET", "OPTIONS")
@@ -281,8 +321,39 @@ func NewAPIRouter(han *controllers.APIController, logWriter io.Writer, authMiddl
 	// Credentials and providers
 	apiRouter.Handle("/credentials/", http.HandlerFunc(han.ListCredentials)).Methods("GET", "OPTIONS")
 	apiRouter.Handle("/credentials", http.HandlerFunc(han.ListCredentials)).Methods("GET", "OPTIONS")
+	// Create credentials
+	apiRouter.Handle("/credentials/", http.HandlerFunc(han.CreateCredentialsHandler)).Methods("POST", "OPTIONS")
+	apiRouter.Handle("/credentials", http.HandlerFunc(han.CreateCredentialsHandler)).Methods("POST", "OPTIONS")
+	// Update credentials
+	apiRouter.Handle("/credentials/{credentialsName}/", http.HandlerFunc(han.UpdateCredentialsHandler)).Methods("PUT", "OPTIONS")
+	apiRouter.Handle("/credentials/{credentialsName}", http.HandlerFunc(han.UpdateCredentialsHandler)).Methods("PUT", "OPTIONS")
+	// Delete credentials
+	apiRouter.Handle("/credentials/{credentialsName}/", http.HandlerFunc(han.DeleteCredentialsHandler)).Methods("DELETE", "OPTIONS")
+	apiRouter.Handle("/credentials/{credentialsName}", http.HandlerFunc(han.DeleteCredentialsHandler)).Methods("DELETE", "OPTIONS")
 	apiRouter.Handle("/providers/", http.HandlerFunc(han.ListProviders)).Methods("GET", "OPTIONS")
 	apiRouter.Handle("/providers", http.HandlerFunc(han.ListProviders)).Methods("GET", "OPTIONS")
+	// Start provider drain
+	apiRouter.Handle("/providers/{providerName}/drain/", http.HandlerFunc(han.StartProviderDrainHandler)).Methods("POST", "OPTIONS")
+	apiRouter.Handle("/providers/{providerName}/drain", http.HandlerFunc(han.StartProviderDrainHandler)).Methods("POST", "OPTIONS")
+	// Stop provider drain
+	apiRouter.Handle("/providers/{providerName}/drain/", http.HandlerFunc(han.StopProviderDrainHandler)).Methods("DELETE", "OPTIONS")
+	apiRouter.Handle("/providers/{providerName}/drain", http.HandlerFunc(han.StopProviderDrainHandler)).Methods("DELETE", "OPTIONS")
+	// Get provider drain status
+	apiRouter.Handle("/providers/{providerName}/drain/", http.HandlerFunc(han.GetProviderDrainStatusHandler)).Methods("GET", "OPTIONS")
+	apiRouter.Handle("/providers/{providerName}/drain", http.HandlerFunc(han.GetProviderDrainStatusHandler)).Methods("GET", "OPTIONS")
+
+	////////////////////////
+	// Webhook deliveries //
+	////////////////////////
+	// List webhook deliveries
+	apiRouter.Handle("/webhook-deliveries/", http.HandlerFunc(han.ListWebhookDeliveriesHandler)).Methods("GET", "OPTIONS")
+	apiRouter.Handle("/webhook-deliveries", http.HandlerFunc(han.ListWebhookDeliveriesHandler)).Methods("GET", "OPTIONS")
+	// Get webhook delivery
+	apiRouter.Handle("/webhook-deliveries/{deliveryID}/", http.HandlerFunc(han.GetWebhookDeliveryHandler)).Methods("GET", "OPTIONS")
+	apiRouter.Handle("/webhook-deliveries/{deliveryID}", http.HandlerFunc(han.GetWebhookDeliveryHandler)).Methods("GET", "OPTIONS")
+	// Replay webhook delivery
+	apiRouter.Handle("/webhook-deliveries/{deliveryID}/replay/", http.HandlerFunc(han.ReplayWebhookDeliveryHandler)).Methods("POST", "OPTIONS")
+	apiRouter.Handle("/webhook-deliveries/{deliveryID}/replay", http.HandlerFunc(han.ReplayWebhookDeliveryHandler)).Methods("POST", "OPTIONS")
 
 	// Websocket log writer
 	apiRouter.Handle("/{ws:ws\\/?}", http.HandlerFunc(han.WSHandler)).Methods("GET")
@@ -36,7 +36,13 @@ if [ -z "$METADATA_URL" ];then
 	echo "no token is available and METADATA_URL is not set"
 	exit 1
 fi
-GITHUB_TOKEN=$(curl --retry 5 --retry-delay 5 --retry-connrefused --fail -s -X GET -H 'Accept: application/json' -H "Authorization: Bearer ${BEARER_TOKEN}" "${METADATA_URL}/runner-registration-token/")
+# Try to fetch a just-in-time runner config first. If garm or GitHub can't generate one,
+# we fall back to using a registration token.
+JIT_CONFIG=$(curl --retry 5 --retry-delay 5 --retry-connrefused --fail -s -X GET -H 'Accept: application/json' -H "Authorization: Bearer ${BEARER_TOKEN}" "${METADATA_URL}/runner-jit-config/" || true)
+GITHUB_TOKEN=""
+if [ -z "$JIT_CONFIG" ];then
+	GITHUB_TOKEN=$(curl --retry 5 --retry-delay 5 --retry-connrefused --fail -s -X GET -H 'Accept: application/json' -H "Authorization: Bearer ${BEARER_TOKEN}" "${METADATA_URL}/runner-registration-token/")
+fi
 
 function call() {
 	PAYLOAD="$1"
@@ -100,6 +106,21 @@ function downloadAndExtractRunner() {
 	# chown {{ .RunnerUsername }}:{{ .RunnerGroup }} -R /home/{{ .RunnerUsername }}/actions-runner/ || fail "failed to change owner"
 }
 
+# The JIT config is a base64 encoded JSON holding the base64 encoded contents of the
+# .runner, .credentials and .credentials_rsaparams files. Writing those files is all
+# config.sh would have done for us.
+function writeJITConfig() {
+	JIT_JSON=$(echo "$JIT_CONFIG" | base64 -d) || fail "failed to decode JIT config"
+	for FILE in .runner .credentials .credentials_rsaparams; do
+		CONTENT=$(echo "$JIT_JSON" | grep -o "\"\\${FILE}\": *\"[^\"]*\"" | cut -d'"' -f4)
+		if [ -z "$CONTENT" ];then
+			fail "JIT config is missing $FILE"
+		fi
+		echo "$CONTENT" | base64 -d > "$FILE" || fail "failed to write $FILE"
+		chmod 600 "$FILE" || fail "failed to set permissions on $FILE"
+	done
+}
+
 TEMP_TOKEN=""
 GH_RUNNER_GROUP="{{.GitHubRunnerGroup}}"
 
@@ -124,35 +145,40 @@ else
 fi
 
 
-sendStatus "configuring runner"
-set +e
-attempt=1
-while true; do
-	ERROUT=$(mktemp)
-	./config.sh --unattended --url "{{ .RepoURL }}" --token "$GITHUB_TOKEN" $RUNNER_GROUP_OPT --name "{{ .RunnerName }}" --labels "{{ .RunnerLabels }}" --ephemeral 2>$ERROUT
-	if [ $? -eq 0 ]; then
-		rm $ERROUT || true
-		sendStatus "runner successfully configured after $attempt attempt(s)"
-		break
-	fi
-	LAST_ERR=$(cat $ERROUT)
-	echo "$LAST_ERR"
-
-	# if the runner is already configured, remove it and try again. In the past configuring a runner
-	# managed to register it but timed out later, resulting in an error.
-	./config.sh remove --token "$GITHUB_TOKEN" || true
-
-	if [ $attempt -gt 5 ];then
+if [ -n "$JIT_CONFIG" ];then
+	sendStatus "configuring runner using JIT config"
+	writeJITConfig
+else
+	sendStatus "configuring runner"
+	set +e
+	attempt=1
+	while true; do
+		ERROUT=$(mktemp)
+		./config.sh --unattended --url "{{ .RepoURL }}" --token "$GITHUB_TOKEN" $RUNNER_GROUP_OPT --name "{{ .RunnerName }}" --labels "{{ .RunnerLabels }}" --ephemeral 2>$ERROUT
+		if [ $? -eq 0 ]; then
+			rm $ERROUT || true
+			sendStatus "runner successfully configured after $attempt attempt(s)"
+			break
+		fi
+		LAST_ERR=$(cat $ERROUT)
+		echo "$LAST_ERR"
+
+		# if the runner is already configured, remove it and try again. In the past configuring a runner
+		# managed to register it but timed out later, resulting in an error.
+		./config.sh remove --token "$GITHUB_TOKEN" || true
+
+		if [ $attempt -gt 5 ];then
+			rm $ERROUT || true
+			fail "failed to configure runner: $LAST_ERR"
+		fi
+
+		sendStatus "failed to configure runner (attempt $attempt): $LAST_ERR (retrying in 5 seconds)"
+		attempt=$((attempt+1))
 		rm $ERROUT || true
-		fail "failed to configure runner: $LAST_ERR"
-	fi
-
-	sendStatus "failed to configure runner (attempt $attempt): $LAST_ERR (retrying in 5 seconds)"
-	attempt=$((attempt+1))
-	rm $ERROUT || true
-	sleep 5
-done
-set -e
+		sleep 5
+	done
+	set -e
+fi
 
 sendStatus "installing runner service"
 sudo ./svc.sh install {{ .RunnerUsername }} || fail "failed to install service"
@@ -365,7 +391,17 @@ function Install-Runner() {
 			Import-Certificate -CertificatePath $env:TMP\garm-ca.pem
 		}
 
-		$GithubRegistrationToken = Invoke-WebRequest -UseBasicParsing -Headers @{"Accept"="application/json"; "Authorization"="Bearer $Token"} -Uri $MetadataURL/runner-registration-token/
+		# Try to fetch a just-in-time runner config first. If garm or GitHub can't generate one,
+		# we fall back to using a registration token.
+		$JITConfig = ""
+		try {
+			$JITConfig = (Invoke-WebRequest -UseBasicParsing -Headers @{"Accept"="application/json"; "Authorization"="Bearer $Token"} -Uri $MetadataURL/runner-jit-config/).Content
+		} catch {
+			$JITConfig = ""
+		}
+		if ($JITConfig.Length -eq 0) {
+			$GithubRegistrationToken = Invoke-WebRequest -UseBasicParsing -Headers @{"Accept"="application/json"; "Authorization"="Bearer $Token"} -Uri $MetadataURL/runner-registration-token/
+		}
 		Update-GarmStatus -CallbackURL $CallbackURL -Message "downloading tools from $DownloadURL"
 
 		$downloadToken="{{.TempDownloadToken}}"
@@ -390,7 +426,23 @@ function Install-Runner() {
 		}
 		Update-GarmStatus -CallbackURL $CallbackURL -Message "configuring and starting runner"
 		cd $runnerDir
-		./config.cmd --unattended --url "{{ .RepoURL }}" --token $GithubRegistrationToken $runnerGroupOpt --name "{{ .RunnerName }}" --labels "{{ .RunnerLabels }}" --ephemeral --runasservice
+		if ($JITConfig.Length -gt 0) {
+			# The JIT config is a base64 encoded JSON holding the base64 encoded contents of the
+			# .runner, .credentials and .credentials_rsaparams files.
+			$jitFiles = ConvertFrom-Json ([System.Text.Encoding]::UTF8.GetString([System.Convert]::FromBase64String($JITConfig)))
+			foreach ($file in @(".runner", ".credentials", ".credentials_rsaparams")) {
+				$content = $jitFiles.$file
+				if (!$content) {
+					Throw "JIT config is missing $file"
+				}
+				[System.IO.File]::WriteAllBytes((Join-Path $runnerDir $file), [System.Convert]::FromBase64String($content))
+			}
+			$serviceName = "actions.runner.{{ .RunnerName }}"
+			New-Service -Name $serviceName -BinaryPathName (Join-Path $runnerDir "bin\RunnerService.exe") -StartupType Automatic | Out-Null
+			Start-Service -Name $serviceName
+		} else {
+			./config.cmd --unattended --url "{{ .RepoURL }}" --token $GithubRegistrationToken $runnerGroupOpt --name "{{ .RunnerName }}" --labels "{{ .RunnerLabels }}" --ephemeral --runasservice
+		}
 
 		$agentInfoFile = Join-Path $runnerDir ".runner"
 		$agentInfo = ConvertFrom-Json (gc -raw $agentInfoFile)
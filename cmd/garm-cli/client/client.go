@@ -136,6 +136,52 @@ func (c *Client) ListCredentials() ([]params.GithubCredentials, error) {
 	return ghCreds, nil
 }
 
+func (c *Client) CreateCredentials(param params.CreateGithubCredentialsParams) (params.GithubCredentials, error) {
+	var response params.GithubCredentials
+	url := fmt.Sprintf("%s/api/v1/credentials", c.Config.BaseURL)
+
+	body, err := json.Marshal(param)
+	if err != nil {
+		return params.GithubCredentials{}, err
+	}
+	resp, err := c.client.R().
+		SetBody(body).
+		SetResult(&response).
+		Post(url)
+	if err := c.handleError(err, resp); err != nil {
+		return params.GithubCredentials{}, err
+	}
+	return response, nil
+}
+
+func (c *Client) UpdateCredentials(name string, param params.UpdateGithubCredentialsParams) (params.GithubCredentials, error) {
+	var response params.GithubCredentials
+	url := fmt.Sprintf("%s/api/v1/credentials/%s", c.Config.BaseURL, name)
+
+	body, err := json.Marshal(param)
+	if err != nil {
+		return params.GithubCredentials{}, err
+	}
+	resp, err := c.client.R().
+		SetBody(body).
+		SetResult(&response).
+		Put(url)
+	if err := c.handleError(err, resp); err != nil {
+		return params.GithubCredentials{}, err
+	}
+	return response, nil
+}
+
+func (c *Client) DeleteCredentials(name string) error {
+	url := fmt.Sprintf("%s/api/v1/credentials/%s", c.Config.BaseURL, name)
+	resp, err := c.client.R().
+		Delete(url)
+	if err := c.handleError(err, resp); err != nil {
+		return err
+	}
+	return nil
+}
+
 func (c *Client) ListProviders() ([]params.Provider, error) {
 	var providers []params.Provider
 	url := fmt.Sprintf("%s/api/v1/providers", c.Config.BaseURL)
@@ -299,3 +345,27 @@ func (c *Client) UpdatePoolByID(poolID string, param params.UpdatePoolParams) (p
 	}
 	return response, nil
 }
+
+func (c *Client) GetPoolQuota(poolID string) (params.PoolQuota, error) {
+	var response params.PoolQuota
+	url := fmt.Sprintf("%s/api/v1/pools/%s/quota", c.Config.BaseURL, poolID)
+	resp, err := c.client.R().
+		SetResult(&response).
+		Get(url)
+	if err := c.handleError(err, resp); err != nil {
+		return params.PoolQuota{}, err
+	}
+	return response, nil
+}
+
+func (c *Client) ResetPoolCircuitBreaker(poolID string) (params.Pool, error) {
+	var response params.Pool
+	url := fmt.Sprintf("%s/api/v1/pools/%s/circuit-breaker/reset", c.Config.BaseURL, poolID)
+	resp, err := c.client.R().
+		SetResult(&response).
+		Post(url)
+	if err := c.handleError(err, resp); err != nil {
+		return params.Pool{}, err
+	}
+	return response, nil
+}
@@ -0,0 +1,93 @@
+// Copyright 2023 Cloudbase Solutions SRL
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package client
+
+import (
+	"fmt"
+
+	"github.com/cloudbase/garm/params"
+)
+
+func (c *Client) StartPoolDrain(poolID string) (params.Pool, error) {
+	var response params.Pool
+	url := fmt.Sprintf("%s/api/v1/pools/%s/drain", c.Config.BaseURL, poolID)
+	resp, err := c.client.R().
+		SetResult(&response).
+		Post(url)
+	if err := c.handleError(err, resp); err != nil {
+		return params.Pool{}, err
+	}
+	return response, nil
+}
+
+func (c *Client) StopPoolDrain(poolID string) (params.Pool, error) {
+	var response params.Pool
+	url := fmt.Sprintf("%s/api/v1/pools/%s/drain", c.Config.BaseURL, poolID)
+	resp, err := c.client.R().
+		SetResult(&response).
+		Delete(url)
+	if err := c.handleError(err, resp); err != nil {
+		return params.Pool{}, err
+	}
+	return response, nil
+}
+
+func (c *Client) GetPoolDrainStatus(poolID string) (params.DrainStatus, error) {
+	var response params.DrainStatus
+	url := fmt.Sprintf("%s/api/v1/pools/%s/drain", c.Config.BaseURL, poolID)
+	resp, err := c.client.R().
+		SetResult(&response).
+		Get(url)
+	if err := c.handleError(err, resp); err != nil {
+		return params.DrainStatus{}, err
+	}
+	return response, nil
+}
+
+func (c *Client) StartProviderDrain(providerName string) (params.Provider, error) {
+	var response params.Provider
+	url := fmt.Sprintf("%s/api/v1/providers/%s/drain", c.Config.BaseURL, providerName)
+	resp, err := c.client.R().
+		SetResult(&response).
+		Post(url)
+	if err := c.handleError(err, resp); err != nil {
+		return params.Provider{}, err
+	}
+	return response, nil
+}
+
+func (c *Client) StopProviderDrain(providerName string) (params.Provider, error) {
+	var response params.Provider
+	url := fmt.Sprintf("%s/api/v1/providers/%s/drain", c.Config.BaseURL, providerName)
+	resp, err := c.client.R().
+		SetResult(&response).
+		Delete(url)
+	if err := c.handleError(err, resp); err != nil {
+		return params.Provider{}, err
+	}
+	return response, nil
+}
+
+func (c *Client) GetProviderDrainStatus(providerName string) (params.DrainStatus, error) {
+	var response params.DrainStatus
+	url := fmt.Sprintf("%s/api/v1/providers/%s/drain", c.Config.BaseURL, providerName)
+	resp, err := c.client.R().
+		SetResult(&response).
+		Get(url)
+	if err := c.handleError(err, resp); err != nil {
+		return params.DrainStatus{}, err
+	}
+	return response, nil
+}
@@ -194,3 +194,43 @@ func (c *Client) CreateMetricsToken() (string, error) {
 	}
 	return t.Token, nil
 }
+
+func (c *Client) InstallOrgWebhook(orgID string, param params.InstallWebhookParams) (params.HookInfo, error) {
+	var response params.HookInfo
+	url := fmt.Sprintf("%s/api/v1/organizations/%s/webhook", c.Config.BaseURL, orgID)
+
+	body, err := json.Marshal(param)
+	if err != nil {
+		return params.HookInfo{}, err
+	}
+	resp, err := c.client.R().
+		SetBody(body).
+		SetResult(&response).
+		Post(url)
+	if err := c.handleError(err, resp); err != nil {
+		return params.HookInfo{}, err
+	}
+	return response, nil
+}
+
+func (c *Client) GetOrgWebhookInfo(orgID string) (params.HookInfo, error) {
+	var response params.HookInfo
+	url := fmt.Sprintf("%s/api/v1/organizations/%s/webhook", c.Config.BaseURL, orgID)
+	resp, err := c.client.R().
+		SetResult(&response).
+		Get(url)
+	if err := c.handleError(err, resp); err != nil {
+		return params.HookInfo{}, err
+	}
+	return response, nil
+}
+
+func (c *Client) UninstallOrgWebhook(orgID string) error {
+	url := fmt.Sprintf("%s/api/v1/organizations/%s/webhook", c.Config.BaseURL, orgID)
+	resp, err := c.client.R().
+		Delete(url)
+	if err := c.handleError(err, resp); err != nil {
+		return err
+	}
+	return nil
+}
@@ -177,3 +177,43 @@ func (c *Client) ListRepoInstances(repoID string) ([]params.Instance, error) {
 	}
 	return response, nil
 }
+
+func (c *Client) InstallRepoWebhook(repoID string, param params.InstallWebhookParams) (params.HookInfo, error) {
+	var response params.HookInfo
+	url := fmt.Sprintf("%s/api/v1/repositories/%s/webhook", c.Config.BaseURL, repoID)
+
+	body, err := json.Marshal(param)
+	if err != nil {
+		return params.HookInfo{}, err
+	}
+	resp, err := c.client.R().
+		SetBody(body).
+		SetResult(&response).
+		Post(url)
+	if err := c.handleError(err, resp); err != nil {
+		return params.HookInfo{}, err
+	}
+	return response, nil
+}
+
+func (c *Client) GetRepoWebhookInfo(repoID string) (params.HookInfo, error) {
+	var response params.HookInfo
+	url := fmt.Sprintf("%s/api/v1/repositories/%s/webhook", c.Config.BaseURL, repoID)
+	resp, err := c.client.R().
+		SetResult(&response).
+		Get(url)
+	if err := c.handleError(err, resp); err != nil {
+		return params.HookInfo{}, err
+	}
+	return response, nil
+}
+
+func (c *Client) UninstallRepoWebhook(repoID string) error {
+	url := fmt.Sprintf("%s/api/v1/repositories/%s/webhook", c.Config.BaseURL, repoID)
+	resp, err := c.client.R().
+		Delete(url)
+	if err := c.handleError(err, resp); err != nil {
+		return err
+	}
+	return nil
+}
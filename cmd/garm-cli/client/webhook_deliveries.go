@@ -0,0 +1,57 @@
+// Copyright 2023 Cloudbase Solutions SRL
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package client
+
+import (
+	"fmt"
+
+	"github.com/cloudbase/garm/params"
+)
+
+func (c *Client) ListWebhookDeliveries() ([]params.WebhookDelivery, error) {
+	var response []params.WebhookDelivery
+	url := fmt.Sprintf("%s/api/v1/webhook-deliveries", c.Config.BaseURL)
+	resp, err := c.client.R().
+		SetResult(&response).
+		Get(url)
+	if err := c.handleError(err, resp); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+func (c *Client) GetWebhookDelivery(deliveryID string) (params.WebhookDelivery, error) {
+	var response params.WebhookDelivery
+	url := fmt.Sprintf("%s/api/v1/webhook-deliveries/%s", c.Config.BaseURL, deliveryID)
+	resp, err := c.client.R().
+		SetResult(&response).
+		Get(url)
+	if err := c.handleError(err, resp); err != nil {
+		return params.WebhookDelivery{}, err
+	}
+	return response, nil
+}
+
+func (c *Client) ReplayWebhookDelivery(deliveryID string) (params.WebhookDelivery, error) {
+	var response params.WebhookDelivery
+	url := fmt.Sprintf("%s/api/v1/webhook-deliveries/%s/replay", c.Config.BaseURL, deliveryID)
+	resp, err := c.client.R().
+		SetResult(&response).
+		Post(url)
+	if err := c.handleError(err, resp); err != nil {
+		return params.WebhookDelivery{}, err
+	}
+	return response, nil
+}
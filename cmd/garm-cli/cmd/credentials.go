@@ -16,59 +16,290 @@ package cmd
 
 import (
 	"fmt"
+	"os"
 
 	"github.com/cloudbase/garm/params"
 
 	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 )
 
+var (
+	credsName           string
+	credsDescription    string
+	credsAuthType       string
+	credsOAuth2Token    string
+	credsAppID          int64
+	credsInstallationID int64
+	credsWebhookSecret  string
+	credsPrivateKeyPath string
+	credsBaseURL        string
+	credsAPIBaseURL     string
+	credsUploadBaseURL  string
+	credsCABundlePath   string
+	credsMaxRunners     uint
+)
+
 // credentialsCmd represents the credentials command
 var credentialsCmd = &cobra.Command{
 	Use:     "credentials",
 	Aliases: []string{"creds"},
-	Short:   "List configured credentials",
-	Long: `List all available credentials configured in the service
-config file.
+	Short:   "Manage github credentials",
+	Long: `Add, list, update or remove github credentials.
 
-Currently, github personal tokens are configured statically in the config file
-of the garm service. This command lists the names of those credentials,
-which in turn can be used to define pools of runners withing repositories.`,
+Github credentials can be defined statically in the config file of the garm
+service, or they can be stored in the garm database using this command. Credentials
+defined in the config file can only be listed. Both github personal tokens and
+GitHub App credentials are supported. The names of the credentials can be used to
+define repositories, organizations and enterprises.`,
 	Run: nil,
 }
 
+var credsListCmd = &cobra.Command{
+	Use:          "list",
+	Aliases:      []string{"ls"},
+	Short:        "List configured github credentials",
+	Long:         `List the names of the github credentials availabe to the garm, along with their auth type.`,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if needsInit {
+			return errNeedsInitError
+		}
+
+		creds, err := cli.ListCredentials()
+		if err != nil {
+			return err
+		}
+		formatGithubCredentials(creds)
+		return nil
+	},
+}
+
+var credsAddCmd = &cobra.Command{
+	Use:          "add",
+	Aliases:      []string{"create"},
+	Short:        "Add github credentials",
+	Long:         `Add new github credentials to the garm database.`,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if needsInit {
+			return errNeedsInitError
+		}
+
+		newCredsReq := params.CreateGithubCredentialsParams{
+			Name:          credsName,
+			Description:   credsDescription,
+			AuthType:      params.GithubAuthType(credsAuthType),
+			OAuth2Token:   credsOAuth2Token,
+			BaseURL:       credsBaseURL,
+			APIBaseURL:    credsAPIBaseURL,
+			UploadBaseURL: credsUploadBaseURL,
+			MaxRunners:    credsMaxRunners,
+		}
+
+		if newCredsReq.AuthType == params.GithubAuthTypeApp {
+			app, err := githubAppFromFlags()
+			if err != nil {
+				return err
+			}
+			newCredsReq.App = app
+		}
+
+		if credsCABundlePath != "" {
+			caBundle, err := os.ReadFile(credsCABundlePath)
+			if err != nil {
+				return errors.Wrap(err, "reading CA bundle")
+			}
+			newCredsReq.CABundle = caBundle
+		}
+
+		creds, err := cli.CreateCredentials(newCredsReq)
+		if err != nil {
+			return err
+		}
+		formatOneGithubCredentials(creds)
+		return nil
+	},
+}
+
+var credsUpdateCmd = &cobra.Command{
+	Use:          "update",
+	Short:        "Update github credentials",
+	Long:         `Update github credentials stored in the garm database. Pools using these credentials will pick up the changes without a restart.`,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if needsInit {
+			return errNeedsInitError
+		}
+
+		if len(args) == 0 {
+			return fmt.Errorf("command requires a credentials name")
+		}
+
+		if len(args) > 1 {
+			return fmt.Errorf("too many arguments")
+		}
+
+		updateCredsReq := params.UpdateGithubCredentialsParams{
+			OAuth2Token: credsOAuth2Token,
+		}
+
+		if cmd.Flags().Changed("description") {
+			updateCredsReq.Description = &credsDescription
+		}
+
+		if cmd.Flags().Changed("base-url") {
+			updateCredsReq.BaseURL = &credsBaseURL
+		}
+
+		if cmd.Flags().Changed("api-base-url") {
+			updateCredsReq.APIBaseURL = &credsAPIBaseURL
+		}
+
+		if cmd.Flags().Changed("upload-base-url") {
+			updateCredsReq.UploadBaseURL = &credsUploadBaseURL
+		}
+
+		if cmd.Flags().Changed("max-runners") {
+			updateCredsReq.MaxRunners = &credsMaxRunners
+		}
+
+		if cmd.Flags().Changed("app-id") || cmd.Flags().Changed("installation-id") || cmd.Flags().Changed("private-key-path") || cmd.Flags().Changed("app-webhook-secret") {
+			app, err := githubAppFromFlags()
+			if err != nil {
+				return err
+			}
+			updateCredsReq.App = &app
+		}
+
+		if credsCABundlePath != "" {
+			caBundle, err := os.ReadFile(credsCABundlePath)
+			if err != nil {
+				return errors.Wrap(err, "reading CA bundle")
+			}
+			updateCredsReq.CABundle = caBundle
+		}
+
+		creds, err := cli.UpdateCredentials(args[0], updateCredsReq)
+		if err != nil {
+			return err
+		}
+		formatOneGithubCredentials(creds)
+		return nil
+	},
+}
+
+var credsDeleteCmd = &cobra.Command{
+	Use:          "delete",
+	Aliases:      []string{"remove", "rm", "del"},
+	Short:        "Removes github credentials",
+	Long:         `Delete github credentials from the garm database. Credentials that are in use can not be removed.`,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if needsInit {
+			return errNeedsInitError
+		}
+
+		if len(args) == 0 {
+			return fmt.Errorf("command requires a credentials name")
+		}
+
+		if len(args) > 1 {
+			return fmt.Errorf("too many arguments")
+		}
+
+		if err := cli.DeleteCredentials(args[0]); err != nil {
+			return err
+		}
+		return nil
+	},
+}
+
 func init() {
+	credsAddCmd.Flags().StringVar(&credsName, "name", "", "The name of the credentials.")
+	credsAddCmd.Flags().StringVar(&credsDescription, "description", "", "A description for the credentials.")
+	credsAddCmd.Flags().StringVar(&credsAuthType, "auth-type", string(params.GithubAuthTypePAT), "The auth type of the credentials (pat or app).")
+	credsAddCmd.Flags().StringVar(&credsOAuth2Token, "oauth2-token", "", "The github personal access token. Used when auth type is pat.")
+	credsAddCmd.Flags().Int64Var(&credsAppID, "app-id", 0, "The ID of the GitHub App. Used when auth type is app.")
+	credsAddCmd.Flags().Int64Var(&credsInstallationID, "installation-id", 0, "The installation ID of the GitHub App. Used when auth type is app.")
+	credsAddCmd.Flags().StringVar(&credsPrivateKeyPath, "private-key-path", "", "Path to the private key of the GitHub App. Used when auth type is app.")
+	credsAddCmd.Flags().StringVar(&credsWebhookSecret, "app-webhook-secret", "", "The webhook secret of the GitHub App, used to validate installation webhooks. Used when auth type is app.")
+	credsAddCmd.Flags().StringVar(&credsBaseURL, "base-url", "", "The base URL of your GitHub Enterprise Server. Leave empty for github.com.")
+	credsAddCmd.Flags().StringVar(&credsAPIBaseURL, "api-base-url", "", "The API base URL of your GitHub Enterprise Server. Leave empty for github.com.")
+	credsAddCmd.Flags().StringVar(&credsUploadBaseURL, "upload-base-url", "", "The upload base URL of your GitHub Enterprise Server. Leave empty for github.com.")
+	credsAddCmd.Flags().StringVar(&credsCABundlePath, "ca-cert-bundle", "", "Path to a CA certificate bundle in PEM format, used to talk to the github API.")
+	credsAddCmd.Flags().UintVar(&credsMaxRunners, "max-runners", 0, "Maximum number of runners for entities using these credentials, across all their pools. A value of 0 means no limit.")
+	credsAddCmd.MarkFlagRequired("name") //nolint
+
+	credsUpdateCmd.Flags().StringVar(&credsDescription, "description", "", "A description for the credentials.")
+	credsUpdateCmd.Flags().StringVar(&credsOAuth2Token, "oauth2-token", "", "The new github personal access token.")
+	credsUpdateCmd.Flags().Int64Var(&credsAppID, "app-id", 0, "The ID of the GitHub App.")
+	credsUpdateCmd.Flags().Int64Var(&credsInstallationID, "installation-id", 0, "The installation ID of the GitHub App.")
+	credsUpdateCmd.Flags().StringVar(&credsPrivateKeyPath, "private-key-path", "", "Path to the private key of the GitHub App.")
+	credsUpdateCmd.Flags().StringVar(&credsWebhookSecret, "app-webhook-secret", "", "The webhook secret of the GitHub App.")
+	credsUpdateCmd.Flags().StringVar(&credsBaseURL, "base-url", "", "The base URL of your GitHub Enterprise Server.")
+	credsUpdateCmd.Flags().StringVar(&credsAPIBaseURL, "api-base-url", "", "The API base URL of your GitHub Enterprise Server.")
+	credsUpdateCmd.Flags().StringVar(&credsUploadBaseURL, "upload-base-url", "", "The upload base URL of your GitHub Enterprise Server.")
+	credsUpdateCmd.Flags().StringVar(&credsCABundlePath, "ca-cert-bundle", "", "Path to a CA certificate bundle in PEM format, used to talk to the github API.")
+	credsUpdateCmd.Flags().UintVar(&credsMaxRunners, "max-runners", 0, "Maximum number of runners for entities using these credentials, across all their pools. A value of 0 means no limit.")
+
 	credentialsCmd.AddCommand(
-		&cobra.Command{
-			Use:          "list",
-			Aliases:      []string{"ls"},
-			Short:        "List configured github credentials",
-			Long:         `List the names of the github personal access tokens availabe to the garm.`,
-			SilenceUsage: true,
-			RunE: func(cmd *cobra.Command, args []string) error {
-				if needsInit {
-					return errNeedsInitError
-				}
-
-				creds, err := cli.ListCredentials()
-				if err != nil {
-					return err
-				}
-				formatGithubCredentials(creds)
-				return nil
-			},
-		})
+		credsListCmd,
+		credsAddCmd,
+		credsUpdateCmd,
+		credsDeleteCmd,
+	)
 
 	rootCmd.AddCommand(credentialsCmd)
 }
 
+// githubAppFromFlags builds the github app details from the command line flags.
+// When updating, all github app details must be set.
+func githubAppFromFlags() (params.GithubApp, error) {
+	if credsPrivateKeyPath == "" {
+		return params.GithubApp{}, fmt.Errorf("missing --private-key-path")
+	}
+	privateKey, err := os.ReadFile(credsPrivateKeyPath)
+	if err != nil {
+		return params.GithubApp{}, errors.Wrap(err, "reading private key")
+	}
+	return params.GithubApp{
+		AppID:          credsAppID,
+		InstallationID: credsInstallationID,
+		PrivateKey:     privateKey,
+		WebhookSecret:  credsWebhookSecret,
+	}, nil
+}
+
 func formatGithubCredentials(creds []params.GithubCredentials) {
 	t := table.NewWriter()
-	header := table.Row{"Name", "Description", "Base URL", "API URL", "Upload URL"}
+	header := table.Row{"Name", "Description", "Base URL", "API URL", "Upload URL", "Auth type", "Runners"}
 	t.AppendHeader(header)
 	for _, val := range creds {
-		t.AppendRow(table.Row{val.Name, val.Description, val.BaseURL, val.APIBaseURL, val.UploadBaseURL})
+		runners := formatUsage(val.Runners, val.MaxRunners, "")
+		t.AppendRow(table.Row{val.Name, val.Description, val.BaseURL, val.APIBaseURL, val.UploadBaseURL, val.AuthType, runners})
 		t.AppendSeparator()
 	}
 	fmt.Println(t.Render())
 }
+
+func formatOneGithubCredentials(creds params.GithubCredentials) {
+	t := table.NewWriter()
+	header := table.Row{"Field", "Value"}
+	t.AppendHeader(header)
+	t.AppendRow(table.Row{"Name", creds.Name})
+	t.AppendRow(table.Row{"Description", creds.Description})
+	t.AppendRow(table.Row{"Base URL", creds.BaseURL})
+	t.AppendRow(table.Row{"API URL", creds.APIBaseURL})
+	t.AppendRow(table.Row{"Upload URL", creds.UploadBaseURL})
+	t.AppendRow(table.Row{"Auth type", creds.AuthType})
+	if creds.MaxRunners > 0 {
+		t.AppendRow(table.Row{"Max runners", creds.MaxRunners})
+	}
+	t.SetColumnConfigs([]table.ColumnConfig{
+		{Number: 1, AutoMerge: true},
+		{Number: 2, AutoMerge: false},
+	})
+	fmt.Println(t.Render())
+}
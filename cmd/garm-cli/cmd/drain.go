@@ -0,0 +1,244 @@
+// Copyright 2023 Cloudbase Solutions SRL
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/cloudbase/garm/params"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/spf13/cobra"
+)
+
+var poolDrainCmd = &cobra.Command{
+	Use:          "drain",
+	SilenceUsage: true,
+	Short:        "Drain a pool",
+	Long: `Start or stop draining a pool, and check on its progress.
+
+A draining pool does not create new runners. Its idle runners are
+removed, while runners that are running a job are left alone. The
+drain has finished once the pool has no runners left.`,
+	Run: nil,
+}
+
+var providerDrainCmd = &cobra.Command{
+	Use:          "drain",
+	SilenceUsage: true,
+	Short:        "Drain a provider",
+	Long: `Start or stop draining all pools that use a provider, and check
+on its progress.
+
+Pools that use a draining provider do not create new runners. Their
+idle runners are removed, while runners that are running a job are
+left alone. The drain has finished once none of these pools have any
+runners left.`,
+	Run: nil,
+}
+
+var poolDrainStartCmd = &cobra.Command{
+	Use:          "start",
+	Short:        "Start draining a pool",
+	Long:         `Stop creating runners in a pool and remove its idle runners.`,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if needsInit {
+			return errNeedsInitError
+		}
+
+		if len(args) == 0 {
+			return fmt.Errorf("requires a pool ID")
+		}
+
+		if len(args) > 1 {
+			return fmt.Errorf("too many arguments")
+		}
+
+		result, err := cli.StartPoolDrain(args[0])
+		if err != nil {
+			return err
+		}
+		formatOnePool(result)
+		return nil
+	},
+}
+
+var poolDrainStopCmd = &cobra.Command{
+	Use:          "stop",
+	Short:        "Stop draining a pool",
+	Long:         `Let a drained pool create runners again.`,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if needsInit {
+			return errNeedsInitError
+		}
+
+		if len(args) == 0 {
+			return fmt.Errorf("requires a pool ID")
+		}
+
+		if len(args) > 1 {
+			return fmt.Errorf("too many arguments")
+		}
+
+		result, err := cli.StopPoolDrain(args[0])
+		if err != nil {
+			return err
+		}
+		formatOnePool(result)
+		return nil
+	},
+}
+
+var poolDrainStatusCmd = &cobra.Command{
+	Use:          "status",
+	Short:        "Show the drain status of a pool",
+	Long:         `Show whether a pool is draining, and how many runners it has left.`,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if needsInit {
+			return errNeedsInitError
+		}
+
+		if len(args) == 0 {
+			return fmt.Errorf("requires a pool ID")
+		}
+
+		if len(args) > 1 {
+			return fmt.Errorf("too many arguments")
+		}
+
+		result, err := cli.GetPoolDrainStatus(args[0])
+		if err != nil {
+			return err
+		}
+		formatDrainStatus(result)
+		return nil
+	},
+}
+
+var providerDrainStartCmd = &cobra.Command{
+	Use:          "start",
+	Short:        "Start draining a provider",
+	Long:         `Stop creating runners in all pools that use a provider, and remove their idle runners.`,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if needsInit {
+			return errNeedsInitError
+		}
+
+		if len(args) == 0 {
+			return fmt.Errorf("requires a provider name")
+		}
+
+		if len(args) > 1 {
+			return fmt.Errorf("too many arguments")
+		}
+
+		result, err := cli.StartProviderDrain(args[0])
+		if err != nil {
+			return err
+		}
+		formatOneProvider(result)
+		return nil
+	},
+}
+
+var providerDrainStopCmd = &cobra.Command{
+	Use:   "stop",
+	Short: "Stop draining a provider",
+	Long: `Let the pools that use a drained provider create runners again.
+Pools that were drained individually keep draining.`,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if needsInit {
+			return errNeedsInitError
+		}
+
+		if len(args) == 0 {
+			return fmt.Errorf("requires a provider name")
+		}
+
+		if len(args) > 1 {
+			return fmt.Errorf("too many arguments")
+		}
+
+		result, err := cli.StopProviderDrain(args[0])
+		if err != nil {
+			return err
+		}
+		formatOneProvider(result)
+		return nil
+	},
+}
+
+var providerDrainStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show the drain status of a provider",
+	Long: `Show whether a provider is draining, and how many runners are left
+in the pools that use it.`,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if needsInit {
+			return errNeedsInitError
+		}
+
+		if len(args) == 0 {
+			return fmt.Errorf("requires a provider name")
+		}
+
+		if len(args) > 1 {
+			return fmt.Errorf("too many arguments")
+		}
+
+		result, err := cli.GetProviderDrainStatus(args[0])
+		if err != nil {
+			return err
+		}
+		formatDrainStatus(result)
+		return nil
+	},
+}
+
+func init() {
+	poolDrainCmd.AddCommand(
+		poolDrainStartCmd,
+		poolDrainStopCmd,
+		poolDrainStatusCmd,
+	)
+	providerDrainCmd.AddCommand(
+		providerDrainStartCmd,
+		providerDrainStopCmd,
+		providerDrainStatusCmd,
+	)
+
+	poolCmd.AddCommand(poolDrainCmd)
+	providerCmd.AddCommand(providerDrainCmd)
+}
+
+func formatDrainStatus(status params.DrainStatus) {
+	t := table.NewWriter()
+	header := table.Row{"Field", "Value"}
+	t.AppendHeader(header)
+	t.AppendRow(table.Row{"Draining", status.Draining})
+	if status.StartedAt != nil {
+		t.AppendRow(table.Row{"Started At", status.StartedAt})
+	}
+	t.AppendRow(table.Row{"Remaining Runners", status.RemainingRunners})
+	t.AppendRow(table.Row{"Active Runners", status.ActiveRunners})
+	t.AppendRow(table.Row{"Finished", status.Finished})
+	fmt.Println(t.Render())
+}
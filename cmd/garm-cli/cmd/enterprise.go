@@ -27,6 +27,8 @@ var (
 	enterpriseName          string
 	enterpriseWebhookSecret string
 	enterpriseCreds         string
+	enterprisePoolStrategy  string
+	enterpriseJobScheduling string
 )
 
 // enterpriseCmd represents the enterprise command
@@ -55,9 +57,11 @@ var enterpriseAddCmd = &cobra.Command{
 		}
 
 		newEnterpriseReq := params.CreateEnterpriseParams{
-			Name:            enterpriseName,
-			WebhookSecret:   enterpriseWebhookSecret,
-			CredentialsName: enterpriseCreds,
+			Name:                  enterpriseName,
+			WebhookSecret:         enterpriseWebhookSecret,
+			CredentialsName:       enterpriseCreds,
+			PoolSelectionStrategy: params.PoolSelectionStrategy(enterprisePoolStrategy),
+			JobSchedulingStrategy: params.JobSchedulingStrategy(enterpriseJobScheduling),
 		}
 		enterprise, err := cli.CreateEnterprise(newEnterpriseReq)
 		if err != nil {
@@ -154,8 +158,10 @@ var enterpriseUpdateCmd = &cobra.Command{
 		}
 
 		enterpriseUpdateReq := params.UpdateEntityParams{
-			WebhookSecret:   repoWebhookSecret,
-			CredentialsName: repoCreds,
+			WebhookSecret:         repoWebhookSecret,
+			CredentialsName:       repoCreds,
+			PoolSelectionStrategy: params.PoolSelectionStrategy(enterprisePoolStrategy),
+			JobSchedulingStrategy: params.JobSchedulingStrategy(enterpriseJobScheduling),
 		}
 		enterprise, err := cli.UpdateEnterprise(args[0], enterpriseUpdateReq)
 		if err != nil {
@@ -171,10 +177,14 @@ func init() {
 	enterpriseAddCmd.Flags().StringVar(&enterpriseName, "name", "", "The name of the enterprise")
 	enterpriseAddCmd.Flags().StringVar(&enterpriseWebhookSecret, "webhook-secret", "", "The webhook secret for this enterprise")
 	enterpriseAddCmd.Flags().StringVar(&enterpriseCreds, "credentials", "", "Credentials name. See credentials list.")
+	enterpriseAddCmd.Flags().StringVar(&enterprisePoolStrategy, "pool-selection-strategy", "", "Strategy used to pick a pool for a job when several pools match its labels (round_robin, priority, least_loaded, weighted_random).")
+	enterpriseAddCmd.Flags().StringVar(&enterpriseJobScheduling, "job-scheduling-strategy", "", "Order in which queued jobs are handled (fifo, fair_share). With fair_share, the jobs of repositories using fewer runners go first.")
 	enterpriseAddCmd.MarkFlagRequired("credentials") //nolint
 	enterpriseAddCmd.MarkFlagRequired("name")        //nolint
 	enterpriseUpdateCmd.Flags().StringVar(&enterpriseWebhookSecret, "webhook-secret", "", "The webhook secret for this enterprise")
 	enterpriseUpdateCmd.Flags().StringVar(&enterpriseCreds, "credentials", "", "Credentials name. See credentials list.")
+	enterpriseUpdateCmd.Flags().StringVar(&enterprisePoolStrategy, "pool-selection-strategy", "", "Strategy used to pick a pool for a job when several pools match its labels (round_robin, priority, least_loaded, weighted_random).")
+	enterpriseUpdateCmd.Flags().StringVar(&enterpriseJobScheduling, "job-scheduling-strategy", "", "Order in which queued jobs are handled (fifo, fair_share). With fair_share, the jobs of repositories using fewer runners go first.")
 
 	enterpriseCmd.AddCommand(
 		enterpriseListCmd,
@@ -189,10 +199,10 @@ func init() {
 
 func formatEnterprises(enterprises []params.Enterprise) {
 	t := table.NewWriter()
-	header := table.Row{"ID", "Name", "Credentials name", "Pool mgr running"}
+	header := table.Row{"ID", "Name", "Endpoint", "Credentials name", "Pool mgr running"}
 	t.AppendHeader(header)
 	for _, val := range enterprises {
-		t.AppendRow(table.Row{val.ID, val.Name, val.CredentialsName, val.PoolManagerStatus.IsRunning})
+		t.AppendRow(table.Row{val.ID, val.Name, val.Endpoint, val.CredentialsName, val.PoolManagerStatus.IsRunning})
 		t.AppendSeparator()
 	}
 	fmt.Println(t.Render())
@@ -205,7 +215,15 @@ func formatOneEnterprise(enterprise params.Enterprise) {
 	t.AppendHeader(header)
 	t.AppendRow(table.Row{"ID", enterprise.ID})
 	t.AppendRow(table.Row{"Name", enterprise.Name})
+	t.AppendRow(table.Row{"Endpoint", enterprise.Endpoint})
 	t.AppendRow(table.Row{"Credentials", enterprise.CredentialsName})
+	t.AppendRow(table.Row{"Pool selection strategy", formatPoolSelectionStrategy(enterprise.PoolSelectionStrategy)})
+	t.AppendRow(table.Row{"Job scheduling strategy", formatJobSchedulingStrategy(enterprise.JobSchedulingStrategy)})
+	if enterprise.WebhookURL != "" {
+		t.AppendRow(table.Row{"Webhook URL", enterprise.WebhookURL})
+	}
+	t.AppendRow(table.Row{"Webhook last seen", formatWebhookTimestamp(enterprise.WebhookLastSeenAt)})
+	t.AppendRow(table.Row{"Webhook verified at", formatWebhookTimestamp(enterprise.WebhookVerifiedAt)})
 	t.AppendRow(table.Row{"Pool manager running", enterprise.PoolManagerStatus.IsRunning})
 	if !enterprise.PoolManagerStatus.IsRunning {
 		t.AppendRow(table.Row{"Failure reason", enterprise.PoolManagerStatus.FailureReason})
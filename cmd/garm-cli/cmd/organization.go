@@ -24,9 +24,16 @@ import (
 )
 
 var (
-	orgName          string
-	orgWebhookSecret string
-	orgCreds         string
+	orgName            string
+	orgWebhookSecret   string
+	orgCreds           string
+	orgManageWebhook   bool
+	orgPollingEnabled  bool
+	orgPollingInterval uint
+	orgPoolStrategy    string
+	orgJobScheduling   string
+
+	orgWebhookInsecure bool
 )
 
 // organizationCmd represents the organization command
@@ -55,9 +62,14 @@ var orgAddCmd = &cobra.Command{
 		}
 
 		newOrgReq := params.CreateOrgParams{
-			Name:            orgName,
-			WebhookSecret:   orgWebhookSecret,
-			CredentialsName: orgCreds,
+			Name:                  orgName,
+			WebhookSecret:         orgWebhookSecret,
+			CredentialsName:       orgCreds,
+			ManageWebhook:         orgManageWebhook,
+			PollingEnabled:        orgPollingEnabled,
+			PollingInterval:       orgPollingInterval,
+			PoolSelectionStrategy: params.PoolSelectionStrategy(orgPoolStrategy),
+			JobSchedulingStrategy: params.JobSchedulingStrategy(orgJobScheduling),
 		}
 		org, err := cli.CreateOrganization(newOrgReq)
 		if err != nil {
@@ -71,7 +83,7 @@ var orgAddCmd = &cobra.Command{
 var orgUpdateCmd = &cobra.Command{
 	Use:          "update",
 	Short:        "Update organization",
-	Long:         `Update organization credentials or webhook secret.`,
+	Long:         `Update organization credentials or webhook secret, and toggle polling for workflow jobs.`,
 	SilenceUsage: true,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		if needsInit {
@@ -87,8 +99,16 @@ var orgUpdateCmd = &cobra.Command{
 		}
 
 		orgUpdateReq := params.UpdateEntityParams{
-			WebhookSecret:   repoWebhookSecret,
-			CredentialsName: orgCreds,
+			WebhookSecret:         orgWebhookSecret,
+			CredentialsName:       orgCreds,
+			PoolSelectionStrategy: params.PoolSelectionStrategy(orgPoolStrategy),
+			JobSchedulingStrategy: params.JobSchedulingStrategy(orgJobScheduling),
+		}
+		if cmd.Flags().Changed("polling-enabled") {
+			orgUpdateReq.PollingEnabled = &orgPollingEnabled
+		}
+		if cmd.Flags().Changed("polling-interval") {
+			orgUpdateReq.PollingInterval = &orgPollingInterval
 		}
 		org, err := cli.UpdateOrganization(args[0], orgUpdateReq)
 		if err != nil {
@@ -166,15 +186,115 @@ var orgDeleteCmd = &cobra.Command{
 	},
 }
 
+var orgWebhookCmd = &cobra.Command{
+	Use:          "webhook",
+	Short:        "Manage an organization webhooks",
+	Long:         `Install, show or uninstall the garm webhook of an organization.`,
+	SilenceUsage: true,
+	Run:          nil,
+}
+
+var orgWebhookInstallCmd = &cobra.Command{
+	Use:          "install",
+	Short:        "Install webhook",
+	Long:         `Install the garm webhook on an organization. If a garm webhook already exists, it will be updated.`,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if needsInit {
+			return errNeedsInitError
+		}
+		if len(args) == 0 {
+			return fmt.Errorf("requires an organization ID")
+		}
+		if len(args) > 1 {
+			return fmt.Errorf("too many arguments")
+		}
+
+		installParams := params.InstallWebhookParams{
+			InsecureSSL: orgWebhookInsecure,
+		}
+		info, err := cli.InstallOrgWebhook(args[0], installParams)
+		if err != nil {
+			return err
+		}
+		formatOneHookInfo(info)
+		return nil
+	},
+}
+
+var orgWebhookShowCmd = &cobra.Command{
+	Use:          "show",
+	Short:        "Show webhook info",
+	Long:         `Show information about the garm webhook installed on an organization.`,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if needsInit {
+			return errNeedsInitError
+		}
+		if len(args) == 0 {
+			return fmt.Errorf("requires an organization ID")
+		}
+		if len(args) > 1 {
+			return fmt.Errorf("too many arguments")
+		}
+
+		info, err := cli.GetOrgWebhookInfo(args[0])
+		if err != nil {
+			return err
+		}
+		formatOneHookInfo(info)
+		return nil
+	},
+}
+
+var orgWebhookUninstallCmd = &cobra.Command{
+	Use:          "uninstall",
+	Short:        "Uninstall webhook",
+	Long:         `Uninstall the garm webhook from an organization.`,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if needsInit {
+			return errNeedsInitError
+		}
+		if len(args) == 0 {
+			return fmt.Errorf("requires an organization ID")
+		}
+		if len(args) > 1 {
+			return fmt.Errorf("too many arguments")
+		}
+
+		if err := cli.UninstallOrgWebhook(args[0]); err != nil {
+			return err
+		}
+		return nil
+	},
+}
+
 func init() {
 
 	orgAddCmd.Flags().StringVar(&orgName, "name", "", "The name of the organization")
 	orgAddCmd.Flags().StringVar(&orgWebhookSecret, "webhook-secret", "", "The webhook secret for this organization")
 	orgAddCmd.Flags().StringVar(&orgCreds, "credentials", "", "Credentials name. See credentials list.")
+	orgAddCmd.Flags().BoolVar(&orgManageWebhook, "manage-webhook", false, "Let garm install and manage the webhook of this organization. A webhook secret is generated if none is set.")
+	orgAddCmd.Flags().BoolVar(&orgPollingEnabled, "polling-enabled", false, "Poll the GitHub API for workflow jobs of this organization, instead of relying on webhooks.")
+	orgAddCmd.Flags().UintVar(&orgPollingInterval, "polling-interval", 0, "Interval in seconds at which the GitHub API is polled for workflow jobs. Defaults to 60 seconds.")
+	orgAddCmd.Flags().StringVar(&orgPoolStrategy, "pool-selection-strategy", "", "Strategy used to pick a pool for a job when several pools match its labels (round_robin, priority, least_loaded, weighted_random).")
+	orgAddCmd.Flags().StringVar(&orgJobScheduling, "job-scheduling-strategy", "", "Order in which queued jobs are handled (fifo, fair_share). With fair_share, the jobs of repositories using fewer runners go first.")
 	orgAddCmd.MarkFlagRequired("credentials") //nolint
 	orgAddCmd.MarkFlagRequired("name")        //nolint
 	orgUpdateCmd.Flags().StringVar(&orgWebhookSecret, "webhook-secret", "", "The webhook secret for this organization")
 	orgUpdateCmd.Flags().StringVar(&orgCreds, "credentials", "", "Credentials name. See credentials list.")
+	orgUpdateCmd.Flags().BoolVar(&orgPollingEnabled, "polling-enabled", false, "Poll the GitHub API for workflow jobs of this organization, instead of relying on webhooks.")
+	orgUpdateCmd.Flags().UintVar(&orgPollingInterval, "polling-interval", 0, "Interval in seconds at which the GitHub API is polled for workflow jobs. Defaults to 60 seconds.")
+	orgUpdateCmd.Flags().StringVar(&orgPoolStrategy, "pool-selection-strategy", "", "Strategy used to pick a pool for a job when several pools match its labels (round_robin, priority, least_loaded, weighted_random).")
+	orgUpdateCmd.Flags().StringVar(&orgJobScheduling, "job-scheduling-strategy", "", "Order in which queued jobs are handled (fifo, fair_share). With fair_share, the jobs of repositories using fewer runners go first.")
+
+	orgWebhookInstallCmd.Flags().BoolVar(&orgWebhookInsecure, "insecure", false, "Skip TLS verification when GitHub delivers events to the garm webhook URL.")
+	orgWebhookCmd.AddCommand(
+		orgWebhookInstallCmd,
+		orgWebhookShowCmd,
+		orgWebhookUninstallCmd,
+	)
 
 	organizationCmd.AddCommand(
 		orgListCmd,
@@ -182,6 +302,7 @@ func init() {
 		orgShowCmd,
 		orgDeleteCmd,
 		orgUpdateCmd,
+		orgWebhookCmd,
 	)
 
 	rootCmd.AddCommand(organizationCmd)
@@ -189,10 +310,10 @@ func init() {
 
 func formatOrganizations(orgs []params.Organization) {
 	t := table.NewWriter()
-	header := table.Row{"ID", "Name", "Credentials name", "Pool mgr running"}
+	header := table.Row{"ID", "Name", "Endpoint", "Credentials name", "Pool mgr running"}
 	t.AppendHeader(header)
 	for _, val := range orgs {
-		t.AppendRow(table.Row{val.ID, val.Name, val.CredentialsName, val.PoolManagerStatus.IsRunning})
+		t.AppendRow(table.Row{val.ID, val.Name, val.Endpoint, val.CredentialsName, val.PoolManagerStatus.IsRunning})
 		t.AppendSeparator()
 	}
 	fmt.Println(t.Render())
@@ -205,7 +326,19 @@ func formatOneOrganization(org params.Organization) {
 	t.AppendHeader(header)
 	t.AppendRow(table.Row{"ID", org.ID})
 	t.AppendRow(table.Row{"Name", org.Name})
+	t.AppendRow(table.Row{"Endpoint", org.Endpoint})
 	t.AppendRow(table.Row{"Credentials", org.CredentialsName})
+	t.AppendRow(table.Row{"Polling enabled", org.PollingEnabled})
+	if org.PollingEnabled {
+		t.AppendRow(table.Row{"Polling interval", org.PollingInterval})
+	}
+	t.AppendRow(table.Row{"Pool selection strategy", formatPoolSelectionStrategy(org.PoolSelectionStrategy)})
+	t.AppendRow(table.Row{"Job scheduling strategy", formatJobSchedulingStrategy(org.JobSchedulingStrategy)})
+	if org.WebhookURL != "" {
+		t.AppendRow(table.Row{"Webhook URL", org.WebhookURL})
+	}
+	t.AppendRow(table.Row{"Webhook last seen", formatWebhookTimestamp(org.WebhookLastSeenAt)})
+	t.AppendRow(table.Row{"Webhook verified at", formatWebhookTimestamp(org.WebhookVerifiedAt)})
 	t.AppendRow(table.Row{"Pool manager running", org.PoolManagerStatus.IsRunning})
 	if !org.PoolManagerStatus.IsRunning {
 		t.AppendRow(table.Row{"Failure reason", org.PoolManagerStatus.FailureReason})
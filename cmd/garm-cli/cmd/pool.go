@@ -19,6 +19,7 @@ import (
 	"fmt"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/cloudbase/garm/params"
 
@@ -28,24 +29,39 @@ import (
 )
 
 var (
-	poolProvider               string
-	poolMaxRunners             uint
-	poolMinIdleRunners         uint
-	poolRunnerPrefix           string
-	poolImage                  string
-	poolFlavor                 string
-	poolOSType                 string
-	poolOSArch                 string
-	poolTags                   string
-	poolEnabled                bool
-	poolRunnerBootstrapTimeout uint
-	poolRepository             string
-	poolOrganization           string
-	poolEnterprise             string
-	poolExtraSpecsFile         string
-	poolExtraSpecs             string
-	poolAll                    bool
-	poolGitHubRunnerGroup      string
+	poolProvider                string
+	poolMaxRunners              uint
+	poolMinIdleRunners          uint
+	poolWarmRunners             uint
+	poolRunnerPrefix            string
+	poolImage                   string
+	poolFlavor                  string
+	poolOSType                  string
+	poolOSArch                  string
+	poolTags                    string
+	poolEnabled                 bool
+	poolAutoMinIdleRunners      bool
+	poolPriority                uint
+	poolWeight                  uint
+	poolMaxSurge                uint
+	poolReplacementCanary       bool
+	poolIdleTimeout             uint
+	poolMaxLifetime             uint
+	poolCircuitBreakerThreshold uint
+	poolCircuitBreakerWindow    uint
+	poolMaxRunnersPerRepository uint
+	poolRunnerBootstrapTimeout  uint
+	poolRepository              string
+	poolOrganization            string
+	poolEnterprise              string
+	poolExtraSpecsFile          string
+	poolExtraSpecs              string
+	poolScalingSchedulesFile    string
+	poolScalingSchedules        string
+	poolFallbackProvidersFile   string
+	poolFallbackProviders       string
+	poolAll                     bool
+	poolGitHubRunnerGroup       string
 )
 
 // runnerCmd represents the runner command
@@ -171,6 +187,62 @@ var poolDeleteCmd = &cobra.Command{
 	},
 }
 
+var poolResetCircuitBreakerCmd = &cobra.Command{
+	Use:   "reset-circuit-breaker",
+	Short: "Reset the circuit breaker of a pool",
+	Long: `Reset the circuit breaker of a pool, after fixing the cause of its provisioning failures.
+The pool starts creating runners right away.`,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if needsInit {
+			return errNeedsInitError
+		}
+
+		if len(args) == 0 {
+			return fmt.Errorf("requires a pool ID")
+		}
+
+		if len(args) > 1 {
+			return fmt.Errorf("too many arguments")
+		}
+
+		pool, err := cli.ResetPoolCircuitBreaker(args[0])
+		if err != nil {
+			return err
+		}
+		formatOnePool(pool)
+		return nil
+	},
+}
+
+var poolQuotaCmd = &cobra.Command{
+	Use:   "quota",
+	Short: "Show the runners of a pool used by each repository",
+	Long: `Show the number of runners of a pool used by each repository, along with the
+max runners per repository of the pool.`,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if needsInit {
+			return errNeedsInitError
+		}
+
+		if len(args) == 0 {
+			return fmt.Errorf("requires a pool ID")
+		}
+
+		if len(args) > 1 {
+			return fmt.Errorf("too many arguments")
+		}
+
+		quota, err := cli.GetPoolQuota(args[0])
+		if err != nil {
+			return err
+		}
+		formatPoolQuota(quota)
+		return nil
+	},
+}
+
 var poolAddCmd = &cobra.Command{
 	Use:          "add",
 	Aliases:      []string{"create"},
@@ -187,17 +259,28 @@ var poolAddCmd = &cobra.Command{
 			RunnerPrefix: params.RunnerPrefix{
 				Prefix: poolRunnerPrefix,
 			},
-			ProviderName:           poolProvider,
-			MaxRunners:             poolMaxRunners,
-			MinIdleRunners:         poolMinIdleRunners,
-			Image:                  poolImage,
-			Flavor:                 poolFlavor,
-			OSType:                 params.OSType(poolOSType),
-			OSArch:                 params.OSArch(poolOSArch),
-			Tags:                   tags,
-			Enabled:                poolEnabled,
-			RunnerBootstrapTimeout: poolRunnerBootstrapTimeout,
-			GitHubRunnerGroup:      poolGitHubRunnerGroup,
+			ProviderName:            poolProvider,
+			MaxRunners:              poolMaxRunners,
+			MinIdleRunners:          poolMinIdleRunners,
+			WarmRunners:             poolWarmRunners,
+			Image:                   poolImage,
+			Flavor:                  poolFlavor,
+			OSType:                  params.OSType(poolOSType),
+			OSArch:                  params.OSArch(poolOSArch),
+			Tags:                    tags,
+			Enabled:                 poolEnabled,
+			AutoMinIdleRunners:      poolAutoMinIdleRunners,
+			Priority:                poolPriority,
+			Weight:                  poolWeight,
+			MaxSurge:                poolMaxSurge,
+			ReplacementCanary:       poolReplacementCanary,
+			IdleTimeout:             poolIdleTimeout,
+			MaxLifetime:             poolMaxLifetime,
+			CircuitBreakerThreshold: poolCircuitBreakerThreshold,
+			CircuitBreakerWindow:    poolCircuitBreakerWindow,
+			MaxRunnersPerRepository: poolMaxRunnersPerRepository,
+			RunnerBootstrapTimeout:  poolRunnerBootstrapTimeout,
+			GitHubRunnerGroup:       poolGitHubRunnerGroup,
 		}
 
 		if cmd.Flags().Changed("extra-specs") {
@@ -216,6 +299,38 @@ var poolAddCmd = &cobra.Command{
 			newPoolParams.ExtraSpecs = data
 		}
 
+		if cmd.Flags().Changed("scaling-schedules") {
+			schedules, err := asScalingSchedules([]byte(poolScalingSchedules))
+			if err != nil {
+				return err
+			}
+			newPoolParams.ScalingSchedules = schedules
+		}
+
+		if poolScalingSchedulesFile != "" {
+			schedules, err := scalingSchedulesFromFile(poolScalingSchedulesFile)
+			if err != nil {
+				return err
+			}
+			newPoolParams.ScalingSchedules = schedules
+		}
+
+		if cmd.Flags().Changed("fallback-providers") {
+			fallbacks, err := asFallbackProviders([]byte(poolFallbackProviders))
+			if err != nil {
+				return err
+			}
+			newPoolParams.FallbackProviders = fallbacks
+		}
+
+		if poolFallbackProvidersFile != "" {
+			fallbacks, err := fallbackProvidersFromFile(poolFallbackProvidersFile)
+			if err != nil {
+				return err
+			}
+			newPoolParams.FallbackProviders = fallbacks
+		}
+
 		if err := newPoolParams.Validate(); err != nil {
 			return err
 		}
@@ -295,6 +410,50 @@ explicitly remove them using the runner delete command.
 			poolUpdateParams.MinIdleRunners = &poolMinIdleRunners
 		}
 
+		if cmd.Flags().Changed("warm-runners") {
+			poolUpdateParams.WarmRunners = &poolWarmRunners
+		}
+
+		if cmd.Flags().Changed("auto-min-idle-runners") {
+			poolUpdateParams.AutoMinIdleRunners = &poolAutoMinIdleRunners
+		}
+
+		if cmd.Flags().Changed("priority") {
+			poolUpdateParams.Priority = &poolPriority
+		}
+
+		if cmd.Flags().Changed("weight") {
+			poolUpdateParams.Weight = &poolWeight
+		}
+
+		if cmd.Flags().Changed("max-surge") {
+			poolUpdateParams.MaxSurge = &poolMaxSurge
+		}
+
+		if cmd.Flags().Changed("replacement-canary") {
+			poolUpdateParams.ReplacementCanary = &poolReplacementCanary
+		}
+
+		if cmd.Flags().Changed("idle-timeout") {
+			poolUpdateParams.IdleTimeout = &poolIdleTimeout
+		}
+
+		if cmd.Flags().Changed("max-lifetime") {
+			poolUpdateParams.MaxLifetime = &poolMaxLifetime
+		}
+
+		if cmd.Flags().Changed("circuit-breaker-threshold") {
+			poolUpdateParams.CircuitBreakerThreshold = &poolCircuitBreakerThreshold
+		}
+
+		if cmd.Flags().Changed("circuit-breaker-window") {
+			poolUpdateParams.CircuitBreakerWindow = &poolCircuitBreakerWindow
+		}
+
+		if cmd.Flags().Changed("max-runners-per-repository") {
+			poolUpdateParams.MaxRunnersPerRepository = &poolMaxRunnersPerRepository
+		}
+
 		if cmd.Flags().Changed("runner-prefix") {
 			poolUpdateParams.RunnerPrefix = params.RunnerPrefix{
 				Prefix: poolRunnerPrefix,
@@ -329,6 +488,38 @@ explicitly remove them using the runner delete command.
 			poolUpdateParams.ExtraSpecs = data
 		}
 
+		if cmd.Flags().Changed("scaling-schedules") {
+			schedules, err := asScalingSchedules([]byte(poolScalingSchedules))
+			if err != nil {
+				return err
+			}
+			poolUpdateParams.ScalingSchedules = schedules
+		}
+
+		if poolScalingSchedulesFile != "" {
+			schedules, err := scalingSchedulesFromFile(poolScalingSchedulesFile)
+			if err != nil {
+				return err
+			}
+			poolUpdateParams.ScalingSchedules = schedules
+		}
+
+		if cmd.Flags().Changed("fallback-providers") {
+			fallbacks, err := asFallbackProviders([]byte(poolFallbackProviders))
+			if err != nil {
+				return err
+			}
+			poolUpdateParams.FallbackProviders = fallbacks
+		}
+
+		if poolFallbackProvidersFile != "" {
+			fallbacks, err := fallbackProvidersFromFile(poolFallbackProvidersFile)
+			if err != nil {
+				return err
+			}
+			poolUpdateParams.FallbackProviders = fallbacks
+		}
+
 		pool, err := cli.UpdatePoolByID(args[0], poolUpdateParams)
 		if err != nil {
 			return err
@@ -354,12 +545,29 @@ func init() {
 	poolUpdateCmd.Flags().StringVar(&poolRunnerPrefix, "runner-prefix", "", "The name prefix to use for runners in this pool.")
 	poolUpdateCmd.Flags().UintVar(&poolMaxRunners, "max-runners", 5, "The maximum number of runner this pool will create.")
 	poolUpdateCmd.Flags().UintVar(&poolMinIdleRunners, "min-idle-runners", 1, "Attempt to maintain a minimum of idle self-hosted runners of this type.")
+	poolUpdateCmd.Flags().BoolVar(&poolAutoMinIdleRunners, "auto-min-idle-runners", false, "Set the number of idle runners from the demand seen during the same hour last week. The min-idle-runners value is used as a floor.")
+	poolUpdateCmd.Flags().UintVar(&poolWarmRunners, "warm-runners", 0, "Number of pre-provisioned, stopped runners to keep in this pool. They are started when a job is queued.")
+	poolUpdateCmd.Flags().UintVar(&poolPriority, "priority", 0, "Priority of this pool. When the priority pool selection strategy is used, pools with a higher priority are tried first.")
+	poolUpdateCmd.Flags().UintVar(&poolWeight, "weight", 0, "Weight of this pool. When the weighted_random pool selection strategy is used, pools with a higher weight are more likely to be tried first.")
+	poolUpdateCmd.Flags().UintVar(&poolMaxSurge, "max-surge", 0, "Number of replacement runners that may be set up at the same time, when the image, flavor, extra specs or tags of this pool change. A value of 0 means 1.")
+	poolUpdateCmd.Flags().BoolVar(&poolReplacementCanary, "replacement-canary", false, "When the image, flavor, extra specs or tags of this pool change, create a single runner and wait for it to run a job successfully, before replacing the rest of the runners.")
 	poolUpdateCmd.Flags().StringVar(&poolGitHubRunnerGroup, "runner-group", "", "The GitHub runner group in which all runners of this pool will be added.")
 	poolUpdateCmd.Flags().BoolVar(&poolEnabled, "enabled", false, "Enable this pool.")
 	poolUpdateCmd.Flags().UintVar(&poolRunnerBootstrapTimeout, "runner-bootstrap-timeout", 20, "Duration in minutes after which a runner is considered failed if it does not join Github.")
+	poolUpdateCmd.Flags().UintVar(&poolIdleTimeout, "idle-timeout", 0, "Duration in minutes a runner needs to be idle before it is considered for scale down. A value of 0 means 2 minutes.")
+	poolUpdateCmd.Flags().UintVar(&poolMaxLifetime, "max-lifetime", 0, "Duration in minutes after which an idle runner is recycled, even if it never ran a job. A value of 0 disables recycling.")
+	poolUpdateCmd.Flags().UintVar(&poolCircuitBreakerThreshold, "circuit-breaker-threshold", 0, "Number of consecutive provisioning failures after which the pool stops creating runners for a while. A value of 0 means 5.")
+	poolUpdateCmd.Flags().UintVar(&poolCircuitBreakerWindow, "circuit-breaker-window", 0, "Duration in minutes in which provisioning failures need to happen to be considered consecutive. A value of 0 means 30 minutes.")
+	poolUpdateCmd.Flags().UintVar(&poolMaxRunnersPerRepository, "max-runners-per-repository", 0, "Maximum number of runners of this pool that may be used by jobs of a single repository. Only used by organization and enterprise pools. A value of 0 means no limit.")
 	poolUpdateCmd.Flags().StringVar(&poolExtraSpecsFile, "extra-specs-file", "", "A file containing a valid json which will be passed to the IaaS provider managing the pool.")
 	poolUpdateCmd.Flags().StringVar(&poolExtraSpecs, "extra-specs", "", "A valid json which will be passed to the IaaS provider managing the pool.")
+	poolUpdateCmd.Flags().StringVar(&poolScalingSchedulesFile, "scaling-schedules-file", "", "A file containing a json list of scaling schedules for this pool. Replaces existing schedules.")
+	poolUpdateCmd.Flags().StringVar(&poolScalingSchedules, "scaling-schedules", "", "A json list of scaling schedules for this pool. Replaces existing schedules. Use '[]' to remove all schedules.")
 	poolUpdateCmd.MarkFlagsMutuallyExclusive("extra-specs-file", "extra-specs")
+	poolUpdateCmd.Flags().StringVar(&poolFallbackProvidersFile, "fallback-providers-file", "", "A file containing a json list of fallback providers for this pool. Replaces existing fallback providers.")
+	poolUpdateCmd.Flags().StringVar(&poolFallbackProviders, "fallback-providers", "", "A json list of fallback providers for this pool. Replaces existing fallback providers. Use '[]' to remove all fallback providers.")
+	poolUpdateCmd.MarkFlagsMutuallyExclusive("scaling-schedules-file", "scaling-schedules")
+	poolUpdateCmd.MarkFlagsMutuallyExclusive("fallback-providers-file", "fallback-providers")
 
 	poolAddCmd.Flags().StringVar(&poolProvider, "provider-name", "", "The name of the provider where runners will be created.")
 	poolAddCmd.Flags().StringVar(&poolImage, "image", "", "The provider-specific image name to use for runners in this pool.")
@@ -370,10 +578,25 @@ func init() {
 	poolAddCmd.Flags().StringVar(&poolOSArch, "os-arch", "amd64", "Operating system architecture (amd64, arm, etc).")
 	poolAddCmd.Flags().StringVar(&poolExtraSpecsFile, "extra-specs-file", "", "A file containing a valid json which will be passed to the IaaS provider managing the pool.")
 	poolAddCmd.Flags().StringVar(&poolExtraSpecs, "extra-specs", "", "A valid json which will be passed to the IaaS provider managing the pool.")
+	poolAddCmd.Flags().StringVar(&poolScalingSchedulesFile, "scaling-schedules-file", "", "A file containing a json list of scaling schedules for this pool.")
+	poolAddCmd.Flags().StringVar(&poolScalingSchedules, "scaling-schedules", "", "A json list of scaling schedules for this pool.")
+	poolAddCmd.Flags().StringVar(&poolFallbackProvidersFile, "fallback-providers-file", "", "A file containing a json list of fallback providers for this pool.")
+	poolAddCmd.Flags().StringVar(&poolFallbackProviders, "fallback-providers", "", "A json list of fallback providers for this pool. They are tried in order when the provider of the pool fails to create a runner, or is at capacity.")
 	poolAddCmd.Flags().StringVar(&poolGitHubRunnerGroup, "runner-group", "", "The GitHub runner group in which all runners of this pool will be added.")
 	poolAddCmd.Flags().UintVar(&poolMaxRunners, "max-runners", 5, "The maximum number of runner this pool will create.")
 	poolAddCmd.Flags().UintVar(&poolRunnerBootstrapTimeout, "runner-bootstrap-timeout", 20, "Duration in minutes after which a runner is considered failed if it does not join Github.")
+	poolAddCmd.Flags().UintVar(&poolIdleTimeout, "idle-timeout", 0, "Duration in minutes a runner needs to be idle before it is considered for scale down. A value of 0 means 2 minutes.")
+	poolAddCmd.Flags().UintVar(&poolMaxLifetime, "max-lifetime", 0, "Duration in minutes after which an idle runner is recycled, even if it never ran a job. A value of 0 disables recycling.")
+	poolAddCmd.Flags().UintVar(&poolCircuitBreakerThreshold, "circuit-breaker-threshold", 0, "Number of consecutive provisioning failures after which the pool stops creating runners for a while. A value of 0 means 5.")
+	poolAddCmd.Flags().UintVar(&poolCircuitBreakerWindow, "circuit-breaker-window", 0, "Duration in minutes in which provisioning failures need to happen to be considered consecutive. A value of 0 means 30 minutes.")
+	poolAddCmd.Flags().UintVar(&poolMaxRunnersPerRepository, "max-runners-per-repository", 0, "Maximum number of runners of this pool that may be used by jobs of a single repository. Only used by organization and enterprise pools. A value of 0 means no limit.")
 	poolAddCmd.Flags().UintVar(&poolMinIdleRunners, "min-idle-runners", 1, "Attempt to maintain a minimum of idle self-hosted runners of this type.")
+	poolAddCmd.Flags().BoolVar(&poolAutoMinIdleRunners, "auto-min-idle-runners", false, "Set the number of idle runners from the demand seen during the same hour last week. The min-idle-runners value is used as a floor.")
+	poolAddCmd.Flags().UintVar(&poolWarmRunners, "warm-runners", 0, "Number of pre-provisioned, stopped runners to keep in this pool. They are started when a job is queued.")
+	poolAddCmd.Flags().UintVar(&poolPriority, "priority", 0, "Priority of this pool. When the priority pool selection strategy is used, pools with a higher priority are tried first.")
+	poolAddCmd.Flags().UintVar(&poolWeight, "weight", 0, "Weight of this pool. When the weighted_random pool selection strategy is used, pools with a higher weight are more likely to be tried first.")
+	poolAddCmd.Flags().UintVar(&poolMaxSurge, "max-surge", 0, "Number of replacement runners that may be set up at the same time, when the image, flavor, extra specs or tags of this pool change. A value of 0 means 1.")
+	poolAddCmd.Flags().BoolVar(&poolReplacementCanary, "replacement-canary", false, "When the image, flavor, extra specs or tags of this pool change, create a single runner and wait for it to run a job successfully, before replacing the rest of the runners.")
 	poolAddCmd.Flags().BoolVar(&poolEnabled, "enabled", false, "Enable this pool.")
 	poolAddCmd.MarkFlagRequired("provider-name") //nolint
 	poolAddCmd.MarkFlagRequired("image")         //nolint
@@ -385,6 +608,8 @@ func init() {
 	poolAddCmd.Flags().StringVarP(&poolEnterprise, "enterprise", "e", "", "Add the new pool withing this enterprise.")
 	poolAddCmd.MarkFlagsMutuallyExclusive("repo", "org", "enterprise")
 	poolAddCmd.MarkFlagsMutuallyExclusive("extra-specs-file", "extra-specs")
+	poolAddCmd.MarkFlagsMutuallyExclusive("scaling-schedules-file", "scaling-schedules")
+	poolAddCmd.MarkFlagsMutuallyExclusive("fallback-providers-file", "fallback-providers")
 
 	poolCmd.AddCommand(
 		poolListCmd,
@@ -392,6 +617,8 @@ func init() {
 		poolDeleteCmd,
 		poolUpdateCmd,
 		poolAddCmd,
+		poolResetCircuitBreakerCmd,
+		poolQuotaCmd,
 	)
 
 	rootCmd.AddCommand(poolCmd)
@@ -422,6 +649,38 @@ func asRawMessage(data []byte) (json.RawMessage, error) {
 	return asRawJson, nil
 }
 
+func scalingSchedulesFromFile(schedulesFile string) ([]params.ScalingSchedule, error) {
+	data, err := os.ReadFile(schedulesFile)
+	if err != nil {
+		return nil, errors.Wrap(err, "opening scaling schedules file")
+	}
+	return asScalingSchedules(data)
+}
+
+func asScalingSchedules(data []byte) ([]params.ScalingSchedule, error) {
+	schedules := []params.ScalingSchedule{}
+	if err := json.Unmarshal(data, &schedules); err != nil {
+		return nil, errors.Wrap(err, "decoding scaling schedules")
+	}
+	return schedules, nil
+}
+
+func fallbackProvidersFromFile(fallbacksFile string) ([]params.PoolProvider, error) {
+	data, err := os.ReadFile(fallbacksFile)
+	if err != nil {
+		return nil, errors.Wrap(err, "opening fallback providers file")
+	}
+	return asFallbackProviders(data)
+}
+
+func asFallbackProviders(data []byte) ([]params.PoolProvider, error) {
+	fallbacks := []params.PoolProvider{}
+	if err := json.Unmarshal(data, &fallbacks); err != nil {
+		return nil, errors.Wrap(err, "decoding fallback providers")
+	}
+	return fallbacks, nil
+}
+
 func formatPools(pools []params.Pool) {
 	t := table.NewWriter()
 	header := table.Row{"ID", "Image", "Flavor", "Tags", "Belongs to", "Level", "Enabled", "Runner Prefix"}
@@ -479,24 +738,58 @@ func formatOnePool(pool params.Pool) {
 	t.AppendHeader(header)
 	t.AppendRow(table.Row{"ID", pool.ID})
 	t.AppendRow(table.Row{"Provider Name", pool.ProviderName})
+	for _, fallback := range pool.FallbackProviders {
+		t.AppendRow(table.Row{"Fallback Providers", formatFallbackProvider(fallback)}, rowConfigAutoMerge)
+	}
 	t.AppendRow(table.Row{"Image", pool.Image})
 	t.AppendRow(table.Row{"Flavor", pool.Flavor})
 	t.AppendRow(table.Row{"OS Type", pool.OSType})
 	t.AppendRow(table.Row{"OS Architecture", pool.OSArch})
 	t.AppendRow(table.Row{"Max Runners", pool.MaxRunners})
 	t.AppendRow(table.Row{"Min Idle Runners", pool.MinIdleRunners})
+	t.AppendRow(table.Row{"Auto Min Idle Runners", pool.AutoMinIdleRunners})
+	t.AppendRow(table.Row{"Warm Runners", pool.WarmRunners})
+	t.AppendRow(table.Row{"Priority", pool.Priority})
+	t.AppendRow(table.Row{"Weight", pool.Weight})
+	t.AppendRow(table.Row{"Config Revision", pool.ConfigRevision})
+	t.AppendRow(table.Row{"Max Surge", pool.GetMaxSurge()})
+	t.AppendRow(table.Row{"Replacement Canary", pool.ReplacementCanary})
 	t.AppendRow(table.Row{"Runner Bootstrap Timeout", pool.RunnerBootstrapTimeout})
+	t.AppendRow(table.Row{"Idle Timeout", pool.GetIdleTimeout()})
+	t.AppendRow(table.Row{"Max Lifetime", formatMaxLifetime(pool.MaxLifetime)})
+	t.AppendRow(table.Row{"Circuit Breaker", formatCircuitBreaker(pool)})
+	if pool.MaxRunnersPerRepository > 0 {
+		t.AppendRow(table.Row{"Max Runners Per Repository", pool.MaxRunnersPerRepository})
+	}
+	if pool.CircuitBreaker.FailureReason != "" {
+		t.AppendRow(table.Row{"Last Provisioning Failure", pool.CircuitBreaker.FailureReason})
+	}
 	t.AppendRow(table.Row{"Tags", strings.Join(tags, ", ")})
 	t.AppendRow(table.Row{"Belongs to", belongsTo})
 	t.AppendRow(table.Row{"Level", level})
 	t.AppendRow(table.Row{"Enabled", pool.Enabled})
+	t.AppendRow(table.Row{"Draining", pool.Draining})
+	if pool.DrainStartedAt != nil {
+		t.AppendRow(table.Row{"Drain Started At", pool.DrainStartedAt})
+	}
 	t.AppendRow(table.Row{"Runner Prefix", pool.GetRunnerPrefix()})
 	t.AppendRow(table.Row{"Extra specs", string(pool.ExtraSpecs)})
 	t.AppendRow(table.Row{"GitHub Runner Group", string(pool.GitHubRunnerGroup)})
 
+	if len(pool.ScalingSchedules) > 0 {
+		for _, schedule := range pool.ScalingSchedules {
+			timezone := schedule.Timezone
+			if timezone == "" {
+				timezone = "UTC"
+			}
+			t.AppendRow(table.Row{"Scaling Schedules", fmt.Sprintf("%s: %q %s (min idle: %d, max: %d)", schedule.Name, schedule.Schedule, timezone, schedule.MinIdleRunners, schedule.MaxRunners)}, rowConfigAutoMerge)
+		}
+		t.AppendRow(table.Row{"Active Scaling Schedule", pool.ActiveScalingSchedule})
+	}
+
 	if len(pool.Instances) > 0 {
 		for _, instance := range pool.Instances {
-			t.AppendRow(table.Row{"Instances", fmt.Sprintf("%s (%s)", instance.Name, instance.ID)}, rowConfigAutoMerge)
+			t.AppendRow(table.Row{"Instances", fmt.Sprintf("%s (%s, config revision %d, provider %s)", instance.Name, instance.ID, instance.PoolConfigRevision, pool.InstanceProviderName(instance))}, rowConfigAutoMerge)
 		}
 	}
 
@@ -506,3 +799,61 @@ func formatOnePool(pool params.Pool) {
 	})
 	fmt.Println(t.Render())
 }
+
+func formatFallbackProvider(fallback params.PoolProvider) string {
+	overrides := []string{}
+	if fallback.Image != "" {
+		overrides = append(overrides, fmt.Sprintf("image: %s", fallback.Image))
+	}
+	if fallback.Flavor != "" {
+		overrides = append(overrides, fmt.Sprintf("flavor: %s", fallback.Flavor))
+	}
+	if len(fallback.ExtraSpecs) > 0 {
+		overrides = append(overrides, fmt.Sprintf("extra specs: %s", string(fallback.ExtraSpecs)))
+	}
+	if len(overrides) == 0 {
+		return fallback.ProviderName
+	}
+	return fmt.Sprintf("%s (%s)", fallback.ProviderName, strings.Join(overrides, ", "))
+}
+
+func formatMaxLifetime(maxLifetime uint) string {
+	if maxLifetime == 0 {
+		return "unlimited"
+	}
+	return (time.Duration(maxLifetime) * time.Minute).String()
+}
+
+func formatCircuitBreaker(pool params.Pool) string {
+	breaker := pool.CircuitBreaker
+	threshold := pool.GetCircuitBreakerThreshold()
+	if breaker.IsOpen(time.Now()) {
+		return fmt.Sprintf("open until %s (%d/%d failures)", breaker.OpenUntil.Format(time.RFC3339), breaker.ConsecutiveFailures, threshold)
+	}
+	return fmt.Sprintf("closed (%d/%d failures)", breaker.ConsecutiveFailures, threshold)
+}
+
+func formatPoolSelectionStrategy(strategy params.PoolSelectionStrategy) string {
+	if strategy == "" {
+		return string(params.PoolSelectionRoundRobin)
+	}
+	return string(strategy)
+}
+
+func formatJobSchedulingStrategy(strategy params.JobSchedulingStrategy) string {
+	if strategy == "" {
+		return string(params.JobSchedulingFIFO)
+	}
+	return string(strategy)
+}
+
+func formatPoolQuota(quota params.PoolQuota) {
+	t := table.NewWriter()
+	header := table.Row{"Repository", "Runners"}
+	t.AppendHeader(header)
+	for _, repo := range quota.Repositories {
+		t.AppendRow(table.Row{repo.Repository, formatUsage(repo.Runners, quota.MaxRunnersPerRepository, "")})
+		t.AppendSeparator()
+	}
+	fmt.Println(t.Render())
+}
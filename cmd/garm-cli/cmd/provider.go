@@ -16,6 +16,7 @@ package cmd
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/cloudbase/garm/params"
 
@@ -30,8 +31,8 @@ var providerCmd = &cobra.Command{
 	Short:        "Interacts with the providers API resource.",
 	Long: `Run operations on the provider resource.
 
-Currently this command only lists all available configured
-providers. Providers are added to the configuration file of
+This command lists all available configured providers, and
+can drain all pools that use a provider. Providers are added to the configuration file of
 the service and are referenced by name when adding repositories
 and organizations. Runners will be created in these environments.`,
 	Run: nil,
@@ -63,11 +64,74 @@ func init() {
 
 func formatProviders(providers []params.Provider) {
 	t := table.NewWriter()
-	header := table.Row{"Name", "Description", "Type"}
+	header := table.Row{"Name", "Description", "Type", "Health", "Draining", "Instances"}
 	t.AppendHeader(header)
 	for _, val := range providers {
-		t.AppendRow(table.Row{val.Name, val.Description, val.ProviderType})
+		instances := formatUsage(val.Usage.Instances, val.Limits.MaxInstances, "")
+		t.AppendRow(table.Row{val.Name, val.Description, val.ProviderType, val.Health.Status, val.Draining, instances})
 		t.AppendSeparator()
 	}
 	fmt.Println(t.Render())
 }
+
+func formatOneProvider(provider params.Provider) {
+	t := table.NewWriter()
+	header := table.Row{"Field", "Value"}
+	t.AppendHeader(header)
+	t.AppendRow(table.Row{"Name", provider.Name})
+	t.AppendRow(table.Row{"Description", provider.Description})
+	t.AppendRow(table.Row{"Type", provider.ProviderType})
+	if provider.InterfaceVersion != "" {
+		t.AppendRow(table.Row{"Interface Version", provider.InterfaceVersion})
+	}
+	t.AppendRow(table.Row{"Capabilities", formatCapabilities(provider.Capabilities)})
+	t.AppendRow(table.Row{"Health", provider.Health.Status})
+	if provider.Health.DegradedSince != nil {
+		t.AppendRow(table.Row{"Degraded Since", provider.Health.DegradedSince})
+	}
+	if provider.Health.Error != "" {
+		t.AppendRow(table.Row{"Health Check Error", provider.Health.Error})
+	}
+	t.AppendRow(table.Row{"Draining", provider.Draining})
+	if provider.DrainStartedAt != nil {
+		t.AppendRow(table.Row{"Drain Started At", provider.DrainStartedAt})
+	}
+	t.AppendRow(table.Row{"Instances", formatUsage(provider.Usage.Instances, provider.Limits.MaxInstances, "")})
+	if provider.Limits.MaxCPUs > 0 {
+		t.AppendRow(table.Row{"CPUs", formatUsage(provider.Usage.CPUs, provider.Limits.MaxCPUs, "")})
+	}
+	if provider.Limits.MaxMemoryMB > 0 {
+		t.AppendRow(table.Row{"Memory", formatUsage(provider.Usage.MemoryMB, provider.Limits.MaxMemoryMB, " MB")})
+	}
+	fmt.Println(t.Render())
+}
+
+// formatCapabilities lists the optional features supported by a provider.
+func formatCapabilities(capabilities params.ProviderCapabilities) string {
+	var ret []string
+	if capabilities.StartStop {
+		ret = append(ret, "start/stop")
+	}
+	if capabilities.ConsoleLogs {
+		ret = append(ret, "console logs")
+	}
+	if capabilities.ImageValidation {
+		ret = append(ret, "image validation")
+	}
+	if capabilities.BulkList {
+		ret = append(ret, "bulk listing")
+	}
+	if len(ret) == 0 {
+		return "none"
+	}
+	return strings.Join(ret, ", ")
+}
+
+// formatUsage formats the usage of a resource against its limit. A limit of 0 means
+// there is no limit.
+func formatUsage(used, limit uint, unit string) string {
+	if limit == 0 {
+		return fmt.Sprintf("%d%s", used, unit)
+	}
+	return fmt.Sprintf("%d/%d%s", used, limit, unit)
+}
@@ -16,6 +16,7 @@ package cmd
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/cloudbase/garm/params"
 
@@ -24,10 +25,16 @@ import (
 )
 
 var (
-	repoOwner         string
-	repoName          string
-	repoWebhookSecret string
-	repoCreds         string
+	repoOwner           string
+	repoName            string
+	repoWebhookSecret   string
+	repoCreds           string
+	repoManageWebhook   bool
+	repoPollingEnabled  bool
+	repoPollingInterval uint
+	repoPoolStrategy    string
+
+	repoWebhookInsecure bool
 )
 
 // repositoryCmd represents the repository command
@@ -56,10 +63,14 @@ var repoAddCmd = &cobra.Command{
 		}
 
 		newRepoReq := params.CreateRepoParams{
-			Owner:           repoOwner,
-			Name:            repoName,
-			WebhookSecret:   repoWebhookSecret,
-			CredentialsName: repoCreds,
+			Owner:                 repoOwner,
+			Name:                  repoName,
+			WebhookSecret:         repoWebhookSecret,
+			CredentialsName:       repoCreds,
+			ManageWebhook:         repoManageWebhook,
+			PollingEnabled:        repoPollingEnabled,
+			PollingInterval:       repoPollingInterval,
+			PoolSelectionStrategy: params.PoolSelectionStrategy(repoPoolStrategy),
 		}
 		repo, err := cli.CreateRepository(newRepoReq)
 		if err != nil {
@@ -93,7 +104,7 @@ var repoListCmd = &cobra.Command{
 var repoUpdateCmd = &cobra.Command{
 	Use:          "update",
 	Short:        "Update repository",
-	Long:         `Update repository credentials or webhook secret.`,
+	Long:         `Update repository credentials or webhook secret, and toggle polling for workflow jobs.`,
 	SilenceUsage: true,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		if needsInit {
@@ -109,8 +120,15 @@ var repoUpdateCmd = &cobra.Command{
 		}
 
 		repoUpdateReq := params.UpdateEntityParams{
-			WebhookSecret:   repoWebhookSecret,
-			CredentialsName: repoCreds,
+			WebhookSecret:         repoWebhookSecret,
+			CredentialsName:       repoCreds,
+			PoolSelectionStrategy: params.PoolSelectionStrategy(repoPoolStrategy),
+		}
+		if cmd.Flags().Changed("polling-enabled") {
+			repoUpdateReq.PollingEnabled = &repoPollingEnabled
+		}
+		if cmd.Flags().Changed("polling-interval") {
+			repoUpdateReq.PollingInterval = &repoPollingInterval
 		}
 		repo, err := cli.UpdateRepo(args[0], repoUpdateReq)
 		if err != nil {
@@ -168,17 +186,115 @@ var repoDeleteCmd = &cobra.Command{
 	},
 }
 
+var repoWebhookCmd = &cobra.Command{
+	Use:          "webhook",
+	Short:        "Manage a repository webhooks",
+	Long:         `Install, show or uninstall the garm webhook of a repository.`,
+	SilenceUsage: true,
+	Run:          nil,
+}
+
+var repoWebhookInstallCmd = &cobra.Command{
+	Use:          "install",
+	Short:        "Install webhook",
+	Long:         `Install the garm webhook on a repository. If a garm webhook already exists, it will be updated.`,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if needsInit {
+			return errNeedsInitError
+		}
+		if len(args) == 0 {
+			return fmt.Errorf("requires a repository ID")
+		}
+		if len(args) > 1 {
+			return fmt.Errorf("too many arguments")
+		}
+
+		installParams := params.InstallWebhookParams{
+			InsecureSSL: repoWebhookInsecure,
+		}
+		info, err := cli.InstallRepoWebhook(args[0], installParams)
+		if err != nil {
+			return err
+		}
+		formatOneHookInfo(info)
+		return nil
+	},
+}
+
+var repoWebhookShowCmd = &cobra.Command{
+	Use:          "show",
+	Short:        "Show webhook info",
+	Long:         `Show information about the garm webhook installed on a repository.`,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if needsInit {
+			return errNeedsInitError
+		}
+		if len(args) == 0 {
+			return fmt.Errorf("requires a repository ID")
+		}
+		if len(args) > 1 {
+			return fmt.Errorf("too many arguments")
+		}
+
+		info, err := cli.GetRepoWebhookInfo(args[0])
+		if err != nil {
+			return err
+		}
+		formatOneHookInfo(info)
+		return nil
+	},
+}
+
+var repoWebhookUninstallCmd = &cobra.Command{
+	Use:          "uninstall",
+	Short:        "Uninstall webhook",
+	Long:         `Uninstall the garm webhook from a repository.`,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if needsInit {
+			return errNeedsInitError
+		}
+		if len(args) == 0 {
+			return fmt.Errorf("requires a repository ID")
+		}
+		if len(args) > 1 {
+			return fmt.Errorf("too many arguments")
+		}
+
+		if err := cli.UninstallRepoWebhook(args[0]); err != nil {
+			return err
+		}
+		return nil
+	},
+}
+
 func init() {
 
 	repoAddCmd.Flags().StringVar(&repoOwner, "owner", "", "The owner of this repository")
 	repoAddCmd.Flags().StringVar(&repoName, "name", "", "The name of the repository")
 	repoAddCmd.Flags().StringVar(&repoWebhookSecret, "webhook-secret", "", "The webhook secret for this repository")
 	repoAddCmd.Flags().StringVar(&repoCreds, "credentials", "", "Credentials name. See credentials list.")
+	repoAddCmd.Flags().BoolVar(&repoManageWebhook, "manage-webhook", false, "Let garm install and manage the webhook of this repository. A webhook secret is generated if none is set.")
+	repoAddCmd.Flags().BoolVar(&repoPollingEnabled, "polling-enabled", false, "Poll the GitHub API for workflow jobs of this repository, instead of relying on webhooks.")
+	repoAddCmd.Flags().UintVar(&repoPollingInterval, "polling-interval", 0, "Interval in seconds at which the GitHub API is polled for workflow jobs. Defaults to 60 seconds.")
+	repoAddCmd.Flags().StringVar(&repoPoolStrategy, "pool-selection-strategy", "", "Strategy used to pick a pool for a job when several pools match its labels (round_robin, priority, least_loaded, weighted_random).")
 	repoAddCmd.MarkFlagRequired("credentials") //nolint
 	repoAddCmd.MarkFlagRequired("owner")       //nolint
 	repoAddCmd.MarkFlagRequired("name")        //nolint
 	repoUpdateCmd.Flags().StringVar(&repoWebhookSecret, "webhook-secret", "", "The webhook secret for this repository")
 	repoUpdateCmd.Flags().StringVar(&repoCreds, "credentials", "", "Credentials name. See credentials list.")
+	repoUpdateCmd.Flags().BoolVar(&repoPollingEnabled, "polling-enabled", false, "Poll the GitHub API for workflow jobs of this repository, instead of relying on webhooks.")
+	repoUpdateCmd.Flags().UintVar(&repoPollingInterval, "polling-interval", 0, "Interval in seconds at which the GitHub API is polled for workflow jobs. Defaults to 60 seconds.")
+	repoUpdateCmd.Flags().StringVar(&repoPoolStrategy, "pool-selection-strategy", "", "Strategy used to pick a pool for a job when several pools match its labels (round_robin, priority, least_loaded, weighted_random).")
+
+	repoWebhookInstallCmd.Flags().BoolVar(&repoWebhookInsecure, "insecure", false, "Skip TLS verification when GitHub delivers events to the garm webhook URL.")
+	repoWebhookCmd.AddCommand(
+		repoWebhookInstallCmd,
+		repoWebhookShowCmd,
+		repoWebhookUninstallCmd,
+	)
 
 	repositoryCmd.AddCommand(
 		repoListCmd,
@@ -186,6 +302,7 @@ func init() {
 		repoShowCmd,
 		repoDeleteCmd,
 		repoUpdateCmd,
+		repoWebhookCmd,
 	)
 
 	rootCmd.AddCommand(repositoryCmd)
@@ -193,10 +310,10 @@ func init() {
 
 func formatRepositories(repos []params.Repository) {
 	t := table.NewWriter()
-	header := table.Row{"ID", "Owner", "Name", "Credentials name", "Pool mgr running"}
+	header := table.Row{"ID", "Owner", "Name", "Endpoint", "Credentials name", "Pool mgr running"}
 	t.AppendHeader(header)
 	for _, val := range repos {
-		t.AppendRow(table.Row{val.ID, val.Owner, val.Name, val.CredentialsName, val.PoolManagerStatus.IsRunning})
+		t.AppendRow(table.Row{val.ID, val.Owner, val.Name, val.Endpoint, val.CredentialsName, val.PoolManagerStatus.IsRunning})
 		t.AppendSeparator()
 	}
 	fmt.Println(t.Render())
@@ -210,7 +327,18 @@ func formatOneRepository(repo params.Repository) {
 	t.AppendRow(table.Row{"ID", repo.ID})
 	t.AppendRow(table.Row{"Owner", repo.Owner})
 	t.AppendRow(table.Row{"Name", repo.Name})
+	t.AppendRow(table.Row{"Endpoint", repo.Endpoint})
 	t.AppendRow(table.Row{"Credentials", repo.CredentialsName})
+	t.AppendRow(table.Row{"Polling enabled", repo.PollingEnabled})
+	if repo.PollingEnabled {
+		t.AppendRow(table.Row{"Polling interval", repo.PollingInterval})
+	}
+	t.AppendRow(table.Row{"Pool selection strategy", formatPoolSelectionStrategy(repo.PoolSelectionStrategy)})
+	if repo.WebhookURL != "" {
+		t.AppendRow(table.Row{"Webhook URL", repo.WebhookURL})
+	}
+	t.AppendRow(table.Row{"Webhook last seen", formatWebhookTimestamp(repo.WebhookLastSeenAt)})
+	t.AppendRow(table.Row{"Webhook verified at", formatWebhookTimestamp(repo.WebhookVerifiedAt)})
 	t.AppendRow(table.Row{"Pool manager running", repo.PoolManagerStatus.IsRunning})
 	if !repo.PoolManagerStatus.IsRunning {
 		t.AppendRow(table.Row{"Failure reason", repo.PoolManagerStatus.FailureReason})
@@ -228,3 +356,15 @@ func formatOneRepository(repo params.Repository) {
 
 	fmt.Println(t.Render())
 }
+
+func formatOneHookInfo(hook params.HookInfo) {
+	t := table.NewWriter()
+	header := table.Row{"Field", "Value"}
+	t.AppendHeader(header)
+	t.AppendRow(table.Row{"ID", hook.ID})
+	t.AppendRow(table.Row{"URL", hook.URL})
+	t.AppendRow(table.Row{"Events", strings.Join(hook.Events, ", ")})
+	t.AppendRow(table.Row{"Active", hook.Active})
+	t.AppendRow(table.Row{"Insecure SSL", hook.InsecureSSL})
+	fmt.Println(t.Render())
+}
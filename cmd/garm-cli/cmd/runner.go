@@ -216,6 +216,9 @@ func formatSingleInstance(instance params.Instance) {
 	t.AppendHeader(header)
 	t.AppendRow(table.Row{"ID", instance.ID}, table.RowConfig{AutoMerge: false})
 	t.AppendRow(table.Row{"Provider ID", instance.ProviderID}, table.RowConfig{AutoMerge: false})
+	if instance.ProviderName != "" {
+		t.AppendRow(table.Row{"Provider Name", instance.ProviderName}, table.RowConfig{AutoMerge: false})
+	}
 	t.AppendRow(table.Row{"Name", instance.Name}, table.RowConfig{AutoMerge: false})
 	t.AppendRow(table.Row{"OS Type", instance.OSType}, table.RowConfig{AutoMerge: false})
 	t.AppendRow(table.Row{"OS Architecture", instance.OSArch}, table.RowConfig{AutoMerge: false})
@@ -0,0 +1,176 @@
+// Copyright 2023 Cloudbase Solutions SRL
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/cloudbase/garm/params"
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/spf13/cobra"
+)
+
+var showDeliveryPayload bool
+
+// webhookDeliveryCmd represents the webhook-delivery command
+var webhookDeliveryCmd = &cobra.Command{
+	Use:          "webhook-delivery",
+	Aliases:      []string{"delivery"},
+	SilenceUsage: true,
+	Short:        "Inspect webhook deliveries",
+	Long: `Inspect and replay webhook deliveries received from GitHub.
+
+Webhooks are recorded as soon as they are received, and are processed
+in the background. Processed deliveries are kept for a few days.`,
+	Run: nil,
+}
+
+var webhookDeliveryListCmd = &cobra.Command{
+	Use:          "list",
+	Aliases:      []string{"ls"},
+	Short:        "List webhook deliveries",
+	Long:         `List all webhook deliveries recorded by garm.`,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if needsInit {
+			return errNeedsInitError
+		}
+
+		deliveries, err := cli.ListWebhookDeliveries()
+		if err != nil {
+			return err
+		}
+		formatWebhookDeliveries(deliveries)
+		return nil
+	},
+}
+
+var webhookDeliveryShowCmd = &cobra.Command{
+	Use:          "show",
+	Short:        "Show details for one webhook delivery",
+	Long:         `Displays detailed information about a single webhook delivery.`,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if needsInit {
+			return errNeedsInitError
+		}
+
+		if len(args) == 0 {
+			return fmt.Errorf("requires a delivery ID")
+		}
+
+		if len(args) > 1 {
+			return fmt.Errorf("too many arguments")
+		}
+
+		delivery, err := cli.GetWebhookDelivery(args[0])
+		if err != nil {
+			return err
+		}
+		formatOneWebhookDelivery(delivery, showDeliveryPayload)
+		return nil
+	},
+}
+
+var webhookDeliveryReplayCmd = &cobra.Command{
+	Use:          "replay",
+	Short:        "Process a webhook delivery again",
+	Long:         `Resets a webhook delivery to pending, so it is processed again. This is mostly useful for debugging.`,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if needsInit {
+			return errNeedsInitError
+		}
+
+		if len(args) == 0 {
+			return fmt.Errorf("requires a delivery ID")
+		}
+
+		if len(args) > 1 {
+			return fmt.Errorf("too many arguments")
+		}
+
+		delivery, err := cli.ReplayWebhookDelivery(args[0])
+		if err != nil {
+			return err
+		}
+		formatOneWebhookDelivery(delivery, false)
+		return nil
+	},
+}
+
+func formatWebhookDeliveries(deliveries []params.WebhookDelivery) {
+	t := table.NewWriter()
+	header := table.Row{"ID", "Event", "Target type", "Status", "Attempts", "Received at"}
+	t.AppendHeader(header)
+	for _, val := range deliveries {
+		t.AppendRow(table.Row{val.ID, val.Event, val.HookTargetType, val.Status, val.Attempts, val.CreatedAt})
+		t.AppendSeparator()
+	}
+	fmt.Println(t.Render())
+}
+
+func formatOneWebhookDelivery(delivery params.WebhookDelivery, withPayload bool) {
+	t := table.NewWriter()
+	header := table.Row{"Field", "Value"}
+	t.AppendHeader(header)
+	t.AppendRow(table.Row{"ID", delivery.ID})
+	t.AppendRow(table.Row{"Event", delivery.Event})
+	t.AppendRow(table.Row{"Target type", delivery.HookTargetType})
+	t.AppendRow(table.Row{"Status", delivery.Status})
+	t.AppendRow(table.Row{"Attempts", delivery.Attempts})
+	if delivery.LastError != "" {
+		t.AppendRow(table.Row{"Last error", delivery.LastError})
+	}
+	if delivery.Status == params.WebhookDeliveryPending {
+		t.AppendRow(table.Row{"Next attempt at", delivery.NextAttemptAt})
+	}
+	t.AppendRow(table.Row{"Received at", delivery.CreatedAt})
+	t.AppendRow(table.Row{"Updated at", delivery.UpdatedAt})
+	fmt.Println(t.Render())
+
+	if withPayload && len(delivery.Payload) > 0 {
+		var payload bytes.Buffer
+		if err := json.Indent(&payload, delivery.Payload, "", "  "); err != nil {
+			fmt.Println(string(delivery.Payload))
+			return
+		}
+		fmt.Println(payload.String())
+	}
+}
+
+// formatWebhookTimestamp formats the time at which garm last received or verified
+// a webhook for an entity.
+func formatWebhookTimestamp(timestamp *time.Time) string {
+	if timestamp == nil {
+		return "never"
+	}
+	return timestamp.String()
+}
+
+func init() {
+	webhookDeliveryShowCmd.Flags().BoolVar(&showDeliveryPayload, "payload", false, "Also print the webhook payload.")
+
+	webhookDeliveryCmd.AddCommand(
+		webhookDeliveryListCmd,
+		webhookDeliveryShowCmd,
+		webhookDeliveryReplayCmd,
+	)
+
+	rootCmd.AddCommand(webhookDeliveryCmd)
+}
@@ -17,6 +17,7 @@ package config
 import (
 	"crypto/tls"
 	"crypto/x509"
+	"encoding/pem"
 	"fmt"
 	"log"
 	"net"
@@ -117,6 +118,10 @@ type Default struct {
 	// MetadataURL is the URL where instances can fetch information they may need
 	// to set themselves up.
 	MetadataURL string `toml:"metadata_url" json:"metadata-url"`
+	// WebhookURL is the URL where GitHub can reach the garm webhook endpoint.
+	// This is optional, and is only needed if garm should manage webhooks
+	// for repositories and organizations.
+	WebhookURL string `toml:"webhook_url" json:"webhook-url"`
 	// LogFile is the location of the log file.
 	LogFile           string `toml:"log_file,omitempty" json:"log-file"`
 	EnableLogStreamer bool   `toml:"enable_log_streamer"`
@@ -139,6 +144,12 @@ func (d *Default) Validate() error {
 		return errors.Wrap(err, "validating metadata_url")
 	}
 
+	if d.WebhookURL != "" {
+		if _, err := url.ParseRequestURI(d.WebhookURL); err != nil {
+			return errors.Wrap(err, "validating webhook_url")
+		}
+	}
+
 	if d.ConfigDir == "" {
 		return fmt.Errorf("config_dir cannot be empty")
 	}
@@ -150,19 +161,92 @@ func (d *Default) Validate() error {
 	return nil
 }
 
+// GithubApp holds the credentials of a GitHub App installation. Garm uses
+// the private key of the app to mint short lived installation tokens.
+type GithubApp struct {
+	AppID          int64 `toml:"app_id" json:"app-id"`
+	InstallationID int64 `toml:"installation_id" json:"installation-id"`
+	// PrivateKeyPath is the path on disk to the PEM encoded private key
+	// generated for the GitHub App.
+	PrivateKeyPath string `toml:"private_key_path" json:"private-key-path"`
+	// WebhookSecret is the secret of the webhook of the GitHub App. It is used
+	// to validate installation webhooks, and is optional.
+	WebhookSecret string `toml:"webhook_secret" json:"webhook-secret"`
+}
+
+func (a *GithubApp) PrivateKeyBytes() ([]byte, error) {
+	if a.PrivateKeyPath == "" {
+		return nil, fmt.Errorf("missing private_key_path")
+	}
+
+	contents, err := os.ReadFile(a.PrivateKeyPath)
+	if err != nil {
+		return nil, errors.Wrap(err, "reading private key")
+	}
+
+	block, _ := pem.Decode(contents)
+	if block == nil {
+		return nil, fmt.Errorf("failed to decode private key")
+	}
+
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		if _, err := x509.ParsePKCS1PrivateKey(block.Bytes); err != nil {
+			return nil, errors.Wrap(err, "parsing private key")
+		}
+	case "PRIVATE KEY":
+		if _, err := x509.ParsePKCS8PrivateKey(block.Bytes); err != nil {
+			return nil, errors.Wrap(err, "parsing private key")
+		}
+	default:
+		return nil, fmt.Errorf("unsupported private key type: %s", block.Type)
+	}
+
+	return contents, nil
+}
+
+func (a *GithubApp) Validate() error {
+	if a.AppID == 0 {
+		return fmt.Errorf("missing app_id")
+	}
+
+	if a.InstallationID == 0 {
+		return fmt.Errorf("missing installation_id")
+	}
+
+	if _, err := a.PrivateKeyBytes(); err != nil {
+		return errors.Wrap(err, "validating private key")
+	}
+	return nil
+}
+
 // Github hold configuration options specific to interacting with github.
-// Currently that is just a OAuth2 personal token.
+// Credentials are either a OAuth2 personal token or a GitHub App installation.
 type Github struct {
-	Name          string `toml:"name" json:"name"`
-	Description   string `toml:"description" json:"description"`
-	OAuth2Token   string `toml:"oauth2_token" json:"oauth2-token"`
-	APIBaseURL    string `toml:"api_base_url" json:"api-base-url"`
-	UploadBaseURL string `toml:"upload_base_url" json:"upload-base-url"`
-	BaseURL       string `toml:"base_url" json:"base-url"`
+	Name        string `toml:"name" json:"name"`
+	Description string `toml:"description" json:"description"`
+	// AuthType selects the way garm authenticates against the GitHub API.
+	// Valid values are "pat" and "app". Defaults to "pat".
+	AuthType      params.GithubAuthType `toml:"auth_type" json:"auth-type"`
+	OAuth2Token   string                `toml:"oauth2_token" json:"oauth2-token"`
+	App           GithubApp             `toml:"app" json:"app"`
+	APIBaseURL    string                `toml:"api_base_url" json:"api-base-url"`
+	UploadBaseURL string                `toml:"upload_base_url" json:"upload-base-url"`
+	BaseURL       string                `toml:"base_url" json:"base-url"`
 	// CACertBundlePath is the path on disk to a CA certificate bundle that
 	// can validate the endpoints defined above. Leave empty if not using a
 	// self signed certificate.
 	CACertBundlePath string `toml:"ca_cert_bundle" json:"ca-cert-bundle"`
+	// MaxRunners is the maximum number of runners garm will create for entities
+	// using these credentials, across all their pools. A value of 0 means no limit.
+	MaxRunners uint `toml:"max_runners" json:"max-runners"`
+}
+
+func (g *Github) GetAuthType() params.GithubAuthType {
+	if g.AuthType == "" {
+		return params.GithubAuthTypePAT
+	}
+	return g.AuthType
 }
 
 func (g *Github) APIEndpoint() string {
@@ -212,8 +296,17 @@ func (g *Github) BaseEndpoint() string {
 }
 
 func (g *Github) Validate() error {
-	if g.OAuth2Token == "" {
-		return fmt.Errorf("missing github oauth2 token")
+	switch g.GetAuthType() {
+	case params.GithubAuthTypePAT:
+		if g.OAuth2Token == "" {
+			return fmt.Errorf("missing github oauth2 token")
+		}
+	case params.GithubAuthTypeApp:
+		if err := g.App.Validate(); err != nil {
+			return errors.Wrap(err, "validating github app")
+		}
+	default:
+		return fmt.Errorf("invalid auth_type: %s", g.AuthType)
 	}
 
 	return nil
@@ -227,6 +320,44 @@ type Provider struct {
 	Description  string              `toml:"description" json:"description"`
 	LXD          LXD                 `toml:"lxd" json:"lxd"`
 	External     External            `toml:"external" json:"external"`
+	// MaxInstances is the maximum number of runners garm will create in this
+	// provider, across all pools that use it. A value of 0 means no limit.
+	MaxInstances uint `toml:"max_instances" json:"max-instances"`
+	// MaxCPUs is the maximum number of CPUs the runners in this provider may use.
+	// The CPUs used by a runner are taken from the Flavors table. A value of 0 means
+	// no limit.
+	MaxCPUs uint `toml:"max_cpus" json:"max-cpus"`
+	// MaxMemoryMB is the maximum amount of memory, in MB, the runners in this provider
+	// may use. A value of 0 means no limit.
+	MaxMemoryMB uint `toml:"max_memory_mb" json:"max-memory-mb"`
+	// Flavors holds the resources used by each flavor of this provider. It is required
+	// for all flavors used by pools, if a CPU or memory budget is set.
+	Flavors []ProviderFlavor `toml:"flavors" json:"flavors"`
+}
+
+// ProviderFlavor holds the resources used by one runner of a flavor.
+type ProviderFlavor struct {
+	Name     string `toml:"name" json:"name"`
+	CPUs     uint   `toml:"cpus" json:"cpus"`
+	MemoryMB uint   `toml:"memory_mb" json:"memory-mb"`
+}
+
+// Limits returns the capacity limits of the provider.
+func (p *Provider) Limits() params.ProviderLimits {
+	flavors := make([]params.ProviderFlavor, len(p.Flavors))
+	for idx, flavor := range p.Flavors {
+		flavors[idx] = params.ProviderFlavor{
+			Name:     flavor.Name,
+			CPUs:     flavor.CPUs,
+			MemoryMB: flavor.MemoryMB,
+		}
+	}
+	return params.ProviderLimits{
+		MaxInstances: p.MaxInstances,
+		MaxCPUs:      p.MaxCPUs,
+		MaxMemoryMB:  p.MaxMemoryMB,
+		Flavors:      flavors,
+	}
 }
 
 func (p *Provider) Validate() error {
@@ -234,6 +365,20 @@ func (p *Provider) Validate() error {
 		return fmt.Errorf("missing provider name")
 	}
 
+	flavors := map[string]bool{}
+	for _, flavor := range p.Flavors {
+		if flavor.Name == "" {
+			return fmt.Errorf("missing flavor name for provider %s", p.Name)
+		}
+		if flavors[flavor.Name] {
+			return fmt.Errorf("duplicate flavor %s for provider %s", flavor.Name, p.Name)
+		}
+		flavors[flavor.Name] = true
+	}
+	if (p.MaxCPUs > 0 || p.MaxMemoryMB > 0) && len(p.Flavors) == 0 {
+		return fmt.Errorf("flavors are required for provider %s when max_cpus or max_memory_mb is set", p.Name)
+	}
+
 	switch p.ProviderType {
 	case params.LXDProvider:
 		if err := p.LXD.Validate(); err != nil {
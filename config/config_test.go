@@ -15,6 +15,10 @@
 package config
 
 import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
 	"os"
 	"path/filepath"
 	"testing"
@@ -165,6 +169,25 @@ func TestDefaultSectionConfig(t *testing.T) {
 			},
 			errString: "missing metadata-url",
 		},
+		{
+			name: "WebhookURL is optional",
+			cfg: Default{
+				CallbackURL: cfg.CallbackURL,
+				MetadataURL: cfg.MetadataURL,
+				ConfigDir:   cfg.ConfigDir,
+			},
+			errString: "",
+		},
+		{
+			name: "WebhookURL must be valid",
+			cfg: Default{
+				CallbackURL: cfg.CallbackURL,
+				MetadataURL: cfg.MetadataURL,
+				WebhookURL:  "garm.example.com/webhooks",
+				ConfigDir:   cfg.ConfigDir,
+			},
+			errString: "validating webhook_url:.*",
+		},
 		{
 			name: "ConfigDir cannot be empty",
 			cfg: Default{
@@ -535,6 +558,208 @@ func TestJWTAuthConfig(t *testing.T) {
 	}
 }
 
+func writeGithubAppPrivateKey(t *testing.T, dir string) string {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.Nil(t, err)
+
+	keyPath := filepath.Join(dir, "app.pem")
+	contents := pem.EncodeToMemory(&pem.Block{
+		Type:  "RSA PRIVATE KEY",
+		Bytes: x509.MarshalPKCS1PrivateKey(key),
+	})
+	err = os.WriteFile(keyPath, contents, 0o600)
+	require.Nil(t, err)
+	return keyPath
+}
+
+func TestGithubConfig(t *testing.T) {
+	dir, err := os.MkdirTemp("", "garm-config-test")
+	require.Nil(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	keyPath := writeGithubAppPrivateKey(t, dir)
+	invalidKeyPath := filepath.Join(dir, "invalid.pem")
+	err = os.WriteFile(invalidKeyPath, []byte("not a key"), 0o600)
+	require.Nil(t, err)
+
+	tests := []struct {
+		name      string
+		cfg       Github
+		errString string
+	}{
+		{
+			name:      "Config is valid",
+			cfg:       getDefaultGithubConfig()[0],
+			errString: "",
+		},
+		{
+			name: "oauth2 token is missing",
+			cfg: Github{
+				Name: "dummy_creds",
+			},
+			errString: "missing github oauth2 token",
+		},
+		{
+			name: "invalid auth type",
+			cfg: Github{
+				Name:        "dummy_creds",
+				AuthType:    "bogus",
+				OAuth2Token: "bogus",
+			},
+			errString: "invalid auth_type: bogus",
+		},
+		{
+			name: "github app is valid",
+			cfg: Github{
+				Name:     "dummy_app_creds",
+				AuthType: params.GithubAuthTypeApp,
+				App: GithubApp{
+					AppID:          1,
+					InstallationID: 2,
+					PrivateKeyPath: keyPath,
+				},
+			},
+			errString: "",
+		},
+		{
+			name: "github app is missing app ID",
+			cfg: Github{
+				Name:     "dummy_app_creds",
+				AuthType: params.GithubAuthTypeApp,
+				App: GithubApp{
+					InstallationID: 2,
+					PrivateKeyPath: keyPath,
+				},
+			},
+			errString: "validating github app: missing app_id",
+		},
+		{
+			name: "github app is missing installation ID",
+			cfg: Github{
+				Name:     "dummy_app_creds",
+				AuthType: params.GithubAuthTypeApp,
+				App: GithubApp{
+					AppID:          1,
+					PrivateKeyPath: keyPath,
+				},
+			},
+			errString: "validating github app: missing installation_id",
+		},
+		{
+			name: "github app private key is invalid",
+			cfg: Github{
+				Name:     "dummy_app_creds",
+				AuthType: params.GithubAuthTypeApp,
+				App: GithubApp{
+					AppID:          1,
+					InstallationID: 2,
+					PrivateKeyPath: invalidKeyPath,
+				},
+			},
+			errString: "validating github app: validating private key: failed to decode private key",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.cfg.Validate()
+			if tc.errString == "" {
+				require.Nil(t, err)
+			} else {
+				require.NotNil(t, err)
+				require.EqualError(t, err, tc.errString)
+			}
+		})
+	}
+}
+
+func TestProviderLimitsConfig(t *testing.T) {
+	tests := []struct {
+		name      string
+		cfg       func(p *Provider)
+		errString string
+	}{
+		{
+			name:      "Config is valid",
+			cfg:       func(p *Provider) {},
+			errString: "",
+		},
+		{
+			name: "limits are valid",
+			cfg: func(p *Provider) {
+				p.MaxInstances = 10
+				p.MaxCPUs = 16
+				p.Flavors = []ProviderFlavor{{Name: "default", CPUs: 2, MemoryMB: 2048}}
+			},
+			errString: "",
+		},
+		{
+			name: "flavor name is missing",
+			cfg: func(p *Provider) {
+				p.Flavors = []ProviderFlavor{{CPUs: 2}}
+			},
+			errString: "missing flavor name for provider test_lxd",
+		},
+		{
+			name: "flavor is duplicated",
+			cfg: func(p *Provider) {
+				p.Flavors = []ProviderFlavor{{Name: "default"}, {Name: "default"}}
+			},
+			errString: "duplicate flavor default for provider test_lxd",
+		},
+		{
+			name: "flavors are missing",
+			cfg: func(p *Provider) {
+				p.MaxMemoryMB = 8192
+			},
+			errString: "flavors are required for provider test_lxd when max_cpus or max_memory_mb is set",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := getDefaultProvidersConfig()[0]
+			tc.cfg(&cfg)
+			err := cfg.Validate()
+			if tc.errString == "" {
+				require.Nil(t, err)
+			} else {
+				require.NotNil(t, err)
+				require.EqualError(t, err, tc.errString)
+			}
+		})
+	}
+}
+
+func TestProviderLimits(t *testing.T) {
+	cfg := getDefaultProvidersConfig()[0]
+	cfg.MaxInstances = 3
+	cfg.MaxCPUs = 8
+	cfg.Flavors = []ProviderFlavor{{Name: "default", CPUs: 4, MemoryMB: 4096}}
+
+	limits := cfg.Limits()
+	require.True(t, limits.IsSet())
+
+	usage := limits.Usage(map[string]uint{"default": 1, "unknown": 1})
+	require.Equal(t, params.ProviderUsage{Instances: 2, CPUs: 4, MemoryMB: 4096}, usage)
+	require.Nil(t, limits.Fits(usage, "default"))
+	require.EqualError(t, limits.Fits(usage, "unknown"), "resources of flavor unknown are unknown")
+
+	usage = limits.Usage(map[string]uint{"default": 2})
+	require.EqualError(t, limits.Fits(usage, "default"), "max cpus (8) reached")
+
+	usage = limits.Usage(map[string]uint{"unknown": 3})
+	require.EqualError(t, limits.Fits(usage, "default"), "max instances (3) reached")
+}
+
+func TestGithubAuthTypeDefaultsToPAT(t *testing.T) {
+	cfg := getDefaultGithubConfig()[0]
+	require.Equal(t, params.GithubAuthTypePAT, cfg.GetAuthType())
+
+	cfg.AuthType = params.GithubAuthTypeApp
+	require.Equal(t, params.GithubAuthTypeApp, cfg.GetAuthType())
+}
+
 func TestTimeToLiveDuration(t *testing.T) {
 	cfg := JWTAuth{
 		Secret:     EncryptionPassphrase,
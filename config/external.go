@@ -24,6 +24,15 @@ import (
 	"github.com/pkg/errors"
 )
 
+type ExternalMode string
+
+const (
+	// ExternalExecMode runs the provider executable once for every operation.
+	ExternalExecMode ExternalMode = "exec"
+	// ExternalDaemonMode talks to a long running provider process over a Unix socket.
+	ExternalDaemonMode ExternalMode = "daemon"
+)
+
 // External represents the config for an external provider.
 // The external provider is a provider that delegates all operations
 // to an external binary. This way, you can write your own logic in
@@ -42,6 +51,24 @@ type External struct {
 	// the provider. If specified, it will take precedence over the "garm-external-provider"
 	// executable in the ProviderDir.
 	ProviderExecutable string `toml:"provider_executable" json:"provider-executable"`
+	// HealthCheck enables periodic health checks of the provider, using the Health
+	// command. Only enable it for provider executables that implement this command.
+	HealthCheck bool `toml:"health_check" json:"health-check"`
+	// Mode is the way garm talks to the provider. Defaults to exec.
+	Mode ExternalMode `toml:"mode" json:"mode"`
+	// SocketPath is the path to the Unix socket the provider listens on in daemon mode.
+	SocketPath string `toml:"socket_path" json:"socket-path"`
+	// StartDaemon makes garm start the provider executable in daemon mode, instead of
+	// connecting to a provider process that is managed by someone else.
+	StartDaemon bool `toml:"start_daemon" json:"start-daemon"`
+}
+
+// ProviderMode returns the mode of the provider, defaulting to exec.
+func (e *External) ProviderMode() ExternalMode {
+	if e.Mode == "" {
+		return ExternalExecMode
+	}
+	return e.Mode
 }
 
 func (e *External) ExecutablePath() (string, error) {
@@ -66,6 +93,24 @@ func (e *External) Validate() error {
 		}
 	}
 
+	switch e.ProviderMode() {
+	case ExternalExecMode:
+	case ExternalDaemonMode:
+		if e.SocketPath == "" {
+			return fmt.Errorf("missing socket_path")
+		}
+		if !filepath.IsAbs(e.SocketPath) {
+			return fmt.Errorf("path to socket must be an absolute path")
+		}
+		if !e.StartDaemon {
+			// The provider process is not started by garm, so we don't need
+			// the executable.
+			return nil
+		}
+	default:
+		return fmt.Errorf("invalid mode %s", e.Mode)
+	}
+
 	execPath, err := e.ExecutablePath()
 	if err != nil {
 		return errors.Wrap(err, "fetching executable path")
@@ -94,6 +94,50 @@ func TestExternal(t *testing.T) {
 			},
 			errString: "checking provider executable: stat /tmp/garm-external-provider: no such file or directory",
 		},
+		{
+			name: "Daemon mode does not need an executable",
+			cfg: External{
+				Mode:        ExternalDaemonMode,
+				SocketPath:  "/run/garm/provider.sock",
+				ProviderDir: "/tmp",
+			},
+			errString: "",
+		},
+		{
+			name: "Daemon mode needs an executable if garm starts the daemon",
+			cfg: External{
+				Mode:        ExternalDaemonMode,
+				SocketPath:  "/run/garm/provider.sock",
+				StartDaemon: true,
+				ProviderDir: "/tmp",
+			},
+			errString: "checking provider executable: stat /tmp/garm-external-provider: no such file or directory",
+		},
+		{
+			name: "Daemon mode requires a socket path",
+			cfg: External{
+				Mode:        ExternalDaemonMode,
+				ProviderDir: cfg.ProviderDir,
+			},
+			errString: "missing socket_path",
+		},
+		{
+			name: "Socket path must be absolute",
+			cfg: External{
+				Mode:        ExternalDaemonMode,
+				SocketPath:  "provider.sock",
+				ProviderDir: cfg.ProviderDir,
+			},
+			errString: "path to socket must be an absolute path",
+		},
+		{
+			name: "Mode must be valid",
+			cfg: External{
+				Mode:        "dummy",
+				ProviderDir: cfg.ProviderDir,
+			},
+			errString: "invalid mode dummy",
+		},
 	}
 
 	for _, tc := range tests {
@@ -16,17 +16,21 @@ package common
 
 import (
 	"context"
+	"time"
 
 	"github.com/cloudbase/garm/params"
 )
 
 type RepoStore interface {
-	CreateRepository(ctx context.Context, owner, name, credentialsName, webhookSecret string) (params.Repository, error)
-	GetRepository(ctx context.Context, owner, name string) (params.Repository, error)
+	CreateRepository(ctx context.Context, endpoint, owner, name, credentialsName, webhookSecret string) (params.Repository, error)
+	GetRepository(ctx context.Context, endpoint, owner, name string) (params.Repository, error)
 	GetRepositoryByID(ctx context.Context, repoID string) (params.Repository, error)
 	ListRepositories(ctx context.Context) ([]params.Repository, error)
 	DeleteRepository(ctx context.Context, repoID string) error
 	UpdateRepository(ctx context.Context, repoID string, param params.UpdateEntityParams) (params.Repository, error)
+	// SetRepositoryManagedHookID records the ID of the webhook garm installed for a
+	// repository. A hookID of 0 means garm does not manage a webhook for it.
+	SetRepositoryManagedHookID(ctx context.Context, repoID string, hookID int64) error
 
 	CreateRepositoryPool(ctx context.Context, repoId string, param params.CreatePoolParams) (params.Pool, error)
 
@@ -40,12 +44,15 @@ type RepoStore interface {
 }
 
 type OrgStore interface {
-	CreateOrganization(ctx context.Context, name, credentialsName, webhookSecret string) (params.Organization, error)
-	GetOrganization(ctx context.Context, name string) (params.Organization, error)
+	CreateOrganization(ctx context.Context, endpoint, name, credentialsName, webhookSecret string) (params.Organization, error)
+	GetOrganization(ctx context.Context, endpoint, name string) (params.Organization, error)
 	GetOrganizationByID(ctx context.Context, orgID string) (params.Organization, error)
 	ListOrganizations(ctx context.Context) ([]params.Organization, error)
 	DeleteOrganization(ctx context.Context, orgID string) error
 	UpdateOrganization(ctx context.Context, orgID string, param params.UpdateEntityParams) (params.Organization, error)
+	// SetOrganizationManagedHookID records the ID of the webhook garm installed for an
+	// organization. A hookID of 0 means garm does not manage a webhook for it.
+	SetOrganizationManagedHookID(ctx context.Context, orgID string, hookID int64) error
 
 	CreateOrganizationPool(ctx context.Context, orgId string, param params.CreatePoolParams) (params.Pool, error)
 	GetOrganizationPool(ctx context.Context, orgID, poolID string) (params.Pool, error)
@@ -58,8 +65,8 @@ type OrgStore interface {
 }
 
 type EnterpriseStore interface {
-	CreateEnterprise(ctx context.Context, name, credentialsName, webhookSecret string) (params.Enterprise, error)
-	GetEnterprise(ctx context.Context, name string) (params.Enterprise, error)
+	CreateEnterprise(ctx context.Context, endpoint, name, credentialsName, webhookSecret string) (params.Enterprise, error)
+	GetEnterprise(ctx context.Context, endpoint, name string) (params.Enterprise, error)
 	GetEnterpriseByID(ctx context.Context, enterpriseID string) (params.Enterprise, error)
 	ListEnterprises(ctx context.Context) ([]params.Enterprise, error)
 	DeleteEnterprise(ctx context.Context, enterpriseID string) error
@@ -75,6 +82,21 @@ type EnterpriseStore interface {
 	ListEnterpriseInstances(ctx context.Context, enterpriseID string) ([]params.Instance, error)
 }
 
+type EntityStore interface {
+	// UpdateEntityEndpoint sets the github endpoint of a repository, organization or
+	// enterprise. It is used to migrate entities created before the endpoint was
+	// recorded.
+	UpdateEntityEndpoint(ctx context.Context, entityType params.PoolType, entityID, endpoint string) error
+}
+
+type GithubCredentialsStore interface {
+	CreateGithubCredentials(ctx context.Context, param params.CreateGithubCredentialsParams) (params.GithubCredentials, error)
+	GetGithubCredentialsByName(ctx context.Context, name string) (params.GithubCredentials, error)
+	ListGithubCredentials(ctx context.Context) ([]params.GithubCredentials, error)
+	UpdateGithubCredentials(ctx context.Context, name string, param params.UpdateGithubCredentialsParams) (params.GithubCredentials, error)
+	DeleteGithubCredentials(ctx context.Context, name string) error
+}
+
 type PoolStore interface {
 	// Probably a bad idea without some king of filter or at least pagination
 	// TODO: add filter/pagination
@@ -85,8 +107,19 @@ type PoolStore interface {
 	ListPoolInstances(ctx context.Context, poolID string) ([]params.Instance, error)
 
 	PoolInstanceCount(ctx context.Context, poolID string) (int64, error)
+	// ProviderInstanceCountByFlavor returns the number of instances placed on a provider,
+	// across all pools, keyed by the flavor they use on that provider.
+	ProviderInstanceCountByFlavor(ctx context.Context, providerName string) (map[string]uint, error)
+	// CredentialsInstanceCount returns the number of instances in all pools of the
+	// repositories, organizations and enterprises using the given credentials.
+	CredentialsInstanceCount(ctx context.Context, credentialsName string) (int64, error)
 	GetPoolInstanceByName(ctx context.Context, poolID string, instanceName string) (params.Instance, error)
 	FindPoolsMatchingAllTags(ctx context.Context, entityType params.PoolType, entityID string, tags []string) ([]params.Pool, error)
+	// SetPoolValidatedConfigRevision records that a job ran successfully on a runner created
+	// from the given config revision of the pool. Older revisions are ignored.
+	SetPoolValidatedConfigRevision(ctx context.Context, poolID string, revision uint) error
+	// SetPoolCircuitBreaker saves the state of the circuit breaker of a pool.
+	SetPoolCircuitBreaker(ctx context.Context, poolID string, breaker params.CircuitBreaker) (params.Pool, error)
 }
 
 type UserStore interface {
@@ -127,15 +160,61 @@ type JobsStore interface {
 	DeleteCompletedJobs(ctx context.Context) error
 }
 
+type WebhookDeliveryStore interface {
+	CreateWebhookDelivery(ctx context.Context, param params.WebhookDelivery) (params.WebhookDelivery, error)
+	GetWebhookDelivery(ctx context.Context, deliveryID string) (params.WebhookDelivery, error)
+	ListWebhookDeliveries(ctx context.Context) ([]params.WebhookDelivery, error)
+	ListWebhookDeliveriesByStatus(ctx context.Context, status params.WebhookDeliveryStatus) ([]params.WebhookDelivery, error)
+	UpdateWebhookDelivery(ctx context.Context, deliveryID string, param params.UpdateWebhookDeliveryParams) (params.WebhookDelivery, error)
+	DeleteWebhookDeliveriesOlderThan(ctx context.Context, olderThan time.Time) error
+
+	// GetWebhookDeliveryHighWaterMark returns the time at which the newest webhook delivery
+	// processed for an entity was received. A zero time is returned if no delivery was
+	// processed yet.
+	GetWebhookDeliveryHighWaterMark(ctx context.Context, entityType params.PoolType, entityID string) (time.Time, error)
+	// UpdateWebhookDeliveryHighWaterMark moves the high-water mark of an entity forward.
+	// Times older than the current mark are ignored.
+	UpdateWebhookDeliveryHighWaterMark(ctx context.Context, entityType params.PoolType, entityID string, deliveredAt time.Time) error
+	// RecordWebhookSeen records that a webhook with a valid signature was received for an
+	// entity. If verified is set, the webhook was a ping.
+	RecordWebhookSeen(ctx context.Context, entityType params.PoolType, entityID string, verified bool) error
+}
+
+type PoolDemandStore interface {
+	// RecordPoolQueuedJobs records a sample of the number of queued jobs a pool could run.
+	RecordPoolQueuedJobs(ctx context.Context, poolID string, sampledAt time.Time, queued uint) error
+	// RecordPoolJobStarted records a job that was picked up by a runner of the pool, after
+	// spending the given time queued.
+	RecordPoolJobStarted(ctx context.Context, poolID string, startedAt time.Time, queueLatency time.Duration) error
+	// ListPoolDemandStats returns the hourly demand statistics of a pool, for the hours
+	// starting in the [from, to) interval.
+	ListPoolDemandStats(ctx context.Context, poolID string, from, to time.Time) ([]params.PoolDemandStats, error)
+	DeletePoolDemandStatsOlderThan(ctx context.Context, olderThan time.Time) error
+}
+
+type DrainStore interface {
+	// SetPoolDrain starts or stops the drain of a pool. Starting a drain that is
+	// already in progress keeps its original start time.
+	SetPoolDrain(ctx context.Context, poolID string, draining bool) (params.Pool, error)
+	// SetProviderDrain starts or stops the drain of all pools using a provider.
+	SetProviderDrain(ctx context.Context, providerName string, draining bool) error
+	ListProviderDrains(ctx context.Context) ([]params.ProviderDrain, error)
+}
+
 //go:generate mockery --name=Store
 type Store interface {
 	RepoStore
 	OrgStore
 	EnterpriseStore
+	EntityStore
+	GithubCredentialsStore
 	PoolStore
 	UserStore
 	InstanceStore
 	JobsStore
+	WebhookDeliveryStore
+	PoolDemandStore
+	DrainStore
 
 	ControllerInfo() (params.ControllerInfo, error)
 	InitController() (params.ControllerInfo, error)
@@ -7,6 +7,8 @@ import (
 
 	params "github.com/cloudbase/garm/params"
 	mock "github.com/stretchr/testify/mock"
+
+	time "time"
 )
 
 // Store is an autogenerated mock type for the Store type
@@ -66,23 +68,23 @@ func (_m *Store) ControllerInfo() (params.ControllerInfo, error) {
 	return r0, r1
 }
 
-// CreateEnterprise provides a mock function with given fields: ctx, name, credentialsName, webhookSecret
-func (_m *Store) CreateEnterprise(ctx context.Context, name string, credentialsName string, webhookSecret string) (params.Enterprise, error) {
-	ret := _m.Called(ctx, name, credentialsName, webhookSecret)
+// CreateEnterprise provides a mock function with given fields: ctx, endpoint, name, credentialsName, webhookSecret
+func (_m *Store) CreateEnterprise(ctx context.Context, endpoint string, name string, credentialsName string, webhookSecret string) (params.Enterprise, error) {
+	ret := _m.Called(ctx, endpoint, name, credentialsName, webhookSecret)
 
 	var r0 params.Enterprise
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, string, string, string) (params.Enterprise, error)); ok {
-		return rf(ctx, name, credentialsName, webhookSecret)
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string, string) (params.Enterprise, error)); ok {
+		return rf(ctx, endpoint, name, credentialsName, webhookSecret)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, string, string, string) params.Enterprise); ok {
-		r0 = rf(ctx, name, credentialsName, webhookSecret)
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string, string) params.Enterprise); ok {
+		r0 = rf(ctx, endpoint, name, credentialsName, webhookSecret)
 	} else {
 		r0 = ret.Get(0).(params.Enterprise)
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, string, string, string) error); ok {
-		r1 = rf(ctx, name, credentialsName, webhookSecret)
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, string, string) error); ok {
+		r1 = rf(ctx, endpoint, name, credentialsName, webhookSecret)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -114,6 +116,30 @@ func (_m *Store) CreateEnterprisePool(ctx context.Context, enterpriseID string,
 	return r0, r1
 }
 
+// CreateGithubCredentials provides a mock function with given fields: ctx, param
+func (_m *Store) CreateGithubCredentials(ctx context.Context, param params.CreateGithubCredentialsParams) (params.GithubCredentials, error) {
+	ret := _m.Called(ctx, param)
+
+	var r0 params.GithubCredentials
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, params.CreateGithubCredentialsParams) (params.GithubCredentials, error)); ok {
+		return rf(ctx, param)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, params.CreateGithubCredentialsParams) params.GithubCredentials); ok {
+		r0 = rf(ctx, param)
+	} else {
+		r0 = ret.Get(0).(params.GithubCredentials)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, params.CreateGithubCredentialsParams) error); ok {
+		r1 = rf(ctx, param)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // CreateInstance provides a mock function with given fields: ctx, poolID, param
 func (_m *Store) CreateInstance(ctx context.Context, poolID string, param params.CreateInstanceParams) (params.Instance, error) {
 	ret := _m.Called(ctx, poolID, param)
@@ -162,23 +188,23 @@ func (_m *Store) CreateOrUpdateJob(ctx context.Context, job params.Job) (params.
 	return r0, r1
 }
 
-// CreateOrganization provides a mock function with given fields: ctx, name, credentialsName, webhookSecret
-func (_m *Store) CreateOrganization(ctx context.Context, name string, credentialsName string, webhookSecret string) (params.Organization, error) {
-	ret := _m.Called(ctx, name, credentialsName, webhookSecret)
+// CreateOrganization provides a mock function with given fields: ctx, endpoint, name, credentialsName, webhookSecret
+func (_m *Store) CreateOrganization(ctx context.Context, endpoint string, name string, credentialsName string, webhookSecret string) (params.Organization, error) {
+	ret := _m.Called(ctx, endpoint, name, credentialsName, webhookSecret)
 
 	var r0 params.Organization
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, string, string, string) (params.Organization, error)); ok {
-		return rf(ctx, name, credentialsName, webhookSecret)
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string, string) (params.Organization, error)); ok {
+		return rf(ctx, endpoint, name, credentialsName, webhookSecret)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, string, string, string) params.Organization); ok {
-		r0 = rf(ctx, name, credentialsName, webhookSecret)
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string, string) params.Organization); ok {
+		r0 = rf(ctx, endpoint, name, credentialsName, webhookSecret)
 	} else {
 		r0 = ret.Get(0).(params.Organization)
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, string, string, string) error); ok {
-		r1 = rf(ctx, name, credentialsName, webhookSecret)
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, string, string) error); ok {
+		r1 = rf(ctx, endpoint, name, credentialsName, webhookSecret)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -210,23 +236,23 @@ func (_m *Store) CreateOrganizationPool(ctx context.Context, orgId string, param
 	return r0, r1
 }
 
-// CreateRepository provides a mock function with given fields: ctx, owner, name, credentialsName, webhookSecret
-func (_m *Store) CreateRepository(ctx context.Context, owner string, name string, credentialsName string, webhookSecret string) (params.Repository, error) {
-	ret := _m.Called(ctx, owner, name, credentialsName, webhookSecret)
+// CreateRepository provides a mock function with given fields: ctx, endpoint, owner, name, credentialsName, webhookSecret
+func (_m *Store) CreateRepository(ctx context.Context, endpoint string, owner string, name string, credentialsName string, webhookSecret string) (params.Repository, error) {
+	ret := _m.Called(ctx, endpoint, owner, name, credentialsName, webhookSecret)
 
 	var r0 params.Repository
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, string, string, string, string) (params.Repository, error)); ok {
-		return rf(ctx, owner, name, credentialsName, webhookSecret)
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string, string, string) (params.Repository, error)); ok {
+		return rf(ctx, endpoint, owner, name, credentialsName, webhookSecret)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, string, string, string, string) params.Repository); ok {
-		r0 = rf(ctx, owner, name, credentialsName, webhookSecret)
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string, string, string) params.Repository); ok {
+		r0 = rf(ctx, endpoint, owner, name, credentialsName, webhookSecret)
 	} else {
 		r0 = ret.Get(0).(params.Repository)
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, string, string, string, string) error); ok {
-		r1 = rf(ctx, owner, name, credentialsName, webhookSecret)
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, string, string, string) error); ok {
+		r1 = rf(ctx, endpoint, owner, name, credentialsName, webhookSecret)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -282,6 +308,54 @@ func (_m *Store) CreateUser(ctx context.Context, user params.NewUserParams) (par
 	return r0, r1
 }
 
+// CreateWebhookDelivery provides a mock function with given fields: ctx, param
+func (_m *Store) CreateWebhookDelivery(ctx context.Context, param params.WebhookDelivery) (params.WebhookDelivery, error) {
+	ret := _m.Called(ctx, param)
+
+	var r0 params.WebhookDelivery
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, params.WebhookDelivery) (params.WebhookDelivery, error)); ok {
+		return rf(ctx, param)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, params.WebhookDelivery) params.WebhookDelivery); ok {
+		r0 = rf(ctx, param)
+	} else {
+		r0 = ret.Get(0).(params.WebhookDelivery)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, params.WebhookDelivery) error); ok {
+		r1 = rf(ctx, param)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CredentialsInstanceCount provides a mock function with given fields: ctx, credentialsName
+func (_m *Store) CredentialsInstanceCount(ctx context.Context, credentialsName string) (int64, error) {
+	ret := _m.Called(ctx, credentialsName)
+
+	var r0 int64
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (int64, error)); ok {
+		return rf(ctx, credentialsName)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) int64); ok {
+		r0 = rf(ctx, credentialsName)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, credentialsName)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // DeleteCompletedJobs provides a mock function with given fields: ctx
 func (_m *Store) DeleteCompletedJobs(ctx context.Context) error {
 	ret := _m.Called(ctx)
@@ -324,6 +398,20 @@ func (_m *Store) DeleteEnterprisePool(ctx context.Context, enterpriseID string,
 	return r0
 }
 
+// DeleteGithubCredentials provides a mock function with given fields: ctx, name
+func (_m *Store) DeleteGithubCredentials(ctx context.Context, name string) error {
+	ret := _m.Called(ctx, name)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, name)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // DeleteInstance provides a mock function with given fields: ctx, poolID, instanceName
 func (_m *Store) DeleteInstance(ctx context.Context, poolID string, instanceName string) error {
 	ret := _m.Called(ctx, poolID, instanceName)
@@ -394,6 +482,20 @@ func (_m *Store) DeletePoolByID(ctx context.Context, poolID string) error {
 	return r0
 }
 
+// DeletePoolDemandStatsOlderThan provides a mock function with given fields: ctx, olderThan
+func (_m *Store) DeletePoolDemandStatsOlderThan(ctx context.Context, olderThan time.Time) error {
+	ret := _m.Called(ctx, olderThan)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time) error); ok {
+		r0 = rf(ctx, olderThan)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // DeleteRepository provides a mock function with given fields: ctx, repoID
 func (_m *Store) DeleteRepository(ctx context.Context, repoID string) error {
 	ret := _m.Called(ctx, repoID)
@@ -422,6 +524,20 @@ func (_m *Store) DeleteRepositoryPool(ctx context.Context, repoID string, poolID
 	return r0
 }
 
+// DeleteWebhookDeliveriesOlderThan provides a mock function with given fields: ctx, olderThan
+func (_m *Store) DeleteWebhookDeliveriesOlderThan(ctx context.Context, olderThan time.Time) error {
+	ret := _m.Called(ctx, olderThan)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time) error); ok {
+		r0 = rf(ctx, olderThan)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // FindEnterprisePoolByTags provides a mock function with given fields: ctx, enterpriseID, tags
 func (_m *Store) FindEnterprisePoolByTags(ctx context.Context, enterpriseID string, tags []string) (params.Pool, error) {
 	ret := _m.Called(ctx, enterpriseID, tags)
@@ -520,23 +636,23 @@ func (_m *Store) FindRepositoryPoolByTags(ctx context.Context, repoID string, ta
 	return r0, r1
 }
 
-// GetEnterprise provides a mock function with given fields: ctx, name
-func (_m *Store) GetEnterprise(ctx context.Context, name string) (params.Enterprise, error) {
-	ret := _m.Called(ctx, name)
+// GetEnterprise provides a mock function with given fields: ctx, endpoint, name
+func (_m *Store) GetEnterprise(ctx context.Context, endpoint string, name string) (params.Enterprise, error) {
+	ret := _m.Called(ctx, endpoint, name)
 
 	var r0 params.Enterprise
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, string) (params.Enterprise, error)); ok {
-		return rf(ctx, name)
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) (params.Enterprise, error)); ok {
+		return rf(ctx, endpoint, name)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, string) params.Enterprise); ok {
-		r0 = rf(ctx, name)
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) params.Enterprise); ok {
+		r0 = rf(ctx, endpoint, name)
 	} else {
 		r0 = ret.Get(0).(params.Enterprise)
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
-		r1 = rf(ctx, name)
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, endpoint, name)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -592,6 +708,30 @@ func (_m *Store) GetEnterprisePool(ctx context.Context, enterpriseID string, poo
 	return r0, r1
 }
 
+// GetGithubCredentialsByName provides a mock function with given fields: ctx, name
+func (_m *Store) GetGithubCredentialsByName(ctx context.Context, name string) (params.GithubCredentials, error) {
+	ret := _m.Called(ctx, name)
+
+	var r0 params.GithubCredentials
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (params.GithubCredentials, error)); ok {
+		return rf(ctx, name)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) params.GithubCredentials); ok {
+		r0 = rf(ctx, name)
+	} else {
+		r0 = ret.Get(0).(params.GithubCredentials)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, name)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetInstanceByName provides a mock function with given fields: ctx, instanceName
 func (_m *Store) GetInstanceByName(ctx context.Context, instanceName string) (params.Instance, error) {
 	ret := _m.Called(ctx, instanceName)
@@ -640,23 +780,23 @@ func (_m *Store) GetJobByID(ctx context.Context, jobID int64) (params.Job, error
 	return r0, r1
 }
 
-// GetOrganization provides a mock function with given fields: ctx, name
-func (_m *Store) GetOrganization(ctx context.Context, name string) (params.Organization, error) {
-	ret := _m.Called(ctx, name)
+// GetOrganization provides a mock function with given fields: ctx, endpoint, name
+func (_m *Store) GetOrganization(ctx context.Context, endpoint string, name string) (params.Organization, error) {
+	ret := _m.Called(ctx, endpoint, name)
 
 	var r0 params.Organization
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, string) (params.Organization, error)); ok {
-		return rf(ctx, name)
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) (params.Organization, error)); ok {
+		return rf(ctx, endpoint, name)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, string) params.Organization); ok {
-		r0 = rf(ctx, name)
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) params.Organization); ok {
+		r0 = rf(ctx, endpoint, name)
 	} else {
 		r0 = ret.Get(0).(params.Organization)
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
-		r1 = rf(ctx, name)
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, endpoint, name)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -760,23 +900,23 @@ func (_m *Store) GetPoolInstanceByName(ctx context.Context, poolID string, insta
 	return r0, r1
 }
 
-// GetRepository provides a mock function with given fields: ctx, owner, name
-func (_m *Store) GetRepository(ctx context.Context, owner string, name string) (params.Repository, error) {
-	ret := _m.Called(ctx, owner, name)
+// GetRepository provides a mock function with given fields: ctx, endpoint, owner, name
+func (_m *Store) GetRepository(ctx context.Context, endpoint string, owner string, name string) (params.Repository, error) {
+	ret := _m.Called(ctx, endpoint, owner, name)
 
 	var r0 params.Repository
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, string, string) (params.Repository, error)); ok {
-		return rf(ctx, owner, name)
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string) (params.Repository, error)); ok {
+		return rf(ctx, endpoint, owner, name)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, string, string) params.Repository); ok {
-		r0 = rf(ctx, owner, name)
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string) params.Repository); ok {
+		r0 = rf(ctx, endpoint, owner, name)
 	} else {
 		r0 = ret.Get(0).(params.Repository)
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
-		r1 = rf(ctx, owner, name)
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, string) error); ok {
+		r1 = rf(ctx, endpoint, owner, name)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -880,6 +1020,54 @@ func (_m *Store) GetUserByID(ctx context.Context, userID string) (params.User, e
 	return r0, r1
 }
 
+// GetWebhookDelivery provides a mock function with given fields: ctx, deliveryID
+func (_m *Store) GetWebhookDelivery(ctx context.Context, deliveryID string) (params.WebhookDelivery, error) {
+	ret := _m.Called(ctx, deliveryID)
+
+	var r0 params.WebhookDelivery
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (params.WebhookDelivery, error)); ok {
+		return rf(ctx, deliveryID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) params.WebhookDelivery); ok {
+		r0 = rf(ctx, deliveryID)
+	} else {
+		r0 = ret.Get(0).(params.WebhookDelivery)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, deliveryID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetWebhookDeliveryHighWaterMark provides a mock function with given fields: ctx, entityType, entityID
+func (_m *Store) GetWebhookDeliveryHighWaterMark(ctx context.Context, entityType params.PoolType, entityID string) (time.Time, error) {
+	ret := _m.Called(ctx, entityType, entityID)
+
+	var r0 time.Time
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, params.PoolType, string) (time.Time, error)); ok {
+		return rf(ctx, entityType, entityID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, params.PoolType, string) time.Time); ok {
+		r0 = rf(ctx, entityType, entityID)
+	} else {
+		r0 = ret.Get(0).(time.Time)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, params.PoolType, string) error); ok {
+		r1 = rf(ctx, entityType, entityID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // HasAdminUser provides a mock function with given fields: ctx
 func (_m *Store) HasAdminUser(ctx context.Context) bool {
 	ret := _m.Called(ctx)
@@ -1100,6 +1288,32 @@ func (_m *Store) ListEntityJobsByStatus(ctx context.Context, entityType params.P
 	return r0, r1
 }
 
+// ListGithubCredentials provides a mock function with given fields: ctx
+func (_m *Store) ListGithubCredentials(ctx context.Context) ([]params.GithubCredentials, error) {
+	ret := _m.Called(ctx)
+
+	var r0 []params.GithubCredentials
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) ([]params.GithubCredentials, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) []params.GithubCredentials); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]params.GithubCredentials)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // ListInstanceEvents provides a mock function with given fields: ctx, instanceID, eventType, eventLevel
 func (_m *Store) ListInstanceEvents(ctx context.Context, instanceID string, eventType params.EventType, eventLevel params.EventLevel) ([]params.StatusMessage, error) {
 	ret := _m.Called(ctx, instanceID, eventType, eventLevel)
@@ -1230,6 +1444,32 @@ func (_m *Store) ListOrganizations(ctx context.Context) ([]params.Organization,
 	return r0, r1
 }
 
+// ListPoolDemandStats provides a mock function with given fields: ctx, poolID, from, to
+func (_m *Store) ListPoolDemandStats(ctx context.Context, poolID string, from time.Time, to time.Time) ([]params.PoolDemandStats, error) {
+	ret := _m.Called(ctx, poolID, from, to)
+
+	var r0 []params.PoolDemandStats
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, time.Time, time.Time) ([]params.PoolDemandStats, error)); ok {
+		return rf(ctx, poolID, from, to)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, time.Time, time.Time) []params.PoolDemandStats); ok {
+		r0 = rf(ctx, poolID, from, to)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]params.PoolDemandStats)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, time.Time, time.Time) error); ok {
+		r1 = rf(ctx, poolID, from, to)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // ListPoolInstances provides a mock function with given fields: ctx, poolID
 func (_m *Store) ListPoolInstances(ctx context.Context, poolID string) ([]params.Instance, error) {
 	ret := _m.Called(ctx, poolID)
@@ -1256,6 +1496,32 @@ func (_m *Store) ListPoolInstances(ctx context.Context, poolID string) ([]params
 	return r0, r1
 }
 
+// ListProviderDrains provides a mock function with given fields: ctx
+func (_m *Store) ListProviderDrains(ctx context.Context) ([]params.ProviderDrain, error) {
+	ret := _m.Called(ctx)
+
+	var r0 []params.ProviderDrain
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) ([]params.ProviderDrain, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) []params.ProviderDrain); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]params.ProviderDrain)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // ListRepoInstances provides a mock function with given fields: ctx, repoID
 func (_m *Store) ListRepoInstances(ctx context.Context, repoID string) ([]params.Instance, error) {
 	ret := _m.Called(ctx, repoID)
@@ -1334,6 +1600,58 @@ func (_m *Store) ListRepositories(ctx context.Context) ([]params.Repository, err
 	return r0, r1
 }
 
+// ListWebhookDeliveries provides a mock function with given fields: ctx
+func (_m *Store) ListWebhookDeliveries(ctx context.Context) ([]params.WebhookDelivery, error) {
+	ret := _m.Called(ctx)
+
+	var r0 []params.WebhookDelivery
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) ([]params.WebhookDelivery, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) []params.WebhookDelivery); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]params.WebhookDelivery)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListWebhookDeliveriesByStatus provides a mock function with given fields: ctx, status
+func (_m *Store) ListWebhookDeliveriesByStatus(ctx context.Context, status params.WebhookDeliveryStatus) ([]params.WebhookDelivery, error) {
+	ret := _m.Called(ctx, status)
+
+	var r0 []params.WebhookDelivery
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, params.WebhookDeliveryStatus) ([]params.WebhookDelivery, error)); ok {
+		return rf(ctx, status)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, params.WebhookDeliveryStatus) []params.WebhookDelivery); ok {
+		r0 = rf(ctx, status)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]params.WebhookDelivery)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, params.WebhookDeliveryStatus) error); ok {
+		r1 = rf(ctx, status)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // LockJob provides a mock function with given fields: ctx, jobID, entityID
 func (_m *Store) LockJob(ctx context.Context, jobID int64, entityID string) error {
 	ret := _m.Called(ctx, jobID, entityID)
@@ -1372,6 +1690,178 @@ func (_m *Store) PoolInstanceCount(ctx context.Context, poolID string) (int64, e
 	return r0, r1
 }
 
+// ProviderInstanceCountByFlavor provides a mock function with given fields: ctx, providerName
+func (_m *Store) ProviderInstanceCountByFlavor(ctx context.Context, providerName string) (map[string]uint, error) {
+	ret := _m.Called(ctx, providerName)
+
+	var r0 map[string]uint
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (map[string]uint, error)); ok {
+		return rf(ctx, providerName)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) map[string]uint); ok {
+		r0 = rf(ctx, providerName)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(map[string]uint)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, providerName)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RecordPoolJobStarted provides a mock function with given fields: ctx, poolID, startedAt, queueLatency
+func (_m *Store) RecordPoolJobStarted(ctx context.Context, poolID string, startedAt time.Time, queueLatency time.Duration) error {
+	ret := _m.Called(ctx, poolID, startedAt, queueLatency)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, time.Time, time.Duration) error); ok {
+		r0 = rf(ctx, poolID, startedAt, queueLatency)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RecordPoolQueuedJobs provides a mock function with given fields: ctx, poolID, sampledAt, queued
+func (_m *Store) RecordPoolQueuedJobs(ctx context.Context, poolID string, sampledAt time.Time, queued uint) error {
+	ret := _m.Called(ctx, poolID, sampledAt, queued)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, time.Time, uint) error); ok {
+		r0 = rf(ctx, poolID, sampledAt, queued)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RecordWebhookSeen provides a mock function with given fields: ctx, entityType, entityID, verified
+func (_m *Store) RecordWebhookSeen(ctx context.Context, entityType params.PoolType, entityID string, verified bool) error {
+	ret := _m.Called(ctx, entityType, entityID, verified)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, params.PoolType, string, bool) error); ok {
+		r0 = rf(ctx, entityType, entityID, verified)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SetOrganizationManagedHookID provides a mock function with given fields: ctx, orgID, hookID
+func (_m *Store) SetOrganizationManagedHookID(ctx context.Context, orgID string, hookID int64) error {
+	ret := _m.Called(ctx, orgID, hookID)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, int64) error); ok {
+		r0 = rf(ctx, orgID, hookID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SetPoolCircuitBreaker provides a mock function with given fields: ctx, poolID, breaker
+func (_m *Store) SetPoolCircuitBreaker(ctx context.Context, poolID string, breaker params.CircuitBreaker) (params.Pool, error) {
+	ret := _m.Called(ctx, poolID, breaker)
+
+	var r0 params.Pool
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, params.CircuitBreaker) (params.Pool, error)); ok {
+		return rf(ctx, poolID, breaker)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, params.CircuitBreaker) params.Pool); ok {
+		r0 = rf(ctx, poolID, breaker)
+	} else {
+		r0 = ret.Get(0).(params.Pool)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, params.CircuitBreaker) error); ok {
+		r1 = rf(ctx, poolID, breaker)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SetPoolDrain provides a mock function with given fields: ctx, poolID, draining
+func (_m *Store) SetPoolDrain(ctx context.Context, poolID string, draining bool) (params.Pool, error) {
+	ret := _m.Called(ctx, poolID, draining)
+
+	var r0 params.Pool
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, bool) (params.Pool, error)); ok {
+		return rf(ctx, poolID, draining)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, bool) params.Pool); ok {
+		r0 = rf(ctx, poolID, draining)
+	} else {
+		r0 = ret.Get(0).(params.Pool)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, bool) error); ok {
+		r1 = rf(ctx, poolID, draining)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SetPoolValidatedConfigRevision provides a mock function with given fields: ctx, poolID, revision
+func (_m *Store) SetPoolValidatedConfigRevision(ctx context.Context, poolID string, revision uint) error {
+	ret := _m.Called(ctx, poolID, revision)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, uint) error); ok {
+		r0 = rf(ctx, poolID, revision)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SetProviderDrain provides a mock function with given fields: ctx, providerName, draining
+func (_m *Store) SetProviderDrain(ctx context.Context, providerName string, draining bool) error {
+	ret := _m.Called(ctx, providerName, draining)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, bool) error); ok {
+		r0 = rf(ctx, providerName, draining)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SetRepositoryManagedHookID provides a mock function with given fields: ctx, repoID, hookID
+func (_m *Store) SetRepositoryManagedHookID(ctx context.Context, repoID string, hookID int64) error {
+	ret := _m.Called(ctx, repoID, hookID)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, int64) error); ok {
+		r0 = rf(ctx, repoID, hookID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // UnlockJob provides a mock function with given fields: ctx, jobID, entityID
 func (_m *Store) UnlockJob(ctx context.Context, jobID int64, entityID string) error {
 	ret := _m.Called(ctx, jobID, entityID)
@@ -1434,6 +1924,44 @@ func (_m *Store) UpdateEnterprisePool(ctx context.Context, enterpriseID string,
 	return r0, r1
 }
 
+// UpdateEntityEndpoint provides a mock function with given fields: ctx, entityType, entityID, endpoint
+func (_m *Store) UpdateEntityEndpoint(ctx context.Context, entityType params.PoolType, entityID string, endpoint string) error {
+	ret := _m.Called(ctx, entityType, entityID, endpoint)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, params.PoolType, string, string) error); ok {
+		r0 = rf(ctx, entityType, entityID, endpoint)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UpdateGithubCredentials provides a mock function with given fields: ctx, name, param
+func (_m *Store) UpdateGithubCredentials(ctx context.Context, name string, param params.UpdateGithubCredentialsParams) (params.GithubCredentials, error) {
+	ret := _m.Called(ctx, name, param)
+
+	var r0 params.GithubCredentials
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, params.UpdateGithubCredentialsParams) (params.GithubCredentials, error)); ok {
+		return rf(ctx, name, param)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, params.UpdateGithubCredentialsParams) params.GithubCredentials); ok {
+		r0 = rf(ctx, name, param)
+	} else {
+		r0 = ret.Get(0).(params.GithubCredentials)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, params.UpdateGithubCredentialsParams) error); ok {
+		r1 = rf(ctx, name, param)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // UpdateInstance provides a mock function with given fields: ctx, instanceID, param
 func (_m *Store) UpdateInstance(ctx context.Context, instanceID string, param params.UpdateInstanceParams) (params.Instance, error) {
 	ret := _m.Called(ctx, instanceID, param)
@@ -1578,6 +2106,44 @@ func (_m *Store) UpdateUser(ctx context.Context, user string, param params.Updat
 	return r0, r1
 }
 
+// UpdateWebhookDelivery provides a mock function with given fields: ctx, deliveryID, param
+func (_m *Store) UpdateWebhookDelivery(ctx context.Context, deliveryID string, param params.UpdateWebhookDeliveryParams) (params.WebhookDelivery, error) {
+	ret := _m.Called(ctx, deliveryID, param)
+
+	var r0 params.WebhookDelivery
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, params.UpdateWebhookDeliveryParams) (params.WebhookDelivery, error)); ok {
+		return rf(ctx, deliveryID, param)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, params.UpdateWebhookDeliveryParams) params.WebhookDelivery); ok {
+		r0 = rf(ctx, deliveryID, param)
+	} else {
+		r0 = ret.Get(0).(params.WebhookDelivery)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, params.UpdateWebhookDeliveryParams) error); ok {
+		r1 = rf(ctx, deliveryID, param)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UpdateWebhookDeliveryHighWaterMark provides a mock function with given fields: ctx, entityType, entityID, deliveredAt
+func (_m *Store) UpdateWebhookDeliveryHighWaterMark(ctx context.Context, entityType params.PoolType, entityID string, deliveredAt time.Time) error {
+	ret := _m.Called(ctx, entityType, entityID, deliveredAt)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, params.PoolType, string, time.Time) error); ok {
+		r0 = rf(ctx, entityType, entityID, deliveredAt)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // NewStore creates a new instance of Store. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewStore(t interface {
@@ -0,0 +1,89 @@
+// Copyright 2023 Cloudbase Solutions SRL
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package sql
+
+import (
+	"context"
+	"time"
+
+	"github.com/cloudbase/garm/database/common"
+	runnerErrors "github.com/cloudbase/garm/errors"
+	"github.com/cloudbase/garm/params"
+
+	"github.com/pkg/errors"
+)
+
+var _ common.DrainStore = &sqlDatabase{}
+
+func (s *sqlDatabase) SetPoolDrain(ctx context.Context, poolID string, draining bool) (params.Pool, error) {
+	pool, err := s.getPoolByID(ctx, poolID, "Tags", "Instances", "Enterprise", "Organization", "Repository")
+	if err != nil {
+		return params.Pool{}, errors.Wrap(err, "fetching pool")
+	}
+
+	var drainStartedAt *time.Time
+	if draining {
+		drainStartedAt = pool.DrainStartedAt
+		if drainStartedAt == nil {
+			now := time.Now().UTC()
+			drainStartedAt = &now
+		}
+	}
+
+	if q := s.conn.Model(&pool).Update("drain_started_at", drainStartedAt); q.Error != nil {
+		return params.Pool{}, errors.Wrap(q.Error, "updating pool")
+	}
+	pool.DrainStartedAt = drainStartedAt
+
+	return s.sqlToCommonPool(pool), nil
+}
+
+func (s *sqlDatabase) SetProviderDrain(ctx context.Context, providerName string, draining bool) error {
+	if providerName == "" {
+		return errors.Wrap(runnerErrors.ErrBadRequest, "missing provider name")
+	}
+
+	if !draining {
+		if q := s.conn.Where("provider_name = ?", providerName).Delete(&ProviderDrain{}); q.Error != nil {
+			return errors.Wrap(q.Error, "removing provider drain")
+		}
+		return nil
+	}
+
+	drain := ProviderDrain{}
+	q := s.conn.Where(ProviderDrain{ProviderName: providerName}).
+		Attrs(ProviderDrain{StartedAt: time.Now().UTC()}).
+		FirstOrCreate(&drain)
+	if q.Error != nil {
+		return errors.Wrap(q.Error, "saving provider drain")
+	}
+	return nil
+}
+
+func (s *sqlDatabase) ListProviderDrains(ctx context.Context) ([]params.ProviderDrain, error) {
+	var drains []ProviderDrain
+	if q := s.conn.Model(&ProviderDrain{}).Find(&drains); q.Error != nil {
+		return nil, errors.Wrap(q.Error, "fetching provider drains")
+	}
+
+	ret := make([]params.ProviderDrain, len(drains))
+	for idx, drain := range drains {
+		ret[idx] = params.ProviderDrain{
+			ProviderName: drain.ProviderName,
+			StartedAt:    drain.StartedAt.UTC(),
+		}
+	}
+	return ret, nil
+}
@@ -0,0 +1,146 @@
+// Copyright 2023 Cloudbase Solutions SRL
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package sql
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	dbCommon "github.com/cloudbase/garm/database/common"
+	runnerErrors "github.com/cloudbase/garm/errors"
+	garmTesting "github.com/cloudbase/garm/internal/testing"
+	"github.com/cloudbase/garm/params"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type DrainTestSuite struct {
+	suite.Suite
+	Store dbCommon.Store
+	Pool  params.Pool
+}
+
+func (s *DrainTestSuite) SetupTest() {
+	db, err := NewSQLDatabase(context.Background(), garmTesting.GetTestSqliteDBConfig(s.T()))
+	if err != nil {
+		s.FailNow(fmt.Sprintf("failed to create db connection: %s", err))
+	}
+	s.Store = db
+
+	org, err := db.CreateOrganization(context.Background(), "https://github.com", "test-org", "test-creds", "test-webhookSecret")
+	if err != nil {
+		s.FailNow(fmt.Sprintf("failed to create org: %s", err))
+	}
+
+	pool, err := db.CreateOrganizationPool(
+		context.Background(),
+		org.ID,
+		params.CreatePoolParams{
+			ProviderName:   "test-provider",
+			MaxRunners:     4,
+			MinIdleRunners: 2,
+			Image:          "test-image",
+			Flavor:         "test-flavor",
+			OSType:         "linux",
+			Tags:           []string{"self-hosted", "amd64", "linux"},
+		},
+	)
+	if err != nil {
+		s.FailNow(fmt.Sprintf("cannot create org pool: %v", err))
+	}
+	s.Pool = pool
+}
+
+func (s *DrainTestSuite) TestSetPoolDrain() {
+	pool, err := s.Store.SetPoolDrain(context.Background(), s.Pool.ID, true)
+
+	s.Require().Nil(err)
+	s.Require().True(pool.Draining)
+	s.Require().NotNil(pool.DrainStartedAt)
+	s.Require().Equal(s.Pool.Tags, pool.Tags)
+
+	stored, err := s.Store.GetPoolByID(context.Background(), s.Pool.ID)
+	s.Require().Nil(err)
+	s.Require().True(stored.Draining)
+	s.Require().True(pool.DrainStartedAt.Equal(*stored.DrainStartedAt))
+}
+
+func (s *DrainTestSuite) TestSetPoolDrainKeepsStartTime() {
+	pool, err := s.Store.SetPoolDrain(context.Background(), s.Pool.ID, true)
+	s.Require().Nil(err)
+
+	again, err := s.Store.SetPoolDrain(context.Background(), s.Pool.ID, true)
+
+	s.Require().Nil(err)
+	s.Require().True(pool.DrainStartedAt.Equal(*again.DrainStartedAt))
+}
+
+func (s *DrainTestSuite) TestSetPoolDrainStop() {
+	_, err := s.Store.SetPoolDrain(context.Background(), s.Pool.ID, true)
+	s.Require().Nil(err)
+
+	pool, err := s.Store.SetPoolDrain(context.Background(), s.Pool.ID, false)
+
+	s.Require().Nil(err)
+	s.Require().False(pool.Draining)
+	s.Require().Nil(pool.DrainStartedAt)
+
+	stored, err := s.Store.GetPoolByID(context.Background(), s.Pool.ID)
+	s.Require().Nil(err)
+	s.Require().False(stored.Draining)
+}
+
+func (s *DrainTestSuite) TestSetPoolDrainInvalidPoolID() {
+	_, err := s.Store.SetPoolDrain(context.Background(), "dummy-pool-id", true)
+
+	s.Require().ErrorIs(err, runnerErrors.ErrBadRequest)
+}
+
+func (s *DrainTestSuite) TestSetProviderDrain() {
+	err := s.Store.SetProviderDrain(context.Background(), "test-provider", true)
+	s.Require().Nil(err)
+
+	drains, err := s.Store.ListProviderDrains(context.Background())
+	s.Require().Nil(err)
+	s.Require().Len(drains, 1)
+	s.Require().Equal("test-provider", drains[0].ProviderName)
+	startedAt := drains[0].StartedAt
+
+	// Starting a drain again keeps the original start time.
+	err = s.Store.SetProviderDrain(context.Background(), "test-provider", true)
+	s.Require().Nil(err)
+	drains, err = s.Store.ListProviderDrains(context.Background())
+	s.Require().Nil(err)
+	s.Require().Len(drains, 1)
+	s.Require().True(startedAt.Equal(drains[0].StartedAt))
+
+	err = s.Store.SetProviderDrain(context.Background(), "test-provider", false)
+	s.Require().Nil(err)
+	drains, err = s.Store.ListProviderDrains(context.Background())
+	s.Require().Nil(err)
+	s.Require().Len(drains, 0)
+}
+
+func (s *DrainTestSuite) TestSetProviderDrainMissingName() {
+	err := s.Store.SetProviderDrain(context.Background(), "", true)
+
+	s.Require().ErrorIs(err, runnerErrors.ErrBadRequest)
+}
+
+func TestDrainTestSuite(t *testing.T) {
+	t.Parallel()
+	suite.Run(t, new(DrainTestSuite))
+}
@@ -13,7 +13,7 @@ import (
 	"gorm.io/gorm"
 )
 
-func (s *sqlDatabase) CreateEnterprise(ctx context.Context, name, credentialsName, webhookSecret string) (params.Enterprise, error) {
+func (s *sqlDatabase) CreateEnterprise(ctx context.Context, endpoint, name, credentialsName, webhookSecret string) (params.Enterprise, error) {
 	if webhookSecret == "" {
 		return params.Enterprise{}, errors.New("creating enterprise: missing secret")
 	}
@@ -22,6 +22,7 @@ func (s *sqlDatabase) CreateEnterprise(ctx context.Context, name, credentialsNam
 		return params.Enterprise{}, errors.Wrap(err, "encoding secret")
 	}
 	newEnterprise := Enterprise{
+		Endpoint:        endpoint,
 		Name:            name,
 		WebhookSecret:   secret,
 		CredentialsName: credentialsName,
@@ -40,8 +41,8 @@ func (s *sqlDatabase) CreateEnterprise(ctx context.Context, name, credentialsNam
 	return param, nil
 }
 
-func (s *sqlDatabase) GetEnterprise(ctx context.Context, name string) (params.Enterprise, error) {
-	enterprise, err := s.getEnterprise(ctx, name)
+func (s *sqlDatabase) GetEnterprise(ctx context.Context, endpoint, name string) (params.Enterprise, error) {
+	enterprise, err := s.getEnterprise(ctx, endpoint, name)
 	if err != nil {
 		return params.Enterprise{}, errors.Wrap(err, "fetching enterprise")
 	}
@@ -117,6 +118,14 @@ func (s *sqlDatabase) UpdateEnterprise(ctx context.Context, enterpriseID string,
 		enterprise.WebhookSecret = secret
 	}
 
+	if param.PoolSelectionStrategy != "" {
+		enterprise.PoolSelectionStrategy = param.PoolSelectionStrategy
+	}
+
+	if param.JobSchedulingStrategy != "" {
+		enterprise.JobSchedulingStrategy = param.JobSchedulingStrategy
+	}
+
 	q := s.conn.Save(&enterprise)
 	if q.Error != nil {
 		return params.Enterprise{}, errors.Wrap(q.Error, "saving enterprise")
@@ -140,23 +149,48 @@ func (s *sqlDatabase) CreateEnterprisePool(ctx context.Context, enterpriseID str
 	}
 
 	newPool := Pool{
-		ProviderName:           param.ProviderName,
-		MaxRunners:             param.MaxRunners,
-		MinIdleRunners:         param.MinIdleRunners,
-		RunnerPrefix:           param.GetRunnerPrefix(),
-		Image:                  param.Image,
-		Flavor:                 param.Flavor,
-		OSType:                 param.OSType,
-		OSArch:                 param.OSArch,
-		EnterpriseID:           &enterprise.ID,
-		Enabled:                param.Enabled,
-		RunnerBootstrapTimeout: param.RunnerBootstrapTimeout,
+		ProviderName:            param.ProviderName,
+		MaxRunners:              param.MaxRunners,
+		MinIdleRunners:          param.MinIdleRunners,
+		WarmRunners:             param.WarmRunners,
+		RunnerPrefix:            param.GetRunnerPrefix(),
+		Image:                   param.Image,
+		Flavor:                  param.Flavor,
+		OSType:                  param.OSType,
+		OSArch:                  param.OSArch,
+		EnterpriseID:            &enterprise.ID,
+		Enabled:                 param.Enabled,
+		RunnerBootstrapTimeout:  param.RunnerBootstrapTimeout,
+		AutoMinIdleRunners:      param.AutoMinIdleRunners,
+		Priority:                param.Priority,
+		Weight:                  param.Weight,
+		MaxSurge:                param.MaxSurge,
+		ReplacementCanary:       param.ReplacementCanary,
+		IdleTimeout:             param.IdleTimeout,
+		MaxLifetime:             param.MaxLifetime,
+		CircuitBreakerThreshold: param.CircuitBreakerThreshold,
+		CircuitBreakerWindow:    param.CircuitBreakerWindow,
+		MaxRunnersPerRepository: param.MaxRunnersPerRepository,
 	}
 
 	if len(param.ExtraSpecs) > 0 {
 		newPool.ExtraSpecs = datatypes.JSON(param.ExtraSpecs)
 	}
 
+	if len(param.ScalingSchedules) > 0 {
+		newPool.ScalingSchedules, err = scalingSchedulesToJSON(param.ScalingSchedules)
+		if err != nil {
+			return params.Pool{}, errors.Wrap(err, "creating pool")
+		}
+	}
+
+	if len(param.FallbackProviders) > 0 {
+		newPool.FallbackProviders, err = fallbackProvidersToJSON(param.FallbackProviders)
+		if err != nil {
+			return params.Pool{}, errors.Wrap(err, "creating pool")
+		}
+	}
+
 	_, err = s.getEnterprisePoolByUniqueFields(ctx, enterpriseID, newPool.ProviderName, newPool.Image, newPool.Flavor)
 	if err != nil {
 		if !errors.Is(err, runnerErrors.ErrNotFound) {
@@ -259,10 +293,10 @@ func (s *sqlDatabase) ListEnterpriseInstances(ctx context.Context, enterpriseID
 	return ret, nil
 }
 
-func (s *sqlDatabase) getEnterprise(ctx context.Context, name string) (Enterprise, error) {
+func (s *sqlDatabase) getEnterprise(ctx context.Context, endpoint, name string) (Enterprise, error) {
 	var enterprise Enterprise
 
-	q := s.conn.Where("name = ? COLLATE NOCASE", name)
+	q := s.conn.Where("name = ? COLLATE NOCASE and endpoint = ? COLLATE NOCASE", name, endpoint)
 	q = q.First(&enterprise)
 	if q.Error != nil {
 		if errors.Is(q.Error, gorm.ErrRecordNotFound) {
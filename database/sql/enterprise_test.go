@@ -83,6 +83,7 @@ func (s *EnterpriseTestSuite) SetupTest() {
 	for i := 1; i <= 3; i++ {
 		enterprise, err := db.CreateEnterprise(
 			context.Background(),
+			"https://github.com",
 			fmt.Sprintf("test-enterprise-%d", i),
 			fmt.Sprintf("test-creds-%d", i),
 			fmt.Sprintf("test-webhook-secret-%d", i),
@@ -161,6 +162,7 @@ func (s *EnterpriseTestSuite) TestCreateEnterprise() {
 	// call tested function
 	enterprise, err := s.Store.CreateEnterprise(
 		context.Background(),
+		"https://github.com",
 		s.Fixtures.CreateEnterpriseParams.Name,
 		s.Fixtures.CreateEnterpriseParams.CredentialsName,
 		s.Fixtures.CreateEnterpriseParams.WebhookSecret)
@@ -191,6 +193,7 @@ func (s *EnterpriseTestSuite) TestCreateEnterpriseInvalidDBPassphrase() {
 
 	_, err = sqlDB.CreateEnterprise(
 		context.Background(),
+		"https://github.com",
 		s.Fixtures.CreateEnterpriseParams.Name,
 		s.Fixtures.CreateEnterpriseParams.CredentialsName,
 		s.Fixtures.CreateEnterpriseParams.WebhookSecret)
@@ -208,6 +211,7 @@ func (s *EnterpriseTestSuite) TestCreateEnterpriseDBCreateErr() {
 
 	_, err := s.StoreSQLMocked.CreateEnterprise(
 		context.Background(),
+		"https://github.com",
 		s.Fixtures.CreateEnterpriseParams.Name,
 		s.Fixtures.CreateEnterpriseParams.CredentialsName,
 		s.Fixtures.CreateEnterpriseParams.WebhookSecret)
@@ -218,7 +222,7 @@ func (s *EnterpriseTestSuite) TestCreateEnterpriseDBCreateErr() {
 }
 
 func (s *EnterpriseTestSuite) TestGetEnterprise() {
-	enterprise, err := s.Store.GetEnterprise(context.Background(), s.Fixtures.Enterprises[0].Name)
+	enterprise, err := s.Store.GetEnterprise(context.Background(), "https://github.com", s.Fixtures.Enterprises[0].Name)
 
 	s.Require().Nil(err)
 	s.Require().Equal(s.Fixtures.Enterprises[0].Name, enterprise.Name)
@@ -226,14 +230,21 @@ func (s *EnterpriseTestSuite) TestGetEnterprise() {
 }
 
 func (s *EnterpriseTestSuite) TestGetEnterpriseCaseInsensitive() {
-	enterprise, err := s.Store.GetEnterprise(context.Background(), "TeSt-eNtErPriSe-1")
+	enterprise, err := s.Store.GetEnterprise(context.Background(), "https://github.com", "TeSt-eNtErPriSe-1")
 
 	s.Require().Nil(err)
 	s.Require().Equal("test-enterprise-1", enterprise.Name)
 }
 
+func (s *EnterpriseTestSuite) TestGetEnterpriseOtherEndpoint() {
+	_, err := s.Store.GetEnterprise(context.Background(), "https://ghes.example.com", s.Fixtures.Enterprises[0].Name)
+
+	s.Require().NotNil(err)
+	s.Require().Equal("fetching enterprise: not found", err.Error())
+}
+
 func (s *EnterpriseTestSuite) TestGetEnterpriseNotFound() {
-	_, err := s.Store.GetEnterprise(context.Background(), "dummy-name")
+	_, err := s.Store.GetEnterprise(context.Background(), "https://github.com", "dummy-name")
 
 	s.Require().NotNil(err)
 	s.Require().Equal("fetching enterprise: not found", err.Error())
@@ -241,11 +252,11 @@ func (s *EnterpriseTestSuite) TestGetEnterpriseNotFound() {
 
 func (s *EnterpriseTestSuite) TestGetEnterpriseDBDecryptingErr() {
 	s.Fixtures.SQLMock.
-		ExpectQuery(regexp.QuoteMeta("SELECT * FROM `enterprises` WHERE name = ? COLLATE NOCASE AND `enterprises`.`deleted_at` IS NULL ORDER BY `enterprises`.`id` LIMIT 1")).
-		WithArgs(s.Fixtures.Enterprises[0].Name).
+		ExpectQuery(regexp.QuoteMeta("SELECT * FROM `enterprises` WHERE (name = ? COLLATE NOCASE and endpoint = ? COLLATE NOCASE) AND `enterprises`.`deleted_at` IS NULL ORDER BY `enterprises`.`id` LIMIT 1")).
+		WithArgs(s.Fixtures.Enterprises[0].Name, "https://github.com").
 		WillReturnRows(sqlmock.NewRows([]string{"name"}).AddRow(s.Fixtures.Enterprises[0].Name))
 
-	_, err := s.StoreSQLMocked.GetEnterprise(context.Background(), s.Fixtures.Enterprises[0].Name)
+	_, err := s.StoreSQLMocked.GetEnterprise(context.Background(), "https://github.com", s.Fixtures.Enterprises[0].Name)
 
 	s.assertSQLMockExpectations()
 	s.Require().NotNil(err)
@@ -0,0 +1,57 @@
+// Copyright 2023 Cloudbase Solutions SRL
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package sql
+
+import (
+	"context"
+
+	runnerErrors "github.com/cloudbase/garm/errors"
+	"github.com/cloudbase/garm/params"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+)
+
+func (s *sqlDatabase) UpdateEntityEndpoint(ctx context.Context, entityType params.PoolType, entityID, endpoint string) error {
+	u, err := uuid.Parse(entityID)
+	if err != nil {
+		return errors.Wrap(runnerErrors.ErrBadRequest, "parsing id")
+	}
+
+	model, err := entityModel(entityType)
+	if err != nil {
+		return err
+	}
+
+	q := s.conn.Model(model).Where("id = ?", u).UpdateColumn("endpoint", endpoint)
+	if q.Error != nil {
+		return errors.Wrap(q.Error, "updating endpoint")
+	}
+	return nil
+}
+
+// entityModel returns an empty model for the given entity type, to be used in queries.
+func entityModel(entityType params.PoolType) (interface{}, error) {
+	switch entityType {
+	case params.RepositoryPool:
+		return &Repository{}, nil
+	case params.OrganizationPool:
+		return &Organization{}, nil
+	case params.EnterprisePool:
+		return &Enterprise{}, nil
+	default:
+		return nil, runnerErrors.NewBadRequestError("invalid entity type %s", entityType)
+	}
+}
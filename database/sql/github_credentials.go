@@ -0,0 +1,251 @@
+// Copyright 2023 Cloudbase Solutions SRL
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package sql
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/cloudbase/garm/config"
+	runnerErrors "github.com/cloudbase/garm/errors"
+	"github.com/cloudbase/garm/params"
+	"github.com/cloudbase/garm/util"
+
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+func (s *sqlDatabase) CreateGithubCredentials(ctx context.Context, param params.CreateGithubCredentialsParams) (params.GithubCredentials, error) {
+	newCreds := GithubCredentials{
+		Name:          param.Name,
+		Description:   param.Description,
+		AuthType:      param.GetAuthType(),
+		BaseURL:       param.BaseURL,
+		APIBaseURL:    param.APIBaseURL,
+		UploadBaseURL: param.UploadBaseURL,
+		CABundle:      param.CABundle,
+		MaxRunners:    param.MaxRunners,
+	}
+
+	switch newCreds.AuthType {
+	case params.GithubAuthTypePAT:
+		token, err := util.Aes256EncodeString(param.OAuth2Token, s.cfg.Passphrase)
+		if err != nil {
+			return params.GithubCredentials{}, fmt.Errorf("failed to encrypt token")
+		}
+		newCreds.OAuth2Token = token
+	case params.GithubAuthTypeApp:
+		privateKey, err := util.Aes256EncodeString(string(param.App.PrivateKey), s.cfg.Passphrase)
+		if err != nil {
+			return params.GithubCredentials{}, fmt.Errorf("failed to encrypt private key")
+		}
+		webhookSecret, err := s.encryptAppWebhookSecret(param.App.WebhookSecret)
+		if err != nil {
+			return params.GithubCredentials{}, fmt.Errorf("failed to encrypt webhook secret")
+		}
+		newCreds.AppID = param.App.AppID
+		newCreds.InstallationID = param.App.InstallationID
+		newCreds.AppPrivateKey = privateKey
+		newCreds.AppWebhookSecret = webhookSecret
+	}
+
+	q := s.conn.Create(&newCreds)
+	if q.Error != nil {
+		return params.GithubCredentials{}, errors.Wrap(q.Error, "creating github credentials")
+	}
+
+	ret, err := s.sqlToCommonGithubCredentials(newCreds)
+	if err != nil {
+		return params.GithubCredentials{}, errors.Wrap(err, "creating github credentials")
+	}
+	return ret, nil
+}
+
+func (s *sqlDatabase) GetGithubCredentialsByName(ctx context.Context, name string) (params.GithubCredentials, error) {
+	creds, err := s.getGithubCredentialsByName(name)
+	if err != nil {
+		return params.GithubCredentials{}, errors.Wrap(err, "fetching github credentials")
+	}
+
+	ret, err := s.sqlToCommonGithubCredentials(creds)
+	if err != nil {
+		return params.GithubCredentials{}, errors.Wrap(err, "fetching github credentials")
+	}
+	return ret, nil
+}
+
+func (s *sqlDatabase) ListGithubCredentials(ctx context.Context) ([]params.GithubCredentials, error) {
+	var creds []GithubCredentials
+	q := s.conn.Find(&creds)
+	if q.Error != nil {
+		return nil, errors.Wrap(q.Error, "fetching github credentials")
+	}
+
+	ret := make([]params.GithubCredentials, len(creds))
+	for idx, val := range creds {
+		var err error
+		ret[idx], err = s.sqlToCommonGithubCredentials(val)
+		if err != nil {
+			return nil, errors.Wrap(err, "fetching github credentials")
+		}
+	}
+	return ret, nil
+}
+
+func (s *sqlDatabase) UpdateGithubCredentials(ctx context.Context, name string, param params.UpdateGithubCredentialsParams) (params.GithubCredentials, error) {
+	creds, err := s.getGithubCredentialsByName(name)
+	if err != nil {
+		return params.GithubCredentials{}, errors.Wrap(err, "fetching github credentials")
+	}
+
+	if param.Description != nil {
+		creds.Description = *param.Description
+	}
+
+	if param.BaseURL != nil {
+		creds.BaseURL = *param.BaseURL
+	}
+
+	if param.APIBaseURL != nil {
+		creds.APIBaseURL = *param.APIBaseURL
+	}
+
+	if param.UploadBaseURL != nil {
+		creds.UploadBaseURL = *param.UploadBaseURL
+	}
+
+	if len(param.CABundle) > 0 {
+		creds.CABundle = param.CABundle
+	}
+
+	if param.MaxRunners != nil {
+		creds.MaxRunners = *param.MaxRunners
+	}
+
+	if param.OAuth2Token != "" {
+		token, err := util.Aes256EncodeString(param.OAuth2Token, s.cfg.Passphrase)
+		if err != nil {
+			return params.GithubCredentials{}, fmt.Errorf("saving github credentials: failed to encrypt token: %w", err)
+		}
+		creds.OAuth2Token = token
+	}
+
+	if param.App != nil {
+		privateKey, err := util.Aes256EncodeString(string(param.App.PrivateKey), s.cfg.Passphrase)
+		if err != nil {
+			return params.GithubCredentials{}, fmt.Errorf("saving github credentials: failed to encrypt private key: %w", err)
+		}
+		webhookSecret, err := s.encryptAppWebhookSecret(param.App.WebhookSecret)
+		if err != nil {
+			return params.GithubCredentials{}, fmt.Errorf("saving github credentials: failed to encrypt webhook secret: %w", err)
+		}
+		creds.AppID = param.App.AppID
+		creds.InstallationID = param.App.InstallationID
+		creds.AppPrivateKey = privateKey
+		creds.AppWebhookSecret = webhookSecret
+	}
+
+	q := s.conn.Save(&creds)
+	if q.Error != nil {
+		return params.GithubCredentials{}, errors.Wrap(q.Error, "saving github credentials")
+	}
+
+	ret, err := s.sqlToCommonGithubCredentials(creds)
+	if err != nil {
+		return params.GithubCredentials{}, errors.Wrap(err, "saving github credentials")
+	}
+	return ret, nil
+}
+
+func (s *sqlDatabase) DeleteGithubCredentials(ctx context.Context, name string) error {
+	creds, err := s.getGithubCredentialsByName(name)
+	if err != nil {
+		return errors.Wrap(err, "fetching github credentials")
+	}
+
+	q := s.conn.Unscoped().Delete(&creds)
+	if q.Error != nil && !errors.Is(q.Error, gorm.ErrRecordNotFound) {
+		return errors.Wrap(q.Error, "deleting github credentials")
+	}
+	return nil
+}
+
+func (s *sqlDatabase) getGithubCredentialsByName(name string) (GithubCredentials, error) {
+	var creds GithubCredentials
+	q := s.conn.Where("name = ?", name).First(&creds)
+	if q.Error != nil {
+		if errors.Is(q.Error, gorm.ErrRecordNotFound) {
+			return GithubCredentials{}, runnerErrors.ErrNotFound
+		}
+		return GithubCredentials{}, errors.Wrap(q.Error, "fetching github credentials from database")
+	}
+	return creds, nil
+}
+
+func (s *sqlDatabase) sqlToCommonGithubCredentials(creds GithubCredentials) (params.GithubCredentials, error) {
+	// Apply the same endpoint defaults we use for credentials defined in the config file.
+	endpoints := config.Github{
+		BaseURL:       creds.BaseURL,
+		APIBaseURL:    creds.APIBaseURL,
+		UploadBaseURL: creds.UploadBaseURL,
+	}
+
+	ret := params.GithubCredentials{
+		Name:          creds.Name,
+		Description:   creds.Description,
+		BaseURL:       endpoints.BaseEndpoint(),
+		APIBaseURL:    endpoints.APIEndpoint(),
+		UploadBaseURL: endpoints.UploadEndpoint(),
+		CABundle:      creds.CABundle,
+		AuthType:      creds.AuthType,
+		MaxRunners:    creds.MaxRunners,
+	}
+
+	switch creds.AuthType {
+	case params.GithubAuthTypePAT:
+		token, err := util.Aes256DecodeString(creds.OAuth2Token, s.cfg.Passphrase)
+		if err != nil {
+			return params.GithubCredentials{}, errors.Wrap(err, "decrypting token")
+		}
+		ret.OAuth2Token = token
+	case params.GithubAuthTypeApp:
+		privateKey, err := util.Aes256DecodeString(creds.AppPrivateKey, s.cfg.Passphrase)
+		if err != nil {
+			return params.GithubCredentials{}, errors.Wrap(err, "decrypting private key")
+		}
+		ret.App = params.GithubApp{
+			AppID:          creds.AppID,
+			InstallationID: creds.InstallationID,
+			PrivateKey:     []byte(privateKey),
+		}
+		if len(creds.AppWebhookSecret) > 0 {
+			webhookSecret, err := util.Aes256DecodeString(creds.AppWebhookSecret, s.cfg.Passphrase)
+			if err != nil {
+				return params.GithubCredentials{}, errors.Wrap(err, "decrypting webhook secret")
+			}
+			ret.App.WebhookSecret = webhookSecret
+		}
+	}
+	return ret, nil
+}
+
+// encryptAppWebhookSecret encrypts the webhook secret of a github app. The webhook
+// secret is optional, and is left empty if it is not set.
+func (s *sqlDatabase) encryptAppWebhookSecret(secret string) ([]byte, error) {
+	if secret == "" {
+		return nil, nil
+	}
+	return util.Aes256EncodeString(secret, s.cfg.Passphrase)
+}
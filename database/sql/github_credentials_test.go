@@ -0,0 +1,188 @@
+// Copyright 2023 Cloudbase Solutions SRL
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package sql
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	dbCommon "github.com/cloudbase/garm/database/common"
+	runnerErrors "github.com/cloudbase/garm/errors"
+	garmTesting "github.com/cloudbase/garm/internal/testing"
+	"github.com/cloudbase/garm/params"
+	"github.com/cloudbase/garm/util/appdefaults"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type GithubCredentialsTestFixtures struct {
+	Credentials             []params.GithubCredentials
+	CreateCredentialsParams params.CreateGithubCredentialsParams
+}
+
+type GithubCredentialsTestSuite struct {
+	suite.Suite
+	Store    dbCommon.Store
+	Fixtures *GithubCredentialsTestFixtures
+}
+
+func (s *GithubCredentialsTestSuite) SetupTest() {
+	// create testing sqlite database
+	db, err := NewSQLDatabase(context.Background(), garmTesting.GetTestSqliteDBConfig(s.T()))
+	if err != nil {
+		s.FailNow(fmt.Sprintf("failed to create db connection: %s", err))
+	}
+	s.Store = db
+
+	// create some credentials objects in the database, for testing purposes
+	creds := []params.GithubCredentials{}
+	for i := 1; i <= 3; i++ {
+		cred, err := db.CreateGithubCredentials(
+			context.Background(),
+			params.CreateGithubCredentialsParams{
+				Name:        fmt.Sprintf("test-creds-%d", i),
+				Description: fmt.Sprintf("test-description-%d", i),
+				OAuth2Token: fmt.Sprintf("test-token-%d", i),
+			},
+		)
+		if err != nil {
+			s.FailNow(fmt.Sprintf("failed to create database object (test-creds-%d): %s", i, err))
+		}
+		creds = append(creds, cred)
+	}
+
+	s.Fixtures = &GithubCredentialsTestFixtures{
+		Credentials: creds,
+		CreateCredentialsParams: params.CreateGithubCredentialsParams{
+			Name:        "new-creds",
+			Description: "new-creds-description",
+			OAuth2Token: "new-creds-token",
+			BaseURL:     "https://ghe.example.com",
+			APIBaseURL:  "https://ghe.example.com/api/v3/",
+		},
+	}
+}
+
+func (s *GithubCredentialsTestSuite) TestCreateGithubCredentials() {
+	creds, err := s.Store.CreateGithubCredentials(context.Background(), s.Fixtures.CreateCredentialsParams)
+
+	s.Require().Nil(err)
+	s.Require().Equal(s.Fixtures.CreateCredentialsParams.Name, creds.Name)
+	s.Require().Equal(s.Fixtures.CreateCredentialsParams.Description, creds.Description)
+	s.Require().Equal(s.Fixtures.CreateCredentialsParams.OAuth2Token, creds.OAuth2Token)
+	s.Require().Equal(params.GithubAuthTypePAT, creds.AuthType)
+	s.Require().Equal(s.Fixtures.CreateCredentialsParams.BaseURL, creds.BaseURL)
+	s.Require().Equal(s.Fixtures.CreateCredentialsParams.APIBaseURL, creds.APIBaseURL)
+	// The upload URL defaults to the API URL.
+	s.Require().Equal(s.Fixtures.CreateCredentialsParams.APIBaseURL, creds.UploadBaseURL)
+}
+
+func (s *GithubCredentialsTestSuite) TestCreateGithubCredentialsApp() {
+	s.Fixtures.CreateCredentialsParams.AuthType = params.GithubAuthTypeApp
+	s.Fixtures.CreateCredentialsParams.OAuth2Token = ""
+	s.Fixtures.CreateCredentialsParams.App = params.GithubApp{
+		AppID:          1,
+		InstallationID: 2,
+		PrivateKey:     []byte("test-private-key"),
+		WebhookSecret:  "test-webhook-secret",
+	}
+
+	_, err := s.Store.CreateGithubCredentials(context.Background(), s.Fixtures.CreateCredentialsParams)
+	s.Require().Nil(err)
+
+	creds, err := s.Store.GetGithubCredentialsByName(context.Background(), s.Fixtures.CreateCredentialsParams.Name)
+	s.Require().Nil(err)
+	s.Require().Equal(params.GithubAuthTypeApp, creds.AuthType)
+	s.Require().Equal(s.Fixtures.CreateCredentialsParams.App, creds.App)
+	s.Require().Empty(creds.OAuth2Token)
+}
+
+func (s *GithubCredentialsTestSuite) TestCreateGithubCredentialsDuplicateName() {
+	s.Fixtures.CreateCredentialsParams.Name = s.Fixtures.Credentials[0].Name
+
+	_, err := s.Store.CreateGithubCredentials(context.Background(), s.Fixtures.CreateCredentialsParams)
+
+	s.Require().NotNil(err)
+}
+
+func (s *GithubCredentialsTestSuite) TestGetGithubCredentialsByName() {
+	creds, err := s.Store.GetGithubCredentialsByName(context.Background(), s.Fixtures.Credentials[0].Name)
+
+	s.Require().Nil(err)
+	s.Require().Equal(s.Fixtures.Credentials[0], creds)
+	s.Require().Equal("test-token-1", creds.OAuth2Token)
+	s.Require().Equal(appdefaults.DefaultGithubURL, creds.BaseURL)
+	s.Require().Equal(appdefaults.GithubDefaultBaseURL, creds.APIBaseURL)
+	s.Require().Equal(appdefaults.GithubDefaultUploadBaseURL, creds.UploadBaseURL)
+}
+
+func (s *GithubCredentialsTestSuite) TestGetGithubCredentialsByNameNotFound() {
+	_, err := s.Store.GetGithubCredentialsByName(context.Background(), "dummy-name")
+
+	s.Require().NotNil(err)
+	s.Require().ErrorIs(err, runnerErrors.ErrNotFound)
+}
+
+func (s *GithubCredentialsTestSuite) TestListGithubCredentials() {
+	creds, err := s.Store.ListGithubCredentials(context.Background())
+
+	s.Require().Nil(err)
+	s.Require().Equal(s.Fixtures.Credentials, creds)
+}
+
+func (s *GithubCredentialsTestSuite) TestUpdateGithubCredentials() {
+	description := "updated-description"
+	updateParams := params.UpdateGithubCredentialsParams{
+		Description: &description,
+		OAuth2Token: "updated-token",
+	}
+
+	creds, err := s.Store.UpdateGithubCredentials(context.Background(), s.Fixtures.Credentials[0].Name, updateParams)
+
+	s.Require().Nil(err)
+	s.Require().Equal(description, creds.Description)
+	s.Require().Equal("updated-token", creds.OAuth2Token)
+
+	stored, err := s.Store.GetGithubCredentialsByName(context.Background(), s.Fixtures.Credentials[0].Name)
+	s.Require().Nil(err)
+	s.Require().Equal(creds, stored)
+}
+
+func (s *GithubCredentialsTestSuite) TestUpdateGithubCredentialsNotFound() {
+	_, err := s.Store.UpdateGithubCredentials(context.Background(), "dummy-name", params.UpdateGithubCredentialsParams{})
+
+	s.Require().NotNil(err)
+	s.Require().ErrorIs(err, runnerErrors.ErrNotFound)
+}
+
+func (s *GithubCredentialsTestSuite) TestDeleteGithubCredentials() {
+	err := s.Store.DeleteGithubCredentials(context.Background(), s.Fixtures.Credentials[0].Name)
+
+	s.Require().Nil(err)
+	_, err = s.Store.GetGithubCredentialsByName(context.Background(), s.Fixtures.Credentials[0].Name)
+	s.Require().ErrorIs(err, runnerErrors.ErrNotFound)
+}
+
+func (s *GithubCredentialsTestSuite) TestDeleteGithubCredentialsNotFound() {
+	err := s.Store.DeleteGithubCredentials(context.Background(), "dummy-name")
+
+	s.Require().NotNil(err)
+	s.Require().ErrorIs(err, runnerErrors.ErrNotFound)
+}
+
+func TestGithubCredentialsTestSuite(t *testing.T) {
+	suite.Run(t, new(GithubCredentialsTestSuite))
+}
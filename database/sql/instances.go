@@ -43,16 +43,18 @@ func (s *sqlDatabase) CreateInstance(ctx context.Context, poolID string, param p
 	}
 
 	newInstance := Instance{
-		Pool:              pool,
-		Name:              param.Name,
-		Status:            param.Status,
-		RunnerStatus:      param.RunnerStatus,
-		OSType:            param.OSType,
-		OSArch:            param.OSArch,
-		CallbackURL:       param.CallbackURL,
-		MetadataURL:       param.MetadataURL,
-		GitHubRunnerGroup: param.GitHubRunnerGroup,
-		AditionalLabels:   labels,
+		Pool:               pool,
+		Name:               param.Name,
+		Status:             param.Status,
+		RunnerStatus:       param.RunnerStatus,
+		OSType:             param.OSType,
+		OSArch:             param.OSArch,
+		CallbackURL:        param.CallbackURL,
+		MetadataURL:        param.MetadataURL,
+		GitHubRunnerGroup:  param.GitHubRunnerGroup,
+		AditionalLabels:    labels,
+		PoolConfigRevision: pool.ConfigRevision,
+		ProviderName:       param.ProviderName,
 	}
 	q := s.conn.Create(&newInstance)
 	if q.Error != nil {
@@ -212,6 +214,10 @@ func (s *sqlDatabase) UpdateInstance(ctx context.Context, instanceID string, par
 		instance.ProviderID = &param.ProviderID
 	}
 
+	if param.ProviderName != "" {
+		instance.ProviderName = param.ProviderName
+	}
+
 	if param.OSName != "" {
 		instance.OSName = param.OSName
 	}
@@ -231,6 +237,10 @@ func (s *sqlDatabase) UpdateInstance(ctx context.Context, instanceID string, par
 		instance.CreateAttempt = param.CreateAttempt
 	}
 
+	if param.QuotaAttempt != 0 {
+		instance.QuotaAttempt = param.QuotaAttempt
+	}
+
 	if param.TokenFetched != nil {
 		instance.TokenFetched = *param.TokenFetched
 	}
@@ -305,3 +315,61 @@ func (s *sqlDatabase) PoolInstanceCount(ctx context.Context, poolID string) (int
 	}
 	return cnt, nil
 }
+
+func (s *sqlDatabase) ProviderInstanceCountByFlavor(ctx context.Context, providerName string) (map[string]uint, error) {
+	var counts []struct {
+		ProviderName      string
+		Flavor            string
+		FallbackProviders datatypes.JSON
+		Count             uint
+	}
+	// Instances created before pools had fallback providers do not record their provider,
+	// and were placed on the provider of their pool.
+	q := s.conn.Model(&Instance{}).
+		Select("pools.provider_name as provider_name, pools.flavor as flavor, pools.fallback_providers as fallback_providers, count(instances.id) as count").
+		Joins("inner join pools on pools.id = instances.pool_id").
+		Where("pools.deleted_at is null").
+		Where(
+			"instances.provider_name = ? or ((instances.provider_name is null or instances.provider_name = '') and pools.provider_name = ?)",
+			providerName, providerName).
+		Group("pools.id").
+		Scan(&counts)
+	if q.Error != nil {
+		return nil, errors.Wrap(q.Error, "fetching instance count")
+	}
+
+	ret := make(map[string]uint, len(counts))
+	for _, cnt := range counts {
+		flavor := cnt.Flavor
+		if cnt.ProviderName != providerName {
+			// The instances were placed on a fallback provider of the pool, which may
+			// override its flavor.
+			var fallbacks []params.PoolProvider
+			_ = json.Unmarshal(cnt.FallbackProviders, &fallbacks)
+			for _, fallback := range fallbacks {
+				if fallback.ProviderName == providerName && fallback.Flavor != "" {
+					flavor = fallback.Flavor
+				}
+			}
+		}
+		ret[flavor] += cnt.Count
+	}
+	return ret, nil
+}
+
+func (s *sqlDatabase) CredentialsInstanceCount(ctx context.Context, credentialsName string) (int64, error) {
+	var cnt int64
+	q := s.conn.Model(&Instance{}).
+		Joins("inner join pools on pools.id = instances.pool_id and pools.deleted_at is null").
+		Joins("left join repositories on repositories.id = pools.repo_id and repositories.deleted_at is null").
+		Joins("left join organizations on organizations.id = pools.org_id and organizations.deleted_at is null").
+		Joins("left join enterprises on enterprises.id = pools.enterprise_id and enterprises.deleted_at is null").
+		Where(
+			"repositories.credentials_name = ? or organizations.credentials_name = ? or enterprises.credentials_name = ?",
+			credentialsName, credentialsName, credentialsName).
+		Count(&cnt)
+	if q.Error != nil {
+		return 0, errors.Wrap(q.Error, "fetching instance count")
+	}
+	return cnt, nil
+}
@@ -78,7 +78,7 @@ func (s *InstancesTestSuite) SetupTest() {
 	s.Store = db
 
 	// create an organization for testing purposes
-	org, err := s.Store.CreateOrganization(context.Background(), "test-org", "test-creds", "test-webhookSecret")
+	org, err := s.Store.CreateOrganization(context.Background(), "https://github.com", "test-org", "test-creds", "test-webhookSecret")
 	if err != nil {
 		s.FailNow(fmt.Sprintf("failed to create org: %s", err))
 	}
@@ -191,6 +191,8 @@ func (s *InstancesTestSuite) TestCreateInstance() {
 	s.Require().Equal(storeInstance.OSArch, instance.OSArch)
 	s.Require().Equal(storeInstance.OSType, instance.OSType)
 	s.Require().Equal(storeInstance.CallbackURL, instance.CallbackURL)
+	s.Require().False(storeInstance.CreatedAt.IsZero())
+	s.Require().True(storeInstance.CreatedAt.Equal(instance.CreatedAt))
 }
 
 func (s *InstancesTestSuite) TestCreateInstanceInvalidPoolID() {
@@ -526,6 +528,63 @@ func (s *InstancesTestSuite) TestPoolInstanceCount() {
 	s.Require().Equal(int64(len(s.Fixtures.Instances)), instancesCount)
 }
 
+func (s *InstancesTestSuite) TestProviderInstanceCountByFlavor() {
+	counts, err := s.Store.ProviderInstanceCountByFlavor(context.Background(), s.Fixtures.Pool.ProviderName)
+
+	s.Require().Nil(err)
+	s.Require().Equal(map[string]uint{s.Fixtures.Pool.Flavor: uint(len(s.Fixtures.Instances))}, counts)
+
+	counts, err = s.Store.ProviderInstanceCountByFlavor(context.Background(), "dummy-provider")
+	s.Require().Nil(err)
+	s.Require().Len(counts, 0)
+}
+
+func (s *InstancesTestSuite) TestProviderInstanceCountByFlavorFallbackProvider() {
+	pool := s.Fixtures.Pool
+	updateParams := params.UpdatePoolParams{
+		FallbackProviders: []params.PoolProvider{
+			{
+				ProviderName: "fallback-provider",
+				Flavor:       "fallback-flavor",
+			},
+		},
+	}
+	_, err := s.Store.UpdateOrganizationPool(context.Background(), pool.OrgID, pool.ID, updateParams)
+	if err != nil {
+		s.FailNow(fmt.Sprintf("failed to update pool: %s", err))
+	}
+	_, err = s.Store.UpdateInstance(context.Background(), s.Fixtures.Instances[0].ID, params.UpdateInstanceParams{ProviderName: "fallback-provider"})
+	if err != nil {
+		s.FailNow(fmt.Sprintf("failed to update instance: %s", err))
+	}
+
+	counts, err := s.Store.ProviderInstanceCountByFlavor(context.Background(), pool.ProviderName)
+	s.Require().Nil(err)
+	s.Require().Equal(map[string]uint{pool.Flavor: uint(len(s.Fixtures.Instances) - 1)}, counts)
+
+	counts, err = s.Store.ProviderInstanceCountByFlavor(context.Background(), "fallback-provider")
+	s.Require().Nil(err)
+	s.Require().Equal(map[string]uint{"fallback-flavor": 1}, counts)
+}
+
+func (s *InstancesTestSuite) TestUpdateInstanceProviderName() {
+	instance, err := s.Store.UpdateInstance(context.Background(), s.Fixtures.Instances[0].ID, params.UpdateInstanceParams{ProviderName: "fallback-provider"})
+
+	s.Require().Nil(err)
+	s.Require().Equal("fallback-provider", instance.ProviderName)
+}
+
+func (s *InstancesTestSuite) TestCredentialsInstanceCount() {
+	cnt, err := s.Store.CredentialsInstanceCount(context.Background(), "test-creds")
+
+	s.Require().Nil(err)
+	s.Require().Equal(int64(len(s.Fixtures.Instances)), cnt)
+
+	cnt, err = s.Store.CredentialsInstanceCount(context.Background(), "dummy-creds")
+	s.Require().Nil(err)
+	s.Require().Equal(int64(0), cnt)
+}
+
 func (s *InstancesTestSuite) TestPoolInstanceCountInvalidPoolID() {
 	_, err := s.Store.PoolInstanceCount(context.Background(), "dummy-pool-id")
 
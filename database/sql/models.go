@@ -60,6 +60,7 @@ type Pool struct {
 	RunnerPrefix           string
 	MaxRunners             uint
 	MinIdleRunners         uint
+	WarmRunners            uint
 	RunnerBootstrapTimeout uint
 	Image                  string `gorm:"index:idx_pool_type"`
 	Flavor                 string `gorm:"index:idx_pool_type"`
@@ -70,8 +71,34 @@ type Pool struct {
 	// ExtraSpecs is an opaque json that gets sent to the provider
 	// as part of the bootstrap params for instances. It can contain
 	// any kind of data needed by providers.
-	ExtraSpecs        datatypes.JSON
-	GitHubRunnerGroup string
+	ExtraSpecs         datatypes.JSON
+	GitHubRunnerGroup  string
+	AutoMinIdleRunners bool
+	Priority           uint
+	Weight             uint
+	// ScalingSchedules holds the json encoded scaling schedules of the pool.
+	ScalingSchedules datatypes.JSON
+	// FallbackProviders holds the json encoded fallback providers of the pool.
+	FallbackProviders datatypes.JSON
+	// DrainStartedAt is set while the pool is being drained.
+	DrainStartedAt *time.Time
+	// ConfigRevision is increased every time the image, flavor, extra specs or tags
+	// of the pool change.
+	ConfigRevision          uint
+	MaxSurge                uint
+	ReplacementCanary       bool
+	ValidatedConfigRevision uint
+	IdleTimeout             uint
+	MaxLifetime             uint
+	CircuitBreakerThreshold uint
+	CircuitBreakerWindow    uint
+	MaxRunnersPerRepository uint
+	// The state of the circuit breaker of the pool.
+	ConsecutiveFailures     uint
+	LastFailureAt           *time.Time
+	CircuitBreakerTrips     uint
+	CircuitBreakerOpenUntil *time.Time
+	CircuitBreakerReason    string
 
 	RepoID     *uuid.UUID `gorm:"index"`
 	Repository Repository `gorm:"foreignKey:RepoID;"`
@@ -89,31 +116,108 @@ type Repository struct {
 	Base
 
 	CredentialsName string
-	Owner           string `gorm:"index:idx_owner_nocase,unique,collate:nocase"`
-	Name            string `gorm:"index:idx_owner_nocase,unique,collate:nocase"`
+	Endpoint        string `gorm:"index:idx_repo_endpoint_owner_nocase,unique,collate:nocase"`
+	Owner           string `gorm:"index:idx_repo_endpoint_owner_nocase,unique,collate:nocase"`
+	Name            string `gorm:"index:idx_repo_endpoint_owner_nocase,unique,collate:nocase"`
 	WebhookSecret   []byte
-	Pools           []Pool        `gorm:"foreignKey:RepoID"`
-	Jobs            []WorkflowJob `gorm:"foreignKey:RepoID;constraint:OnDelete:SET NULL"`
+	PollingEnabled  bool
+	PollingInterval uint
+	// PoolSelectionStrategy is empty for entities created before it was added.
+	PoolSelectionStrategy params.PoolSelectionStrategy
+	Pools                 []Pool        `gorm:"foreignKey:RepoID"`
+	Jobs                  []WorkflowJob `gorm:"foreignKey:RepoID;constraint:OnDelete:SET NULL"`
+
+	// ManagedHookID is the ID of the webhook garm installed for this repository.
+	ManagedHookID int64
+
+	// LastWebhookDeliveryAt is the time at which the newest webhook delivery we
+	// processed for this repository was received.
+	LastWebhookDeliveryAt *time.Time
+	// WebhookLastSeenAt is the time at which we last received a webhook with a
+	// valid signature for this repository.
+	WebhookLastSeenAt *time.Time
+	// WebhookVerifiedAt is the time at which we last received a ping with a valid
+	// signature for this repository.
+	WebhookVerifiedAt *time.Time
 }
 
 type Organization struct {
 	Base
 
 	CredentialsName string
-	Name            string `gorm:"index:idx_org_name_nocase,collate:nocase"`
+	Endpoint        string `gorm:"index:idx_org_endpoint_name_nocase,collate:nocase"`
+	Name            string `gorm:"index:idx_org_endpoint_name_nocase,collate:nocase"`
 	WebhookSecret   []byte
-	Pools           []Pool        `gorm:"foreignKey:OrgID"`
-	Jobs            []WorkflowJob `gorm:"foreignKey:OrgID;constraint:OnDelete:SET NULL"`
+	PollingEnabled  bool
+	PollingInterval uint
+	// PoolSelectionStrategy is empty for entities created before it was added.
+	PoolSelectionStrategy params.PoolSelectionStrategy
+	// JobSchedulingStrategy is the order in which queued jobs are handled.
+	JobSchedulingStrategy params.JobSchedulingStrategy
+	Pools                 []Pool        `gorm:"foreignKey:OrgID"`
+	Jobs                  []WorkflowJob `gorm:"foreignKey:OrgID;constraint:OnDelete:SET NULL"`
+
+	// ManagedHookID is the ID of the webhook garm installed for this organization.
+	ManagedHookID int64
+
+	// LastWebhookDeliveryAt is the time at which the newest webhook delivery we
+	// processed for this organization was received.
+	LastWebhookDeliveryAt *time.Time
+	// WebhookLastSeenAt is the time at which we last received a webhook with a
+	// valid signature for this organization.
+	WebhookLastSeenAt *time.Time
+	// WebhookVerifiedAt is the time at which we last received a ping with a valid
+	// signature for this organization.
+	WebhookVerifiedAt *time.Time
 }
 
 type Enterprise struct {
 	Base
 
 	CredentialsName string
-	Name            string `gorm:"index:idx_ent_name_nocase,collate:nocase"`
+	Endpoint        string `gorm:"index:idx_ent_endpoint_name_nocase,collate:nocase"`
+	Name            string `gorm:"index:idx_ent_endpoint_name_nocase,collate:nocase"`
 	WebhookSecret   []byte
-	Pools           []Pool        `gorm:"foreignKey:EnterpriseID"`
-	Jobs            []WorkflowJob `gorm:"foreignKey:EnterpriseID;constraint:OnDelete:SET NULL"`
+	// PoolSelectionStrategy is empty for entities created before it was added.
+	PoolSelectionStrategy params.PoolSelectionStrategy
+	// JobSchedulingStrategy is the order in which queued jobs are handled.
+	JobSchedulingStrategy params.JobSchedulingStrategy
+	Pools                 []Pool        `gorm:"foreignKey:EnterpriseID"`
+	Jobs                  []WorkflowJob `gorm:"foreignKey:EnterpriseID;constraint:OnDelete:SET NULL"`
+
+	// LastWebhookDeliveryAt is the time at which the newest webhook delivery we
+	// processed for this enterprise was received.
+	LastWebhookDeliveryAt *time.Time
+	// WebhookLastSeenAt is the time at which we last received a webhook with a
+	// valid signature for this enterprise.
+	WebhookLastSeenAt *time.Time
+	// WebhookVerifiedAt is the time at which we last received a ping with a valid
+	// signature for this enterprise.
+	WebhookVerifiedAt *time.Time
+}
+
+type GithubCredentials struct {
+	Base
+
+	Name        string `gorm:"type:varchar(64);uniqueIndex"`
+	Description string
+	AuthType    params.GithubAuthType
+	// OAuth2Token is the encrypted personal access token. Only set when
+	// AuthType is "pat".
+	OAuth2Token []byte
+	// AppID, InstallationID, AppPrivateKey and AppWebhookSecret hold the github app
+	// details. They are only set when AuthType is "app". The private key and the
+	// webhook secret are encrypted.
+	AppID            int64
+	InstallationID   int64
+	AppPrivateKey    []byte `gorm:"type:longblob"`
+	AppWebhookSecret []byte
+
+	BaseURL       string
+	APIBaseURL    string
+	UploadBaseURL string
+	CABundle      []byte `gorm:"type:longblob"`
+	MaxRunners    uint
 }
 
 type Address struct {
@@ -154,9 +258,15 @@ type Instance struct {
 	MetadataURL       string
 	ProviderFault     []byte `gorm:"type:longblob"`
 	CreateAttempt     int
+	QuotaAttempt      int
 	TokenFetched      bool
 	GitHubRunnerGroup string
 	AditionalLabels   datatypes.JSON
+	// PoolConfigRevision is the config revision of the pool this instance was created from.
+	PoolConfigRevision uint
+	// ProviderName is the provider the instance was placed on. It is empty for instances
+	// created before pools had fallback providers, which use the provider of their pool.
+	ProviderName string
 
 	PoolID uuid.UUID
 	Pool   Pool `gorm:"foreignKey:PoolID"`
@@ -236,3 +346,51 @@ type WorkflowJob struct {
 	UpdatedAt time.Time
 	DeletedAt gorm.DeletedAt `gorm:"index"`
 }
+
+// ProviderDrain records a drain started for a provider. The provider itself
+// is defined in the config file.
+type ProviderDrain struct {
+	ProviderName string `gorm:"type:varchar(64);primarykey"`
+	StartedAt    time.Time
+}
+
+// PoolDemandStat holds the demand statistics of a pool, for one hour.
+type PoolDemandStat struct {
+	ID uint `gorm:"primarykey"`
+
+	PoolID uuid.UUID `gorm:"uniqueIndex:idx_pool_demand_bucket"`
+	Pool   Pool      `gorm:"foreignKey:PoolID;constraint:OnDelete:CASCADE"`
+	Bucket time.Time `gorm:"uniqueIndex:idx_pool_demand_bucket;index"`
+
+	Samples uint
+	// QueuedJobs is a json encoded map of concurrently queued jobs to the number
+	// of samples in which they were seen.
+	QueuedJobs        datatypes.JSON
+	MaxQueuedJobs     uint
+	JobsStarted       uint
+	TotalQueueLatency float64
+	MaxQueueLatency   float64
+
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// WebhookDelivery is a webhook payload received from github, waiting to be
+// processed or kept around for inspection after it was processed.
+type WebhookDelivery struct {
+	// ID is the value of the X-GitHub-Delivery header.
+	ID string `gorm:"type:varchar(64);primarykey"`
+
+	Event          string `gorm:"type:varchar(64)"`
+	EntityID       string `gorm:"type:varchar(64)"`
+	HookTargetType string `gorm:"type:varchar(64)"`
+	Payload        []byte `gorm:"type:longblob"`
+
+	Status        params.WebhookDeliveryStatus `gorm:"type:varchar(32);index"`
+	Attempts      uint
+	LastError     string `gorm:"type:text"`
+	NextAttemptAt time.Time
+
+	CreatedAt time.Time `gorm:"index"`
+	UpdatedAt time.Time
+}
@@ -28,7 +28,7 @@ import (
 	"gorm.io/gorm"
 )
 
-func (s *sqlDatabase) CreateOrganization(ctx context.Context, name, credentialsName, webhookSecret string) (params.Organization, error) {
+func (s *sqlDatabase) CreateOrganization(ctx context.Context, endpoint, name, credentialsName, webhookSecret string) (params.Organization, error) {
 	if webhookSecret == "" {
 		return params.Organization{}, errors.New("creating org: missing secret")
 	}
@@ -37,6 +37,7 @@ func (s *sqlDatabase) CreateOrganization(ctx context.Context, name, credentialsN
 		return params.Organization{}, fmt.Errorf("failed to encrypt string")
 	}
 	newOrg := Organization{
+		Endpoint:        endpoint,
 		Name:            name,
 		WebhookSecret:   secret,
 		CredentialsName: credentialsName,
@@ -56,8 +57,8 @@ func (s *sqlDatabase) CreateOrganization(ctx context.Context, name, credentialsN
 	return param, nil
 }
 
-func (s *sqlDatabase) GetOrganization(ctx context.Context, name string) (params.Organization, error) {
-	org, err := s.getOrg(ctx, name)
+func (s *sqlDatabase) GetOrganization(ctx context.Context, endpoint, name string) (params.Organization, error) {
+	org, err := s.getOrg(ctx, endpoint, name)
 	if err != nil {
 		return params.Organization{}, errors.Wrap(err, "fetching org")
 	}
@@ -121,6 +122,22 @@ func (s *sqlDatabase) UpdateOrganization(ctx context.Context, orgID string, para
 		org.WebhookSecret = secret
 	}
 
+	if param.PollingEnabled != nil {
+		org.PollingEnabled = *param.PollingEnabled
+	}
+
+	if param.PollingInterval != nil {
+		org.PollingInterval = *param.PollingInterval
+	}
+
+	if param.PoolSelectionStrategy != "" {
+		org.PoolSelectionStrategy = param.PoolSelectionStrategy
+	}
+
+	if param.JobSchedulingStrategy != "" {
+		org.JobSchedulingStrategy = param.JobSchedulingStrategy
+	}
+
 	q := s.conn.Save(&org)
 	if q.Error != nil {
 		return params.Organization{}, errors.Wrap(q.Error, "saving org")
@@ -133,6 +150,18 @@ func (s *sqlDatabase) UpdateOrganization(ctx context.Context, orgID string, para
 	return newParams, nil
 }
 
+func (s *sqlDatabase) SetOrganizationManagedHookID(ctx context.Context, orgID string, hookID int64) error {
+	org, err := s.getOrgByID(ctx, orgID)
+	if err != nil {
+		return errors.Wrap(err, "fetching org")
+	}
+
+	if q := s.conn.Model(&org).UpdateColumn("managed_hook_id", hookID); q.Error != nil {
+		return errors.Wrap(q.Error, "saving org")
+	}
+	return nil
+}
+
 func (s *sqlDatabase) GetOrganizationByID(ctx context.Context, orgID string) (params.Organization, error) {
 	org, err := s.getOrgByID(ctx, orgID, "Pools")
 	if err != nil {
@@ -157,23 +186,48 @@ func (s *sqlDatabase) CreateOrganizationPool(ctx context.Context, orgId string,
 	}
 
 	newPool := Pool{
-		ProviderName:           param.ProviderName,
-		MaxRunners:             param.MaxRunners,
-		MinIdleRunners:         param.MinIdleRunners,
-		RunnerPrefix:           param.GetRunnerPrefix(),
-		Image:                  param.Image,
-		Flavor:                 param.Flavor,
-		OSType:                 param.OSType,
-		OSArch:                 param.OSArch,
-		OrgID:                  &org.ID,
-		Enabled:                param.Enabled,
-		RunnerBootstrapTimeout: param.RunnerBootstrapTimeout,
+		ProviderName:            param.ProviderName,
+		MaxRunners:              param.MaxRunners,
+		MinIdleRunners:          param.MinIdleRunners,
+		WarmRunners:             param.WarmRunners,
+		RunnerPrefix:            param.GetRunnerPrefix(),
+		Image:                   param.Image,
+		Flavor:                  param.Flavor,
+		OSType:                  param.OSType,
+		OSArch:                  param.OSArch,
+		OrgID:                   &org.ID,
+		Enabled:                 param.Enabled,
+		RunnerBootstrapTimeout:  param.RunnerBootstrapTimeout,
+		AutoMinIdleRunners:      param.AutoMinIdleRunners,
+		Priority:                param.Priority,
+		Weight:                  param.Weight,
+		MaxSurge:                param.MaxSurge,
+		ReplacementCanary:       param.ReplacementCanary,
+		IdleTimeout:             param.IdleTimeout,
+		MaxLifetime:             param.MaxLifetime,
+		CircuitBreakerThreshold: param.CircuitBreakerThreshold,
+		CircuitBreakerWindow:    param.CircuitBreakerWindow,
+		MaxRunnersPerRepository: param.MaxRunnersPerRepository,
 	}
 
 	if len(param.ExtraSpecs) > 0 {
 		newPool.ExtraSpecs = datatypes.JSON(param.ExtraSpecs)
 	}
 
+	if len(param.ScalingSchedules) > 0 {
+		newPool.ScalingSchedules, err = scalingSchedulesToJSON(param.ScalingSchedules)
+		if err != nil {
+			return params.Pool{}, errors.Wrap(err, "creating pool")
+		}
+	}
+
+	if len(param.FallbackProviders) > 0 {
+		newPool.FallbackProviders, err = fallbackProvidersToJSON(param.FallbackProviders)
+		if err != nil {
+			return params.Pool{}, errors.Wrap(err, "creating pool")
+		}
+	}
+
 	_, err = s.getOrgPoolByUniqueFields(ctx, orgId, newPool.ProviderName, newPool.Image, newPool.Flavor)
 	if err != nil {
 		if !errors.Is(err, runnerErrors.ErrNotFound) {
@@ -324,10 +378,10 @@ func (s *sqlDatabase) getOrgByID(ctx context.Context, id string, preload ...stri
 	return org, nil
 }
 
-func (s *sqlDatabase) getOrg(ctx context.Context, name string) (Organization, error) {
+func (s *sqlDatabase) getOrg(ctx context.Context, endpoint, name string) (Organization, error) {
 	var org Organization
 
-	q := s.conn.Where("name = ? COLLATE NOCASE", name)
+	q := s.conn.Where("name = ? COLLATE NOCASE and endpoint = ? COLLATE NOCASE", name, endpoint)
 	q = q.First(&org)
 	if q.Error != nil {
 		if errors.Is(q.Error, gorm.ErrRecordNotFound) {
@@ -82,6 +82,7 @@ func (s *OrgTestSuite) SetupTest() {
 	for i := 1; i <= 3; i++ {
 		org, err := db.CreateOrganization(
 			context.Background(),
+			"https://github.com",
 			fmt.Sprintf("test-org-%d", i),
 			fmt.Sprintf("test-creds-%d", i),
 			fmt.Sprintf("test-webhook-secret-%d", i),
@@ -160,6 +161,7 @@ func (s *OrgTestSuite) TestCreateOrganization() {
 	// call tested function
 	org, err := s.Store.CreateOrganization(
 		context.Background(),
+		"https://github.com",
 		s.Fixtures.CreateOrgParams.Name,
 		s.Fixtures.CreateOrgParams.CredentialsName,
 		s.Fixtures.CreateOrgParams.WebhookSecret)
@@ -190,6 +192,7 @@ func (s *OrgTestSuite) TestCreateOrganizationInvalidDBPassphrase() {
 
 	_, err = sqlDB.CreateOrganization(
 		context.Background(),
+		"https://github.com",
 		s.Fixtures.CreateOrgParams.Name,
 		s.Fixtures.CreateOrgParams.CredentialsName,
 		s.Fixtures.CreateOrgParams.WebhookSecret)
@@ -207,6 +210,7 @@ func (s *OrgTestSuite) TestCreateOrganizationDBCreateErr() {
 
 	_, err := s.StoreSQLMocked.CreateOrganization(
 		context.Background(),
+		"https://github.com",
 		s.Fixtures.CreateOrgParams.Name,
 		s.Fixtures.CreateOrgParams.CredentialsName,
 		s.Fixtures.CreateOrgParams.WebhookSecret)
@@ -217,7 +221,7 @@ func (s *OrgTestSuite) TestCreateOrganizationDBCreateErr() {
 }
 
 func (s *OrgTestSuite) TestGetOrganization() {
-	org, err := s.Store.GetOrganization(context.Background(), s.Fixtures.Orgs[0].Name)
+	org, err := s.Store.GetOrganization(context.Background(), "https://github.com", s.Fixtures.Orgs[0].Name)
 
 	s.Require().Nil(err)
 	s.Require().Equal(s.Fixtures.Orgs[0].Name, org.Name)
@@ -225,14 +229,30 @@ func (s *OrgTestSuite) TestGetOrganization() {
 }
 
 func (s *OrgTestSuite) TestGetOrganizationCaseInsensitive() {
-	org, err := s.Store.GetOrganization(context.Background(), "TeSt-oRg-1")
+	org, err := s.Store.GetOrganization(context.Background(), "https://github.com", "TeSt-oRg-1")
 
 	s.Require().Nil(err)
 	s.Require().Equal("test-org-1", org.Name)
 }
 
+func (s *OrgTestSuite) TestGetOrganizationOtherEndpoint() {
+	org, err := s.Store.CreateOrganization(context.Background(), "https://ghes.example.com", s.Fixtures.Orgs[0].Name, "test-creds", "test-webhook-secret")
+	if err != nil {
+		s.FailNow(fmt.Sprintf("failed to create organization: %s", err))
+	}
+
+	ghesOrg, err := s.Store.GetOrganization(context.Background(), "https://ghes.example.com", s.Fixtures.Orgs[0].Name)
+	s.Require().Nil(err)
+	s.Require().Equal(org.ID, ghesOrg.ID)
+	s.Require().Equal("https://ghes.example.com", ghesOrg.Endpoint)
+
+	githubOrg, err := s.Store.GetOrganization(context.Background(), "https://github.com", s.Fixtures.Orgs[0].Name)
+	s.Require().Nil(err)
+	s.Require().Equal(s.Fixtures.Orgs[0].ID, githubOrg.ID)
+}
+
 func (s *OrgTestSuite) TestGetOrganizationNotFound() {
-	_, err := s.Store.GetOrganization(context.Background(), "dummy-name")
+	_, err := s.Store.GetOrganization(context.Background(), "https://github.com", "dummy-name")
 
 	s.Require().NotNil(err)
 	s.Require().Equal("fetching org: not found", err.Error())
@@ -240,11 +260,11 @@ func (s *OrgTestSuite) TestGetOrganizationNotFound() {
 
 func (s *OrgTestSuite) TestGetOrganizationDBDecryptingErr() {
 	s.Fixtures.SQLMock.
-		ExpectQuery(regexp.QuoteMeta("SELECT * FROM `organizations` WHERE name = ? COLLATE NOCASE AND `organizations`.`deleted_at` IS NULL ORDER BY `organizations`.`id` LIMIT 1")).
-		WithArgs(s.Fixtures.Orgs[0].Name).
+		ExpectQuery(regexp.QuoteMeta("SELECT * FROM `organizations` WHERE (name = ? COLLATE NOCASE and endpoint = ? COLLATE NOCASE) AND `organizations`.`deleted_at` IS NULL ORDER BY `organizations`.`id` LIMIT 1")).
+		WithArgs(s.Fixtures.Orgs[0].Name, "https://github.com").
 		WillReturnRows(sqlmock.NewRows([]string{"name"}).AddRow(s.Fixtures.Orgs[0].Name))
 
-	_, err := s.StoreSQLMocked.GetOrganization(context.Background(), s.Fixtures.Orgs[0].Name)
+	_, err := s.StoreSQLMocked.GetOrganization(context.Background(), "https://github.com", s.Fixtures.Orgs[0].Name)
 
 	s.assertSQLMockExpectations()
 	s.Require().NotNil(err)
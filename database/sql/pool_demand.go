@@ -0,0 +1,146 @@
+// Copyright 2023 Cloudbase Solutions SRL
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package sql
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/cloudbase/garm/database/common"
+	runnerErrors "github.com/cloudbase/garm/errors"
+	"github.com/cloudbase/garm/params"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+var _ common.PoolDemandStore = &sqlDatabase{}
+
+// demandBucket returns the start of the hour in which the given time falls.
+func demandBucket(at time.Time) time.Time {
+	return at.UTC().Truncate(time.Hour)
+}
+
+func sqlToParamsPoolDemandStats(stat PoolDemandStat) params.PoolDemandStats {
+	ret := params.PoolDemandStats{
+		PoolID:            stat.PoolID.String(),
+		Bucket:            stat.Bucket.UTC(),
+		Samples:           stat.Samples,
+		QueuedJobs:        map[uint]uint{},
+		MaxQueuedJobs:     stat.MaxQueuedJobs,
+		JobsStarted:       stat.JobsStarted,
+		TotalQueueLatency: stat.TotalQueueLatency,
+		MaxQueueLatency:   stat.MaxQueueLatency,
+	}
+	_ = json.Unmarshal(stat.QueuedJobs, &ret.QueuedJobs)
+	return ret
+}
+
+// updatePoolDemandStat fetches or creates the statistics of a pool for the hour
+// in which the given time falls, and saves them after applying the update.
+func (s *sqlDatabase) updatePoolDemandStat(poolID string, at time.Time, update func(stat *PoolDemandStat) error) error {
+	id, err := uuid.Parse(poolID)
+	if err != nil {
+		return errors.Wrap(runnerErrors.ErrBadRequest, "parsing id")
+	}
+
+	err = s.conn.Transaction(func(tx *gorm.DB) error {
+		stat := PoolDemandStat{}
+		q := tx.Where(PoolDemandStat{PoolID: id, Bucket: demandBucket(at)}).FirstOrCreate(&stat)
+		if q.Error != nil {
+			return errors.Wrap(q.Error, "fetching pool demand stats")
+		}
+
+		if err := update(&stat); err != nil {
+			return err
+		}
+
+		if q := tx.Save(&stat); q.Error != nil {
+			return errors.Wrap(q.Error, "saving pool demand stats")
+		}
+		return nil
+	})
+	if err != nil {
+		return errors.Wrap(err, "updating pool demand stats")
+	}
+	return nil
+}
+
+func (s *sqlDatabase) RecordPoolQueuedJobs(ctx context.Context, poolID string, sampledAt time.Time, queued uint) error {
+	return s.updatePoolDemandStat(poolID, sampledAt, func(stat *PoolDemandStat) error {
+		histogram := map[uint]uint{}
+		if len(stat.QueuedJobs) > 0 {
+			if err := json.Unmarshal(stat.QueuedJobs, &histogram); err != nil {
+				return errors.Wrap(err, "decoding queued jobs")
+			}
+		}
+		histogram[queued]++
+
+		asJSON, err := json.Marshal(histogram)
+		if err != nil {
+			return errors.Wrap(err, "encoding queued jobs")
+		}
+		stat.QueuedJobs = asJSON
+		stat.Samples++
+		if queued > stat.MaxQueuedJobs {
+			stat.MaxQueuedJobs = queued
+		}
+		return nil
+	})
+}
+
+func (s *sqlDatabase) RecordPoolJobStarted(ctx context.Context, poolID string, startedAt time.Time, queueLatency time.Duration) error {
+	return s.updatePoolDemandStat(poolID, startedAt, func(stat *PoolDemandStat) error {
+		latency := queueLatency.Seconds()
+		stat.JobsStarted++
+		stat.TotalQueueLatency += latency
+		if latency > stat.MaxQueueLatency {
+			stat.MaxQueueLatency = latency
+		}
+		return nil
+	})
+}
+
+func (s *sqlDatabase) ListPoolDemandStats(ctx context.Context, poolID string, from, to time.Time) ([]params.PoolDemandStats, error) {
+	id, err := uuid.Parse(poolID)
+	if err != nil {
+		return nil, errors.Wrap(runnerErrors.ErrBadRequest, "parsing id")
+	}
+
+	var stats []PoolDemandStat
+	q := s.conn.
+		Where("pool_id = ? and bucket >= ? and bucket < ?", id, demandBucket(from), to.UTC()).
+		Order("bucket").
+		Find(&stats)
+	if q.Error != nil {
+		return nil, errors.Wrap(q.Error, "fetching pool demand stats")
+	}
+
+	ret := make([]params.PoolDemandStats, len(stats))
+	for idx, stat := range stats {
+		ret[idx] = sqlToParamsPoolDemandStats(stat)
+	}
+	return ret, nil
+}
+
+func (s *sqlDatabase) DeletePoolDemandStatsOlderThan(ctx context.Context, olderThan time.Time) error {
+	q := s.conn.Where("bucket < ?", demandBucket(olderThan)).Delete(&PoolDemandStat{})
+	if q.Error != nil {
+		return errors.Wrap(q.Error, "deleting pool demand stats")
+	}
+	return nil
+}
@@ -0,0 +1,155 @@
+// Copyright 2023 Cloudbase Solutions SRL
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package sql
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	dbCommon "github.com/cloudbase/garm/database/common"
+	runnerErrors "github.com/cloudbase/garm/errors"
+	garmTesting "github.com/cloudbase/garm/internal/testing"
+	"github.com/cloudbase/garm/params"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type PoolDemandTestSuite struct {
+	suite.Suite
+	Store dbCommon.Store
+	Pool  params.Pool
+	// Now is the start of an hour, used as the reference time in tests.
+	Now time.Time
+}
+
+func (s *PoolDemandTestSuite) SetupTest() {
+	db, err := NewSQLDatabase(context.Background(), garmTesting.GetTestSqliteDBConfig(s.T()))
+	if err != nil {
+		s.FailNow(fmt.Sprintf("failed to create db connection: %s", err))
+	}
+	s.Store = db
+
+	org, err := db.CreateOrganization(context.Background(), "https://github.com", "test-org", "test-creds", "test-webhookSecret")
+	if err != nil {
+		s.FailNow(fmt.Sprintf("failed to create org: %s", err))
+	}
+
+	pool, err := db.CreateOrganizationPool(
+		context.Background(),
+		org.ID,
+		params.CreatePoolParams{
+			ProviderName:   "test-provider",
+			MaxRunners:     4,
+			MinIdleRunners: 2,
+			Image:          "test-image",
+			Flavor:         "test-flavor",
+			OSType:         "linux",
+			Tags:           []string{"self-hosted", "amd64", "linux"},
+		},
+	)
+	if err != nil {
+		s.FailNow(fmt.Sprintf("cannot create org pool: %v", err))
+	}
+	s.Pool = pool
+	s.Now = time.Now().UTC().Truncate(time.Hour)
+}
+
+func (s *PoolDemandTestSuite) TestRecordPoolQueuedJobs() {
+	for _, queued := range []uint{0, 3, 3, 1} {
+		err := s.Store.RecordPoolQueuedJobs(context.Background(), s.Pool.ID, s.Now.Add(10*time.Minute), queued)
+		s.Require().Nil(err)
+	}
+
+	stats, err := s.Store.ListPoolDemandStats(context.Background(), s.Pool.ID, s.Now, s.Now.Add(time.Hour))
+
+	s.Require().Nil(err)
+	s.Require().Len(stats, 1)
+	s.Require().Equal(s.Pool.ID, stats[0].PoolID)
+	s.Require().True(s.Now.Equal(stats[0].Bucket))
+	s.Require().Equal(uint(4), stats[0].Samples)
+	s.Require().Equal(map[uint]uint{0: 1, 1: 1, 3: 2}, stats[0].QueuedJobs)
+	s.Require().Equal(uint(3), stats[0].MaxQueuedJobs)
+	s.Require().Equal(uint(3), stats[0].QueuedJobsPercentile(90))
+	s.Require().Equal(uint(1), stats[0].QueuedJobsPercentile(50))
+}
+
+func (s *PoolDemandTestSuite) TestRecordPoolJobStarted() {
+	err := s.Store.RecordPoolJobStarted(context.Background(), s.Pool.ID, s.Now, 30*time.Second)
+	s.Require().Nil(err)
+	err = s.Store.RecordPoolJobStarted(context.Background(), s.Pool.ID, s.Now.Add(59*time.Minute), 90*time.Second)
+	s.Require().Nil(err)
+
+	stats, err := s.Store.ListPoolDemandStats(context.Background(), s.Pool.ID, s.Now, s.Now.Add(time.Hour))
+
+	s.Require().Nil(err)
+	s.Require().Len(stats, 1)
+	s.Require().Equal(uint(2), stats[0].JobsStarted)
+	s.Require().Equal(float64(120), stats[0].TotalQueueLatency)
+	s.Require().Equal(float64(90), stats[0].MaxQueueLatency)
+}
+
+func (s *PoolDemandTestSuite) TestListPoolDemandStatsInterval() {
+	for i := 0; i < 3; i++ {
+		err := s.Store.RecordPoolQueuedJobs(context.Background(), s.Pool.ID, s.Now.Add(-time.Duration(i)*time.Hour), uint(i))
+		s.Require().Nil(err)
+	}
+
+	stats, err := s.Store.ListPoolDemandStats(context.Background(), s.Pool.ID, s.Now.Add(-2*time.Hour), s.Now)
+
+	s.Require().Nil(err)
+	s.Require().Len(stats, 2)
+	s.Require().True(s.Now.Add(-2 * time.Hour).Equal(stats[0].Bucket))
+	s.Require().True(s.Now.Add(-1 * time.Hour).Equal(stats[1].Bucket))
+}
+
+func (s *PoolDemandTestSuite) TestListPoolDemandStatsInvalidPoolID() {
+	_, err := s.Store.ListPoolDemandStats(context.Background(), "dummy-pool-id", s.Now, s.Now)
+
+	s.Require().ErrorIs(err, runnerErrors.ErrBadRequest)
+}
+
+func (s *PoolDemandTestSuite) TestDeletePoolDemandStatsOlderThan() {
+	err := s.Store.RecordPoolQueuedJobs(context.Background(), s.Pool.ID, s.Now.Add(-3*time.Hour), 1)
+	s.Require().Nil(err)
+	err = s.Store.RecordPoolQueuedJobs(context.Background(), s.Pool.ID, s.Now, 1)
+	s.Require().Nil(err)
+
+	err = s.Store.DeletePoolDemandStatsOlderThan(context.Background(), s.Now.Add(-time.Hour))
+	s.Require().Nil(err)
+
+	stats, err := s.Store.ListPoolDemandStats(context.Background(), s.Pool.ID, s.Now.Add(-4*time.Hour), s.Now.Add(time.Hour))
+	s.Require().Nil(err)
+	s.Require().Len(stats, 1)
+	s.Require().True(s.Now.Equal(stats[0].Bucket))
+}
+
+func (s *PoolDemandTestSuite) TestDeletePoolRemovesDemandStats() {
+	err := s.Store.RecordPoolQueuedJobs(context.Background(), s.Pool.ID, s.Now, 1)
+	s.Require().Nil(err)
+
+	err = s.Store.DeletePoolByID(context.Background(), s.Pool.ID)
+	s.Require().Nil(err)
+
+	stats, err := s.Store.ListPoolDemandStats(context.Background(), s.Pool.ID, s.Now, s.Now.Add(time.Hour))
+	s.Require().Nil(err)
+	s.Require().Len(stats, 0)
+}
+
+func TestPoolDemandTestSuite(t *testing.T) {
+	t.Parallel()
+	suite.Run(t, new(PoolDemandTestSuite))
+}
@@ -68,6 +68,44 @@ func (s *sqlDatabase) DeletePoolByID(ctx context.Context, poolID string) error {
 	return nil
 }
 
+func (s *sqlDatabase) SetPoolValidatedConfigRevision(ctx context.Context, poolID string, revision uint) error {
+	pool, err := s.getPoolByID(ctx, poolID)
+	if err != nil {
+		return errors.Wrap(err, "fetching pool by ID")
+	}
+
+	q := s.conn.Model(&pool).
+		Where("validated_config_revision < ?", revision).
+		Update("validated_config_revision", revision)
+	if q.Error != nil {
+		return errors.Wrap(q.Error, "updating pool")
+	}
+
+	return nil
+}
+
+func (s *sqlDatabase) SetPoolCircuitBreaker(ctx context.Context, poolID string, breaker params.CircuitBreaker) (params.Pool, error) {
+	pool, err := s.getPoolByID(ctx, poolID, "Tags", "Instances", "Enterprise", "Organization", "Repository")
+	if err != nil {
+		return params.Pool{}, errors.Wrap(err, "fetching pool by ID")
+	}
+
+	pool.ConsecutiveFailures = breaker.ConsecutiveFailures
+	pool.LastFailureAt = breaker.LastFailureAt
+	pool.CircuitBreakerTrips = breaker.Trips
+	pool.CircuitBreakerOpenUntil = breaker.OpenUntil
+	pool.CircuitBreakerReason = breaker.FailureReason
+
+	q := s.conn.Model(&pool).
+		Select("consecutive_failures", "last_failure_at", "circuit_breaker_trips", "circuit_breaker_open_until", "circuit_breaker_reason").
+		Updates(&pool)
+	if q.Error != nil {
+		return params.Pool{}, errors.Wrap(q.Error, "updating pool")
+	}
+
+	return s.sqlToCommonPool(pool), nil
+}
+
 func (s *sqlDatabase) getEntityPool(ctx context.Context, entityType params.PoolType, entityID, poolID string, preload ...string) (Pool, error) {
 	if entityID == "" {
 		return Pool{}, errors.Wrap(runnerErrors.ErrBadRequest, "missing entity id")
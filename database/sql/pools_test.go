@@ -20,6 +20,7 @@ import (
 	"fmt"
 	"regexp"
 	"testing"
+	"time"
 
 	dbCommon "github.com/cloudbase/garm/database/common"
 	garmTesting "github.com/cloudbase/garm/internal/testing"
@@ -61,7 +62,7 @@ func (s *PoolsTestSuite) SetupTest() {
 	s.Store = db
 
 	// create an organization for testing purposes
-	org, err := s.Store.CreateOrganization(context.Background(), "test-org", "test-creds", "test-webhookSecret")
+	org, err := s.Store.CreateOrganization(context.Background(), "https://github.com", "test-org", "test-creds", "test-webhookSecret")
 	if err != nil {
 		s.FailNow(fmt.Sprintf("failed to create org: %s", err))
 	}
@@ -128,7 +129,7 @@ func (s *PoolsTestSuite) TestListAllPools() {
 
 func (s *PoolsTestSuite) TestListAllPoolsDBFetchErr() {
 	s.Fixtures.SQLMock.
-		ExpectQuery(regexp.QuoteMeta("SELECT `pools`.`id`,`pools`.`created_at`,`pools`.`updated_at`,`pools`.`deleted_at`,`pools`.`provider_name`,`pools`.`runner_prefix`,`pools`.`max_runners`,`pools`.`min_idle_runners`,`pools`.`runner_bootstrap_timeout`,`pools`.`image`,`pools`.`flavor`,`pools`.`os_type`,`pools`.`os_arch`,`pools`.`enabled`,`pools`.`git_hub_runner_group`,`pools`.`repo_id`,`pools`.`org_id`,`pools`.`enterprise_id` FROM `pools` WHERE `pools`.`deleted_at` IS NULL")).
+		ExpectQuery(regexp.QuoteMeta("SELECT `pools`.`id`,`pools`.`created_at`,`pools`.`updated_at`,`pools`.`deleted_at`,`pools`.`provider_name`,`pools`.`runner_prefix`,`pools`.`max_runners`,`pools`.`min_idle_runners`,`pools`.`warm_runners`,`pools`.`runner_bootstrap_timeout`,`pools`.`image`,`pools`.`flavor`,`pools`.`os_type`,`pools`.`os_arch`,`pools`.`enabled`,`pools`.`git_hub_runner_group`,`pools`.`auto_min_idle_runners`,`pools`.`priority`,`pools`.`weight`,`pools`.`scaling_schedules`,`pools`.`fallback_providers`,`pools`.`drain_started_at`,`pools`.`config_revision`,`pools`.`max_surge`,`pools`.`replacement_canary`,`pools`.`validated_config_revision`,`pools`.`idle_timeout`,`pools`.`max_lifetime`,`pools`.`circuit_breaker_threshold`,`pools`.`circuit_breaker_window`,`pools`.`max_runners_per_repository`,`pools`.`consecutive_failures`,`pools`.`last_failure_at`,`pools`.`circuit_breaker_trips`,`pools`.`circuit_breaker_open_until`,`pools`.`circuit_breaker_reason`,`pools`.`repo_id`,`pools`.`org_id`,`pools`.`enterprise_id` FROM `pools` WHERE `pools`.`deleted_at` IS NULL")).
 		WillReturnError(fmt.Errorf("mocked fetching all pools error"))
 
 	_, err := s.StoreSQLMocked.ListAllPools(context.Background())
@@ -185,6 +186,113 @@ func (s *PoolsTestSuite) TestDeletePoolByIDDBRemoveErr() {
 	s.Require().Equal("removing pool: mocked removing pool error", err.Error())
 }
 
+func (s *PoolsTestSuite) TestUpdatePoolIncreasesConfigRevision() {
+	pool, err := s.Store.UpdateOrganizationPool(context.Background(), s.Fixtures.Org.ID, s.Fixtures.Pools[0].ID, params.UpdatePoolParams{
+		Image: "test-image-updated",
+	})
+
+	s.Require().Nil(err)
+	s.Require().Equal(s.Fixtures.Pools[0].ConfigRevision+1, pool.ConfigRevision)
+}
+
+func (s *PoolsTestSuite) TestUpdatePoolSameConfigKeepsConfigRevision() {
+	var maxRunners uint = 10
+	pool, err := s.Store.UpdateOrganizationPool(context.Background(), s.Fixtures.Org.ID, s.Fixtures.Pools[0].ID, params.UpdatePoolParams{
+		Image:      s.Fixtures.Pools[0].Image,
+		Tags:       []string{"linux", "amd64", "self-hosted"},
+		MaxRunners: &maxRunners,
+	})
+
+	s.Require().Nil(err)
+	s.Require().Equal(s.Fixtures.Pools[0].ConfigRevision, pool.ConfigRevision)
+}
+
+func (s *PoolsTestSuite) TestUpdatePoolTagsIncreasesConfigRevision() {
+	pool, err := s.Store.UpdateOrganizationPool(context.Background(), s.Fixtures.Org.ID, s.Fixtures.Pools[0].ID, params.UpdatePoolParams{
+		Tags: []string{"self-hosted", "arm64", "linux"},
+	})
+
+	s.Require().Nil(err)
+	s.Require().Equal(s.Fixtures.Pools[0].ConfigRevision+1, pool.ConfigRevision)
+}
+
+func (s *PoolsTestSuite) TestCreateInstanceRecordsPoolConfigRevision() {
+	pool, err := s.Store.UpdateOrganizationPool(context.Background(), s.Fixtures.Org.ID, s.Fixtures.Pools[0].ID, params.UpdatePoolParams{
+		Flavor: "test-flavor-updated",
+	})
+	s.Require().Nil(err)
+
+	instance, err := s.Store.CreateInstance(context.Background(), pool.ID, params.CreateInstanceParams{
+		Name:   "test-instance",
+		OSType: "linux",
+	})
+
+	s.Require().Nil(err)
+	s.Require().Equal(pool.ConfigRevision, instance.PoolConfigRevision)
+}
+
+func (s *PoolsTestSuite) TestSetPoolValidatedConfigRevision() {
+	err := s.Store.SetPoolValidatedConfigRevision(context.Background(), s.Fixtures.Pools[0].ID, 2)
+	s.Require().Nil(err)
+
+	// Older revisions do not overwrite newer ones.
+	err = s.Store.SetPoolValidatedConfigRevision(context.Background(), s.Fixtures.Pools[0].ID, 1)
+	s.Require().Nil(err)
+
+	pool, err := s.Store.GetPoolByID(context.Background(), s.Fixtures.Pools[0].ID)
+	s.Require().Nil(err)
+	s.Require().Equal(uint(2), pool.ValidatedConfigRevision)
+}
+
+func (s *PoolsTestSuite) TestSetPoolValidatedConfigRevisionInvalidPoolID() {
+	err := s.Store.SetPoolValidatedConfigRevision(context.Background(), "dummy-pool-id", 1)
+
+	s.Require().NotNil(err)
+	s.Require().Equal("fetching pool by ID: parsing id: invalid request", err.Error())
+}
+
+func (s *PoolsTestSuite) TestSetPoolCircuitBreaker() {
+	now := time.Now().UTC()
+	openUntil := now.Add(5 * time.Minute)
+	breaker := params.CircuitBreaker{
+		ConsecutiveFailures: 5,
+		LastFailureAt:       &now,
+		Trips:               1,
+		OpenUntil:           &openUntil,
+		FailureReason:       "failed to create instance",
+	}
+
+	pool, err := s.Store.SetPoolCircuitBreaker(context.Background(), s.Fixtures.Pools[0].ID, breaker)
+
+	s.Require().Nil(err)
+	s.Require().Equal(breaker.ConsecutiveFailures, pool.CircuitBreaker.ConsecutiveFailures)
+	s.Require().Equal(breaker.Trips, pool.CircuitBreaker.Trips)
+	s.Require().Equal(breaker.FailureReason, pool.CircuitBreaker.FailureReason)
+
+	stored, err := s.Store.GetPoolByID(context.Background(), s.Fixtures.Pools[0].ID)
+	s.Require().Nil(err)
+	s.Require().True(stored.CircuitBreaker.IsOpen(now))
+	s.Require().True(openUntil.Equal(*stored.CircuitBreaker.OpenUntil))
+	s.Require().Equal(breaker.FailureReason, stored.CircuitBreaker.FailureReason)
+}
+
+func (s *PoolsTestSuite) TestSetPoolCircuitBreakerReset() {
+	now := time.Now().UTC()
+	_, err := s.Store.SetPoolCircuitBreaker(context.Background(), s.Fixtures.Pools[0].ID, params.CircuitBreaker{
+		ConsecutiveFailures: 5,
+		OpenUntil:           &now,
+		FailureReason:       "failed to create instance",
+	})
+	s.Require().Nil(err)
+
+	_, err = s.Store.SetPoolCircuitBreaker(context.Background(), s.Fixtures.Pools[0].ID, params.CircuitBreaker{})
+	s.Require().Nil(err)
+
+	stored, err := s.Store.GetPoolByID(context.Background(), s.Fixtures.Pools[0].ID)
+	s.Require().Nil(err)
+	s.Require().Equal(params.CircuitBreaker{}, stored.CircuitBreaker)
+}
+
 func TestPoolsTestSuite(t *testing.T) {
 	t.Parallel()
 	suite.Run(t, new(PoolsTestSuite))
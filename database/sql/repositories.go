@@ -28,7 +28,7 @@ import (
 	"gorm.io/gorm"
 )
 
-func (s *sqlDatabase) CreateRepository(ctx context.Context, owner, name, credentialsName, webhookSecret string) (params.Repository, error) {
+func (s *sqlDatabase) CreateRepository(ctx context.Context, endpoint, owner, name, credentialsName, webhookSecret string) (params.Repository, error) {
 	if webhookSecret == "" {
 		return params.Repository{}, errors.New("creating repo: missing secret")
 	}
@@ -37,6 +37,7 @@ func (s *sqlDatabase) CreateRepository(ctx context.Context, owner, name, credent
 		return params.Repository{}, fmt.Errorf("failed to encrypt string")
 	}
 	newRepo := Repository{
+		Endpoint:        endpoint,
 		Name:            name,
 		Owner:           owner,
 		WebhookSecret:   secret,
@@ -56,8 +57,8 @@ func (s *sqlDatabase) CreateRepository(ctx context.Context, owner, name, credent
 	return param, nil
 }
 
-func (s *sqlDatabase) GetRepository(ctx context.Context, owner, name string) (params.Repository, error) {
-	repo, err := s.getRepo(ctx, owner, name)
+func (s *sqlDatabase) GetRepository(ctx context.Context, endpoint, owner, name string) (params.Repository, error) {
+	repo, err := s.getRepo(ctx, endpoint, owner, name)
 	if err != nil {
 		return params.Repository{}, errors.Wrap(err, "fetching repo")
 	}
@@ -121,6 +122,18 @@ func (s *sqlDatabase) UpdateRepository(ctx context.Context, repoID string, param
 		repo.WebhookSecret = secret
 	}
 
+	if param.PollingEnabled != nil {
+		repo.PollingEnabled = *param.PollingEnabled
+	}
+
+	if param.PollingInterval != nil {
+		repo.PollingInterval = *param.PollingInterval
+	}
+
+	if param.PoolSelectionStrategy != "" {
+		repo.PoolSelectionStrategy = param.PoolSelectionStrategy
+	}
+
 	q := s.conn.Save(&repo)
 	if q.Error != nil {
 		return params.Repository{}, errors.Wrap(q.Error, "saving repo")
@@ -133,6 +146,18 @@ func (s *sqlDatabase) UpdateRepository(ctx context.Context, repoID string, param
 	return newParams, nil
 }
 
+func (s *sqlDatabase) SetRepositoryManagedHookID(ctx context.Context, repoID string, hookID int64) error {
+	repo, err := s.getRepoByID(ctx, repoID)
+	if err != nil {
+		return errors.Wrap(err, "fetching repo")
+	}
+
+	if q := s.conn.Model(&repo).UpdateColumn("managed_hook_id", hookID); q.Error != nil {
+		return errors.Wrap(q.Error, "saving repo")
+	}
+	return nil
+}
+
 func (s *sqlDatabase) GetRepositoryByID(ctx context.Context, repoID string) (params.Repository, error) {
 	repo, err := s.getRepoByID(ctx, repoID, "Pools")
 	if err != nil {
@@ -157,23 +182,48 @@ func (s *sqlDatabase) CreateRepositoryPool(ctx context.Context, repoId string, p
 	}
 
 	newPool := Pool{
-		ProviderName:           param.ProviderName,
-		MaxRunners:             param.MaxRunners,
-		MinIdleRunners:         param.MinIdleRunners,
-		RunnerPrefix:           param.GetRunnerPrefix(),
-		Image:                  param.Image,
-		Flavor:                 param.Flavor,
-		OSType:                 param.OSType,
-		OSArch:                 param.OSArch,
-		RepoID:                 &repo.ID,
-		Enabled:                param.Enabled,
-		RunnerBootstrapTimeout: param.RunnerBootstrapTimeout,
+		ProviderName:            param.ProviderName,
+		MaxRunners:              param.MaxRunners,
+		MinIdleRunners:          param.MinIdleRunners,
+		WarmRunners:             param.WarmRunners,
+		RunnerPrefix:            param.GetRunnerPrefix(),
+		Image:                   param.Image,
+		Flavor:                  param.Flavor,
+		OSType:                  param.OSType,
+		OSArch:                  param.OSArch,
+		RepoID:                  &repo.ID,
+		Enabled:                 param.Enabled,
+		RunnerBootstrapTimeout:  param.RunnerBootstrapTimeout,
+		AutoMinIdleRunners:      param.AutoMinIdleRunners,
+		Priority:                param.Priority,
+		Weight:                  param.Weight,
+		MaxSurge:                param.MaxSurge,
+		ReplacementCanary:       param.ReplacementCanary,
+		IdleTimeout:             param.IdleTimeout,
+		MaxLifetime:             param.MaxLifetime,
+		CircuitBreakerThreshold: param.CircuitBreakerThreshold,
+		CircuitBreakerWindow:    param.CircuitBreakerWindow,
+		MaxRunnersPerRepository: param.MaxRunnersPerRepository,
 	}
 
 	if len(param.ExtraSpecs) > 0 {
 		newPool.ExtraSpecs = datatypes.JSON(param.ExtraSpecs)
 	}
 
+	if len(param.ScalingSchedules) > 0 {
+		newPool.ScalingSchedules, err = scalingSchedulesToJSON(param.ScalingSchedules)
+		if err != nil {
+			return params.Pool{}, errors.Wrap(err, "creating pool")
+		}
+	}
+
+	if len(param.FallbackProviders) > 0 {
+		newPool.FallbackProviders, err = fallbackProvidersToJSON(param.FallbackProviders)
+		if err != nil {
+			return params.Pool{}, errors.Wrap(err, "creating pool")
+		}
+	}
+
 	_, err = s.getRepoPoolByUniqueFields(ctx, repoId, newPool.ProviderName, newPool.Image, newPool.Flavor)
 	if err != nil {
 		if !errors.Is(err, runnerErrors.ErrNotFound) {
@@ -277,10 +327,10 @@ func (s *sqlDatabase) UpdateRepositoryPool(ctx context.Context, repoID, poolID s
 	return s.updatePool(pool, param)
 }
 
-func (s *sqlDatabase) getRepo(ctx context.Context, owner, name string) (Repository, error) {
+func (s *sqlDatabase) getRepo(ctx context.Context, endpoint, owner, name string) (Repository, error) {
 	var repo Repository
 
-	q := s.conn.Where("name = ? COLLATE NOCASE and owner = ? COLLATE NOCASE", name, owner).
+	q := s.conn.Where("name = ? COLLATE NOCASE and owner = ? COLLATE NOCASE and endpoint = ? COLLATE NOCASE", name, owner, endpoint).
 		First(&repo)
 
 	q = q.First(&repo)
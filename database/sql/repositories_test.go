@@ -23,6 +23,7 @@ import (
 	"testing"
 
 	dbCommon "github.com/cloudbase/garm/database/common"
+	runnerErrors "github.com/cloudbase/garm/errors"
 	garmTesting "github.com/cloudbase/garm/internal/testing"
 	"github.com/cloudbase/garm/params"
 
@@ -92,6 +93,7 @@ func (s *RepoTestSuite) SetupTest() {
 	for i := 1; i <= 3; i++ {
 		repo, err := db.CreateRepository(
 			context.Background(),
+			"https://github.com",
 			fmt.Sprintf("test-owner-%d", i),
 			fmt.Sprintf("test-repo-%d", i),
 			fmt.Sprintf("test-creds-%d", i),
@@ -172,6 +174,7 @@ func (s *RepoTestSuite) TestCreateRepository() {
 	// call tested function
 	repo, err := s.Store.CreateRepository(
 		context.Background(),
+		"https://github.com",
 		s.Fixtures.CreateRepoParams.Owner,
 		s.Fixtures.CreateRepoParams.Name,
 		s.Fixtures.CreateRepoParams.CredentialsName,
@@ -205,6 +208,7 @@ func (s *RepoTestSuite) TestCreateRepositoryInvalidDBPassphrase() {
 
 	_, err = sqlDB.CreateRepository(
 		context.Background(),
+		"https://github.com",
 		s.Fixtures.CreateRepoParams.Owner,
 		s.Fixtures.CreateRepoParams.Name,
 		s.Fixtures.CreateRepoParams.CredentialsName,
@@ -224,6 +228,7 @@ func (s *RepoTestSuite) TestCreateRepositoryInvalidDBCreateErr() {
 
 	_, err := s.StoreSQLMocked.CreateRepository(
 		context.Background(),
+		"https://github.com",
 		s.Fixtures.CreateRepoParams.Owner,
 		s.Fixtures.CreateRepoParams.Name,
 		s.Fixtures.CreateRepoParams.CredentialsName,
@@ -236,7 +241,7 @@ func (s *RepoTestSuite) TestCreateRepositoryInvalidDBCreateErr() {
 }
 
 func (s *RepoTestSuite) TestGetRepository() {
-	repo, err := s.Store.GetRepository(context.Background(), s.Fixtures.Repos[0].Owner, s.Fixtures.Repos[0].Name)
+	repo, err := s.Store.GetRepository(context.Background(), "https://github.com", s.Fixtures.Repos[0].Owner, s.Fixtures.Repos[0].Name)
 
 	s.Require().Nil(err)
 	s.Require().Equal(s.Fixtures.Repos[0].Owner, repo.Owner)
@@ -245,15 +250,67 @@ func (s *RepoTestSuite) TestGetRepository() {
 }
 
 func (s *RepoTestSuite) TestGetRepositoryCaseInsensitive() {
-	repo, err := s.Store.GetRepository(context.Background(), "TeSt-oWnEr-1", "TeSt-rEpO-1")
+	repo, err := s.Store.GetRepository(context.Background(), "https://github.com", "TeSt-oWnEr-1", "TeSt-rEpO-1")
 
 	s.Require().Nil(err)
 	s.Require().Equal("test-owner-1", repo.Owner)
 	s.Require().Equal("test-repo-1", repo.Name)
 }
 
+func (s *RepoTestSuite) TestGetRepositoryOtherEndpoint() {
+	repo, err := s.Store.CreateRepository(context.Background(), "https://ghes.example.com", s.Fixtures.Repos[0].Owner, s.Fixtures.Repos[0].Name, "test-creds", "test-webhook-secret")
+	if err != nil {
+		s.FailNow(fmt.Sprintf("failed to create repository: %s", err))
+	}
+
+	ghesRepo, err := s.Store.GetRepository(context.Background(), "https://ghes.example.com", s.Fixtures.Repos[0].Owner, s.Fixtures.Repos[0].Name)
+	s.Require().Nil(err)
+	s.Require().Equal(repo.ID, ghesRepo.ID)
+	s.Require().Equal("https://ghes.example.com", ghesRepo.Endpoint)
+
+	githubRepo, err := s.Store.GetRepository(context.Background(), "https://github.com", s.Fixtures.Repos[0].Owner, s.Fixtures.Repos[0].Name)
+	s.Require().Nil(err)
+	s.Require().Equal(s.Fixtures.Repos[0].ID, githubRepo.ID)
+}
+
+func (s *RepoTestSuite) TestCreateRepositoryDuplicateOnSameEndpoint() {
+	_, err := s.Store.CreateRepository(context.Background(), "https://github.com", s.Fixtures.Repos[0].Owner, s.Fixtures.Repos[0].Name, "test-creds", "test-webhook-secret")
+
+	s.Require().NotNil(err)
+}
+
+func (s *RepoTestSuite) TestMigrateDropsRepositoryNameIndex() {
+	sqlDB := s.Store.(*sqlDatabase)
+	// The unique index used before the endpoint was part of the repository identity.
+	if err := sqlDB.conn.Exec("CREATE UNIQUE INDEX idx_owner_nocase ON repositories (owner COLLATE NOCASE, name COLLATE NOCASE)").Error; err != nil {
+		s.FailNow(fmt.Sprintf("failed to create index: %s", err))
+	}
+
+	err := sqlDB.migrateDB()
+
+	s.Require().Nil(err)
+	s.Require().False(sqlDB.conn.Migrator().HasIndex(&Repository{}, "idx_owner_nocase"))
+	_, err = s.Store.CreateRepository(context.Background(), "https://ghes.example.com", s.Fixtures.Repos[0].Owner, s.Fixtures.Repos[0].Name, "test-creds", "test-webhook-secret")
+	s.Require().Nil(err)
+}
+
+func (s *RepoTestSuite) TestUpdateEntityEndpoint() {
+	err := s.Store.UpdateEntityEndpoint(context.Background(), params.RepositoryPool, s.Fixtures.Repos[0].ID, "https://ghes.example.com")
+
+	s.Require().Nil(err)
+	repo, err := s.Store.GetRepositoryByID(context.Background(), s.Fixtures.Repos[0].ID)
+	s.Require().Nil(err)
+	s.Require().Equal("https://ghes.example.com", repo.Endpoint)
+}
+
+func (s *RepoTestSuite) TestUpdateEntityEndpointInvalidEntityType() {
+	err := s.Store.UpdateEntityEndpoint(context.Background(), params.PoolType("invalid"), s.Fixtures.Repos[0].ID, "https://ghes.example.com")
+
+	s.Require().Equal(runnerErrors.NewBadRequestError("invalid entity type invalid"), err)
+}
+
 func (s *RepoTestSuite) TestGetRepositoryNotFound() {
-	_, err := s.Store.GetRepository(context.Background(), "dummy-owner", "dummy-name")
+	_, err := s.Store.GetRepository(context.Background(), "https://github.com", "dummy-owner", "dummy-name")
 
 	s.Require().NotNil(err)
 	s.Require().Equal("fetching repo: not found", err.Error())
@@ -261,15 +318,15 @@ func (s *RepoTestSuite) TestGetRepositoryNotFound() {
 
 func (s *RepoTestSuite) TestGetRepositoryDBDecryptingErr() {
 	s.Fixtures.SQLMock.
-		ExpectQuery(regexp.QuoteMeta("SELECT * FROM `repositories` WHERE (name = ? COLLATE NOCASE and owner = ? COLLATE NOCASE) AND `repositories`.`deleted_at` IS NULL ORDER BY `repositories`.`id` LIMIT 1")).
-		WithArgs(s.Fixtures.Repos[0].Name, s.Fixtures.Repos[0].Owner).
+		ExpectQuery(regexp.QuoteMeta("SELECT * FROM `repositories` WHERE (name = ? COLLATE NOCASE and owner = ? COLLATE NOCASE and endpoint = ? COLLATE NOCASE) AND `repositories`.`deleted_at` IS NULL ORDER BY `repositories`.`id` LIMIT 1")).
+		WithArgs(s.Fixtures.Repos[0].Name, s.Fixtures.Repos[0].Owner, "https://github.com").
 		WillReturnRows(sqlmock.NewRows([]string{"name", "owner"}).AddRow(s.Fixtures.Repos[0].Name, s.Fixtures.Repos[0].Owner))
 	s.Fixtures.SQLMock.
-		ExpectQuery(regexp.QuoteMeta("SELECT * FROM `repositories` WHERE (name = ? COLLATE NOCASE and owner = ? COLLATE NOCASE) AND `repositories`.`deleted_at` IS NULL ORDER BY `repositories`.`id`,`repositories`.`id` LIMIT 1")).
-		WithArgs(s.Fixtures.Repos[0].Name, s.Fixtures.Repos[0].Owner).
+		ExpectQuery(regexp.QuoteMeta("SELECT * FROM `repositories` WHERE (name = ? COLLATE NOCASE and owner = ? COLLATE NOCASE and endpoint = ? COLLATE NOCASE) AND `repositories`.`deleted_at` IS NULL ORDER BY `repositories`.`id`,`repositories`.`id` LIMIT 1")).
+		WithArgs(s.Fixtures.Repos[0].Name, s.Fixtures.Repos[0].Owner, "https://github.com").
 		WillReturnRows(sqlmock.NewRows([]string{"name", "owner"}).AddRow(s.Fixtures.Repos[0].Name, s.Fixtures.Repos[0].Owner))
 
-	_, err := s.StoreSQLMocked.GetRepository(context.Background(), s.Fixtures.Repos[0].Owner, s.Fixtures.Repos[0].Name)
+	_, err := s.StoreSQLMocked.GetRepository(context.Background(), "https://github.com", s.Fixtures.Repos[0].Owner, s.Fixtures.Repos[0].Name)
 
 	s.assertSQLMockExpectations()
 	s.Require().NotNil(err)
@@ -352,6 +409,45 @@ func (s *RepoTestSuite) TestUpdateRepository() {
 	s.Require().Equal(s.Fixtures.UpdateRepoParams.WebhookSecret, repo.WebhookSecret)
 }
 
+func (s *RepoTestSuite) TestUpdateRepositoryPolling() {
+	pollingEnabled := true
+	var pollingInterval uint = 30
+	updateParams := params.UpdateEntityParams{
+		PollingEnabled:  &pollingEnabled,
+		PollingInterval: &pollingInterval,
+	}
+
+	repo, err := s.Store.UpdateRepository(context.Background(), s.Fixtures.Repos[0].ID, updateParams)
+
+	s.Require().Nil(err)
+	s.Require().True(repo.PollingEnabled)
+	s.Require().Equal(pollingInterval, repo.PollingInterval)
+	// Fields that were not set are left untouched.
+	s.Require().Equal(s.Fixtures.Repos[0].CredentialsName, repo.CredentialsName)
+	s.Require().Equal(s.Fixtures.Repos[0].WebhookSecret, repo.WebhookSecret)
+
+	stored, err := s.Store.GetRepositoryByID(context.Background(), s.Fixtures.Repos[0].ID)
+	s.Require().Nil(err)
+	s.Require().True(stored.PollingEnabled)
+	s.Require().Equal(pollingInterval, stored.PollingInterval)
+}
+
+func (s *RepoTestSuite) TestUpdateRepositoryPoolSelectionStrategy() {
+	updateParams := params.UpdateEntityParams{
+		PoolSelectionStrategy: params.PoolSelectionPriority,
+	}
+
+	repo, err := s.Store.UpdateRepository(context.Background(), s.Fixtures.Repos[0].ID, updateParams)
+
+	s.Require().Nil(err)
+	s.Require().Equal(params.PoolSelectionPriority, repo.PoolSelectionStrategy)
+
+	// An empty strategy leaves the stored one untouched.
+	repo, err = s.Store.UpdateRepository(context.Background(), s.Fixtures.Repos[0].ID, params.UpdateEntityParams{})
+	s.Require().Nil(err)
+	s.Require().Equal(params.PoolSelectionPriority, repo.PoolSelectionStrategy)
+}
+
 func (s *RepoTestSuite) TestUpdateRepositoryInvalidRepoID() {
 	_, err := s.Store.UpdateRepository(context.Background(), "dummy-repo-id", s.Fixtures.UpdateRepoParams)
 
@@ -203,6 +203,20 @@ func (s *sqlDatabase) migrateDB() error {
 		}
 	}
 
+	// The github endpoint is now part of the unique fields of repositories, organizations
+	// and enterprises. Drop the indexes that only cover the name.
+	for model, index := range map[interface{}]string{
+		&Repository{}:   "idx_owner_nocase",
+		&Organization{}: "idx_org_name_nocase",
+		&Enterprise{}:   "idx_ent_name_nocase",
+	} {
+		if s.conn.Migrator().HasIndex(model, index) {
+			if err := s.conn.Migrator().DropIndex(model, index); err != nil {
+				log.Printf("failed to drop index %s: %s", index, err)
+			}
+		}
+	}
+
 	if err := s.cascadeMigration(); err != nil {
 		return errors.Wrap(err, "running cascade migration")
 	}
@@ -227,12 +241,16 @@ func (s *sqlDatabase) migrateDB() error {
 		&Repository{},
 		&Organization{},
 		&Enterprise{},
+		&GithubCredentials{},
 		&Address{},
 		&InstanceStatusUpdate{},
 		&Instance{},
 		&ControllerInfo{},
 		&User{},
 		&WorkflowJob{},
+		&WebhookDelivery{},
+		&PoolDemandStat{},
+		&ProviderDrain{},
 	); err != nil {
 		return errors.Wrap(err, "running auto migrate")
 	}
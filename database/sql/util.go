@@ -15,8 +15,10 @@
 package sql
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
+	"time"
 
 	"github.com/cloudbase/garm/params"
 	"github.com/cloudbase/garm/util"
@@ -35,25 +37,29 @@ func (s *sqlDatabase) sqlToParamsInstance(instance Instance) params.Instance {
 	var labels []string
 	_ = json.Unmarshal(instance.AditionalLabels, &labels)
 	ret := params.Instance{
-		ID:                instance.ID.String(),
-		ProviderID:        id,
-		AgentID:           instance.AgentID,
-		Name:              instance.Name,
-		OSType:            instance.OSType,
-		OSName:            instance.OSName,
-		OSVersion:         instance.OSVersion,
-		OSArch:            instance.OSArch,
-		Status:            instance.Status,
-		RunnerStatus:      instance.RunnerStatus,
-		PoolID:            instance.PoolID.String(),
-		CallbackURL:       instance.CallbackURL,
-		MetadataURL:       instance.MetadataURL,
-		StatusMessages:    []params.StatusMessage{},
-		CreateAttempt:     instance.CreateAttempt,
-		UpdatedAt:         instance.UpdatedAt,
-		TokenFetched:      instance.TokenFetched,
-		GitHubRunnerGroup: instance.GitHubRunnerGroup,
-		AditionalLabels:   labels,
+		ID:                 instance.ID.String(),
+		ProviderID:         id,
+		AgentID:            instance.AgentID,
+		Name:               instance.Name,
+		OSType:             instance.OSType,
+		OSName:             instance.OSName,
+		OSVersion:          instance.OSVersion,
+		OSArch:             instance.OSArch,
+		Status:             instance.Status,
+		RunnerStatus:       instance.RunnerStatus,
+		PoolID:             instance.PoolID.String(),
+		CallbackURL:        instance.CallbackURL,
+		MetadataURL:        instance.MetadataURL,
+		StatusMessages:     []params.StatusMessage{},
+		CreateAttempt:      instance.CreateAttempt,
+		QuotaAttempt:       instance.QuotaAttempt,
+		CreatedAt:          instance.CreatedAt,
+		UpdatedAt:          instance.UpdatedAt,
+		TokenFetched:       instance.TokenFetched,
+		GitHubRunnerGroup:  instance.GitHubRunnerGroup,
+		AditionalLabels:    labels,
+		PoolConfigRevision: instance.PoolConfigRevision,
+		ProviderName:       instance.ProviderName,
 	}
 
 	if len(instance.ProviderFault) > 0 {
@@ -95,8 +101,18 @@ func (s *sqlDatabase) sqlToCommonOrganization(org Organization) (params.Organiza
 		ID:              org.ID.String(),
 		Name:            org.Name,
 		CredentialsName: org.CredentialsName,
+		Endpoint:        org.Endpoint,
 		Pools:           make([]params.Pool, len(org.Pools)),
 		WebhookSecret:   secret,
+		PollingEnabled:  org.PollingEnabled,
+		PollingInterval: org.PollingInterval,
+		ManagedHookID:   org.ManagedHookID,
+
+		PoolSelectionStrategy: org.PoolSelectionStrategy,
+		JobSchedulingStrategy: org.JobSchedulingStrategy,
+
+		WebhookLastSeenAt: org.WebhookLastSeenAt,
+		WebhookVerifiedAt: org.WebhookVerifiedAt,
 	}
 
 	for idx, pool := range org.Pools {
@@ -119,8 +135,15 @@ func (s *sqlDatabase) sqlToCommonEnterprise(enterprise Enterprise) (params.Enter
 		ID:              enterprise.ID.String(),
 		Name:            enterprise.Name,
 		CredentialsName: enterprise.CredentialsName,
+		Endpoint:        enterprise.Endpoint,
 		Pools:           make([]params.Pool, len(enterprise.Pools)),
 		WebhookSecret:   secret,
+
+		PoolSelectionStrategy: enterprise.PoolSelectionStrategy,
+		JobSchedulingStrategy: enterprise.JobSchedulingStrategy,
+
+		WebhookLastSeenAt: enterprise.WebhookLastSeenAt,
+		WebhookVerifiedAt: enterprise.WebhookVerifiedAt,
 	}
 
 	for idx, pool := range enterprise.Pools {
@@ -136,19 +159,47 @@ func (s *sqlDatabase) sqlToCommonPool(pool Pool) params.Pool {
 		ProviderName:   pool.ProviderName,
 		MaxRunners:     pool.MaxRunners,
 		MinIdleRunners: pool.MinIdleRunners,
+		WarmRunners:    pool.WarmRunners,
 		RunnerPrefix: params.RunnerPrefix{
 			Prefix: pool.RunnerPrefix,
 		},
-		Image:                  pool.Image,
-		Flavor:                 pool.Flavor,
-		OSArch:                 pool.OSArch,
-		OSType:                 pool.OSType,
-		Enabled:                pool.Enabled,
-		Tags:                   make([]params.Tag, len(pool.Tags)),
-		Instances:              make([]params.Instance, len(pool.Instances)),
-		RunnerBootstrapTimeout: pool.RunnerBootstrapTimeout,
-		ExtraSpecs:             json.RawMessage(pool.ExtraSpecs),
-		GitHubRunnerGroup:      pool.GitHubRunnerGroup,
+		Image:                   pool.Image,
+		Flavor:                  pool.Flavor,
+		OSArch:                  pool.OSArch,
+		OSType:                  pool.OSType,
+		Enabled:                 pool.Enabled,
+		Tags:                    make([]params.Tag, len(pool.Tags)),
+		Instances:               make([]params.Instance, len(pool.Instances)),
+		RunnerBootstrapTimeout:  pool.RunnerBootstrapTimeout,
+		ExtraSpecs:              json.RawMessage(pool.ExtraSpecs),
+		GitHubRunnerGroup:       pool.GitHubRunnerGroup,
+		AutoMinIdleRunners:      pool.AutoMinIdleRunners,
+		Priority:                pool.Priority,
+		Weight:                  pool.Weight,
+		Draining:                pool.DrainStartedAt != nil,
+		DrainStartedAt:          pool.DrainStartedAt,
+		ConfigRevision:          pool.ConfigRevision,
+		MaxSurge:                pool.MaxSurge,
+		ReplacementCanary:       pool.ReplacementCanary,
+		ValidatedConfigRevision: pool.ValidatedConfigRevision,
+		IdleTimeout:             pool.IdleTimeout,
+		MaxLifetime:             pool.MaxLifetime,
+		CircuitBreakerThreshold: pool.CircuitBreakerThreshold,
+		CircuitBreakerWindow:    pool.CircuitBreakerWindow,
+		MaxRunnersPerRepository: pool.MaxRunnersPerRepository,
+		CircuitBreaker: params.CircuitBreaker{
+			ConsecutiveFailures: pool.ConsecutiveFailures,
+			LastFailureAt:       pool.LastFailureAt,
+			Trips:               pool.CircuitBreakerTrips,
+			OpenUntil:           pool.CircuitBreakerOpenUntil,
+			FailureReason:       pool.CircuitBreakerReason,
+		},
+	}
+
+	_ = json.Unmarshal(pool.ScalingSchedules, &ret.ScalingSchedules)
+	_ = json.Unmarshal(pool.FallbackProviders, &ret.FallbackProviders)
+	if schedule, ok := ret.ScalingScheduleAt(time.Now()); ok {
+		ret.ActiveScalingSchedule = schedule.Name
 	}
 
 	if pool.RepoID != nil {
@@ -179,6 +230,22 @@ func (s *sqlDatabase) sqlToCommonPool(pool Pool) params.Pool {
 	return ret
 }
 
+func scalingSchedulesToJSON(schedules []params.ScalingSchedule) (datatypes.JSON, error) {
+	asJSON, err := json.Marshal(schedules)
+	if err != nil {
+		return nil, errors.Wrap(err, "marshaling scaling schedules")
+	}
+	return datatypes.JSON(asJSON), nil
+}
+
+func fallbackProvidersToJSON(fallbacks []params.PoolProvider) (datatypes.JSON, error) {
+	asJSON, err := json.Marshal(fallbacks)
+	if err != nil {
+		return nil, errors.Wrap(err, "marshaling fallback providers")
+	}
+	return datatypes.JSON(asJSON), nil
+}
+
 func (s *sqlDatabase) sqlToCommonTags(tag Tag) params.Tag {
 	return params.Tag{
 		ID:   tag.ID.String(),
@@ -200,8 +267,17 @@ func (s *sqlDatabase) sqlToCommonRepository(repo Repository) (params.Repository,
 		Name:            repo.Name,
 		Owner:           repo.Owner,
 		CredentialsName: repo.CredentialsName,
+		Endpoint:        repo.Endpoint,
 		Pools:           make([]params.Pool, len(repo.Pools)),
 		WebhookSecret:   secret,
+		PollingEnabled:  repo.PollingEnabled,
+		PollingInterval: repo.PollingInterval,
+		ManagedHookID:   repo.ManagedHookID,
+
+		PoolSelectionStrategy: repo.PoolSelectionStrategy,
+
+		WebhookLastSeenAt: repo.WebhookLastSeenAt,
+		WebhookVerifiedAt: repo.WebhookVerifiedAt,
 	}
 
 	for idx, pool := range repo.Pools {
@@ -246,6 +322,10 @@ func (s *sqlDatabase) getOrCreateTag(tagName string) (Tag, error) {
 }
 
 func (s *sqlDatabase) updatePool(pool Pool, param params.UpdatePoolParams) (params.Pool, error) {
+	if poolConfigChanged(pool, param) {
+		pool.ConfigRevision++
+	}
+
 	if param.Enabled != nil && pool.Enabled != *param.Enabled {
 		pool.Enabled = *param.Enabled
 	}
@@ -270,6 +350,50 @@ func (s *sqlDatabase) updatePool(pool Pool, param params.UpdatePoolParams) (para
 		pool.MinIdleRunners = *param.MinIdleRunners
 	}
 
+	if param.WarmRunners != nil {
+		pool.WarmRunners = *param.WarmRunners
+	}
+
+	if param.AutoMinIdleRunners != nil {
+		pool.AutoMinIdleRunners = *param.AutoMinIdleRunners
+	}
+
+	if param.Priority != nil {
+		pool.Priority = *param.Priority
+	}
+
+	if param.Weight != nil {
+		pool.Weight = *param.Weight
+	}
+
+	if param.MaxSurge != nil {
+		pool.MaxSurge = *param.MaxSurge
+	}
+
+	if param.ReplacementCanary != nil {
+		pool.ReplacementCanary = *param.ReplacementCanary
+	}
+
+	if param.IdleTimeout != nil {
+		pool.IdleTimeout = *param.IdleTimeout
+	}
+
+	if param.MaxLifetime != nil {
+		pool.MaxLifetime = *param.MaxLifetime
+	}
+
+	if param.CircuitBreakerThreshold != nil {
+		pool.CircuitBreakerThreshold = *param.CircuitBreakerThreshold
+	}
+
+	if param.CircuitBreakerWindow != nil {
+		pool.CircuitBreakerWindow = *param.CircuitBreakerWindow
+	}
+
+	if param.MaxRunnersPerRepository != nil {
+		pool.MaxRunnersPerRepository = *param.MaxRunnersPerRepository
+	}
+
 	if param.OSArch != "" {
 		pool.OSArch = param.OSArch
 	}
@@ -282,6 +406,22 @@ func (s *sqlDatabase) updatePool(pool Pool, param params.UpdatePoolParams) (para
 		pool.ExtraSpecs = datatypes.JSON(param.ExtraSpecs)
 	}
 
+	if param.ScalingSchedules != nil {
+		schedules, err := scalingSchedulesToJSON(param.ScalingSchedules)
+		if err != nil {
+			return params.Pool{}, errors.Wrap(err, "updating pool")
+		}
+		pool.ScalingSchedules = schedules
+	}
+
+	if param.FallbackProviders != nil {
+		fallbacks, err := fallbackProvidersToJSON(param.FallbackProviders)
+		if err != nil {
+			return params.Pool{}, errors.Wrap(err, "updating pool")
+		}
+		pool.FallbackProviders = fallbacks
+	}
+
 	if param.RunnerBootstrapTimeout != nil && *param.RunnerBootstrapTimeout > 0 {
 		pool.RunnerBootstrapTimeout = *param.RunnerBootstrapTimeout
 	}
@@ -311,3 +451,56 @@ func (s *sqlDatabase) updatePool(pool Pool, param params.UpdatePoolParams) (para
 
 	return s.sqlToCommonPool(pool), nil
 }
+
+// poolConfigChanged returns true if the update changes the image, flavor, extra specs,
+// tags or fallback providers of the pool. Runners created before such a change are stale.
+func poolConfigChanged(pool Pool, param params.UpdatePoolParams) bool {
+	if param.Image != "" && param.Image != pool.Image {
+		return true
+	}
+
+	if param.Flavor != "" && param.Flavor != pool.Flavor {
+		return true
+	}
+
+	if param.ExtraSpecs != nil && !bytes.Equal(param.ExtraSpecs, pool.ExtraSpecs) {
+		return true
+	}
+
+	if len(param.Tags) > 0 {
+		current := map[string]bool{}
+		for _, tag := range pool.Tags {
+			current[tag.Name] = true
+		}
+		wanted := map[string]bool{}
+		for _, tag := range param.Tags {
+			wanted[tag] = true
+		}
+		if len(current) != len(wanted) {
+			return true
+		}
+		for tag := range wanted {
+			if !current[tag] {
+				return true
+			}
+		}
+	}
+
+	if param.FallbackProviders != nil {
+		var current []params.PoolProvider
+		_ = json.Unmarshal(pool.FallbackProviders, &current)
+		if len(current) != len(param.FallbackProviders) {
+			return true
+		}
+		for idx, fallback := range param.FallbackProviders {
+			if fallback.ProviderName != current[idx].ProviderName ||
+				fallback.Image != current[idx].Image ||
+				fallback.Flavor != current[idx].Flavor ||
+				!bytes.Equal(fallback.ExtraSpecs, current[idx].ExtraSpecs) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
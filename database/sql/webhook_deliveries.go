@@ -0,0 +1,252 @@
+// Copyright 2023 Cloudbase Solutions SRL
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package sql
+
+import (
+	"context"
+	"time"
+
+	"github.com/cloudbase/garm/database/common"
+	runnerErrors "github.com/cloudbase/garm/errors"
+	"github.com/cloudbase/garm/params"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var _ common.WebhookDeliveryStore = &sqlDatabase{}
+
+func sqlToParamsWebhookDelivery(delivery WebhookDelivery) params.WebhookDelivery {
+	return params.WebhookDelivery{
+		ID:             delivery.ID,
+		Event:          params.Event(delivery.Event),
+		EntityID:       delivery.EntityID,
+		HookTargetType: delivery.HookTargetType,
+		Payload:        delivery.Payload,
+		Status:         delivery.Status,
+		Attempts:       delivery.Attempts,
+		LastError:      delivery.LastError,
+		NextAttemptAt:  delivery.NextAttemptAt,
+		CreatedAt:      delivery.CreatedAt,
+		UpdatedAt:      delivery.UpdatedAt,
+	}
+}
+
+// CreateWebhookDelivery records a new webhook delivery. Github may send the same
+// delivery more than once. If a delivery with the same ID already exists, a
+// conflict error is returned. If CreatedAt is not set, the current time is used.
+func (s *sqlDatabase) CreateWebhookDelivery(ctx context.Context, param params.WebhookDelivery) (params.WebhookDelivery, error) {
+	if param.ID == "" {
+		return params.WebhookDelivery{}, runnerErrors.NewBadRequestError("missing delivery ID")
+	}
+
+	delivery := WebhookDelivery{
+		ID:             param.ID,
+		Event:          string(param.Event),
+		EntityID:       param.EntityID,
+		HookTargetType: param.HookTargetType,
+		Payload:        param.Payload,
+		Status:         param.Status,
+		Attempts:       param.Attempts,
+		LastError:      param.LastError,
+		NextAttemptAt:  param.NextAttemptAt,
+		CreatedAt:      param.CreatedAt,
+	}
+	if delivery.Status == "" {
+		delivery.Status = params.WebhookDeliveryPending
+	}
+
+	q := s.conn.Clauses(clause.OnConflict{DoNothing: true}).Create(&delivery)
+	if q.Error != nil {
+		return params.WebhookDelivery{}, errors.Wrap(q.Error, "creating webhook delivery")
+	}
+	if q.RowsAffected == 0 {
+		return params.WebhookDelivery{}, runnerErrors.NewConflictError("webhook delivery %s already exists", param.ID)
+	}
+
+	return sqlToParamsWebhookDelivery(delivery), nil
+}
+
+func (s *sqlDatabase) GetWebhookDelivery(ctx context.Context, deliveryID string) (params.WebhookDelivery, error) {
+	delivery, err := s.getWebhookDelivery(deliveryID)
+	if err != nil {
+		return params.WebhookDelivery{}, errors.Wrap(err, "fetching webhook delivery")
+	}
+	return sqlToParamsWebhookDelivery(delivery), nil
+}
+
+func (s *sqlDatabase) ListWebhookDeliveries(ctx context.Context) ([]params.WebhookDelivery, error) {
+	var deliveries []WebhookDelivery
+	q := s.conn.Order("created_at desc").Find(&deliveries)
+	if q.Error != nil {
+		return nil, errors.Wrap(q.Error, "fetching webhook deliveries")
+	}
+
+	ret := make([]params.WebhookDelivery, len(deliveries))
+	for idx, val := range deliveries {
+		ret[idx] = sqlToParamsWebhookDelivery(val)
+	}
+	return ret, nil
+}
+
+func (s *sqlDatabase) ListWebhookDeliveriesByStatus(ctx context.Context, status params.WebhookDeliveryStatus) ([]params.WebhookDelivery, error) {
+	var deliveries []WebhookDelivery
+	q := s.conn.Where("status = ?", status).Order("created_at asc").Find(&deliveries)
+	if q.Error != nil {
+		return nil, errors.Wrap(q.Error, "fetching webhook deliveries")
+	}
+
+	ret := make([]params.WebhookDelivery, len(deliveries))
+	for idx, val := range deliveries {
+		ret[idx] = sqlToParamsWebhookDelivery(val)
+	}
+	return ret, nil
+}
+
+func (s *sqlDatabase) UpdateWebhookDelivery(ctx context.Context, deliveryID string, param params.UpdateWebhookDeliveryParams) (params.WebhookDelivery, error) {
+	delivery, err := s.getWebhookDelivery(deliveryID)
+	if err != nil {
+		return params.WebhookDelivery{}, errors.Wrap(err, "fetching webhook delivery")
+	}
+
+	if param.Status != nil {
+		delivery.Status = *param.Status
+	}
+
+	if param.Attempts != nil {
+		delivery.Attempts = *param.Attempts
+	}
+
+	if param.LastError != nil {
+		delivery.LastError = *param.LastError
+	}
+
+	if param.NextAttemptAt != nil {
+		delivery.NextAttemptAt = *param.NextAttemptAt
+	}
+
+	q := s.conn.Save(&delivery)
+	if q.Error != nil {
+		return params.WebhookDelivery{}, errors.Wrap(q.Error, "saving webhook delivery")
+	}
+	return sqlToParamsWebhookDelivery(delivery), nil
+}
+
+// DeleteWebhookDeliveriesOlderThan removes processed deliveries (completed or failed)
+// that were received before the given time. Pending deliveries are never removed.
+func (s *sqlDatabase) DeleteWebhookDeliveriesOlderThan(ctx context.Context, olderThan time.Time) error {
+	q := s.conn.Where("status <> ? and created_at < ?", params.WebhookDeliveryPending, olderThan).Delete(&WebhookDelivery{})
+	if q.Error != nil {
+		return errors.Wrap(q.Error, "deleting webhook deliveries")
+	}
+	return nil
+}
+
+func (s *sqlDatabase) GetWebhookDeliveryHighWaterMark(ctx context.Context, entityType params.PoolType, entityID string) (time.Time, error) {
+	var mark *time.Time
+	switch entityType {
+	case params.RepositoryPool:
+		repo, err := s.getRepoByID(ctx, entityID)
+		if err != nil {
+			return time.Time{}, errors.Wrap(err, "fetching repo")
+		}
+		mark = repo.LastWebhookDeliveryAt
+	case params.OrganizationPool:
+		org, err := s.getOrgByID(ctx, entityID)
+		if err != nil {
+			return time.Time{}, errors.Wrap(err, "fetching org")
+		}
+		mark = org.LastWebhookDeliveryAt
+	case params.EnterprisePool:
+		enterprise, err := s.getEnterpriseByID(ctx, entityID)
+		if err != nil {
+			return time.Time{}, errors.Wrap(err, "fetching enterprise")
+		}
+		mark = enterprise.LastWebhookDeliveryAt
+	default:
+		return time.Time{}, runnerErrors.NewBadRequestError("invalid entity type %s", entityType)
+	}
+
+	if mark == nil {
+		return time.Time{}, nil
+	}
+	return *mark, nil
+}
+
+func (s *sqlDatabase) UpdateWebhookDeliveryHighWaterMark(ctx context.Context, entityType params.PoolType, entityID string, deliveredAt time.Time) error {
+	u, err := uuid.Parse(entityID)
+	if err != nil {
+		return errors.Wrap(runnerErrors.ErrBadRequest, "parsing id")
+	}
+
+	model, err := entityModel(entityType)
+	if err != nil {
+		return err
+	}
+
+	// Deliveries are processed concurrently, and not necessarily in the order in which
+	// they were received. Never move the mark backwards.
+	q := s.conn.Model(model).
+		Where("id = ?", u).
+		Where("last_webhook_delivery_at is null or last_webhook_delivery_at < ?", deliveredAt).
+		UpdateColumn("last_webhook_delivery_at", deliveredAt)
+	if q.Error != nil {
+		return errors.Wrap(q.Error, "updating webhook delivery high-water mark")
+	}
+	return nil
+}
+
+func (s *sqlDatabase) RecordWebhookSeen(ctx context.Context, entityType params.PoolType, entityID string, verified bool) error {
+	u, err := uuid.Parse(entityID)
+	if err != nil {
+		return errors.Wrap(runnerErrors.ErrBadRequest, "parsing id")
+	}
+
+	model, err := entityModel(entityType)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	updates := map[string]interface{}{
+		"webhook_last_seen_at": now,
+	}
+	if verified {
+		updates["webhook_verified_at"] = now
+	}
+
+	q := s.conn.Model(model).Where("id = ?", u).UpdateColumns(updates)
+	if q.Error != nil {
+		return errors.Wrap(q.Error, "recording webhook")
+	}
+	if q.RowsAffected == 0 {
+		return runnerErrors.ErrNotFound
+	}
+	return nil
+}
+
+func (s *sqlDatabase) getWebhookDelivery(deliveryID string) (WebhookDelivery, error) {
+	var delivery WebhookDelivery
+	q := s.conn.Where("id = ?", deliveryID).First(&delivery)
+	if q.Error != nil {
+		if errors.Is(q.Error, gorm.ErrRecordNotFound) {
+			return WebhookDelivery{}, runnerErrors.ErrNotFound
+		}
+		return WebhookDelivery{}, errors.Wrap(q.Error, "fetching webhook delivery from database")
+	}
+	return delivery, nil
+}
@@ -0,0 +1,261 @@
+// Copyright 2023 Cloudbase Solutions SRL
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package sql
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	dbCommon "github.com/cloudbase/garm/database/common"
+	runnerErrors "github.com/cloudbase/garm/errors"
+	garmTesting "github.com/cloudbase/garm/internal/testing"
+	"github.com/cloudbase/garm/params"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type WebhookDeliveriesTestFixtures struct {
+	Deliveries     []params.WebhookDelivery
+	CreateDelivery params.WebhookDelivery
+	Repo           params.Repository
+}
+
+type WebhookDeliveriesTestSuite struct {
+	suite.Suite
+	Store    dbCommon.Store
+	Fixtures *WebhookDeliveriesTestFixtures
+}
+
+func (s *WebhookDeliveriesTestSuite) SetupTest() {
+	// create testing sqlite database
+	db, err := NewSQLDatabase(context.Background(), garmTesting.GetTestSqliteDBConfig(s.T()))
+	if err != nil {
+		s.FailNow(fmt.Sprintf("failed to create db connection: %s", err))
+	}
+	s.Store = db
+
+	// create some webhook deliveries in the database, for testing purposes
+	deliveries := []params.WebhookDelivery{}
+	for i := 1; i <= 3; i++ {
+		delivery, err := db.CreateWebhookDelivery(
+			context.Background(),
+			params.WebhookDelivery{
+				ID:             fmt.Sprintf("test-delivery-%d", i),
+				Event:          params.WorkflowJobEvent,
+				HookTargetType: "repository",
+				Payload:        []byte(fmt.Sprintf(`{"action": "queued", "id": %d}`, i)),
+			},
+		)
+		if err != nil {
+			s.FailNow(fmt.Sprintf("failed to create database object (test-delivery-%d): %s", i, err))
+		}
+		deliveries = append(deliveries, delivery)
+	}
+
+	repo, err := db.CreateRepository(context.Background(), "https://github.com", "test-owner", "test-repo", "test-creds", "test-webhook-secret")
+	if err != nil {
+		s.FailNow(fmt.Sprintf("failed to create database object (test-repo): %s", err))
+	}
+
+	s.Fixtures = &WebhookDeliveriesTestFixtures{
+		Repo:       repo,
+		Deliveries: deliveries,
+		CreateDelivery: params.WebhookDelivery{
+			ID:             "new-delivery",
+			Event:          params.WorkflowJobEvent,
+			HookTargetType: "organization",
+			Payload:        []byte(`{"action": "completed"}`),
+		},
+	}
+}
+
+func (s *WebhookDeliveriesTestSuite) TestCreateWebhookDelivery() {
+	delivery, err := s.Store.CreateWebhookDelivery(context.Background(), s.Fixtures.CreateDelivery)
+
+	s.Require().Nil(err)
+	s.Require().Equal(s.Fixtures.CreateDelivery.ID, delivery.ID)
+	s.Require().Equal(s.Fixtures.CreateDelivery.Event, delivery.Event)
+	s.Require().Equal(s.Fixtures.CreateDelivery.HookTargetType, delivery.HookTargetType)
+	s.Require().JSONEq(string(s.Fixtures.CreateDelivery.Payload), string(delivery.Payload))
+	s.Require().Equal(params.WebhookDeliveryPending, delivery.Status)
+}
+
+func (s *WebhookDeliveriesTestSuite) TestCreateWebhookDeliveryDuplicate() {
+	_, err := s.Store.CreateWebhookDelivery(context.Background(), params.WebhookDelivery{ID: s.Fixtures.Deliveries[0].ID})
+
+	s.Require().Equal(runnerErrors.NewConflictError("webhook delivery %s already exists", s.Fixtures.Deliveries[0].ID), err)
+
+	// The original delivery is left untouched.
+	delivery, err := s.Store.GetWebhookDelivery(context.Background(), s.Fixtures.Deliveries[0].ID)
+	s.Require().Nil(err)
+	s.Require().JSONEq(string(s.Fixtures.Deliveries[0].Payload), string(delivery.Payload))
+}
+
+func (s *WebhookDeliveriesTestSuite) TestCreateWebhookDeliveryMissingID() {
+	s.Fixtures.CreateDelivery.ID = ""
+
+	_, err := s.Store.CreateWebhookDelivery(context.Background(), s.Fixtures.CreateDelivery)
+
+	s.Require().Equal(runnerErrors.NewBadRequestError("missing delivery ID"), err)
+}
+
+func (s *WebhookDeliveriesTestSuite) TestGetWebhookDeliveryNotFound() {
+	_, err := s.Store.GetWebhookDelivery(context.Background(), "dummy-delivery-id")
+
+	s.Require().Equal("fetching webhook delivery: not found", err.Error())
+}
+
+func (s *WebhookDeliveriesTestSuite) TestListWebhookDeliveries() {
+	deliveries, err := s.Store.ListWebhookDeliveries(context.Background())
+
+	s.Require().Nil(err)
+	s.Require().Len(deliveries, len(s.Fixtures.Deliveries))
+}
+
+func (s *WebhookDeliveriesTestSuite) TestUpdateWebhookDelivery() {
+	status := params.WebhookDeliveryFailed
+	attempts := uint(3)
+	lastError := "test-error"
+	nextAttempt := time.Now().Add(time.Minute).UTC().Truncate(time.Second)
+
+	delivery, err := s.Store.UpdateWebhookDelivery(context.Background(), s.Fixtures.Deliveries[0].ID, params.UpdateWebhookDeliveryParams{
+		Status:        &status,
+		Attempts:      &attempts,
+		LastError:     &lastError,
+		NextAttemptAt: &nextAttempt,
+	})
+
+	s.Require().Nil(err)
+	s.Require().Equal(status, delivery.Status)
+	s.Require().Equal(attempts, delivery.Attempts)
+	s.Require().Equal(lastError, delivery.LastError)
+	s.Require().True(nextAttempt.Equal(delivery.NextAttemptAt))
+}
+
+func (s *WebhookDeliveriesTestSuite) TestListWebhookDeliveriesByStatus() {
+	status := params.WebhookDeliveryCompleted
+	_, err := s.Store.UpdateWebhookDelivery(context.Background(), s.Fixtures.Deliveries[0].ID, params.UpdateWebhookDeliveryParams{Status: &status})
+	if err != nil {
+		s.FailNow(fmt.Sprintf("failed to update webhook delivery: %s", err))
+	}
+
+	pending, err := s.Store.ListWebhookDeliveriesByStatus(context.Background(), params.WebhookDeliveryPending)
+
+	s.Require().Nil(err)
+	s.Require().Len(pending, 2)
+	for _, delivery := range pending {
+		s.Require().NotEqual(s.Fixtures.Deliveries[0].ID, delivery.ID)
+	}
+}
+
+func (s *WebhookDeliveriesTestSuite) TestDeleteWebhookDeliveriesOlderThan() {
+	status := params.WebhookDeliveryCompleted
+	_, err := s.Store.UpdateWebhookDelivery(context.Background(), s.Fixtures.Deliveries[0].ID, params.UpdateWebhookDeliveryParams{Status: &status})
+	if err != nil {
+		s.FailNow(fmt.Sprintf("failed to update webhook delivery: %s", err))
+	}
+
+	err = s.Store.DeleteWebhookDeliveriesOlderThan(context.Background(), time.Now().Add(time.Minute))
+
+	s.Require().Nil(err)
+	deliveries, err := s.Store.ListWebhookDeliveries(context.Background())
+	s.Require().Nil(err)
+	// Pending deliveries are never removed.
+	s.Require().Len(deliveries, 2)
+	_, err = s.Store.GetWebhookDelivery(context.Background(), s.Fixtures.Deliveries[0].ID)
+	s.Require().ErrorIs(err, runnerErrors.ErrNotFound)
+}
+
+func (s *WebhookDeliveriesTestSuite) TestCreateWebhookDeliveryWithCreatedAt() {
+	createdAt := time.Now().Add(-time.Hour).UTC().Truncate(time.Second)
+	s.Fixtures.CreateDelivery.CreatedAt = createdAt
+
+	delivery, err := s.Store.CreateWebhookDelivery(context.Background(), s.Fixtures.CreateDelivery)
+
+	s.Require().Nil(err)
+	s.Require().True(createdAt.Equal(delivery.CreatedAt))
+}
+
+func (s *WebhookDeliveriesTestSuite) TestGetWebhookDeliveryHighWaterMarkNotSet() {
+	mark, err := s.Store.GetWebhookDeliveryHighWaterMark(context.Background(), params.RepositoryPool, s.Fixtures.Repo.ID)
+
+	s.Require().Nil(err)
+	s.Require().True(mark.IsZero())
+}
+
+func (s *WebhookDeliveriesTestSuite) TestUpdateWebhookDeliveryHighWaterMark() {
+	deliveredAt := time.Now().UTC().Truncate(time.Second)
+
+	err := s.Store.UpdateWebhookDeliveryHighWaterMark(context.Background(), params.RepositoryPool, s.Fixtures.Repo.ID, deliveredAt)
+
+	s.Require().Nil(err)
+	mark, err := s.Store.GetWebhookDeliveryHighWaterMark(context.Background(), params.RepositoryPool, s.Fixtures.Repo.ID)
+	s.Require().Nil(err)
+	s.Require().True(deliveredAt.Equal(mark))
+}
+
+func (s *WebhookDeliveriesTestSuite) TestUpdateWebhookDeliveryHighWaterMarkOlder() {
+	deliveredAt := time.Now().UTC().Truncate(time.Second)
+	err := s.Store.UpdateWebhookDeliveryHighWaterMark(context.Background(), params.RepositoryPool, s.Fixtures.Repo.ID, deliveredAt)
+	if err != nil {
+		s.FailNow(fmt.Sprintf("failed to update high-water mark: %s", err))
+	}
+
+	err = s.Store.UpdateWebhookDeliveryHighWaterMark(context.Background(), params.RepositoryPool, s.Fixtures.Repo.ID, deliveredAt.Add(-time.Minute))
+
+	s.Require().Nil(err)
+	mark, err := s.Store.GetWebhookDeliveryHighWaterMark(context.Background(), params.RepositoryPool, s.Fixtures.Repo.ID)
+	s.Require().Nil(err)
+	s.Require().True(deliveredAt.Equal(mark))
+}
+
+func (s *WebhookDeliveriesTestSuite) TestGetWebhookDeliveryHighWaterMarkInvalidEntityType() {
+	_, err := s.Store.GetWebhookDeliveryHighWaterMark(context.Background(), params.PoolType("invalid"), s.Fixtures.Repo.ID)
+
+	s.Require().Equal(runnerErrors.NewBadRequestError("invalid entity type invalid"), err)
+}
+
+func (s *WebhookDeliveriesTestSuite) TestRecordWebhookSeen() {
+	err := s.Store.RecordWebhookSeen(context.Background(), params.RepositoryPool, s.Fixtures.Repo.ID, false)
+
+	s.Require().Nil(err)
+	repo, err := s.Store.GetRepositoryByID(context.Background(), s.Fixtures.Repo.ID)
+	s.Require().Nil(err)
+	s.Require().NotNil(repo.WebhookLastSeenAt)
+	s.Require().Nil(repo.WebhookVerifiedAt)
+}
+
+func (s *WebhookDeliveriesTestSuite) TestRecordWebhookSeenVerified() {
+	err := s.Store.RecordWebhookSeen(context.Background(), params.RepositoryPool, s.Fixtures.Repo.ID, true)
+
+	s.Require().Nil(err)
+	repo, err := s.Store.GetRepositoryByID(context.Background(), s.Fixtures.Repo.ID)
+	s.Require().Nil(err)
+	s.Require().NotNil(repo.WebhookLastSeenAt)
+	s.Require().NotNil(repo.WebhookVerifiedAt)
+}
+
+func (s *WebhookDeliveriesTestSuite) TestRecordWebhookSeenNotFound() {
+	err := s.Store.RecordWebhookSeen(context.Background(), params.OrganizationPool, s.Fixtures.Repo.ID, false)
+
+	s.Require().Equal(runnerErrors.ErrNotFound, err)
+}
+
+func TestWebhookDeliveriesTestSuite(t *testing.T) {
+	t.Parallel()
+	suite.Run(t, new(WebhookDeliveriesTestSuite))
+}
@@ -45,15 +45,29 @@ func (b *baseError) Error() string {
 // NewProviderError returns a new ProviderError
 func NewProviderError(msg string, a ...interface{}) error {
 	return &ProviderError{
-		baseError{
+		baseError: baseError{
 			msg: fmt.Sprintf(msg, a...),
 		},
 	}
 }
 
-// UnauthorizedError is returned when a request is unauthorized
+// NewProviderFaultError returns a new ProviderError that carries the fault reported
+// by the provider.
+func NewProviderFaultError(fault ProviderFault, msg string, a ...interface{}) error {
+	return &ProviderError{
+		baseError: baseError{
+			msg: fmt.Sprintf(msg, a...),
+		},
+		Fault: &fault,
+	}
+}
+
+// ProviderError is returned when a provider fails to carry out an operation
 type ProviderError struct {
 	baseError
+	// Fault classifies the error. It is nil if the provider did not report a
+	// structured error.
+	Fault *ProviderFault
 }
 
 // NewMissingSecretError returns a new MissingSecretError
@@ -0,0 +1,122 @@
+// Copyright 2023 Cloudbase Solutions SRL
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package errors
+
+import (
+	"encoding/json"
+	"errors"
+)
+
+// ProviderFaultCode classifies the errors returned by providers.
+type ProviderFaultCode string
+
+const (
+	// ProviderFaultUnknown is used for errors the provider did not classify. They
+	// are retried.
+	ProviderFaultUnknown ProviderFaultCode = "unknown"
+	// ProviderFaultNotFound is used when the instance does not exist.
+	ProviderFaultNotFound ProviderFaultCode = "not_found"
+	// ProviderFaultDuplicate is used when the instance already exists.
+	ProviderFaultDuplicate ProviderFaultCode = "duplicate"
+	// ProviderFaultQuotaExceeded is used when the provider has no room left for new
+	// instances. Creating the instance is retried on other providers, or later.
+	ProviderFaultQuotaExceeded ProviderFaultCode = "quota_exceeded"
+	// ProviderFaultTransient is used for errors that are expected to go away on their
+	// own, like timeouts or a cloud API that is briefly unavailable.
+	ProviderFaultTransient ProviderFaultCode = "transient"
+	// ProviderFaultInvalidRequest is used when the request can never succeed, like when
+	// the image or flavor does not exist. Such requests are not retried.
+	ProviderFaultInvalidRequest ProviderFaultCode = "invalid_request"
+)
+
+// ProviderFault is the structured form of an error returned by a provider. External
+// providers print it as JSON when an operation fails, and garm stores it in the
+// provider_fault field of instances that could not be created.
+type ProviderFault struct {
+	Code    ProviderFaultCode `json:"code"`
+	Message string            `json:"message"`
+	// Retryable is true if the operation may succeed if it is tried again.
+	Retryable bool `json:"retryable"`
+	// QuotaExceeded is true if the operation failed because the provider has no room
+	// left for new instances.
+	QuotaExceeded bool `json:"quota_exceeded"`
+	// Details holds any additional information the provider wants to report.
+	Details map[string]interface{} `json:"details,omitempty"`
+}
+
+// NewProviderFault returns a new ProviderFault, classified according to its code.
+func NewProviderFault(code ProviderFaultCode, message string) ProviderFault {
+	fault := ProviderFault{
+		Code:    code,
+		Message: message,
+	}
+	switch code {
+	case ProviderFaultQuotaExceeded:
+		fault.Retryable = true
+		fault.QuotaExceeded = true
+	case ProviderFaultTransient:
+		fault.Retryable = true
+	case ProviderFaultNotFound, ProviderFaultDuplicate, ProviderFaultInvalidRequest:
+		fault.Retryable = false
+	default:
+		fault.Code = ProviderFaultUnknown
+		fault.Retryable = true
+	}
+	return fault
+}
+
+// providerFaultJSON is the JSON form of a ProviderFault. The booleans are optional, and
+// default to the classification of the code when the provider does not set them.
+type providerFaultJSON struct {
+	Code          ProviderFaultCode      `json:"code"`
+	Message       string                 `json:"message"`
+	Retryable     *bool                  `json:"retryable"`
+	QuotaExceeded *bool                  `json:"quota_exceeded"`
+	Details       map[string]interface{} `json:"details"`
+}
+
+// DecodeProviderFault decodes a fault encoded as JSON. A fault that only has a code and
+// a message is classified according to its code, and codes garm does not know are
+// unknown faults. False is returned if data does not hold a fault.
+func DecodeProviderFault(data []byte) (ProviderFault, bool) {
+	var decoded providerFaultJSON
+	if err := json.Unmarshal(data, &decoded); err != nil || decoded.Code == "" {
+		return ProviderFault{}, false
+	}
+
+	fault := NewProviderFault(decoded.Code, decoded.Message)
+	if decoded.Retryable != nil {
+		fault.Retryable = *decoded.Retryable
+	}
+	if decoded.QuotaExceeded != nil {
+		fault.QuotaExceeded = *decoded.QuotaExceeded
+	}
+	fault.Details = decoded.Details
+	return fault, true
+}
+
+func (f ProviderFault) Error() string {
+	return f.Message
+}
+
+// ProviderFaultFromError returns the fault carried by a ProviderError in the chain of
+// err, if any.
+func ProviderFaultFromError(err error) (ProviderFault, bool) {
+	var providerErr *ProviderError
+	if !errors.As(err, &providerErr) || providerErr.Fault == nil {
+		return ProviderFault{}, false
+	}
+	return *providerErr.Fault, true
+}
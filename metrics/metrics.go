@@ -11,7 +11,10 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 )
 
-var webhooksReceived *prometheus.CounterVec = nil
+var (
+	webhooksReceived     *prometheus.CounterVec = nil
+	workflowRunsReceived *prometheus.CounterVec = nil
+)
 
 // RecordWebhookWithLabels will increment a webhook metric identified by specific
 // values. If metrics are disabled, this function is a noop.
@@ -29,6 +32,22 @@ func RecordWebhookWithLabels(lvs ...string) error {
 	return nil
 }
 
+// RecordWorkflowRunWithLabels will increment the workflow run metric identified by specific
+// values. If metrics are disabled, this function is a noop.
+func RecordWorkflowRunWithLabels(lvs ...string) error {
+	if workflowRunsReceived == nil {
+		// not registered. Noop
+		return nil
+	}
+
+	counter, err := workflowRunsReceived.GetMetricWithLabelValues(lvs...)
+	if err != nil {
+		return errors.Wrap(err, "recording metric")
+	}
+	counter.Inc()
+	return nil
+}
+
 func RegisterCollectors(runner *runner.Runner) error {
 	if webhooksReceived != nil {
 		// Already registered.
@@ -56,6 +75,17 @@ func RegisterCollectors(runner *runner.Runner) error {
 	if err != nil {
 		return errors.Wrap(err, "registering webhooks recv counter")
 	}
+
+	// metric to count workflow_run webhooks with a valid signature
+	workflowRunsReceived = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "garm_workflow_runs_received",
+		Help: "The total number of workflow_run webhooks received",
+	}, []string{"action", "conclusion", "pool_owner", "pool_type", "hostname", "controller_id"})
+
+	err = prometheus.Register(workflowRunsReceived)
+	if err != nil {
+		return errors.Wrap(err, "registering workflow runs recv counter")
+	}
 	return nil
 }
 
@@ -76,6 +106,11 @@ func NewGarmCollector(r *runner.Runner) (*GarmCollector, error) {
 			"Health of the runner",
 			[]string{"hostname", "controller_id"}, nil,
 		),
+		providerHealthMetric: prometheus.NewDesc(
+			"garm_provider_health",
+			"Health of the provider",
+			[]string{"name", "provider_type", "status", "hostname", "controller_id"}, nil,
+		),
 		cachedControllerInfo: controllerInfo,
 	}, nil
 }
@@ -83,6 +118,7 @@ func NewGarmCollector(r *runner.Runner) (*GarmCollector, error) {
 type GarmCollector struct {
 	healthMetric         *prometheus.Desc
 	instanceMetric       *prometheus.Desc
+	providerHealthMetric *prometheus.Desc
 	runner               *runner.Runner
 	cachedControllerInfo params.ControllerInfo
 }
@@ -90,6 +126,7 @@ type GarmCollector struct {
 func (c *GarmCollector) Describe(ch chan<- *prometheus.Desc) {
 	ch <- c.instanceMetric
 	ch <- c.healthMetric
+	ch <- c.providerHealthMetric
 }
 
 func (c *GarmCollector) Collect(ch chan<- prometheus.Metric) {
@@ -100,6 +137,7 @@ func (c *GarmCollector) Collect(ch chan<- prometheus.Metric) {
 	}
 	c.CollectInstanceMetric(ch, controllerInfo.Hostname, controllerInfo.ControllerID.String())
 	c.CollectHealthMetric(ch, controllerInfo.Hostname, controllerInfo.ControllerID.String())
+	c.CollectProviderHealthMetric(ch, controllerInfo.Hostname, controllerInfo.ControllerID.String())
 }
 
 func (c *GarmCollector) CollectHealthMetric(ch chan<- prometheus.Metric, hostname string, controllerID string) {
@@ -117,6 +155,33 @@ func (c *GarmCollector) CollectHealthMetric(ch chan<- prometheus.Metric, hostnam
 	ch <- m
 }
 
+// CollectProviderHealthMetric collects the result of the last health check of each provider.
+func (c *GarmCollector) CollectProviderHealthMetric(ch chan<- prometheus.Metric, hostname string, controllerID string) {
+	providers, err := c.runner.ListProviders(auth.GetAdminContext())
+	if err != nil {
+		log.Printf("cannot collect metrics, listing providers: %s", err)
+		return
+	}
+
+	for _, provider := range providers {
+		m, err := prometheus.NewConstMetric(
+			c.providerHealthMetric,
+			prometheus.GaugeValue,
+			1,
+			provider.Name,
+			string(provider.ProviderType),
+			string(provider.Health.Status),
+			hostname,
+			controllerID,
+		)
+		if err != nil {
+			log.Printf("cannot collect metrics, creating metric: %s", err)
+			continue
+		}
+		ch <- m
+	}
+}
+
 // CollectInstanceMetric collects the metrics for the runner instances
 // reflecting the statuses and the pool they belong to.
 func (c *GarmCollector) CollectInstanceMetric(ch chan<- prometheus.Metric, hostname string, controllerID string) {
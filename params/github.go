@@ -22,6 +22,18 @@ const (
 	// WorkflowJobEvent is the event set in the webhook payload from github
 	// when a workflow_job hook is sent.
 	WorkflowJobEvent Event = "workflow_job"
+	// PingEvent is sent by github when a webhook is created, or when a ping
+	// is requested for an existing webhook.
+	PingEvent Event = "ping"
+	// WorkflowRunEvent is sent by github when a workflow run is requested, starts
+	// or completes.
+	WorkflowRunEvent Event = "workflow_run"
+	// InstallationEvent is sent to github app webhooks when an app is installed,
+	// uninstalled, suspended or its permissions change.
+	InstallationEvent Event = "installation"
+	// InstallationRepositoriesEvent is sent to github app webhooks when repositories
+	// are added to or removed from an app installation.
+	InstallationRepositoriesEvent Event = "installation_repositories"
 )
 
 // WorkflowJob holds the payload sent by github when a workload_job is sent.
@@ -38,6 +50,7 @@ type WorkflowJob struct {
 		HTMLURL     string    `json:"html_url"`
 		Status      string    `json:"status"`
 		Conclusion  string    `json:"conclusion"`
+		CreatedAt   time.Time `json:"created_at"`
 		StartedAt   time.Time `json:"started_at"`
 		CompletedAt time.Time `json:"completed_at"`
 		Name        string    `json:"name"`
@@ -207,3 +220,79 @@ type WorkflowJob struct {
 		SiteAdmin         bool   `json:"site_admin"`
 	} `json:"sender"`
 }
+
+// WebhookTarget holds the fields present in most webhook payloads, which identify
+// the repository, organization or enterprise the webhook was sent for.
+type WebhookTarget struct {
+	Repository struct {
+		Name    string `json:"name"`
+		HTMLURL string `json:"html_url"`
+		Owner   struct {
+			Login string `json:"login"`
+		} `json:"owner"`
+	} `json:"repository"`
+	Organization struct {
+		Login string `json:"login"`
+	} `json:"organization"`
+	Enterprise struct {
+		Slug    string `json:"slug"`
+		HTMLURL string `json:"html_url"`
+	} `json:"enterprise"`
+	// Sender is the user that triggered the webhook. It is present in all webhooks
+	// and its HTML URL tells us which github server sent the webhook.
+	Sender struct {
+		Login   string `json:"login"`
+		HTMLURL string `json:"html_url"`
+	} `json:"sender"`
+}
+
+// Ping holds the payload sent by github when a ping is sent.
+type Ping struct {
+	WebhookTarget
+	Zen    string `json:"zen"`
+	HookID int64  `json:"hook_id"`
+}
+
+// WorkflowRun holds the payload sent by github when a workflow_run hook is sent.
+type WorkflowRun struct {
+	WebhookTarget
+	Action      string `json:"action"`
+	WorkflowRun struct {
+		ID         int64     `json:"id"`
+		Name       string    `json:"name"`
+		RunNumber  int64     `json:"run_number"`
+		RunAttempt int64     `json:"run_attempt"`
+		Event      string    `json:"event"`
+		Status     string    `json:"status"`
+		Conclusion string    `json:"conclusion"`
+		HeadBranch string    `json:"head_branch"`
+		HTMLURL    string    `json:"html_url"`
+		CreatedAt  time.Time `json:"created_at"`
+		UpdatedAt  time.Time `json:"updated_at"`
+	} `json:"workflow_run"`
+}
+
+// InstallationRepository is a repository listed in an installation or
+// installation_repositories hook.
+type InstallationRepository struct {
+	ID       int64  `json:"id"`
+	Name     string `json:"name"`
+	FullName string `json:"full_name"`
+}
+
+// Installation holds the payload sent by github when an installation or
+// installation_repositories hook is sent.
+type Installation struct {
+	Action       string `json:"action"`
+	Installation struct {
+		ID      int64 `json:"id"`
+		AppID   int64 `json:"app_id"`
+		Account struct {
+			Login string `json:"login"`
+			Type  string `json:"type"`
+		} `json:"account"`
+	} `json:"installation"`
+	Repositories        []InstallationRepository `json:"repositories"`
+	RepositoriesAdded   []InstallationRepository `json:"repositories_added"`
+	RepositoriesRemoved []InstallationRepository `json:"repositories_removed"`
+}
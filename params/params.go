@@ -16,6 +16,8 @@ package params
 
 import (
 	"encoding/json"
+	"fmt"
+	"sort"
 	"time"
 
 	"github.com/cloudbase/garm/runner/providers/common"
@@ -23,6 +25,7 @@ import (
 
 	"github.com/google/go-github/v53/github"
 	"github.com/google/uuid"
+	"github.com/robfig/cron/v3"
 )
 
 type (
@@ -34,6 +37,68 @@ type (
 	OSArch       string
 	ProviderType string
 	JobStatus    string
+	// WebhookDeliveryStatus is the processing status of a webhook delivery.
+	WebhookDeliveryStatus string
+	// GithubAuthType is the type of authentication used to talk to the GitHub API.
+	GithubAuthType string
+	// PoolSelectionStrategy is the strategy used to pick a pool for a job, when
+	// several pools of an entity match its labels. It is set per repository,
+	// organization or enterprise. An empty strategy means round robin.
+	PoolSelectionStrategy string
+	// JobSchedulingStrategy is the order in which queued jobs are handled.
+	JobSchedulingStrategy string
+)
+
+const (
+	// PoolSelectionRoundRobin tries the matching pools in turn. This is the default.
+	PoolSelectionRoundRobin PoolSelectionStrategy = "round_robin"
+	// PoolSelectionPriority tries the pool with the highest priority first, and
+	// falls back to lower priority pools when it is full.
+	PoolSelectionPriority PoolSelectionStrategy = "priority"
+	// PoolSelectionLeastLoaded tries the pool with the lowest ratio of runners to
+	// max runners first.
+	PoolSelectionLeastLoaded PoolSelectionStrategy = "least_loaded"
+	// PoolSelectionWeightedRandom picks the order of the pools at random, with
+	// the odds of each pool proportional to its weight.
+	PoolSelectionWeightedRandom PoolSelectionStrategy = "weighted_random"
+)
+
+// IsValid returns true if the strategy is one garm knows about. An empty strategy
+// is valid and means round robin.
+func (p PoolSelectionStrategy) IsValid() bool {
+	switch p {
+	case "", PoolSelectionRoundRobin, PoolSelectionPriority,
+		PoolSelectionLeastLoaded, PoolSelectionWeightedRandom:
+		return true
+	}
+	return false
+}
+
+const (
+	// JobSchedulingFIFO handles queued jobs in the order in which they were received.
+	// This is the default.
+	JobSchedulingFIFO JobSchedulingStrategy = "fifo"
+	// JobSchedulingFairShare handles the queued jobs of the repositories that use the
+	// fewest runners first, so that a busy repository does not starve the others.
+	JobSchedulingFairShare JobSchedulingStrategy = "fair_share"
+)
+
+// IsValid returns true if the strategy is one garm knows about. An empty strategy
+// is valid and means fifo.
+func (j JobSchedulingStrategy) IsValid() bool {
+	switch j {
+	case "", JobSchedulingFIFO, JobSchedulingFairShare:
+		return true
+	}
+	return false
+}
+
+const (
+	// GithubAuthTypePAT authenticates using a personal access token.
+	GithubAuthTypePAT GithubAuthType = "pat"
+	// GithubAuthTypeApp authenticates as a GitHub App installation, using
+	// short lived installation tokens.
+	GithubAuthTypeApp GithubAuthType = "app"
 )
 
 const (
@@ -49,6 +114,16 @@ const (
 	JobStatusCompleted  JobStatus = "completed"
 )
 
+const (
+	// WebhookDeliveryPending is set on deliveries that are waiting to be processed.
+	WebhookDeliveryPending WebhookDeliveryStatus = "pending"
+	// WebhookDeliveryCompleted is set on deliveries that were successfully processed.
+	WebhookDeliveryCompleted WebhookDeliveryStatus = "completed"
+	// WebhookDeliveryFailed is set on deliveries that could not be processed, after
+	// all attempts were exhausted.
+	WebhookDeliveryFailed WebhookDeliveryStatus = "failed"
+)
+
 const (
 	RepositoryPool   PoolType = "repository"
 	OrganizationPool PoolType = "organization"
@@ -148,6 +223,9 @@ type Instance struct {
 	// up.
 	StatusMessages []StatusMessage `json:"status_messages,omitempty"`
 
+	// CreatedAt is the timestamp of the creation of this runner.
+	CreatedAt time.Time `json:"created_at"`
+
 	// UpdatedAt is the timestamp of the last update to this runner.
 	UpdatedAt time.Time `json:"updated_at"`
 
@@ -155,10 +233,19 @@ type Instance struct {
 	// The runner group must be created by someone with access to the enterprise.
 	GitHubRunnerGroup string `json:"github-runner-group"`
 
+	// PoolConfigRevision is the config revision of the pool at the time this
+	// runner was created.
+	PoolConfigRevision uint `json:"pool_config_revision"`
+
+	// ProviderName is the name of the provider on which the runner was placed. This
+	// is the provider of the pool, or one of its fallback providers.
+	ProviderName string `json:"provider_name,omitempty"`
+
 	// Do not serialize sensitive info.
 	CallbackURL     string   `json:"-"`
 	MetadataURL     string   `json:"-"`
 	CreateAttempt   int      `json:"-"`
+	QuotaAttempt    int      `json:"-"`
 	TokenFetched    bool     `json:"-"`
 	AditionalLabels []string `json:"-"`
 }
@@ -249,6 +336,7 @@ type Pool struct {
 	ProviderName           string     `json:"provider_name"`
 	MaxRunners             uint       `json:"max_runners"`
 	MinIdleRunners         uint       `json:"min_idle_runners"`
+	WarmRunners            uint       `json:"warm_runners"`
 	Image                  string     `json:"image"`
 	Flavor                 string     `json:"flavor"`
 	OSType                 OSType     `json:"os_type"`
@@ -272,12 +360,161 @@ type Pool struct {
 	// GithubRunnerGroup is the github runner group in which the runners will be added.
 	// The runner group must be created by someone with access to the enterprise.
 	GitHubRunnerGroup string `json:"github-runner-group"`
+	// AutoMinIdleRunners sets the idle runner target of the pool from its recent demand.
+	// MinIdleRunners is used as a floor, and MaxRunners as a ceiling.
+	AutoMinIdleRunners bool `json:"auto_min_idle_runners"`
+	// Priority is used by the priority pool selection strategy. When several pools
+	// match a job, pools with a higher priority are tried first.
+	Priority uint `json:"priority"`
+	// Weight is used by the weighted_random pool selection strategy. Pools with a
+	// higher weight are more likely to be tried first. A weight of 0 means the pool
+	// is only used when all other pools fail.
+	Weight uint `json:"weight"`
+	// ScalingSchedules override the min idle runners and max runners of this pool
+	// during the time windows they define. The first active schedule wins.
+	ScalingSchedules []ScalingSchedule `json:"scaling_schedules,omitempty"`
+	// ActiveScalingSchedule is the name of the scaling schedule that was active
+	// when the pool was fetched.
+	ActiveScalingSchedule string `json:"active_scaling_schedule,omitempty"`
+	// Draining is set while the pool is being drained. A draining pool does not
+	// create new runners, and its idle runners are removed.
+	Draining bool `json:"draining"`
+	// DrainStartedAt is the time at which the drain of the pool was started.
+	DrainStartedAt *time.Time `json:"drain_started_at,omitempty"`
+	// ConfigRevision is increased every time the image, flavor, extra specs or tags
+	// of the pool change. Idle runners created from an older revision are replaced.
+	ConfigRevision uint `json:"config_revision"`
+	// MaxSurge is the number of replacement runners that may be set up at the same
+	// time, while stale runners are replaced. A value of 0 means 1.
+	MaxSurge uint `json:"max_surge"`
+	// ReplacementCanary makes the pool create a single runner from a new config
+	// revision, and wait for it to run a job successfully, before replacing the
+	// rest of the stale runners. The wait ends after 30 minutes if the canary is online.
+	ReplacementCanary bool `json:"replacement_canary"`
+	// ValidatedConfigRevision is the latest config revision on which a job ran
+	// successfully.
+	ValidatedConfigRevision uint `json:"validated_config_revision"`
+	// IdleTimeout is the time in minutes a runner needs to be idle before it is
+	// considered for scale down. A value of 0 means the default of 2 minutes.
+	IdleTimeout uint `json:"idle_timeout"`
+	// MaxLifetime is the time in minutes after which an idle runner is recycled,
+	// even if it never ran a job. A value of 0 means runners are not recycled.
+	MaxLifetime uint `json:"max_lifetime"`
+	// CircuitBreakerThreshold is the number of consecutive provisioning failures after
+	// which the pool stops creating runners. A value of 0 means the default of 5.
+	CircuitBreakerThreshold uint `json:"circuit_breaker_threshold"`
+	// CircuitBreakerWindow is the time in minutes in which provisioning failures need
+	// to happen to be considered consecutive. A value of 0 means the default of 30.
+	CircuitBreakerWindow uint `json:"circuit_breaker_window"`
+	// MaxRunnersPerRepository is the maximum number of runners of this pool that may be
+	// used by jobs of a single repository. It only makes sense for organization and
+	// enterprise pools. A value of 0 means no limit.
+	MaxRunnersPerRepository uint `json:"max_runners_per_repository"`
+	// FallbackProviders are tried in order when the provider of the pool fails to
+	// create a runner, or is at capacity.
+	FallbackProviders []PoolProvider `json:"fallback_providers,omitempty"`
+	// CircuitBreaker is the state of the circuit breaker of the pool.
+	CircuitBreaker CircuitBreaker `json:"circuit_breaker"`
+}
+
+// CircuitBreaker is the state of the circuit breaker of a pool. The breaker opens after
+// a number of consecutive provisioning failures, and the pool stops creating runners until
+// it closes again. Every time the breaker opens, it stays open twice as long.
+type CircuitBreaker struct {
+	// ConsecutiveFailures is the number of provisioning failures since the last
+	// runner that came online.
+	ConsecutiveFailures uint `json:"consecutive_failures"`
+	// LastFailureAt is the time of the last provisioning failure.
+	LastFailureAt *time.Time `json:"last_failure_at,omitempty"`
+	// Trips is the number of times the breaker opened since the last runner that
+	// came online.
+	Trips uint `json:"trips"`
+	// OpenUntil is the time until which the pool does not create runners.
+	OpenUntil *time.Time `json:"open_until,omitempty"`
+	// FailureReason is the reason of the last provisioning failure.
+	FailureReason string `json:"failure_reason,omitempty"`
+}
+
+// IsOpen returns true if the breaker stops the pool from creating runners.
+func (c CircuitBreaker) IsOpen(now time.Time) bool {
+	return c.OpenUntil != nil && now.Before(*c.OpenUntil)
 }
 
 func (p Pool) GetID() string {
 	return p.ID
 }
 
+// GetMaxSurge returns the number of replacement runners that may be set up at the
+// same time, while stale runners are replaced.
+func (p Pool) GetMaxSurge() uint {
+	if p.MaxSurge == 0 {
+		return 1
+	}
+	return p.MaxSurge
+}
+
+// Providers returns the providers on which runners of the pool may be created, in the
+// order in which they are tried. The provider of the pool comes first, followed by its
+// fallback providers. The image, flavor and extra specs of a fallback provider default
+// to those of the pool.
+func (p Pool) Providers() []PoolProvider {
+	ret := []PoolProvider{
+		{
+			ProviderName: p.ProviderName,
+			Image:        p.Image,
+			Flavor:       p.Flavor,
+			ExtraSpecs:   p.ExtraSpecs,
+		},
+	}
+	for _, fallback := range p.FallbackProviders {
+		if fallback.Image == "" {
+			fallback.Image = p.Image
+		}
+		if fallback.Flavor == "" {
+			fallback.Flavor = p.Flavor
+		}
+		if len(fallback.ExtraSpecs) == 0 {
+			fallback.ExtraSpecs = p.ExtraSpecs
+		}
+		ret = append(ret, fallback)
+	}
+	return ret
+}
+
+// InstanceProviderName returns the name of the provider on which an instance of the
+// pool was placed. Instances that do not record their provider were placed on the
+// provider of the pool.
+func (p Pool) InstanceProviderName(instance Instance) string {
+	if instance.ProviderName != "" {
+		return instance.ProviderName
+	}
+	return p.ProviderName
+}
+
+// ScalingScheduleAt returns the first scaling schedule that is active at the given time.
+func (p Pool) ScalingScheduleAt(now time.Time) (ScalingSchedule, bool) {
+	for _, schedule := range p.ScalingSchedules {
+		if schedule.IsActive(now) {
+			return schedule, true
+		}
+	}
+	return ScalingSchedule{}, false
+}
+
+// WithScalingSchedule returns a copy of the pool where min idle runners and
+// max runners are replaced by the values of the scaling schedule active at the
+// given time. If no schedule is active, the pool is returned unchanged.
+func (p Pool) WithScalingSchedule(now time.Time) Pool {
+	schedule, ok := p.ScalingScheduleAt(now)
+	if !ok {
+		return p
+	}
+	p.MinIdleRunners = schedule.MinIdleRunners
+	p.MaxRunners = schedule.MaxRunners
+	p.ActiveScalingSchedule = schedule.Name
+	return p
+}
+
 func (p *Pool) RunnerTimeout() uint {
 	if p.RunnerBootstrapTimeout == 0 {
 		return appdefaults.DefaultRunnerBootstrapTimeout
@@ -285,6 +522,79 @@ func (p *Pool) RunnerTimeout() uint {
 	return p.RunnerBootstrapTimeout
 }
 
+// GetIdleTimeout returns the time a runner needs to be idle before it is considered
+// for scale down.
+func (p Pool) GetIdleTimeout() time.Duration {
+	if p.IdleTimeout == 0 {
+		return appdefaults.DefaultIdleTimeout * time.Minute
+	}
+	return time.Duration(p.IdleTimeout) * time.Minute
+}
+
+// GetCircuitBreakerThreshold returns the number of consecutive provisioning failures
+// after which the pool stops creating runners.
+func (p Pool) GetCircuitBreakerThreshold() uint {
+	if p.CircuitBreakerThreshold == 0 {
+		return appdefaults.DefaultCircuitBreakerThreshold
+	}
+	return p.CircuitBreakerThreshold
+}
+
+// GetCircuitBreakerWindow returns the time in which provisioning failures need to
+// happen to be considered consecutive.
+func (p Pool) GetCircuitBreakerWindow() time.Duration {
+	if p.CircuitBreakerWindow == 0 {
+		return appdefaults.DefaultCircuitBreakerWindow * time.Minute
+	}
+	return time.Duration(p.CircuitBreakerWindow) * time.Minute
+}
+
+// CircuitBreakerAfterFailure returns the state of the circuit breaker of the pool after
+// a provisioning failure. Failures are consecutive if they happen within the circuit
+// breaker window of each other. The window of the first failure after the breaker
+// closes starts when the breaker closes, so that it opens again right away.
+func (p Pool) CircuitBreakerAfterFailure(reason string, now time.Time) CircuitBreaker {
+	breaker := p.CircuitBreaker
+
+	var last time.Time
+	if breaker.LastFailureAt != nil {
+		last = *breaker.LastFailureAt
+	}
+	if breaker.OpenUntil != nil && breaker.OpenUntil.After(last) {
+		last = *breaker.OpenUntil
+	}
+	if now.Sub(last) > p.GetCircuitBreakerWindow() {
+		breaker.ConsecutiveFailures = 0
+	}
+
+	breaker.ConsecutiveFailures++
+	breaker.LastFailureAt = &now
+	breaker.FailureReason = reason
+
+	if breaker.ConsecutiveFailures >= p.GetCircuitBreakerThreshold() && !breaker.IsOpen(now) {
+		breaker.Trips++
+		backoff := appdefaults.CircuitBreakerBaseBackoff
+		for i := uint(1); i < breaker.Trips && backoff < appdefaults.CircuitBreakerMaxBackoff; i++ {
+			backoff *= 2
+		}
+		if backoff > appdefaults.CircuitBreakerMaxBackoff {
+			backoff = appdefaults.CircuitBreakerMaxBackoff
+		}
+		openUntil := now.Add(backoff)
+		breaker.OpenUntil = &openUntil
+	}
+	return breaker
+}
+
+// HasExpired returns true if the instance has lived longer than the max lifetime
+// of the pool.
+func (p Pool) HasExpired(instance Instance, now time.Time) bool {
+	if p.MaxLifetime == 0 || instance.CreatedAt.IsZero() {
+		return false
+	}
+	return now.Sub(instance.CreatedAt) > time.Duration(p.MaxLifetime)*time.Minute
+}
+
 func (p *Pool) PoolType() PoolType {
 	if p.RepoID != "" {
 		return RepositoryPool
@@ -299,12 +609,183 @@ func (p *Pool) PoolType() PoolType {
 // used by swagger client generated code
 type Pools []Pool
 
+// DrainStatus is the drain state of a pool or a provider.
+type DrainStatus struct {
+	Draining  bool       `json:"draining"`
+	StartedAt *time.Time `json:"started_at,omitempty"`
+	// RemainingRunners is the number of runners that are left.
+	RemainingRunners uint `json:"remaining_runners"`
+	// ActiveRunners is the number of remaining runners that are running a job.
+	ActiveRunners uint `json:"active_runners"`
+	// Finished is set once a drain was started and no runners are left.
+	Finished bool `json:"finished"`
+}
+
+// PoolQuota holds the number of runners of a pool used by each repository.
+type PoolQuota struct {
+	PoolID string `json:"pool_id"`
+	// MaxRunnersPerRepository is the maximum number of runners of the pool a single
+	// repository may use. A value of 0 means no limit.
+	MaxRunnersPerRepository uint                `json:"max_runners_per_repository"`
+	Repositories            []RepositoryRunners `json:"repositories"`
+}
+
+// RepositoryRunners is the number of runners used by a repository.
+type RepositoryRunners struct {
+	Repository string `json:"repository"`
+	Runners    uint   `json:"runners"`
+}
+
+// ProviderDrain records a drain that was started for a provider.
+type ProviderDrain struct {
+	ProviderName string    `json:"provider_name"`
+	StartedAt    time.Time `json:"started_at"`
+}
+
+// PoolDemandStats holds the demand statistics of a pool, for one hour.
+type PoolDemandStats struct {
+	PoolID string `json:"pool_id"`
+	// Bucket is the start of the hour these statistics were recorded in.
+	Bucket time.Time `json:"bucket"`
+	// Samples is the number of times the queued jobs of the pool were counted.
+	Samples uint `json:"samples"`
+	// QueuedJobs maps a number of concurrently queued jobs to the number of
+	// samples in which it was seen.
+	QueuedJobs    map[uint]uint `json:"queued_jobs"`
+	MaxQueuedJobs uint          `json:"max_queued_jobs"`
+	// JobsStarted is the number of jobs picked up by runners of the pool.
+	JobsStarted uint `json:"jobs_started"`
+	// TotalQueueLatency is the sum of the time jobs picked up by runners of the
+	// pool spent queued, in seconds.
+	TotalQueueLatency float64 `json:"total_queue_latency"`
+	// MaxQueueLatency is the longest time a job picked up by a runner of the pool
+	// spent queued, in seconds.
+	MaxQueueLatency float64 `json:"max_queue_latency"`
+}
+
+// QueuedJobsPercentile returns the number of concurrently queued jobs that was
+// not exceeded in the given percentage of samples.
+func (p PoolDemandStats) QueuedJobsPercentile(percentile uint) uint {
+	if p.Samples == 0 {
+		return 0
+	}
+
+	counts := make([]uint, 0, len(p.QueuedJobs))
+	for queued := range p.QueuedJobs {
+		counts = append(counts, queued)
+	}
+	sort.Slice(counts, func(i, j int) bool { return counts[i] < counts[j] })
+
+	// The number of samples that must be at or below the returned value.
+	needed := (p.Samples*percentile + 99) / 100
+	var seen uint
+	for _, queued := range counts {
+		seen += p.QueuedJobs[queued]
+		if seen >= needed {
+			return queued
+		}
+	}
+	return p.MaxQueuedJobs
+}
+
+// PoolProvider is a provider on which the runners of a pool may be created, along
+// with the image, flavor and extra specs to use on that provider.
+type PoolProvider struct {
+	ProviderName string `json:"provider_name"`
+	// Image, Flavor and ExtraSpecs override those of the pool. Empty values
+	// mean the values of the pool are used.
+	Image      string          `json:"image,omitempty"`
+	Flavor     string          `json:"flavor,omitempty"`
+	ExtraSpecs json.RawMessage `json:"extra_specs,omitempty"`
+}
+
+// ValidateFallbackProviders checks that the fallback providers of a pool are set, and
+// that no provider is used more than once.
+func ValidateFallbackProviders(providerName string, fallbacks []PoolProvider) error {
+	seen := map[string]bool{
+		providerName: true,
+	}
+	for _, fallback := range fallbacks {
+		if fallback.ProviderName == "" {
+			return fmt.Errorf("missing fallback provider name")
+		}
+		if seen[fallback.ProviderName] {
+			return fmt.Errorf("provider %s is used more than once", fallback.ProviderName)
+		}
+		seen[fallback.ProviderName] = true
+
+		if len(fallback.ExtraSpecs) > 0 && !json.Valid(fallback.ExtraSpecs) {
+			return fmt.Errorf("invalid extra specs for fallback provider %s", fallback.ProviderName)
+		}
+	}
+	return nil
+}
+
+// ScalingSchedule overrides the min idle runners and max runners of a pool
+// while it is active.
+type ScalingSchedule struct {
+	Name string `json:"name"`
+	// Schedule is a standard 5 field cron expression. The schedule is active during
+	// every minute matched by the expression. For example, "* 8-17 * * 1-5" is active
+	// from 08:00 to 17:59, Monday to Friday.
+	Schedule string `json:"schedule"`
+	// Timezone is the IANA time zone in which the schedule is evaluated.
+	// Defaults to UTC.
+	Timezone       string `json:"timezone,omitempty"`
+	MinIdleRunners uint   `json:"min_idle_runners"`
+	MaxRunners     uint   `json:"max_runners"`
+}
+
+// Validate checks the schedule. The warm runners of the pool are not changed by the
+// schedule, so the max runners of the schedule must leave room for them.
+func (s ScalingSchedule) Validate(warmRunners uint) error {
+	if s.Name == "" {
+		return fmt.Errorf("missing scaling schedule name")
+	}
+
+	if _, err := cron.ParseStandard(s.Schedule); err != nil {
+		return fmt.Errorf("invalid schedule for scaling schedule %s: %w", s.Name, err)
+	}
+
+	if _, err := time.LoadLocation(s.Timezone); err != nil {
+		return fmt.Errorf("invalid timezone for scaling schedule %s: %w", s.Name, err)
+	}
+
+	if s.MaxRunners == 0 {
+		return fmt.Errorf("max_runners cannot be 0 for scaling schedule %s", s.Name)
+	}
+
+	if s.MinIdleRunners > s.MaxRunners {
+		return fmt.Errorf("min_idle_runners cannot be larger than max_runners for scaling schedule %s", s.Name)
+	}
+
+	if s.MinIdleRunners+warmRunners > s.MaxRunners {
+		return fmt.Errorf("min_idle_runners plus warm_runners cannot be larger than max_runners for scaling schedule %s", s.Name)
+	}
+	return nil
+}
+
+// IsActive returns true if the schedule matches the minute of the given time.
+func (s ScalingSchedule) IsActive(now time.Time) bool {
+	schedule, err := cron.ParseStandard(s.Schedule)
+	if err != nil {
+		return false
+	}
+	loc, err := time.LoadLocation(s.Timezone)
+	if err != nil {
+		return false
+	}
+	minute := now.In(loc).Truncate(time.Minute)
+	return schedule.Next(minute.Add(-time.Second)).Equal(minute)
+}
+
 type Internal struct {
 	OAuth2Token         string `json:"oauth2"`
 	ControllerID        string `json:"controller_id"`
 	InstanceCallbackURL string `json:"instance_callback_url"`
 	InstanceMetadataURL string `json:"instance_metadata_url"`
 	JWTSecret           string `json:"jwt_secret"`
+	WebhookURL          string `json:"webhook_url"`
 	// GithubCredentialsDetails contains all info about the credentials, except the
 	// token, which is added above.
 	GithubCredentialsDetails GithubCredentials `json:"gh_creds_details"`
@@ -317,6 +798,29 @@ type Repository struct {
 	Pools             []Pool            `json:"pool,omitempty"`
 	CredentialsName   string            `json:"credentials_name"`
 	PoolManagerStatus PoolManagerStatus `json:"pool_manager_status,omitempty"`
+	// Endpoint is the github server this entity lives on. It is derived from the
+	// base URL of the credentials used by the entity.
+	Endpoint string `json:"endpoint"`
+	// PollingEnabled is set when garm polls the github API for workflow jobs,
+	// instead of relying on webhooks.
+	PollingEnabled bool `json:"polling_enabled"`
+	// PollingInterval is the interval in seconds at which the github API is polled.
+	// A value of 0 means the default interval is used.
+	PollingInterval uint `json:"polling_interval"`
+	// PoolSelectionStrategy picks a pool for jobs that match several pools.
+	PoolSelectionStrategy PoolSelectionStrategy `json:"pool_selection_strategy"`
+	// WebhookLastSeenAt is the time at which garm last received a webhook with a valid
+	// signature for this entity.
+	WebhookLastSeenAt *time.Time `json:"webhook_last_seen_at,omitempty"`
+	// WebhookVerifiedAt is the time at which garm last received a ping from a webhook
+	// with a valid signature for this entity.
+	WebhookVerifiedAt *time.Time `json:"webhook_verified_at,omitempty"`
+	// WebhookURL is the URL github should send webhooks to for this entity. It is
+	// only set if the webhook_url option is configured.
+	WebhookURL string `json:"webhook_url,omitempty"`
+	// ManagedHookID is the ID of the webhook garm installed for this entity. Garm
+	// only updates or removes this webhook.
+	ManagedHookID int64 `json:"managed_hook_id,omitempty"`
 	// Do not serialize sensitive info.
 	WebhookSecret string `json:"-"`
 }
@@ -338,6 +842,31 @@ type Organization struct {
 	Pools             []Pool            `json:"pool,omitempty"`
 	CredentialsName   string            `json:"credentials_name"`
 	PoolManagerStatus PoolManagerStatus `json:"pool_manager_status,omitempty"`
+	// Endpoint is the github server this entity lives on. It is derived from the
+	// base URL of the credentials used by the entity.
+	Endpoint string `json:"endpoint"`
+	// PollingEnabled is set when garm polls the github API for workflow jobs,
+	// instead of relying on webhooks.
+	PollingEnabled bool `json:"polling_enabled"`
+	// PollingInterval is the interval in seconds at which the github API is polled.
+	// A value of 0 means the default interval is used.
+	PollingInterval uint `json:"polling_interval"`
+	// PoolSelectionStrategy picks a pool for jobs that match several pools.
+	PoolSelectionStrategy PoolSelectionStrategy `json:"pool_selection_strategy"`
+	// JobSchedulingStrategy is the order in which queued jobs are handled.
+	JobSchedulingStrategy JobSchedulingStrategy `json:"job_scheduling_strategy"`
+	// WebhookLastSeenAt is the time at which garm last received a webhook with a valid
+	// signature for this entity.
+	WebhookLastSeenAt *time.Time `json:"webhook_last_seen_at,omitempty"`
+	// WebhookVerifiedAt is the time at which garm last received a ping from a webhook
+	// with a valid signature for this entity.
+	WebhookVerifiedAt *time.Time `json:"webhook_verified_at,omitempty"`
+	// WebhookURL is the URL github should send webhooks to for this entity. It is
+	// only set if the webhook_url option is configured.
+	WebhookURL string `json:"webhook_url,omitempty"`
+	// ManagedHookID is the ID of the webhook garm installed for this entity. Garm
+	// only updates or removes this webhook.
+	ManagedHookID int64 `json:"managed_hook_id,omitempty"`
 	// Do not serialize sensitive info.
 	WebhookSecret string `json:"-"`
 }
@@ -359,6 +888,22 @@ type Enterprise struct {
 	Pools             []Pool            `json:"pool,omitempty"`
 	CredentialsName   string            `json:"credentials_name"`
 	PoolManagerStatus PoolManagerStatus `json:"pool_manager_status,omitempty"`
+	// Endpoint is the github server this entity lives on. It is derived from the
+	// base URL of the credentials used by the entity.
+	Endpoint string `json:"endpoint"`
+	// PoolSelectionStrategy picks a pool for jobs that match several pools.
+	PoolSelectionStrategy PoolSelectionStrategy `json:"pool_selection_strategy"`
+	// JobSchedulingStrategy is the order in which queued jobs are handled.
+	JobSchedulingStrategy JobSchedulingStrategy `json:"job_scheduling_strategy"`
+	// WebhookLastSeenAt is the time at which garm last received a webhook with a valid
+	// signature for this entity.
+	WebhookLastSeenAt *time.Time `json:"webhook_last_seen_at,omitempty"`
+	// WebhookVerifiedAt is the time at which garm last received a ping from a webhook
+	// with a valid signature for this entity.
+	WebhookVerifiedAt *time.Time `json:"webhook_verified_at,omitempty"`
+	// WebhookURL is the URL github should send webhooks to for this entity. It is
+	// only set if the webhook_url option is configured.
+	WebhookURL string `json:"webhook_url,omitempty"`
 	// Do not serialize sensitive info.
 	WebhookSecret string `json:"-"`
 }
@@ -396,12 +941,34 @@ type ControllerInfo struct {
 }
 
 type GithubCredentials struct {
-	Name          string `json:"name,omitempty"`
-	Description   string `json:"description,omitempty"`
-	BaseURL       string `json:"base_url"`
-	APIBaseURL    string `json:"api_base_url"`
-	UploadBaseURL string `json:"upload_base_url"`
-	CABundle      []byte `json:"ca_bundle,omitempty"`
+	Name          string         `json:"name,omitempty"`
+	Description   string         `json:"description,omitempty"`
+	BaseURL       string         `json:"base_url"`
+	APIBaseURL    string         `json:"api_base_url"`
+	UploadBaseURL string         `json:"upload_base_url"`
+	CABundle      []byte         `json:"ca_bundle,omitempty"`
+	AuthType      GithubAuthType `json:"auth_type"`
+	// MaxRunners is the maximum number of runners that may be created for entities
+	// using these credentials. A value of 0 means no limit.
+	MaxRunners uint `json:"max_runners"`
+	// Runners is the number of runners that currently exist for entities using
+	// these credentials.
+	Runners uint `json:"runners"`
+
+	// Do not serialize sensitive info.
+	OAuth2Token string    `json:"-"`
+	App         GithubApp `json:"-"`
+}
+
+// GithubApp holds the information needed to mint installation tokens
+// for a GitHub App.
+type GithubApp struct {
+	AppID          int64  `json:"app_id"`
+	InstallationID int64  `json:"installation_id"`
+	PrivateKey     []byte `json:"private_key"`
+	// WebhookSecret is the secret of the webhook of the github app. It is used to
+	// validate installation webhooks, and is optional.
+	WebhookSecret string `json:"webhook_secret,omitempty"`
 }
 
 // used by swagger client generated code
@@ -411,14 +978,162 @@ type Provider struct {
 	Name         string       `json:"name"`
 	ProviderType ProviderType `json:"type"`
 	Description  string       `json:"description"`
+	// Draining is set while all pools using this provider are being drained.
+	Draining bool `json:"draining"`
+	// DrainStartedAt is the time at which the drain of the provider was started.
+	DrainStartedAt *time.Time `json:"drain_started_at,omitempty"`
+	// Limits holds the capacity limits of the provider.
+	Limits ProviderLimits `json:"limits"`
+	// Usage holds the resources used by runners in this provider, across all pools.
+	Usage ProviderUsage `json:"usage"`
+	// Health holds the result of the last health check of the provider.
+	Health ProviderHealth `json:"health"`
+	// InterfaceVersion is the version of the external provider interface implemented
+	// by the provider. It is empty for providers that are built into garm.
+	InterfaceVersion string `json:"interface_version,omitempty"`
+	// Capabilities holds the optional features supported by the provider.
+	Capabilities ProviderCapabilities `json:"capabilities"`
+}
+
+// ProviderCapabilities holds the optional features supported by a provider.
+type ProviderCapabilities struct {
+	// StartStop is set if the provider can stop and start instances. It is
+	// required for warm runners.
+	StartStop bool `json:"start_stop"`
+	// ConsoleLogs is set if the provider can fetch the console log of an instance.
+	ConsoleLogs bool `json:"console_logs"`
+	// ImageValidation is set if the provider can check that an image and flavor
+	// exist, when a pool is created or updated.
+	ImageValidation bool `json:"image_validation"`
+	// BulkList is set if the provider can list the instances of all pools at once.
+	BulkList bool `json:"bulk_list"`
+}
+
+type ProviderHealthStatus string
+
+const (
+	// ProviderHealthUnknown is the status of a provider that was not checked yet, or
+	// that does not support health checks.
+	ProviderHealthUnknown ProviderHealthStatus = "unknown"
+	ProviderHealthy       ProviderHealthStatus = "healthy"
+	// ProviderDegraded is the status of a provider that failed its last health check.
+	// No new runners are scheduled onto a degraded provider.
+	ProviderDegraded ProviderHealthStatus = "degraded"
+)
+
+// ProviderHealth holds the result of the last health check of a provider.
+type ProviderHealth struct {
+	Status ProviderHealthStatus `json:"status"`
+	// LastCheckAt is the time of the last health check.
+	LastCheckAt *time.Time `json:"last_check_at,omitempty"`
+	// DegradedSince is the time the provider was marked as degraded.
+	DegradedSince *time.Time `json:"degraded_since,omitempty"`
+	// Error is the error returned by the last failed health check, while the
+	// provider is degraded.
+	Error string `json:"error,omitempty"`
+}
+
+// IsDegraded returns true if the provider failed enough health checks in a row to be
+// marked as degraded, and has not passed one since.
+func (h ProviderHealth) IsDegraded() bool {
+	return h.Status == ProviderDegraded
+}
+
+// ProviderFlavor holds the resources used by one runner of a flavor.
+type ProviderFlavor struct {
+	Name     string `json:"name"`
+	CPUs     uint   `json:"cpus"`
+	MemoryMB uint   `json:"memory_mb"`
+}
+
+// ProviderLimits holds the capacity limits of a provider. A value of 0 means
+// no limit.
+type ProviderLimits struct {
+	MaxInstances uint             `json:"max_instances"`
+	MaxCPUs      uint             `json:"max_cpus"`
+	MaxMemoryMB  uint             `json:"max_memory_mb"`
+	Flavors      []ProviderFlavor `json:"flavors,omitempty"`
+}
+
+// IsSet returns true if any limit is set.
+func (l ProviderLimits) IsSet() bool {
+	return l.MaxInstances > 0 || l.MaxCPUs > 0 || l.MaxMemoryMB > 0
+}
+
+// GetFlavor returns the resources used by a flavor.
+func (l ProviderLimits) GetFlavor(name string) (ProviderFlavor, bool) {
+	for _, flavor := range l.Flavors {
+		if flavor.Name == name {
+			return flavor, true
+		}
+	}
+	return ProviderFlavor{}, false
+}
+
+// Usage returns the resources used by a number of instances of each flavor.
+// Flavors missing from the limits do not count towards the CPU and memory usage.
+func (l ProviderLimits) Usage(instancesByFlavor map[string]uint) ProviderUsage {
+	ret := ProviderUsage{}
+	for name, count := range instancesByFlavor {
+		ret.Instances += count
+		if flavor, ok := l.GetFlavor(name); ok {
+			ret.CPUs += flavor.CPUs * count
+			ret.MemoryMB += flavor.MemoryMB * count
+		}
+	}
+	return ret
+}
+
+// Fits returns an error if one more runner of the given flavor would go over
+// the limits, given the current usage.
+func (l ProviderLimits) Fits(usage ProviderUsage, flavorName string) error {
+	if l.MaxInstances > 0 && usage.Instances+1 > l.MaxInstances {
+		return fmt.Errorf("max instances (%d) reached", l.MaxInstances)
+	}
+	if l.MaxCPUs == 0 && l.MaxMemoryMB == 0 {
+		return nil
+	}
+
+	flavor, ok := l.GetFlavor(flavorName)
+	if !ok {
+		return fmt.Errorf("resources of flavor %s are unknown", flavorName)
+	}
+	if l.MaxCPUs > 0 && usage.CPUs+flavor.CPUs > l.MaxCPUs {
+		return fmt.Errorf("max cpus (%d) reached", l.MaxCPUs)
+	}
+	if l.MaxMemoryMB > 0 && usage.MemoryMB+flavor.MemoryMB > l.MaxMemoryMB {
+		return fmt.Errorf("max memory (%d MB) reached", l.MaxMemoryMB)
+	}
+	return nil
+}
+
+// ProviderUsage holds the resources used by the runners in a provider.
+type ProviderUsage struct {
+	Instances uint `json:"instances"`
+	CPUs      uint `json:"cpus"`
+	MemoryMB  uint `json:"memory_mb"`
 }
 
 // used by swagger client generated code
 type Providers []Provider
 
+// HookInfo holds details about a webhook installed by garm
+// on a repository or organization.
+type HookInfo struct {
+	ID          int64    `json:"id"`
+	URL         string   `json:"url"`
+	Events      []string `json:"events"`
+	Active      bool     `json:"active"`
+	InsecureSSL bool     `json:"insecure_ssl"`
+}
+
 type UpdatePoolStateParams struct {
-	WebhookSecret  string
-	InternalConfig *Internal
+	WebhookSecret         string
+	PollingEnabled        bool
+	PollingInterval       uint
+	PoolSelectionStrategy PoolSelectionStrategy
+	JobSchedulingStrategy JobSchedulingStrategy
+	InternalConfig        *Internal
 }
 
 type PoolManagerStatus struct {
@@ -491,3 +1206,42 @@ type Job struct {
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 }
+
+// WebhookDelivery is a webhook received from github. Deliveries are persisted
+// before being processed, so they are not lost if processing fails or garm
+// is restarted.
+type WebhookDelivery struct {
+	// ID is the unique ID github sets for each delivery, in the X-GitHub-Delivery header.
+	ID string `json:"id"`
+	// Event is the github event that triggered the delivery.
+	Event Event `json:"event"`
+	// EntityID is the ID of the repository, organization or enterprise the delivery
+	// was sent for, if it was sent to the webhook URL of that entity.
+	EntityID string `json:"entity_id,omitempty"`
+	// HookTargetType is the type of entity the webhook is configured on.
+	HookTargetType string `json:"hook_target_type"`
+	// Payload is the body of the webhook, as sent by github.
+	Payload json.RawMessage `json:"payload,omitempty"`
+	// Status is the processing status of this delivery.
+	Status WebhookDeliveryStatus `json:"status"`
+	// Attempts is the number of times we tried to process this delivery.
+	Attempts uint `json:"attempts"`
+	// LastError holds the error encountered during the last attempt, if any.
+	LastError string `json:"last_error,omitempty"`
+	// NextAttemptAt is the earliest time at which a pending delivery will be processed.
+	NextAttemptAt time.Time `json:"next_attempt_at"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// used by swagger client generated code
+type WebhookDeliveries []WebhookDelivery
+
+// WebhookEntity identifies the repository, organization or enterprise a webhook
+// was sent for.
+type WebhookEntity struct {
+	ID   string   `json:"id"`
+	Type PoolType `json:"type"`
+	Name string   `json:"name"`
+}
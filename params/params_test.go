@@ -0,0 +1,187 @@
+// Copyright 2023 Cloudbase Solutions SRL
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package params
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cloudbase/garm/util/appdefaults"
+
+	"github.com/stretchr/testify/require"
+)
+
+func timePtr(t time.Time) *time.Time {
+	return &t
+}
+
+func TestCircuitBreakerIsOpen(t *testing.T) {
+	now := time.Date(2023, 10, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		breaker CircuitBreaker
+		isOpen  bool
+	}{
+		{
+			name:    "Breaker never opened",
+			breaker: CircuitBreaker{ConsecutiveFailures: 2},
+			isOpen:  false,
+		},
+		{
+			name:    "Breaker open until later",
+			breaker: CircuitBreaker{OpenUntil: timePtr(now.Add(time.Minute))},
+			isOpen:  true,
+		},
+		{
+			name:    "Breaker closes now",
+			breaker: CircuitBreaker{OpenUntil: timePtr(now)},
+			isOpen:  false,
+		},
+		{
+			name:    "Breaker closed earlier",
+			breaker: CircuitBreaker{OpenUntil: timePtr(now.Add(-time.Minute))},
+			isOpen:  false,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.isOpen, tc.breaker.IsOpen(now))
+		})
+	}
+}
+
+func TestCircuitBreakerAfterFailure(t *testing.T) {
+	now := time.Date(2023, 10, 1, 12, 0, 0, 0, time.UTC)
+	window := 10 * time.Minute
+
+	tests := []struct {
+		name                string
+		breaker             CircuitBreaker
+		consecutiveFailures uint
+		trips               uint
+		openUntil           *time.Time
+	}{
+		{
+			name:                "First failure",
+			breaker:             CircuitBreaker{},
+			consecutiveFailures: 1,
+		},
+		{
+			name: "Failure within the window",
+			breaker: CircuitBreaker{
+				ConsecutiveFailures: 1,
+				LastFailureAt:       timePtr(now.Add(-window + time.Minute)),
+			},
+			consecutiveFailures: 2,
+		},
+		{
+			name: "Failure after the window resets the count",
+			breaker: CircuitBreaker{
+				ConsecutiveFailures: 2,
+				LastFailureAt:       timePtr(now.Add(-window - time.Minute)),
+			},
+			consecutiveFailures: 1,
+		},
+		{
+			name: "Failure reaching the threshold opens the breaker",
+			breaker: CircuitBreaker{
+				ConsecutiveFailures: 2,
+				LastFailureAt:       timePtr(now.Add(-time.Minute)),
+			},
+			consecutiveFailures: 3,
+			trips:               1,
+			openUntil:           timePtr(now.Add(appdefaults.CircuitBreakerBaseBackoff)),
+		},
+		{
+			name: "Failure while the breaker is open keeps it open",
+			breaker: CircuitBreaker{
+				ConsecutiveFailures: 3,
+				LastFailureAt:       timePtr(now.Add(-time.Minute)),
+				Trips:               1,
+				OpenUntil:           timePtr(now.Add(time.Minute)),
+			},
+			consecutiveFailures: 4,
+			trips:               1,
+			openUntil:           timePtr(now.Add(time.Minute)),
+		},
+		{
+			name: "Failure right after the breaker closes opens it again",
+			breaker: CircuitBreaker{
+				ConsecutiveFailures: 3,
+				LastFailureAt:       timePtr(now.Add(-2 * window)),
+				Trips:               1,
+				OpenUntil:           timePtr(now.Add(-time.Minute)),
+			},
+			consecutiveFailures: 4,
+			trips:               2,
+			openUntil:           timePtr(now.Add(2 * appdefaults.CircuitBreakerBaseBackoff)),
+		},
+		{
+			name: "Failure a window after the breaker closes resets the count",
+			breaker: CircuitBreaker{
+				ConsecutiveFailures: 3,
+				LastFailureAt:       timePtr(now.Add(-3 * window)),
+				Trips:               1,
+				OpenUntil:           timePtr(now.Add(-window - time.Minute)),
+			},
+			consecutiveFailures: 1,
+			trips:               1,
+			openUntil:           timePtr(now.Add(-window - time.Minute)),
+		},
+		{
+			name: "Backoff doubles with every trip",
+			breaker: CircuitBreaker{
+				ConsecutiveFailures: 3,
+				LastFailureAt:       timePtr(now.Add(-time.Minute)),
+				Trips:               3,
+				OpenUntil:           timePtr(now.Add(-time.Minute)),
+			},
+			consecutiveFailures: 4,
+			trips:               4,
+			openUntil:           timePtr(now.Add(8 * appdefaults.CircuitBreakerBaseBackoff)),
+		},
+		{
+			name: "Backoff is capped",
+			breaker: CircuitBreaker{
+				ConsecutiveFailures: 3,
+				LastFailureAt:       timePtr(now.Add(-time.Minute)),
+				Trips:               20,
+				OpenUntil:           timePtr(now.Add(-time.Minute)),
+			},
+			consecutiveFailures: 4,
+			trips:               21,
+			openUntil:           timePtr(now.Add(appdefaults.CircuitBreakerMaxBackoff)),
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			pool := Pool{
+				CircuitBreakerThreshold: 3,
+				CircuitBreakerWindow:    uint(window / time.Minute),
+				CircuitBreaker:          tc.breaker,
+			}
+
+			breaker := pool.CircuitBreakerAfterFailure("test failure", now)
+			require.Equal(t, tc.consecutiveFailures, breaker.ConsecutiveFailures)
+			require.Equal(t, tc.trips, breaker.Trips)
+			require.Equal(t, tc.openUntil, breaker.OpenUntil)
+			require.Equal(t, now, *breaker.LastFailureAt)
+			require.Equal(t, "test failure", breaker.FailureReason)
+		})
+	}
+}
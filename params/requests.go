@@ -15,11 +15,15 @@
 package params
 
 import (
+	"crypto/x509"
 	"encoding/json"
+	"encoding/pem"
 	"fmt"
+	"time"
 
 	"github.com/cloudbase/garm/errors"
 	"github.com/cloudbase/garm/runner/providers/common"
+	"github.com/cloudbase/garm/util/appdefaults"
 )
 
 const DefaultRunnerPrefix = "garm"
@@ -35,6 +39,16 @@ type CreateRepoParams struct {
 	Name            string `json:"name"`
 	CredentialsName string `json:"credentials_name"`
 	WebhookSecret   string `json:"webhook_secret"`
+	// ManageWebhook instructs garm to install the workflow_job webhook on the
+	// repository. If no webhook secret is set, one will be generated.
+	ManageWebhook bool `json:"manage_webhook"`
+	// PollingEnabled makes garm poll the github API for workflow jobs, instead
+	// of relying on webhooks.
+	PollingEnabled bool `json:"polling_enabled"`
+	// PollingInterval is the interval in seconds at which the github API is polled.
+	PollingInterval uint `json:"polling_interval"`
+	// PoolSelectionStrategy defaults to round_robin.
+	PoolSelectionStrategy PoolSelectionStrategy `json:"pool_selection_strategy"`
 }
 
 func (c *CreateRepoParams) Validate() error {
@@ -49,16 +63,32 @@ func (c *CreateRepoParams) Validate() error {
 	if c.CredentialsName == "" {
 		return errors.NewBadRequestError("missing credentials name")
 	}
-	if c.WebhookSecret == "" {
+	if c.WebhookSecret == "" && !c.ManageWebhook && !c.PollingEnabled {
 		return errors.NewMissingSecretError("missing secret")
 	}
-	return nil
+	if err := validatePoolSelectionStrategy(c.PoolSelectionStrategy); err != nil {
+		return err
+	}
+	return validatePollingInterval(c.PollingInterval)
 }
 
 type CreateOrgParams struct {
 	Name            string `json:"name"`
 	CredentialsName string `json:"credentials_name"`
 	WebhookSecret   string `json:"webhook_secret"`
+	// ManageWebhook instructs garm to install the workflow_job webhook on the
+	// organization. If no webhook secret is set, one will be generated.
+	ManageWebhook bool `json:"manage_webhook"`
+	// PollingEnabled makes garm poll the github API for workflow jobs, instead
+	// of relying on webhooks.
+	PollingEnabled bool `json:"polling_enabled"`
+	// PollingInterval is the interval in seconds at which the github API is polled.
+	PollingInterval uint `json:"polling_interval"`
+	// PoolSelectionStrategy defaults to round_robin.
+	PoolSelectionStrategy PoolSelectionStrategy `json:"pool_selection_strategy"`
+	// JobSchedulingStrategy is the order in which queued jobs are handled. Defaults
+	// to fifo.
+	JobSchedulingStrategy JobSchedulingStrategy `json:"job_scheduling_strategy"`
 }
 
 func (c *CreateOrgParams) Validate() error {
@@ -69,16 +99,27 @@ func (c *CreateOrgParams) Validate() error {
 	if c.CredentialsName == "" {
 		return errors.NewBadRequestError("missing credentials name")
 	}
-	if c.WebhookSecret == "" {
+	if c.WebhookSecret == "" && !c.ManageWebhook && !c.PollingEnabled {
 		return errors.NewMissingSecretError("missing secret")
 	}
-	return nil
+	if err := validatePoolSelectionStrategy(c.PoolSelectionStrategy); err != nil {
+		return err
+	}
+	if err := validateJobSchedulingStrategy(c.JobSchedulingStrategy); err != nil {
+		return err
+	}
+	return validatePollingInterval(c.PollingInterval)
 }
 
 type CreateEnterpriseParams struct {
 	Name            string `json:"name"`
 	CredentialsName string `json:"credentials_name"`
 	WebhookSecret   string `json:"webhook_secret"`
+	// PoolSelectionStrategy defaults to round_robin.
+	PoolSelectionStrategy PoolSelectionStrategy `json:"pool_selection_strategy"`
+	// JobSchedulingStrategy is the order in which queued jobs are handled. Defaults
+	// to fifo.
+	JobSchedulingStrategy JobSchedulingStrategy `json:"job_scheduling_strategy"`
 }
 
 func (c *CreateEnterpriseParams) Validate() error {
@@ -91,7 +132,18 @@ func (c *CreateEnterpriseParams) Validate() error {
 	if c.WebhookSecret == "" {
 		return errors.NewMissingSecretError("missing secret")
 	}
-	return nil
+	if err := validatePoolSelectionStrategy(c.PoolSelectionStrategy); err != nil {
+		return err
+	}
+	return validateJobSchedulingStrategy(c.JobSchedulingStrategy)
+}
+
+// InstallWebhookParams holds the options used when garm installs the
+// workflow_job webhook on a repository or organization.
+type InstallWebhookParams struct {
+	// InsecureSSL disables TLS certificate verification when GitHub
+	// delivers events to the garm webhook URL.
+	InsecureSSL bool `json:"insecure_ssl"`
 }
 
 // NewUserParams holds the needed information to create
@@ -112,6 +164,7 @@ type UpdatePoolParams struct {
 	Enabled                *bool           `json:"enabled,omitempty"`
 	MaxRunners             *uint           `json:"max_runners,omitempty"`
 	MinIdleRunners         *uint           `json:"min_idle_runners,omitempty"`
+	WarmRunners            *uint           `json:"warm_runners,omitempty"`
 	RunnerBootstrapTimeout *uint           `json:"runner_bootstrap_timeout,omitempty"`
 	Image                  string          `json:"image"`
 	Flavor                 string          `json:"flavor"`
@@ -121,7 +174,25 @@ type UpdatePoolParams struct {
 	// GithubRunnerGroup is the github runner group in which the runners of this
 	// pool will be added to.
 	// The runner group must be created by someone with access to the enterprise.
-	GitHubRunnerGroup *string `json:"github-runner-group,omitempty"`
+	GitHubRunnerGroup  *string `json:"github-runner-group,omitempty"`
+	AutoMinIdleRunners *bool   `json:"auto_min_idle_runners,omitempty"`
+	Priority           *uint   `json:"priority,omitempty"`
+	Weight             *uint   `json:"weight,omitempty"`
+	MaxSurge           *uint   `json:"max_surge,omitempty"`
+	ReplacementCanary  *bool   `json:"replacement_canary,omitempty"`
+	IdleTimeout        *uint   `json:"idle_timeout,omitempty"`
+	MaxLifetime        *uint   `json:"max_lifetime,omitempty"`
+	// CircuitBreakerThreshold and CircuitBreakerWindow configure when the pool
+	// stops creating runners, after repeated provisioning failures.
+	CircuitBreakerThreshold *uint `json:"circuit_breaker_threshold,omitempty"`
+	CircuitBreakerWindow    *uint `json:"circuit_breaker_window,omitempty"`
+	MaxRunnersPerRepository *uint `json:"max_runners_per_repository,omitempty"`
+	// FallbackProviders replaces the fallback providers of the pool. A nil value
+	// leaves them unchanged, while an empty list removes them.
+	FallbackProviders []PoolProvider `json:"fallback_providers"`
+	// ScalingSchedules replaces the scaling schedules of the pool. A nil value
+	// leaves them unchanged, while an empty list removes them.
+	ScalingSchedules []ScalingSchedule `json:"scaling_schedules"`
 }
 
 type CreateInstanceParams struct {
@@ -137,6 +208,8 @@ type CreateInstanceParams struct {
 	GitHubRunnerGroup string
 	CreateAttempt     int `json:"-"`
 	AditionalLabels   []string
+	// ProviderName is the provider on which the runner will be created.
+	ProviderName string
 }
 
 type CreatePoolParams struct {
@@ -145,6 +218,7 @@ type CreatePoolParams struct {
 	ProviderName           string          `json:"provider_name"`
 	MaxRunners             uint            `json:"max_runners"`
 	MinIdleRunners         uint            `json:"min_idle_runners"`
+	WarmRunners            uint            `json:"warm_runners"`
 	Image                  string          `json:"image"`
 	Flavor                 string          `json:"flavor"`
 	OSType                 OSType          `json:"os_type"`
@@ -156,7 +230,19 @@ type CreatePoolParams struct {
 	// GithubRunnerGroup is the github runner group in which the runners of this
 	// pool will be added to.
 	// The runner group must be created by someone with access to the enterprise.
-	GitHubRunnerGroup string `json:"github-runner-group"`
+	GitHubRunnerGroup       string            `json:"github-runner-group"`
+	AutoMinIdleRunners      bool              `json:"auto_min_idle_runners"`
+	Priority                uint              `json:"priority"`
+	Weight                  uint              `json:"weight"`
+	MaxSurge                uint              `json:"max_surge"`
+	ReplacementCanary       bool              `json:"replacement_canary"`
+	IdleTimeout             uint              `json:"idle_timeout"`
+	MaxLifetime             uint              `json:"max_lifetime"`
+	CircuitBreakerThreshold uint              `json:"circuit_breaker_threshold"`
+	CircuitBreakerWindow    uint              `json:"circuit_breaker_window"`
+	MaxRunnersPerRepository uint              `json:"max_runners_per_repository"`
+	FallbackProviders       []PoolProvider    `json:"fallback_providers,omitempty"`
+	ScalingSchedules        []ScalingSchedule `json:"scaling_schedules,omitempty"`
 }
 
 func (p *CreatePoolParams) Validate() error {
@@ -168,6 +254,10 @@ func (p *CreatePoolParams) Validate() error {
 		return fmt.Errorf("min_idle_runners cannot be larger than max_runners")
 	}
 
+	if p.MinIdleRunners+p.WarmRunners > p.MaxRunners {
+		return fmt.Errorf("min_idle_runners plus warm_runners cannot be larger than max_runners")
+	}
+
 	if p.MaxRunners == 0 {
 		return fmt.Errorf("max_runners cannot be 0")
 	}
@@ -184,6 +274,16 @@ func (p *CreatePoolParams) Validate() error {
 		return fmt.Errorf("missing image")
 	}
 
+	if err := ValidateFallbackProviders(p.ProviderName, p.FallbackProviders); err != nil {
+		return err
+	}
+
+	for _, schedule := range p.ScalingSchedules {
+		if err := schedule.Validate(p.WarmRunners); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -200,8 +300,10 @@ type UpdateInstanceParams struct {
 	Status        common.InstanceStatus `json:"status,omitempty"`
 	RunnerStatus  common.RunnerStatus   `json:"runner_status,omitempty"`
 	ProviderFault []byte                `json:"provider_fault,omitempty"`
+	ProviderName  string                `json:"provider_name,omitempty"`
 	AgentID       int64                 `json:"-"`
 	CreateAttempt int                   `json:"-"`
+	QuotaAttempt  int                   `json:"-"`
 	TokenFetched  *bool                 `json:"-"`
 }
 
@@ -230,6 +332,53 @@ func (p PasswordLoginParams) Validate() error {
 type UpdateEntityParams struct {
 	CredentialsName string `json:"credentials_name"`
 	WebhookSecret   string `json:"webhook_secret"`
+	PollingEnabled  *bool  `json:"polling_enabled,omitempty"`
+	PollingInterval *uint  `json:"polling_interval,omitempty"`
+	// PoolSelectionStrategy is left unchanged if empty.
+	PoolSelectionStrategy PoolSelectionStrategy `json:"pool_selection_strategy,omitempty"`
+	// JobSchedulingStrategy changes the order in which queued jobs are handled. It can
+	// only be set on organizations and enterprises. An empty value leaves it unchanged.
+	JobSchedulingStrategy JobSchedulingStrategy `json:"job_scheduling_strategy,omitempty"`
+}
+
+func (u UpdateEntityParams) Validate() error {
+	if err := validatePoolSelectionStrategy(u.PoolSelectionStrategy); err != nil {
+		return err
+	}
+	if err := validateJobSchedulingStrategy(u.JobSchedulingStrategy); err != nil {
+		return err
+	}
+	if u.PollingInterval != nil {
+		return validatePollingInterval(*u.PollingInterval)
+	}
+	return nil
+}
+
+// validatePoolSelectionStrategy validates the strategy used to pick a pool for a job.
+// An empty strategy means the default is used.
+func validatePoolSelectionStrategy(strategy PoolSelectionStrategy) error {
+	if !strategy.IsValid() {
+		return errors.NewBadRequestError("invalid pool selection strategy: %s", strategy)
+	}
+	return nil
+}
+
+// validateJobSchedulingStrategy validates the order in which queued jobs are handled.
+// An empty strategy means the default is used.
+func validateJobSchedulingStrategy(strategy JobSchedulingStrategy) error {
+	if !strategy.IsValid() {
+		return errors.NewBadRequestError("invalid job scheduling strategy: %s", strategy)
+	}
+	return nil
+}
+
+// validatePollingInterval validates the interval at which the github API is polled
+// for workflow jobs. A value of 0 means the default interval is used.
+func validatePollingInterval(interval uint) error {
+	if interval != 0 && interval < appdefaults.MinimumPollingInterval {
+		return errors.NewBadRequestError("polling interval must be at least %d seconds", appdefaults.MinimumPollingInterval)
+	}
+	return nil
 }
 
 type InstanceUpdateMessage struct {
@@ -237,3 +386,136 @@ type InstanceUpdateMessage struct {
 	Message string              `json:"message"`
 	AgentID *int64              `json:"agent_id"`
 }
+
+// CreateGithubCredentialsParams holds the information needed to store a new
+// set of github credentials in the database.
+type CreateGithubCredentialsParams struct {
+	Name          string         `json:"name"`
+	Description   string         `json:"description"`
+	AuthType      GithubAuthType `json:"auth_type"`
+	OAuth2Token   string         `json:"oauth2_token,omitempty"`
+	App           GithubApp      `json:"app"`
+	BaseURL       string         `json:"base_url,omitempty"`
+	APIBaseURL    string         `json:"api_base_url,omitempty"`
+	UploadBaseURL string         `json:"upload_base_url,omitempty"`
+	CABundle      []byte         `json:"ca_bundle,omitempty"`
+	MaxRunners    uint           `json:"max_runners,omitempty"`
+}
+
+func (c *CreateGithubCredentialsParams) GetAuthType() GithubAuthType {
+	if c.AuthType == "" {
+		return GithubAuthTypePAT
+	}
+	return c.AuthType
+}
+
+func (c *CreateGithubCredentialsParams) Validate() error {
+	if c.Name == "" {
+		return errors.NewBadRequestError("missing credentials name")
+	}
+
+	switch c.GetAuthType() {
+	case GithubAuthTypePAT:
+		if c.OAuth2Token == "" {
+			return errors.NewBadRequestError("missing oauth2 token")
+		}
+	case GithubAuthTypeApp:
+		if err := c.App.Validate(); err != nil {
+			return err
+		}
+	default:
+		return errors.NewBadRequestError("invalid auth type: %s", c.AuthType)
+	}
+
+	if err := validateCABundle(c.CABundle); err != nil {
+		return err
+	}
+	return nil
+}
+
+// UpdateGithubCredentialsParams holds the fields that can be changed for github
+// credentials stored in the database. The auth type of the credentials can not
+// be changed.
+type UpdateGithubCredentialsParams struct {
+	Description   *string    `json:"description,omitempty"`
+	OAuth2Token   string     `json:"oauth2_token,omitempty"`
+	App           *GithubApp `json:"app,omitempty"`
+	BaseURL       *string    `json:"base_url,omitempty"`
+	APIBaseURL    *string    `json:"api_base_url,omitempty"`
+	UploadBaseURL *string    `json:"upload_base_url,omitempty"`
+	CABundle      []byte     `json:"ca_bundle,omitempty"`
+	MaxRunners    *uint      `json:"max_runners,omitempty"`
+}
+
+func (u *UpdateGithubCredentialsParams) Validate(authType GithubAuthType) error {
+	switch authType {
+	case GithubAuthTypePAT:
+		if u.App != nil {
+			return errors.NewBadRequestError("cannot set github app details on credentials using a personal access token")
+		}
+	case GithubAuthTypeApp:
+		if u.OAuth2Token != "" {
+			return errors.NewBadRequestError("cannot set an oauth2 token on credentials using a github app")
+		}
+		if u.App != nil {
+			if err := u.App.Validate(); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := validateCABundle(u.CABundle); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (g *GithubApp) Validate() error {
+	if g.AppID == 0 {
+		return errors.NewBadRequestError("missing app ID")
+	}
+
+	if g.InstallationID == 0 {
+		return errors.NewBadRequestError("missing installation ID")
+	}
+
+	block, _ := pem.Decode(g.PrivateKey)
+	if block == nil {
+		return errors.NewBadRequestError("failed to decode private key")
+	}
+
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		if _, err := x509.ParsePKCS1PrivateKey(block.Bytes); err != nil {
+			return errors.NewBadRequestError("failed to parse private key: %s", err)
+		}
+	case "PRIVATE KEY":
+		if _, err := x509.ParsePKCS8PrivateKey(block.Bytes); err != nil {
+			return errors.NewBadRequestError("failed to parse private key: %s", err)
+		}
+	default:
+		return errors.NewBadRequestError("unsupported private key type: %s", block.Type)
+	}
+	return nil
+}
+
+func validateCABundle(caBundle []byte) error {
+	if len(caBundle) == 0 {
+		return nil
+	}
+
+	roots := x509.NewCertPool()
+	if ok := roots.AppendCertsFromPEM(caBundle); !ok {
+		return errors.NewBadRequestError("failed to parse CA cert bundle")
+	}
+	return nil
+}
+
+// UpdateWebhookDeliveryParams holds the fields of a webhook delivery that change
+// while it is being processed.
+type UpdateWebhookDeliveryParams struct {
+	Status        *WebhookDeliveryStatus `json:"status,omitempty"`
+	Attempts      *uint                  `json:"attempts,omitempty"`
+	LastError     *string                `json:"last_error,omitempty"`
+	NextAttemptAt *time.Time             `json:"next_attempt_at,omitempty"`
+}
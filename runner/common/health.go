@@ -0,0 +1,94 @@
+// Copyright 2023 Cloudbase Solutions SRL
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package common
+
+import (
+	"sync"
+	"time"
+
+	"github.com/cloudbase/garm/params"
+)
+
+// healthFailureThreshold is the number of health checks in a row a provider must fail
+// before it is marked as degraded. A single failed check is often a transient error, and
+// would otherwise needlessly move new runners to fallback providers.
+const healthFailureThreshold = 3
+
+// HealthTracker keeps the result of the last health check of a provider. It is
+// meant to be embedded in providers.
+type HealthTracker struct {
+	mux      sync.Mutex
+	health   params.ProviderHealth
+	failures int
+}
+
+// RecordHealth records the result of a health check, and returns the error it was given.
+// The provider is marked as degraded once it fails healthFailureThreshold checks in a row,
+// and as healthy as soon as a check succeeds.
+func (h *HealthTracker) RecordHealth(err error) error {
+	h.mux.Lock()
+	defer h.mux.Unlock()
+
+	now := time.Now().UTC()
+	h.health.LastCheckAt = &now
+	if err == nil {
+		h.failures = 0
+		h.health.Status = params.ProviderHealthy
+		h.health.DegradedSince = nil
+		h.health.Error = ""
+		return nil
+	}
+
+	h.failures++
+	if h.failures < healthFailureThreshold && !h.health.IsDegraded() {
+		return err
+	}
+	h.degrade(now, err)
+	return err
+}
+
+// MarkDegraded marks the provider as degraded right away, and returns the error it was
+// given. It is meant for failures that will not go away on their own, like a provider
+// that could not be set up when it was loaded.
+func (h *HealthTracker) MarkDegraded(err error) error {
+	h.mux.Lock()
+	defer h.mux.Unlock()
+
+	now := time.Now().UTC()
+	h.health.LastCheckAt = &now
+	h.failures++
+	h.degrade(now, err)
+	return err
+}
+
+func (h *HealthTracker) degrade(now time.Time, err error) {
+	if h.health.DegradedSince == nil {
+		h.health.DegradedSince = &now
+	}
+	h.health.Status = params.ProviderDegraded
+	h.health.Error = err.Error()
+}
+
+// ProviderHealth returns the result of the last health check.
+func (h *HealthTracker) ProviderHealth() params.ProviderHealth {
+	h.mux.Lock()
+	defer h.mux.Unlock()
+
+	ret := h.health
+	if ret.Status == "" {
+		ret.Status = params.ProviderHealthUnknown
+	}
+	return ret
+}
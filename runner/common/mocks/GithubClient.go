@@ -14,6 +14,76 @@ type GithubClient struct {
 	mock.Mock
 }
 
+// CreateHook provides a mock function with given fields: ctx, owner, repo, hook
+func (_m *GithubClient) CreateHook(ctx context.Context, owner string, repo string, hook *github.Hook) (*github.Hook, *github.Response, error) {
+	ret := _m.Called(ctx, owner, repo, hook)
+
+	var r0 *github.Hook
+	var r1 *github.Response
+	var r2 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, *github.Hook) (*github.Hook, *github.Response, error)); ok {
+		return rf(ctx, owner, repo, hook)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, *github.Hook) *github.Hook); ok {
+		r0 = rf(ctx, owner, repo, hook)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*github.Hook)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, *github.Hook) *github.Response); ok {
+		r1 = rf(ctx, owner, repo, hook)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*github.Response)
+		}
+	}
+
+	if rf, ok := ret.Get(2).(func(context.Context, string, string, *github.Hook) error); ok {
+		r2 = rf(ctx, owner, repo, hook)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// CreateOrgHook provides a mock function with given fields: ctx, org, hook
+func (_m *GithubClient) CreateOrgHook(ctx context.Context, org string, hook *github.Hook) (*github.Hook, *github.Response, error) {
+	ret := _m.Called(ctx, org, hook)
+
+	var r0 *github.Hook
+	var r1 *github.Response
+	var r2 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, *github.Hook) (*github.Hook, *github.Response, error)); ok {
+		return rf(ctx, org, hook)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, *github.Hook) *github.Hook); ok {
+		r0 = rf(ctx, org, hook)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*github.Hook)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, *github.Hook) *github.Response); ok {
+		r1 = rf(ctx, org, hook)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*github.Response)
+		}
+	}
+
+	if rf, ok := ret.Get(2).(func(context.Context, string, *github.Hook) error); ok {
+		r2 = rf(ctx, org, hook)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
 // CreateOrganizationRegistrationToken provides a mock function with given fields: ctx, owner
 func (_m *GithubClient) CreateOrganizationRegistrationToken(ctx context.Context, owner string) (*github.RegistrationToken, *github.Response, error) {
 	ret := _m.Called(ctx, owner)
@@ -84,6 +154,268 @@ func (_m *GithubClient) CreateRegistrationToken(ctx context.Context, owner strin
 	return r0, r1, r2
 }
 
+// DeleteHook provides a mock function with given fields: ctx, owner, repo, id
+func (_m *GithubClient) DeleteHook(ctx context.Context, owner string, repo string, id int64) (*github.Response, error) {
+	ret := _m.Called(ctx, owner, repo, id)
+
+	var r0 *github.Response
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, int64) (*github.Response, error)); ok {
+		return rf(ctx, owner, repo, id)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, int64) *github.Response); ok {
+		r0 = rf(ctx, owner, repo, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*github.Response)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, int64) error); ok {
+		r1 = rf(ctx, owner, repo, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// DeleteOrgHook provides a mock function with given fields: ctx, org, id
+func (_m *GithubClient) DeleteOrgHook(ctx context.Context, org string, id int64) (*github.Response, error) {
+	ret := _m.Called(ctx, org, id)
+
+	var r0 *github.Response
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, int64) (*github.Response, error)); ok {
+		return rf(ctx, org, id)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, int64) *github.Response); ok {
+		r0 = rf(ctx, org, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*github.Response)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, int64) error); ok {
+		r1 = rf(ctx, org, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// EditHook provides a mock function with given fields: ctx, owner, repo, id, hook
+func (_m *GithubClient) EditHook(ctx context.Context, owner string, repo string, id int64, hook *github.Hook) (*github.Hook, *github.Response, error) {
+	ret := _m.Called(ctx, owner, repo, id, hook)
+
+	var r0 *github.Hook
+	var r1 *github.Response
+	var r2 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, int64, *github.Hook) (*github.Hook, *github.Response, error)); ok {
+		return rf(ctx, owner, repo, id, hook)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, int64, *github.Hook) *github.Hook); ok {
+		r0 = rf(ctx, owner, repo, id, hook)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*github.Hook)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, int64, *github.Hook) *github.Response); ok {
+		r1 = rf(ctx, owner, repo, id, hook)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*github.Response)
+		}
+	}
+
+	if rf, ok := ret.Get(2).(func(context.Context, string, string, int64, *github.Hook) error); ok {
+		r2 = rf(ctx, owner, repo, id, hook)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// EditOrgHook provides a mock function with given fields: ctx, org, id, hook
+func (_m *GithubClient) EditOrgHook(ctx context.Context, org string, id int64, hook *github.Hook) (*github.Hook, *github.Response, error) {
+	ret := _m.Called(ctx, org, id, hook)
+
+	var r0 *github.Hook
+	var r1 *github.Response
+	var r2 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, int64, *github.Hook) (*github.Hook, *github.Response, error)); ok {
+		return rf(ctx, org, id, hook)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, int64, *github.Hook) *github.Hook); ok {
+		r0 = rf(ctx, org, id, hook)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*github.Hook)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, int64, *github.Hook) *github.Response); ok {
+		r1 = rf(ctx, org, id, hook)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*github.Response)
+		}
+	}
+
+	if rf, ok := ret.Get(2).(func(context.Context, string, int64, *github.Hook) error); ok {
+		r2 = rf(ctx, org, id, hook)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// GenerateOrgJITConfig provides a mock function with given fields: ctx, owner, request
+func (_m *GithubClient) GenerateOrgJITConfig(ctx context.Context, owner string, request *github.GenerateJITConfigRequest) (*github.JITRunnerConfig, *github.Response, error) {
+	ret := _m.Called(ctx, owner, request)
+
+	var r0 *github.JITRunnerConfig
+	var r1 *github.Response
+	var r2 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, *github.GenerateJITConfigRequest) (*github.JITRunnerConfig, *github.Response, error)); ok {
+		return rf(ctx, owner, request)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, *github.GenerateJITConfigRequest) *github.JITRunnerConfig); ok {
+		r0 = rf(ctx, owner, request)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*github.JITRunnerConfig)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, *github.GenerateJITConfigRequest) *github.Response); ok {
+		r1 = rf(ctx, owner, request)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*github.Response)
+		}
+	}
+
+	if rf, ok := ret.Get(2).(func(context.Context, string, *github.GenerateJITConfigRequest) error); ok {
+		r2 = rf(ctx, owner, request)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// GenerateRepoJITConfig provides a mock function with given fields: ctx, owner, repo, request
+func (_m *GithubClient) GenerateRepoJITConfig(ctx context.Context, owner string, repo string, request *github.GenerateJITConfigRequest) (*github.JITRunnerConfig, *github.Response, error) {
+	ret := _m.Called(ctx, owner, repo, request)
+
+	var r0 *github.JITRunnerConfig
+	var r1 *github.Response
+	var r2 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, *github.GenerateJITConfigRequest) (*github.JITRunnerConfig, *github.Response, error)); ok {
+		return rf(ctx, owner, repo, request)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, *github.GenerateJITConfigRequest) *github.JITRunnerConfig); ok {
+		r0 = rf(ctx, owner, repo, request)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*github.JITRunnerConfig)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, *github.GenerateJITConfigRequest) *github.Response); ok {
+		r1 = rf(ctx, owner, repo, request)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*github.Response)
+		}
+	}
+
+	if rf, ok := ret.Get(2).(func(context.Context, string, string, *github.GenerateJITConfigRequest) error); ok {
+		r2 = rf(ctx, owner, repo, request)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// GetHookDelivery provides a mock function with given fields: ctx, owner, repo, hookID, deliveryID
+func (_m *GithubClient) GetHookDelivery(ctx context.Context, owner string, repo string, hookID int64, deliveryID int64) (*github.HookDelivery, *github.Response, error) {
+	ret := _m.Called(ctx, owner, repo, hookID, deliveryID)
+
+	var r0 *github.HookDelivery
+	var r1 *github.Response
+	var r2 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, int64, int64) (*github.HookDelivery, *github.Response, error)); ok {
+		return rf(ctx, owner, repo, hookID, deliveryID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, int64, int64) *github.HookDelivery); ok {
+		r0 = rf(ctx, owner, repo, hookID, deliveryID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*github.HookDelivery)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, int64, int64) *github.Response); ok {
+		r1 = rf(ctx, owner, repo, hookID, deliveryID)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*github.Response)
+		}
+	}
+
+	if rf, ok := ret.Get(2).(func(context.Context, string, string, int64, int64) error); ok {
+		r2 = rf(ctx, owner, repo, hookID, deliveryID)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// GetOrgHookDelivery provides a mock function with given fields: ctx, org, hookID, deliveryID
+func (_m *GithubClient) GetOrgHookDelivery(ctx context.Context, org string, hookID int64, deliveryID int64) (*github.HookDelivery, *github.Response, error) {
+	ret := _m.Called(ctx, org, hookID, deliveryID)
+
+	var r0 *github.HookDelivery
+	var r1 *github.Response
+	var r2 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, int64, int64) (*github.HookDelivery, *github.Response, error)); ok {
+		return rf(ctx, org, hookID, deliveryID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, int64, int64) *github.HookDelivery); ok {
+		r0 = rf(ctx, org, hookID, deliveryID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*github.HookDelivery)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, int64, int64) *github.Response); ok {
+		r1 = rf(ctx, org, hookID, deliveryID)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*github.Response)
+		}
+	}
+
+	if rf, ok := ret.Get(2).(func(context.Context, string, int64, int64) error); ok {
+		r2 = rf(ctx, org, hookID, deliveryID)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
 // GetWorkflowJobByID provides a mock function with given fields: ctx, owner, repo, jobID
 func (_m *GithubClient) GetWorkflowJobByID(ctx context.Context, owner string, repo string, jobID int64) (*github.WorkflowJob, *github.Response, error) {
 	ret := _m.Called(ctx, owner, repo, jobID)
@@ -119,6 +451,181 @@ func (_m *GithubClient) GetWorkflowJobByID(ctx context.Context, owner string, re
 	return r0, r1, r2
 }
 
+// ListHookDeliveries provides a mock function with given fields: ctx, owner, repo, id, opts
+func (_m *GithubClient) ListHookDeliveries(ctx context.Context, owner string, repo string, id int64, opts *github.ListCursorOptions) ([]*github.HookDelivery, *github.Response, error) {
+	ret := _m.Called(ctx, owner, repo, id, opts)
+
+	var r0 []*github.HookDelivery
+	var r1 *github.Response
+	var r2 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, int64, *github.ListCursorOptions) ([]*github.HookDelivery, *github.Response, error)); ok {
+		return rf(ctx, owner, repo, id, opts)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, int64, *github.ListCursorOptions) []*github.HookDelivery); ok {
+		r0 = rf(ctx, owner, repo, id, opts)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*github.HookDelivery)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, int64, *github.ListCursorOptions) *github.Response); ok {
+		r1 = rf(ctx, owner, repo, id, opts)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*github.Response)
+		}
+	}
+
+	if rf, ok := ret.Get(2).(func(context.Context, string, string, int64, *github.ListCursorOptions) error); ok {
+		r2 = rf(ctx, owner, repo, id, opts)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// ListHooks provides a mock function with given fields: ctx, owner, repo, opts
+func (_m *GithubClient) ListHooks(ctx context.Context, owner string, repo string, opts *github.ListOptions) ([]*github.Hook, *github.Response, error) {
+	ret := _m.Called(ctx, owner, repo, opts)
+
+	var r0 []*github.Hook
+	var r1 *github.Response
+	var r2 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, *github.ListOptions) ([]*github.Hook, *github.Response, error)); ok {
+		return rf(ctx, owner, repo, opts)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, *github.ListOptions) []*github.Hook); ok {
+		r0 = rf(ctx, owner, repo, opts)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*github.Hook)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, *github.ListOptions) *github.Response); ok {
+		r1 = rf(ctx, owner, repo, opts)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*github.Response)
+		}
+	}
+
+	if rf, ok := ret.Get(2).(func(context.Context, string, string, *github.ListOptions) error); ok {
+		r2 = rf(ctx, owner, repo, opts)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// ListOrgHookDeliveries provides a mock function with given fields: ctx, org, id, opts
+func (_m *GithubClient) ListOrgHookDeliveries(ctx context.Context, org string, id int64, opts *github.ListCursorOptions) ([]*github.HookDelivery, *github.Response, error) {
+	ret := _m.Called(ctx, org, id, opts)
+
+	var r0 []*github.HookDelivery
+	var r1 *github.Response
+	var r2 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, int64, *github.ListCursorOptions) ([]*github.HookDelivery, *github.Response, error)); ok {
+		return rf(ctx, org, id, opts)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, int64, *github.ListCursorOptions) []*github.HookDelivery); ok {
+		r0 = rf(ctx, org, id, opts)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*github.HookDelivery)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, int64, *github.ListCursorOptions) *github.Response); ok {
+		r1 = rf(ctx, org, id, opts)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*github.Response)
+		}
+	}
+
+	if rf, ok := ret.Get(2).(func(context.Context, string, int64, *github.ListCursorOptions) error); ok {
+		r2 = rf(ctx, org, id, opts)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// ListOrgHooks provides a mock function with given fields: ctx, org, opts
+func (_m *GithubClient) ListOrgHooks(ctx context.Context, org string, opts *github.ListOptions) ([]*github.Hook, *github.Response, error) {
+	ret := _m.Called(ctx, org, opts)
+
+	var r0 []*github.Hook
+	var r1 *github.Response
+	var r2 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, *github.ListOptions) ([]*github.Hook, *github.Response, error)); ok {
+		return rf(ctx, org, opts)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, *github.ListOptions) []*github.Hook); ok {
+		r0 = rf(ctx, org, opts)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*github.Hook)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, *github.ListOptions) *github.Response); ok {
+		r1 = rf(ctx, org, opts)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*github.Response)
+		}
+	}
+
+	if rf, ok := ret.Get(2).(func(context.Context, string, *github.ListOptions) error); ok {
+		r2 = rf(ctx, org, opts)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// ListOrgRepos provides a mock function with given fields: ctx, org, opts
+func (_m *GithubClient) ListOrgRepos(ctx context.Context, org string, opts *github.RepositoryListByOrgOptions) ([]*github.Repository, *github.Response, error) {
+	ret := _m.Called(ctx, org, opts)
+
+	var r0 []*github.Repository
+	var r1 *github.Response
+	var r2 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, *github.RepositoryListByOrgOptions) ([]*github.Repository, *github.Response, error)); ok {
+		return rf(ctx, org, opts)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, *github.RepositoryListByOrgOptions) []*github.Repository); ok {
+		r0 = rf(ctx, org, opts)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*github.Repository)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, *github.RepositoryListByOrgOptions) *github.Response); ok {
+		r1 = rf(ctx, org, opts)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*github.Response)
+		}
+	}
+
+	if rf, ok := ret.Get(2).(func(context.Context, string, *github.RepositoryListByOrgOptions) error); ok {
+		r2 = rf(ctx, org, opts)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
 // ListOrganizationRunnerApplicationDownloads provides a mock function with given fields: ctx, owner
 func (_m *GithubClient) ListOrganizationRunnerApplicationDownloads(ctx context.Context, owner string) ([]*github.RunnerApplicationDownload, *github.Response, error) {
 	ret := _m.Called(ctx, owner)
@@ -154,6 +661,41 @@ func (_m *GithubClient) ListOrganizationRunnerApplicationDownloads(ctx context.C
 	return r0, r1, r2
 }
 
+// ListOrganizationRunnerGroups provides a mock function with given fields: ctx, org, opts
+func (_m *GithubClient) ListOrganizationRunnerGroups(ctx context.Context, org string, opts *github.ListOrgRunnerGroupOptions) (*github.RunnerGroups, *github.Response, error) {
+	ret := _m.Called(ctx, org, opts)
+
+	var r0 *github.RunnerGroups
+	var r1 *github.Response
+	var r2 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, *github.ListOrgRunnerGroupOptions) (*github.RunnerGroups, *github.Response, error)); ok {
+		return rf(ctx, org, opts)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, *github.ListOrgRunnerGroupOptions) *github.RunnerGroups); ok {
+		r0 = rf(ctx, org, opts)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*github.RunnerGroups)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, *github.ListOrgRunnerGroupOptions) *github.Response); ok {
+		r1 = rf(ctx, org, opts)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*github.Response)
+		}
+	}
+
+	if rf, ok := ret.Get(2).(func(context.Context, string, *github.ListOrgRunnerGroupOptions) error); ok {
+		r2 = rf(ctx, org, opts)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
 // ListOrganizationRunners provides a mock function with given fields: ctx, owner, opts
 func (_m *GithubClient) ListOrganizationRunners(ctx context.Context, owner string, opts *github.ListOptions) (*github.Runners, *github.Response, error) {
 	ret := _m.Called(ctx, owner, opts)
@@ -189,6 +731,41 @@ func (_m *GithubClient) ListOrganizationRunners(ctx context.Context, owner strin
 	return r0, r1, r2
 }
 
+// ListRepositoryWorkflowRuns provides a mock function with given fields: ctx, owner, repo, opts
+func (_m *GithubClient) ListRepositoryWorkflowRuns(ctx context.Context, owner string, repo string, opts *github.ListWorkflowRunsOptions) (*github.WorkflowRuns, *github.Response, error) {
+	ret := _m.Called(ctx, owner, repo, opts)
+
+	var r0 *github.WorkflowRuns
+	var r1 *github.Response
+	var r2 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, *github.ListWorkflowRunsOptions) (*github.WorkflowRuns, *github.Response, error)); ok {
+		return rf(ctx, owner, repo, opts)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, *github.ListWorkflowRunsOptions) *github.WorkflowRuns); ok {
+		r0 = rf(ctx, owner, repo, opts)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*github.WorkflowRuns)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, *github.ListWorkflowRunsOptions) *github.Response); ok {
+		r1 = rf(ctx, owner, repo, opts)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*github.Response)
+		}
+	}
+
+	if rf, ok := ret.Get(2).(func(context.Context, string, string, *github.ListWorkflowRunsOptions) error); ok {
+		r2 = rf(ctx, owner, repo, opts)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
 // ListRunnerApplicationDownloads provides a mock function with given fields: ctx, owner, repo
 func (_m *GithubClient) ListRunnerApplicationDownloads(ctx context.Context, owner string, repo string) ([]*github.RunnerApplicationDownload, *github.Response, error) {
 	ret := _m.Called(ctx, owner, repo)
@@ -259,6 +836,93 @@ func (_m *GithubClient) ListRunners(ctx context.Context, owner string, repo stri
 	return r0, r1, r2
 }
 
+// ListWorkflowJobs provides a mock function with given fields: ctx, owner, repo, runID, opts
+func (_m *GithubClient) ListWorkflowJobs(ctx context.Context, owner string, repo string, runID int64, opts *github.ListWorkflowJobsOptions) (*github.Jobs, *github.Response, error) {
+	ret := _m.Called(ctx, owner, repo, runID, opts)
+
+	var r0 *github.Jobs
+	var r1 *github.Response
+	var r2 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, int64, *github.ListWorkflowJobsOptions) (*github.Jobs, *github.Response, error)); ok {
+		return rf(ctx, owner, repo, runID, opts)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, int64, *github.ListWorkflowJobsOptions) *github.Jobs); ok {
+		r0 = rf(ctx, owner, repo, runID, opts)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*github.Jobs)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, int64, *github.ListWorkflowJobsOptions) *github.Response); ok {
+		r1 = rf(ctx, owner, repo, runID, opts)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*github.Response)
+		}
+	}
+
+	if rf, ok := ret.Get(2).(func(context.Context, string, string, int64, *github.ListWorkflowJobsOptions) error); ok {
+		r2 = rf(ctx, owner, repo, runID, opts)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// PingHook provides a mock function with given fields: ctx, owner, repo, id
+func (_m *GithubClient) PingHook(ctx context.Context, owner string, repo string, id int64) (*github.Response, error) {
+	ret := _m.Called(ctx, owner, repo, id)
+
+	var r0 *github.Response
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, int64) (*github.Response, error)); ok {
+		return rf(ctx, owner, repo, id)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, int64) *github.Response); ok {
+		r0 = rf(ctx, owner, repo, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*github.Response)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, int64) error); ok {
+		r1 = rf(ctx, owner, repo, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// PingOrgHook provides a mock function with given fields: ctx, org, id
+func (_m *GithubClient) PingOrgHook(ctx context.Context, org string, id int64) (*github.Response, error) {
+	ret := _m.Called(ctx, org, id)
+
+	var r0 *github.Response
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, int64) (*github.Response, error)); ok {
+		return rf(ctx, org, id)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, int64) *github.Response); ok {
+		r0 = rf(ctx, org, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*github.Response)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, int64) error); ok {
+		r1 = rf(ctx, org, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // RemoveOrganizationRunner provides a mock function with given fields: ctx, owner, runnerID
 func (_m *GithubClient) RemoveOrganizationRunner(ctx context.Context, owner string, runnerID int64) (*github.Response, error) {
 	ret := _m.Called(ctx, owner, runnerID)
@@ -26,6 +26,54 @@ func (_m *PoolManager) ForceDeleteRunner(runner params.Instance) error {
 	return r0
 }
 
+// GetWebhookInfo provides a mock function with given fields:
+func (_m *PoolManager) GetWebhookInfo() (params.HookInfo, error) {
+	ret := _m.Called()
+
+	var r0 params.HookInfo
+	var r1 error
+	if rf, ok := ret.Get(0).(func() (params.HookInfo, error)); ok {
+		return rf()
+	}
+	if rf, ok := ret.Get(0).(func() params.HookInfo); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(params.HookInfo)
+	}
+
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GithubRunnerJITConfig provides a mock function with given fields: instance
+func (_m *PoolManager) GithubRunnerJITConfig(instance params.Instance) (string, error) {
+	ret := _m.Called(instance)
+
+	var r0 string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(params.Instance) (string, error)); ok {
+		return rf(instance)
+	}
+	if rf, ok := ret.Get(0).(func(params.Instance) string); ok {
+		r0 = rf(instance)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(params.Instance) error); ok {
+		r1 = rf(instance)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GithubRunnerRegistrationToken provides a mock function with given fields:
 func (_m *PoolManager) GithubRunnerRegistrationToken() (string, error) {
 	ret := _m.Called()
@@ -78,6 +126,30 @@ func (_m *PoolManager) ID() string {
 	return r0
 }
 
+// InstallWebhook provides a mock function with given fields: param
+func (_m *PoolManager) InstallWebhook(param params.InstallWebhookParams) (params.HookInfo, error) {
+	ret := _m.Called(param)
+
+	var r0 params.HookInfo
+	var r1 error
+	if rf, ok := ret.Get(0).(func(params.InstallWebhookParams) (params.HookInfo, error)); ok {
+		return rf(param)
+	}
+	if rf, ok := ret.Get(0).(func(params.InstallWebhookParams) params.HookInfo); ok {
+		r0 = rf(param)
+	} else {
+		r0 = ret.Get(0).(params.HookInfo)
+	}
+
+	if rf, ok := ret.Get(1).(func(params.InstallWebhookParams) error); ok {
+		r1 = rf(param)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // RefreshState provides a mock function with given fields: param
 func (_m *PoolManager) RefreshState(param params.UpdatePoolStateParams) error {
 	ret := _m.Called(param)
@@ -134,6 +206,20 @@ func (_m *PoolManager) Stop() error {
 	return r0
 }
 
+// UninstallWebhook provides a mock function with given fields:
+func (_m *PoolManager) UninstallWebhook() error {
+	ret := _m.Called()
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func() error); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // Wait provides a mock function with given fields:
 func (_m *PoolManager) Wait() error {
 	ret := _m.Called()
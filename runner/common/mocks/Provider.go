@@ -66,6 +66,30 @@ func (_m *Provider) DeleteInstance(ctx context.Context, instance string) error {
 	return r0
 }
 
+// GetConsoleLog provides a mock function with given fields: ctx, instance
+func (_m *Provider) GetConsoleLog(ctx context.Context, instance string) (string, error) {
+	ret := _m.Called(ctx, instance)
+
+	var r0 string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (string, error)); ok {
+		return rf(ctx, instance)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) string); ok {
+		r0 = rf(ctx, instance)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, instance)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetInstance provides a mock function with given fields: ctx, instance
 func (_m *Provider) GetInstance(ctx context.Context, instance string) (params.Instance, error) {
 	ret := _m.Called(ctx, instance)
@@ -90,6 +114,20 @@ func (_m *Provider) GetInstance(ctx context.Context, instance string) (params.In
 	return r0, r1
 }
 
+// Health provides a mock function with given fields: ctx
+func (_m *Provider) Health(ctx context.Context) error {
+	ret := _m.Called(ctx)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context) error); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // ListInstances provides a mock function with given fields: ctx, poolID
 func (_m *Provider) ListInstances(ctx context.Context, poolID string) ([]params.Instance, error) {
 	ret := _m.Called(ctx, poolID)
@@ -158,6 +196,20 @@ func (_m *Provider) Stop(ctx context.Context, instance string, force bool) error
 	return r0
 }
 
+// ValidateImage provides a mock function with given fields: ctx, image, flavor
+func (_m *Provider) ValidateImage(ctx context.Context, image string, flavor string) error {
+	ret := _m.Called(ctx, image, flavor)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
+		r0 = rf(ctx, image, flavor)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // NewProvider creates a new instance of Provider. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewProvider(t interface {
@@ -33,6 +33,34 @@ const (
 	// BackoffTimer is the time we wait before attempting to make another request
 	// to the github API.
 	BackoffTimer = 1 * time.Minute
+
+	// PollTargetsRefreshInterval is the interval at which we refresh the list of
+	// repositories we poll for workflow jobs, when polling an organization.
+	PollTargetsRefreshInterval = 10 * time.Minute
+	// PollRateLimitReserve is the number of API requests we leave untouched when polling
+	// for workflow jobs. Once we get below this, polling pauses until the rate limit resets,
+	// leaving the remaining requests for runner management.
+	PollRateLimitReserve = 200
+
+	// WebhookDeliveryRecoveryWindow is how far back we look for webhook deliveries we
+	// missed. Github only keeps deliveries for a few days, and garm removes processed
+	// deliveries after 3 days. This needs to stay below both.
+	WebhookDeliveryRecoveryWindow = 24 * time.Hour
+	// WebhookDeliveryRecoveryOverlap is subtracted from the high-water mark when looking
+	// for missed deliveries, to account for clock differences between garm and github.
+	WebhookDeliveryRecoveryOverlap = 5 * time.Minute
+
+	// PoolDemandSampleInterval is the interval at which we count the queued jobs
+	// each pool could run.
+	PoolDemandSampleInterval = 1 * time.Minute
+	// PoolDemandLookback is how far back we look in the demand statistics of a pool
+	// to set its idle runner target, when auto_min_idle_runners is enabled.
+	PoolDemandLookback = 7 * 24 * time.Hour
+	// PoolDemandStatsRetention is how long we keep pool demand statistics.
+	PoolDemandStatsRetention = 2 * PoolDemandLookback
+	// PoolDemandPercentile is the percentile of concurrently queued jobs used as the
+	// idle runner target of pools with auto_min_idle_runners enabled.
+	PoolDemandPercentile = 90
 )
 
 //go:generate mockery --all
@@ -40,9 +68,20 @@ type PoolManager interface {
 	ID() string
 	WebhookSecret() string
 	GithubRunnerRegistrationToken() (string, error)
+	// GithubRunnerJITConfig returns a base64 encoded just-in-time runner configuration
+	// for the given instance. The config can only be used to register that one runner.
+	GithubRunnerJITConfig(instance params.Instance) (string, error)
 	HandleWorkflowJob(job params.WorkflowJob) error
 	RefreshState(param params.UpdatePoolStateParams) error
 	ForceDeleteRunner(runner params.Instance) error
+
+	// InstallWebhook installs the workflow_job webhook pointing to garm on the entity
+	// managed by this pool manager. An existing garm webhook is updated instead.
+	InstallWebhook(param params.InstallWebhookParams) (params.HookInfo, error)
+	// GetWebhookInfo returns details about the webhook pointing to garm.
+	GetWebhookInfo() (params.HookInfo, error)
+	// UninstallWebhook removes the webhook pointing to garm.
+	UninstallWebhook() error
 	// AddPool(ctx context.Context, pool params.Pool) error
 
 	// PoolManager lifecycle functions. Start/stop pool.
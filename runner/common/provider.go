@@ -36,6 +36,16 @@ type Provider interface {
 	Stop(ctx context.Context, instance string, force bool) error
 	// Start boots up an instance.
 	Start(ctx context.Context, instance string) error
+	// Health checks if the provider is able to serve requests. The result of the
+	// last check is reported by AsParams. Providers that cannot check their health
+	// return nil.
+	Health(ctx context.Context) error
+	// GetConsoleLog returns the console log of an instance. It is only used if the
+	// provider reports the ConsoleLogs capability.
+	GetConsoleLog(ctx context.Context, instance string) (string, error)
+	// ValidateImage returns an error if instances cannot be created using the image
+	// and flavor. It is only used if the provider reports the ImageValidation capability.
+	ValidateImage(ctx context.Context, image, flavor string) error
 
 	AsParams() params.Provider
 }
@@ -22,6 +22,8 @@ type GithubClient interface {
 	RemoveRunner(ctx context.Context, owner, repo string, runnerID int64) (*github.Response, error)
 	// CreateRegistrationToken creates a runner registration token for one repository.
 	CreateRegistrationToken(ctx context.Context, owner, repo string) (*github.RegistrationToken, *github.Response, error)
+	// GenerateRepoJITConfig generates a just-in-time runner configuration for one repository.
+	GenerateRepoJITConfig(ctx context.Context, owner, repo string, request *github.GenerateJITConfigRequest) (*github.JITRunnerConfig, *github.Response, error)
 
 	// ListOrganizationRunners lists all runners within an organization.
 	ListOrganizationRunners(ctx context.Context, owner string, opts *github.ListOptions) (*github.Runners, *github.Response, error)
@@ -32,6 +34,47 @@ type GithubClient interface {
 	RemoveOrganizationRunner(ctx context.Context, owner string, runnerID int64) (*github.Response, error)
 	// CreateOrganizationRegistrationToken creates a runner registration token for an organization.
 	CreateOrganizationRegistrationToken(ctx context.Context, owner string) (*github.RegistrationToken, *github.Response, error)
+	// GenerateOrgJITConfig generates a just-in-time runner configuration for an organization.
+	GenerateOrgJITConfig(ctx context.Context, owner string, request *github.GenerateJITConfigRequest) (*github.JITRunnerConfig, *github.Response, error)
+	// ListOrganizationRunnerGroups lists all runner groups within an organization.
+	ListOrganizationRunnerGroups(ctx context.Context, org string, opts *github.ListOrgRunnerGroupOptions) (*github.RunnerGroups, *github.Response, error)
+
+	// ListHooks lists all webhooks defined on a repository.
+	ListHooks(ctx context.Context, owner, repo string, opts *github.ListOptions) ([]*github.Hook, *github.Response, error)
+	// CreateHook creates a new webhook on a repository.
+	CreateHook(ctx context.Context, owner, repo string, hook *github.Hook) (*github.Hook, *github.Response, error)
+	// EditHook updates a webhook defined on a repository.
+	EditHook(ctx context.Context, owner, repo string, id int64, hook *github.Hook) (*github.Hook, *github.Response, error)
+	// DeleteHook removes a webhook from a repository.
+	DeleteHook(ctx context.Context, owner, repo string, id int64) (*github.Response, error)
+	// PingHook triggers a ping event to be sent to a repository webhook.
+	PingHook(ctx context.Context, owner, repo string, id int64) (*github.Response, error)
+	// ListHookDeliveries lists the deliveries of a repository webhook, newest first.
+	ListHookDeliveries(ctx context.Context, owner, repo string, id int64, opts *github.ListCursorOptions) ([]*github.HookDelivery, *github.Response, error)
+	// GetHookDelivery gets a single delivery of a repository webhook, including its payload.
+	GetHookDelivery(ctx context.Context, owner, repo string, hookID, deliveryID int64) (*github.HookDelivery, *github.Response, error)
+
+	// ListOrgHooks lists all webhooks defined on an organization.
+	ListOrgHooks(ctx context.Context, org string, opts *github.ListOptions) ([]*github.Hook, *github.Response, error)
+	// CreateOrgHook creates a new webhook on an organization.
+	CreateOrgHook(ctx context.Context, org string, hook *github.Hook) (*github.Hook, *github.Response, error)
+	// EditOrgHook updates a webhook defined on an organization.
+	EditOrgHook(ctx context.Context, org string, id int64, hook *github.Hook) (*github.Hook, *github.Response, error)
+	// DeleteOrgHook removes a webhook from an organization.
+	DeleteOrgHook(ctx context.Context, org string, id int64) (*github.Response, error)
+	// PingOrgHook triggers a ping event to be sent to an organization webhook.
+	PingOrgHook(ctx context.Context, org string, id int64) (*github.Response, error)
+	// ListOrgHookDeliveries lists the deliveries of an organization webhook, newest first.
+	ListOrgHookDeliveries(ctx context.Context, org string, id int64, opts *github.ListCursorOptions) ([]*github.HookDelivery, *github.Response, error)
+	// GetOrgHookDelivery gets a single delivery of an organization webhook, including its payload.
+	GetOrgHookDelivery(ctx context.Context, org string, hookID, deliveryID int64) (*github.HookDelivery, *github.Response, error)
+
+	// ListRepositoryWorkflowRuns lists the workflow runs of a repository.
+	ListRepositoryWorkflowRuns(ctx context.Context, owner, repo string, opts *github.ListWorkflowRunsOptions) (*github.WorkflowRuns, *github.Response, error)
+	// ListWorkflowJobs lists the jobs that belong to a workflow run.
+	ListWorkflowJobs(ctx context.Context, owner, repo string, runID int64, opts *github.ListWorkflowJobsOptions) (*github.Jobs, *github.Response, error)
+	// ListOrgRepos lists the repositories that belong to an organization.
+	ListOrgRepos(ctx context.Context, org string, opts *github.RepositoryListByOrgOptions) ([]*github.Repository, *github.Response, error)
 }
 
 type GithubEnterpriseClient interface {
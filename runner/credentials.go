@@ -0,0 +1,301 @@
+// Copyright 2023 Cloudbase Solutions SRL
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package runner
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/cloudbase/garm/auth"
+	"github.com/cloudbase/garm/config"
+	dbCommon "github.com/cloudbase/garm/database/common"
+	runnerErrors "github.com/cloudbase/garm/errors"
+	"github.com/cloudbase/garm/params"
+
+	"github.com/pkg/errors"
+)
+
+// getGithubCredentials returns the github credentials with the given name. Credentials
+// defined in the config file take precedence over the ones stored in the database.
+func getGithubCredentials(ctx context.Context, name string, cfgCreds map[string]config.Github, store dbCommon.Store) (params.GithubCredentials, error) {
+	if creds, ok := cfgCreds[name]; ok {
+		return githubCredentialsFromConfig(creds)
+	}
+
+	creds, err := store.GetGithubCredentialsByName(ctx, name)
+	if err != nil {
+		return params.GithubCredentials{}, errors.Wrap(err, "fetching github credentials")
+	}
+	return creds, nil
+}
+
+func githubCredentialsFromConfig(creds config.Github) (params.GithubCredentials, error) {
+	caBundle, err := creds.CACertBundle()
+	if err != nil {
+		return params.GithubCredentials{}, fmt.Errorf("fetching CA bundle for creds: %w", err)
+	}
+
+	var app params.GithubApp
+	if creds.GetAuthType() == params.GithubAuthTypeApp {
+		privateKey, err := creds.App.PrivateKeyBytes()
+		if err != nil {
+			return params.GithubCredentials{}, fmt.Errorf("fetching github app private key for creds: %w", err)
+		}
+		app = params.GithubApp{
+			AppID:          creds.App.AppID,
+			InstallationID: creds.App.InstallationID,
+			PrivateKey:     privateKey,
+			WebhookSecret:  creds.App.WebhookSecret,
+		}
+	}
+
+	return params.GithubCredentials{
+		Name:          creds.Name,
+		Description:   creds.Description,
+		BaseURL:       creds.BaseEndpoint(),
+		APIBaseURL:    creds.APIEndpoint(),
+		UploadBaseURL: creds.UploadEndpoint(),
+		CABundle:      caBundle,
+		AuthType:      creds.GetAuthType(),
+		MaxRunners:    creds.MaxRunners,
+		OAuth2Token:   creds.OAuth2Token,
+		App:           app,
+	}, nil
+}
+
+func (r *Runner) getGithubCredentials(ctx context.Context, name string) (params.GithubCredentials, error) {
+	return getGithubCredentials(ctx, name, r.credentials, r.store)
+}
+
+func (r *Runner) ListCredentials(ctx context.Context) ([]params.GithubCredentials, error) {
+	if !auth.IsAdmin(ctx) {
+		return nil, runnerErrors.ErrUnauthorized
+	}
+	ret, err := r.listAllCredentials(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for idx, val := range ret {
+		runners, err := r.store.CredentialsInstanceCount(ctx, val.Name)
+		if err != nil {
+			return nil, errors.Wrap(err, "fetching credentials usage")
+		}
+		ret[idx].Runners = uint(runners)
+	}
+	return ret, nil
+}
+
+// listAllCredentials returns the credentials defined in the config file, followed by the
+// credentials stored in the database. Secrets are left out, but the github app details
+// needed to identify the installation of app credentials are kept.
+func (r *Runner) listAllCredentials(ctx context.Context) ([]params.GithubCredentials, error) {
+	ret := []params.GithubCredentials{}
+
+	for _, val := range r.config.Github {
+		ret = append(ret, params.GithubCredentials{
+			Name:          val.Name,
+			Description:   val.Description,
+			BaseURL:       val.BaseEndpoint(),
+			APIBaseURL:    val.APIEndpoint(),
+			UploadBaseURL: val.UploadEndpoint(),
+			AuthType:      val.GetAuthType(),
+			MaxRunners:    val.MaxRunners,
+			App: params.GithubApp{
+				AppID:          val.App.AppID,
+				InstallationID: val.App.InstallationID,
+			},
+		})
+	}
+
+	dbCreds, err := r.store.ListGithubCredentials(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "fetching github credentials")
+	}
+	for _, val := range dbCreds {
+		ret = append(ret, params.GithubCredentials{
+			Name:          val.Name,
+			Description:   val.Description,
+			BaseURL:       val.BaseURL,
+			APIBaseURL:    val.APIBaseURL,
+			UploadBaseURL: val.UploadBaseURL,
+			AuthType:      val.AuthType,
+			MaxRunners:    val.MaxRunners,
+			App: params.GithubApp{
+				AppID:          val.App.AppID,
+				InstallationID: val.App.InstallationID,
+			},
+		})
+	}
+	return ret, nil
+}
+
+func (r *Runner) CreateGithubCredentials(ctx context.Context, param params.CreateGithubCredentialsParams) (params.GithubCredentials, error) {
+	if !auth.IsAdmin(ctx) {
+		return params.GithubCredentials{}, runnerErrors.ErrUnauthorized
+	}
+
+	if err := param.Validate(); err != nil {
+		return params.GithubCredentials{}, errors.Wrap(err, "validating params")
+	}
+
+	if _, ok := r.credentials[param.Name]; ok {
+		return params.GithubCredentials{}, runnerErrors.NewConflictError("credentials %s are already defined in the config file", param.Name)
+	}
+
+	_, err := r.store.GetGithubCredentialsByName(ctx, param.Name)
+	if err != nil {
+		if !errors.Is(err, runnerErrors.ErrNotFound) {
+			return params.GithubCredentials{}, errors.Wrap(err, "fetching github credentials")
+		}
+	} else {
+		return params.GithubCredentials{}, runnerErrors.NewConflictError("credentials %s already exist", param.Name)
+	}
+
+	creds, err := r.store.CreateGithubCredentials(ctx, param)
+	if err != nil {
+		return params.GithubCredentials{}, errors.Wrap(err, "creating github credentials")
+	}
+	return creds, nil
+}
+
+func (r *Runner) UpdateGithubCredentials(ctx context.Context, name string, param params.UpdateGithubCredentialsParams) (params.GithubCredentials, error) {
+	if !auth.IsAdmin(ctx) {
+		return params.GithubCredentials{}, runnerErrors.ErrUnauthorized
+	}
+
+	if _, ok := r.credentials[name]; ok {
+		return params.GithubCredentials{}, runnerErrors.NewBadRequestError("credentials %s are defined in the config file and can not be updated", name)
+	}
+
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	creds, err := r.store.GetGithubCredentialsByName(ctx, name)
+	if err != nil {
+		return params.GithubCredentials{}, errors.Wrap(err, "fetching github credentials")
+	}
+
+	if err := param.Validate(creds.AuthType); err != nil {
+		return params.GithubCredentials{}, errors.Wrap(err, "validating params")
+	}
+
+	creds, err = r.store.UpdateGithubCredentials(ctx, name, param)
+	if err != nil {
+		return params.GithubCredentials{}, errors.Wrap(err, "updating github credentials")
+	}
+
+	// Pool managers only look at the credentials when they are created or when their state
+	// is refreshed. Refresh all the pool managers using these credentials, so they pick up
+	// the new values without needing a restart.
+	if err := r.refreshPoolManagersForCredentials(ctx, name); err != nil {
+		return params.GithubCredentials{}, errors.Wrap(err, "refreshing pool managers")
+	}
+	return creds, nil
+}
+
+func (r *Runner) DeleteGithubCredentials(ctx context.Context, name string) error {
+	if !auth.IsAdmin(ctx) {
+		return runnerErrors.ErrUnauthorized
+	}
+
+	if _, ok := r.credentials[name]; ok {
+		return runnerErrors.NewBadRequestError("credentials %s are defined in the config file and can not be deleted", name)
+	}
+
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	repos, err := r.store.ListRepositories(ctx)
+	if err != nil {
+		return errors.Wrap(err, "fetching repositories")
+	}
+	for _, repo := range repos {
+		if repo.CredentialsName == name {
+			return runnerErrors.NewConflictError("credentials %s are in use by repository %s/%s", name, repo.Owner, repo.Name)
+		}
+	}
+
+	orgs, err := r.store.ListOrganizations(ctx)
+	if err != nil {
+		return errors.Wrap(err, "fetching organizations")
+	}
+	for _, org := range orgs {
+		if org.CredentialsName == name {
+			return runnerErrors.NewConflictError("credentials %s are in use by organization %s", name, org.Name)
+		}
+	}
+
+	enterprises, err := r.store.ListEnterprises(ctx)
+	if err != nil {
+		return errors.Wrap(err, "fetching enterprises")
+	}
+	for _, enterprise := range enterprises {
+		if enterprise.CredentialsName == name {
+			return runnerErrors.NewConflictError("credentials %s are in use by enterprise %s", name, enterprise.Name)
+		}
+	}
+
+	if err := r.store.DeleteGithubCredentials(ctx, name); err != nil {
+		return errors.Wrap(err, "deleting github credentials")
+	}
+	return nil
+}
+
+// refreshPoolManagersForCredentials refreshes the state of all pool managers belonging to
+// entities that use the credentials with the given name. Failing to refresh one pool manager
+// does not prevent the others from being refreshed.
+func (r *Runner) refreshPoolManagersForCredentials(ctx context.Context, name string) error {
+	repos, err := r.store.ListRepositories(ctx)
+	if err != nil {
+		return errors.Wrap(err, "fetching repositories")
+	}
+	for _, repo := range repos {
+		if repo.CredentialsName != name {
+			continue
+		}
+		if _, err := r.poolManagerCtrl.UpdateRepoPoolManager(r.ctx, repo); err != nil {
+			log.Printf("failed to refresh pool manager for repo %s/%s: %s", repo.Owner, repo.Name, err)
+		}
+	}
+
+	orgs, err := r.store.ListOrganizations(ctx)
+	if err != nil {
+		return errors.Wrap(err, "fetching organizations")
+	}
+	for _, org := range orgs {
+		if org.CredentialsName != name {
+			continue
+		}
+		if _, err := r.poolManagerCtrl.UpdateOrgPoolManager(r.ctx, org); err != nil {
+			log.Printf("failed to refresh pool manager for org %s: %s", org.Name, err)
+		}
+	}
+
+	enterprises, err := r.store.ListEnterprises(ctx)
+	if err != nil {
+		return errors.Wrap(err, "fetching enterprises")
+	}
+	for _, enterprise := range enterprises {
+		if enterprise.CredentialsName != name {
+			continue
+		}
+		if _, err := r.poolManagerCtrl.UpdateEnterprisePoolManager(r.ctx, enterprise); err != nil {
+			log.Printf("failed to refresh pool manager for enterprise %s: %s", enterprise.Name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,276 @@
+// Copyright 2023 Cloudbase Solutions SRL
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package runner
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/cloudbase/garm/auth"
+	"github.com/cloudbase/garm/config"
+	"github.com/cloudbase/garm/database"
+	dbCommon "github.com/cloudbase/garm/database/common"
+	runnerErrors "github.com/cloudbase/garm/errors"
+	garmTesting "github.com/cloudbase/garm/internal/testing"
+	"github.com/cloudbase/garm/params"
+	runnerCommonMocks "github.com/cloudbase/garm/runner/common/mocks"
+	runnerMocks "github.com/cloudbase/garm/runner/mocks"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type CredentialsTestFixtures struct {
+	AdminContext            context.Context
+	Store                   dbCommon.Store
+	StoreCredentials        params.GithubCredentials
+	StoreRepo               params.Repository
+	Credentials             map[string]config.Github
+	CreateCredentialsParams params.CreateGithubCredentialsParams
+	PoolMgrMock             *runnerCommonMocks.PoolManager
+	PoolMgrCtrlMock         *runnerMocks.PoolManagerController
+}
+
+type CredentialsTestSuite struct {
+	suite.Suite
+	Fixtures *CredentialsTestFixtures
+	Runner   *Runner
+}
+
+func (s *CredentialsTestSuite) SetupTest() {
+	adminCtx := auth.GetAdminContext()
+
+	// create testing sqlite database
+	dbCfg := garmTesting.GetTestSqliteDBConfig(s.T())
+	db, err := database.NewDatabase(adminCtx, dbCfg)
+	if err != nil {
+		s.FailNow(fmt.Sprintf("failed to create db connection: %s", err))
+	}
+
+	creds, err := db.CreateGithubCredentials(adminCtx, params.CreateGithubCredentialsParams{
+		Name:        "test-db-creds",
+		Description: "test-db-creds-description",
+		OAuth2Token: "test-db-creds-token",
+	})
+	if err != nil {
+		s.FailNow(fmt.Sprintf("failed to create github credentials: %s", err))
+	}
+
+	repo, err := db.CreateRepository(adminCtx, "https://github.com", "test-owner", "test-repo", creds.Name, "test-webhook-secret")
+	if err != nil {
+		s.FailNow(fmt.Sprintf("failed to create repository: %s", err))
+	}
+
+	fixtures := &CredentialsTestFixtures{
+		AdminContext:     adminCtx,
+		Store:            db,
+		StoreCredentials: creds,
+		StoreRepo:        repo,
+		Credentials: map[string]config.Github{
+			"test-creds": {
+				Name:        "test-creds",
+				Description: "test-creds-description",
+				OAuth2Token: "test-creds-oauth2-token",
+			},
+		},
+		CreateCredentialsParams: params.CreateGithubCredentialsParams{
+			Name:        "test-new-creds",
+			Description: "test-new-creds-description",
+			OAuth2Token: "test-new-creds-token",
+		},
+		PoolMgrMock:     runnerCommonMocks.NewPoolManager(s.T()),
+		PoolMgrCtrlMock: runnerMocks.NewPoolManagerController(s.T()),
+	}
+	s.Fixtures = fixtures
+
+	s.Runner = &Runner{
+		config: config.Config{
+			Github: []config.Github{fixtures.Credentials["test-creds"]},
+		},
+		credentials:     fixtures.Credentials,
+		ctx:             fixtures.AdminContext,
+		store:           fixtures.Store,
+		poolManagerCtrl: fixtures.PoolMgrCtrlMock,
+	}
+}
+
+func (s *CredentialsTestSuite) TestListCredentials() {
+	creds, err := s.Runner.ListCredentials(s.Fixtures.AdminContext)
+
+	s.Require().Nil(err)
+	s.Require().Len(creds, 2)
+	s.Require().Equal("test-creds", creds[0].Name)
+	s.Require().Equal(s.Fixtures.StoreCredentials.Name, creds[1].Name)
+	s.Require().Empty(creds[1].OAuth2Token)
+}
+
+func (s *CredentialsTestSuite) TestListCredentialsRunners() {
+	var maxRunners uint = 10
+	_, err := s.Fixtures.Store.UpdateGithubCredentials(s.Fixtures.AdminContext, s.Fixtures.StoreCredentials.Name, params.UpdateGithubCredentialsParams{
+		MaxRunners: &maxRunners,
+	})
+	s.Require().Nil(err)
+	pool, err := s.Fixtures.Store.CreateRepositoryPool(s.Fixtures.AdminContext, s.Fixtures.StoreRepo.ID, params.CreatePoolParams{
+		ProviderName: "test-provider",
+		MaxRunners:   4,
+		Image:        "test-image",
+		Flavor:       "test-flavor",
+		OSType:       "linux",
+		Tags:         []string{"self-hosted"},
+	})
+	s.Require().Nil(err)
+	_, err = s.Fixtures.Store.CreateInstance(s.Fixtures.AdminContext, pool.ID, params.CreateInstanceParams{Name: "test-instance", OSType: "linux"})
+	s.Require().Nil(err)
+
+	creds, err := s.Runner.ListCredentials(s.Fixtures.AdminContext)
+
+	s.Require().Nil(err)
+	s.Require().Len(creds, 2)
+	s.Require().Equal(uint(0), creds[0].Runners)
+	s.Require().Equal(maxRunners, creds[1].MaxRunners)
+	s.Require().Equal(uint(1), creds[1].Runners)
+}
+
+func (s *CredentialsTestSuite) TestInstallationCredentials() {
+	s.Runner.config.Github = append(s.Runner.config.Github, config.Github{
+		Name:     "test-config-app-creds",
+		AuthType: params.GithubAuthTypeApp,
+		App: config.GithubApp{
+			AppID:          1,
+			InstallationID: 42,
+		},
+	})
+	_, err := s.Fixtures.Store.CreateGithubCredentials(s.Fixtures.AdminContext, params.CreateGithubCredentialsParams{
+		Name:     "test-db-app-creds",
+		AuthType: params.GithubAuthTypeApp,
+		App: params.GithubApp{
+			AppID:          2,
+			InstallationID: 42,
+			PrivateKey:     []byte("test-private-key"),
+		},
+	})
+	if err != nil {
+		s.FailNow(fmt.Sprintf("failed to create github credentials: %s", err))
+	}
+
+	creds, err := s.Runner.installationCredentials(42)
+
+	s.Require().Nil(err)
+	s.Require().Len(creds, 2)
+	s.Require().Equal("test-config-app-creds", creds[0].Name)
+	s.Require().Equal("test-db-app-creds", creds[1].Name)
+	s.Require().Empty(creds[1].App.PrivateKey)
+
+	creds, err = s.Runner.installationCredentials(43)
+	s.Require().Nil(err)
+	s.Require().Empty(creds)
+}
+
+func (s *CredentialsTestSuite) TestCreateGithubCredentials() {
+	creds, err := s.Runner.CreateGithubCredentials(s.Fixtures.AdminContext, s.Fixtures.CreateCredentialsParams)
+
+	s.Require().Nil(err)
+	s.Require().Equal(s.Fixtures.CreateCredentialsParams.Name, creds.Name)
+	s.Require().Equal(s.Fixtures.CreateCredentialsParams.Description, creds.Description)
+}
+
+func (s *CredentialsTestSuite) TestCreateGithubCredentialsErrUnauthorized() {
+	_, err := s.Runner.CreateGithubCredentials(context.Background(), s.Fixtures.CreateCredentialsParams)
+
+	s.Require().Equal(runnerErrors.ErrUnauthorized, err)
+}
+
+func (s *CredentialsTestSuite) TestCreateGithubCredentialsInvalidParams() {
+	s.Fixtures.CreateCredentialsParams.OAuth2Token = ""
+
+	_, err := s.Runner.CreateGithubCredentials(s.Fixtures.AdminContext, s.Fixtures.CreateCredentialsParams)
+
+	s.Require().Equal("validating params: missing oauth2 token", err.Error())
+}
+
+func (s *CredentialsTestSuite) TestCreateGithubCredentialsDefinedInConfig() {
+	s.Fixtures.CreateCredentialsParams.Name = "test-creds"
+
+	_, err := s.Runner.CreateGithubCredentials(s.Fixtures.AdminContext, s.Fixtures.CreateCredentialsParams)
+
+	s.Require().Equal(runnerErrors.NewConflictError("credentials test-creds are already defined in the config file"), err)
+}
+
+func (s *CredentialsTestSuite) TestCreateGithubCredentialsAlreadyExist() {
+	s.Fixtures.CreateCredentialsParams.Name = s.Fixtures.StoreCredentials.Name
+
+	_, err := s.Runner.CreateGithubCredentials(s.Fixtures.AdminContext, s.Fixtures.CreateCredentialsParams)
+
+	s.Require().Equal(runnerErrors.NewConflictError("credentials %s already exist", s.Fixtures.StoreCredentials.Name), err)
+}
+
+func (s *CredentialsTestSuite) TestUpdateGithubCredentials() {
+	s.Fixtures.PoolMgrCtrlMock.On("UpdateRepoPoolManager", s.Fixtures.AdminContext, mock.AnythingOfType("params.Repository")).Return(s.Fixtures.PoolMgrMock, nil)
+
+	updateParams := params.UpdateGithubCredentialsParams{
+		OAuth2Token: "test-updated-token",
+	}
+	creds, err := s.Runner.UpdateGithubCredentials(s.Fixtures.AdminContext, s.Fixtures.StoreCredentials.Name, updateParams)
+
+	s.Fixtures.PoolMgrCtrlMock.AssertExpectations(s.T())
+	s.Require().Nil(err)
+	s.Require().Equal("test-updated-token", creds.OAuth2Token)
+}
+
+func (s *CredentialsTestSuite) TestUpdateGithubCredentialsDefinedInConfig() {
+	_, err := s.Runner.UpdateGithubCredentials(s.Fixtures.AdminContext, "test-creds", params.UpdateGithubCredentialsParams{})
+
+	s.Require().Equal(runnerErrors.NewBadRequestError("credentials test-creds are defined in the config file and can not be updated"), err)
+}
+
+func (s *CredentialsTestSuite) TestUpdateGithubCredentialsAppDetailsOnPAT() {
+	updateParams := params.UpdateGithubCredentialsParams{
+		App: &params.GithubApp{AppID: 1},
+	}
+
+	_, err := s.Runner.UpdateGithubCredentials(s.Fixtures.AdminContext, s.Fixtures.StoreCredentials.Name, updateParams)
+
+	s.Require().Equal("validating params: cannot set github app details on credentials using a personal access token", err.Error())
+}
+
+func (s *CredentialsTestSuite) TestDeleteGithubCredentialsInUse() {
+	err := s.Runner.DeleteGithubCredentials(s.Fixtures.AdminContext, s.Fixtures.StoreCredentials.Name)
+
+	s.Require().Equal(runnerErrors.NewConflictError("credentials %s are in use by repository test-owner/test-repo", s.Fixtures.StoreCredentials.Name), err)
+}
+
+func (s *CredentialsTestSuite) TestDeleteGithubCredentials() {
+	err := s.Fixtures.Store.DeleteRepository(s.Fixtures.AdminContext, s.Fixtures.StoreRepo.ID)
+	if err != nil {
+		s.FailNow(fmt.Sprintf("failed to delete repository: %s", err))
+	}
+
+	err = s.Runner.DeleteGithubCredentials(s.Fixtures.AdminContext, s.Fixtures.StoreCredentials.Name)
+
+	s.Require().Nil(err)
+	_, err = s.Fixtures.Store.GetGithubCredentialsByName(s.Fixtures.AdminContext, s.Fixtures.StoreCredentials.Name)
+	s.Require().ErrorIs(err, runnerErrors.ErrNotFound)
+}
+
+func (s *CredentialsTestSuite) TestDeleteGithubCredentialsDefinedInConfig() {
+	err := s.Runner.DeleteGithubCredentials(s.Fixtures.AdminContext, "test-creds")
+
+	s.Require().Equal(runnerErrors.NewBadRequestError("credentials test-creds are defined in the config file and can not be deleted"), err)
+}
+
+func TestCredentialsTestSuite(t *testing.T) {
+	suite.Run(t, new(CredentialsTestSuite))
+}
@@ -0,0 +1,203 @@
+// Copyright 2023 Cloudbase Solutions SRL
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package runner
+
+import (
+	"context"
+	"time"
+
+	"github.com/cloudbase/garm/auth"
+	runnerErrors "github.com/cloudbase/garm/errors"
+	"github.com/cloudbase/garm/params"
+	providerCommon "github.com/cloudbase/garm/runner/providers/common"
+
+	"github.com/pkg/errors"
+)
+
+// drainStatus returns the drain status of a pool or provider, given the
+// runners it still has.
+func drainStatus(startedAt *time.Time, instances []params.Instance) params.DrainStatus {
+	ret := params.DrainStatus{
+		Draining:         startedAt != nil,
+		StartedAt:        startedAt,
+		RemainingRunners: uint(len(instances)),
+	}
+	for _, instance := range instances {
+		if instance.RunnerStatus == providerCommon.RunnerActive {
+			ret.ActiveRunners++
+		}
+	}
+	ret.Finished = ret.Draining && ret.RemainingRunners == 0
+	return ret
+}
+
+// StartPoolDrain stops the creation of new runners in a pool. Idle runners are removed,
+// while runners that are running a job are left alone.
+func (r *Runner) StartPoolDrain(ctx context.Context, poolID string) (params.Pool, error) {
+	if !auth.IsAdmin(ctx) {
+		return params.Pool{}, runnerErrors.ErrUnauthorized
+	}
+
+	pool, err := r.store.SetPoolDrain(ctx, poolID, true)
+	if err != nil {
+		return params.Pool{}, errors.Wrap(err, "starting pool drain")
+	}
+	return pool, nil
+}
+
+// StopPoolDrain lets a pool create runners again.
+func (r *Runner) StopPoolDrain(ctx context.Context, poolID string) (params.Pool, error) {
+	if !auth.IsAdmin(ctx) {
+		return params.Pool{}, runnerErrors.ErrUnauthorized
+	}
+
+	pool, err := r.store.SetPoolDrain(ctx, poolID, false)
+	if err != nil {
+		return params.Pool{}, errors.Wrap(err, "stopping pool drain")
+	}
+	return pool, nil
+}
+
+// GetPoolDrainStatus returns the drain status of a pool. A pool is also drained when
+// the provider it creates runners on is drained, in which case the drain started when
+// the first of the two drains did.
+func (r *Runner) GetPoolDrainStatus(ctx context.Context, poolID string) (params.DrainStatus, error) {
+	if !auth.IsAdmin(ctx) {
+		return params.DrainStatus{}, runnerErrors.ErrUnauthorized
+	}
+
+	pool, err := r.store.GetPoolByID(ctx, poolID)
+	if err != nil {
+		return params.DrainStatus{}, errors.Wrap(err, "fetching pool")
+	}
+
+	drains, err := r.store.ListProviderDrains(ctx)
+	if err != nil {
+		return params.DrainStatus{}, errors.Wrap(err, "fetching provider drains")
+	}
+	startedAt := pool.DrainStartedAt
+	for _, drain := range drains {
+		if drain.ProviderName != pool.ProviderName {
+			continue
+		}
+		if startedAt == nil || drain.StartedAt.Before(*startedAt) {
+			drainStartedAt := drain.StartedAt
+			startedAt = &drainStartedAt
+		}
+	}
+
+	instances, err := r.store.ListPoolInstances(ctx, poolID)
+	if err != nil {
+		return params.DrainStatus{}, errors.Wrap(err, "fetching instances")
+	}
+	return drainStatus(startedAt, instances), nil
+}
+
+// StartProviderDrain drains all pools that use a provider.
+func (r *Runner) StartProviderDrain(ctx context.Context, providerName string) (params.Provider, error) {
+	return r.setProviderDrain(ctx, providerName, true)
+}
+
+// StopProviderDrain lets the pools that use a provider create runners again. Pools
+// that were drained individually keep draining.
+func (r *Runner) StopProviderDrain(ctx context.Context, providerName string) (params.Provider, error) {
+	return r.setProviderDrain(ctx, providerName, false)
+}
+
+func (r *Runner) setProviderDrain(ctx context.Context, providerName string, draining bool) (params.Provider, error) {
+	if !auth.IsAdmin(ctx) {
+		return params.Provider{}, runnerErrors.ErrUnauthorized
+	}
+
+	if _, ok := r.providers[providerName]; !ok {
+		return params.Provider{}, runnerErrors.NewNotFoundError("provider %s not found", providerName)
+	}
+
+	if err := r.store.SetProviderDrain(ctx, providerName, draining); err != nil {
+		return params.Provider{}, errors.Wrap(err, "updating provider drain")
+	}
+	return r.getProvider(ctx, providerName)
+}
+
+func (r *Runner) GetProviderDrainStatus(ctx context.Context, providerName string) (params.DrainStatus, error) {
+	if !auth.IsAdmin(ctx) {
+		return params.DrainStatus{}, runnerErrors.ErrUnauthorized
+	}
+
+	provider, err := r.getProvider(ctx, providerName)
+	if err != nil {
+		return params.DrainStatus{}, err
+	}
+
+	pools, err := r.store.ListAllPools(ctx)
+	if err != nil {
+		return params.DrainStatus{}, errors.Wrap(err, "fetching pools")
+	}
+
+	instances := []params.Instance{}
+	for _, pool := range pools {
+		if !usesProvider(pool, providerName) {
+			continue
+		}
+		poolInstances, err := r.store.ListPoolInstances(ctx, pool.ID)
+		if err != nil {
+			return params.DrainStatus{}, errors.Wrap(err, "fetching instances")
+		}
+		for _, instance := range poolInstances {
+			if pool.InstanceProviderName(instance) == providerName {
+				instances = append(instances, instance)
+			}
+		}
+	}
+	return drainStatus(provider.DrainStartedAt, instances), nil
+}
+
+// usesProvider returns true if the pool may create runners on the provider.
+func usesProvider(pool params.Pool, providerName string) bool {
+	for _, provider := range pool.Providers() {
+		if provider.ProviderName == providerName {
+			return true
+		}
+	}
+	return false
+}
+
+// getProvider returns a configured provider, along with its drain state and usage.
+func (r *Runner) getProvider(ctx context.Context, providerName string) (params.Provider, error) {
+	provider, ok := r.providers[providerName]
+	if !ok {
+		return params.Provider{}, runnerErrors.NewNotFoundError("provider %s not found", providerName)
+	}
+
+	drains, err := r.store.ListProviderDrains(ctx)
+	if err != nil {
+		return params.Provider{}, errors.Wrap(err, "fetching provider drains")
+	}
+	return r.withProviderUsage(ctx, withProviderDrain(provider.AsParams(), drains))
+}
+
+// withProviderDrain sets the drain state of a provider from the list of drains in progress.
+func withProviderDrain(provider params.Provider, drains []params.ProviderDrain) params.Provider {
+	for _, drain := range drains {
+		if drain.ProviderName != provider.Name {
+			continue
+		}
+		startedAt := drain.StartedAt
+		provider.Draining = true
+		provider.DrainStartedAt = &startedAt
+		break
+	}
+	return provider
+}
@@ -10,6 +10,7 @@ import (
 	runnerErrors "github.com/cloudbase/garm/errors"
 	"github.com/cloudbase/garm/params"
 	"github.com/cloudbase/garm/runner/common"
+	"github.com/cloudbase/garm/util"
 	"github.com/cloudbase/garm/util/appdefaults"
 
 	"github.com/pkg/errors"
@@ -25,12 +26,16 @@ func (r *Runner) CreateEnterprise(ctx context.Context, param params.CreateEnterp
 		return params.Enterprise{}, errors.Wrap(err, "validating params")
 	}
 
-	creds, ok := r.credentials[param.CredentialsName]
-	if !ok {
-		return params.Enterprise{}, runnerErrors.NewBadRequestError("credentials %s not defined", param.CredentialsName)
+	creds, err := r.getGithubCredentials(ctx, param.CredentialsName)
+	if err != nil {
+		if errors.Is(err, runnerErrors.ErrNotFound) {
+			return params.Enterprise{}, runnerErrors.NewBadRequestError("credentials %s not defined", param.CredentialsName)
+		}
+		return params.Enterprise{}, errors.Wrap(err, "fetching credentials")
 	}
 
-	_, err = r.store.GetEnterprise(ctx, param.Name)
+	endpoint := util.GithubEndpoint(creds.BaseURL)
+	_, err = r.store.GetEnterprise(ctx, endpoint, param.Name)
 	if err != nil {
 		if !errors.Is(err, runnerErrors.ErrNotFound) {
 			return params.Enterprise{}, errors.Wrap(err, "fetching enterprise")
@@ -39,7 +44,7 @@ func (r *Runner) CreateEnterprise(ctx context.Context, param params.CreateEnterp
 		return params.Enterprise{}, runnerErrors.NewConflictError("enterprise %s already exists", param.Name)
 	}
 
-	enterprise, err = r.store.CreateEnterprise(ctx, param.Name, creds.Name, param.WebhookSecret)
+	enterprise, err = r.store.CreateEnterprise(ctx, endpoint, param.Name, creds.Name, param.WebhookSecret)
 	if err != nil {
 		return params.Enterprise{}, errors.Wrap(err, "creating enterprise")
 	}
@@ -52,6 +57,17 @@ func (r *Runner) CreateEnterprise(ctx context.Context, param params.CreateEnterp
 		}
 	}()
 
+	if param.PoolSelectionStrategy != "" || param.JobSchedulingStrategy != "" {
+		strategyParams := params.UpdateEntityParams{
+			PoolSelectionStrategy: param.PoolSelectionStrategy,
+			JobSchedulingStrategy: param.JobSchedulingStrategy,
+		}
+		enterprise, err = r.store.UpdateEnterprise(ctx, enterprise.ID, strategyParams)
+		if err != nil {
+			return params.Enterprise{}, errors.Wrap(err, "setting scheduling strategies")
+		}
+	}
+
 	var poolMgr common.PoolManager
 	poolMgr, err = r.poolManagerCtrl.CreateEnterprisePoolManager(r.ctx, enterprise, r.providers, r.store)
 	if err != nil {
@@ -63,6 +79,7 @@ func (r *Runner) CreateEnterprise(ctx context.Context, param params.CreateEnterp
 		}
 		return params.Enterprise{}, errors.Wrap(err, "starting enterprise pool manager")
 	}
+	enterprise.WebhookURL = util.EntityWebhookURL(r.config.Default.WebhookURL, enterprise.ID)
 	return enterprise, nil
 }
 
@@ -86,6 +103,7 @@ func (r *Runner) ListEnterprises(ctx context.Context) ([]params.Enterprise, erro
 		} else {
 			enterprise.PoolManagerStatus = poolMgr.Status()
 		}
+		enterprise.WebhookURL = util.EntityWebhookURL(r.config.Default.WebhookURL, enterprise.ID)
 		allEnterprises = append(allEnterprises, enterprise)
 	}
 
@@ -107,6 +125,7 @@ func (r *Runner) GetEnterpriseByID(ctx context.Context, enterpriseID string) (pa
 		enterprise.PoolManagerStatus.FailureReason = fmt.Sprintf("failed to get pool manager: %q", err)
 	}
 	enterprise.PoolManagerStatus = poolMgr.Status()
+	enterprise.WebhookURL = util.EntityWebhookURL(r.config.Default.WebhookURL, enterprise.ID)
 	return enterprise, nil
 }
 
@@ -149,6 +168,15 @@ func (r *Runner) UpdateEnterprise(ctx context.Context, enterpriseID string, para
 		return params.Enterprise{}, runnerErrors.ErrUnauthorized
 	}
 
+	if err := param.Validate(); err != nil {
+		return params.Enterprise{}, errors.Wrap(err, "validating params")
+	}
+
+	if param.PollingEnabled != nil || param.PollingInterval != nil {
+		// Github does not offer an API to list workflow jobs at the enterprise level.
+		return params.Enterprise{}, runnerErrors.NewBadRequestError("polling is not supported for enterprises")
+	}
+
 	r.mux.Lock()
 	defer r.mux.Unlock()
 
@@ -157,10 +185,27 @@ func (r *Runner) UpdateEnterprise(ctx context.Context, enterpriseID string, para
 		return params.Enterprise{}, errors.Wrap(err, "fetching enterprise")
 	}
 
+	var endpoint string
 	if param.CredentialsName != "" {
 		// Check that credentials are set before saving to db
-		if _, ok := r.credentials[param.CredentialsName]; !ok {
-			return params.Enterprise{}, runnerErrors.NewBadRequestError("invalid credentials (%s) for enterprise %s", param.CredentialsName, enterprise.Name)
+		creds, err := r.getGithubCredentials(ctx, param.CredentialsName)
+		if err != nil {
+			if errors.Is(err, runnerErrors.ErrNotFound) {
+				return params.Enterprise{}, runnerErrors.NewBadRequestError("invalid credentials (%s) for enterprise %s", param.CredentialsName, enterprise.Name)
+			}
+			return params.Enterprise{}, errors.Wrap(err, "fetching credentials")
+		}
+		// The endpoint is part of the identity of the enterprise. It can't be moved to
+		// another github server by switching credentials.
+		endpoint = util.GithubEndpoint(creds.BaseURL)
+		if enterprise.Endpoint != "" && enterprise.Endpoint != endpoint {
+			return params.Enterprise{}, runnerErrors.NewBadRequestError("credentials %s are for %s, but enterprise %s is on %s", param.CredentialsName, endpoint, enterprise.Name, enterprise.Endpoint)
+		}
+	}
+
+	if enterprise.Endpoint == "" && endpoint != "" {
+		if err := r.store.UpdateEntityEndpoint(ctx, params.EnterprisePool, enterpriseID, endpoint); err != nil {
+			return params.Enterprise{}, errors.Wrap(err, "updating enterprise endpoint")
 		}
 	}
 
@@ -175,6 +220,7 @@ func (r *Runner) UpdateEnterprise(ctx context.Context, enterpriseID string, para
 	}
 
 	enterprise.PoolManagerStatus = poolMgr.Status()
+	enterprise.WebhookURL = util.EntityWebhookURL(r.config.Default.WebhookURL, enterprise.ID)
 	return enterprise, nil
 }
 
@@ -195,7 +241,7 @@ func (r *Runner) CreateEnterprisePool(ctx context.Context, enterpriseID string,
 		return params.Pool{}, runnerErrors.ErrNotFound
 	}
 
-	createPoolParams, err := r.appendTagsToCreatePoolParams(param)
+	createPoolParams, err := r.appendTagsToCreatePoolParams(ctx, param)
 	if err != nil {
 		return params.Pool{}, errors.Wrap(err, "fetching pool params")
 	}
@@ -279,6 +325,7 @@ func (r *Runner) UpdateEnterprisePool(ctx context.Context, enterpriseID, poolID
 
 	maxRunners := pool.MaxRunners
 	minIdleRunners := pool.MinIdleRunners
+	warmRunners := pool.WarmRunners
 
 	if param.MaxRunners != nil {
 		maxRunners = *param.MaxRunners
@@ -286,11 +333,32 @@ func (r *Runner) UpdateEnterprisePool(ctx context.Context, enterpriseID, poolID
 	if param.MinIdleRunners != nil {
 		minIdleRunners = *param.MinIdleRunners
 	}
+	if param.WarmRunners != nil {
+		warmRunners = *param.WarmRunners
+	}
 
 	if minIdleRunners > maxRunners {
 		return params.Pool{}, runnerErrors.NewBadRequestError("min_idle_runners cannot be larger than max_runners")
 	}
 
+	if minIdleRunners+warmRunners > maxRunners {
+		return params.Pool{}, runnerErrors.NewBadRequestError("min_idle_runners plus warm_runners cannot be larger than max_runners")
+	}
+
+	if err := validateScalingSchedules(poolWithUpdate(pool, param)); err != nil {
+		return params.Pool{}, err
+	}
+
+	if param.FallbackProviders != nil {
+		if err := r.validateFallbackProviders(pool.ProviderName, param.FallbackProviders); err != nil {
+			return params.Pool{}, err
+		}
+	}
+
+	if err := r.validateProviderCapabilities(ctx, poolWithUpdate(pool, param), imagesChanged(param)); err != nil {
+		return params.Pool{}, err
+	}
+
 	newPool, err := r.store.UpdateEnterprisePool(ctx, enterpriseID, poolID, param)
 	if err != nil {
 		return params.Pool{}, errors.Wrap(err, "updating pool")
@@ -310,11 +378,11 @@ func (r *Runner) ListEnterpriseInstances(ctx context.Context, enterpriseID strin
 	return instances, nil
 }
 
-func (r *Runner) findEnterprisePoolManager(name string) (common.PoolManager, error) {
+func (r *Runner) findEnterprisePoolManager(endpoint, name string) (common.PoolManager, error) {
 	r.mux.Lock()
 	defer r.mux.Unlock()
 
-	enterprise, err := r.store.GetEnterprise(r.ctx, name)
+	enterprise, err := r.store.GetEnterprise(r.ctx, endpoint, name)
 	if err != nil {
 		return nil, errors.Wrap(err, "fetching enterprise")
 	}
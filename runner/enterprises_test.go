@@ -75,6 +75,7 @@ func (s *EnterpriseTestSuite) SetupTest() {
 		name := fmt.Sprintf("test-enterprise-%v", i)
 		enterprise, err := db.CreateEnterprise(
 			adminCtx,
+			"https://github.com",
 			name,
 			fmt.Sprintf("test-creds-%v", i),
 			fmt.Sprintf("test-webhook-secret-%v", i),
@@ -89,6 +90,10 @@ func (s *EnterpriseTestSuite) SetupTest() {
 	var maxRunners uint = 40
 	var minIdleRunners uint = 20
 	providerMock := runnerCommonMocks.NewProvider(s.T())
+	providerMock.On("AsParams").Return(params.Provider{
+		Name:         "test-provider",
+		Capabilities: params.ProviderCapabilities{StartStop: true},
+	}).Maybe()
 	fixtures := &EnterpriseTestFixtures{
 		AdminContext:     adminCtx,
 		DBFile:           dbCfg.SQLite.DBFile,
@@ -316,6 +321,39 @@ func (s *EnterpriseTestSuite) TestUpdateEnterpriseInvalidCreds() {
 	s.Require().Equal(runnerErrors.NewBadRequestError("invalid credentials (%s) for enterprise %s", s.Fixtures.UpdateRepoParams.CredentialsName, s.Fixtures.StoreEnterprises["test-enterprise-1"].Name), err)
 }
 
+func (s *EnterpriseTestSuite) TestUpdateEnterprisePollingNotSupported() {
+	pollingEnabled := true
+	s.Fixtures.UpdateRepoParams.PollingEnabled = &pollingEnabled
+
+	_, err := s.Runner.UpdateEnterprise(s.Fixtures.AdminContext, s.Fixtures.StoreEnterprises["test-enterprise-1"].ID, s.Fixtures.UpdateRepoParams)
+
+	s.Require().Equal(runnerErrors.NewBadRequestError("polling is not supported for enterprises"), err)
+}
+
+func (s *EnterpriseTestSuite) TestUpdateEnterprisePoolSelectionStrategy() {
+	s.Fixtures.UpdateRepoParams.PoolSelectionStrategy = params.PoolSelectionWeightedRandom
+	s.Fixtures.PoolMgrCtrlMock.On("UpdateEnterprisePoolManager", s.Fixtures.AdminContext, mock.AnythingOfType("params.Enterprise")).Return(s.Fixtures.PoolMgrMock, nil)
+	s.Fixtures.PoolMgrMock.On("Status").Return(params.PoolManagerStatus{IsRunning: true}, nil)
+
+	enterprise, err := s.Runner.UpdateEnterprise(s.Fixtures.AdminContext, s.Fixtures.StoreEnterprises["test-enterprise-1"].ID, s.Fixtures.UpdateRepoParams)
+
+	s.Fixtures.PoolMgrCtrlMock.AssertExpectations(s.T())
+	s.Require().Nil(err)
+	s.Require().Equal(params.PoolSelectionWeightedRandom, enterprise.PoolSelectionStrategy)
+}
+
+func (s *EnterpriseTestSuite) TestUpdateEnterpriseJobSchedulingStrategy() {
+	s.Fixtures.UpdateRepoParams.JobSchedulingStrategy = params.JobSchedulingFairShare
+	s.Fixtures.PoolMgrCtrlMock.On("UpdateEnterprisePoolManager", s.Fixtures.AdminContext, mock.AnythingOfType("params.Enterprise")).Return(s.Fixtures.PoolMgrMock, nil)
+	s.Fixtures.PoolMgrMock.On("Status").Return(params.PoolManagerStatus{IsRunning: true}, nil)
+
+	enterprise, err := s.Runner.UpdateEnterprise(s.Fixtures.AdminContext, s.Fixtures.StoreEnterprises["test-enterprise-1"].ID, s.Fixtures.UpdateRepoParams)
+
+	s.Fixtures.PoolMgrCtrlMock.AssertExpectations(s.T())
+	s.Require().Nil(err)
+	s.Require().Equal(params.JobSchedulingFairShare, enterprise.JobSchedulingStrategy)
+}
+
 func (s *EnterpriseTestSuite) TestUpdateEnterprisePoolMgrFailed() {
 	s.Fixtures.PoolMgrCtrlMock.On("UpdateEnterprisePoolManager", s.Fixtures.AdminContext, mock.AnythingOfType("params.Enterprise")).Return(s.Fixtures.PoolMgrMock, s.Fixtures.ErrMock)
 
@@ -522,7 +560,7 @@ func (s *EnterpriseTestSuite) TestListEnterpriseInstancesErrUnauthorized() {
 func (s *EnterpriseTestSuite) TestFindEnterprisePoolManager() {
 	s.Fixtures.PoolMgrCtrlMock.On("GetEnterprisePoolManager", mock.AnythingOfType("params.Enterprise")).Return(s.Fixtures.PoolMgrMock, nil)
 
-	poolManager, err := s.Runner.findEnterprisePoolManager(s.Fixtures.StoreEnterprises["test-enterprise-1"].Name)
+	poolManager, err := s.Runner.findEnterprisePoolManager(s.Fixtures.StoreEnterprises["test-enterprise-1"].Endpoint, s.Fixtures.StoreEnterprises["test-enterprise-1"].Name)
 
 	s.Fixtures.PoolMgrMock.AssertExpectations(s.T())
 	s.Fixtures.PoolMgrCtrlMock.AssertExpectations(s.T())
@@ -533,7 +571,7 @@ func (s *EnterpriseTestSuite) TestFindEnterprisePoolManager() {
 func (s *EnterpriseTestSuite) TestFindEnterprisePoolManagerFetchPoolMgrFailed() {
 	s.Fixtures.PoolMgrCtrlMock.On("GetEnterprisePoolManager", mock.AnythingOfType("params.Enterprise")).Return(s.Fixtures.PoolMgrMock, s.Fixtures.ErrMock)
 
-	_, err := s.Runner.findEnterprisePoolManager(s.Fixtures.StoreEnterprises["test-enterprise-1"].Name)
+	_, err := s.Runner.findEnterprisePoolManager(s.Fixtures.StoreEnterprises["test-enterprise-1"].Endpoint, s.Fixtures.StoreEnterprises["test-enterprise-1"].Name)
 
 	s.Fixtures.PoolMgrMock.AssertExpectations(s.T())
 	s.Fixtures.PoolMgrCtrlMock.AssertExpectations(s.T())
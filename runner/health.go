@@ -0,0 +1,67 @@
+// Copyright 2023 Cloudbase Solutions SRL
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package runner
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/cloudbase/garm/runner/common"
+)
+
+// providerHealthLoop periodically checks the health of all providers. Pool managers
+// stop scheduling runners onto providers that failed their last health check.
+func (r *Runner) providerHealthLoop() {
+	ticker := time.NewTicker(providerHealthCheckInterval)
+	defer ticker.Stop()
+
+	r.checkProviderHealth()
+	for {
+		select {
+		case <-ticker.C:
+			r.checkProviderHealth()
+		case <-r.ctx.Done():
+			return
+		}
+	}
+}
+
+// checkProviderHealth runs the health check of all providers. Only changes in the health
+// of a provider are logged, to avoid filling the logs while a provider is down.
+func (r *Runner) checkProviderHealth() {
+	var wg sync.WaitGroup
+	for name, provider := range r.providers {
+		wg.Add(1)
+		go func(name string, provider common.Provider) {
+			defer wg.Done()
+
+			wasDegraded := provider.AsParams().Health.IsDegraded()
+			ctx, cancel := context.WithTimeout(r.ctx, providerHealthCheckTimeout)
+			defer cancel()
+
+			err := provider.Health(ctx)
+			isDegraded := provider.AsParams().Health.IsDegraded()
+			switch {
+			case isDegraded && !wasDegraded:
+				log.Printf("provider %s is degraded: %s", name, err)
+			case !isDegraded && wasDegraded:
+				log.Printf("provider %s recovered", name)
+			}
+		}(name, provider)
+	}
+	wg.Wait()
+}
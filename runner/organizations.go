@@ -24,6 +24,7 @@ import (
 	runnerErrors "github.com/cloudbase/garm/errors"
 	"github.com/cloudbase/garm/params"
 	"github.com/cloudbase/garm/runner/common"
+	"github.com/cloudbase/garm/util"
 	"github.com/cloudbase/garm/util/appdefaults"
 
 	"github.com/pkg/errors"
@@ -38,12 +39,16 @@ func (r *Runner) CreateOrganization(ctx context.Context, param params.CreateOrgP
 		return params.Organization{}, errors.Wrap(err, "validating params")
 	}
 
-	creds, ok := r.credentials[param.CredentialsName]
-	if !ok {
-		return params.Organization{}, runnerErrors.NewBadRequestError("credentials %s not defined", param.CredentialsName)
+	creds, err := r.getGithubCredentials(ctx, param.CredentialsName)
+	if err != nil {
+		if errors.Is(err, runnerErrors.ErrNotFound) {
+			return params.Organization{}, runnerErrors.NewBadRequestError("credentials %s not defined", param.CredentialsName)
+		}
+		return params.Organization{}, errors.Wrap(err, "fetching credentials")
 	}
 
-	_, err = r.store.GetOrganization(ctx, param.Name)
+	endpoint := util.GithubEndpoint(creds.BaseURL)
+	_, err = r.store.GetOrganization(ctx, endpoint, param.Name)
 	if err != nil {
 		if !errors.Is(err, runnerErrors.ErrNotFound) {
 			return params.Organization{}, errors.Wrap(err, "fetching org")
@@ -52,18 +57,55 @@ func (r *Runner) CreateOrganization(ctx context.Context, param params.CreateOrgP
 		return params.Organization{}, runnerErrors.NewConflictError("organization %s already exists", param.Name)
 	}
 
-	org, err = r.store.CreateOrganization(ctx, param.Name, creds.Name, param.WebhookSecret)
+	if param.ManageWebhook && r.config.Default.WebhookURL == "" {
+		return params.Organization{}, runnerErrors.NewBadRequestError("webhook_url must be configured in order to manage webhooks")
+	}
+
+	// The secret may only be missing if garm manages the webhook or polls for jobs.
+	// Generate one, to be used by the managed webhook or by one set up later on.
+	if param.WebhookSecret == "" {
+		param.WebhookSecret, err = util.GetRandomString(webhookSecretLength)
+		if err != nil {
+			return params.Organization{}, errors.Wrap(err, "generating webhook secret")
+		}
+	}
+
+	org, err = r.store.CreateOrganization(ctx, endpoint, param.Name, creds.Name, param.WebhookSecret)
 	if err != nil {
 		return params.Organization{}, errors.Wrap(err, "creating organization")
 	}
 
-	defer func() {
+	// The ID is passed as an argument, as the named return value is overwritten
+	// by the time the deferred function runs.
+	defer func(id string) {
 		if err != nil {
-			if deleteErr := r.store.DeleteOrganization(ctx, org.ID); deleteErr != nil {
+			if deleteErr := r.store.DeleteOrganization(ctx, id); deleteErr != nil {
 				log.Printf("failed to delete org: %s", deleteErr)
 			}
 		}
-	}()
+	}(org.ID)
+
+	if param.PollingEnabled || param.PollingInterval != 0 {
+		pollingParams := params.UpdateEntityParams{
+			PollingEnabled:  &param.PollingEnabled,
+			PollingInterval: &param.PollingInterval,
+		}
+		org, err = r.store.UpdateOrganization(ctx, org.ID, pollingParams)
+		if err != nil {
+			return params.Organization{}, errors.Wrap(err, "setting polling options")
+		}
+	}
+
+	if param.PoolSelectionStrategy != "" || param.JobSchedulingStrategy != "" {
+		strategyParams := params.UpdateEntityParams{
+			PoolSelectionStrategy: param.PoolSelectionStrategy,
+			JobSchedulingStrategy: param.JobSchedulingStrategy,
+		}
+		org, err = r.store.UpdateOrganization(ctx, org.ID, strategyParams)
+		if err != nil {
+			return params.Organization{}, errors.Wrap(err, "setting scheduling strategies")
+		}
+	}
 
 	poolMgr, err := r.poolManagerCtrl.CreateOrgPoolManager(r.ctx, org, r.providers, r.store)
 	if err != nil {
@@ -75,6 +117,16 @@ func (r *Runner) CreateOrganization(ctx context.Context, param params.CreateOrgP
 		}
 		return params.Organization{}, errors.Wrap(err, "starting org pool manager")
 	}
+
+	if param.ManageWebhook {
+		if _, err := poolMgr.InstallWebhook(params.InstallWebhookParams{}); err != nil {
+			if deleteErr := r.poolManagerCtrl.DeleteOrgPoolManager(org); deleteErr != nil {
+				log.Printf("failed to cleanup pool manager for org %s", org.ID)
+			}
+			return params.Organization{}, errors.Wrap(err, "installing webhook")
+		}
+	}
+	org.WebhookURL = util.EntityWebhookURL(r.config.Default.WebhookURL, org.ID)
 	return org, nil
 }
 
@@ -99,6 +151,7 @@ func (r *Runner) ListOrganizations(ctx context.Context) ([]params.Organization,
 			org.PoolManagerStatus = poolMgr.Status()
 		}
 
+		org.WebhookURL = util.EntityWebhookURL(r.config.Default.WebhookURL, org.ID)
 		allOrgs = append(allOrgs, org)
 	}
 
@@ -121,6 +174,7 @@ func (r *Runner) GetOrganizationByID(ctx context.Context, orgID string) (params.
 		org.PoolManagerStatus.FailureReason = fmt.Sprintf("failed to get pool manager: %q", err)
 	}
 	org.PoolManagerStatus = poolMgr.Status()
+	org.WebhookURL = util.EntityWebhookURL(r.config.Default.WebhookURL, org.ID)
 	return org, nil
 }
 
@@ -148,6 +202,18 @@ func (r *Runner) DeleteOrganization(ctx context.Context, orgID string) error {
 		return runnerErrors.NewBadRequestError("org has pools defined (%s)", strings.Join(poolIds, ", "))
 	}
 
+	if r.config.Default.WebhookURL != "" {
+		poolMgr, err := r.poolManagerCtrl.GetOrgPoolManager(org)
+		if err != nil {
+			return errors.Wrap(err, "fetching org pool manager")
+		}
+		// Remove the webhook garm installed, if any. Failing to remove the webhook
+		// should not prevent the organization from being deleted.
+		if err := poolMgr.UninstallWebhook(); err != nil && !errors.Is(err, runnerErrors.ErrNotFound) {
+			log.Printf("failed to uninstall webhook for org %s: %s", org.Name, err)
+		}
+	}
+
 	if err := r.poolManagerCtrl.DeleteOrgPoolManager(org); err != nil {
 		return errors.Wrap(err, "deleting org pool manager")
 	}
@@ -163,6 +229,10 @@ func (r *Runner) UpdateOrganization(ctx context.Context, orgID string, param par
 		return params.Organization{}, runnerErrors.ErrUnauthorized
 	}
 
+	if err := param.Validate(); err != nil {
+		return params.Organization{}, errors.Wrap(err, "validating params")
+	}
+
 	r.mux.Lock()
 	defer r.mux.Unlock()
 
@@ -171,10 +241,27 @@ func (r *Runner) UpdateOrganization(ctx context.Context, orgID string, param par
 		return params.Organization{}, errors.Wrap(err, "fetching org")
 	}
 
+	var endpoint string
 	if param.CredentialsName != "" {
 		// Check that credentials are set before saving to db
-		if _, ok := r.credentials[param.CredentialsName]; !ok {
-			return params.Organization{}, runnerErrors.NewBadRequestError("invalid credentials (%s) for org %s", param.CredentialsName, org.Name)
+		creds, err := r.getGithubCredentials(ctx, param.CredentialsName)
+		if err != nil {
+			if errors.Is(err, runnerErrors.ErrNotFound) {
+				return params.Organization{}, runnerErrors.NewBadRequestError("invalid credentials (%s) for org %s", param.CredentialsName, org.Name)
+			}
+			return params.Organization{}, errors.Wrap(err, "fetching credentials")
+		}
+		// The endpoint is part of the identity of the org. It can't be moved to another
+		// github server by switching credentials.
+		endpoint = util.GithubEndpoint(creds.BaseURL)
+		if org.Endpoint != "" && org.Endpoint != endpoint {
+			return params.Organization{}, runnerErrors.NewBadRequestError("credentials %s are for %s, but org %s is on %s", param.CredentialsName, endpoint, org.Name, org.Endpoint)
+		}
+	}
+
+	if org.Endpoint == "" && endpoint != "" {
+		if err := r.store.UpdateEntityEndpoint(ctx, params.OrganizationPool, orgID, endpoint); err != nil {
+			return params.Organization{}, errors.Wrap(err, "updating org endpoint")
 		}
 	}
 
@@ -188,7 +275,13 @@ func (r *Runner) UpdateOrganization(ctx context.Context, orgID string, param par
 		return params.Organization{}, fmt.Errorf("updating org pool manager: %w", err)
 	}
 
+	if param.WebhookSecret != "" {
+		// Make sure the webhook garm manages uses the new secret.
+		r.refreshManagedWebhook(poolMgr)
+	}
+
 	org.PoolManagerStatus = poolMgr.Status()
+	org.WebhookURL = util.EntityWebhookURL(r.config.Default.WebhookURL, org.ID)
 	return org, nil
 }
 
@@ -209,7 +302,7 @@ func (r *Runner) CreateOrgPool(ctx context.Context, orgID string, param params.C
 		return params.Pool{}, runnerErrors.ErrNotFound
 	}
 
-	createPoolParams, err := r.appendTagsToCreatePoolParams(param)
+	createPoolParams, err := r.appendTagsToCreatePoolParams(ctx, param)
 	if err != nil {
 		return params.Pool{}, errors.Wrap(err, "fetching pool params")
 	}
@@ -293,6 +386,7 @@ func (r *Runner) UpdateOrgPool(ctx context.Context, orgID, poolID string, param
 
 	maxRunners := pool.MaxRunners
 	minIdleRunners := pool.MinIdleRunners
+	warmRunners := pool.WarmRunners
 
 	if param.MaxRunners != nil {
 		maxRunners = *param.MaxRunners
@@ -300,11 +394,32 @@ func (r *Runner) UpdateOrgPool(ctx context.Context, orgID, poolID string, param
 	if param.MinIdleRunners != nil {
 		minIdleRunners = *param.MinIdleRunners
 	}
+	if param.WarmRunners != nil {
+		warmRunners = *param.WarmRunners
+	}
 
 	if minIdleRunners > maxRunners {
 		return params.Pool{}, runnerErrors.NewBadRequestError("min_idle_runners cannot be larger than max_runners")
 	}
 
+	if minIdleRunners+warmRunners > maxRunners {
+		return params.Pool{}, runnerErrors.NewBadRequestError("min_idle_runners plus warm_runners cannot be larger than max_runners")
+	}
+
+	if err := validateScalingSchedules(poolWithUpdate(pool, param)); err != nil {
+		return params.Pool{}, err
+	}
+
+	if param.FallbackProviders != nil {
+		if err := r.validateFallbackProviders(pool.ProviderName, param.FallbackProviders); err != nil {
+			return params.Pool{}, err
+		}
+	}
+
+	if err := r.validateProviderCapabilities(ctx, poolWithUpdate(pool, param), imagesChanged(param)); err != nil {
+		return params.Pool{}, err
+	}
+
 	newPool, err := r.store.UpdateOrganizationPool(ctx, orgID, poolID, param)
 	if err != nil {
 		return params.Pool{}, errors.Wrap(err, "updating pool")
@@ -324,11 +439,11 @@ func (r *Runner) ListOrgInstances(ctx context.Context, orgID string) ([]params.I
 	return instances, nil
 }
 
-func (r *Runner) findOrgPoolManager(name string) (common.PoolManager, error) {
+func (r *Runner) findOrgPoolManager(endpoint, name string) (common.PoolManager, error) {
 	r.mux.Lock()
 	defer r.mux.Unlock()
 
-	org, err := r.store.GetOrganization(r.ctx, name)
+	org, err := r.store.GetOrganization(r.ctx, endpoint, name)
 	if err != nil {
 		return nil, errors.Wrap(err, "fetching org")
 	}
@@ -339,3 +454,80 @@ func (r *Runner) findOrgPoolManager(name string) (common.PoolManager, error) {
 	}
 	return poolManager, nil
 }
+
+func (r *Runner) InstallOrgWebhook(ctx context.Context, orgID string, param params.InstallWebhookParams) (params.HookInfo, error) {
+	if !auth.IsAdmin(ctx) {
+		return params.HookInfo{}, runnerErrors.ErrUnauthorized
+	}
+
+	if r.config.Default.WebhookURL == "" {
+		return params.HookInfo{}, runnerErrors.NewBadRequestError("webhook_url must be configured in order to manage webhooks")
+	}
+
+	org, err := r.store.GetOrganizationByID(ctx, orgID)
+	if err != nil {
+		return params.HookInfo{}, errors.Wrap(err, "fetching org")
+	}
+
+	poolMgr, err := r.poolManagerCtrl.GetOrgPoolManager(org)
+	if err != nil {
+		return params.HookInfo{}, errors.Wrap(err, "fetching org pool manager")
+	}
+
+	info, err := poolMgr.InstallWebhook(param)
+	if err != nil {
+		return params.HookInfo{}, errors.Wrap(err, "installing webhook")
+	}
+	return info, nil
+}
+
+func (r *Runner) GetOrgWebhookInfo(ctx context.Context, orgID string) (params.HookInfo, error) {
+	if !auth.IsAdmin(ctx) {
+		return params.HookInfo{}, runnerErrors.ErrUnauthorized
+	}
+
+	if r.config.Default.WebhookURL == "" {
+		return params.HookInfo{}, runnerErrors.NewBadRequestError("webhook_url must be configured in order to manage webhooks")
+	}
+
+	org, err := r.store.GetOrganizationByID(ctx, orgID)
+	if err != nil {
+		return params.HookInfo{}, errors.Wrap(err, "fetching org")
+	}
+
+	poolMgr, err := r.poolManagerCtrl.GetOrgPoolManager(org)
+	if err != nil {
+		return params.HookInfo{}, errors.Wrap(err, "fetching org pool manager")
+	}
+
+	info, err := poolMgr.GetWebhookInfo()
+	if err != nil {
+		return params.HookInfo{}, errors.Wrap(err, "fetching webhook info")
+	}
+	return info, nil
+}
+
+func (r *Runner) UninstallOrgWebhook(ctx context.Context, orgID string) error {
+	if !auth.IsAdmin(ctx) {
+		return runnerErrors.ErrUnauthorized
+	}
+
+	if r.config.Default.WebhookURL == "" {
+		return runnerErrors.NewBadRequestError("webhook_url must be configured in order to manage webhooks")
+	}
+
+	org, err := r.store.GetOrganizationByID(ctx, orgID)
+	if err != nil {
+		return errors.Wrap(err, "fetching org")
+	}
+
+	poolMgr, err := r.poolManagerCtrl.GetOrgPoolManager(org)
+	if err != nil {
+		return errors.Wrap(err, "fetching org pool manager")
+	}
+
+	if err := poolMgr.UninstallWebhook(); err != nil {
+		return errors.Wrap(err, "uninstalling webhook")
+	}
+	return nil
+}
@@ -75,6 +75,7 @@ func (s *OrgTestSuite) SetupTest() {
 		name := fmt.Sprintf("test-org-%v", i)
 		org, err := db.CreateOrganization(
 			adminCtx,
+			"https://github.com",
 			name,
 			fmt.Sprintf("test-creds-%v", i),
 			fmt.Sprintf("test-webhook-secret-%v", i),
@@ -89,6 +90,10 @@ func (s *OrgTestSuite) SetupTest() {
 	var maxRunners uint = 40
 	var minIdleRunners uint = 20
 	providerMock := runnerCommonMocks.NewProvider(s.T())
+	providerMock.On("AsParams").Return(params.Provider{
+		Name:         "test-provider",
+		Capabilities: params.ProviderCapabilities{StartStop: true},
+	}).Maybe()
 	fixtures := &OrgTestFixtures{
 		AdminContext: adminCtx,
 		DBFile:       dbCfg.SQLite.DBFile,
@@ -221,6 +226,45 @@ func (s *OrgTestSuite) TestCreateOrganizationStartPoolMgrFailed() {
 	s.Require().Equal(fmt.Sprintf("starting org pool manager: %s", s.Fixtures.ErrMock.Error()), err.Error())
 }
 
+func (s *OrgTestSuite) TestCreateOrganizationManageWebhook() {
+	s.Runner.config.Default.WebhookURL = "https://garm.example.com/webhooks"
+	s.Fixtures.CreateOrgParams.WebhookSecret = ""
+	s.Fixtures.CreateOrgParams.ManageWebhook = true
+	s.Fixtures.PoolMgrMock.On("Start").Return(nil)
+	s.Fixtures.PoolMgrMock.On("InstallWebhook", params.InstallWebhookParams{}).Return(params.HookInfo{}, nil)
+	s.Fixtures.PoolMgrCtrlMock.On("CreateOrgPoolManager", s.Fixtures.AdminContext, mock.AnythingOfType("params.Organization"), s.Fixtures.Providers, s.Fixtures.Store).Return(s.Fixtures.PoolMgrMock, nil)
+
+	org, err := s.Runner.CreateOrganization(s.Fixtures.AdminContext, s.Fixtures.CreateOrgParams)
+
+	s.Fixtures.PoolMgrMock.AssertExpectations(s.T())
+	s.Fixtures.PoolMgrCtrlMock.AssertExpectations(s.T())
+	s.Require().Nil(err)
+	s.Require().Len(org.WebhookSecret, webhookSecretLength)
+}
+
+func (s *OrgTestSuite) TestCreateOrganizationManageWebhookMissingWebhookURL() {
+	s.Fixtures.CreateOrgParams.ManageWebhook = true
+
+	_, err := s.Runner.CreateOrganization(s.Fixtures.AdminContext, s.Fixtures.CreateOrgParams)
+
+	s.Require().Equal(runnerErrors.NewBadRequestError("webhook_url must be configured in order to manage webhooks"), err)
+}
+
+func (s *OrgTestSuite) TestCreateOrganizationPollingEnabled() {
+	s.Fixtures.CreateOrgParams.WebhookSecret = ""
+	s.Fixtures.CreateOrgParams.PollingEnabled = true
+	s.Fixtures.PoolMgrMock.On("Start").Return(nil)
+	s.Fixtures.PoolMgrCtrlMock.On("CreateOrgPoolManager", s.Fixtures.AdminContext, mock.AnythingOfType("params.Organization"), s.Fixtures.Providers, s.Fixtures.Store).Return(s.Fixtures.PoolMgrMock, nil)
+
+	org, err := s.Runner.CreateOrganization(s.Fixtures.AdminContext, s.Fixtures.CreateOrgParams)
+
+	s.Fixtures.PoolMgrMock.AssertExpectations(s.T())
+	s.Fixtures.PoolMgrCtrlMock.AssertExpectations(s.T())
+	s.Require().Nil(err)
+	s.Require().True(org.PollingEnabled)
+	s.Require().NotEmpty(org.WebhookSecret)
+}
+
 func (s *OrgTestSuite) TestListOrganizations() {
 	s.Fixtures.PoolMgrCtrlMock.On("GetOrgPoolManager", mock.AnythingOfType("params.Organization")).Return(s.Fixtures.PoolMgrMock, nil)
 	s.Fixtures.PoolMgrMock.On("Status").Return(params.PoolManagerStatus{IsRunning: true}, nil)
@@ -316,6 +360,28 @@ func (s *OrgTestSuite) TestUpdateOrganizationInvalidCreds() {
 	s.Require().Equal(runnerErrors.NewBadRequestError("invalid credentials (%s) for org %s", s.Fixtures.UpdateRepoParams.CredentialsName, s.Fixtures.StoreOrgs["test-org-1"].Name), err)
 }
 
+func (s *OrgTestSuite) TestUpdateOrganizationPolling() {
+	pollingEnabled := true
+	s.Fixtures.UpdateRepoParams.PollingEnabled = &pollingEnabled
+	s.Fixtures.PoolMgrCtrlMock.On("UpdateOrgPoolManager", s.Fixtures.AdminContext, mock.AnythingOfType("params.Organization")).Return(s.Fixtures.PoolMgrMock, nil)
+	s.Fixtures.PoolMgrMock.On("Status").Return(params.PoolManagerStatus{IsRunning: true}, nil)
+
+	org, err := s.Runner.UpdateOrganization(s.Fixtures.AdminContext, s.Fixtures.StoreOrgs["test-org-1"].ID, s.Fixtures.UpdateRepoParams)
+
+	s.Fixtures.PoolMgrCtrlMock.AssertExpectations(s.T())
+	s.Require().Nil(err)
+	s.Require().True(org.PollingEnabled)
+}
+
+func (s *OrgTestSuite) TestUpdateOrganizationPollingIntervalTooLow() {
+	pollingInterval := uint(1)
+	s.Fixtures.UpdateRepoParams.PollingInterval = &pollingInterval
+
+	_, err := s.Runner.UpdateOrganization(s.Fixtures.AdminContext, s.Fixtures.StoreOrgs["test-org-1"].ID, s.Fixtures.UpdateRepoParams)
+
+	s.Require().Equal("validating params: polling interval must be at least 10 seconds", err.Error())
+}
+
 func (s *OrgTestSuite) TestUpdateOrganizationPoolMgrFailed() {
 	s.Fixtures.PoolMgrCtrlMock.On("UpdateOrgPoolManager", s.Fixtures.AdminContext, mock.AnythingOfType("params.Organization")).Return(s.Fixtures.PoolMgrMock, s.Fixtures.ErrMock)
 
@@ -522,7 +588,7 @@ func (s *OrgTestSuite) TestListOrgInstancesErrUnauthorized() {
 func (s *OrgTestSuite) TestFindOrgPoolManager() {
 	s.Fixtures.PoolMgrCtrlMock.On("GetOrgPoolManager", mock.AnythingOfType("params.Organization")).Return(s.Fixtures.PoolMgrMock, nil)
 
-	poolManager, err := s.Runner.findOrgPoolManager(s.Fixtures.StoreOrgs["test-org-1"].Name)
+	poolManager, err := s.Runner.findOrgPoolManager(s.Fixtures.StoreOrgs["test-org-1"].Endpoint, s.Fixtures.StoreOrgs["test-org-1"].Name)
 
 	s.Fixtures.PoolMgrMock.AssertExpectations(s.T())
 	s.Fixtures.PoolMgrCtrlMock.AssertExpectations(s.T())
@@ -533,13 +599,62 @@ func (s *OrgTestSuite) TestFindOrgPoolManager() {
 func (s *OrgTestSuite) TestFindOrgPoolManagerFetchPoolMgrFailed() {
 	s.Fixtures.PoolMgrCtrlMock.On("GetOrgPoolManager", mock.AnythingOfType("params.Organization")).Return(s.Fixtures.PoolMgrMock, s.Fixtures.ErrMock)
 
-	_, err := s.Runner.findOrgPoolManager(s.Fixtures.StoreOrgs["test-org-1"].Name)
+	_, err := s.Runner.findOrgPoolManager(s.Fixtures.StoreOrgs["test-org-1"].Endpoint, s.Fixtures.StoreOrgs["test-org-1"].Name)
 
 	s.Fixtures.PoolMgrMock.AssertExpectations(s.T())
 	s.Fixtures.PoolMgrCtrlMock.AssertExpectations(s.T())
 	s.Require().Regexp("fetching pool manager for org", err.Error())
 }
 
+func (s *OrgTestSuite) TestInstallOrgWebhook() {
+	s.Runner.config.Default.WebhookURL = "https://garm.example.com/webhooks"
+	hookInfo := params.HookInfo{
+		ID:     1,
+		URL:    s.Runner.config.Default.WebhookURL,
+		Events: []string{"workflow_job"},
+		Active: true,
+	}
+	s.Fixtures.PoolMgrMock.On("InstallWebhook", params.InstallWebhookParams{}).Return(hookInfo, nil)
+	s.Fixtures.PoolMgrCtrlMock.On("GetOrgPoolManager", mock.AnythingOfType("params.Organization")).Return(s.Fixtures.PoolMgrMock, nil)
+
+	info, err := s.Runner.InstallOrgWebhook(s.Fixtures.AdminContext, s.Fixtures.StoreOrgs["test-org-1"].ID, params.InstallWebhookParams{})
+
+	s.Fixtures.PoolMgrMock.AssertExpectations(s.T())
+	s.Fixtures.PoolMgrCtrlMock.AssertExpectations(s.T())
+	s.Require().Nil(err)
+	s.Require().Equal(hookInfo, info)
+}
+
+func (s *OrgTestSuite) TestGetOrgWebhookInfoNotFound() {
+	s.Runner.config.Default.WebhookURL = "https://garm.example.com/webhooks"
+	s.Fixtures.PoolMgrMock.On("GetWebhookInfo").Return(params.HookInfo{}, runnerErrors.ErrNotFound)
+	s.Fixtures.PoolMgrCtrlMock.On("GetOrgPoolManager", mock.AnythingOfType("params.Organization")).Return(s.Fixtures.PoolMgrMock, nil)
+
+	_, err := s.Runner.GetOrgWebhookInfo(s.Fixtures.AdminContext, s.Fixtures.StoreOrgs["test-org-1"].ID)
+
+	s.Fixtures.PoolMgrMock.AssertExpectations(s.T())
+	s.Fixtures.PoolMgrCtrlMock.AssertExpectations(s.T())
+	s.Require().ErrorIs(err, runnerErrors.ErrNotFound)
+}
+
+func (s *OrgTestSuite) TestUninstallOrgWebhook() {
+	s.Runner.config.Default.WebhookURL = "https://garm.example.com/webhooks"
+	s.Fixtures.PoolMgrMock.On("UninstallWebhook").Return(nil)
+	s.Fixtures.PoolMgrCtrlMock.On("GetOrgPoolManager", mock.AnythingOfType("params.Organization")).Return(s.Fixtures.PoolMgrMock, nil)
+
+	err := s.Runner.UninstallOrgWebhook(s.Fixtures.AdminContext, s.Fixtures.StoreOrgs["test-org-1"].ID)
+
+	s.Fixtures.PoolMgrMock.AssertExpectations(s.T())
+	s.Fixtures.PoolMgrCtrlMock.AssertExpectations(s.T())
+	s.Require().Nil(err)
+}
+
+func (s *OrgTestSuite) TestUninstallOrgWebhookErrUnauthorized() {
+	err := s.Runner.UninstallOrgWebhook(context.Background(), "dummy-org-id")
+
+	s.Require().Equal(runnerErrors.ErrUnauthorized, err)
+}
+
 func TestOrgTestSuite(t *testing.T) {
 	t.Parallel()
 	suite.Run(t, new(OrgTestSuite))
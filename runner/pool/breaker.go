@@ -0,0 +1,54 @@
+// Copyright 2023 Cloudbase Solutions SRL
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package pool
+
+import (
+	"time"
+
+	"github.com/cloudbase/garm/params"
+)
+
+// isCircuitBreakerOpen returns true if the circuit breaker of the pool stops it from
+// creating runners, after repeated provisioning failures.
+func (r *basePoolManager) isCircuitBreakerOpen(pool params.Pool) bool {
+	return pool.CircuitBreaker.IsOpen(time.Now().UTC())
+}
+
+// recordProvisioningFailure counts a runner that failed to be created by the provider,
+// or that failed to come online. The circuit breaker of the pool opens once enough
+// consecutive failures are counted.
+func (r *basePoolManager) recordProvisioningFailure(poolID string, reason string) {
+	r.breakerMux.Lock()
+	defer r.breakerMux.Unlock()
+
+	pool, err := r.store.GetPoolByID(r.ctx, poolID)
+	if err != nil {
+		r.log("failed to fetch pool %s: %s", poolID, err)
+		return
+	}
+
+	now := time.Now().UTC()
+	wasOpen := pool.CircuitBreaker.IsOpen(now)
+	breaker := pool.CircuitBreakerAfterFailure(reason, now)
+	if _, err := r.store.SetPoolCircuitBreaker(r.ctx, poolID, breaker); err != nil {
+		r.log("failed to update circuit breaker of pool %s: %s", poolID, err)
+		return
+	}
+
+	if !wasOpen && breaker.IsOpen(now) {
+		r.log("circuit breaker of pool %s is open until %s after %d consecutive failures: %s",
+			poolID, breaker.OpenUntil.Format(time.RFC3339), breaker.ConsecutiveFailures, reason)
+	}
+}
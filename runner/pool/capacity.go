@@ -0,0 +1,56 @@
+// Copyright 2023 Cloudbase Solutions SRL
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package pool
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/cloudbase/garm/params"
+)
+
+// capacityMux serializes the creation of runners that count towards a limit shared by
+// several pool managers, like the capacity of a provider or the quota of credentials.
+var capacityMux sync.Mutex
+
+// providersLimited returns true if any of the given providers has capacity limits.
+func (r *basePoolManager) providersLimited(candidates []params.PoolProvider) bool {
+	for _, candidate := range candidates {
+		provider, ok := r.providers[candidate.ProviderName]
+		if ok && provider.AsParams().Limits.IsSet() {
+			return true
+		}
+	}
+	return false
+}
+
+// checkProviderCapacity returns an error if one more runner of the given flavor would go over
+// the capacity limits of the provider. The limits apply to all pools using the provider, regardless
+// of the pool manager they belong to. The caller must hold capacityMux.
+func (r *basePoolManager) checkProviderCapacity(candidate params.PoolProvider, limits params.ProviderLimits) error {
+	if !limits.IsSet() {
+		return nil
+	}
+
+	counts, err := r.store.ProviderInstanceCountByFlavor(r.ctx, candidate.ProviderName)
+	if err != nil {
+		return fmt.Errorf("failed to fetch instance count of provider %s: %w", candidate.ProviderName, err)
+	}
+
+	if err := limits.Fits(limits.Usage(counts), candidate.Flavor); err != nil {
+		return fmt.Errorf("provider %s is at capacity: %w", candidate.ProviderName, err)
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+// Copyright 2023 Cloudbase Solutions SRL
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package pool
+
+import (
+	"time"
+
+	"github.com/cloudbase/garm/params"
+	"github.com/cloudbase/garm/runner/common"
+
+	"github.com/pkg/errors"
+)
+
+// recordPoolDemand counts the queued jobs each of our pools could run, and records
+// the sample in the demand statistics of the pool. A job that matches more than one
+// pool is counted for each of them.
+func (r *basePoolManager) recordPoolDemand() error {
+	pools, err := r.helper.ListPools()
+	if err != nil {
+		return errors.Wrap(err, "listing pools")
+	}
+
+	queued, err := r.store.ListEntityJobsByStatus(r.ctx, r.helper.PoolType(), r.helper.ID(), params.JobStatusQueued)
+	if err != nil {
+		return errors.Wrap(err, "listing queued jobs")
+	}
+
+	poolsCache := poolsForTags{}
+	queuedPerPool := map[string]uint{}
+	for _, job := range queued {
+		poolSel, ok := poolsCache.Get(job.Labels)
+		if !ok {
+			potentialPools, err := r.store.FindPoolsMatchingAllTags(r.ctx, r.helper.PoolType(), r.helper.ID(), job.Labels)
+			if err != nil {
+				r.log("error finding pools matching labels: %s", err)
+				continue
+			}
+			poolSel = poolsCache.Add(job.Labels, potentialPools)
+		}
+		for _, pool := range poolSel.pools {
+			queuedPerPool[pool.ID]++
+		}
+	}
+
+	now := time.Now().UTC()
+	for _, pool := range pools {
+		if err := r.store.RecordPoolQueuedJobs(r.ctx, pool.ID, now, queuedPerPool[pool.ID]); err != nil {
+			r.log("failed to record demand for pool %s: %s", pool.ID, err)
+		}
+	}
+
+	if err := r.store.DeletePoolDemandStatsOlderThan(r.ctx, now.Add(-common.PoolDemandStatsRetention)); err != nil {
+		r.log("failed to delete old pool demand stats: %s", err)
+	}
+	return nil
+}
+
+// recordJobStarted records the time a job spent queued, in the demand statistics
+// of the pool whose runner picked it up.
+func (r *basePoolManager) recordJobStarted(poolID string, job params.WorkflowJob) {
+	createdAt := job.WorkflowJob.CreatedAt
+	startedAt := job.WorkflowJob.StartedAt
+	if createdAt.IsZero() || startedAt.IsZero() || startedAt.Before(createdAt) {
+		return
+	}
+
+	if err := r.store.RecordPoolJobStarted(r.ctx, poolID, startedAt, startedAt.Sub(createdAt)); err != nil {
+		r.log("failed to record started job for pool %s: %s", poolID, err)
+	}
+}
+
+// autoMinIdleRunners returns the idle runner target of a pool with auto_min_idle_runners
+// enabled. This is the 90th percentile of concurrently queued jobs the pool could run,
+// during the same hour, last week. The target never goes below the min idle runners of
+// the pool, nor above its max runners.
+func (r *basePoolManager) autoMinIdleRunners(pool params.Pool, now time.Time) uint {
+	floor := pool.MinIdleRunners
+
+	from := now.Add(-common.PoolDemandLookback)
+	stats, err := r.store.ListPoolDemandStats(r.ctx, pool.ID, from, from.Add(time.Hour))
+	if err != nil {
+		r.log("failed to fetch demand stats for pool %s: %s", pool.ID, err)
+		return floor
+	}
+	if len(stats) == 0 {
+		return floor
+	}
+
+	target := stats[0].QueuedJobsPercentile(common.PoolDemandPercentile)
+	if target < floor {
+		target = floor
+	}
+	if target > pool.MaxRunners {
+		target = pool.MaxRunners
+	}
+	return target
+}
+
+// scalingTargets returns a copy of the pool with the min idle runners and max runners
+// that apply right now, taking into account scaling schedules and auto min idle runners.
+func (r *basePoolManager) scalingTargets(pool params.Pool) params.Pool {
+	now := time.Now()
+	pool = pool.WithScalingSchedule(now)
+	if pool.AutoMinIdleRunners {
+		pool.MinIdleRunners = r.autoMinIdleRunners(pool, now)
+	}
+	return pool
+}
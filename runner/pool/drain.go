@@ -0,0 +1,142 @@
+// Copyright 2023 Cloudbase Solutions SRL
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package pool
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/cloudbase/garm/params"
+	providerCommon "github.com/cloudbase/garm/runner/providers/common"
+)
+
+// providerDrainCacheTTL is the time for which the provider drains fetched from the
+// database are reused. It is shorter than the interval of the reconcile loops, so each
+// pass fetches them once, and sees the drains started since the previous pass.
+const providerDrainCacheTTL = 2 * time.Second
+
+// providerDrainCache holds the names of the providers that are being drained. They are
+// checked for every pool and instance of a reconcile pass.
+type providerDrainCache struct {
+	mux       sync.Mutex
+	draining  map[string]bool
+	fetchedAt time.Time
+}
+
+// isDraining returns true if the pool, or the provider it uses, is being drained.
+// No new runners are created in a draining pool.
+func (r *basePoolManager) isDraining(pool params.Pool) bool {
+	if pool.Draining {
+		return true
+	}
+	return r.isProviderDraining(pool.ProviderName)
+}
+
+// isProviderDraining returns true if the provider is being drained.
+func (r *basePoolManager) isProviderDraining(providerName string) bool {
+	return r.drainingProviders()[providerName]
+}
+
+// drainingProviders returns the names of the providers that are being drained. The
+// returned map must not be modified.
+func (r *basePoolManager) drainingProviders() map[string]bool {
+	r.drainCache.mux.Lock()
+	defer r.drainCache.mux.Unlock()
+
+	if r.drainCache.draining != nil && time.Since(r.drainCache.fetchedAt) < providerDrainCacheTTL {
+		return r.drainCache.draining
+	}
+
+	drains, err := r.store.ListProviderDrains(r.ctx)
+	if err != nil {
+		r.log("failed to fetch provider drains: %s", err)
+		return nil
+	}
+	ret := map[string]bool{}
+	for _, drain := range drains {
+		ret[drain.ProviderName] = true
+	}
+	r.drainCache.draining = ret
+	r.drainCache.fetchedAt = time.Now()
+	return ret
+}
+
+// isDrainable returns true if the runner can be removed when its pool or provider is
+// drained. Idle runners are drained, along with runners that failed and would otherwise
+// be retried.
+func isDrainable(inst params.Instance) bool {
+	idle := inst.RunnerStatus == providerCommon.RunnerIdle &&
+		(inst.Status == providerCommon.InstanceRunning || inst.Status == providerCommon.InstanceStopped)
+	return idle || inst.Status == providerCommon.InstanceError
+}
+
+// drainOnePool removes the idle and warm runners of a draining pool, along with runners
+// that failed and would otherwise be retried. Runners that are running a job, or that
+// are still being set up, are left alone. The latter are removed once they become idle.
+func (r *basePoolManager) drainOnePool(pool params.Pool) error {
+	existingInstances, err := r.store.ListPoolInstances(r.ctx, pool.ID)
+	if err != nil {
+		return fmt.Errorf("failed to list instances for pool %s: %w", pool.ID, err)
+	}
+
+	for _, inst := range existingInstances {
+		if !isDrainable(inst) {
+			continue
+		}
+
+		if !r.keyMux.TryLock(inst.Name) {
+			r.log("failed to acquire lock for instance %s", inst.Name)
+			continue
+		}
+		err := r.removeRunner(inst, "pool is draining")
+		r.keyMux.Unlock(inst.Name, false)
+		if err != nil {
+			return fmt.Errorf("failed to delete instance %s: %w", inst.ID, err)
+		}
+	}
+	return nil
+}
+
+// drainFallbackRunners removes the idle and failed runners of a pool that were placed on a
+// fallback provider that is being drained. The pool keeps creating runners on its other
+// providers. The instances that were not removed are returned.
+func (r *basePoolManager) drainFallbackRunners(pool params.Pool, instances []params.Instance) ([]params.Instance, error) {
+	draining := r.drainingProviders()
+	if len(draining) == 0 {
+		return instances, nil
+	}
+
+	remaining := []params.Instance{}
+	for _, inst := range instances {
+		providerName := pool.InstanceProviderName(inst)
+		if !isDrainable(inst) || !draining[providerName] {
+			remaining = append(remaining, inst)
+			continue
+		}
+
+		if !r.keyMux.TryLock(inst.Name) {
+			r.log("failed to acquire lock for instance %s", inst.Name)
+			remaining = append(remaining, inst)
+			continue
+		}
+		err := r.removeRunner(inst, fmt.Sprintf("provider %s is draining", providerName))
+		r.keyMux.Unlock(inst.Name, false)
+		if err != nil {
+			return nil, fmt.Errorf("failed to delete instance %s: %w", inst.ID, err)
+		}
+	}
+	return remaining, nil
+}
@@ -0,0 +1,89 @@
+// Copyright 2023 Cloudbase Solutions SRL
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package pool
+
+import (
+	"time"
+
+	"github.com/cloudbase/garm/params"
+	providerCommon "github.com/cloudbase/garm/runner/providers/common"
+)
+
+func (s *PoolManagerTestSuite) TestScaleDownDrainsFallbackProvider() {
+	createParams := s.Fixtures.CreatePoolParams
+	createParams.FallbackProviders = []params.PoolProvider{{ProviderName: "fallback-provider"}}
+	pool := s.createPool(createParams)
+
+	primary := s.createInstances(pool, "test-provider", 2, providerCommon.InstanceRunning, providerCommon.RunnerIdle)
+	fallbackIdle := s.createInstances(pool, "fallback-provider", 2, providerCommon.InstanceRunning, providerCommon.RunnerIdle)
+	fallbackError := s.createInstances(pool, "fallback-provider", 1, providerCommon.InstanceError, providerCommon.RunnerFailed)
+	fallbackActive := s.createInstances(pool, "fallback-provider", 1, providerCommon.InstanceRunning, providerCommon.RunnerActive)
+
+	err := s.Fixtures.Store.SetProviderDrain(s.Fixtures.AdminContext, "fallback-provider", true)
+	s.Require().Nil(err)
+	s.Require().False(s.PoolManager.isDraining(pool))
+
+	err = s.PoolManager.scaleDownOnePool(s.Fixtures.AdminContext, pool)
+	s.Require().Nil(err)
+
+	expected := map[string]providerCommon.InstanceStatus{
+		primary[0].Name:        providerCommon.InstanceRunning,
+		primary[1].Name:        providerCommon.InstanceRunning,
+		fallbackIdle[0].Name:   providerCommon.InstancePendingDelete,
+		fallbackIdle[1].Name:   providerCommon.InstancePendingDelete,
+		fallbackError[0].Name:  providerCommon.InstancePendingDelete,
+		fallbackActive[0].Name: providerCommon.InstanceRunning,
+	}
+	instances, err := s.Fixtures.Store.ListPoolInstances(s.Fixtures.AdminContext, pool.ID)
+	s.Require().Nil(err)
+	s.Require().Len(instances, len(expected))
+	for _, instance := range instances {
+		s.Require().Equal(expected[instance.Name], instance.Status, instance.Name)
+	}
+}
+
+func (s *PoolManagerTestSuite) TestScaleDownDrainsPool() {
+	pool := s.createPool(s.Fixtures.CreatePoolParams)
+
+	s.createInstances(pool, pool.ProviderName, 2, providerCommon.InstanceRunning, providerCommon.RunnerIdle)
+	s.createInstances(pool, pool.ProviderName, 1, providerCommon.InstanceStopped, providerCommon.RunnerIdle)
+	s.createInstances(pool, pool.ProviderName, 1, providerCommon.InstanceError, providerCommon.RunnerFailed)
+	s.createInstances(pool, pool.ProviderName, 1, providerCommon.InstanceRunning, providerCommon.RunnerActive)
+	s.createInstances(pool, pool.ProviderName, 1, providerCommon.InstanceCreating, providerCommon.RunnerPending)
+
+	err := s.Fixtures.Store.SetProviderDrain(s.Fixtures.AdminContext, "test-provider", true)
+	s.Require().Nil(err)
+
+	err = s.PoolManager.scaleDownOnePool(s.Fixtures.AdminContext, pool)
+	s.Require().Nil(err)
+	s.Require().Equal(map[providerCommon.InstanceStatus]int{
+		providerCommon.InstancePendingDelete: 4,
+		providerCommon.InstanceRunning:       1,
+		providerCommon.InstanceCreating:      1,
+	}, s.countInstances(pool.ID))
+}
+
+func (s *PoolManagerTestSuite) TestDrainingProvidersIsCached() {
+	pool := s.createPool(s.Fixtures.CreatePoolParams)
+	s.Require().False(s.PoolManager.isDraining(pool))
+
+	// Drains started within the same reconcile pass are seen on the next one.
+	err := s.Fixtures.Store.SetProviderDrain(s.Fixtures.AdminContext, "test-provider", true)
+	s.Require().Nil(err)
+	s.Require().False(s.PoolManager.isDraining(pool))
+
+	s.PoolManager.drainCache.fetchedAt = time.Now().Add(-providerDrainCacheTTL)
+	s.Require().True(s.PoolManager.isDraining(pool))
+}
@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"strings"
 	"sync"
+	"time"
 
 	dbCommon "github.com/cloudbase/garm/database/common"
 	runnerErrors "github.com/cloudbase/garm/errors"
@@ -99,6 +100,8 @@ func (r *enterprise) UpdateState(param params.UpdatePoolStateParams) error {
 	defer r.mux.Unlock()
 
 	r.cfg.WebhookSecret = param.WebhookSecret
+	r.cfg.PoolSelectionStrategy = param.PoolSelectionStrategy
+	r.cfg.JobSchedulingStrategy = param.JobSchedulingStrategy
 	if param.InternalConfig != nil {
 		r.cfgInternal = *param.InternalConfig
 	}
@@ -189,6 +192,12 @@ func (r *enterprise) GetGithubRegistrationToken() (string, error) {
 	return *tk.Token, nil
 }
 
+func (r *enterprise) GetJITConfig(instance params.Instance, labels []string) (string, error) {
+	// The github client we use does not yet support generating JIT configs for enterprises.
+	// Runners will fall back to using a registration token.
+	return "", runnerErrors.NewNotFoundError("JIT config is not available for enterprises")
+}
+
 func (r *enterprise) String() string {
 	return r.cfg.Name
 }
@@ -231,3 +240,69 @@ func (r *enterprise) ValidateOwner(job params.WorkflowJob) error {
 func (r *enterprise) ID() string {
 	return r.id
 }
+
+func (r *enterprise) GetPollingInterval() time.Duration {
+	// Polling is not supported for enterprises.
+	return 0
+}
+
+func (r *enterprise) PoolSelectionStrategy() params.PoolSelectionStrategy {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	return r.cfg.PoolSelectionStrategy
+}
+
+func (r *enterprise) JobSchedulingStrategy() params.JobSchedulingStrategy {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	return r.cfg.JobSchedulingStrategy
+}
+
+func (r *enterprise) ListPollTargets() ([]*github.Repository, error) {
+	return nil, runnerErrors.NewBadRequestError("polling is not supported for enterprises")
+}
+
+func (r *enterprise) GetWebhookURL() string {
+	return r.cfgInternal.WebhookURL
+}
+
+// The github client we use does not support managing enterprise webhooks. Those
+// need to be configured manually.
+
+func (r *enterprise) ListHooks() ([]*github.Hook, error) {
+	return nil, runnerErrors.NewBadRequestError("webhooks can not be managed for enterprises")
+}
+
+func (r *enterprise) CreateHook(hook *github.Hook) (*github.Hook, error) {
+	return nil, runnerErrors.NewBadRequestError("webhooks can not be managed for enterprises")
+}
+
+func (r *enterprise) EditHook(id int64, hook *github.Hook) (*github.Hook, error) {
+	return nil, runnerErrors.NewBadRequestError("webhooks can not be managed for enterprises")
+}
+
+func (r *enterprise) DeleteHook(id int64) error {
+	return runnerErrors.NewBadRequestError("webhooks can not be managed for enterprises")
+}
+
+func (r *enterprise) PingHook(id int64) error {
+	return runnerErrors.NewBadRequestError("webhooks can not be managed for enterprises")
+}
+
+func (r *enterprise) ManagedHookID() (int64, error) {
+	return 0, nil
+}
+
+func (r *enterprise) SetManagedHookID(hookID int64) error {
+	return runnerErrors.NewBadRequestError("webhooks can not be managed for enterprises")
+}
+
+func (r *enterprise) ListHookDeliveries(hookID int64, since time.Time) ([]*github.HookDelivery, error) {
+	return nil, runnerErrors.NewBadRequestError("webhooks can not be managed for enterprises")
+}
+
+func (r *enterprise) GetHookDelivery(hookID, deliveryID int64) (*github.HookDelivery, error) {
+	return nil, runnerErrors.NewBadRequestError("webhooks can not be managed for enterprises")
+}
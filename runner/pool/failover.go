@@ -0,0 +1,175 @@
+// Copyright 2023 Cloudbase Solutions SRL
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package pool
+
+import (
+	"fmt"
+
+	"github.com/cloudbase/garm/params"
+	"github.com/cloudbase/garm/runner/common"
+
+	"github.com/pkg/errors"
+)
+
+// instanceProvider returns the provider on which an instance of the pool was placed.
+func (r *basePoolManager) instanceProvider(pool params.Pool, instance params.Instance) (common.Provider, error) {
+	providerName := pool.InstanceProviderName(instance)
+	provider, ok := r.providers[providerName]
+	if !ok {
+		return nil, fmt.Errorf("unknown provider %s for pool %s", providerName, pool.ID)
+	}
+	return provider, nil
+}
+
+// isProviderDegraded returns true if the provider is marked as degraded. Runners
+// are not scheduled onto a degraded provider, and the runners already placed on it are
+// left alone until it recovers.
+func (r *basePoolManager) isProviderDegraded(providerName string) bool {
+	provider, ok := r.providers[providerName]
+	return ok && provider.AsParams().Health.IsDegraded()
+}
+
+// providerCapabilities returns the optional features supported by a provider.
+func (r *basePoolManager) providerCapabilities(providerName string) params.ProviderCapabilities {
+	provider, ok := r.providers[providerName]
+	if !ok {
+		return params.ProviderCapabilities{}
+	}
+	return provider.AsParams().Capabilities
+}
+
+// canKeepWarm returns true if an idle runner can be stopped and kept as a warm runner.
+// This needs a healthy provider, which supports stopping and starting instances.
+func (r *basePoolManager) canKeepWarm(pool params.Pool, instance params.Instance) bool {
+	providerName := pool.InstanceProviderName(instance)
+	return !r.isProviderDegraded(providerName) && r.providerCapabilities(providerName).StartStop
+}
+
+// isInstanceProviderDegraded returns true if the provider an instance was placed on is degraded.
+func (r *basePoolManager) isInstanceProviderDegraded(instance params.Instance) bool {
+	providerName := instance.ProviderName
+	if providerName == "" {
+		pool, err := r.helper.GetPoolByID(instance.PoolID)
+		if err != nil {
+			r.log("failed to fetch pool %s: %s", instance.PoolID, err)
+			return false
+		}
+		providerName = pool.ProviderName
+	}
+	return r.isProviderDegraded(providerName)
+}
+
+// providerIndex returns the position of a provider in the list of providers of a pool,
+// or -1 if the pool does not use the provider. An empty name stands for the provider
+// of the pool.
+func providerIndex(candidates []params.PoolProvider, providerName string) int {
+	if providerName == "" {
+		return 0
+	}
+	for idx, candidate := range candidates {
+		if candidate.ProviderName == providerName {
+			return idx
+		}
+	}
+	return -1
+}
+
+// selectProvider returns the position of the first provider of the pool, starting at the
+// given position, on which a new runner can be created. Fallback providers that are unknown
+// or being drained are skipped, as well as providers that are degraded or at capacity. The
+// caller must hold capacityMux if any of the providers has capacity limits.
+func (r *basePoolManager) selectProvider(pool params.Pool, candidates []params.PoolProvider, start int) (int, error) {
+	var skipErr error
+	for idx := start; idx < len(candidates); idx++ {
+		candidate := candidates[idx]
+		provider, ok := r.providers[candidate.ProviderName]
+		if !ok {
+			if idx == 0 {
+				return -1, fmt.Errorf("unknown provider %s for pool %s", candidate.ProviderName, pool.ID)
+			}
+			r.log("skipping unknown fallback provider %s of pool %s", candidate.ProviderName, pool.ID)
+			continue
+		}
+
+		if idx > 0 && r.isProviderDraining(candidate.ProviderName) {
+			continue
+		}
+
+		providerParams := provider.AsParams()
+		if providerParams.Health.IsDegraded() {
+			if skipErr == nil {
+				skipErr = fmt.Errorf("provider %s is degraded: %s", candidate.ProviderName, providerParams.Health.Error)
+			}
+			continue
+		}
+
+		if err := r.checkProviderCapacity(candidate, providerParams.Limits); err != nil {
+			if skipErr == nil {
+				skipErr = err
+			}
+			continue
+		}
+		return idx, nil
+	}
+
+	if skipErr != nil {
+		return -1, skipErr
+	}
+	return -1, fmt.Errorf("no provider left to try for pool %s", pool.ID)
+}
+
+// reserveProvider selects the next provider of the pool on which the instance can be
+// created, and records it on the instance. Recording the provider right away makes the
+// instance count towards the capacity of that provider.
+func (r *basePoolManager) reserveProvider(pool params.Pool, instance params.Instance, candidates []params.PoolProvider, start int) (int, error) {
+	if start >= len(candidates) {
+		return -1, fmt.Errorf("no provider left to try for pool %s", pool.ID)
+	}
+
+	if r.providersLimited(candidates[start:]) {
+		capacityMux.Lock()
+		defer capacityMux.Unlock()
+	}
+
+	idx, err := r.selectProvider(pool, candidates, start)
+	if err != nil {
+		return -1, err
+	}
+
+	updateParams := params.UpdateInstanceParams{
+		ProviderName: candidates[idx].ProviderName,
+	}
+	if _, err := r.store.UpdateInstance(r.ctx, instance.ID, updateParams); err != nil {
+		return -1, errors.Wrap(err, "updating instance")
+	}
+	return idx, nil
+}
+
+// movePendingInstance places an instance that was not created yet on the first provider
+// of its pool that is able to take it.
+func (r *basePoolManager) movePendingInstance(instance params.Instance) (params.Instance, error) {
+	pool, err := r.helper.GetPoolByID(instance.PoolID)
+	if err != nil {
+		return params.Instance{}, errors.Wrap(err, "fetching pool")
+	}
+
+	candidates := pool.Providers()
+	idx, err := r.reserveProvider(pool, instance, candidates, 0)
+	if err != nil {
+		return params.Instance{}, err
+	}
+	instance.ProviderName = candidates[idx].ProviderName
+	return instance, nil
+}
@@ -0,0 +1,60 @@
+// Copyright 2023 Cloudbase Solutions SRL
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package pool
+
+import (
+	"github.com/cloudbase/garm/params"
+)
+
+func (s *PoolManagerTestSuite) TestSelectProviderSkipsDegradedProvider() {
+	createParams := s.Fixtures.CreatePoolParams
+	createParams.FallbackProviders = []params.PoolProvider{{ProviderName: "fallback-provider"}}
+	pool := s.createPool(createParams)
+	s.Fixtures.ProviderHealth["test-provider"] = params.ProviderHealth{
+		Status: params.ProviderDegraded,
+		Error:  "mock error",
+	}
+
+	idx, err := s.PoolManager.selectProvider(pool, pool.Providers(), 0)
+	s.Require().Nil(err)
+	s.Require().Equal(1, idx)
+}
+
+func (s *PoolManagerTestSuite) TestSelectProviderAllDegraded() {
+	createParams := s.Fixtures.CreatePoolParams
+	createParams.FallbackProviders = []params.PoolProvider{{ProviderName: "fallback-provider"}}
+	pool := s.createPool(createParams)
+	for _, name := range []string{"test-provider", "fallback-provider"} {
+		s.Fixtures.ProviderHealth[name] = params.ProviderHealth{
+			Status: params.ProviderDegraded,
+			Error:  "mock error",
+		}
+	}
+
+	idx, err := s.PoolManager.selectProvider(pool, pool.Providers(), 0)
+	s.Require().Equal(-1, idx)
+	s.Require().EqualError(err, "provider test-provider is degraded: mock error")
+}
+
+func (s *PoolManagerTestSuite) TestSelectProviderRecovered() {
+	createParams := s.Fixtures.CreatePoolParams
+	createParams.FallbackProviders = []params.PoolProvider{{ProviderName: "fallback-provider"}}
+	pool := s.createPool(createParams)
+	s.Fixtures.ProviderHealth["test-provider"] = params.ProviderHealth{Status: params.ProviderHealthy}
+
+	idx, err := s.PoolManager.selectProvider(pool, pool.Providers(), 0)
+	s.Require().Nil(err)
+	s.Require().Equal(0, idx)
+}
@@ -0,0 +1,148 @@
+// Copyright 2023 Cloudbase Solutions SRL
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package pool
+
+import (
+	"encoding/json"
+	"time"
+
+	runnerErrors "github.com/cloudbase/garm/errors"
+	"github.com/cloudbase/garm/params"
+	providerCommon "github.com/cloudbase/garm/runner/providers/common"
+)
+
+const (
+	// quotaBackoffBase is the time we wait before trying again to create an instance
+	// that failed because its providers were out of quota. It doubles with each attempt.
+	quotaBackoffBase = 1 * time.Minute
+	// quotaBackoffMax is the longest we wait between attempts to create an instance
+	// that failed because its providers were out of quota.
+	quotaBackoffMax = 15 * time.Minute
+)
+
+// faultAction is what the pool manager does with an instance that could not be created.
+type faultAction int
+
+const (
+	// faultActionRetry creates the instance again on the next retry pass.
+	faultActionRetry faultAction = iota
+	// faultActionBackOff creates the instance again, once the quota backoff elapsed.
+	faultActionBackOff
+	// faultActionGiveUp leaves the instance in error state, without retrying it.
+	faultActionGiveUp
+)
+
+// actionForFault decides what to do with an instance, based on the class of the fault
+// that prevented its creation. Faults are classified by the providers. Note that the
+// instance is always tried on the other providers of its pool before it is marked as
+// failed.
+func actionForFault(fault runnerErrors.ProviderFault) faultAction {
+	switch {
+	case fault.QuotaExceeded:
+		return faultActionBackOff
+	case fault.Code == runnerErrors.ProviderFaultDuplicate:
+		// The instance is removed from the provider before it is created again.
+		return faultActionRetry
+	case !fault.Retryable:
+		return faultActionGiveUp
+	default:
+		return faultActionRetry
+	}
+}
+
+// faultFromError returns the fault carried by an error returned while creating an
+// instance. Errors that were not classified by the provider are retried.
+func faultFromError(err error) runnerErrors.ProviderFault {
+	if fault, ok := runnerErrors.ProviderFaultFromError(err); ok {
+		return fault
+	}
+	return runnerErrors.NewProviderFault(runnerErrors.ProviderFaultUnknown, err.Error())
+}
+
+// instanceFault decodes the fault recorded for an instance. Faults set by providers on
+// the instances they return, and faults recorded by older versions of garm, may be plain
+// text, in which case false is returned.
+func instanceFault(instance params.Instance) (runnerErrors.ProviderFault, bool) {
+	if len(instance.ProviderFault) == 0 {
+		return runnerErrors.ProviderFault{}, false
+	}
+	return runnerErrors.DecodeProviderFault(instance.ProviderFault)
+}
+
+// quotaBackoff returns the time to wait before the next attempt to create an instance
+// that failed because its providers were out of quota.
+func quotaBackoff(quotaAttempt int) time.Duration {
+	backoff := quotaBackoffBase
+	for i := 0; i < quotaAttempt && backoff < quotaBackoffMax; i++ {
+		backoff *= 2
+	}
+	if backoff > quotaBackoffMax {
+		return quotaBackoffMax
+	}
+	return backoff
+}
+
+// isQuotaFailure returns true if an instance could not be created because its providers
+// were out of quota.
+func isQuotaFailure(instance params.Instance) bool {
+	fault, ok := instanceFault(instance)
+	return ok && fault.QuotaExceeded
+}
+
+// shouldRetryFailedInstance returns true if an instance in error state should be
+// created again now. Instances that failed because their providers were out of quota are
+// retried for as long as the quota is exceeded, without using up their create attempts.
+func shouldRetryFailedInstance(instance params.Instance, now time.Time) bool {
+	if instance.CreateAttempt >= maxCreateAttempts && !isQuotaFailure(instance) {
+		return false
+	}
+
+	fault, ok := instanceFault(instance)
+	if !ok {
+		return true
+	}
+
+	switch actionForFault(fault) {
+	case faultActionGiveUp:
+		return false
+	case faultActionBackOff:
+		return !now.Before(instance.UpdatedAt.Add(quotaBackoff(instance.QuotaAttempt)))
+	default:
+		return true
+	}
+}
+
+// setInstanceFailed moves an instance that could not be created to the error state, and
+// records the fault that prevented its creation. Instances that failed in a way that
+// will not go away are marked as permanently failed, by using up their create attempts.
+func (r *basePoolManager) setInstanceFailed(instance params.Instance, err error) {
+	fault := faultFromError(err)
+	asJs, jsErr := json.Marshal(fault)
+	if jsErr != nil {
+		asJs = []byte(err.Error())
+	}
+
+	updateParams := params.UpdateInstanceParams{
+		Status:        providerCommon.InstanceError,
+		ProviderFault: asJs,
+	}
+	if actionForFault(fault) == faultActionGiveUp {
+		r.log("instance %s failed permanently (%s): %s", instance.Name, fault.Code, fault.Message)
+		updateParams.CreateAttempt = maxCreateAttempts
+	}
+	if _, err := r.updateInstance(instance.Name, updateParams); err != nil {
+		r.log("failed to update runner %s status: %s", instance.Name, err)
+	}
+}
@@ -0,0 +1,227 @@
+// Copyright 2023 Cloudbase Solutions SRL
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package pool
+
+import (
+	"fmt"
+	"time"
+
+	runnerErrors "github.com/cloudbase/garm/errors"
+	"github.com/cloudbase/garm/params"
+	providerCommon "github.com/cloudbase/garm/runner/providers/common"
+
+	"github.com/stretchr/testify/mock"
+)
+
+func (s *PoolManagerTestSuite) TestActionForFault() {
+	tests := []struct {
+		name   string
+		fault  runnerErrors.ProviderFault
+		action faultAction
+	}{
+		{
+			name:   "Unknown fault",
+			fault:  runnerErrors.NewProviderFault(runnerErrors.ProviderFaultUnknown, ""),
+			action: faultActionRetry,
+		},
+		{
+			name:   "Transient fault",
+			fault:  runnerErrors.NewProviderFault(runnerErrors.ProviderFaultTransient, ""),
+			action: faultActionRetry,
+		},
+		{
+			name:   "Quota exceeded",
+			fault:  runnerErrors.NewProviderFault(runnerErrors.ProviderFaultQuotaExceeded, ""),
+			action: faultActionBackOff,
+		},
+		{
+			name:   "Duplicate instance",
+			fault:  runnerErrors.NewProviderFault(runnerErrors.ProviderFaultDuplicate, ""),
+			action: faultActionRetry,
+		},
+		{
+			name:   "Invalid request",
+			fault:  runnerErrors.NewProviderFault(runnerErrors.ProviderFaultInvalidRequest, ""),
+			action: faultActionGiveUp,
+		},
+		{
+			name:   "Not found",
+			fault:  runnerErrors.NewProviderFault(runnerErrors.ProviderFaultNotFound, ""),
+			action: faultActionGiveUp,
+		},
+		{
+			name:   "Unknown fault the provider says is not retryable",
+			fault:  runnerErrors.ProviderFault{Code: runnerErrors.ProviderFaultUnknown},
+			action: faultActionGiveUp,
+		},
+		{
+			name:   "Quota flag wins over the code",
+			fault:  runnerErrors.ProviderFault{Code: runnerErrors.ProviderFaultUnknown, QuotaExceeded: true},
+			action: faultActionBackOff,
+		},
+	}
+
+	for _, tc := range tests {
+		s.Run(tc.name, func() {
+			s.Require().Equal(tc.action, actionForFault(tc.fault))
+		})
+	}
+}
+
+func (s *PoolManagerTestSuite) TestQuotaBackoff() {
+	tests := []struct {
+		quotaAttempt int
+		backoff      time.Duration
+	}{
+		{quotaAttempt: 0, backoff: quotaBackoffBase},
+		{quotaAttempt: 1, backoff: 2 * quotaBackoffBase},
+		{quotaAttempt: 2, backoff: 4 * quotaBackoffBase},
+		{quotaAttempt: 3, backoff: 8 * quotaBackoffBase},
+		{quotaAttempt: 4, backoff: quotaBackoffMax},
+		{quotaAttempt: 100, backoff: quotaBackoffMax},
+	}
+
+	for _, tc := range tests {
+		s.Require().Equal(tc.backoff, quotaBackoff(tc.quotaAttempt), "quota attempt %d", tc.quotaAttempt)
+	}
+}
+
+func (s *PoolManagerTestSuite) TestShouldRetryFailedInstance() {
+	updatedAt := time.Date(2023, 10, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name          string
+		providerFault string
+		createAttempt int
+		now           time.Time
+		retry         bool
+	}{
+		{
+			name:          "No fault recorded",
+			providerFault: "",
+			now:           updatedAt,
+			retry:         true,
+		},
+		{
+			name:          "Plain text fault",
+			providerFault: "failed to create instance",
+			now:           updatedAt,
+			retry:         true,
+		},
+		{
+			name:          "Fault with only a code and a message is classified by its code",
+			providerFault: `{"code":"transient","message":"timed out"}`,
+			now:           updatedAt,
+			retry:         true,
+		},
+		{
+			name:          "Invalid request",
+			providerFault: `{"code":"invalid_request","message":"no such image"}`,
+			now:           updatedAt,
+			retry:         false,
+		},
+		{
+			name:          "Quota exceeded within the backoff",
+			providerFault: `{"code":"quota_exceeded","message":"no cores left"}`,
+			now:           updatedAt.Add(2*quotaBackoffBase - time.Second),
+			retry:         false,
+		},
+		{
+			name:          "Quota exceeded after the backoff",
+			providerFault: `{"code":"quota_exceeded","message":"no cores left"}`,
+			now:           updatedAt.Add(2 * quotaBackoffBase),
+			retry:         true,
+		},
+		{
+			name:          "Create attempts used up",
+			providerFault: `{"code":"transient","message":"timed out"}`,
+			createAttempt: maxCreateAttempts,
+			now:           updatedAt,
+			retry:         false,
+		},
+		{
+			name:          "Quota exceeded after the create attempts were used up",
+			providerFault: `{"code":"quota_exceeded","message":"no cores left"}`,
+			createAttempt: maxCreateAttempts,
+			now:           updatedAt.Add(2 * quotaBackoffBase),
+			retry:         true,
+		},
+	}
+
+	for _, tc := range tests {
+		s.Run(tc.name, func() {
+			instance := params.Instance{
+				CreateAttempt: 1,
+				QuotaAttempt:  1,
+				UpdatedAt:     updatedAt,
+				ProviderFault: []byte(tc.providerFault),
+			}
+			if tc.createAttempt != 0 {
+				instance.CreateAttempt = tc.createAttempt
+			}
+			s.Require().Equal(tc.retry, shouldRetryFailedInstance(instance, tc.now))
+		})
+	}
+}
+
+func (s *PoolManagerTestSuite) TestRetryFailedInstancesCountsQuotaAttemptsSeparately() {
+	tests := []struct {
+		name                  string
+		providerFault         string
+		createAttempt         int
+		expectedCreateAttempt int
+		expectedQuotaAttempt  int
+	}{
+		{
+			name:                  "Transient fault",
+			providerFault:         `{"code":"transient","message":"timed out"}`,
+			createAttempt:         1,
+			expectedCreateAttempt: 2,
+			expectedQuotaAttempt:  0,
+		},
+		{
+			name:                  "Quota exceeded",
+			providerFault:         `{"code":"quota_exceeded","message":"no cores left"}`,
+			createAttempt:         maxCreateAttempts,
+			expectedCreateAttempt: maxCreateAttempts,
+			expectedQuotaAttempt:  1,
+		},
+	}
+
+	for idx, tc := range tests {
+		s.Run(tc.name, func() {
+			createParams := s.Fixtures.CreatePoolParams
+			createParams.Image = fmt.Sprintf("test-image-%d", idx)
+			pool := s.createPool(createParams)
+			instance := s.createInstances(pool, pool.ProviderName, 1, providerCommon.InstanceError, providerCommon.RunnerPending)[0]
+			_, err := s.Fixtures.Store.UpdateInstance(s.Fixtures.AdminContext, instance.ID, params.UpdateInstanceParams{
+				CreateAttempt: tc.createAttempt,
+				ProviderFault: []byte(tc.providerFault),
+			})
+			s.Require().Nil(err)
+			s.backdateInstances([]params.Instance{instance}, quotaBackoffMax)
+			s.Fixtures.Providers["test-provider"].On("DeleteInstance", mock.Anything, instance.Name).Return(nil).Once()
+
+			err = s.PoolManager.retryFailedInstancesForOnePool(s.Fixtures.AdminContext, pool)
+			s.Require().Nil(err)
+
+			instance, err = s.Fixtures.Store.GetInstanceByName(s.Fixtures.AdminContext, instance.Name)
+			s.Require().Nil(err)
+			s.Require().Equal(providerCommon.InstancePendingCreate, instance.Status)
+			s.Require().Equal(tc.expectedCreateAttempt, instance.CreateAttempt)
+			s.Require().Equal(tc.expectedQuotaAttempt, instance.QuotaAttempt)
+		})
+	}
+}
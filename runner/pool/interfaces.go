@@ -15,6 +15,8 @@
 package pool
 
 import (
+	"time"
+
 	"github.com/cloudbase/garm/params"
 	"github.com/cloudbase/garm/runner/common"
 
@@ -25,10 +27,34 @@ type poolHelper interface {
 	GetGithubToken() string
 	GetGithubRunners() ([]*github.Runner, error)
 	GetGithubRegistrationToken() (string, error)
+	GetJITConfig(instance params.Instance, labels []string) (string, error)
 	GetRunnerInfoFromWorkflow(job params.WorkflowJob) (params.RunnerInfo, error)
 	RemoveGithubRunner(runnerID int64) (*github.Response, error)
 	FetchTools() ([]*github.RunnerApplicationDownload, error)
 
+	GetWebhookURL() string
+	ListHooks() ([]*github.Hook, error)
+	CreateHook(hook *github.Hook) (*github.Hook, error)
+	EditHook(id int64, hook *github.Hook) (*github.Hook, error)
+	DeleteHook(id int64) error
+	PingHook(id int64) error
+	// ManagedHookID returns the ID of the webhook garm installed on the entity, or 0 if
+	// garm does not manage a webhook for it.
+	ManagedHookID() (int64, error)
+	SetManagedHookID(hookID int64) error
+	// ListHookDeliveries lists the deliveries of a webhook, newest first. Deliveries older
+	// than since may also be returned.
+	ListHookDeliveries(hookID int64, since time.Time) ([]*github.HookDelivery, error)
+	GetHookDelivery(hookID, deliveryID int64) (*github.HookDelivery, error)
+
+	GetPollingInterval() time.Duration
+	ListPollTargets() ([]*github.Repository, error)
+
+	// PoolSelectionStrategy returns the pool selection strategy of the entity.
+	PoolSelectionStrategy() params.PoolSelectionStrategy
+	// JobSchedulingStrategy returns the order in which queued jobs are handled.
+	JobSchedulingStrategy() params.JobSchedulingStrategy
+
 	GithubCLI() common.GithubClient
 
 	FetchDbInstances() ([]params.Instance, error)
@@ -0,0 +1,115 @@
+// Copyright 2023 Cloudbase Solutions SRL
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package pool
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/cloudbase/garm/params"
+	providerCommon "github.com/cloudbase/garm/runner/providers/common"
+)
+
+// recordRemovalReason records the reason a runner is removed in its events.
+func (r *basePoolManager) recordRemovalReason(inst params.Instance, reason string) {
+	r.log("removing runner %s from pool %s: %s", inst.Name, inst.PoolID, reason)
+	if err := r.store.AddInstanceEvent(r.ctx, inst.ID, params.StatusEvent, params.EventInfo, fmt.Sprintf("removing runner: %s", reason)); err != nil {
+		r.log("failed to record removal of runner %s: %s", inst.Name, err)
+	}
+}
+
+// logConsoleOutput logs the end of the console log of a runner that never came online,
+// to help find out why it failed. Only providers that support console logs are asked.
+func (r *basePoolManager) logConsoleOutput(pool params.Pool, instance params.Instance) {
+	if instance.ProviderID == "" {
+		return
+	}
+
+	provider, err := r.instanceProvider(pool, instance)
+	if err != nil || !provider.AsParams().Capabilities.ConsoleLogs {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.ctx, consoleLogTimeout)
+	defer cancel()
+	consoleLog, err := provider.GetConsoleLog(ctx, instance.ProviderID)
+	if err != nil {
+		r.log("failed to fetch console log of runner %s: %s", instance.Name, err)
+		return
+	}
+
+	if len(consoleLog) > consoleLogTail {
+		consoleLog = consoleLog[len(consoleLog)-consoleLogTail:]
+	}
+	r.log("console log of runner %s:\n%s", instance.Name, consoleLog)
+}
+
+// removeRunner records the reason a runner is removed in its events, and removes it.
+// The caller must hold the lock for the runner.
+func (r *basePoolManager) removeRunner(inst params.Instance, reason string) error {
+	r.recordRemovalReason(inst, reason)
+	return r.ForceDeleteRunner(inst)
+}
+
+// recycleExpiredRunners removes the idle runners of a pool that have lived longer than
+// the max lifetime of the pool. Runners that are running a job are left alone. New runners
+// are created in their place by ensureIdleRunnersForOnePool. Like scale down, only part of
+// the expired runners is removed on each pass, oldest first, so a pool whose runners all
+// expire together is not left without idle runners. The instances that were not recycled
+// are returned.
+func (r *basePoolManager) recycleExpiredRunners(pool params.Pool, instances []params.Instance) ([]params.Instance, error) {
+	if pool.MaxLifetime == 0 {
+		return instances, nil
+	}
+
+	now := time.Now().UTC()
+	remaining := []params.Instance{}
+	expired := []params.Instance{}
+	for _, inst := range instances {
+		idle := inst.RunnerStatus == providerCommon.RunnerIdle &&
+			(inst.Status == providerCommon.InstanceRunning || inst.Status == providerCommon.InstanceStopped)
+		if !idle || !pool.HasExpired(inst, now) {
+			remaining = append(remaining, inst)
+			continue
+		}
+		expired = append(expired, inst)
+	}
+
+	sort.SliceStable(expired, func(i, j int) bool {
+		return expired[i].CreatedAt.Before(expired[j].CreatedAt)
+	})
+	numRecycle := int(math.Ceil(float64(len(expired)) * scaleDownFactor))
+	for idx, inst := range expired {
+		if idx >= numRecycle {
+			remaining = append(remaining, inst)
+			continue
+		}
+
+		if !r.keyMux.TryLock(inst.Name) {
+			r.log("failed to acquire lock for instance %s", inst.Name)
+			remaining = append(remaining, inst)
+			continue
+		}
+		err := r.removeRunner(inst, fmt.Sprintf("max lifetime of %d minutes exceeded", pool.MaxLifetime))
+		r.keyMux.Unlock(inst.Name, false)
+		if err != nil {
+			return nil, fmt.Errorf("failed to delete instance %s: %w", inst.ID, err)
+		}
+	}
+	return remaining, nil
+}
@@ -0,0 +1,88 @@
+// Copyright 2023 Cloudbase Solutions SRL
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package pool
+
+import (
+	"time"
+
+	providerCommon "github.com/cloudbase/garm/runner/providers/common"
+)
+
+func (s *PoolManagerTestSuite) TestRecycleExpiredRunners() {
+	createParams := s.Fixtures.CreatePoolParams
+	createParams.MaxLifetime = 60
+	pool := s.createPool(createParams)
+	expired := s.createInstances(pool, pool.ProviderName, 4, providerCommon.InstanceRunning, providerCommon.RunnerIdle)
+	s.backdateInstances(expired, 2*time.Hour)
+	active := s.createInstances(pool, pool.ProviderName, 1, providerCommon.InstanceRunning, providerCommon.RunnerActive)
+	s.backdateInstances(active, 2*time.Hour)
+	s.createInstances(pool, pool.ProviderName, 1, providerCommon.InstanceStopped, providerCommon.RunnerIdle)
+
+	instances, err := s.Fixtures.Store.ListPoolInstances(s.Fixtures.AdminContext, pool.ID)
+	s.Require().Nil(err)
+
+	// Half of the expired runners are recycled on each pass.
+	remaining, err := s.PoolManager.recycleExpiredRunners(pool, instances)
+	s.Require().Nil(err)
+	s.Require().Len(remaining, 4)
+	s.Require().Equal(map[providerCommon.InstanceStatus]int{
+		providerCommon.InstanceRunning:       3,
+		providerCommon.InstanceStopped:       1,
+		providerCommon.InstancePendingDelete: 2,
+	}, s.countInstances(pool.ID))
+
+	instances, err = s.Fixtures.Store.ListPoolInstances(s.Fixtures.AdminContext, pool.ID)
+	s.Require().Nil(err)
+	_, err = s.PoolManager.recycleExpiredRunners(pool, instances)
+	s.Require().Nil(err)
+	s.Require().Equal(map[providerCommon.InstanceStatus]int{
+		providerCommon.InstanceRunning:       2,
+		providerCommon.InstanceStopped:       1,
+		providerCommon.InstancePendingDelete: 3,
+	}, s.countInstances(pool.ID))
+}
+
+func (s *PoolManagerTestSuite) TestRecycleExpiredRunnersWithoutMaxLifetime() {
+	pool := s.createPool(s.Fixtures.CreatePoolParams)
+	s.backdateInstances(s.createInstances(pool, pool.ProviderName, 2, providerCommon.InstanceRunning, providerCommon.RunnerIdle), 24*time.Hour)
+
+	instances, err := s.Fixtures.Store.ListPoolInstances(s.Fixtures.AdminContext, pool.ID)
+	s.Require().Nil(err)
+
+	remaining, err := s.PoolManager.recycleExpiredRunners(pool, instances)
+	s.Require().Nil(err)
+	s.Require().Len(remaining, 2)
+	s.Require().Equal(map[providerCommon.InstanceStatus]int{
+		providerCommon.InstanceRunning: 2,
+	}, s.countInstances(pool.ID))
+}
+
+func (s *PoolManagerTestSuite) TestScaleDownHonoursIdleTimeout() {
+	createParams := s.Fixtures.CreatePoolParams
+	createParams.IdleTimeout = 5
+	pool := s.createPool(createParams)
+	instances := s.createInstances(pool, pool.ProviderName, 4, providerCommon.InstanceRunning, providerCommon.RunnerIdle)
+	s.backdateInstances(instances[:2], 10*time.Minute)
+	s.backdateInstances(instances[2:], time.Minute)
+
+	// Only the runners idle for longer than the idle timeout are considered, and half
+	// of them are removed.
+	err := s.PoolManager.scaleDownOnePool(s.Fixtures.AdminContext, pool)
+	s.Require().Nil(err)
+	s.Require().Equal(map[providerCommon.InstanceStatus]int{
+		providerCommon.InstanceRunning:       3,
+		providerCommon.InstancePendingDelete: 1,
+	}, s.countInstances(pool.ID))
+}
@@ -20,6 +20,7 @@ import (
 	"net/http"
 	"strings"
 	"sync"
+	"time"
 
 	dbCommon "github.com/cloudbase/garm/database/common"
 	runnerErrors "github.com/cloudbase/garm/errors"
@@ -74,6 +75,11 @@ type organization struct {
 	id          string
 	store       dbCommon.Store
 
+	// pollTargets caches the repositories of this organization that
+	// we poll for workflow jobs when polling is enabled.
+	pollTargets          []*github.Repository
+	pollTargetsUpdatedAt time.Time
+
 	mux sync.Mutex
 }
 
@@ -111,6 +117,10 @@ func (r *organization) UpdateState(param params.UpdatePoolStateParams) error {
 	defer r.mux.Unlock()
 
 	r.cfg.WebhookSecret = param.WebhookSecret
+	r.cfg.PollingEnabled = param.PollingEnabled
+	r.cfg.PollingInterval = param.PollingInterval
+	r.cfg.PoolSelectionStrategy = param.PoolSelectionStrategy
+	r.cfg.JobSchedulingStrategy = param.JobSchedulingStrategy
 	if param.InternalConfig != nil {
 		r.cfgInternal = *param.InternalConfig
 	}
@@ -202,6 +212,67 @@ func (r *organization) GetGithubRegistrationToken() (string, error) {
 	return *tk.Token, nil
 }
 
+func (r *organization) getRunnerGroupIDByName(groupName string) (int64, error) {
+	if groupName == "" {
+		return defaultRunnerGroupID, nil
+	}
+
+	opts := github.ListOrgRunnerGroupOptions{
+		ListOptions: github.ListOptions{
+			PerPage: 100,
+		},
+	}
+
+	for {
+		groups, ghResp, err := r.ghcli.ListOrganizationRunnerGroups(r.ctx, r.cfg.Name, &opts)
+		if err != nil {
+			if ghResp != nil && ghResp.StatusCode == http.StatusUnauthorized {
+				return 0, errors.Wrap(runnerErrors.ErrUnauthorized, "fetching runner groups")
+			}
+			return 0, errors.Wrap(err, "fetching runner groups")
+		}
+		for _, group := range groups.RunnerGroups {
+			if group.GetName() == groupName {
+				return group.GetID(), nil
+			}
+		}
+		if ghResp.NextPage == 0 {
+			break
+		}
+		opts.Page = ghResp.NextPage
+	}
+
+	return 0, runnerErrors.NewNotFoundError("runner group %s not found", groupName)
+}
+
+func (r *organization) GetJITConfig(instance params.Instance, labels []string) (string, error) {
+	groupID, err := r.getRunnerGroupIDByName(instance.GitHubRunnerGroup)
+	if err != nil {
+		return "", errors.Wrap(err, "fetching runner group ID")
+	}
+
+	req := github.GenerateJITConfigRequest{
+		Name:          instance.Name,
+		RunnerGroupID: groupID,
+		WorkFolder:    github.String(runnerWorkFolder),
+		Labels:        labels,
+	}
+	jitConfig, ghResp, err := r.ghcli.GenerateOrgJITConfig(r.ctx, r.cfg.Name, &req)
+	if err != nil {
+		if ghResp != nil {
+			switch ghResp.StatusCode {
+			case http.StatusUnauthorized:
+				return "", errors.Wrap(runnerErrors.ErrUnauthorized, "fetching JIT config")
+			case http.StatusNotFound:
+				// Older versions of GitHub Enterprise Server do not have this API.
+				return "", errors.Wrap(runnerErrors.ErrNotFound, "fetching JIT config")
+			}
+		}
+		return "", errors.Wrap(err, "generating JIT config")
+	}
+	return jitConfig.GetEncodedJITConfig(), nil
+}
+
 func (r *organization) String() string {
 	return r.cfg.Name
 }
@@ -244,3 +315,171 @@ func (r *organization) ValidateOwner(job params.WorkflowJob) error {
 func (r *organization) ID() string {
 	return r.id
 }
+
+func (r *organization) GetPollingInterval() time.Duration {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	return pollingInterval(r.cfg.PollingEnabled, r.cfg.PollingInterval)
+}
+
+func (r *organization) PoolSelectionStrategy() params.PoolSelectionStrategy {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	return r.cfg.PoolSelectionStrategy
+}
+
+func (r *organization) JobSchedulingStrategy() params.JobSchedulingStrategy {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	return r.cfg.JobSchedulingStrategy
+}
+
+// ListPollTargets returns the repositories in this organization that need to be polled
+// for workflow jobs. The list of repositories rarely changes, so we cache it for a while
+// to spare the API rate limit.
+func (r *organization) ListPollTargets() ([]*github.Repository, error) {
+	r.mux.Lock()
+	if r.pollTargets != nil && time.Since(r.pollTargetsUpdatedAt) < common.PollTargetsRefreshInterval {
+		targets := r.pollTargets
+		r.mux.Unlock()
+		return targets, nil
+	}
+	r.mux.Unlock()
+
+	opts := github.RepositoryListByOrgOptions{
+		ListOptions: github.ListOptions{
+			PerPage: 100,
+		},
+	}
+
+	var targets []*github.Repository
+	for {
+		repos, ghResp, err := r.ghcli.ListOrgRepos(r.ctx, r.cfg.Name, &opts)
+		if err != nil {
+			return nil, wrapGithubError(ghResp, err, "fetching repositories")
+		}
+		for _, repo := range repos {
+			// Archived and disabled repositories can not run workflows.
+			if repo.GetArchived() || repo.GetDisabled() {
+				continue
+			}
+			targets = append(targets, repo)
+		}
+		if ghResp.NextPage == 0 {
+			break
+		}
+		opts.Page = ghResp.NextPage
+	}
+
+	r.mux.Lock()
+	r.pollTargets = targets
+	r.pollTargetsUpdatedAt = time.Now()
+	r.mux.Unlock()
+	return targets, nil
+}
+
+func (r *organization) GetWebhookURL() string {
+	return r.cfgInternal.WebhookURL
+}
+
+func (r *organization) ListHooks() ([]*github.Hook, error) {
+	opts := github.ListOptions{
+		PerPage: 100,
+	}
+
+	var allHooks []*github.Hook
+	for {
+		hooks, ghResp, err := r.ghcli.ListOrgHooks(r.ctx, r.cfg.Name, &opts)
+		if err != nil {
+			return nil, wrapGithubError(ghResp, err, "fetching hooks")
+		}
+		allHooks = append(allHooks, hooks...)
+		if ghResp.NextPage == 0 {
+			break
+		}
+		opts.Page = ghResp.NextPage
+	}
+	return allHooks, nil
+}
+
+func (r *organization) CreateHook(hook *github.Hook) (*github.Hook, error) {
+	newHook, ghResp, err := r.ghcli.CreateOrgHook(r.ctx, r.cfg.Name, hook)
+	if err != nil {
+		return nil, wrapGithubError(ghResp, err, "creating hook")
+	}
+	return newHook, nil
+}
+
+func (r *organization) EditHook(id int64, hook *github.Hook) (*github.Hook, error) {
+	newHook, ghResp, err := r.ghcli.EditOrgHook(r.ctx, r.cfg.Name, id, hook)
+	if err != nil {
+		return nil, wrapGithubError(ghResp, err, "updating hook")
+	}
+	return newHook, nil
+}
+
+func (r *organization) DeleteHook(id int64) error {
+	ghResp, err := r.ghcli.DeleteOrgHook(r.ctx, r.cfg.Name, id)
+	if err != nil {
+		return wrapGithubError(ghResp, err, "deleting hook")
+	}
+	return nil
+}
+
+func (r *organization) PingHook(id int64) error {
+	ghResp, err := r.ghcli.PingOrgHook(r.ctx, r.cfg.Name, id)
+	if err != nil {
+		return wrapGithubError(ghResp, err, "pinging hook")
+	}
+	return nil
+}
+
+func (r *organization) ManagedHookID() (int64, error) {
+	org, err := r.store.GetOrganizationByID(r.ctx, r.id)
+	if err != nil {
+		return 0, errors.Wrap(err, "fetching org")
+	}
+	return org.ManagedHookID, nil
+}
+
+func (r *organization) SetManagedHookID(hookID int64) error {
+	if err := r.store.SetOrganizationManagedHookID(r.ctx, r.id, hookID); err != nil {
+		return errors.Wrap(err, "recording managed hook")
+	}
+	return nil
+}
+
+func (r *organization) ListHookDeliveries(hookID int64, since time.Time) ([]*github.HookDelivery, error) {
+	opts := github.ListCursorOptions{
+		PerPage: 100,
+	}
+
+	var allDeliveries []*github.HookDelivery
+	for {
+		deliveries, ghResp, err := r.ghcli.ListOrgHookDeliveries(r.ctx, r.cfg.Name, hookID, &opts)
+		if err != nil {
+			return nil, wrapGithubError(ghResp, err, "fetching hook deliveries")
+		}
+		allDeliveries = append(allDeliveries, deliveries...)
+		if ghResp.Cursor == "" || len(deliveries) == 0 {
+			break
+		}
+		// Deliveries are sorted newest first. Stop once we reach deliveries older than since.
+		if deliveries[len(deliveries)-1].GetDeliveredAt().Before(since) {
+			break
+		}
+		opts.Cursor = ghResp.Cursor
+	}
+	return allDeliveries, nil
+}
+
+func (r *organization) GetHookDelivery(hookID, deliveryID int64) (*github.HookDelivery, error) {
+	delivery, ghResp, err := r.ghcli.GetOrgHookDelivery(r.ctx, r.cfg.Name, hookID, deliveryID)
+	if err != nil {
+		return nil, wrapGithubError(ghResp, err, "fetching hook delivery")
+	}
+	return delivery, nil
+}
@@ -0,0 +1,342 @@
+// Copyright 2023 Cloudbase Solutions SRL
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package pool
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/cloudbase/garm/params"
+	"github.com/cloudbase/garm/runner/common"
+
+	"github.com/google/go-github/v53/github"
+	"github.com/pkg/errors"
+)
+
+// jobPoller holds the state of the workflow job poller. It is only ever
+// accessed from the polling loop, so it needs no locking.
+type jobPoller struct {
+	// nextPoll is the earliest time at which we poll the github API again.
+	nextPoll time.Time
+	// activeRuns holds the workflow runs that still had unfinished jobs the
+	// last time we polled, along with the repository they belong to. We keep
+	// checking these runs until all their jobs complete, so we don't miss the
+	// completed event of jobs that finish between two polls.
+	activeRuns map[int64]*github.Repository
+	// jobStatus holds the last status we've seen for the jobs of the active runs.
+	jobStatus map[int64]string
+	// seeded is set once a poll went through all repositories. Until then, jobs that
+	// are already completed the first time we see them are recorded, but not handled.
+	seeded bool
+}
+
+func (p *jobPoller) reset() {
+	p.nextPoll = time.Time{}
+	p.activeRuns = nil
+	p.jobStatus = nil
+	p.seeded = false
+}
+
+// rateLimitError is returned by the poller when we are about to exhaust the
+// github API rate limit.
+type rateLimitError struct {
+	reset time.Time
+}
+
+func (e *rateLimitError) Error() string {
+	return fmt.Sprintf("github API rate limit almost exhausted; resets at %s", e.reset.Format(time.RFC3339))
+}
+
+// checkRateLimit returns an error if the number of remaining API requests
+// dropped below the reserve we keep for runner management.
+func checkRateLimit(resp *github.Response) error {
+	if resp == nil || resp.Rate.Limit == 0 {
+		return nil
+	}
+	if resp.Rate.Remaining < common.PollRateLimitReserve {
+		return &rateLimitError{reset: resp.Rate.Reset.Time}
+	}
+	return nil
+}
+
+// pollBackoffUntil returns the time until which we should stop polling, if
+// err was caused by the github API rate limit.
+func pollBackoffUntil(err error) (time.Time, bool) {
+	var rateErr *rateLimitError
+	if errors.As(err, &rateErr) {
+		return rateErr.reset, true
+	}
+
+	var ghRateErr *github.RateLimitError
+	if errors.As(err, &ghRateErr) {
+		return ghRateErr.Rate.Reset.Time, true
+	}
+
+	var abuseErr *github.AbuseRateLimitError
+	if errors.As(err, &abuseErr) {
+		retryAfter := common.BackoffTimer
+		if abuseErr.RetryAfter != nil {
+			retryAfter = *abuseErr.RetryAfter
+		}
+		return time.Now().Add(retryAfter), true
+	}
+	return time.Time{}, false
+}
+
+// pollWorkflowJobs is meant to run in a loop. When polling is enabled for the entity,
+// it lists workflow runs and jobs using the github API, and feeds any job status change
+// into HandleWorkflowJob, as if we had received it via a webhook.
+func (r *basePoolManager) pollWorkflowJobs() error {
+	interval := r.helper.GetPollingInterval()
+	if interval == 0 {
+		// Polling is disabled. Forget any state, so if polling is enabled
+		// later, we start fresh.
+		r.poller.reset()
+		return nil
+	}
+
+	now := time.Now()
+	if now.Before(r.poller.nextPoll) {
+		return nil
+	}
+	r.poller.nextPoll = now.Add(interval)
+
+	if err := r.pollOnce(); err != nil {
+		if until, ok := pollBackoffUntil(err); ok {
+			if until.After(r.poller.nextPoll) {
+				r.poller.nextPoll = until
+			}
+			r.log("rate limited while polling for jobs; pausing until %s", r.poller.nextPoll.Format(time.RFC3339))
+			return nil
+		}
+		return errors.Wrap(err, "polling workflow jobs")
+	}
+	return nil
+}
+
+// pollOnce lists the active workflow runs of all poll targets, and handles the jobs
+// whose status changed since the last poll. The progress made is kept even if the poll
+// fails partway, so jobs that were already handled are not handled again.
+func (r *basePoolManager) pollOnce() (err error) {
+	targets, err := r.helper.ListPollTargets()
+	if err != nil {
+		return errors.Wrap(err, "listing repositories")
+	}
+
+	// Runs we were tracking are checked even if they no longer show up in the list of
+	// active runs, so we see the last status change of their jobs.
+	pending := map[int64]*github.Repository{}
+	for runID, repo := range r.poller.activeRuns {
+		pending[runID] = repo
+	}
+	activeRuns := map[int64]*github.Repository{}
+	seenJobs := map[int64]string{}
+	defer func() {
+		// Runs we did not get to are kept, along with the status we last saw for
+		// their jobs, and are checked on the next poll.
+		for runID, repo := range pending {
+			activeRuns[runID] = repo
+		}
+		if err != nil {
+			for jobID, status := range r.poller.jobStatus {
+				if _, ok := seenJobs[jobID]; !ok {
+					seenJobs[jobID] = status
+				}
+			}
+		} else {
+			r.poller.seeded = true
+		}
+		r.poller.activeRuns = activeRuns
+		r.poller.jobStatus = seenJobs
+	}()
+
+	for _, repo := range targets {
+		repoRuns, err := r.listActiveWorkflowRuns(repo)
+		if err != nil {
+			return errors.Wrapf(err, "listing workflow runs for %s", repoFullName(repo))
+		}
+		for _, run := range repoRuns {
+			pending[run.GetID()] = repo
+		}
+
+		for runID, runRepo := range pending {
+			if repoFullName(runRepo) != repoFullName(repo) {
+				continue
+			}
+			if err := r.pollWorkflowRun(repo, runID, activeRuns, seenJobs); err != nil {
+				return errors.Wrapf(err, "listing jobs for workflow run %d", runID)
+			}
+			delete(pending, runID)
+		}
+	}
+
+	// Runs of repositories that are no longer poll targets.
+	for runID, repo := range pending {
+		if err := r.pollWorkflowRun(repo, runID, activeRuns, seenJobs); err != nil {
+			return errors.Wrapf(err, "listing jobs for workflow run %d", runID)
+		}
+		delete(pending, runID)
+	}
+	return nil
+}
+
+// pollWorkflowRun handles the jobs of a workflow run whose status changed since the last
+// poll. The status of the jobs is recorded in seenJobs, and the run is added to activeRuns
+// if it still has unfinished jobs.
+func (r *basePoolManager) pollWorkflowRun(repo *github.Repository, runID int64, activeRuns map[int64]*github.Repository, seenJobs map[int64]string) error {
+	jobs, err := r.listWorkflowJobs(repo, runID)
+	if err != nil {
+		return err
+	}
+
+	for _, job := range jobs {
+		status := job.GetStatus()
+		seenJobs[job.GetID()] = status
+		if status != string(params.JobStatusCompleted) {
+			activeRuns[runID] = repo
+		}
+
+		lastStatus, known := r.poller.jobStatus[job.GetID()]
+		if lastStatus == status {
+			continue
+		}
+		switch params.JobStatus(status) {
+		case params.JobStatusQueued, params.JobStatusInProgress:
+		case params.JobStatusCompleted:
+			// Jobs that completed before we started polling are not replayed.
+			if !known && !r.poller.seeded {
+				continue
+			}
+		default:
+			// Jobs that are waiting or pending can not be picked up by a runner yet.
+			continue
+		}
+
+		if err := r.HandleWorkflowJob(r.githubJobToWorkflowJob(repo, job)); err != nil {
+			r.log("failed to handle polled job %d: %s", job.GetID(), err)
+			// Forget the job, so it is handled again on the next poll. The run is
+			// kept, so the job is listed again even if it completed.
+			delete(seenJobs, job.GetID())
+			activeRuns[runID] = repo
+		}
+	}
+	return nil
+}
+
+// listActiveWorkflowRuns returns the workflow runs of a repository that have not completed.
+// Runs are listed newest first, and we stop at the first page that holds no active run. This
+// costs a single request for most repositories, where filtering by status would cost one for
+// each status we care about.
+func (r *basePoolManager) listActiveWorkflowRuns(repo *github.Repository) ([]*github.WorkflowRun, error) {
+	opts := github.ListWorkflowRunsOptions{
+		ListOptions: github.ListOptions{
+			PerPage: 100,
+		},
+	}
+
+	var activeRuns []*github.WorkflowRun
+	for {
+		runs, ghResp, err := r.helper.GithubCLI().ListRepositoryWorkflowRuns(r.ctx, repo.GetOwner().GetLogin(), repo.GetName(), &opts)
+		if err != nil {
+			return nil, wrapGithubError(ghResp, err, "fetching workflow runs")
+		}
+		if err := checkRateLimit(ghResp); err != nil {
+			return nil, err
+		}
+
+		found := false
+		for _, run := range runs.WorkflowRuns {
+			if run.GetStatus() == string(params.JobStatusCompleted) {
+				continue
+			}
+			activeRuns = append(activeRuns, run)
+			found = true
+		}
+		if !found || ghResp.NextPage == 0 {
+			break
+		}
+		opts.Page = ghResp.NextPage
+	}
+	return activeRuns, nil
+}
+
+func (r *basePoolManager) listWorkflowJobs(repo *github.Repository, runID int64) ([]*github.WorkflowJob, error) {
+	opts := github.ListWorkflowJobsOptions{
+		Filter: "latest",
+		ListOptions: github.ListOptions{
+			PerPage: 100,
+		},
+	}
+
+	var allJobs []*github.WorkflowJob
+	for {
+		jobs, ghResp, err := r.helper.GithubCLI().ListWorkflowJobs(r.ctx, repo.GetOwner().GetLogin(), repo.GetName(), runID, &opts)
+		if err != nil {
+			return nil, wrapGithubError(ghResp, err, "fetching workflow jobs")
+		}
+		if err := checkRateLimit(ghResp); err != nil {
+			return nil, err
+		}
+		allJobs = append(allJobs, jobs.Jobs...)
+		if ghResp.NextPage == 0 {
+			break
+		}
+		opts.Page = ghResp.NextPage
+	}
+	return allJobs, nil
+}
+
+// repoFullName returns the owner and name of a repository. Poll targets of repositories
+// only hold these, so they are used to tell repositories apart.
+func repoFullName(repo *github.Repository) string {
+	return fmt.Sprintf("%s/%s", repo.GetOwner().GetLogin(), repo.GetName())
+}
+
+// githubJobToWorkflowJob converts a job fetched from the github API to the same
+// structure we get when github sends us a workflow_job webhook.
+func (r *basePoolManager) githubJobToWorkflowJob(repo *github.Repository, job *github.WorkflowJob) params.WorkflowJob {
+	var ret params.WorkflowJob
+	ret.Action = job.GetStatus()
+
+	ret.WorkflowJob.ID = job.GetID()
+	ret.WorkflowJob.RunID = job.GetRunID()
+	ret.WorkflowJob.RunURL = job.GetRunURL()
+	ret.WorkflowJob.RunAttempt = job.GetRunAttempt()
+	ret.WorkflowJob.NodeID = job.GetNodeID()
+	ret.WorkflowJob.HeadSha = job.GetHeadSHA()
+	ret.WorkflowJob.URL = job.GetURL()
+	ret.WorkflowJob.HTMLURL = job.GetHTMLURL()
+	ret.WorkflowJob.Status = job.GetStatus()
+	ret.WorkflowJob.Conclusion = job.GetConclusion()
+	ret.WorkflowJob.StartedAt = job.GetStartedAt().Time
+	ret.WorkflowJob.CompletedAt = job.GetCompletedAt().Time
+	ret.WorkflowJob.Name = job.GetName()
+	ret.WorkflowJob.CheckRunURL = job.GetCheckRunURL()
+	ret.WorkflowJob.Labels = job.Labels
+	ret.WorkflowJob.RunnerID = job.GetRunnerID()
+	ret.WorkflowJob.RunnerName = job.GetRunnerName()
+	ret.WorkflowJob.RunnerGroupID = job.GetRunnerGroupID()
+	ret.WorkflowJob.RunnerGroupName = job.GetRunnerGroupName()
+
+	owner := repo.GetOwner().GetLogin()
+	ret.Repository.ID = repo.GetID()
+	ret.Repository.Name = repo.GetName()
+	ret.Repository.FullName = fmt.Sprintf("%s/%s", owner, repo.GetName())
+	ret.Repository.Owner.Login = owner
+	if r.helper.PoolType() == params.OrganizationPool {
+		ret.Organization.Login = owner
+	}
+	return ret
+}
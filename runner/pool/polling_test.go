@@ -0,0 +1,135 @@
+// Copyright 2023 Cloudbase Solutions SRL
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package pool
+
+import (
+	"fmt"
+
+	runnerCommonMocks "github.com/cloudbase/garm/runner/common/mocks"
+	providerCommon "github.com/cloudbase/garm/runner/providers/common"
+
+	"github.com/google/go-github/v53/github"
+	"github.com/stretchr/testify/mock"
+)
+
+func (s *PoolManagerTestSuite) pollTarget(name string) *github.Repository {
+	return &github.Repository{
+		Name:  github.String(name),
+		Owner: &github.User{Login: github.String(s.Fixtures.Repo.Owner)},
+	}
+}
+
+func (s *PoolManagerTestSuite) setupPolling(targets ...*github.Repository) *runnerCommonMocks.GithubClient {
+	ghcli := runnerCommonMocks.NewGithubClient(s.T())
+	helper := s.PoolManager.helper.(*testPoolHelper)
+	helper.ghcli = ghcli
+	helper.pollTargets = targets
+	return ghcli
+}
+
+func (s *PoolManagerTestSuite) mockWorkflowRuns(ghcli *runnerCommonMocks.GithubClient, repo string, runs ...*github.WorkflowRun) *mock.Call {
+	return ghcli.On("ListRepositoryWorkflowRuns", mock.Anything, s.Fixtures.Repo.Owner, repo, mock.Anything).Return(
+		&github.WorkflowRuns{WorkflowRuns: runs}, &github.Response{}, nil)
+}
+
+func (s *PoolManagerTestSuite) mockWorkflowJobs(ghcli *runnerCommonMocks.GithubClient, repo string, runID int64, jobs ...*github.WorkflowJob) *mock.Call {
+	return ghcli.On("ListWorkflowJobs", mock.Anything, s.Fixtures.Repo.Owner, repo, runID, mock.Anything).Return(
+		&github.Jobs{Jobs: jobs}, &github.Response{}, nil)
+}
+
+func workflowRun(id int64, status string) *github.WorkflowRun {
+	return &github.WorkflowRun{ID: github.Int64(id), Status: github.String(status)}
+}
+
+func (s *PoolManagerTestSuite) polledJob(id, runID int64, status, runnerName string) *github.WorkflowJob {
+	return &github.WorkflowJob{
+		ID:         github.Int64(id),
+		RunID:      github.Int64(runID),
+		Status:     github.String(status),
+		Conclusion: github.String("success"),
+		Labels:     s.Fixtures.CreatePoolParams.Tags,
+		RunnerName: github.String(runnerName),
+	}
+}
+
+func (s *PoolManagerTestSuite) TestPollDoesNotReplayJobsCompletedBeforeFirstPoll() {
+	pool := s.createPool(s.Fixtures.CreatePoolParams)
+	instances := s.createInstances(pool, pool.ProviderName, 2, providerCommon.InstanceRunning, providerCommon.RunnerIdle)
+	ghcli := s.setupPolling(s.pollTarget(s.Fixtures.Repo.Name))
+	s.mockWorkflowRuns(ghcli, s.Fixtures.Repo.Name, workflowRun(1, "in_progress")).Once()
+	s.mockWorkflowJobs(ghcli, s.Fixtures.Repo.Name, 1,
+		s.polledJob(1, 1, "completed", instances[0].Name),
+		s.polledJob(2, 1, "waiting", "")).Twice()
+
+	err := s.PoolManager.pollOnce()
+	s.Require().Nil(err)
+	s.Require().True(s.PoolManager.poller.seeded)
+	s.Require().Equal(map[providerCommon.InstanceStatus]int{
+		providerCommon.InstanceRunning: 2,
+	}, s.countInstances(pool.ID))
+
+	// Once seeded, jobs that completed between two polls are handled.
+	s.mockWorkflowRuns(ghcli, s.Fixtures.Repo.Name, workflowRun(1, "in_progress"), workflowRun(2, "in_progress")).Once()
+	s.mockWorkflowJobs(ghcli, s.Fixtures.Repo.Name, 2, s.polledJob(3, 2, "completed", instances[1].Name)).Once()
+
+	err = s.PoolManager.pollOnce()
+	s.Require().Nil(err)
+	s.Require().Equal(map[providerCommon.InstanceStatus]int{
+		providerCommon.InstanceRunning:       1,
+		providerCommon.InstancePendingDelete: 1,
+	}, s.countInstances(pool.ID))
+	instance, err := s.Fixtures.Store.GetInstanceByName(s.Fixtures.AdminContext, instances[1].Name)
+	s.Require().Nil(err)
+	s.Require().Equal(providerCommon.InstancePendingDelete, instance.Status)
+}
+
+func (s *PoolManagerTestSuite) TestPollKeepsProgressOnError() {
+	ghcli := s.setupPolling(s.pollTarget("first-repo"), s.pollTarget("second-repo"))
+	s.PoolManager.poller.jobStatus = map[int64]string{3: "queued"}
+	s.PoolManager.poller.activeRuns = map[int64]*github.Repository{2: s.pollTarget("second-repo")}
+
+	s.mockWorkflowRuns(ghcli, "first-repo", workflowRun(1, "in_progress"))
+	s.mockWorkflowJobs(ghcli, "first-repo", 1,
+		s.polledJob(1, 1, "completed", "other-runner"),
+		s.polledJob(2, 1, "waiting", ""))
+	ghcli.On("ListRepositoryWorkflowRuns", mock.Anything, s.Fixtures.Repo.Owner, "second-repo", mock.Anything).Return(
+		nil, nil, fmt.Errorf("mock error"))
+
+	err := s.PoolManager.pollOnce()
+	s.Require().NotNil(err)
+	s.Require().False(s.PoolManager.poller.seeded)
+	s.Require().Equal(map[int64]string{1: "completed", 2: "waiting", 3: "queued"}, s.PoolManager.poller.jobStatus)
+	s.Require().Equal(map[int64]*github.Repository{
+		1: s.pollTarget("first-repo"),
+		2: s.pollTarget("second-repo"),
+	}, s.PoolManager.poller.activeRuns)
+}
+
+func (s *PoolManagerTestSuite) TestListActiveWorkflowRunsStopsAtCompletedPage() {
+	target := s.pollTarget(s.Fixtures.Repo.Name)
+	ghcli := s.setupPolling(target)
+	firstPage := mock.MatchedBy(func(opts *github.ListWorkflowRunsOptions) bool { return opts.Page == 0 })
+	secondPage := mock.MatchedBy(func(opts *github.ListWorkflowRunsOptions) bool { return opts.Page == 2 })
+	ghcli.On("ListRepositoryWorkflowRuns", mock.Anything, s.Fixtures.Repo.Owner, s.Fixtures.Repo.Name, firstPage).Return(
+		&github.WorkflowRuns{WorkflowRuns: []*github.WorkflowRun{workflowRun(3, "queued"), workflowRun(2, "completed")}},
+		&github.Response{NextPage: 2}, nil).Once()
+	ghcli.On("ListRepositoryWorkflowRuns", mock.Anything, s.Fixtures.Repo.Owner, s.Fixtures.Repo.Name, secondPage).Return(
+		&github.WorkflowRuns{WorkflowRuns: []*github.WorkflowRun{workflowRun(1, "completed")}},
+		&github.Response{NextPage: 3}, nil).Once()
+
+	runs, err := s.PoolManager.listActiveWorkflowRuns(target)
+	s.Require().Nil(err)
+	s.Require().Equal([]*github.WorkflowRun{workflowRun(3, "queued")}, runs)
+}
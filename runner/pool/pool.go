@@ -20,6 +20,7 @@ import (
 	"log"
 	"math"
 	"net/http"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
@@ -49,6 +50,9 @@ var (
 	// The job it picked up would already be transitioned to in_progress so it will be ignored by the
 	// consume loop.
 	jobLabelPrefix = "in_response_to_job:"
+	// Runners created to replace a stale runner are tagged with the name of the runner they
+	// replace. The stale runner is removed once its replacement is online.
+	replacesRunnerLabelPrefix = "replaces_runner:"
 )
 
 const (
@@ -56,6 +60,28 @@ const (
 	// before we give up.
 	// TODO: make this configurable(?)
 	maxCreateAttempts = 5
+	// defaultRunnerGroupID is the ID of the "Default" runner group. Repositories can only
+	// use this group, and it always exists for organizations.
+	defaultRunnerGroupID int64 = 1
+	// runnerWorkFolder is the work folder of the runner, relative to the folder in which
+	// the runner was extracted. This is the same default config.sh uses.
+	runnerWorkFolder = "_work"
+	// workflowJobEvent is the webhook event garm needs github to deliver to run jobs.
+	workflowJobEvent = "workflow_job"
+	// workflowRunEvent is the webhook event garm uses to count workflow runs in its metrics.
+	workflowRunEvent = "workflow_run"
+	// consoleLogTail is the amount of console output we log for runners that never
+	// came online.
+	consoleLogTail = 4096
+	// consoleLogTimeout is the time we wait for a provider to return a console log.
+	consoleLogTimeout = 30 * time.Second
+	// scaleDownFactor is the share of the surplus idle runners of a pool that are
+	// removed on each scale down pass.
+	// TODO: make this configurable(?)
+	scaleDownFactor = 0.5
+	// warmRunnerTransitionTimeout is the time after which an instance that is still stopping
+	// or starting is checked against the provider.
+	warmRunnerTransitionTimeout = 10 * time.Minute
 )
 
 type keyMutex struct {
@@ -100,6 +126,13 @@ type basePoolManager struct {
 	managerIsRunning   bool
 	managerErrorReason string
 
+	poller jobPoller
+	// recoveryMux makes sure only one webhook delivery recovery runs at a time.
+	recoveryMux sync.Mutex
+	// breakerMux serializes updates to the circuit breakers of the pools.
+	breakerMux sync.Mutex
+	drainCache providerDrainCache
+
 	mux    sync.Mutex
 	wg     *sync.WaitGroup
 	keyMux *keyMutex
@@ -110,8 +143,22 @@ func (r *basePoolManager) HandleWorkflowJob(job params.WorkflowJob) error {
 		return errors.Wrap(err, "validating owner")
 	}
 
+	// Webhook deliveries are processed concurrently and retried, so the events of a job may
+	// arrive out of order. Handle one event of a job at a time, and ignore events that are
+	// older than the state we already recorded for the job.
+	jobKey := fmt.Sprintf("job:%d", job.WorkflowJob.ID)
+	if !r.keyMux.TryLock(jobKey) {
+		return fmt.Errorf("another event of job %d is being handled", job.WorkflowJob.ID)
+	}
+	defer r.keyMux.Unlock(jobKey, true)
+
+	storedJob, err := r.store.GetJobByID(r.ctx, job.WorkflowJob.ID)
+	if err == nil && isStaleJobEvent(storedJob, job.Action) {
+		r.log("ignoring %s event for job %d; job is already %s", util.SanitizeLogEntry(job.Action), job.WorkflowJob.ID, storedJob.Action)
+		return nil
+	}
+
 	var jobParams params.Job
-	var err error
 	var triggeredBy int64
 	defer func() {
 		// we're updating the job in the database, regardless of whether it was successful or not.
@@ -176,13 +223,18 @@ func (r *basePoolManager) HandleWorkflowJob(job params.WorkflowJob) error {
 		}
 
 		// update instance workload state.
-		if _, err := r.setInstanceRunnerStatus(jobParams.RunnerName, providerCommon.RunnerTerminated); err != nil {
+		instance, err := r.setInstanceRunnerStatus(jobParams.RunnerName, providerCommon.RunnerTerminated)
+		if err != nil {
 			if errors.Is(err, runnerErrors.ErrNotFound) {
 				return nil
 			}
 			r.log("failed to update runner %s status: %s", util.SanitizeLogEntry(jobParams.RunnerName), err)
 			return errors.Wrap(err, "updating runner")
 		}
+		if jobParams.Conclusion == "success" {
+			r.recordJobSucceeded(instance)
+		}
+		r.recordRemovalReason(instance, fmt.Sprintf("job %d completed", jobParams.ID))
 		r.log("marking instance %s as pending_delete", util.SanitizeLogEntry(jobParams.RunnerName))
 		if _, err := r.setInstanceStatus(jobParams.RunnerName, providerCommon.InstancePendingDelete, nil); err != nil {
 			if errors.Is(err, runnerErrors.ErrNotFound) {
@@ -223,6 +275,7 @@ func (r *basePoolManager) HandleWorkflowJob(job params.WorkflowJob) error {
 		if err != nil {
 			return errors.Wrap(err, "getting pool")
 		}
+		r.recordJobStarted(pool.ID, job)
 		if err := r.ensureIdleRunnersForOnePool(pool); err != nil {
 			r.log("error ensuring idle runners for pool %s: %s", pool.ID, err)
 		}
@@ -393,6 +446,7 @@ func (r *basePoolManager) cleanupOrphanedProviderRunners(runners []*github.Runne
 		}
 
 		if ok := runnerNames[instance.Name]; !ok {
+			r.recordRemovalReason(instance, "runner was not found in github")
 			// Set pending_delete on DB field. Allow consolidate() to remove it.
 			if _, err := r.setInstanceStatus(instance.Name, providerCommon.InstancePendingDelete, nil); err != nil {
 				r.log("failed to update runner %s status: %s", instance.Name, err)
@@ -456,8 +510,14 @@ func (r *basePoolManager) reapTimedOutRunners(runners []*github.Runner) error {
 		// both the runner status as reported by GitHub and the runner status as reported by the provider.
 		// If the runner is "offline" and marked as "failed", it should be safe to reap it.
 		if runner, ok := runnersByName[instance.Name]; !ok || (runner.GetStatus() == "offline" && instance.RunnerStatus == providerCommon.RunnerFailed) {
-			r.log("reaping timed-out/failed runner %s", instance.Name)
-			if err := r.ForceDeleteRunner(instance); err != nil {
+			reason := fmt.Sprintf("runner failed or did not join github within %d minutes", pool.RunnerTimeout())
+			switch instance.RunnerStatus {
+			case providerCommon.RunnerPending, providerCommon.RunnerInstalling, providerCommon.RunnerFailed:
+				// The runner never came online.
+				r.recordProvisioningFailure(pool.ID, fmt.Sprintf("runner %s %s", instance.Name, reason))
+				r.logConsoleOutput(pool, instance)
+			}
+			if err := r.removeRunner(instance, reason); err != nil {
 				r.log("failed to update runner %s status: %s", instance.Name, err)
 				return errors.Wrap(err, "updating runner")
 			}
@@ -525,21 +585,28 @@ func (r *basePoolManager) cleanupOrphanedGithubRunners(runners []*github.Runner)
 			return errors.Wrap(err, "fetching pool")
 		}
 
+		if r.isProviderDegraded(pool.InstanceProviderName(dbInstance)) {
+			// We can't tell if the provider still has the instance. Check again once
+			// the provider recovers.
+			continue
+		}
+
 		// check if the provider still has the instance.
-		provider, ok := r.providers[pool.ProviderName]
-		if !ok {
-			return fmt.Errorf("unknown provider %s for pool %s", pool.ProviderName, pool.ID)
+		provider, err := r.instanceProvider(pool, dbInstance)
+		if err != nil {
+			return err
 		}
 
-		var poolInstances []params.Instance
-		poolInstances, ok = poolInstanceCache[pool.ID]
+		// The instances of a pool may be spread over its fallback providers.
+		cacheKey := fmt.Sprintf("%s/%s", pool.InstanceProviderName(dbInstance), pool.ID)
+		poolInstances, ok := poolInstanceCache[cacheKey]
 		if !ok {
 			r.log("updating instances cache for pool %s", pool.ID)
 			poolInstances, err = provider.ListInstances(r.ctx, pool.ID)
 			if err != nil {
 				return errors.Wrapf(err, "fetching instances for pool %s", pool.ID)
 			}
-			poolInstanceCache[pool.ID] = poolInstances
+			poolInstanceCache[cacheKey] = poolInstances
 		}
 
 		lockAcquired := r.keyMux.TryLock(dbInstance.Name)
@@ -585,6 +652,16 @@ func (r *basePoolManager) cleanupOrphanedGithubRunners(runners []*github.Runner)
 				r.log("instance %s is online but github reports runner as offline", dbInstance.Name)
 				return nil
 			} else {
+				switch dbInstance.Status {
+				case providerCommon.InstanceStopped, providerCommon.InstanceStopping:
+					// This is a warm runner. It was stopped by us and will be started
+					// when a job is queued.
+					return nil
+				}
+				if !provider.AsParams().Capabilities.StartStop {
+					r.log("instance %s was found in stopped state, but provider %s cannot start it", dbInstance.Name, pool.InstanceProviderName(dbInstance))
+					return nil
+				}
 				r.log("instance %s was found in stopped state; starting", dbInstance.Name)
 				//start the instance
 				if err := provider.Start(r.ctx, dbInstance.ProviderID); err != nil {
@@ -677,6 +754,23 @@ func (r *basePoolManager) AddRunner(ctx context.Context, poolID string, aditiona
 		return errors.Wrap(err, "fetching pool")
 	}
 
+	candidates := pool.Providers()
+	creds := r.githubCredentials()
+	if r.providersLimited(candidates) || creds.MaxRunners > 0 {
+		capacityMux.Lock()
+		defer capacityMux.Unlock()
+	}
+
+	if err := r.checkCredentialsQuota(creds); err != nil {
+		return err
+	}
+
+	// The runner is placed on the first provider of the pool that is not at capacity.
+	providerIdx, err := r.selectProvider(pool, candidates, 0)
+	if err != nil {
+		return err
+	}
+
 	name := fmt.Sprintf("%s-%s", pool.GetRunnerPrefix(), util.NewID())
 
 	createParams := params.CreateInstanceParams{
@@ -690,6 +784,7 @@ func (r *basePoolManager) AddRunner(ctx context.Context, poolID string, aditiona
 		CreateAttempt:     1,
 		GitHubRunnerGroup: pool.GitHubRunnerGroup,
 		AditionalLabels:   aditionalLabels,
+		ProviderName:      candidates[providerIdx].ProviderName,
 	}
 
 	_, err = r.store.CreateInstance(r.ctx, poolID, createParams)
@@ -720,28 +815,37 @@ func (r *basePoolManager) waitForTimeoutOrCanceled(timeout time.Duration) {
 
 func (r *basePoolManager) setPoolRunningState(isRunning bool, failureReason string) {
 	r.mux.Lock()
+	wasRunning := r.managerIsRunning
 	r.managerErrorReason = failureReason
 	r.managerIsRunning = isRunning
 	r.mux.Unlock()
+
+	if isRunning && !wasRunning {
+		// The pool manager just started or recovered from a failure. Github may have
+		// sent webhooks we never processed in the meantime.
+		go r.recoverMissedDeliveries()
+	}
 }
 
+// addInstanceToProvider creates the instance on the provider it was placed on. If the
+// provider fails to create it, the instance is moved to the next provider of the pool.
 func (r *basePoolManager) addInstanceToProvider(instance params.Instance) error {
 	pool, err := r.helper.GetPoolByID(instance.PoolID)
 	if err != nil {
 		return errors.Wrap(err, "fetching pool")
 	}
 
-	provider, ok := r.providers[pool.ProviderName]
-	if !ok {
-		return fmt.Errorf("unknown provider %s for pool %s", pool.ProviderName, pool.ID)
+	candidates := pool.Providers()
+	providerIdx := providerIndex(candidates, instance.ProviderName)
+	if providerIdx < 0 {
+		// The provider the instance was placed on was removed from the pool.
+		providerIdx, err = r.reserveProvider(pool, instance, candidates, 0)
+		if err != nil {
+			return errors.Wrap(err, "selecting provider")
+		}
 	}
 
-	labels := []string{}
-	for _, tag := range pool.Tags {
-		labels = append(labels, tag.Name)
-	}
-	labels = append(labels, r.controllerLabel())
-	labels = append(labels, r.poolLabel(pool.ID))
+	labels := r.runnerLabels(pool)
 
 	jwtValidity := pool.RunnerTimeout()
 
@@ -760,15 +864,57 @@ func (r *basePoolManager) addInstanceToProvider(instance params.Instance) error
 		InstanceToken:     jwtToken,
 		OSArch:            pool.OSArch,
 		OSType:            pool.OSType,
-		Flavor:            pool.Flavor,
-		Image:             pool.Image,
-		ExtraSpecs:        pool.ExtraSpecs,
 		Labels:            labels,
 		PoolID:            instance.PoolID,
-		CACertBundle:      r.credsDetails.CABundle,
+		CACertBundle:      r.githubCredentials().CABundle,
 		GitHubRunnerGroup: instance.GitHubRunnerGroup,
 	}
 
+	for {
+		candidate := candidates[providerIdx]
+		providerInstance, err := r.createInstanceOnProvider(pool, bootstrapArgs, candidate)
+		if err == nil && providerInstance.Status != providerCommon.InstanceError {
+			updateInstanceArgs := r.updateArgsFromProviderInstance(providerInstance)
+			updateInstanceArgs.ProviderName = candidate.ProviderName
+			if _, err := r.store.UpdateInstance(r.ctx, instance.ID, updateInstanceArgs); err != nil {
+				return errors.Wrap(err, "updating instance")
+			}
+			return nil
+		}
+
+		nextIdx, nextErr := r.reserveProvider(pool, instance, candidates, providerIdx+1)
+		if nextErr != nil {
+			if err != nil {
+				return errors.Wrap(err, "creating instance")
+			}
+			// The provider reported an error for the instance. Record it.
+			updateInstanceArgs := r.updateArgsFromProviderInstance(providerInstance)
+			if _, err := r.store.UpdateInstance(r.ctx, instance.ID, updateInstanceArgs); err != nil {
+				return errors.Wrap(err, "updating instance")
+			}
+			return nil
+		}
+
+		if err == nil {
+			err = fmt.Errorf("instance is in error state")
+		}
+		r.log("failed to create instance %s on provider %s, trying provider %s: %s", instance.Name, candidate.ProviderName, candidates[nextIdx].ProviderName, err)
+		providerIdx = nextIdx
+	}
+}
+
+// createInstanceOnProvider creates an instance on one of the providers of its pool. An instance
+// that could not be created, or that ended up in error state, is removed from the provider.
+func (r *basePoolManager) createInstanceOnProvider(pool params.Pool, bootstrapArgs params.BootstrapInstance, candidate params.PoolProvider) (params.Instance, error) {
+	provider, ok := r.providers[candidate.ProviderName]
+	if !ok {
+		return params.Instance{}, fmt.Errorf("unknown provider %s for pool %s", candidate.ProviderName, pool.ID)
+	}
+
+	bootstrapArgs.Flavor = candidate.Flavor
+	bootstrapArgs.Image = candidate.Image
+	bootstrapArgs.ExtraSpecs = candidate.ExtraSpecs
+
 	var instanceIDToDelete string
 
 	defer func() {
@@ -783,22 +929,17 @@ func (r *basePoolManager) addInstanceToProvider(instance params.Instance) error
 
 	providerInstance, err := provider.CreateInstance(r.ctx, bootstrapArgs)
 	if err != nil {
-		instanceIDToDelete = instance.Name
-		return errors.Wrap(err, "creating instance")
+		instanceIDToDelete = bootstrapArgs.Name
+		return params.Instance{}, errors.Wrapf(err, "creating instance on provider %s", candidate.ProviderName)
 	}
 
 	if providerInstance.Status == providerCommon.InstanceError {
-		instanceIDToDelete = instance.ProviderID
+		instanceIDToDelete = providerInstance.ProviderID
 		if instanceIDToDelete == "" {
-			instanceIDToDelete = instance.Name
+			instanceIDToDelete = bootstrapArgs.Name
 		}
 	}
-
-	updateInstanceArgs := r.updateArgsFromProviderInstance(providerInstance)
-	if _, err := r.store.UpdateInstance(r.ctx, instance.ID, updateInstanceArgs); err != nil {
-		return errors.Wrap(err, "updating instance")
-	}
-	return nil
+	return providerInstance, nil
 }
 
 func (r *basePoolManager) getRunnerDetailsFromJob(job params.WorkflowJob) (params.RunnerInfo, error) {
@@ -848,6 +989,27 @@ func (r *basePoolManager) getRunnerDetailsFromJob(job params.WorkflowJob) (param
 // We save the details of that job at every level, because we want to at least update the status of the job. We make
 // decissions based on the status of saved jobs. A "queued" job will prompt garm to search for an appropriate pool
 // and spin up a runner there if no other idle runner exists to pick it up.
+// jobActionOrder is the order in which github sends the events of a workflow job.
+var jobActionOrder = map[string]int{
+	string(params.JobStatusQueued):     0,
+	string(params.JobStatusInProgress): 1,
+	string(params.JobStatusCompleted):  2,
+}
+
+// isStaleJobEvent returns true if the job was already recorded in a later phase than
+// the one the event describes.
+func isStaleJobEvent(storedJob params.Job, action string) bool {
+	stored, ok := jobActionOrder[storedJob.Action]
+	if !ok {
+		return false
+	}
+	incoming, ok := jobActionOrder[action]
+	if !ok {
+		return false
+	}
+	return incoming < stored
+}
+
 func (r *basePoolManager) paramsWorkflowJobToParamsJob(job params.WorkflowJob) (params.Job, error) {
 	asUUID, err := uuid.Parse(r.ID())
 	if err != nil {
@@ -910,6 +1072,17 @@ func (r *basePoolManager) controllerLabel() string {
 	return fmt.Sprintf("%s%s", controllerLabelPrefix, r.controllerID)
 }
 
+// runnerLabels returns the labels a runner spawned in the given pool will register with.
+func (r *basePoolManager) runnerLabels(pool params.Pool) []string {
+	labels := []string{}
+	for _, tag := range pool.Tags {
+		labels = append(labels, tag.Name)
+	}
+	labels = append(labels, r.controllerLabel())
+	labels = append(labels, r.poolLabel(pool.ID))
+	return labels
+}
+
 func (r *basePoolManager) updateArgsFromProviderInstance(providerInstance params.Instance) params.UpdateInstanceParams {
 	return params.UpdateInstanceParams{
 		ProviderID:    providerInstance.ProviderID,
@@ -923,30 +1096,77 @@ func (r *basePoolManager) updateArgsFromProviderInstance(providerInstance params
 }
 func (r *basePoolManager) scaleDownOnePool(ctx context.Context, pool params.Pool) error {
 	r.log("scaling down pool %s", pool.ID)
+	if r.isDraining(pool) {
+		return r.drainOnePool(pool)
+	}
+
+	existingInstances, err := r.store.ListPoolInstances(r.ctx, pool.ID)
+	if err != nil {
+		return fmt.Errorf("failed to ensure minimum idle workers for pool %s: %w", pool.ID, err)
+	}
+
+	// Runners placed on a draining fallback provider are removed even if the pool is disabled.
+	existingInstances, err = r.drainFallbackRunners(pool, existingInstances)
+	if err != nil {
+		return fmt.Errorf("failed to drain fallback runners in pool %s: %w", pool.ID, err)
+	}
+
+	pool = r.scalingTargets(pool)
 	if !pool.Enabled {
 		r.log("pool %s is disabled, skipping scale down", pool.ID)
 		return nil
 	}
 
-	existingInstances, err := r.store.ListPoolInstances(r.ctx, pool.ID)
+	existingInstances, err = r.recycleExpiredRunners(pool, existingInstances)
 	if err != nil {
-		return fmt.Errorf("failed to ensure minimum idle workers for pool %s: %w", pool.ID, err)
+		return fmt.Errorf("failed to recycle expired runners in pool %s: %w", pool.ID, err)
+	}
+
+	existingInstances, err = r.trimRunnersAboveMax(pool, existingInstances)
+	if err != nil {
+		return fmt.Errorf("failed to trim runners of pool %s: %w", pool.ID, err)
 	}
 
+	idleTimeout := pool.GetIdleTimeout()
 	idleWorkers := []params.Instance{}
+	warmWorkers := []params.Instance{}
 	for _, inst := range existingInstances {
-		// Idle runners that have been spawned and are still idle after 5 minutes, are take into
-		// consideration for scale-down. The 5 minute grace period prevents a situation where a
+		// Idle runners that have been spawned and are still idle after the idle timeout of the pool,
+		// are taken into consideration for scale-down. The grace period prevents a situation where a
 		// "queued" workflow triggers the creation of a new idle runner, and this routine reaps
 		// an idle runner before they have a chance to pick up a job.
-		if inst.RunnerStatus == providerCommon.RunnerIdle && inst.Status == providerCommon.InstanceRunning && time.Since(inst.UpdatedAt).Minutes() > 2 {
+		if inst.RunnerStatus == providerCommon.RunnerIdle && inst.Status == providerCommon.InstanceRunning && time.Since(inst.UpdatedAt) > idleTimeout {
 			idleWorkers = append(idleWorkers, inst)
 		}
+		if isWarmInstance(inst) {
+			warmWorkers = append(warmWorkers, inst)
+		}
+	}
+
+	// Remove warm runners we no longer need. This happens when warm_runners is lowered
+	// on a pool.
+	for idx, inst := range warmWorkers {
+		if idx < int(pool.WarmRunners) || inst.Status != providerCommon.InstanceStopped {
+			continue
+		}
+		if !r.keyMux.TryLock(inst.Name) {
+			r.log("failed to acquire lock for instance %s", inst.Name)
+			continue
+		}
+		err := r.removeRunner(inst, fmt.Sprintf("pool needs only %d warm runners", pool.WarmRunners))
+		r.keyMux.Unlock(inst.Name, false)
+		if err != nil {
+			return fmt.Errorf("failed to delete instance %s: %w", inst.ID, err)
+		}
 	}
 
 	if len(idleWorkers) == 0 {
 		return nil
 	}
+	// Scale down runners created from an older config revision of the pool first.
+	sort.SliceStable(idleWorkers, func(i, j int) bool {
+		return isStaleInstance(pool, idleWorkers[i]) && !isStaleInstance(pool, idleWorkers[j])
+	})
 
 	surplus := float64(len(idleWorkers) - int(pool.MinIdleRunners))
 
@@ -954,13 +1174,16 @@ func (r *basePoolManager) scaleDownOnePool(ctx context.Context, pool params.Pool
 		return nil
 	}
 
-	scaleDownFactor := 0.5 // could be configurable
 	numScaleDown := int(math.Ceil(surplus * scaleDownFactor))
 
 	if numScaleDown <= 0 || numScaleDown > len(idleWorkers) {
 		return fmt.Errorf("invalid number of instances to scale down: %v, check your scaleDownFactor: %v", numScaleDown, scaleDownFactor)
 	}
 
+	// Idle runners that would otherwise be removed are stopped instead, until we have
+	// the number of warm runners requested by the pool.
+	warmDeficit := int(pool.WarmRunners) - len(warmWorkers)
+
 	g, _ := errgroup.WithContext(ctx)
 
 	for _, instanceToDelete := range idleWorkers[:numScaleDown] {
@@ -973,9 +1196,21 @@ func (r *basePoolManager) scaleDownOnePool(ctx context.Context, pool params.Pool
 		}
 		defer r.keyMux.Unlock(instanceToDelete.Name, false)
 
+		if warmDeficit > 0 && r.canKeepWarm(pool, instanceToDelete) {
+			warmDeficit--
+			g.Go(func() error {
+				r.log("stopping idle worker %s from pool %s", instanceToDelete.Name, pool.ID)
+				if err := r.stopIdleRunner(ctx, pool, instanceToDelete); err != nil {
+					return fmt.Errorf("failed to stop instance %s: %w", instanceToDelete.ID, err)
+				}
+				return nil
+			})
+			continue
+		}
+
 		g.Go(func() error {
-			r.log("scaling down idle worker %s from pool %s\n", instanceToDelete.Name, pool.ID)
-			if err := r.ForceDeleteRunner(instanceToDelete); err != nil {
+			reason := fmt.Sprintf("scaling down, runner was idle for more than %s", idleTimeout)
+			if err := r.removeRunner(instanceToDelete, reason); err != nil {
 				return fmt.Errorf("failed to delete instance %s: %w", instanceToDelete.ID, err)
 			}
 			return nil
@@ -988,10 +1223,76 @@ func (r *basePoolManager) scaleDownOnePool(ctx context.Context, pool params.Pool
 	return nil
 }
 
+// trimRunnersAboveMax removes idle runners while the pool has more runners than its max
+// runners. This happens when a scaling schedule lowers max runners for a time window, or
+// when max runners is lowered on the pool. Runners created from an older config revision
+// of the pool and older runners are removed first. Runners that are running a job are left
+// alone. The instances that were not removed are returned.
+func (r *basePoolManager) trimRunnersAboveMax(pool params.Pool, instances []params.Instance) ([]params.Instance, error) {
+	count := 0
+	idle := []params.Instance{}
+	for _, inst := range instances {
+		switch inst.Status {
+		case providerCommon.InstancePendingDelete, providerCommon.InstanceDeleting:
+			continue
+		case providerCommon.InstanceRunning, providerCommon.InstanceStopped:
+			if inst.RunnerStatus == providerCommon.RunnerIdle {
+				idle = append(idle, inst)
+			}
+		}
+		count++
+	}
+
+	surplus := count - int(pool.MaxRunners)
+	if surplus <= 0 {
+		return instances, nil
+	}
+
+	sort.SliceStable(idle, func(i, j int) bool {
+		iStale, jStale := isStaleInstance(pool, idle[i]), isStaleInstance(pool, idle[j])
+		if iStale != jStale {
+			return iStale
+		}
+		return idle[i].CreatedAt.Before(idle[j].CreatedAt)
+	})
+
+	removed := map[string]bool{}
+	for _, inst := range idle {
+		if len(removed) >= surplus {
+			break
+		}
+		if !r.keyMux.TryLock(inst.Name) {
+			r.log("failed to acquire lock for instance %s", inst.Name)
+			continue
+		}
+		err := r.removeRunner(inst, fmt.Sprintf("pool is above its max runners (%d)", pool.MaxRunners))
+		r.keyMux.Unlock(inst.Name, false)
+		if err != nil {
+			return nil, fmt.Errorf("failed to delete instance %s: %w", inst.ID, err)
+		}
+		removed[inst.Name] = true
+	}
+
+	remaining := []params.Instance{}
+	for _, inst := range instances {
+		if !removed[inst.Name] {
+			remaining = append(remaining, inst)
+		}
+	}
+	return remaining, nil
+}
+
 func (r *basePoolManager) addRunnerToPool(pool params.Pool, aditionalLabels []string) error {
 	if !pool.Enabled {
 		return fmt.Errorf("pool %s is disabled", pool.ID)
 	}
+	if r.isDraining(pool) {
+		return fmt.Errorf("pool %s is draining", pool.ID)
+	}
+	if r.isCircuitBreakerOpen(pool) {
+		return fmt.Errorf("circuit breaker of pool %s is open: %s", pool.ID, pool.CircuitBreaker.FailureReason)
+	}
+	pool = pool.WithScalingSchedule(time.Now())
 
 	poolInstanceCount, err := r.store.PoolInstanceCount(r.ctx, pool.ID)
 	if err != nil {
@@ -1008,8 +1309,202 @@ func (r *basePoolManager) addRunnerToPool(pool params.Pool, aditionalLabels []st
 	return nil
 }
 
+// isWarmInstance returns true if the instance is an idle runner that was stopped (or is
+// in the process of being stopped) by the pool manager, and which will be started when
+// a new job is queued.
+func isWarmInstance(inst params.Instance) bool {
+	if inst.RunnerStatus != providerCommon.RunnerIdle {
+		return false
+	}
+	switch inst.Status {
+	case providerCommon.InstanceStopped, providerCommon.InstanceStopping:
+		return true
+	}
+	return false
+}
+
+// stopIdleRunner stops an idle runner, keeping it in the pool as a warm runner.
+func (r *basePoolManager) stopIdleRunner(ctx context.Context, pool params.Pool, instance params.Instance) error {
+	provider, err := r.instanceProvider(pool, instance)
+	if err != nil {
+		return err
+	}
+
+	if _, err := r.setInstanceStatus(instance.Name, providerCommon.InstanceStopping, nil); err != nil {
+		return errors.Wrap(err, "updating runner")
+	}
+
+	if err := provider.Stop(ctx, instance.ProviderID, false); err != nil {
+		// The runner is left running and will be considered again on the next scale down.
+		if _, statusErr := r.setInstanceStatus(instance.Name, providerCommon.InstanceRunning, nil); statusErr != nil {
+			r.log("failed to update runner %s status: %s", instance.Name, statusErr)
+		}
+		return errors.Wrapf(err, "stopping instance %s", instance.ProviderID)
+	}
+
+	if _, err := r.setInstanceStatus(instance.Name, providerCommon.InstanceStopped, nil); err != nil {
+		return errors.Wrap(err, "updating runner")
+	}
+	return nil
+}
+
+// startWarmRunner starts one of the warm runners of a pool. It returns false if the pool
+// has no warm runner that could be started.
+func (r *basePoolManager) startWarmRunner(pool params.Pool) (bool, error) {
+	if !pool.Enabled || r.isDraining(pool) {
+		return false, nil
+	}
+
+	existingInstances, err := r.store.ListPoolInstances(r.ctx, pool.ID)
+	if err != nil {
+		return false, errors.Wrap(err, "fetching pool instances")
+	}
+
+	for _, inst := range existingInstances {
+		if !isWarmInstance(inst) || inst.Status != providerCommon.InstanceStopped {
+			continue
+		}
+		if r.isProviderDegraded(pool.InstanceProviderName(inst)) {
+			continue
+		}
+
+		if !r.keyMux.TryLock(inst.Name) {
+			r.log("failed to acquire lock for instance %s", inst.Name)
+			continue
+		}
+		err := r.startStoppedInstance(pool, inst.Name)
+		r.keyMux.Unlock(inst.Name, false)
+		if err != nil {
+			r.log("failed to start warm runner %s: %s", inst.Name, err)
+			continue
+		}
+		return true, nil
+	}
+	return false, nil
+}
+
+// startStoppedInstance starts a warm runner. The caller must hold the lock for the instance.
+func (r *basePoolManager) startStoppedInstance(pool params.Pool, instanceName string) error {
+	// The instance may have changed since it was listed.
+	instance, err := r.fetchInstance(instanceName)
+	if err != nil {
+		return errors.Wrap(err, "fetching instance")
+	}
+	if !isWarmInstance(instance) || instance.Status != providerCommon.InstanceStopped {
+		return fmt.Errorf("instance %s is no longer a warm runner", instance.Name)
+	}
+
+	provider, err := r.instanceProvider(pool, instance)
+	if err != nil {
+		return err
+	}
+
+	if _, err := r.setInstanceStatus(instance.Name, providerCommon.InstanceStarting, nil); err != nil {
+		return errors.Wrap(err, "updating runner")
+	}
+
+	if err := provider.Start(r.ctx, instance.ProviderID); err != nil {
+		// We could not start the warm runner. Remove it and let the pool replace it.
+		if deleteErr := r.removeRunner(instance, fmt.Sprintf("failed to start warm runner: %s", err)); deleteErr != nil {
+			r.log("failed to remove runner %s: %s", instance.Name, deleteErr)
+		}
+		return errors.Wrapf(err, "starting instance %s", instance.ProviderID)
+	}
+
+	if _, err := r.setInstanceStatus(instance.Name, providerCommon.InstanceRunning, nil); err != nil {
+		return errors.Wrap(err, "updating runner")
+	}
+	return nil
+}
+
+// reconcileWarmRunnersForOnePool looks at the instances of a pool that have been stopping
+// or starting for longer than warmRunnerTransitionTimeout. This happens when garm is restarted
+// while it waits for a provider to stop or start an instance. The status of the instance is
+// set to the one reported by the provider. Instances the provider did not finish stopping or
+// starting are removed, and the pool replaces them.
+func (r *basePoolManager) reconcileWarmRunnersForOnePool(pool params.Pool) error {
+	existingInstances, err := r.store.ListPoolInstances(r.ctx, pool.ID)
+	if err != nil {
+		return fmt.Errorf("failed to list instances for pool %s: %w", pool.ID, err)
+	}
+
+	for _, inst := range existingInstances {
+		if inst.Status != providerCommon.InstanceStopping && inst.Status != providerCommon.InstanceStarting {
+			continue
+		}
+		if time.Since(inst.UpdatedAt) < warmRunnerTransitionTimeout {
+			continue
+		}
+		// The lock is held while garm waits for the provider. If we can't get it, the
+		// instance is not stuck.
+		if !r.keyMux.TryLock(inst.Name) {
+			continue
+		}
+		err := r.reconcileWarmRunnerTransition(pool, inst)
+		r.keyMux.Unlock(inst.Name, false)
+		if err != nil {
+			r.log("failed to reconcile instance %s: %s", inst.Name, err)
+		}
+	}
+	return nil
+}
+
+// reconcileWarmRunnerTransition sets the status of an instance stuck stopping or starting to
+// the status reported by the provider. The caller must hold the lock for the instance.
+func (r *basePoolManager) reconcileWarmRunnerTransition(pool params.Pool, instance params.Instance) error {
+	provider, err := r.instanceProvider(pool, instance)
+	if err != nil {
+		return err
+	}
+
+	reason := fmt.Sprintf("instance was %s for more than %s", instance.Status, warmRunnerTransitionTimeout)
+	providerInstance, err := provider.GetInstance(r.ctx, instance.ProviderID)
+	if err != nil {
+		if !errors.Is(err, runnerErrors.ErrNotFound) {
+			return errors.Wrapf(err, "fetching instance %s", instance.ProviderID)
+		}
+		return r.removeRunner(instance, reason)
+	}
+
+	switch providerInstance.Status {
+	case providerCommon.InstanceStopped, providerCommon.InstanceRunning:
+		r.log("instance %s was %s for more than %s, provider reports it %s",
+			instance.Name, instance.Status, warmRunnerTransitionTimeout, providerInstance.Status)
+		if _, err := r.setInstanceStatus(instance.Name, providerInstance.Status, nil); err != nil {
+			return errors.Wrap(err, "updating runner")
+		}
+		return nil
+	default:
+		return r.removeRunner(instance, reason)
+	}
+}
+
+func (r *basePoolManager) reconcileWarmRunners() error {
+	pools, err := r.helper.ListPools()
+	if err != nil {
+		return fmt.Errorf("error listing pools: %w", err)
+	}
+	for _, pool := range pools {
+		if err := r.reconcileWarmRunnersForOnePool(pool); err != nil {
+			r.log("failed to reconcile warm runners for pool %s: %s", pool.ID, err)
+		}
+	}
+	return nil
+}
+
 func (r *basePoolManager) ensureIdleRunnersForOnePool(pool params.Pool) error {
-	if !pool.Enabled || pool.MinIdleRunners == 0 {
+	pool = r.scalingTargets(pool)
+	if !pool.Enabled || (pool.MinIdleRunners == 0 && pool.WarmRunners == 0) {
+		return nil
+	}
+
+	if r.isDraining(pool) {
+		r.log("pool %s is draining, skipping idle worker creation", pool.ID)
+		return nil
+	}
+
+	if r.isCircuitBreakerOpen(pool) {
+		r.log("circuit breaker of pool %s is open, skipping idle worker creation", pool.ID)
 		return nil
 	}
 
@@ -1025,16 +1520,34 @@ func (r *basePoolManager) ensureIdleRunnersForOnePool(pool params.Pool) error {
 	}
 
 	idleOrPendingWorkers := []params.Instance{}
+	warmWorkers := []params.Instance{}
 	for _, inst := range existingInstances {
+		if isWarmInstance(inst) {
+			warmWorkers = append(warmWorkers, inst)
+			continue
+		}
 		if inst.RunnerStatus != providerCommon.RunnerActive && inst.RunnerStatus != providerCommon.RunnerTerminated {
 			idleOrPendingWorkers = append(idleOrPendingWorkers, inst)
 		}
 	}
 
+	// Warm runners are created as regular idle runners. Once they are idle for long enough,
+	// scale down will stop them instead of removing them, so any idle runners above
+	// min_idle_runners count towards the warm runners we still need.
+	idleDelta := int(pool.MinIdleRunners) - len(idleOrPendingWorkers)
+	warmDelta := int(pool.WarmRunners) - len(warmWorkers)
+	if idleDelta < 0 {
+		warmDelta += idleDelta
+		idleDelta = 0
+	}
+	if warmDelta < 0 {
+		warmDelta = 0
+	}
+
 	var required int
-	if len(idleOrPendingWorkers) < int(pool.MinIdleRunners) {
+	if idleDelta+warmDelta > 0 {
 		// get the needed delta.
-		required = int(pool.MinIdleRunners) - len(idleOrPendingWorkers)
+		required = idleDelta + warmDelta
 
 		projectedInstanceCount := len(existingInstances) + required
 		if uint(projectedInstanceCount) > pool.MaxRunners {
@@ -1054,7 +1567,8 @@ func (r *basePoolManager) ensureIdleRunnersForOnePool(pool params.Pool) error {
 }
 
 func (r *basePoolManager) retryFailedInstancesForOnePool(ctx context.Context, pool params.Pool) error {
-	if !pool.Enabled {
+	// Failed instances of a draining pool are removed instead of retried.
+	if !pool.Enabled || r.isDraining(pool) || r.isCircuitBreakerOpen(pool) {
 		return nil
 	}
 	r.log("running retry failed instances for pool %s", pool.ID)
@@ -1064,12 +1578,19 @@ func (r *basePoolManager) retryFailedInstancesForOnePool(ctx context.Context, po
 		return fmt.Errorf("failed to list instances for pool %s: %w", pool.ID, err)
 	}
 
+	now := time.Now().UTC()
 	g, errCtx := errgroup.WithContext(ctx)
 	for _, instance := range existingInstances {
 		if instance.Status != providerCommon.InstanceError {
 			continue
 		}
-		if instance.CreateAttempt >= maxCreateAttempts {
+		if !shouldRetryFailedInstance(instance, now) {
+			// The instance used up its create attempts, failed permanently, or its
+			// providers are out of quota and it is too early to try again.
+			continue
+		}
+		if r.isProviderDegraded(pool.InstanceProviderName(instance)) {
+			// The failed instance can't be removed from a degraded provider.
 			continue
 		}
 
@@ -1098,14 +1619,24 @@ func (r *basePoolManager) retryFailedInstancesForOnePool(ctx context.Context, po
 				return err
 			}
 
+			// The retry starts over from the first provider of the pool that has capacity. If
+			// none has, the instance stays on the provider it was last placed on.
+			if _, err := r.reserveProvider(pool, instance, pool.Providers(), 0); err != nil {
+				r.log("failed to select provider for instance %s: %s", instance.Name, err)
+			}
+
 			// TODO(gabriel-samfira): Incrementing CreateAttempt should be done within a transaction.
 			// It's fairly safe to do here (for now), as there should be no other code path that updates
 			// an instance in this state.
 			var tokenFetched bool = false
 			updateParams := params.UpdateInstanceParams{
-				CreateAttempt: instance.CreateAttempt + 1,
-				TokenFetched:  &tokenFetched,
-				Status:        providerCommon.InstancePendingCreate,
+				TokenFetched: &tokenFetched,
+				Status:       providerCommon.InstancePendingCreate,
+			}
+			if isQuotaFailure(instance) {
+				updateParams.QuotaAttempt = instance.QuotaAttempt + 1
+			} else {
+				updateParams.CreateAttempt = instance.CreateAttempt + 1
 			}
 			r.log("queueing previously failed instance %s for retry", instance.Name)
 			// Set instance to pending create and wait for retry.
@@ -1189,9 +1720,9 @@ func (r *basePoolManager) deleteInstanceFromProvider(ctx context.Context, instan
 		return errors.Wrap(err, "fetching pool")
 	}
 
-	provider, ok := r.providers[pool.ProviderName]
-	if !ok {
-		return fmt.Errorf("unknown provider %s for pool %s", pool.ProviderName, pool.ID)
+	provider, err := r.instanceProvider(pool, instance)
+	if err != nil {
+		return err
 	}
 
 	identifier := instance.ProviderID
@@ -1221,6 +1752,11 @@ func (r *basePoolManager) deletePendingInstances() error {
 			continue
 		}
 
+		if r.isInstanceProviderDegraded(instance) {
+			// Retry once the provider recovers.
+			continue
+		}
+
 		r.log("removing instance %s in pool %s", instance.Name, instance.PoolID)
 		lockAcquired := r.keyMux.TryLock(instance.Name)
 		if !lockAcquired {
@@ -1289,6 +1825,17 @@ func (r *basePoolManager) addPendingInstances() error {
 			continue
 		}
 
+		if r.isInstanceProviderDegraded(instance) {
+			// Move the instance to another provider of its pool, if one is available.
+			// Otherwise, it is created once the provider recovers.
+			moved, err := r.movePendingInstance(instance)
+			if err != nil {
+				r.keyMux.Unlock(instance.Name, false)
+				continue
+			}
+			instance = moved
+		}
+
 		// Set the instance to "creating" before launching the goroutine. This will ensure that addPendingInstances()
 		// won't attempt to create the runner a second time.
 		if _, err := r.setInstanceStatus(instance.Name, providerCommon.InstanceCreating, nil); err != nil {
@@ -1303,12 +1850,13 @@ func (r *basePoolManager) addPendingInstances() error {
 			defer r.keyMux.Unlock(instance.Name, false)
 			r.log("creating instance %s in pool %s", instance.Name, instance.PoolID)
 			if err := r.addInstanceToProvider(instance); err != nil {
-				r.log("failed to add instance to provider: %s", err)
-				errAsBytes := []byte(err.Error())
-				if _, err := r.setInstanceStatus(instance.Name, providerCommon.InstanceError, errAsBytes); err != nil {
-					r.log("failed to update runner %s status: %s", instance.Name, err)
+				r.log("failed to create instance %s in provider: %s", instance.Name, err)
+				// Running out of quota says nothing about the health of the pool, so it
+				// does not count towards its circuit breaker.
+				if !faultFromError(err).QuotaExceeded {
+					r.recordProvisioningFailure(instance.PoolID, fmt.Sprintf("failed to create instance %s: %s", instance.Name, err))
 				}
-				r.log("failed to create instance in provider: %s", err)
+				r.setInstanceFailed(instance, err)
 			}
 		}(instance)
 	}
@@ -1367,14 +1915,19 @@ func (r *basePoolManager) cleanupOrphanedRunners() error {
 func (r *basePoolManager) Start() error {
 	r.updateTools() //nolint
 
+	go r.checkWebhook()
 	go r.startLoopForFunction(r.runnerCleanup, common.PoolReapTimeoutInterval, "timeout_reaper", false)
 	go r.startLoopForFunction(r.scaleDown, common.PoolScaleDownInterval, "scale_down", false)
 	go r.startLoopForFunction(r.deletePendingInstances, common.PoolConsilitationInterval, "consolidate[delete_pending]", false)
 	go r.startLoopForFunction(r.addPendingInstances, common.PoolConsilitationInterval, "consolidate[add_pending]", false)
 	go r.startLoopForFunction(r.ensureMinIdleRunners, common.PoolConsilitationInterval, "consolidate[ensure_min_idle]", false)
 	go r.startLoopForFunction(r.retryFailedInstances, common.PoolConsilitationInterval, "consolidate[retry_failed]", false)
+	go r.startLoopForFunction(r.replaceStaleRunners, common.PoolConsilitationInterval, "consolidate[replace_stale]", false)
+	go r.startLoopForFunction(r.reconcileWarmRunners, common.PoolConsilitationInterval, "consolidate[warm_runners]", false)
 	go r.startLoopForFunction(r.updateTools, common.PoolToolUpdateInterval, "update_tools", true)
 	go r.startLoopForFunction(r.consumeQueuedJobs, common.PoolConsilitationInterval, "job_queue_consumer", false)
+	go r.startLoopForFunction(r.pollWorkflowJobs, common.PoolConsilitationInterval, "job_poller", false)
+	go r.startLoopForFunction(r.recordPoolDemand, common.PoolDemandSampleInterval, "demand_recorder", false)
 	return nil
 }
 
@@ -1384,7 +1937,22 @@ func (r *basePoolManager) Stop() error {
 }
 
 func (r *basePoolManager) RefreshState(param params.UpdatePoolStateParams) error {
-	return r.helper.UpdateState(param)
+	if err := r.helper.UpdateState(param); err != nil {
+		return err
+	}
+
+	if param.InternalConfig != nil {
+		r.mux.Lock()
+		r.credsDetails = param.InternalConfig.GithubCredentialsDetails
+		r.mux.Unlock()
+	}
+	return nil
+}
+
+func (r *basePoolManager) githubCredentials() params.GithubCredentials {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	return r.credsDetails
 }
 
 func (r *basePoolManager) WebhookSecret() string {
@@ -1395,10 +1963,204 @@ func (r *basePoolManager) GithubRunnerRegistrationToken() (string, error) {
 	return r.helper.GetGithubRegistrationToken()
 }
 
+func (r *basePoolManager) GithubRunnerJITConfig(instance params.Instance) (string, error) {
+	pool, err := r.helper.GetPoolByID(instance.PoolID)
+	if err != nil {
+		return "", errors.Wrap(err, "fetching pool")
+	}
+
+	jitConfig, err := r.helper.GetJITConfig(instance, r.runnerLabels(pool))
+	if err != nil {
+		return "", errors.Wrap(err, "fetching JIT config")
+	}
+	return jitConfig, nil
+}
+
 func (r *basePoolManager) ID() string {
 	return r.helper.ID()
 }
 
+func hookToParams(hook *github.Hook) params.HookInfo {
+	var hookURL string
+	if val, ok := hook.Config["url"].(string); ok {
+		hookURL = val
+	}
+	var insecureSSL bool
+	if val, ok := hook.Config["insecure_ssl"].(string); ok {
+		insecureSSL = val == "1"
+	}
+	return params.HookInfo{
+		ID:          hook.GetID(),
+		URL:         hookURL,
+		Events:      hook.Events,
+		Active:      hook.GetActive(),
+		InsecureSSL: insecureSSL,
+	}
+}
+
+// entityWebhookURL returns the per-entity webhook URL of this pool manager.
+func (r *basePoolManager) entityWebhookURL() string {
+	return util.EntityWebhookURL(r.helper.GetWebhookURL(), r.helper.ID())
+}
+
+// getGarmHook returns the webhook pointing to the garm webhook URL, if one
+// is defined on the entity. Hooks pointing to the per-entity webhook URL are
+// preferred over hooks pointing to the base webhook URL.
+func (r *basePoolManager) getGarmHook() (*github.Hook, error) {
+	webhookURL := r.helper.GetWebhookURL()
+	if webhookURL == "" {
+		return nil, runnerErrors.NewBadRequestError("webhook_url is not configured")
+	}
+	entityURL := r.entityWebhookURL()
+
+	hooks, err := r.helper.ListHooks()
+	if err != nil {
+		return nil, errors.Wrap(err, "listing hooks")
+	}
+
+	var baseHook *github.Hook
+	for _, hook := range hooks {
+		val, ok := hook.Config["url"].(string)
+		if !ok {
+			continue
+		}
+		switch val {
+		case entityURL:
+			return hook, nil
+		case webhookURL:
+			if baseHook == nil {
+				baseHook = hook
+			}
+		}
+	}
+	if baseHook != nil {
+		return baseHook, nil
+	}
+	return nil, errors.Wrapf(runnerErrors.ErrNotFound, "no webhook pointing to %s was found", entityURL)
+}
+
+// getManagedHook returns the webhook garm installed on the entity. Webhooks that point
+// to garm, but were created by someone else, are never returned.
+func (r *basePoolManager) getManagedHook() (*github.Hook, error) {
+	hookID, err := r.helper.ManagedHookID()
+	if err != nil {
+		return nil, errors.Wrap(err, "fetching managed hook ID")
+	}
+	if hookID == 0 {
+		return nil, errors.Wrapf(runnerErrors.ErrNotFound, "garm does not manage a webhook for %s", r.helper.String())
+	}
+
+	hooks, err := r.helper.ListHooks()
+	if err != nil {
+		return nil, errors.Wrap(err, "listing hooks")
+	}
+	for _, hook := range hooks {
+		if hook.GetID() == hookID {
+			return hook, nil
+		}
+	}
+	return nil, errors.Wrapf(runnerErrors.ErrNotFound, "webhook %d installed by garm was not found", hookID)
+}
+
+// InstallWebhook installs a webhook that garm manages from then on. If garm does not
+// manage a webhook yet, but a webhook pointing to garm already exists, that webhook is
+// taken over, as github does not allow two webhooks with the same URL.
+func (r *basePoolManager) InstallWebhook(param params.InstallWebhookParams) (params.HookInfo, error) {
+	existing, err := r.getManagedHook()
+	if err != nil {
+		if !errors.Is(err, runnerErrors.ErrNotFound) {
+			return params.HookInfo{}, errors.Wrap(err, "fetching hook")
+		}
+		existing, err = r.getGarmHook()
+		if err != nil && !errors.Is(err, runnerErrors.ErrNotFound) {
+			return params.HookInfo{}, errors.Wrap(err, "fetching hook")
+		}
+	}
+
+	insecureSSL := "0"
+	if param.InsecureSSL {
+		insecureSSL = "1"
+	}
+	hook := &github.Hook{
+		Name:   github.String("web"),
+		Active: github.Bool(true),
+		Events: []string{workflowJobEvent, workflowRunEvent},
+		Config: map[string]interface{}{
+			"url":          r.entityWebhookURL(),
+			"content_type": "json",
+			"insecure_ssl": insecureSSL,
+			"secret":       r.helper.WebhookSecret(),
+		},
+	}
+
+	// Reinstalling a hook updates the existing one. This ensures the hook
+	// is active, is subscribed to the events we need, uses the current secret
+	// and points to the per-entity webhook URL.
+	var installed *github.Hook
+	if existing != nil {
+		installed, err = r.helper.EditHook(existing.GetID(), hook)
+	} else {
+		installed, err = r.helper.CreateHook(hook)
+	}
+	if err != nil {
+		return params.HookInfo{}, errors.Wrap(err, "installing hook")
+	}
+	if err := r.helper.SetManagedHookID(installed.GetID()); err != nil {
+		return params.HookInfo{}, errors.Wrap(err, "recording hook")
+	}
+
+	if err := r.helper.PingHook(installed.GetID()); err != nil {
+		r.log("failed to ping hook %d: %s", installed.GetID(), err)
+	}
+	return hookToParams(installed), nil
+}
+
+func (r *basePoolManager) GetWebhookInfo() (params.HookInfo, error) {
+	hook, err := r.getManagedHook()
+	if err != nil {
+		return params.HookInfo{}, errors.Wrap(err, "fetching hook")
+	}
+	return hookToParams(hook), nil
+}
+
+// UninstallWebhook removes the webhook garm installed. Webhooks created by someone
+// else are left untouched.
+func (r *basePoolManager) UninstallWebhook() error {
+	hook, err := r.getManagedHook()
+	if err != nil {
+		return errors.Wrap(err, "fetching hook")
+	}
+
+	if err := r.helper.DeleteHook(hook.GetID()); err != nil {
+		return errors.Wrap(err, "deleting hook")
+	}
+	if err := r.helper.SetManagedHookID(0); err != nil {
+		return errors.Wrap(err, "recording hook")
+	}
+	return nil
+}
+
+// checkWebhook makes sure the webhook installed by garm is still usable. Entities
+// for which garm does not manage a webhook are left untouched.
+func (r *basePoolManager) checkWebhook() {
+	if r.helper.GetWebhookURL() == "" || r.helper.PoolType() == params.EnterprisePool {
+		return
+	}
+
+	hook, err := r.getManagedHook()
+	if err != nil {
+		if !errors.Is(err, runnerErrors.ErrNotFound) {
+			r.log("failed to check webhook: %s", err)
+		}
+		return
+	}
+
+	info := hookToParams(hook)
+	if _, err := r.InstallWebhook(params.InstallWebhookParams{InsecureSSL: info.InsecureSSL}); err != nil {
+		r.log("failed to update webhook %d: %s", info.ID, err)
+	}
+}
+
 func (r *basePoolManager) ForceDeleteRunner(runner params.Instance) error {
 	if !r.managerIsRunning {
 		return runnerErrors.NewConflictError("pool manager is not running for %s", r.helper.String())
@@ -1438,6 +2200,23 @@ func (r *basePoolManager) ForceDeleteRunner(runner params.Instance) error {
 	return nil
 }
 
+// poolLoad returns the ratio between the number of runners in a pool and the max
+// runners the pool may have right now. Pools we can't get a count for are
+// considered full.
+func (r *basePoolManager) poolLoad(pool params.Pool) float64 {
+	pool = pool.WithScalingSchedule(time.Now())
+	if pool.MaxRunners == 0 {
+		return 1
+	}
+
+	count, err := r.store.PoolInstanceCount(r.ctx, pool.ID)
+	if err != nil {
+		r.log("failed to count instances of pool %s: %s", pool.ID, err)
+		return 1
+	}
+	return float64(count) / float64(pool.MaxRunners)
+}
+
 // consumeQueuedJobs will pull all the known jobs from the database and attempt to create a new
 // runner in one of the pools it manages, if it matches the requested labels.
 // This is a best effort attempt to consume queued jobs. We do not have any real way to know which
@@ -1461,13 +2240,33 @@ func (r *basePoolManager) ForceDeleteRunner(runner params.Instance) error {
 // as they do so, new idle runners will be spun up in their stead. New jobs will record in the DB as they come in,
 // so those will trigger the creation of a runner. The jobs we don't know about will be dealt with by the idle runners.
 // Once jobs are consumed, you can set min-idle-runners to 0 again.
+//
+// When several pools match the labels of a job, they are tried in the order given by the
+// pool selection strategy of the entity. The jobs themselves are handled in the order given
+// by the job scheduling strategy of the entity.
 func (r *basePoolManager) consumeQueuedJobs() error {
 	queued, err := r.store.ListEntityJobsByStatus(r.ctx, r.helper.PoolType(), r.helper.ID(), params.JobStatusQueued)
 	if err != nil {
 		return errors.Wrap(err, "listing queued jobs")
 	}
 
-	poolsCache := poolsForTags{}
+	jobs, err := r.entityJobs()
+	if err != nil {
+		return errors.Wrap(err, "listing jobs")
+	}
+
+	if r.helper.JobSchedulingStrategy() == params.JobSchedulingFairShare {
+		instances, err := r.helper.FetchDbInstances()
+		if err != nil {
+			return errors.Wrap(err, "fetching instances")
+		}
+		queued = fairShareOrder(queued, CountRepositoryRunners(instances, jobs))
+	}
+
+	poolsCache := poolsForTags{
+		strategy: r.helper.PoolSelectionStrategy(),
+		poolLoad: r.poolLoad,
+	}
 
 	r.log("found %d queued jobs for %s", len(queued), r.helper.String())
 	for _, job := range queued {
@@ -1542,17 +2341,17 @@ func (r *basePoolManager) consumeQueuedJobs() error {
 			continue
 		}
 
-		poolRR, ok := poolsCache.Get(job.Labels)
+		poolSel, ok := poolsCache.Get(job.Labels)
 		if !ok {
 			potentialPools, err := r.store.FindPoolsMatchingAllTags(r.ctx, r.helper.PoolType(), r.helper.ID(), job.Labels)
 			if err != nil {
 				r.log("error finding pools matching labels: %s", err)
 				continue
 			}
-			poolRR = poolsCache.Add(job.Labels, potentialPools)
+			poolSel = poolsCache.Add(job.Labels, potentialPools)
 		}
 
-		if poolRR.Len() == 0 {
+		if poolSel.Len() == 0 {
 			r.log("could not find pools with labels %s", strings.Join(job.Labels, ","))
 			continue
 		}
@@ -1566,16 +2365,25 @@ func (r *basePoolManager) consumeQueuedJobs() error {
 		jobLabels := []string{
 			fmt.Sprintf("%s%d", jobLabelPrefix, job.ID),
 		}
-		for i := 0; i < poolRR.Len(); i++ {
-			pool, err := poolRR.Next()
+		for _, pool := range poolSel.Order() {
+			if err := r.checkRepositoryQuota(pool, job, jobs); err != nil {
+				r.log("not using pool %s for job %d: %s", pool.ID, job.ID, err)
+				continue
+			}
+
+			started, err := r.startWarmRunner(pool)
 			if err != nil {
-				r.log("could not find a pool to create a runner for job %d: %s", job.ID, err)
+				r.log("could not start a warm runner in pool %s: %s", pool.ID, err)
+			}
+			if started {
+				r.log("a warm runner was started in pool %s as a response to queued job %d", pool.ID, job.ID)
+				runnerCreated = true
 				break
 			}
 
 			r.log("attempting to create a runner in pool %s for job %d", pool.ID, job.ID)
 			if err := r.addRunnerToPool(pool, jobLabels); err != nil {
-				r.log("[PoolRR] could not add runner to pool %s: %s", pool.ID, err)
+				r.log("could not add runner to pool %s: %s", pool.ID, err)
 				continue
 			}
 			r.log("a new runner was added to pool %s as a response to queued job %d", pool.ID, job.ID)
@@ -0,0 +1,618 @@
+// Copyright 2023 Cloudbase Solutions SRL
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package pool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/cloudbase/garm/auth"
+	"github.com/cloudbase/garm/config"
+	"github.com/cloudbase/garm/database"
+	dbCommon "github.com/cloudbase/garm/database/common"
+	runnerErrors "github.com/cloudbase/garm/errors"
+	garmTesting "github.com/cloudbase/garm/internal/testing"
+	"github.com/cloudbase/garm/params"
+	"github.com/cloudbase/garm/runner/common"
+	runnerCommonMocks "github.com/cloudbase/garm/runner/common/mocks"
+	providerCommon "github.com/cloudbase/garm/runner/providers/common"
+	"github.com/cloudbase/garm/util"
+
+	"github.com/google/go-github/v53/github"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// testPoolHelper implements the parts of poolHelper used by the tests, on top of a
+// repository stored in the database. Webhooks and poll targets are kept in memory.
+// Calling any other method panics.
+type testPoolHelper struct {
+	poolHelper
+	store       dbCommon.Store
+	repo        params.Repository
+	hooks       []*github.Hook
+	ghcli       common.GithubClient
+	pollTargets []*github.Repository
+}
+
+func (h *testPoolHelper) GithubCLI() common.GithubClient {
+	return h.ghcli
+}
+
+func (h *testPoolHelper) ListPollTargets() ([]*github.Repository, error) {
+	return h.pollTargets, nil
+}
+
+func (h *testPoolHelper) GetPoolByID(poolID string) (params.Pool, error) {
+	return h.store.GetRepositoryPool(auth.GetAdminContext(), h.repo.ID, poolID)
+}
+
+func (h *testPoolHelper) ListPools() ([]params.Pool, error) {
+	return h.store.ListRepoPools(auth.GetAdminContext(), h.repo.ID)
+}
+
+func (h *testPoolHelper) ValidateOwner(job params.WorkflowJob) error {
+	return nil
+}
+
+func (h *testPoolHelper) PoolType() params.PoolType {
+	return params.RepositoryPool
+}
+
+func (h *testPoolHelper) ID() string {
+	return h.repo.ID
+}
+
+func (h *testPoolHelper) String() string {
+	return fmt.Sprintf("%s/%s", h.repo.Owner, h.repo.Name)
+}
+
+func (h *testPoolHelper) GetCallbackURL() string {
+	return "https://garm.example.com/api/v1/callbacks"
+}
+
+func (h *testPoolHelper) GetMetadataURL() string {
+	return "https://garm.example.com/api/v1/metadata"
+}
+
+func (h *testPoolHelper) GetWebhookURL() string {
+	return "https://garm.example.com/webhooks"
+}
+
+func (h *testPoolHelper) WebhookSecret() string {
+	return h.repo.WebhookSecret
+}
+
+func (h *testPoolHelper) ListHooks() ([]*github.Hook, error) {
+	return h.hooks, nil
+}
+
+func (h *testPoolHelper) CreateHook(hook *github.Hook) (*github.Hook, error) {
+	hook.ID = github.Int64(int64(len(h.hooks) + 1))
+	h.hooks = append(h.hooks, hook)
+	return hook, nil
+}
+
+func (h *testPoolHelper) EditHook(id int64, hook *github.Hook) (*github.Hook, error) {
+	for idx, existing := range h.hooks {
+		if existing.GetID() == id {
+			hook.ID = github.Int64(id)
+			h.hooks[idx] = hook
+			return hook, nil
+		}
+	}
+	return nil, runnerErrors.ErrNotFound
+}
+
+func (h *testPoolHelper) DeleteHook(id int64) error {
+	for idx, existing := range h.hooks {
+		if existing.GetID() == id {
+			h.hooks = append(h.hooks[:idx], h.hooks[idx+1:]...)
+			return nil
+		}
+	}
+	return runnerErrors.ErrNotFound
+}
+
+func (h *testPoolHelper) PingHook(id int64) error {
+	return nil
+}
+
+func (h *testPoolHelper) ManagedHookID() (int64, error) {
+	repo, err := h.store.GetRepositoryByID(auth.GetAdminContext(), h.repo.ID)
+	if err != nil {
+		return 0, err
+	}
+	return repo.ManagedHookID, nil
+}
+
+func (h *testPoolHelper) SetManagedHookID(hookID int64) error {
+	return h.store.SetRepositoryManagedHookID(auth.GetAdminContext(), h.repo.ID, hookID)
+}
+
+type PoolManagerTestFixtures struct {
+	AdminContext         context.Context
+	DBConfig             config.Database
+	Store                dbCommon.Store
+	Repo                 params.Repository
+	Providers            map[string]*runnerCommonMocks.Provider
+	ProviderCapabilities map[string]params.ProviderCapabilities
+	ProviderHealth       map[string]params.ProviderHealth
+	CreatePoolParams     params.CreatePoolParams
+}
+
+type PoolManagerTestSuite struct {
+	suite.Suite
+	Fixtures    *PoolManagerTestFixtures
+	PoolManager *basePoolManager
+}
+
+func (s *PoolManagerTestSuite) SetupTest() {
+	adminCtx := auth.GetAdminContext()
+
+	// create testing sqlite database
+	dbCfg := garmTesting.GetTestSqliteDBConfig(s.T())
+	db, err := database.NewDatabase(adminCtx, dbCfg)
+	if err != nil {
+		s.FailNow(fmt.Sprintf("failed to create db connection: %s", err))
+	}
+
+	repo, err := db.CreateRepository(adminCtx, "https://github.com", "test-owner", "test-repo", "test-creds", "test-webhook-secret")
+	if err != nil {
+		s.FailNow(fmt.Sprintf("failed to create database object (test-repo): %s", err))
+	}
+
+	// setup test fixtures
+	fixtures := &PoolManagerTestFixtures{
+		AdminContext: adminCtx,
+		DBConfig:     dbCfg,
+		Store:        db,
+		Repo:         repo,
+		Providers: map[string]*runnerCommonMocks.Provider{
+			"test-provider":     runnerCommonMocks.NewProvider(s.T()),
+			"fallback-provider": runnerCommonMocks.NewProvider(s.T()),
+		},
+		ProviderCapabilities: map[string]params.ProviderCapabilities{},
+		ProviderHealth:       map[string]params.ProviderHealth{},
+		CreatePoolParams: params.CreatePoolParams{
+			ProviderName: "test-provider",
+			MaxRunners:   10,
+			Image:        "test-image",
+			Flavor:       "test-flavor",
+			OSType:       "linux",
+			OSArch:       "amd64",
+			Tags:         []string{"self-hosted", "linux"},
+			Enabled:      true,
+		},
+	}
+	providers := map[string]common.Provider{}
+	for name, provider := range fixtures.Providers {
+		name := name
+		provider.On("AsParams").Return(func() params.Provider {
+			return params.Provider{
+				Name:         name,
+				Capabilities: fixtures.ProviderCapabilities[name],
+				Health:       fixtures.ProviderHealth[name],
+			}
+		}).Maybe()
+		providers[name] = provider
+	}
+	s.Fixtures = fixtures
+
+	// setup test pool manager
+	s.PoolManager = &basePoolManager{
+		ctx:              adminCtx,
+		controllerID:     "test-controller",
+		store:            db,
+		providers:        providers,
+		helper:           &testPoolHelper{store: db, repo: repo},
+		managerIsRunning: true,
+		keyMux:           &keyMutex{},
+	}
+}
+
+func (s *PoolManagerTestSuite) createPool(createParams params.CreatePoolParams) params.Pool {
+	pool, err := s.Fixtures.Store.CreateRepositoryPool(s.Fixtures.AdminContext, s.Fixtures.Repo.ID, createParams)
+	if err != nil {
+		s.FailNow(fmt.Sprintf("failed to create pool: %s", err))
+	}
+	return pool
+}
+
+// createInstances creates count instances in a pool, on the given provider, in the given state.
+func (s *PoolManagerTestSuite) createInstances(pool params.Pool, providerName string, count int, status providerCommon.InstanceStatus, runnerStatus providerCommon.RunnerStatus) []params.Instance {
+	instances := []params.Instance{}
+	for i := 0; i < count; i++ {
+		instance, err := s.Fixtures.Store.CreateInstance(s.Fixtures.AdminContext, pool.ID, params.CreateInstanceParams{
+			Name:         fmt.Sprintf("%s-%s-%s-%s-%d", pool.ID[:8], providerName, status, runnerStatus, i),
+			OSType:       pool.OSType,
+			Status:       status,
+			RunnerStatus: runnerStatus,
+			ProviderName: providerName,
+		})
+		if err != nil {
+			s.FailNow(fmt.Sprintf("failed to create instance: %s", err))
+		}
+		instances = append(instances, instance)
+	}
+	return instances
+}
+
+// backdateInstances moves the creation and last update of the given instances into the past.
+func (s *PoolManagerTestSuite) backdateInstances(instances []params.Instance, age time.Duration) {
+	connURI, err := s.Fixtures.DBConfig.SQLite.ConnectionString()
+	s.Require().Nil(err)
+	conn, err := gorm.Open(sqlite.Open(connURI), &gorm.Config{})
+	s.Require().Nil(err)
+	sqlDB, err := conn.DB()
+	s.Require().Nil(err)
+	defer sqlDB.Close()
+
+	for _, instance := range instances {
+		when := time.Now().UTC().Add(-age)
+		err := conn.Exec("UPDATE instances SET created_at = ?, updated_at = ? WHERE name = ?", when, when, instance.Name).Error
+		s.Require().Nil(err)
+	}
+}
+
+// countInstances returns the number of instances of a pool in each status.
+func (s *PoolManagerTestSuite) countInstances(poolID string) map[providerCommon.InstanceStatus]int {
+	instances, err := s.Fixtures.Store.ListPoolInstances(s.Fixtures.AdminContext, poolID)
+	s.Require().Nil(err)
+
+	counts := map[providerCommon.InstanceStatus]int{}
+	for _, instance := range instances {
+		counts[instance.Status]++
+	}
+	return counts
+}
+
+func (s *PoolManagerTestSuite) workflowJob(action string, runnerName string) params.WorkflowJob {
+	job := params.WorkflowJob{Action: action}
+	job.WorkflowJob.ID = 1
+	job.WorkflowJob.RunID = 1
+	job.WorkflowJob.Status = action
+	job.WorkflowJob.Labels = s.Fixtures.CreatePoolParams.Tags
+	job.WorkflowJob.RunnerName = runnerName
+	job.Repository.Name = s.Fixtures.Repo.Name
+	job.Repository.Owner.Login = s.Fixtures.Repo.Owner
+	return job
+}
+
+func (s *PoolManagerTestSuite) TestHandleWorkflowJobIgnoresStaleEvents() {
+	pool := s.createPool(s.Fixtures.CreatePoolParams)
+	instance := s.createInstances(pool, pool.ProviderName, 1, providerCommon.InstanceRunning, providerCommon.RunnerIdle)[0]
+
+	completed := s.workflowJob("completed", instance.Name)
+	completed.WorkflowJob.Conclusion = "success"
+	err := s.PoolManager.HandleWorkflowJob(completed)
+	s.Require().Nil(err)
+
+	// The in_progress event of the job arrives after the job completed.
+	err = s.PoolManager.HandleWorkflowJob(s.workflowJob("in_progress", instance.Name))
+	s.Require().Nil(err)
+
+	instance, err = s.Fixtures.Store.GetInstanceByName(s.Fixtures.AdminContext, instance.Name)
+	s.Require().Nil(err)
+	s.Require().Equal(providerCommon.InstancePendingDelete, instance.Status)
+	s.Require().Equal(providerCommon.RunnerTerminated, instance.RunnerStatus)
+
+	job, err := s.Fixtures.Store.GetJobByID(s.Fixtures.AdminContext, completed.WorkflowJob.ID)
+	s.Require().Nil(err)
+	s.Require().Equal("completed", job.Action)
+	s.Require().Equal("success", job.Conclusion)
+}
+
+func (s *PoolManagerTestSuite) TestHandleWorkflowJobWhileAnotherEventIsHandled() {
+	job := s.workflowJob("queued", "")
+	s.Require().True(s.PoolManager.keyMux.TryLock("job:1"))
+	defer s.PoolManager.keyMux.Unlock("job:1", true)
+
+	err := s.PoolManager.HandleWorkflowJob(job)
+	s.Require().NotNil(err)
+}
+
+// userHook returns a webhook pointing to garm that was not created by garm.
+func (s *PoolManagerTestSuite) userHook() *github.Hook {
+	return &github.Hook{
+		Active: github.Bool(false),
+		Events: []string{"push"},
+		Config: map[string]interface{}{
+			"url": util.EntityWebhookURL("https://garm.example.com/webhooks", s.Fixtures.Repo.ID),
+		},
+	}
+}
+
+func (s *PoolManagerTestSuite) TestInstallWebhookRecordsManagedHook() {
+	info, err := s.PoolManager.InstallWebhook(params.InstallWebhookParams{})
+	s.Require().Nil(err)
+
+	repo, err := s.Fixtures.Store.GetRepositoryByID(s.Fixtures.AdminContext, s.Fixtures.Repo.ID)
+	s.Require().Nil(err)
+	s.Require().Equal(info.ID, repo.ManagedHookID)
+	s.Require().Equal(util.EntityWebhookURL("https://garm.example.com/webhooks", s.Fixtures.Repo.ID), info.URL)
+}
+
+func (s *PoolManagerTestSuite) TestCheckWebhookIgnoresUnmanagedHook() {
+	helper := s.PoolManager.helper.(*testPoolHelper)
+	hook, err := helper.CreateHook(s.userHook())
+	s.Require().Nil(err)
+
+	s.PoolManager.checkWebhook()
+
+	hooks, err := helper.ListHooks()
+	s.Require().Nil(err)
+	s.Require().Len(hooks, 1)
+	s.Require().Equal(hook, hooks[0])
+	s.Require().False(hooks[0].GetActive())
+}
+
+func (s *PoolManagerTestSuite) TestCheckWebhookUpdatesManagedHook() {
+	_, err := s.PoolManager.InstallWebhook(params.InstallWebhookParams{})
+	s.Require().Nil(err)
+	helper := s.PoolManager.helper.(*testPoolHelper)
+	helper.hooks[0].Active = github.Bool(false)
+
+	s.PoolManager.checkWebhook()
+
+	s.Require().Len(helper.hooks, 1)
+	s.Require().True(helper.hooks[0].GetActive())
+}
+
+func (s *PoolManagerTestSuite) TestUninstallWebhookIgnoresUnmanagedHook() {
+	helper := s.PoolManager.helper.(*testPoolHelper)
+	_, err := helper.CreateHook(s.userHook())
+	s.Require().Nil(err)
+
+	err = s.PoolManager.UninstallWebhook()
+	s.Require().True(errors.Is(err, runnerErrors.ErrNotFound))
+	s.Require().Len(helper.hooks, 1)
+}
+
+func (s *PoolManagerTestSuite) TestUninstallWebhookRemovesManagedHook() {
+	_, err := s.PoolManager.InstallWebhook(params.InstallWebhookParams{})
+	s.Require().Nil(err)
+
+	err = s.PoolManager.UninstallWebhook()
+	s.Require().Nil(err)
+	s.Require().Len(s.PoolManager.helper.(*testPoolHelper).hooks, 0)
+
+	repo, err := s.Fixtures.Store.GetRepositoryByID(s.Fixtures.AdminContext, s.Fixtures.Repo.ID)
+	s.Require().Nil(err)
+	s.Require().Equal(int64(0), repo.ManagedHookID)
+}
+
+func (s *PoolManagerTestSuite) TestScaleDownStopsIdleRunnersToKeepWarm() {
+	s.Fixtures.ProviderCapabilities["test-provider"] = params.ProviderCapabilities{StartStop: true}
+	createParams := s.Fixtures.CreatePoolParams
+	createParams.WarmRunners = 1
+	createParams.IdleTimeout = 1
+	pool := s.createPool(createParams)
+	s.backdateInstances(s.createInstances(pool, pool.ProviderName, 4, providerCommon.InstanceRunning, providerCommon.RunnerIdle), 2*time.Minute)
+	s.Fixtures.Providers["test-provider"].On("Stop", mock.Anything, mock.Anything, false).Return(nil).Once()
+
+	err := s.PoolManager.scaleDownOnePool(s.Fixtures.AdminContext, pool)
+	s.Require().Nil(err)
+	s.Require().Equal(map[providerCommon.InstanceStatus]int{
+		providerCommon.InstanceRunning:       2,
+		providerCommon.InstanceStopped:       1,
+		providerCommon.InstancePendingDelete: 1,
+	}, s.countInstances(pool.ID))
+}
+
+func (s *PoolManagerTestSuite) TestScaleDownRemovesIdleRunnersIfProviderCannotStop() {
+	createParams := s.Fixtures.CreatePoolParams
+	createParams.WarmRunners = 1
+	createParams.IdleTimeout = 1
+	pool := s.createPool(createParams)
+	s.backdateInstances(s.createInstances(pool, pool.ProviderName, 4, providerCommon.InstanceRunning, providerCommon.RunnerIdle), 2*time.Minute)
+
+	err := s.PoolManager.scaleDownOnePool(s.Fixtures.AdminContext, pool)
+	s.Require().Nil(err)
+	s.Require().Equal(map[providerCommon.InstanceStatus]int{
+		providerCommon.InstanceRunning:       2,
+		providerCommon.InstancePendingDelete: 2,
+	}, s.countInstances(pool.ID))
+}
+
+func (s *PoolManagerTestSuite) TestScaleDownKeepsRunnerIfStopFails() {
+	s.Fixtures.ProviderCapabilities["test-provider"] = params.ProviderCapabilities{StartStop: true}
+	createParams := s.Fixtures.CreatePoolParams
+	createParams.WarmRunners = 1
+	createParams.IdleTimeout = 1
+	pool := s.createPool(createParams)
+	s.backdateInstances(s.createInstances(pool, pool.ProviderName, 1, providerCommon.InstanceRunning, providerCommon.RunnerIdle), 2*time.Minute)
+	s.Fixtures.Providers["test-provider"].On("Stop", mock.Anything, mock.Anything, false).Return(fmt.Errorf("mock error")).Once()
+
+	err := s.PoolManager.scaleDownOnePool(s.Fixtures.AdminContext, pool)
+	s.Require().NotNil(err)
+	s.Require().Equal(map[providerCommon.InstanceStatus]int{
+		providerCommon.InstanceRunning: 1,
+	}, s.countInstances(pool.ID))
+}
+
+func (s *PoolManagerTestSuite) TestScaleDownRemovesExtraWarmRunners() {
+	createParams := s.Fixtures.CreatePoolParams
+	createParams.WarmRunners = 1
+	pool := s.createPool(createParams)
+	s.createInstances(pool, pool.ProviderName, 3, providerCommon.InstanceStopped, providerCommon.RunnerIdle)
+
+	err := s.PoolManager.scaleDownOnePool(s.Fixtures.AdminContext, pool)
+	s.Require().Nil(err)
+	s.Require().Equal(map[providerCommon.InstanceStatus]int{
+		providerCommon.InstanceStopped:       1,
+		providerCommon.InstancePendingDelete: 2,
+	}, s.countInstances(pool.ID))
+}
+
+func (s *PoolManagerTestSuite) TestScaleDownTrimsRunnersAboveScheduleMaxRunners() {
+	createParams := s.Fixtures.CreatePoolParams
+	createParams.ScalingSchedules = []params.ScalingSchedule{
+		{Name: "always", Schedule: "* * * * *", MaxRunners: 3},
+	}
+	pool := s.createPool(createParams)
+	s.createInstances(pool, pool.ProviderName, 4, providerCommon.InstanceRunning, providerCommon.RunnerIdle)
+	s.createInstances(pool, pool.ProviderName, 1, providerCommon.InstanceRunning, providerCommon.RunnerActive)
+
+	err := s.PoolManager.scaleDownOnePool(s.Fixtures.AdminContext, pool)
+	s.Require().Nil(err)
+	s.Require().Equal(map[providerCommon.InstanceStatus]int{
+		providerCommon.InstanceRunning:       3,
+		providerCommon.InstancePendingDelete: 2,
+	}, s.countInstances(pool.ID))
+}
+
+func (s *PoolManagerTestSuite) TestScaleDownKeepsActiveRunnersAboveScheduleMaxRunners() {
+	createParams := s.Fixtures.CreatePoolParams
+	createParams.ScalingSchedules = []params.ScalingSchedule{
+		{Name: "always", Schedule: "* * * * *", MaxRunners: 3},
+	}
+	pool := s.createPool(createParams)
+	s.createInstances(pool, pool.ProviderName, 4, providerCommon.InstanceRunning, providerCommon.RunnerActive)
+
+	err := s.PoolManager.scaleDownOnePool(s.Fixtures.AdminContext, pool)
+	s.Require().Nil(err)
+	s.Require().Equal(map[providerCommon.InstanceStatus]int{
+		providerCommon.InstanceRunning: 4,
+	}, s.countInstances(pool.ID))
+}
+
+func (s *PoolManagerTestSuite) TestStartWarmRunner() {
+	createParams := s.Fixtures.CreatePoolParams
+	createParams.WarmRunners = 1
+	pool := s.createPool(createParams)
+	s.createInstances(pool, pool.ProviderName, 1, providerCommon.InstanceStopped, providerCommon.RunnerIdle)
+	s.Fixtures.Providers["test-provider"].On("Start", mock.Anything, mock.Anything).Return(nil).Once()
+
+	started, err := s.PoolManager.startWarmRunner(pool)
+	s.Require().Nil(err)
+	s.Require().True(started)
+	s.Require().Equal(map[providerCommon.InstanceStatus]int{
+		providerCommon.InstanceRunning: 1,
+	}, s.countInstances(pool.ID))
+}
+
+func (s *PoolManagerTestSuite) TestStartWarmRunnerRemovesRunnerThatFailsToStart() {
+	createParams := s.Fixtures.CreatePoolParams
+	createParams.WarmRunners = 1
+	pool := s.createPool(createParams)
+	s.createInstances(pool, pool.ProviderName, 1, providerCommon.InstanceStopped, providerCommon.RunnerIdle)
+	s.Fixtures.Providers["test-provider"].On("Start", mock.Anything, mock.Anything).Return(fmt.Errorf("mock error")).Once()
+
+	started, err := s.PoolManager.startWarmRunner(pool)
+	s.Require().Nil(err)
+	s.Require().False(started)
+	s.Require().Equal(map[providerCommon.InstanceStatus]int{
+		providerCommon.InstancePendingDelete: 1,
+	}, s.countInstances(pool.ID))
+}
+
+func (s *PoolManagerTestSuite) TestStartWarmRunnerWithoutWarmRunners() {
+	pool := s.createPool(s.Fixtures.CreatePoolParams)
+	s.createInstances(pool, pool.ProviderName, 1, providerCommon.InstanceRunning, providerCommon.RunnerIdle)
+
+	started, err := s.PoolManager.startWarmRunner(pool)
+	s.Require().Nil(err)
+	s.Require().False(started)
+}
+
+func (s *PoolManagerTestSuite) TestReconcileWarmRunners() {
+	tests := []struct {
+		name           string
+		status         providerCommon.InstanceStatus
+		age            time.Duration
+		providerStatus providerCommon.InstanceStatus
+		providerErr    error
+		expectedStatus providerCommon.InstanceStatus
+	}{
+		{
+			name:           "stopping instance the provider stopped",
+			status:         providerCommon.InstanceStopping,
+			age:            warmRunnerTransitionTimeout + time.Minute,
+			providerStatus: providerCommon.InstanceStopped,
+			expectedStatus: providerCommon.InstanceStopped,
+		},
+		{
+			name:           "stopping instance the provider left running",
+			status:         providerCommon.InstanceStopping,
+			age:            warmRunnerTransitionTimeout + time.Minute,
+			providerStatus: providerCommon.InstanceRunning,
+			expectedStatus: providerCommon.InstanceRunning,
+		},
+		{
+			name:           "starting instance the provider started",
+			status:         providerCommon.InstanceStarting,
+			age:            warmRunnerTransitionTimeout + time.Minute,
+			providerStatus: providerCommon.InstanceRunning,
+			expectedStatus: providerCommon.InstanceRunning,
+		},
+		{
+			name:           "starting instance in error",
+			status:         providerCommon.InstanceStarting,
+			age:            warmRunnerTransitionTimeout + time.Minute,
+			providerStatus: providerCommon.InstanceError,
+			expectedStatus: providerCommon.InstancePendingDelete,
+		},
+		{
+			name:           "instance gone from the provider",
+			status:         providerCommon.InstanceStopping,
+			age:            warmRunnerTransitionTimeout + time.Minute,
+			providerErr:    runnerErrors.ErrNotFound,
+			expectedStatus: providerCommon.InstancePendingDelete,
+		},
+		{
+			name:           "provider error",
+			status:         providerCommon.InstanceStarting,
+			age:            warmRunnerTransitionTimeout + time.Minute,
+			providerErr:    fmt.Errorf("mock error"),
+			expectedStatus: providerCommon.InstanceStarting,
+		},
+		{
+			name:           "instance within the timeout",
+			status:         providerCommon.InstanceStopping,
+			expectedStatus: providerCommon.InstanceStopping,
+		},
+	}
+
+	for idx, tc := range tests {
+		s.Run(tc.name, func() {
+			// Pools need a different image to share a provider.
+			createParams := s.Fixtures.CreatePoolParams
+			createParams.Image = fmt.Sprintf("test-image-%d", idx)
+			pool := s.createPool(createParams)
+			instances := s.createInstances(pool, pool.ProviderName, 1, tc.status, providerCommon.RunnerIdle)
+			if tc.age > 0 {
+				s.backdateInstances(instances, tc.age)
+				s.Fixtures.Providers["test-provider"].On("GetInstance", mock.Anything, mock.Anything).
+					Return(params.Instance{Status: tc.providerStatus}, tc.providerErr).Once()
+			}
+
+			err := s.PoolManager.reconcileWarmRunnersForOnePool(pool)
+			s.Require().Nil(err)
+			s.Require().Equal(map[providerCommon.InstanceStatus]int{
+				tc.expectedStatus: 1,
+			}, s.countInstances(pool.ID))
+		})
+	}
+}
+
+func TestPoolManagerTestSuite(t *testing.T) {
+	suite.Run(t, new(PoolManagerTestSuite))
+}
@@ -0,0 +1,139 @@
+// Copyright 2023 Cloudbase Solutions SRL
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package pool
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/cloudbase/garm/params"
+
+	"github.com/google/uuid"
+)
+
+// repositoryKey returns the full name of the repository in which a job was triggered.
+func repositoryKey(job params.Job) string {
+	return fmt.Sprintf("%s/%s", job.RepositoryOwner, job.RepositoryName)
+}
+
+// CountRepositoryRunners returns the number of runners used by each repository, keyed by
+// the full name of the repository. A runner is used by a repository when it runs one of
+// its jobs, or when it was created in response to one of its queued jobs. Runners that
+// are not tied to a job are not counted.
+func CountRepositoryRunners(instances []params.Instance, jobs []params.Job) map[string]uint {
+	queuedJobs := map[int64]params.Job{}
+	runningJobs := map[string]params.Job{}
+	for _, job := range jobs {
+		switch params.JobStatus(job.Status) {
+		case params.JobStatusQueued:
+			queuedJobs[job.ID] = job
+		case params.JobStatusInProgress:
+			if job.RunnerName != "" {
+				runningJobs[job.RunnerName] = job
+			}
+		}
+	}
+
+	ret := map[string]uint{}
+	for _, instance := range instances {
+		job, ok := runningJobs[instance.Name]
+		if !ok {
+			job, ok = queuedJobs[jobIdFromLabels(instance.AditionalLabels)]
+		}
+		if ok {
+			ret[repositoryKey(job)]++
+		}
+	}
+	return ret
+}
+
+// entityJobs returns the queued and in progress jobs of the entity.
+func (r *basePoolManager) entityJobs() ([]params.Job, error) {
+	queued, err := r.store.ListEntityJobsByStatus(r.ctx, r.helper.PoolType(), r.helper.ID(), params.JobStatusQueued)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list queued jobs: %w", err)
+	}
+	inProgress, err := r.store.ListEntityJobsByStatus(r.ctx, r.helper.PoolType(), r.helper.ID(), params.JobStatusInProgress)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list in progress jobs: %w", err)
+	}
+	return append(queued, inProgress...), nil
+}
+
+// checkRepositoryQuota returns an error if the repository of the job already uses the
+// maximum number of runners allowed in the pool.
+func (r *basePoolManager) checkRepositoryQuota(pool params.Pool, job params.Job, jobs []params.Job) error {
+	if pool.MaxRunnersPerRepository == 0 || pool.PoolType() == params.RepositoryPool {
+		return nil
+	}
+
+	instances, err := r.store.ListPoolInstances(r.ctx, pool.ID)
+	if err != nil {
+		return fmt.Errorf("failed to list instances for pool %s: %w", pool.ID, err)
+	}
+
+	repo := repositoryKey(job)
+	if CountRepositoryRunners(instances, jobs)[repo] >= pool.MaxRunnersPerRepository {
+		return fmt.Errorf("repository %s uses the max runners per repository (%d) of pool %s", repo, pool.MaxRunnersPerRepository, pool.ID)
+	}
+	return nil
+}
+
+// checkCredentialsQuota returns an error if the entities using the same credentials as this
+// pool manager already have the maximum number of runners allowed for those credentials.
+// The caller must hold capacityMux.
+func (r *basePoolManager) checkCredentialsQuota(creds params.GithubCredentials) error {
+	if creds.MaxRunners == 0 {
+		return nil
+	}
+
+	cnt, err := r.store.CredentialsInstanceCount(r.ctx, creds.Name)
+	if err != nil {
+		return fmt.Errorf("failed to fetch instance count of credentials %s: %w", creds.Name, err)
+	}
+	if cnt >= int64(creds.MaxRunners) {
+		return fmt.Errorf("max runners (%d) reached for credentials %s", creds.MaxRunners, creds.Name)
+	}
+	return nil
+}
+
+// fairShareOrder orders queued jobs so that the jobs of repositories using fewer runners
+// come first. The jobs of a repository keep their order, and are interleaved with the jobs
+// of other repositories, as if a runner was created for each of them in turn.
+func fairShareOrder(jobs []params.Job, usage map[string]uint) []params.Job {
+	pending := map[string]uint{}
+	keys := make([]uint, len(jobs))
+	for idx, job := range jobs {
+		repo := repositoryKey(job)
+		keys[idx] = usage[repo] + pending[repo]
+		if job.LockedBy == uuid.Nil {
+			pending[repo]++
+		}
+	}
+
+	order := make([]int, len(jobs))
+	for idx := range order {
+		order[idx] = idx
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return keys[order[i]] < keys[order[j]]
+	})
+
+	ret := make([]params.Job, len(jobs))
+	for idx, jobIdx := range order {
+		ret[idx] = jobs[jobIdx]
+	}
+	return ret
+}
@@ -0,0 +1,97 @@
+// Copyright 2023 Cloudbase Solutions SRL
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package pool
+
+import (
+	"fmt"
+
+	"github.com/cloudbase/garm/params"
+
+	"github.com/google/uuid"
+)
+
+func testJob(id int64, repo string, status params.JobStatus) params.Job {
+	return params.Job{
+		ID:              id,
+		Status:          string(status),
+		RepositoryOwner: "test-owner",
+		RepositoryName:  repo,
+	}
+}
+
+func (s *PoolManagerTestSuite) TestCountRepositoryRunners() {
+	running := testJob(1, "repo-a", params.JobStatusInProgress)
+	running.RunnerName = "runner-running"
+	completed := testJob(2, "repo-b", params.JobStatusCompleted)
+	completed.RunnerName = "runner-completed"
+	jobs := []params.Job{
+		running,
+		completed,
+		testJob(3, "repo-a", params.JobStatusQueued),
+		testJob(4, "repo-b", params.JobStatusQueued),
+	}
+
+	instances := []params.Instance{
+		{Name: "runner-running"},
+		{Name: "runner-completed"},
+		{Name: "runner-queued-a", AditionalLabels: []string{fmt.Sprintf("%s3", jobLabelPrefix)}},
+		{Name: "runner-queued-b", AditionalLabels: []string{fmt.Sprintf("%s4", jobLabelPrefix)}},
+		{Name: "runner-unknown-job", AditionalLabels: []string{fmt.Sprintf("%s5", jobLabelPrefix)}},
+		{Name: "runner-no-job"},
+	}
+
+	s.Require().Equal(map[string]uint{
+		"test-owner/repo-a": 2,
+		"test-owner/repo-b": 1,
+	}, CountRepositoryRunners(instances, jobs))
+}
+
+func (s *PoolManagerTestSuite) TestFairShareOrder() {
+	locked := testJob(3, "repo-b", params.JobStatusQueued)
+	locked.LockedBy = uuid.New()
+	jobs := []params.Job{
+		testJob(1, "repo-a", params.JobStatusQueued),
+		testJob(2, "repo-a", params.JobStatusQueued),
+		locked,
+		testJob(4, "repo-b", params.JobStatusQueued),
+		testJob(5, "repo-b", params.JobStatusQueued),
+		testJob(6, "repo-c", params.JobStatusQueued),
+		testJob(7, "repo-c", params.JobStatusQueued),
+	}
+	usage := map[string]uint{
+		"test-owner/repo-a": 2,
+		"test-owner/repo-b": 1,
+	}
+
+	// The jobs of the repositories are interleaved, starting with the repository that
+	// uses the fewest runners. A locked job was already handled and its runner is part
+	// of the usage, so it does not push back the other jobs of its repository.
+	ordered := fairShareOrder(jobs, usage)
+	ids := []int64{}
+	for _, job := range ordered {
+		ids = append(ids, job.ID)
+	}
+	s.Require().Equal([]int64{6, 3, 4, 7, 1, 5, 2}, ids)
+}
+
+func (s *PoolManagerTestSuite) TestFairShareOrderKeepsOrderWithoutUsage() {
+	jobs := []params.Job{
+		testJob(1, "repo-a", params.JobStatusQueued),
+		testJob(2, "repo-b", params.JobStatusQueued),
+		testJob(3, "repo-c", params.JobStatusQueued),
+	}
+
+	s.Require().Equal(jobs, fairShareOrder(jobs, map[string]uint{}))
+}
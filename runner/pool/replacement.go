@@ -0,0 +1,227 @@
+// Copyright 2023 Cloudbase Solutions SRL
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package pool
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/cloudbase/garm/params"
+	providerCommon "github.com/cloudbase/garm/runner/providers/common"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// replacementCanaryTimeout is the time after which the canary of a config revision is
+// considered good, if it stayed online without a job succeeding on it.
+const replacementCanaryTimeout = 30 * time.Minute
+
+// isStaleInstance returns true if the instance was created from an older config
+// revision of the pool.
+func isStaleInstance(pool params.Pool, inst params.Instance) bool {
+	return inst.PoolConfigRevision < pool.ConfigRevision
+}
+
+// isSettingUp returns true if the instance was created, but has not yet registered
+// as an idle runner.
+func isSettingUp(inst params.Instance) bool {
+	switch inst.Status {
+	case providerCommon.InstancePendingCreate, providerCommon.InstanceCreating:
+		return true
+	case providerCommon.InstanceRunning:
+		switch inst.RunnerStatus {
+		case providerCommon.RunnerPending, providerCommon.RunnerInstalling:
+			return true
+		}
+	}
+	return false
+}
+
+// replacedRunnerFromLabels returns the name of the stale runner a replacement runner was
+// created for, or an empty string if the runner is not a replacement.
+func replacedRunnerFromLabels(labels []string) string {
+	for _, lbl := range labels {
+		if strings.HasPrefix(lbl, replacesRunnerLabelPrefix) {
+			return lbl[len(replacesRunnerLabelPrefix):]
+		}
+	}
+	return ""
+}
+
+// isReplacementOnline returns true once a replacement runner registered with github, at
+// which point the stale runner it replaces can be removed.
+func isReplacementOnline(inst params.Instance) bool {
+	switch inst.RunnerStatus {
+	case providerCommon.RunnerIdle, providerCommon.RunnerActive, providerCommon.RunnerTerminated:
+		return true
+	}
+	return false
+}
+
+// isCanaryTimedOut returns true if a runner from the new config revision of a pool stayed
+// online long enough to be trusted without a job succeeding on it.
+func isCanaryTimedOut(inst params.Instance, now time.Time) bool {
+	return isReplacementOnline(inst) && now.Sub(inst.CreatedAt) > replacementCanaryTimeout
+}
+
+// replaceStaleRunnersForOnePool replaces the idle runners of a pool that were created from an
+// older config revision. A replacement is created for every stale idle runner, in batches of at
+// most MaxSurge runners, and the stale runner is only removed once its replacement is online.
+// The next batch is created once the previous one is online. If the pool asks for a canary, a
+// single replacement is created first, and the rest wait until a job ran successfully on the new
+// config revision, or until the canary stayed online for replacementCanaryTimeout. Stale runners
+// that are running a job are left alone.
+func (r *basePoolManager) replaceStaleRunnersForOnePool(pool params.Pool) error {
+	if !pool.Enabled || r.isDraining(pool) || r.isCircuitBreakerOpen(pool) {
+		return nil
+	}
+	pool = pool.WithScalingSchedule(time.Now())
+
+	existingInstances, err := r.store.ListPoolInstances(r.ctx, pool.ID)
+	if err != nil {
+		return fmt.Errorf("failed to list instances for pool %s: %w", pool.ID, err)
+	}
+
+	staleIdle := []params.Instance{}
+	replacements := map[string]params.Instance{}
+	var current int
+	canaryTimedOut := false
+	for _, inst := range existingInstances {
+		if !isStaleInstance(pool, inst) {
+			current++
+			if replaced := replacedRunnerFromLabels(inst.AditionalLabels); replaced != "" {
+				replacements[replaced] = inst
+			}
+			if isCanaryTimedOut(inst, time.Now().UTC()) {
+				canaryTimedOut = true
+			}
+			continue
+		}
+		if inst.RunnerStatus == providerCommon.RunnerIdle &&
+			(inst.Status == providerCommon.InstanceRunning || inst.Status == providerCommon.InstanceStopped) {
+			staleIdle = append(staleIdle, inst)
+		}
+	}
+
+	if len(staleIdle) == 0 {
+		return nil
+	}
+
+	// Remove the stale runners whose replacement is online, and count the replacements
+	// that are still being set up.
+	unreplaced := []params.Instance{}
+	var settingUp int
+	for _, inst := range staleIdle {
+		replacement, ok := replacements[inst.Name]
+		if !ok {
+			unreplaced = append(unreplaced, inst)
+			continue
+		}
+		if !isReplacementOnline(replacement) {
+			settingUp++
+			continue
+		}
+
+		if !r.keyMux.TryLock(inst.Name) {
+			r.log("failed to acquire lock for instance %s", inst.Name)
+			continue
+		}
+		reason := fmt.Sprintf("replaced by runner %s from config revision %d of the pool", replacement.Name, pool.ConfigRevision)
+		err := r.removeRunner(inst, reason)
+		r.keyMux.Unlock(inst.Name, false)
+		if err != nil {
+			return fmt.Errorf("failed to delete instance %s: %w", inst.ID, err)
+		}
+	}
+
+	if len(unreplaced) == 0 {
+		return nil
+	}
+
+	batch := int(pool.GetMaxSurge()) - settingUp
+	if pool.ReplacementCanary && pool.ValidatedConfigRevision < pool.ConfigRevision {
+		switch {
+		case canaryTimedOut:
+			r.log("canary for config revision %d of pool %s has been online for %s, replacing the rest of the runners",
+				pool.ConfigRevision, pool.ID, replacementCanaryTimeout)
+			if err := r.store.SetPoolValidatedConfigRevision(r.ctx, pool.ID, pool.ConfigRevision); err != nil {
+				return fmt.Errorf("failed to validate config revision of pool %s: %w", pool.ID, err)
+			}
+		case current > 0:
+			r.log("waiting for a job to succeed on config revision %d of pool %s", pool.ConfigRevision, pool.ID)
+			return nil
+		default:
+			r.log("creating canary runner for config revision %d of pool %s", pool.ConfigRevision, pool.ID)
+			batch = 1
+		}
+	}
+	if batch <= 0 {
+		return nil
+	}
+	if batch > len(unreplaced) {
+		batch = len(unreplaced)
+	}
+
+	poolInstanceCount := int64(len(existingInstances))
+	for _, inst := range unreplaced[:batch] {
+		// Replacements may go over the max runners of the pool by up to MaxSurge runners,
+		// until the stale runners they replace are gone.
+		if poolInstanceCount >= int64(pool.MaxRunners+pool.GetMaxSurge()) {
+			r.log("max surge reached for pool %s", pool.ID)
+			break
+		}
+		// Skip stale runners that are being handled elsewhere, like by scale down.
+		if !r.keyMux.TryLock(inst.Name) {
+			r.log("failed to acquire lock for instance %s", inst.Name)
+			continue
+		}
+		err := r.AddRunner(r.ctx, pool.ID, []string{fmt.Sprintf("%s%s", replacesRunnerLabelPrefix, inst.Name)})
+		r.keyMux.Unlock(inst.Name, false)
+		if err != nil {
+			return fmt.Errorf("failed to add replacement runner to pool %s: %w", pool.ID, err)
+		}
+		poolInstanceCount++
+	}
+	return nil
+}
+
+func (r *basePoolManager) replaceStaleRunners() error {
+	pools, err := r.helper.ListPools()
+	if err != nil {
+		return fmt.Errorf("error listing pools: %w", err)
+	}
+
+	g, _ := errgroup.WithContext(r.ctx)
+	for _, pool := range pools {
+		pool := pool
+		g.Go(func() error {
+			return r.replaceStaleRunnersForOnePool(pool)
+		})
+	}
+
+	if err := r.waitForErrorGroupOrContextCancelled(g); err != nil {
+		return fmt.Errorf("failed to replace stale runners: %w", err)
+	}
+	return nil
+}
+
+// recordJobSucceeded marks the config revision the runner was created from as validated,
+// which lets the rolling replacement of a pool move past its canary.
+func (r *basePoolManager) recordJobSucceeded(instance params.Instance) {
+	if err := r.store.SetPoolValidatedConfigRevision(r.ctx, instance.PoolID, instance.PoolConfigRevision); err != nil {
+		r.log("failed to record validated config revision for pool %s: %s", instance.PoolID, err)
+	}
+}
@@ -0,0 +1,181 @@
+// Copyright 2023 Cloudbase Solutions SRL
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package pool
+
+import (
+	"time"
+
+	"github.com/cloudbase/garm/params"
+	providerCommon "github.com/cloudbase/garm/runner/providers/common"
+)
+
+// createStalePool creates a pool with idle runners, and changes the image of the pool
+// so that all of them become stale.
+func (s *PoolManagerTestSuite) createStalePool(createParams params.CreatePoolParams, idle int) params.Pool {
+	pool := s.createPool(createParams)
+	instances := s.createInstances(pool, pool.ProviderName, idle, providerCommon.InstanceRunning, providerCommon.RunnerIdle)
+
+	pool, err := s.Fixtures.Store.UpdateRepositoryPool(s.Fixtures.AdminContext, s.Fixtures.Repo.ID, pool.ID, params.UpdatePoolParams{Image: "new-test-image"})
+	s.Require().Nil(err)
+	s.Require().True(isStaleInstance(pool, instances[0]))
+	return pool
+}
+
+// bringReplacementsOnline marks the replacements that are being set up as idle runners, and
+// returns the names of the runners they replace.
+func (s *PoolManagerTestSuite) bringReplacementsOnline(poolID string) []string {
+	instances, err := s.Fixtures.Store.ListPoolInstances(s.Fixtures.AdminContext, poolID)
+	s.Require().Nil(err)
+
+	replaced := []string{}
+	for _, instance := range instances {
+		if instance.Status != providerCommon.InstancePendingCreate {
+			continue
+		}
+		_, err := s.Fixtures.Store.UpdateInstance(s.Fixtures.AdminContext, instance.ID, params.UpdateInstanceParams{
+			Status:       providerCommon.InstanceRunning,
+			RunnerStatus: providerCommon.RunnerIdle,
+		})
+		s.Require().Nil(err)
+		replaced = append(replaced, replacedRunnerFromLabels(instance.AditionalLabels))
+	}
+	return replaced
+}
+
+func (s *PoolManagerTestSuite) TestReplaceStaleRunnersInBatches() {
+	createParams := s.Fixtures.CreatePoolParams
+	createParams.MaxSurge = 2
+	pool := s.createStalePool(createParams, 5)
+
+	// The first batch of replacements is created, and no runner is removed yet.
+	err := s.PoolManager.replaceStaleRunnersForOnePool(pool)
+	s.Require().Nil(err)
+	s.Require().Equal(map[providerCommon.InstanceStatus]int{
+		providerCommon.InstanceRunning:       5,
+		providerCommon.InstancePendingCreate: 2,
+	}, s.countInstances(pool.ID))
+
+	// Nothing happens while the replacements are being set up.
+	err = s.PoolManager.replaceStaleRunnersForOnePool(pool)
+	s.Require().Nil(err)
+	s.Require().Equal(map[providerCommon.InstanceStatus]int{
+		providerCommon.InstanceRunning:       5,
+		providerCommon.InstancePendingCreate: 2,
+	}, s.countInstances(pool.ID))
+
+	// Once the replacements are online, the runners they replace are removed, and the
+	// next batch is created.
+	replaced := s.bringReplacementsOnline(pool.ID)
+	s.Require().Len(replaced, 2)
+	err = s.PoolManager.replaceStaleRunnersForOnePool(pool)
+	s.Require().Nil(err)
+	s.Require().Equal(map[providerCommon.InstanceStatus]int{
+		providerCommon.InstanceRunning:       5,
+		providerCommon.InstancePendingDelete: 2,
+		providerCommon.InstancePendingCreate: 2,
+	}, s.countInstances(pool.ID))
+	for _, name := range replaced {
+		instance, err := s.Fixtures.Store.GetPoolInstanceByName(s.Fixtures.AdminContext, pool.ID, name)
+		s.Require().Nil(err)
+		s.Require().Equal(providerCommon.InstancePendingDelete, instance.Status)
+	}
+}
+
+func (s *PoolManagerTestSuite) TestReplaceStaleRunnersCanary() {
+	createParams := s.Fixtures.CreatePoolParams
+	createParams.MaxSurge = 3
+	createParams.ReplacementCanary = true
+	pool := s.createStalePool(createParams, 3)
+
+	err := s.PoolManager.replaceStaleRunnersForOnePool(pool)
+	s.Require().Nil(err)
+	s.Require().Equal(map[providerCommon.InstanceStatus]int{
+		providerCommon.InstanceRunning:       3,
+		providerCommon.InstancePendingCreate: 1,
+	}, s.countInstances(pool.ID))
+
+	// The runner replaced by the canary is removed once the canary is online, but no other
+	// runner is replaced until a job succeeds on the canary.
+	s.bringReplacementsOnline(pool.ID)
+	err = s.PoolManager.replaceStaleRunnersForOnePool(pool)
+	s.Require().Nil(err)
+	s.Require().Equal(map[providerCommon.InstanceStatus]int{
+		providerCommon.InstanceRunning:       3,
+		providerCommon.InstancePendingDelete: 1,
+	}, s.countInstances(pool.ID))
+
+	err = s.Fixtures.Store.SetPoolValidatedConfigRevision(s.Fixtures.AdminContext, pool.ID, pool.ConfigRevision)
+	s.Require().Nil(err)
+	pool, err = s.Fixtures.Store.GetPoolByID(s.Fixtures.AdminContext, pool.ID)
+	s.Require().Nil(err)
+
+	err = s.PoolManager.replaceStaleRunnersForOnePool(pool)
+	s.Require().Nil(err)
+	s.Require().Equal(map[providerCommon.InstanceStatus]int{
+		providerCommon.InstanceRunning:       3,
+		providerCommon.InstancePendingDelete: 1,
+		providerCommon.InstancePendingCreate: 2,
+	}, s.countInstances(pool.ID))
+}
+
+func (s *PoolManagerTestSuite) TestIsCanaryTimedOut() {
+	now := time.Date(2023, 10, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		instance params.Instance
+		timedOut bool
+	}{
+		{
+			name:     "Canary online for longer than the timeout",
+			instance: params.Instance{RunnerStatus: providerCommon.RunnerIdle, CreatedAt: now.Add(-replacementCanaryTimeout - time.Minute)},
+			timedOut: true,
+		},
+		{
+			name:     "Canary online for less than the timeout",
+			instance: params.Instance{RunnerStatus: providerCommon.RunnerIdle, CreatedAt: now.Add(-time.Minute)},
+			timedOut: false,
+		},
+		{
+			name:     "Canary never came online",
+			instance: params.Instance{RunnerStatus: providerCommon.RunnerInstalling, CreatedAt: now.Add(-replacementCanaryTimeout - time.Minute)},
+			timedOut: false,
+		},
+	}
+
+	for _, tc := range tests {
+		s.Run(tc.name, func() {
+			s.Require().Equal(tc.timedOut, isCanaryTimedOut(tc.instance, now))
+		})
+	}
+}
+
+func (s *PoolManagerTestSuite) TestReplaceStaleRunnersSkipsLockedRunners() {
+	createParams := s.Fixtures.CreatePoolParams
+	createParams.MaxSurge = 2
+	pool := s.createStalePool(createParams, 2)
+
+	instances, err := s.Fixtures.Store.ListPoolInstances(s.Fixtures.AdminContext, pool.ID)
+	s.Require().Nil(err)
+	for _, instance := range instances {
+		s.Require().True(s.PoolManager.keyMux.TryLock(instance.Name))
+	}
+
+	err = s.PoolManager.replaceStaleRunnersForOnePool(pool)
+	s.Require().Nil(err)
+	s.Require().Equal(map[providerCommon.InstanceStatus]int{
+		providerCommon.InstanceRunning: 2,
+	}, s.countInstances(pool.ID))
+}
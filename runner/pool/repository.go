@@ -20,6 +20,7 @@ import (
 	"net/http"
 	"strings"
 	"sync"
+	"time"
 
 	dbCommon "github.com/cloudbase/garm/database/common"
 	runnerErrors "github.com/cloudbase/garm/errors"
@@ -113,6 +114,9 @@ func (r *repository) UpdateState(param params.UpdatePoolStateParams) error {
 	defer r.mux.Unlock()
 
 	r.cfg.WebhookSecret = param.WebhookSecret
+	r.cfg.PollingEnabled = param.PollingEnabled
+	r.cfg.PollingInterval = param.PollingInterval
+	r.cfg.PoolSelectionStrategy = param.PoolSelectionStrategy
 	if param.InternalConfig != nil {
 		r.cfgInternal = *param.InternalConfig
 	}
@@ -203,6 +207,30 @@ func (r *repository) GetGithubRegistrationToken() (string, error) {
 	return *tk.Token, nil
 }
 
+func (r *repository) GetJITConfig(instance params.Instance, labels []string) (string, error) {
+	req := github.GenerateJITConfigRequest{
+		Name: instance.Name,
+		// Repositories can only use the default runner group.
+		RunnerGroupID: defaultRunnerGroupID,
+		WorkFolder:    github.String(runnerWorkFolder),
+		Labels:        labels,
+	}
+	jitConfig, ghResp, err := r.ghcli.GenerateRepoJITConfig(r.ctx, r.cfg.Owner, r.cfg.Name, &req)
+	if err != nil {
+		if ghResp != nil {
+			switch ghResp.StatusCode {
+			case http.StatusUnauthorized:
+				return "", errors.Wrap(runnerErrors.ErrUnauthorized, "fetching JIT config")
+			case http.StatusNotFound:
+				// Older versions of GitHub Enterprise Server do not have this API.
+				return "", errors.Wrap(runnerErrors.ErrNotFound, "fetching JIT config")
+			}
+		}
+		return "", errors.Wrap(err, "generating JIT config")
+	}
+	return jitConfig.GetEncodedJITConfig(), nil
+}
+
 func (r *repository) String() string {
 	return fmt.Sprintf("%s/%s", r.cfg.Owner, r.cfg.Name)
 }
@@ -245,3 +273,139 @@ func (r *repository) ValidateOwner(job params.WorkflowJob) error {
 func (r *repository) ID() string {
 	return r.id
 }
+
+func (r *repository) GetPollingInterval() time.Duration {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	return pollingInterval(r.cfg.PollingEnabled, r.cfg.PollingInterval)
+}
+
+func (r *repository) PoolSelectionStrategy() params.PoolSelectionStrategy {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	return r.cfg.PoolSelectionStrategy
+}
+
+// JobSchedulingStrategy always returns fifo. All jobs of a repository pool manager
+// belong to the same repository, so there is nothing to share fairly.
+func (r *repository) JobSchedulingStrategy() params.JobSchedulingStrategy {
+	return params.JobSchedulingFIFO
+}
+
+// ListPollTargets returns the repositories that need to be polled for workflow jobs.
+// For a repository pool manager, that's just the repository itself.
+func (r *repository) ListPollTargets() ([]*github.Repository, error) {
+	return []*github.Repository{
+		{
+			Name: github.String(r.cfg.Name),
+			Owner: &github.User{
+				Login: github.String(r.cfg.Owner),
+			},
+		},
+	}, nil
+}
+
+func (r *repository) GetWebhookURL() string {
+	return r.cfgInternal.WebhookURL
+}
+
+func (r *repository) ListHooks() ([]*github.Hook, error) {
+	opts := github.ListOptions{
+		PerPage: 100,
+	}
+
+	var allHooks []*github.Hook
+	for {
+		hooks, ghResp, err := r.ghcli.ListHooks(r.ctx, r.cfg.Owner, r.cfg.Name, &opts)
+		if err != nil {
+			return nil, wrapGithubError(ghResp, err, "fetching hooks")
+		}
+		allHooks = append(allHooks, hooks...)
+		if ghResp.NextPage == 0 {
+			break
+		}
+		opts.Page = ghResp.NextPage
+	}
+	return allHooks, nil
+}
+
+func (r *repository) CreateHook(hook *github.Hook) (*github.Hook, error) {
+	newHook, ghResp, err := r.ghcli.CreateHook(r.ctx, r.cfg.Owner, r.cfg.Name, hook)
+	if err != nil {
+		return nil, wrapGithubError(ghResp, err, "creating hook")
+	}
+	return newHook, nil
+}
+
+func (r *repository) EditHook(id int64, hook *github.Hook) (*github.Hook, error) {
+	newHook, ghResp, err := r.ghcli.EditHook(r.ctx, r.cfg.Owner, r.cfg.Name, id, hook)
+	if err != nil {
+		return nil, wrapGithubError(ghResp, err, "updating hook")
+	}
+	return newHook, nil
+}
+
+func (r *repository) DeleteHook(id int64) error {
+	ghResp, err := r.ghcli.DeleteHook(r.ctx, r.cfg.Owner, r.cfg.Name, id)
+	if err != nil {
+		return wrapGithubError(ghResp, err, "deleting hook")
+	}
+	return nil
+}
+
+func (r *repository) PingHook(id int64) error {
+	ghResp, err := r.ghcli.PingHook(r.ctx, r.cfg.Owner, r.cfg.Name, id)
+	if err != nil {
+		return wrapGithubError(ghResp, err, "pinging hook")
+	}
+	return nil
+}
+
+func (r *repository) ManagedHookID() (int64, error) {
+	repo, err := r.store.GetRepositoryByID(r.ctx, r.id)
+	if err != nil {
+		return 0, errors.Wrap(err, "fetching repo")
+	}
+	return repo.ManagedHookID, nil
+}
+
+func (r *repository) SetManagedHookID(hookID int64) error {
+	if err := r.store.SetRepositoryManagedHookID(r.ctx, r.id, hookID); err != nil {
+		return errors.Wrap(err, "recording managed hook")
+	}
+	return nil
+}
+
+func (r *repository) ListHookDeliveries(hookID int64, since time.Time) ([]*github.HookDelivery, error) {
+	opts := github.ListCursorOptions{
+		PerPage: 100,
+	}
+
+	var allDeliveries []*github.HookDelivery
+	for {
+		deliveries, ghResp, err := r.ghcli.ListHookDeliveries(r.ctx, r.cfg.Owner, r.cfg.Name, hookID, &opts)
+		if err != nil {
+			return nil, wrapGithubError(ghResp, err, "fetching hook deliveries")
+		}
+		allDeliveries = append(allDeliveries, deliveries...)
+		if ghResp.Cursor == "" || len(deliveries) == 0 {
+			break
+		}
+		// Deliveries are sorted newest first. Stop once we reach deliveries older than since.
+		if deliveries[len(deliveries)-1].GetDeliveredAt().Before(since) {
+			break
+		}
+		opts.Cursor = ghResp.Cursor
+	}
+	return allDeliveries, nil
+}
+
+func (r *repository) GetHookDelivery(hookID, deliveryID int64) (*github.HookDelivery, error) {
+	delivery, ghResp, err := r.ghcli.GetHookDelivery(r.ctx, r.cfg.Owner, r.cfg.Name, hookID, deliveryID)
+	if err != nil {
+		return nil, wrapGithubError(ghResp, err, "fetching hook delivery")
+	}
+	return delivery, nil
+}
@@ -2,42 +2,111 @@ package pool
 
 import (
 	"log"
+	"math"
+	"math/rand"
+	"net/http"
 	"sort"
 	"strings"
 	"sync"
 	"sync/atomic"
+	"time"
 
 	runnerErrors "github.com/cloudbase/garm/errors"
 	"github.com/cloudbase/garm/params"
+	"github.com/cloudbase/garm/util/appdefaults"
+
+	"github.com/google/go-github/v53/github"
+	"github.com/pkg/errors"
 )
 
-type poolRoundRobin struct {
-	pools []params.Pool
-	next  uint32
+// poolSelection holds the pools that match a set of labels, and decides the
+// order in which they are tried when creating a runner for a job.
+type poolSelection struct {
+	pools    []params.Pool
+	strategy params.PoolSelectionStrategy
+	// poolLoad returns the ratio of runners to max runners of a pool. It is
+	// used by the least_loaded strategy.
+	poolLoad func(pool params.Pool) float64
+	next     uint32
 }
 
-func (p *poolRoundRobin) Next() (params.Pool, error) {
+func (p *poolSelection) Len() int {
+	return len(p.pools)
+}
+
+// Order returns the pools in the order in which they should be tried. The pools
+// are rotated on each call, so pools the strategy considers equal are tried in
+// a round robin fashion.
+func (p *poolSelection) Order() []params.Pool {
 	if len(p.pools) == 0 {
-		return params.Pool{}, runnerErrors.ErrNoPoolsAvailable
+		return nil
 	}
 
 	n := atomic.AddUint32(&p.next, 1)
-	return p.pools[(int(n)-1)%len(p.pools)], nil
-}
+	offset := (int(n) - 1) % len(p.pools)
+	ordered := make([]params.Pool, 0, len(p.pools))
+	ordered = append(ordered, p.pools[offset:]...)
+	ordered = append(ordered, p.pools[:offset]...)
 
-func (p *poolRoundRobin) Len() int {
-	return len(p.pools)
+	switch p.strategy {
+	case params.PoolSelectionPriority:
+		sort.SliceStable(ordered, func(i, j int) bool {
+			return ordered[i].Priority > ordered[j].Priority
+		})
+	case params.PoolSelectionLeastLoaded:
+		if p.poolLoad == nil {
+			break
+		}
+		load := make(map[string]float64, len(ordered))
+		for _, pool := range ordered {
+			load[pool.ID] = p.poolLoad(pool)
+		}
+		sort.SliceStable(ordered, func(i, j int) bool {
+			return load[ordered[i].ID] < load[ordered[j].ID]
+		})
+	case params.PoolSelectionWeightedRandom:
+		ordered = weightedShuffle(ordered)
+	}
+	return ordered
 }
 
-func (p *poolRoundRobin) Reset() {
-	atomic.StoreUint32(&p.next, 0)
+// weightedShuffle returns the pools in a random order, where the odds of a pool
+// being ahead of another are proportional to their weights. Pools with a weight
+// of 0 are always last.
+func weightedShuffle(pools []params.Pool) []params.Pool {
+	type weightedPool struct {
+		pool params.Pool
+		key  float64
+	}
+
+	// Each pool gets a key of rand^(1/weight). Sorting by this key gives a weighted
+	// random sample without replacement (Efraimidis-Spirakis).
+	weighted := make([]weightedPool, len(pools))
+	for idx, pool := range pools {
+		key := -1.0
+		if pool.Weight > 0 {
+			key = math.Pow(rand.Float64(), 1/float64(pool.Weight))
+		}
+		weighted[idx] = weightedPool{pool: pool, key: key}
+	}
+	sort.SliceStable(weighted, func(i, j int) bool {
+		return weighted[i].key > weighted[j].key
+	})
+
+	ret := make([]params.Pool, len(weighted))
+	for idx, w := range weighted {
+		ret[idx] = w.pool
+	}
+	return ret
 }
 
 type poolsForTags struct {
-	pools sync.Map
+	pools    sync.Map
+	strategy params.PoolSelectionStrategy
+	poolLoad func(pool params.Pool) float64
 }
 
-func (p *poolsForTags) Get(tags []string) (*poolRoundRobin, bool) {
+func (p *poolsForTags) Get(tags []string) (*poolSelection, bool) {
 	sort.Strings(tags)
 	key := strings.Join(tags, "^")
 
@@ -46,16 +115,20 @@ func (p *poolsForTags) Get(tags []string) (*poolRoundRobin, bool) {
 		return nil, false
 	}
 
-	return v.(*poolRoundRobin), true
+	return v.(*poolSelection), true
 }
 
-func (p *poolsForTags) Add(tags []string, pools []params.Pool) *poolRoundRobin {
+func (p *poolsForTags) Add(tags []string, pools []params.Pool) *poolSelection {
 	sort.Strings(tags)
 	key := strings.Join(tags, "^")
 
-	poolRR := &poolRoundRobin{pools: pools}
-	v, _ := p.pools.LoadOrStore(key, poolRR)
-	return v.(*poolRoundRobin)
+	selection := &poolSelection{
+		pools:    pools,
+		strategy: p.strategy,
+		poolLoad: p.poolLoad,
+	}
+	v, _ := p.pools.LoadOrStore(key, selection)
+	return v.(*poolSelection)
 }
 
 func (r *basePoolManager) log(msg string, args ...interface{}) {
@@ -65,3 +138,29 @@ func (r *basePoolManager) log(msg string, args ...interface{}) {
 	msgArgs = append(msgArgs, args...)
 	log.Printf("[Pool mgr %s] "+msg, msgArgs...)
 }
+
+// pollingInterval returns the interval at which we poll the github API for
+// workflow jobs, or 0 if polling is disabled.
+func pollingInterval(enabled bool, seconds uint) time.Duration {
+	if !enabled {
+		return 0
+	}
+	if seconds == 0 {
+		seconds = appdefaults.DefaultPollingInterval
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// wrapGithubError maps well known github API error responses to the errors
+// used throughout garm, so they can be surfaced with the proper status code.
+func wrapGithubError(resp *github.Response, err error, msg string) error {
+	if resp != nil {
+		switch resp.StatusCode {
+		case http.StatusUnauthorized:
+			return errors.Wrap(runnerErrors.ErrUnauthorized, msg)
+		case http.StatusNotFound:
+			return errors.Wrap(runnerErrors.ErrNotFound, msg)
+		}
+	}
+	return errors.Wrap(err, msg)
+}
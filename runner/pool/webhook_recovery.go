@@ -0,0 +1,168 @@
+// Copyright 2023 Cloudbase Solutions SRL
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package pool
+
+import (
+	"time"
+
+	runnerErrors "github.com/cloudbase/garm/errors"
+	"github.com/cloudbase/garm/params"
+	"github.com/cloudbase/garm/runner/common"
+	"github.com/cloudbase/garm/util"
+
+	"github.com/google/go-github/v53/github"
+	"github.com/pkg/errors"
+)
+
+// recoverMissedDeliveries asks github for workflow_job deliveries of the garm webhook that
+// we never recorded, and adds them to the webhook delivery queue. Only deliveries received
+// after the high-water mark of this entity are considered. This only works for the webhook
+// garm installed, as we need to find the webhook to list its deliveries.
+func (r *basePoolManager) recoverMissedDeliveries() {
+	if r.helper.GetWebhookURL() == "" || r.helper.PoolType() == params.EnterprisePool {
+		return
+	}
+
+	if !r.recoveryMux.TryLock() {
+		return
+	}
+	defer r.recoveryMux.Unlock()
+
+	recovered, err := r.recoverMissedDeliveriesForHook()
+	if err != nil {
+		r.log("failed to recover missed webhook deliveries: %s", err)
+		return
+	}
+	if recovered > 0 {
+		r.log("recovered %d missed webhook deliveries", recovered)
+	}
+}
+
+func (r *basePoolManager) recoverMissedDeliveriesForHook() (int, error) {
+	hook, err := r.getManagedHook()
+	if err != nil {
+		if errors.Is(err, runnerErrors.ErrNotFound) {
+			return 0, nil
+		}
+		return 0, errors.Wrap(err, "fetching managed webhook")
+	}
+
+	mark, err := r.store.GetWebhookDeliveryHighWaterMark(r.ctx, r.helper.PoolType(), r.helper.ID())
+	if err != nil {
+		return 0, errors.Wrap(err, "fetching webhook delivery high-water mark")
+	}
+
+	since := mark.Add(-common.WebhookDeliveryRecoveryOverlap)
+	oldest := time.Now().UTC().Add(-common.WebhookDeliveryRecoveryWindow)
+	if since.Before(oldest) {
+		since = oldest
+	}
+
+	deliveries, err := r.helper.ListHookDeliveries(hook.GetID(), since)
+	if err != nil {
+		return 0, errors.Wrap(err, "listing hook deliveries")
+	}
+
+	recovered := 0
+	// Deliveries are listed newest first. Queue the oldest ones first.
+	for idx := len(deliveries) - 1; idx >= 0; idx-- {
+		delivery := deliveries[idx]
+		if !r.isMissedDelivery(delivery, since) {
+			continue
+		}
+
+		_, err := r.store.GetWebhookDelivery(r.ctx, delivery.GetGUID())
+		if err == nil {
+			// We recorded this delivery, even if github did not get our response.
+			continue
+		}
+		if !errors.Is(err, runnerErrors.ErrNotFound) {
+			return recovered, errors.Wrap(err, "fetching webhook delivery")
+		}
+
+		if err := r.queueMissedDelivery(hook.GetID(), delivery); err != nil {
+			return recovered, errors.Wrapf(err, "queueing webhook delivery %s", delivery.GetGUID())
+		}
+		recovered++
+	}
+	return recovered, nil
+}
+
+// isMissedDelivery returns true for workflow_job deliveries, received after since, that
+// garm did not accept. Deliveries garm accepted were recorded when they were received.
+func (r *basePoolManager) isMissedDelivery(delivery *github.HookDelivery, since time.Time) bool {
+	if delivery.GetEvent() != workflowJobEvent || delivery.GetGUID() == "" {
+		return false
+	}
+
+	if delivery.GetDeliveredAt().Before(since) {
+		return false
+	}
+
+	statusCode := delivery.GetStatusCode()
+	return statusCode < 200 || statusCode > 299
+}
+
+func (r *basePoolManager) queueMissedDelivery(hookID int64, delivery *github.HookDelivery) error {
+	// The delivery list does not include the payload. We need to fetch each delivery.
+	details, err := r.helper.GetHookDelivery(hookID, delivery.GetID())
+	if err != nil {
+		return errors.Wrap(err, "fetching hook delivery")
+	}
+	if details.Request == nil || details.Request.RawPayload == nil {
+		return runnerErrors.NewBadRequestError("hook delivery has no payload")
+	}
+
+	hookTargetType, err := hookTargetTypeForPool(r.helper.PoolType())
+	if err != nil {
+		return err
+	}
+
+	_, err = r.store.CreateWebhookDelivery(r.ctx, params.WebhookDelivery{
+		ID:             delivery.GetGUID(),
+		Event:          params.WorkflowJobEvent,
+		EntityID:       r.helper.ID(),
+		HookTargetType: hookTargetType,
+		Payload:        *details.Request.RawPayload,
+		Status:         params.WebhookDeliveryPending,
+		NextAttemptAt:  time.Now().UTC(),
+		CreatedAt:      delivery.GetDeliveredAt().UTC(),
+	})
+	if err != nil {
+		var conflictErr *runnerErrors.ConflictError
+		if errors.As(err, &conflictErr) {
+			// The delivery arrived while we were fetching it.
+			return nil
+		}
+		return errors.Wrap(err, "recording webhook delivery")
+	}
+	r.log("queued missed webhook delivery %s", util.SanitizeLogEntry(delivery.GetGUID()))
+	return nil
+}
+
+// hookTargetTypeForPool returns the value github sets in the X-GitHub-Hook-Installation-Target-Type
+// header for webhooks defined on the given entity type.
+func hookTargetTypeForPool(poolType params.PoolType) (string, error) {
+	switch poolType {
+	case params.RepositoryPool:
+		return "repository", nil
+	case params.OrganizationPool:
+		return "organization", nil
+	case params.EnterprisePool:
+		return "business", nil
+	default:
+		return "", runnerErrors.NewBadRequestError("invalid pool type %s", poolType)
+	}
+}
@@ -17,10 +17,12 @@ package runner
 import (
 	"context"
 	"fmt"
+	"sort"
 
 	"github.com/cloudbase/garm/auth"
 	runnerErrors "github.com/cloudbase/garm/errors"
 	"github.com/cloudbase/garm/params"
+	"github.com/cloudbase/garm/runner/pool"
 
 	"github.com/pkg/errors"
 )
@@ -84,6 +86,7 @@ func (r *Runner) UpdatePoolByID(ctx context.Context, poolID string, param params
 
 	maxRunners := pool.MaxRunners
 	minIdleRunners := pool.MinIdleRunners
+	warmRunners := pool.WarmRunners
 
 	if param.MaxRunners != nil {
 		maxRunners = *param.MaxRunners
@@ -91,6 +94,9 @@ func (r *Runner) UpdatePoolByID(ctx context.Context, poolID string, param params
 	if param.MinIdleRunners != nil {
 		minIdleRunners = *param.MinIdleRunners
 	}
+	if param.WarmRunners != nil {
+		warmRunners = *param.WarmRunners
+	}
 
 	if param.RunnerBootstrapTimeout != nil && *param.RunnerBootstrapTimeout == 0 {
 		return params.Pool{}, runnerErrors.NewBadRequestError("runner_bootstrap_timeout cannot be 0")
@@ -100,6 +106,24 @@ func (r *Runner) UpdatePoolByID(ctx context.Context, poolID string, param params
 		return params.Pool{}, runnerErrors.NewBadRequestError("min_idle_runners cannot be larger than max_runners")
 	}
 
+	if minIdleRunners+warmRunners > maxRunners {
+		return params.Pool{}, runnerErrors.NewBadRequestError("min_idle_runners plus warm_runners cannot be larger than max_runners")
+	}
+
+	if err := validateScalingSchedules(poolWithUpdate(pool, param)); err != nil {
+		return params.Pool{}, err
+	}
+
+	if param.FallbackProviders != nil {
+		if err := r.validateFallbackProviders(pool.ProviderName, param.FallbackProviders); err != nil {
+			return params.Pool{}, err
+		}
+	}
+
+	if err := r.validateProviderCapabilities(ctx, poolWithUpdate(pool, param), imagesChanged(param)); err != nil {
+		return params.Pool{}, err
+	}
+
 	if param.Tags != nil && len(param.Tags) > 0 {
 		newTags, err := r.processTags(string(pool.OSArch), pool.OSType, param.Tags)
 		if err != nil {
@@ -126,6 +150,72 @@ func (r *Runner) UpdatePoolByID(ctx context.Context, poolID string, param params
 	return newPool, nil
 }
 
+// ResetPoolCircuitBreaker closes the circuit breaker of a pool, and forgets the
+// provisioning failures it counted. The pool starts creating runners right away.
+func (r *Runner) ResetPoolCircuitBreaker(ctx context.Context, poolID string) (params.Pool, error) {
+	if !auth.IsAdmin(ctx) {
+		return params.Pool{}, runnerErrors.ErrUnauthorized
+	}
+
+	pool, err := r.store.SetPoolCircuitBreaker(ctx, poolID, params.CircuitBreaker{})
+	if err != nil {
+		return params.Pool{}, errors.Wrap(err, "resetting circuit breaker")
+	}
+	return pool, nil
+}
+
+// GetPoolQuota returns the number of runners of a pool used by each repository.
+func (r *Runner) GetPoolQuota(ctx context.Context, poolID string) (params.PoolQuota, error) {
+	if !auth.IsAdmin(ctx) {
+		return params.PoolQuota{}, runnerErrors.ErrUnauthorized
+	}
+
+	quotaPool, err := r.store.GetPoolByID(ctx, poolID)
+	if err != nil {
+		return params.PoolQuota{}, errors.Wrap(err, "fetching pool")
+	}
+
+	instances, err := r.store.ListPoolInstances(ctx, poolID)
+	if err != nil {
+		return params.PoolQuota{}, errors.Wrap(err, "fetching instances")
+	}
+
+	var entityID string
+	switch quotaPool.PoolType() {
+	case params.RepositoryPool:
+		entityID = quotaPool.RepoID
+	case params.OrganizationPool:
+		entityID = quotaPool.OrgID
+	case params.EnterprisePool:
+		entityID = quotaPool.EnterpriseID
+	}
+
+	jobs := []params.Job{}
+	for _, status := range []params.JobStatus{params.JobStatusQueued, params.JobStatusInProgress} {
+		statusJobs, err := r.store.ListEntityJobsByStatus(ctx, quotaPool.PoolType(), entityID, status)
+		if err != nil {
+			return params.PoolQuota{}, errors.Wrap(err, "fetching jobs")
+		}
+		jobs = append(jobs, statusJobs...)
+	}
+
+	ret := params.PoolQuota{
+		PoolID:                  quotaPool.ID,
+		MaxRunnersPerRepository: quotaPool.MaxRunnersPerRepository,
+		Repositories:            []params.RepositoryRunners{},
+	}
+	for repo, runners := range pool.CountRepositoryRunners(instances, jobs) {
+		ret.Repositories = append(ret.Repositories, params.RepositoryRunners{
+			Repository: repo,
+			Runners:    runners,
+		})
+	}
+	sort.Slice(ret.Repositories, func(i, j int) bool {
+		return ret.Repositories[i].Repository < ret.Repositories[j].Repository
+	})
+	return ret, nil
+}
+
 func (r *Runner) ListAllJobs(ctx context.Context) ([]params.Job, error) {
 	if !auth.IsAdmin(ctx) {
 		return []params.Job{}, runnerErrors.ErrUnauthorized
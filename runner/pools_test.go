@@ -18,6 +18,7 @@ import (
 	"context"
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/cloudbase/garm/auth"
 	"github.com/cloudbase/garm/config"
@@ -27,6 +28,9 @@ import (
 	garmTesting "github.com/cloudbase/garm/internal/testing"
 	"github.com/cloudbase/garm/params"
 	"github.com/cloudbase/garm/runner/common"
+	runnerCommonMocks "github.com/cloudbase/garm/runner/common/mocks"
+
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/suite"
 )
 
@@ -57,7 +61,7 @@ func (s *PoolTestSuite) SetupTest() {
 	}
 
 	// create an organization for testing purposes
-	org, err := db.CreateOrganization(context.Background(), "test-org", "test-creds", "test-webhookSecret")
+	org, err := db.CreateOrganization(context.Background(), "https://github.com", "test-org", "test-creds", "test-webhookSecret")
 	if err != nil {
 		s.FailNow(fmt.Sprintf("failed to create org: %s", err))
 	}
@@ -228,6 +232,373 @@ func (s *PoolTestSuite) TestTestUpdatePoolByIDMinIdleGreaterThanMax() {
 	s.Require().Equal(runnerErrors.NewBadRequestError("min_idle_runners cannot be larger than max_runners"), err)
 }
 
+func (s *PoolTestSuite) TestUpdatePoolByIDWarmRunners() {
+	var warmRunners uint = 5
+	s.Fixtures.UpdatePoolParams.WarmRunners = &warmRunners
+
+	pool, err := s.Runner.UpdatePoolByID(s.Fixtures.AdminContext, s.Fixtures.Pools[0].ID, s.Fixtures.UpdatePoolParams)
+
+	s.Require().Nil(err)
+	s.Require().Equal(warmRunners, pool.WarmRunners)
+}
+
+func (s *PoolTestSuite) TestUpdatePoolByIDIdleTimeoutAndMaxLifetime() {
+	var idleTimeout uint = 15
+	var maxLifetime uint = 1440
+	s.Fixtures.UpdatePoolParams.IdleTimeout = &idleTimeout
+	s.Fixtures.UpdatePoolParams.MaxLifetime = &maxLifetime
+
+	pool, err := s.Runner.UpdatePoolByID(s.Fixtures.AdminContext, s.Fixtures.Pools[0].ID, s.Fixtures.UpdatePoolParams)
+
+	s.Require().Nil(err)
+	s.Require().Equal(idleTimeout, pool.IdleTimeout)
+	s.Require().Equal(maxLifetime, pool.MaxLifetime)
+	s.Require().Equal(15*time.Minute, pool.GetIdleTimeout())
+}
+
+func (s *PoolTestSuite) TestUpdatePoolByIDCircuitBreaker() {
+	var threshold uint = 3
+	var window uint = 60
+	s.Fixtures.UpdatePoolParams.CircuitBreakerThreshold = &threshold
+	s.Fixtures.UpdatePoolParams.CircuitBreakerWindow = &window
+
+	pool, err := s.Runner.UpdatePoolByID(s.Fixtures.AdminContext, s.Fixtures.Pools[0].ID, s.Fixtures.UpdatePoolParams)
+
+	s.Require().Nil(err)
+	s.Require().Equal(threshold, pool.GetCircuitBreakerThreshold())
+	s.Require().Equal(time.Hour, pool.GetCircuitBreakerWindow())
+}
+
+func (s *PoolTestSuite) TestResetPoolCircuitBreaker() {
+	openUntil := time.Now().UTC().Add(time.Hour)
+	_, err := s.Fixtures.Store.SetPoolCircuitBreaker(s.Fixtures.AdminContext, s.Fixtures.Pools[0].ID, params.CircuitBreaker{
+		ConsecutiveFailures: 5,
+		Trips:               1,
+		OpenUntil:           &openUntil,
+		FailureReason:       "failed to create instance",
+	})
+	s.Require().Nil(err)
+
+	pool, err := s.Runner.ResetPoolCircuitBreaker(s.Fixtures.AdminContext, s.Fixtures.Pools[0].ID)
+
+	s.Require().Nil(err)
+	s.Require().False(pool.CircuitBreaker.IsOpen(time.Now()))
+	s.Require().Equal(uint(0), pool.CircuitBreaker.ConsecutiveFailures)
+	s.Require().Empty(pool.CircuitBreaker.FailureReason)
+}
+
+func (s *PoolTestSuite) TestResetPoolCircuitBreakerErrUnauthorized() {
+	_, err := s.Runner.ResetPoolCircuitBreaker(context.Background(), s.Fixtures.Pools[0].ID)
+
+	s.Require().NotNil(err)
+	s.Require().Equal(runnerErrors.ErrUnauthorized, err)
+}
+
+func (s *PoolTestSuite) TestGetPoolQuota() {
+	var maxRunnersPerRepository uint = 2
+	s.Fixtures.UpdatePoolParams.MaxRunnersPerRepository = &maxRunnersPerRepository
+	_, err := s.Runner.UpdatePoolByID(s.Fixtures.AdminContext, s.Fixtures.Pools[0].ID, s.Fixtures.UpdatePoolParams)
+	s.Require().Nil(err)
+
+	orgID := uuid.MustParse(s.Fixtures.Pools[0].OrgID)
+	jobs := []params.Job{
+		{ID: 10, Status: string(params.JobStatusQueued), RepositoryOwner: "test-owner", RepositoryName: "repo-a", OrgID: &orgID},
+		{ID: 11, Status: string(params.JobStatusInProgress), RepositoryOwner: "test-owner", RepositoryName: "repo-b", RunnerName: "test-runner-2", OrgID: &orgID},
+	}
+	for _, job := range jobs {
+		_, err := s.Fixtures.Store.CreateOrUpdateJob(s.Fixtures.AdminContext, job)
+		s.Require().Nil(err)
+	}
+
+	instances := []params.CreateInstanceParams{
+		{Name: "test-runner-1", OSType: "linux", AditionalLabels: []string{"in_response_to_job:10"}},
+		{Name: "test-runner-2", OSType: "linux"},
+		{Name: "test-runner-3", OSType: "linux"},
+	}
+	for _, instance := range instances {
+		_, err := s.Fixtures.Store.CreateInstance(s.Fixtures.AdminContext, s.Fixtures.Pools[0].ID, instance)
+		s.Require().Nil(err)
+	}
+
+	quota, err := s.Runner.GetPoolQuota(s.Fixtures.AdminContext, s.Fixtures.Pools[0].ID)
+
+	s.Require().Nil(err)
+	s.Require().Equal(s.Fixtures.Pools[0].ID, quota.PoolID)
+	s.Require().Equal(maxRunnersPerRepository, quota.MaxRunnersPerRepository)
+	s.Require().Equal([]params.RepositoryRunners{
+		{Repository: "test-owner/repo-a", Runners: 1},
+		{Repository: "test-owner/repo-b", Runners: 1},
+	}, quota.Repositories)
+}
+
+func (s *PoolTestSuite) TestGetPoolQuotaErrUnauthorized() {
+	_, err := s.Runner.GetPoolQuota(context.Background(), s.Fixtures.Pools[0].ID)
+
+	s.Require().Equal(runnerErrors.ErrUnauthorized, err)
+}
+
+func (s *PoolTestSuite) TestUpdatePoolByIDPriorityAndWeight() {
+	var priority uint = 100
+	var weight uint = 3
+	s.Fixtures.UpdatePoolParams.Priority = &priority
+	s.Fixtures.UpdatePoolParams.Weight = &weight
+
+	pool, err := s.Runner.UpdatePoolByID(s.Fixtures.AdminContext, s.Fixtures.Pools[0].ID, s.Fixtures.UpdatePoolParams)
+
+	s.Require().Nil(err)
+	s.Require().Equal(priority, pool.Priority)
+	s.Require().Equal(weight, pool.Weight)
+}
+
+func (s *PoolTestSuite) TestUpdatePoolByIDRollingReplacement() {
+	var maxSurge uint = 3
+	replacementCanary := true
+	s.Fixtures.UpdatePoolParams.MaxSurge = &maxSurge
+	s.Fixtures.UpdatePoolParams.ReplacementCanary = &replacementCanary
+
+	pool, err := s.Runner.UpdatePoolByID(s.Fixtures.AdminContext, s.Fixtures.Pools[0].ID, s.Fixtures.UpdatePoolParams)
+
+	s.Require().Nil(err)
+	s.Require().Equal(maxSurge, pool.MaxSurge)
+	s.Require().True(pool.ReplacementCanary)
+	// The fixture changes the image and flavor of the pool.
+	s.Require().Equal(s.Fixtures.Pools[0].ConfigRevision+1, pool.ConfigRevision)
+}
+
+func (s *PoolTestSuite) TestTestUpdatePoolByIDMinIdleAndWarmGreaterThanMax() {
+	var maxRunners uint = 10
+	var minIdleRunners uint = 6
+	var warmRunners uint = 5
+	s.Fixtures.UpdatePoolParams.MaxRunners = &maxRunners
+	s.Fixtures.UpdatePoolParams.MinIdleRunners = &minIdleRunners
+	s.Fixtures.UpdatePoolParams.WarmRunners = &warmRunners
+
+	_, err := s.Runner.UpdatePoolByID(s.Fixtures.AdminContext, s.Fixtures.Pools[0].ID, s.Fixtures.UpdatePoolParams)
+
+	s.Require().NotNil(err)
+	s.Require().Equal(runnerErrors.NewBadRequestError("min_idle_runners plus warm_runners cannot be larger than max_runners"), err)
+}
+
+func (s *PoolTestSuite) TestUpdatePoolByIDScalingSchedules() {
+	s.Fixtures.UpdatePoolParams.ScalingSchedules = []params.ScalingSchedule{
+		{
+			Name:           "never",
+			Schedule:       "0 0 30 2 *",
+			MinIdleRunners: 0,
+			MaxRunners:     1,
+		},
+		{
+			Name:           "always",
+			Schedule:       "* * * * *",
+			Timezone:       "Europe/Bucharest",
+			MinIdleRunners: 1,
+			MaxRunners:     2,
+		},
+	}
+
+	pool, err := s.Runner.UpdatePoolByID(s.Fixtures.AdminContext, s.Fixtures.Pools[0].ID, s.Fixtures.UpdatePoolParams)
+
+	s.Require().Nil(err)
+	s.Require().Equal(s.Fixtures.UpdatePoolParams.ScalingSchedules, pool.ScalingSchedules)
+	s.Require().Equal("always", pool.ActiveScalingSchedule)
+
+	scheduled := pool.WithScalingSchedule(time.Now())
+	s.Require().Equal(uint(1), scheduled.MinIdleRunners)
+	s.Require().Equal(uint(2), scheduled.MaxRunners)
+
+	s.Fixtures.UpdatePoolParams.ScalingSchedules = []params.ScalingSchedule{}
+	pool, err = s.Runner.UpdatePoolByID(s.Fixtures.AdminContext, s.Fixtures.Pools[0].ID, s.Fixtures.UpdatePoolParams)
+
+	s.Require().Nil(err)
+	s.Require().Len(pool.ScalingSchedules, 0)
+	s.Require().Equal("", pool.ActiveScalingSchedule)
+}
+
+func (s *PoolTestSuite) TestUpdatePoolByIDInvalidScalingSchedule() {
+	s.Fixtures.UpdatePoolParams.ScalingSchedules = []params.ScalingSchedule{
+		{
+			Name:           "invalid",
+			Schedule:       "* * * * * * *",
+			MinIdleRunners: 1,
+			MaxRunners:     2,
+		},
+	}
+
+	_, err := s.Runner.UpdatePoolByID(s.Fixtures.AdminContext, s.Fixtures.Pools[0].ID, s.Fixtures.UpdatePoolParams)
+
+	s.Require().NotNil(err)
+	s.Require().Equal(runnerErrors.NewBadRequestError("invalid schedule for scaling schedule invalid: expected exactly 5 fields, found 7: [* * * * * * *]"), err)
+}
+
+func (s *PoolTestSuite) TestUpdatePoolByIDScalingScheduleWarmRunners() {
+	var warmRunners uint = 2
+	s.Fixtures.UpdatePoolParams.WarmRunners = &warmRunners
+	s.Fixtures.UpdatePoolParams.ScalingSchedules = []params.ScalingSchedule{
+		{
+			Name:           "small",
+			Schedule:       "* * * * *",
+			MinIdleRunners: 1,
+			MaxRunners:     2,
+		},
+	}
+
+	_, err := s.Runner.UpdatePoolByID(s.Fixtures.AdminContext, s.Fixtures.Pools[0].ID, s.Fixtures.UpdatePoolParams)
+
+	s.Require().NotNil(err)
+	s.Require().Equal(runnerErrors.NewBadRequestError("min_idle_runners plus warm_runners cannot be larger than max_runners for scaling schedule small"), err)
+
+	// Schedules already set on the pool are checked when only the warm runners change.
+	s.Fixtures.UpdatePoolParams.WarmRunners = nil
+	_, err = s.Runner.UpdatePoolByID(s.Fixtures.AdminContext, s.Fixtures.Pools[0].ID, s.Fixtures.UpdatePoolParams)
+	s.Require().Nil(err)
+
+	s.Fixtures.UpdatePoolParams.WarmRunners = &warmRunners
+	s.Fixtures.UpdatePoolParams.ScalingSchedules = nil
+	_, err = s.Runner.UpdatePoolByID(s.Fixtures.AdminContext, s.Fixtures.Pools[0].ID, s.Fixtures.UpdatePoolParams)
+
+	s.Require().NotNil(err)
+	s.Require().Equal(runnerErrors.NewBadRequestError("min_idle_runners plus warm_runners cannot be larger than max_runners for scaling schedule small"), err)
+}
+
+func (s *PoolTestSuite) TestStartPoolDrain() {
+	pool, err := s.Runner.StartPoolDrain(s.Fixtures.AdminContext, s.Fixtures.Pools[0].ID)
+
+	s.Require().Nil(err)
+	s.Require().True(pool.Draining)
+	s.Require().NotNil(pool.DrainStartedAt)
+}
+
+func (s *PoolTestSuite) TestStartPoolDrainErrUnauthorized() {
+	_, err := s.Runner.StartPoolDrain(context.Background(), s.Fixtures.Pools[0].ID)
+
+	s.Require().NotNil(err)
+	s.Require().Equal(runnerErrors.ErrUnauthorized, err)
+}
+
+func (s *PoolTestSuite) TestStopPoolDrain() {
+	_, err := s.Runner.StartPoolDrain(s.Fixtures.AdminContext, s.Fixtures.Pools[0].ID)
+	s.Require().Nil(err)
+
+	pool, err := s.Runner.StopPoolDrain(s.Fixtures.AdminContext, s.Fixtures.Pools[0].ID)
+
+	s.Require().Nil(err)
+	s.Require().False(pool.Draining)
+	s.Require().Nil(pool.DrainStartedAt)
+}
+
+func (s *PoolTestSuite) TestGetPoolDrainStatus() {
+	_, err := s.Runner.StartPoolDrain(s.Fixtures.AdminContext, s.Fixtures.Pools[0].ID)
+	s.Require().Nil(err)
+	_, err = s.Fixtures.Store.CreateInstance(s.Fixtures.AdminContext, s.Fixtures.Pools[0].ID, s.Fixtures.CreateInstanceParams)
+	if err != nil {
+		s.FailNow(fmt.Sprintf("cannot create instance: %s", err))
+	}
+
+	status, err := s.Runner.GetPoolDrainStatus(s.Fixtures.AdminContext, s.Fixtures.Pools[0].ID)
+
+	s.Require().Nil(err)
+	s.Require().True(status.Draining)
+	s.Require().NotNil(status.StartedAt)
+	s.Require().Equal(uint(1), status.RemainingRunners)
+	s.Require().False(status.Finished)
+}
+
+func (s *PoolTestSuite) TestGetPoolDrainStatusFinished() {
+	_, err := s.Runner.StartPoolDrain(s.Fixtures.AdminContext, s.Fixtures.Pools[0].ID)
+	s.Require().Nil(err)
+
+	status, err := s.Runner.GetPoolDrainStatus(s.Fixtures.AdminContext, s.Fixtures.Pools[0].ID)
+
+	s.Require().Nil(err)
+	s.Require().True(status.Draining)
+	s.Require().Equal(uint(0), status.RemainingRunners)
+	s.Require().True(status.Finished)
+}
+
+func (s *PoolTestSuite) TestGetPoolDrainStatusProviderDraining() {
+	err := s.Fixtures.Store.SetProviderDrain(s.Fixtures.AdminContext, s.Fixtures.Pools[0].ProviderName, true)
+	s.Require().Nil(err)
+
+	status, err := s.Runner.GetPoolDrainStatus(s.Fixtures.AdminContext, s.Fixtures.Pools[0].ID)
+
+	s.Require().Nil(err)
+	s.Require().True(status.Draining)
+	s.Require().NotNil(status.StartedAt)
+	s.Require().True(status.Finished)
+}
+
+func (s *PoolTestSuite) TestGetPoolDrainStatusNotDraining() {
+	status, err := s.Runner.GetPoolDrainStatus(s.Fixtures.AdminContext, s.Fixtures.Pools[0].ID)
+
+	s.Require().Nil(err)
+	s.Require().False(status.Draining)
+	s.Require().False(status.Finished)
+}
+
+func (s *PoolTestSuite) TestStartProviderDrainNotFound() {
+	_, err := s.Runner.StartProviderDrain(s.Fixtures.AdminContext, "dummy-provider")
+
+	s.Require().NotNil(err)
+	s.Require().Equal(runnerErrors.NewNotFoundError("provider dummy-provider not found"), err)
+}
+
+// healthCheckedProvider is a provider that records the result of its health checks
+// with a real health tracker.
+type healthCheckedProvider struct {
+	*runnerCommonMocks.Provider
+	common.HealthTracker
+
+	name      string
+	healthErr error
+}
+
+func (p *healthCheckedProvider) Health(ctx context.Context) error {
+	return p.RecordHealth(p.healthErr)
+}
+
+func (p *healthCheckedProvider) AsParams() params.Provider {
+	return params.Provider{Name: p.name, Health: p.ProviderHealth()}
+}
+
+func (s *PoolTestSuite) TestCheckProviderHealth() {
+	healthy := &healthCheckedProvider{name: "healthy-provider"}
+	failing := &healthCheckedProvider{name: "failing-provider", healthErr: fmt.Errorf("mock error")}
+	s.Runner.providers = map[string]common.Provider{
+		"healthy-provider": healthy,
+		"failing-provider": failing,
+	}
+
+	// A single failed check does not degrade the provider.
+	s.Runner.checkProviderHealth()
+	s.Require().Equal(params.ProviderHealthy, healthy.AsParams().Health.Status)
+	s.Require().False(failing.AsParams().Health.IsDegraded())
+	s.Require().NotNil(failing.AsParams().Health.LastCheckAt)
+
+	s.Runner.checkProviderHealth()
+	s.Runner.checkProviderHealth()
+	health := failing.AsParams().Health
+	s.Require().True(health.IsDegraded())
+	s.Require().NotNil(health.DegradedSince)
+	s.Require().Equal("mock error", health.Error)
+
+	// Further failures keep the time the provider was degraded.
+	s.Runner.checkProviderHealth()
+	s.Require().Equal(health.DegradedSince, failing.AsParams().Health.DegradedSince)
+
+	// The provider recovers as soon as a check succeeds.
+	failing.healthErr = nil
+	s.Runner.checkProviderHealth()
+	health = failing.AsParams().Health
+	s.Require().Equal(params.ProviderHealthy, health.Status)
+	s.Require().Nil(health.DegradedSince)
+	s.Require().Empty(health.Error)
+
+	// The failure count starts over after a recovery.
+	failing.healthErr = fmt.Errorf("mock error")
+	s.Runner.checkProviderHealth()
+	s.Require().False(failing.AsParams().Health.IsDegraded())
+}
+
 func TestPoolTestSuite(t *testing.T) {
 	suite.Run(t, new(PoolTestSuite))
 }
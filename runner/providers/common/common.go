@@ -20,6 +20,8 @@ type RunnerStatus string
 const (
 	InstanceRunning       InstanceStatus = "running"
 	InstanceStopped       InstanceStatus = "stopped"
+	InstanceStopping      InstanceStatus = "stopping"
+	InstanceStarting      InstanceStatus = "starting"
 	InstanceError         InstanceStatus = "error"
 	InstancePendingDelete InstanceStatus = "pending_delete"
 	InstanceDeleting      InstanceStatus = "deleting"
@@ -40,7 +42,8 @@ func IsValidStatus(status InstanceStatus) bool {
 	switch status {
 	case InstanceRunning, InstanceError, InstancePendingCreate,
 		InstancePendingDelete, InstanceStatusUnknown, InstanceStopped,
-		InstanceCreating, InstanceDeleting:
+		InstanceCreating, InstanceDeleting, InstanceStopping,
+		InstanceStarting:
 
 		return true
 	default:
@@ -0,0 +1,72 @@
+// Copyright 2023 Cloudbase Solutions SRL
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package external
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/cloudbase/garm/params"
+)
+
+// bulkListCacheTTL is the time for which the instances of all pools, listed at once,
+// are used to answer ListInstances for each pool.
+const bulkListCacheTTL = 10 * time.Second
+
+// bulkListCache answers ListInstances for providers that are able to list the instances
+// of all pools at once. Every pool manager lists the instances of its pools on each
+// reconcile, so this saves one call to the provider for every pool.
+type bulkListCache struct {
+	mux       sync.Mutex
+	instances []params.Instance
+	fetchedAt time.Time
+}
+
+// list returns the instances of a pool. The instances of all pools are listed again
+// using fetch, if the last listing is too old.
+func (b *bulkListCache) list(ctx context.Context, poolID string, fetch func(context.Context) ([]params.Instance, error)) ([]params.Instance, error) {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+
+	if b.instances == nil || time.Since(b.fetchedAt) > bulkListCacheTTL {
+		instances, err := fetch(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if instances == nil {
+			instances = []params.Instance{}
+		}
+		b.instances = instances
+		b.fetchedAt = time.Now()
+	}
+
+	ret := []params.Instance{}
+	for _, inst := range b.instances {
+		if inst.PoolID == poolID {
+			ret = append(ret, inst)
+		}
+	}
+	return ret, nil
+}
+
+// invalidate drops the last listing. It is called whenever instances are created or
+// deleted, so the pool managers see the change on their next reconcile.
+func (b *bulkListCache) invalidate() {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+
+	b.instances = nil
+}
@@ -0,0 +1,487 @@
+// Copyright 2023 Cloudbase Solutions SRL
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package external
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"sync"
+	"time"
+
+	"github.com/cloudbase/garm/config"
+	garmErrors "github.com/cloudbase/garm/errors"
+	"github.com/cloudbase/garm/params"
+	"github.com/cloudbase/garm/runner/common"
+	"github.com/cloudbase/garm/runner/providers/external/daemon"
+	"github.com/cloudbase/garm/runner/providers/external/execution"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// daemonStartTimeout is the time we wait for a provider we started to listen
+	// on its socket.
+	daemonStartTimeout = 30 * time.Second
+	// daemonDialInterval is the time between attempts to connect to a provider we started.
+	daemonDialInterval = 500 * time.Millisecond
+	// daemonRestartBaseBackoff is the time we wait before starting a provider that exited.
+	// It doubles every time the provider exits again, up to daemonRestartMaxBackoff.
+	daemonRestartBaseBackoff = time.Second
+	// daemonRestartMaxBackoff is the longest we wait before starting a provider that exited.
+	// A provider that ran for longer than this is started again after the base backoff.
+	daemonRestartMaxBackoff = time.Minute
+)
+
+var _ common.Provider = (*daemonProvider)(nil)
+
+func newDaemonProvider(ctx context.Context, cfg *config.Provider, controllerID string) (common.Provider, error) {
+	var execPath string
+	if cfg.External.StartDaemon {
+		var err error
+		execPath, err = cfg.External.ExecutablePath()
+		if err != nil {
+			return nil, errors.Wrap(err, "fetching executable path")
+		}
+	}
+
+	d := &daemonProvider{
+		ctx:          ctx,
+		controllerID: controllerID,
+		cfg:          cfg,
+		execPath:     execPath,
+	}
+
+	// Connect right away, to learn the version and capabilities of the provider. If
+	// the provider is not reachable yet, we learn them once it is.
+	connectCtx, cancel := context.WithTimeout(ctx, negotiateTimeout)
+	defer cancel()
+	if _, err := d.getClient(connectCtx); err != nil {
+		if errors.Is(err, execution.ErrUnsupportedInterfaceVersion) {
+			return nil, err
+		}
+		log.Printf("failed to connect to provider %s: %s", cfg.Name, err)
+	}
+	return d, nil
+}
+
+// daemonProvider is an external provider that runs as a long lived process. Garm talks
+// to it over a Unix socket, instead of running the provider executable for each operation.
+// The connection is established on first use, and again after it breaks.
+type daemonProvider struct {
+	ctx          context.Context
+	controllerID string
+	cfg          *config.Provider
+	execPath     string
+
+	mux        sync.Mutex
+	client     *daemon.Client
+	connecting *connectAttempt
+	cmd        *exec.Cmd
+	// restartBackoff is the time we waited before starting the provider the last time
+	// it exited, and restartAfter is the time after which it may be started again.
+	restartBackoff time.Duration
+	restartAfter   time.Time
+
+	// interfaceVersion and capabilities are reported by the provider when we connect.
+	// They are set while connecting, without holding d.mux, so they have their own lock.
+	infoMux          sync.Mutex
+	interfaceVersion string
+	capabilities     params.ProviderCapabilities
+	listCache        bulkListCache
+
+	common.HealthTracker
+}
+
+// connectAttempt is a connection to the provider that is being established. Callers
+// that need a client while it is in progress wait for its result, instead of connecting
+// again.
+type connectAttempt struct {
+	done   chan struct{}
+	client *daemon.Client
+	err    error
+}
+
+// startDaemon starts the provider executable, unless it is already running. A provider
+// that exited is only started again once its restart backoff has passed. The process is
+// stopped when garm shuts down. The caller must hold d.mux.
+func (d *daemonProvider) startDaemon() error {
+	if d.cmd != nil {
+		return nil
+	}
+	if wait := time.Until(d.restartAfter); wait > 0 {
+		return errors.Errorf("provider %s exited, restarting in %s", d.cfg.Name, wait.Round(time.Second))
+	}
+
+	cmd := exec.CommandContext(d.ctx, d.execPath)
+	cmd.Env = append(os.Environ(),
+		fmt.Sprintf("GARM_COMMAND=%s", execution.ServeCommand),
+		fmt.Sprintf("GARM_CONTROLLER_ID=%s", d.controllerID),
+		fmt.Sprintf("GARM_PROVIDER_CONFIG_FILE=%s", d.cfg.External.ConfigFile),
+		fmt.Sprintf("GARM_PROVIDER_SOCKET=%s", d.cfg.External.SocketPath),
+	)
+	cmd.Stdout = log.Writer()
+	cmd.Stderr = log.Writer()
+	if err := cmd.Start(); err != nil {
+		return errors.Wrapf(err, "starting provider %s", d.execPath)
+	}
+	d.cmd = cmd
+	startedAt := time.Now()
+
+	go func() {
+		err := cmd.Wait()
+
+		d.mux.Lock()
+		defer d.mux.Unlock()
+		if d.cmd != cmd {
+			return
+		}
+		d.cmd = nil
+		d.closeClient()
+
+		if time.Since(startedAt) > daemonRestartMaxBackoff {
+			d.restartBackoff = 0
+		}
+		d.restartBackoff = nextRestartBackoff(d.restartBackoff)
+		d.restartAfter = time.Now().Add(d.restartBackoff)
+		log.Printf("provider %s exited: %v; restarting in %s", d.cfg.Name, err, d.restartBackoff)
+	}()
+	return nil
+}
+
+// nextRestartBackoff returns the time to wait before starting a provider that exited,
+// given the time we waited the last time it exited.
+func nextRestartBackoff(last time.Duration) time.Duration {
+	if last == 0 {
+		return daemonRestartBaseBackoff
+	}
+	if last*2 > daemonRestartMaxBackoff {
+		return daemonRestartMaxBackoff
+	}
+	return last * 2
+}
+
+func (d *daemonProvider) closeClient() {
+	if d.client == nil {
+		return
+	}
+	if err := d.client.Close(); err != nil {
+		log.Printf("failed to close connection to provider %s: %s", d.cfg.Name, err)
+	}
+	d.client = nil
+}
+
+// dial connects to the provider. If we started the provider ourselves, we keep trying
+// until it listens on its socket.
+func (d *daemonProvider) dial(ctx context.Context) (*daemon.Client, error) {
+	if !d.cfg.External.StartDaemon {
+		return daemon.Dial(ctx, d.cfg.External.SocketPath, d.controllerID)
+	}
+
+	timeout := time.NewTimer(daemonStartTimeout)
+	defer timeout.Stop()
+	for {
+		client, err := daemon.Dial(ctx, d.cfg.External.SocketPath, d.controllerID)
+		if err == nil {
+			return client, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, err
+		case <-timeout.C:
+			return nil, err
+		case <-time.After(daemonDialInterval):
+		}
+	}
+}
+
+// getClient returns the connection to the provider, and connects to it if needed. Only
+// one connection is established at a time, without holding d.mux, as it may take until
+// the provider we started listens on its socket.
+func (d *daemonProvider) getClient(ctx context.Context) (*daemon.Client, error) {
+	d.mux.Lock()
+	if d.client != nil {
+		client := d.client
+		d.mux.Unlock()
+		return client, nil
+	}
+
+	if attempt := d.connecting; attempt != nil {
+		d.mux.Unlock()
+		select {
+		case <-attempt.done:
+			return attempt.client, attempt.err
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	if d.cfg.External.StartDaemon {
+		if err := d.startDaemon(); err != nil {
+			d.mux.Unlock()
+			return nil, err
+		}
+	}
+	attempt := &connectAttempt{done: make(chan struct{})}
+	d.connecting = attempt
+	d.mux.Unlock()
+
+	attempt.client, attempt.err = d.connect(ctx)
+
+	d.mux.Lock()
+	d.connecting = nil
+	if attempt.err == nil {
+		d.client = attempt.client
+	}
+	d.mux.Unlock()
+	close(attempt.done)
+
+	return attempt.client, attempt.err
+}
+
+// connect dials the provider, and records the interface version and capabilities it reports.
+func (d *daemonProvider) connect(ctx context.Context) (*daemon.Client, error) {
+	client, err := d.dial(ctx)
+	if err != nil {
+		return nil, errors.Wrapf(err, "connecting to provider %s", d.cfg.Name)
+	}
+
+	// Providers built on older versions of the daemon package do not report
+	// their interface version.
+	version := client.InterfaceVersion()
+	capabilities := client.Capabilities()
+	if version == "" {
+		version = execution.InterfaceVersionV1
+		capabilities = execution.LegacyCapabilities()
+	}
+	if err := execution.ValidateInterfaceVersion(version); err != nil {
+		client.Close()
+		return nil, errors.Wrapf(err, "provider %s", d.cfg.Name)
+	}
+
+	d.infoMux.Lock()
+	d.interfaceVersion = version
+	d.capabilities = capabilities
+	d.infoMux.Unlock()
+
+	return client, nil
+}
+
+func (d *daemonProvider) providerCapabilities() params.ProviderCapabilities {
+	d.infoMux.Lock()
+	defer d.infoMux.Unlock()
+
+	return d.capabilities
+}
+
+// call runs an operation on the provider. If talking to the provider fails, the
+// connection is dropped, and a new one is established by the next operation.
+func (d *daemonProvider) call(ctx context.Context, op func(*daemon.Client) error) error {
+	client, err := d.getClient(ctx)
+	if err != nil {
+		// The provider may not be up yet, or may be restarting.
+		fault := garmErrors.NewProviderFault(garmErrors.ProviderFaultTransient, err.Error())
+		return garmErrors.NewProviderFaultError(fault, "%s", err)
+	}
+
+	err = op(client)
+	if err == nil {
+		return nil
+	}
+
+	fault, ok := daemon.ErrorFault(err)
+	if !ok {
+		if ctx.Err() == nil {
+			d.mux.Lock()
+			if d.client == client {
+				d.closeClient()
+			}
+			d.mux.Unlock()
+		}
+		fault = garmErrors.NewProviderFault(garmErrors.ProviderFaultTransient, err.Error())
+	}
+	return garmErrors.NewProviderFaultError(fault, "provider %s returned error: %s", d.cfg.Name, err)
+}
+
+// CreateInstance creates a new compute instance in the provider.
+func (d *daemonProvider) CreateInstance(ctx context.Context, bootstrapParams params.BootstrapInstance) (params.Instance, error) {
+	defer d.listCache.invalidate()
+
+	var instance params.Instance
+	err := d.call(ctx, func(client *daemon.Client) (err error) {
+		instance, err = client.CreateInstance(ctx, bootstrapParams)
+		return err
+	})
+	if err != nil {
+		return params.Instance{}, err
+	}
+
+	if err := validateResult(instance); err != nil {
+		return params.Instance{}, garmErrors.NewProviderError("failed to validate result: %s", err)
+	}
+	return instance, nil
+}
+
+// Delete instance will delete the instance in a provider.
+func (d *daemonProvider) DeleteInstance(ctx context.Context, instance string) error {
+	defer d.listCache.invalidate()
+
+	return d.call(ctx, func(client *daemon.Client) error {
+		err := client.DeleteInstance(ctx, instance)
+		if daemon.ErrorCode(err) == execution.ExitCodeNotFound {
+			return nil
+		}
+		return err
+	})
+}
+
+// GetInstance will return details about one instance.
+func (d *daemonProvider) GetInstance(ctx context.Context, instance string) (params.Instance, error) {
+	var ret params.Instance
+	err := d.call(ctx, func(client *daemon.Client) (err error) {
+		ret, err = client.GetInstance(ctx, instance)
+		return err
+	})
+	if err != nil {
+		return params.Instance{}, err
+	}
+
+	if err := validateResult(ret); err != nil {
+		return params.Instance{}, garmErrors.NewProviderError("failed to validate result: %s", err)
+	}
+	return ret, nil
+}
+
+// ListInstances will list all instances for a provider. Providers that support bulk
+// listing are asked for the instances of all pools at once, and the result is shared
+// by all pools for a short while.
+func (d *daemonProvider) ListInstances(ctx context.Context, poolID string) ([]params.Instance, error) {
+	if d.providerCapabilities().BulkList {
+		return d.listCache.list(ctx, poolID, d.listAllInstances)
+	}
+
+	var instances []params.Instance
+	err := d.call(ctx, func(client *daemon.Client) (err error) {
+		instances, err = client.ListInstances(ctx, poolID)
+		return err
+	})
+	if err != nil {
+		return []params.Instance{}, err
+	}
+
+	for _, inst := range instances {
+		if err := validateResult(inst); err != nil {
+			return []params.Instance{}, garmErrors.NewProviderError("failed to validate result: %s", err)
+		}
+	}
+	return instances, nil
+}
+
+// listAllInstances lists the instances of all pools.
+func (d *daemonProvider) listAllInstances(ctx context.Context) ([]params.Instance, error) {
+	var instances []params.Instance
+	err := d.call(ctx, func(client *daemon.Client) (err error) {
+		instances, err = client.ListAllInstances(ctx)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, inst := range instances {
+		if err := validateResult(inst); err != nil {
+			return nil, garmErrors.NewProviderError("failed to validate result: %s", err)
+		}
+	}
+	return instances, nil
+}
+
+// RemoveAllInstances will remove all instances created by this provider.
+func (d *daemonProvider) RemoveAllInstances(ctx context.Context) error {
+	return d.call(ctx, func(client *daemon.Client) error {
+		return client.RemoveAllInstances(ctx)
+	})
+}
+
+// Stop shuts down the instance.
+func (d *daemonProvider) Stop(ctx context.Context, instance string, force bool) error {
+	return d.call(ctx, func(client *daemon.Client) error {
+		return client.Stop(ctx, instance, force)
+	})
+}
+
+// Start boots up an instance.
+func (d *daemonProvider) Start(ctx context.Context, instance string) error {
+	return d.call(ctx, func(client *daemon.Client) error {
+		return client.Start(ctx, instance)
+	})
+}
+
+// Health checks that the provider is reachable and able to serve requests. Unlike in
+// exec mode, the check is always run, as every provider built on the daemon package
+// answers it.
+func (d *daemonProvider) Health(ctx context.Context) error {
+	return d.RecordHealth(d.call(ctx, func(client *daemon.Client) error {
+		return client.Health(ctx)
+	}))
+}
+
+// GetConsoleLog returns the console log of an instance.
+func (d *daemonProvider) GetConsoleLog(ctx context.Context, instance string) (string, error) {
+	if !d.providerCapabilities().ConsoleLogs {
+		return "", garmErrors.NewProviderError("provider %s does not support console logs", d.cfg.Name)
+	}
+
+	var consoleLog string
+	err := d.call(ctx, func(client *daemon.Client) (err error) {
+		consoleLog, err = client.GetConsoleLog(ctx, instance)
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+	return consoleLog, nil
+}
+
+// ValidateImage checks that instances can be created using the image and flavor.
+func (d *daemonProvider) ValidateImage(ctx context.Context, image, flavor string) error {
+	if !d.providerCapabilities().ImageValidation {
+		return nil
+	}
+
+	return d.call(ctx, func(client *daemon.Client) error {
+		return client.ValidateImage(ctx, image, flavor)
+	})
+}
+
+func (d *daemonProvider) AsParams() params.Provider {
+	d.infoMux.Lock()
+	interfaceVersion := d.interfaceVersion
+	capabilities := d.capabilities
+	d.infoMux.Unlock()
+
+	return params.Provider{
+		Name:             d.cfg.Name,
+		Description:      d.cfg.Description,
+		ProviderType:     d.cfg.ProviderType,
+		Limits:           d.cfg.Limits(),
+		Health:           d.ProviderHealth(),
+		InterfaceVersion: interfaceVersion,
+		Capabilities:     capabilities,
+	}
+}
@@ -0,0 +1,177 @@
+// Copyright 2023 Cloudbase Solutions SRL
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package daemon
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/rpc"
+	"net/rpc/jsonrpc"
+
+	"github.com/cloudbase/garm/params"
+	"github.com/cloudbase/garm/runner/providers/external/execution"
+)
+
+// Client talks to a provider served by this package.
+type Client struct {
+	client    *rpc.Client
+	handshake HandshakeResponse
+}
+
+// Dial connects to the provider listening on the Unix socket and runs the handshake.
+func Dial(ctx context.Context, socketPath string, controllerID string) (*Client, error) {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "unix", socketPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to %s: %w", socketPath, err)
+	}
+
+	c := &Client{
+		client: jsonrpc.NewClient(conn),
+	}
+
+	req := HandshakeRequest{
+		RequestContext:   newRequestContext(ctx),
+		ProtocolVersion:  ProtocolVersion,
+		ControllerID:     controllerID,
+		InterfaceVersion: execution.InterfaceVersion,
+	}
+	var reply HandshakeResponse
+	if err := c.call(ctx, "Handshake", req, &reply); err != nil {
+		c.Close()
+		return nil, fmt.Errorf("handshake failed: %w", err)
+	}
+	if reply.ProtocolVersion != ProtocolVersion {
+		c.Close()
+		return nil, fmt.Errorf("provider replied with unsupported protocol version %d", reply.ProtocolVersion)
+	}
+	c.handshake = reply
+	return c, nil
+}
+
+// InterfaceVersion returns the version of the provider interface implemented by
+// the provider, as reported in the handshake.
+func (c *Client) InterfaceVersion() string {
+	return c.handshake.InterfaceVersion
+}
+
+// Capabilities returns the optional features supported by the provider, as reported
+// in the handshake.
+func (c *Client) Capabilities() params.ProviderCapabilities {
+	return c.handshake.Capabilities
+}
+
+// Close closes the connection to the provider.
+func (c *Client) Close() error {
+	return c.client.Close()
+}
+
+// newRequestContext returns the request context that carries the deadline of ctx.
+func newRequestContext(ctx context.Context) RequestContext {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return RequestContext{}
+	}
+	return RequestContext{Deadline: &deadline}
+}
+
+// call runs a method of the provider. If the context is canceled before the provider
+// replies, the context error is returned. The provider cancels the operation once the
+// deadline of the context passes, but not when the context is canceled earlier.
+func (c *Client) call(ctx context.Context, method string, args interface{}, reply interface{}) error {
+	call := c.client.Go(methodName(method), args, reply, make(chan *rpc.Call, 1))
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-call.Done:
+		return decodeError(call.Error)
+	}
+}
+
+// CreateInstance creates a new compute instance in the provider.
+func (c *Client) CreateInstance(ctx context.Context, bootstrapParams params.BootstrapInstance) (params.Instance, error) {
+	var instance params.Instance
+	if err := c.call(ctx, "CreateInstance", CreateInstanceRequest{RequestContext: newRequestContext(ctx), BootstrapParams: bootstrapParams}, &instance); err != nil {
+		return params.Instance{}, err
+	}
+	return instance, nil
+}
+
+// DeleteInstance will delete the instance in a provider.
+func (c *Client) DeleteInstance(ctx context.Context, instance string) error {
+	return c.call(ctx, "DeleteInstance", InstanceRequest{RequestContext: newRequestContext(ctx), Instance: instance}, &Empty{})
+}
+
+// GetInstance will return details about one instance.
+func (c *Client) GetInstance(ctx context.Context, instance string) (params.Instance, error) {
+	var ret params.Instance
+	if err := c.call(ctx, "GetInstance", InstanceRequest{RequestContext: newRequestContext(ctx), Instance: instance}, &ret); err != nil {
+		return params.Instance{}, err
+	}
+	return ret, nil
+}
+
+// ListInstances will list all instances for a provider.
+func (c *Client) ListInstances(ctx context.Context, poolID string) ([]params.Instance, error) {
+	var instances []params.Instance
+	if err := c.call(ctx, "ListInstances", ListInstancesRequest{RequestContext: newRequestContext(ctx), PoolID: poolID}, &instances); err != nil {
+		return nil, err
+	}
+	return instances, nil
+}
+
+// RemoveAllInstances will remove all instances created by this provider.
+func (c *Client) RemoveAllInstances(ctx context.Context) error {
+	return c.call(ctx, "RemoveAllInstances", newRequestContext(ctx), &Empty{})
+}
+
+// Stop shuts down the instance.
+func (c *Client) Stop(ctx context.Context, instance string, force bool) error {
+	return c.call(ctx, "Stop", StopRequest{RequestContext: newRequestContext(ctx), Instance: instance, Force: force}, &Empty{})
+}
+
+// Start boots up an instance.
+func (c *Client) Start(ctx context.Context, instance string) error {
+	return c.call(ctx, "Start", InstanceRequest{RequestContext: newRequestContext(ctx), Instance: instance}, &Empty{})
+}
+
+// Health returns an error if the provider is not able to serve requests.
+func (c *Client) Health(ctx context.Context) error {
+	return c.call(ctx, "Health", newRequestContext(ctx), &Empty{})
+}
+
+// GetConsoleLog returns the console log of an instance.
+func (c *Client) GetConsoleLog(ctx context.Context, instance string) (string, error) {
+	var consoleLog string
+	if err := c.call(ctx, "GetConsoleLog", InstanceRequest{RequestContext: newRequestContext(ctx), Instance: instance}, &consoleLog); err != nil {
+		return "", err
+	}
+	return consoleLog, nil
+}
+
+// ValidateImage returns an error if instances cannot be created using the image and flavor.
+func (c *Client) ValidateImage(ctx context.Context, image, flavor string) error {
+	return c.call(ctx, "ValidateImage", ValidateImageRequest{RequestContext: newRequestContext(ctx), Image: image, Flavor: flavor}, &Empty{})
+}
+
+// ListAllInstances lists the instances created by the provider in all pools.
+func (c *Client) ListAllInstances(ctx context.Context) ([]params.Instance, error) {
+	var instances []params.Instance
+	if err := c.call(ctx, "ListAllInstances", newRequestContext(ctx), &instances); err != nil {
+		return nil, err
+	}
+	return instances, nil
+}
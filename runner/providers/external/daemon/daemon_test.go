@@ -0,0 +1,311 @@
+// Copyright 2023 Cloudbase Solutions SRL
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package daemon
+
+import (
+	"context"
+	"fmt"
+	"net/rpc"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	gErrors "github.com/cloudbase/garm/errors"
+	"github.com/cloudbase/garm/params"
+	"github.com/cloudbase/garm/runner/providers/external/execution"
+
+	"github.com/stretchr/testify/require"
+)
+
+type fakeProvider struct {
+	instances map[string]params.Instance
+	healthErr error
+	createErr error
+
+	// healthDeadline and healthControllerID are taken from the context of the last
+	// health check.
+	healthDeadline     time.Time
+	healthControllerID string
+}
+
+func (f *fakeProvider) CreateInstance(_ context.Context, bootstrapParams params.BootstrapInstance) (params.Instance, error) {
+	if f.createErr != nil {
+		return params.Instance{}, f.createErr
+	}
+	instance := params.Instance{
+		ProviderID: bootstrapParams.Name,
+		Name:       bootstrapParams.Name,
+		PoolID:     bootstrapParams.PoolID,
+	}
+	f.instances[instance.ProviderID] = instance
+	return instance, nil
+}
+
+func (f *fakeProvider) DeleteInstance(_ context.Context, instance string) error {
+	if _, ok := f.instances[instance]; !ok {
+		return fmt.Errorf("instance %s: %w", instance, gErrors.ErrNotFound)
+	}
+	delete(f.instances, instance)
+	return nil
+}
+
+func (f *fakeProvider) GetInstance(_ context.Context, instance string) (params.Instance, error) {
+	inst, ok := f.instances[instance]
+	if !ok {
+		return params.Instance{}, fmt.Errorf("instance %s: %w", instance, gErrors.ErrNotFound)
+	}
+	return inst, nil
+}
+
+func (f *fakeProvider) ListInstances(_ context.Context, poolID string) ([]params.Instance, error) {
+	ret := []params.Instance{}
+	for _, inst := range f.instances {
+		if inst.PoolID == poolID {
+			ret = append(ret, inst)
+		}
+	}
+	return ret, nil
+}
+
+func (f *fakeProvider) ListAllInstances(_ context.Context) ([]params.Instance, error) {
+	ret := []params.Instance{}
+	for _, inst := range f.instances {
+		ret = append(ret, inst)
+	}
+	return ret, nil
+}
+
+func (f *fakeProvider) RemoveAllInstances(_ context.Context) error {
+	f.instances = map[string]params.Instance{}
+	return nil
+}
+
+func (f *fakeProvider) Stop(_ context.Context, _ string, _ bool) error {
+	return nil
+}
+
+func (f *fakeProvider) Start(_ context.Context, _ string) error {
+	return nil
+}
+
+func (f *fakeProvider) Health(ctx context.Context) error {
+	f.healthDeadline, _ = ctx.Deadline()
+	f.healthControllerID = execution.ControllerIDFromContext(ctx)
+	return f.healthErr
+}
+
+// legacyProvider is a provider that implements the first version of the interface.
+type legacyProvider struct {
+	fakeProvider
+}
+
+func (l *legacyProvider) InterfaceVersion() string {
+	return execution.InterfaceVersionV1
+}
+
+func serveFakeProvider(t *testing.T, provider execution.ExternalProvider) string {
+	dir, err := os.MkdirTemp("", "garm-test")
+	if err != nil {
+		t.Fatalf("failed to create temporary directory: %s", err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	socketPath := filepath.Join(dir, "provider.sock")
+
+	ctx, cancel := context.WithCancel(execution.WithControllerID(context.Background(), "test-controller"))
+	done := make(chan error, 1)
+	go func() {
+		done <- ListenAndServe(ctx, socketPath, provider)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		require.Nil(t, <-done)
+	})
+
+	require.Eventually(t, func() bool {
+		_, err := os.Stat(socketPath)
+		return err == nil
+	}, 5*time.Second, 10*time.Millisecond)
+	return socketPath
+}
+
+func TestClient(t *testing.T) {
+	provider := &fakeProvider{instances: map[string]params.Instance{}}
+	socketPath := serveFakeProvider(t, provider)
+	ctx := context.Background()
+
+	client, err := Dial(ctx, socketPath, "test-controller")
+	require.Nil(t, err)
+	defer client.Close()
+
+	instance, err := client.CreateInstance(ctx, params.BootstrapInstance{Name: "test-instance", PoolID: "test-pool"})
+	require.Nil(t, err)
+	require.Equal(t, "test-instance", instance.ProviderID)
+
+	instance, err = client.GetInstance(ctx, "test-instance")
+	require.Nil(t, err)
+	require.Equal(t, "test-pool", instance.PoolID)
+
+	instances, err := client.ListInstances(ctx, "test-pool")
+	require.Nil(t, err)
+	require.Len(t, instances, 1)
+
+	instances, err = client.ListAllInstances(ctx)
+	require.Nil(t, err)
+	require.Len(t, instances, 1)
+
+	require.Nil(t, client.Stop(ctx, "test-instance", true))
+	require.Nil(t, client.Start(ctx, "test-instance"))
+	require.Nil(t, client.Health(ctx))
+	require.Nil(t, client.DeleteInstance(ctx, "test-instance"))
+	require.Nil(t, client.RemoveAllInstances(ctx))
+}
+
+func TestHandshakeCapabilities(t *testing.T) {
+	provider := &fakeProvider{instances: map[string]params.Instance{}}
+	socketPath := serveFakeProvider(t, provider)
+
+	client, err := Dial(context.Background(), socketPath, "test-controller")
+	require.Nil(t, err)
+	defer client.Close()
+
+	require.Equal(t, execution.InterfaceVersion, client.InterfaceVersion())
+	require.Equal(t, params.ProviderCapabilities{StartStop: true, BulkList: true}, client.Capabilities())
+}
+
+func TestHandshakeInterfaceVersion(t *testing.T) {
+	provider := &legacyProvider{fakeProvider{instances: map[string]params.Instance{}}}
+	socketPath := serveFakeProvider(t, provider)
+
+	client, err := Dial(context.Background(), socketPath, "test-controller")
+	require.Nil(t, err)
+	defer client.Close()
+
+	require.Equal(t, execution.InterfaceVersionV1, client.InterfaceVersion())
+}
+
+func TestRunGetVersion(t *testing.T) {
+	provider := &fakeProvider{instances: map[string]params.Instance{}}
+	version, err := Run(context.Background(), provider, execution.Environment{Command: execution.GetVersionCommand})
+	require.Nil(t, err)
+	require.Equal(t, execution.InterfaceVersion, version)
+
+	legacy := &legacyProvider{fakeProvider{instances: map[string]params.Instance{}}}
+	version, err = Run(context.Background(), legacy, execution.Environment{Command: execution.GetVersionCommand})
+	require.Nil(t, err)
+	require.Equal(t, execution.InterfaceVersionV1, version)
+}
+
+func TestHandshakeOtherController(t *testing.T) {
+	provider := &fakeProvider{instances: map[string]params.Instance{}}
+	socketPath := serveFakeProvider(t, provider)
+
+	_, err := Dial(context.Background(), socketPath, "other-controller")
+	require.EqualError(t, err, "handshake failed: provider is serving controller test-controller, not other-controller")
+}
+
+func TestRequestContext(t *testing.T) {
+	provider := &fakeProvider{instances: map[string]params.Instance{}}
+	socketPath := serveFakeProvider(t, provider)
+
+	client, err := Dial(context.Background(), socketPath, "test-controller")
+	require.Nil(t, err)
+	defer client.Close()
+
+	require.Nil(t, client.Health(context.Background()))
+	require.True(t, provider.healthDeadline.IsZero())
+	require.Equal(t, "test-controller", provider.healthControllerID)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	deadline, _ := ctx.Deadline()
+	require.Nil(t, client.Health(ctx))
+	require.True(t, deadline.Equal(provider.healthDeadline))
+}
+
+func TestClientErrors(t *testing.T) {
+	provider := &fakeProvider{
+		instances: map[string]params.Instance{},
+		healthErr: fmt.Errorf("cloud is down"),
+	}
+	socketPath := serveFakeProvider(t, provider)
+	ctx := context.Background()
+
+	client, err := Dial(ctx, socketPath, "test-controller")
+	require.Nil(t, err)
+	defer client.Close()
+
+	err = client.DeleteInstance(ctx, "missing-instance")
+	require.True(t, IsRPCError(err))
+	require.Equal(t, execution.ExitCodeNotFound, ErrorCode(err))
+	require.EqualError(t, err, "instance missing-instance: not found")
+	fault, ok := ErrorFault(err)
+	require.True(t, ok)
+	require.Equal(t, gErrors.ProviderFaultNotFound, fault.Code)
+	require.False(t, fault.Retryable)
+
+	err = client.Health(ctx)
+	require.True(t, IsRPCError(err))
+	require.Equal(t, 1, ErrorCode(err))
+	require.EqualError(t, err, "cloud is down")
+
+	err = client.ValidateImage(ctx, "test-image", "test-flavor")
+	require.True(t, IsRPCError(err))
+	require.EqualError(t, err, "ValidateImage is not supported by this provider")
+}
+
+func TestHandshakeUnsupportedVersion(t *testing.T) {
+	provider := &fakeProvider{instances: map[string]params.Instance{}}
+	socketPath := serveFakeProvider(t, provider)
+
+	client, err := Dial(context.Background(), socketPath, "test-controller")
+	require.Nil(t, err)
+	defer client.Close()
+
+	var reply HandshakeResponse
+	err = client.call(context.Background(), "Handshake", HandshakeRequest{ProtocolVersion: ProtocolVersion + 1}, &reply)
+	require.EqualError(t, err, fmt.Sprintf("unsupported protocol version %d (supported: %d)", ProtocolVersion+1, ProtocolVersion))
+}
+
+func TestClientFaults(t *testing.T) {
+	quotaFault := gErrors.NewProviderFault(gErrors.ProviderFaultQuotaExceeded, "no more cores left")
+	quotaFault.Details = map[string]interface{}{"region": "test-region"}
+	provider := &fakeProvider{
+		instances: map[string]params.Instance{},
+		createErr: gErrors.NewProviderFaultError(quotaFault, "failed to create instance: %s", quotaFault.Message),
+	}
+	socketPath := serveFakeProvider(t, provider)
+	ctx := context.Background()
+
+	client, err := Dial(ctx, socketPath, "test-controller")
+	require.Nil(t, err)
+	defer client.Close()
+
+	_, err = client.CreateInstance(ctx, params.BootstrapInstance{Name: "test-instance", PoolID: "test-pool"})
+	require.Equal(t, execution.ExitCodeQuotaExceeded, ErrorCode(err))
+	fault, ok := ErrorFault(err)
+	require.True(t, ok)
+	require.Equal(t, quotaFault, fault)
+}
+
+func TestDecodeErrorWithoutFault(t *testing.T) {
+	err := decodeError(rpc.ServerError(`{"code": 34, "message": "image not found"}`))
+	fault, ok := ErrorFault(err)
+	require.True(t, ok)
+	require.Equal(t, gErrors.NewProviderFault(gErrors.ProviderFaultInvalidRequest, "image not found"), fault)
+
+	_, ok = ErrorFault(fmt.Errorf("connection reset"))
+	require.False(t, ok)
+}
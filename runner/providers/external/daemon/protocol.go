@@ -0,0 +1,193 @@
+// Copyright 2023 Cloudbase Solutions SRL
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+// Package daemon implements the protocol used by garm to talk to external providers
+// that run as long lived processes. Garm connects to the provider over a Unix socket
+// and sends JSON-RPC 1.0 requests, one for each operation of the provider.
+package daemon
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/rpc"
+	"time"
+
+	gErrors "github.com/cloudbase/garm/errors"
+	"github.com/cloudbase/garm/params"
+	"github.com/cloudbase/garm/runner/providers/external/execution"
+)
+
+const (
+	// ProtocolVersion is the version of the protocol implemented by this package. It is
+	// exchanged in the handshake, and changes whenever the protocol changes in a way that
+	// is not backwards compatible.
+	ProtocolVersion = 1
+	// ServiceName is the name of the JSON-RPC service. Methods are called as
+	// ServiceName.Method, for example GarmProvider.CreateInstance.
+	ServiceName = "GarmProvider"
+)
+
+// RequestContext is sent with every request.
+type RequestContext struct {
+	// Deadline is the time after which garm stops waiting for the reply. The provider
+	// cancels the operation once it passes. Operations without a deadline run until
+	// the provider stops.
+	Deadline *time.Time `json:"deadline,omitempty"`
+}
+
+// HandshakeRequest is sent by garm when it connects to a provider.
+type HandshakeRequest struct {
+	RequestContext
+	ProtocolVersion int `json:"protocol_version"`
+	// ControllerID is the ID of the garm controller. Providers only accept the
+	// controller they were started for.
+	ControllerID string `json:"controller_id"`
+	// InterfaceVersion is the newest version of the provider interface garm supports.
+	InterfaceVersion string `json:"interface_version"`
+}
+
+// HandshakeResponse is the reply of the provider to a handshake.
+type HandshakeResponse struct {
+	ProtocolVersion int `json:"protocol_version"`
+	// InterfaceVersion is the version of the provider interface implemented by the provider.
+	InterfaceVersion string `json:"interface_version"`
+	// Capabilities holds the optional features supported by the provider.
+	Capabilities params.ProviderCapabilities `json:"capabilities"`
+}
+
+// CreateInstanceRequest holds the arguments of CreateInstance.
+type CreateInstanceRequest struct {
+	RequestContext
+	BootstrapParams params.BootstrapInstance `json:"bootstrap_params"`
+}
+
+// InstanceRequest holds the arguments of the operations that target one instance.
+type InstanceRequest struct {
+	RequestContext
+	// Instance is the provider ID of the instance.
+	Instance string `json:"instance"`
+}
+
+// ListInstancesRequest holds the arguments of ListInstances.
+type ListInstancesRequest struct {
+	RequestContext
+	PoolID string `json:"pool_id"`
+}
+
+// ValidateImageRequest holds the arguments of ValidateImage.
+type ValidateImageRequest struct {
+	RequestContext
+	Image  string `json:"image"`
+	Flavor string `json:"flavor"`
+}
+
+// StopRequest holds the arguments of Stop.
+type StopRequest struct {
+	RequestContext
+	Instance string `json:"instance"`
+	Force    bool   `json:"force"`
+}
+
+// Empty is used for operations that return nothing.
+type Empty struct{}
+
+// RPCError is an error returned by a provider. It is sent over the wire as the JSON
+// encoding of the error, so the code survives the trip.
+type RPCError struct {
+	// Code uses the same values as the exit codes of providers in exec mode. For
+	// example, execution.ExitCodeNotFound means the instance does not exist.
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+	// Fault is the structured form of the error. Providers built with an older version
+	// of this package do not send it, in which case it is derived from the code.
+	Fault *gErrors.ProviderFault `json:"fault,omitempty"`
+}
+
+func (e *RPCError) Error() string {
+	return e.Message
+}
+
+// encodeError converts an error returned by a provider to an error that can be sent
+// over the wire.
+func encodeError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	fault := execution.ResolveErrorToFault(err)
+	asJs, jsErr := json.Marshal(RPCError{
+		Code:    execution.ResolveErrorToExitCode(err),
+		Message: err.Error(),
+		Fault:   &fault,
+	})
+	if jsErr != nil {
+		return err
+	}
+	return errors.New(string(asJs))
+}
+
+// decodeError converts an error received over the wire to an *RPCError. Errors that did
+// not come from the provider, like connection errors, are returned as is.
+func decodeError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	var serverErr rpc.ServerError
+	if !errors.As(err, &serverErr) {
+		return err
+	}
+
+	var rpcErr RPCError
+	if jsErr := json.Unmarshal([]byte(serverErr), &rpcErr); jsErr != nil {
+		rpcErr = RPCError{Code: 1, Message: string(serverErr)}
+	}
+	if rpcErr.Fault == nil {
+		fault := execution.FaultFromExitCode(rpcErr.Code, rpcErr.Message)
+		rpcErr.Fault = &fault
+	}
+	return &rpcErr
+}
+
+// IsRPCError returns true if the error was returned by the provider, as opposed to an
+// error talking to the provider.
+func IsRPCError(err error) bool {
+	var rpcErr *RPCError
+	return errors.As(err, &rpcErr)
+}
+
+// ErrorCode returns the code of an error returned by the provider, or 0 if the error
+// did not come from the provider.
+func ErrorCode(err error) int {
+	var rpcErr *RPCError
+	if !errors.As(err, &rpcErr) {
+		return 0
+	}
+	return rpcErr.Code
+}
+
+// ErrorFault returns the fault of an error returned by the provider. The second value
+// is false if the error did not come from the provider.
+func ErrorFault(err error) (gErrors.ProviderFault, bool) {
+	var rpcErr *RPCError
+	if !errors.As(err, &rpcErr) || rpcErr.Fault == nil {
+		return gErrors.ProviderFault{}, false
+	}
+	return *rpcErr.Fault, true
+}
+
+func methodName(method string) string {
+	return fmt.Sprintf("%s.%s", ServiceName, method)
+}
@@ -0,0 +1,237 @@
+// Copyright 2023 Cloudbase Solutions SRL
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package daemon
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/rpc"
+	"net/rpc/jsonrpc"
+	"os"
+
+	"github.com/cloudbase/garm/params"
+	"github.com/cloudbase/garm/runner/providers/external/execution"
+)
+
+// Run runs a provider that supports both modes. If the command in the environment is
+// Serve, the provider is served on the socket set by garm until the context is canceled.
+// Otherwise, the command is run once, as in exec mode.
+func Run(ctx context.Context, provider execution.ExternalProvider, env execution.Environment) (string, error) {
+	if env.Command == execution.ServeCommand {
+		ctx = execution.WithControllerID(ctx, env.ControllerID)
+		if err := ListenAndServe(ctx, env.SocketPath, provider); err != nil {
+			return "", fmt.Errorf("failed to serve provider: %w", err)
+		}
+		return "", nil
+	}
+	return execution.Run(ctx, provider, env)
+}
+
+// ListenAndServe listens on a Unix socket and serves the provider until the context is
+// canceled. A stale socket left behind by a previous run is removed.
+func ListenAndServe(ctx context.Context, socketPath string, provider execution.ExternalProvider) error {
+	if err := os.Remove(socketPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to remove stale socket: %w", err)
+	}
+
+	listener, err := net.Listen("unix", socketPath)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", socketPath, err)
+	}
+	defer os.Remove(socketPath)
+
+	if err := os.Chmod(socketPath, 0o600); err != nil {
+		listener.Close()
+		return fmt.Errorf("failed to set socket permissions: %w", err)
+	}
+	return Serve(ctx, listener, provider)
+}
+
+// Serve serves the provider on the listener until the context is canceled. Each
+// connection is served in its own goroutine, and requests on a connection are
+// handled concurrently.
+func Serve(ctx context.Context, listener net.Listener, provider execution.ExternalProvider) error {
+	server := rpc.NewServer()
+	if err := server.RegisterName(ServiceName, &service{ctx: ctx, provider: provider}); err != nil {
+		listener.Close()
+		return fmt.Errorf("failed to register service: %w", err)
+	}
+
+	go func() {
+		<-ctx.Done()
+		listener.Close()
+	}()
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("failed to accept connection: %w", err)
+		}
+		go server.ServeCodec(jsonrpc.NewServerCodec(conn))
+	}
+}
+
+// service exposes an external provider over JSON-RPC. Operations run with a context
+// derived from the one the provider is served with, bounded by the deadline of the
+// request.
+type service struct {
+	ctx      context.Context
+	provider execution.ExternalProvider
+}
+
+// requestContext returns the context an operation runs with.
+func (s *service) requestContext(req RequestContext) (context.Context, context.CancelFunc) {
+	if req.Deadline == nil {
+		return context.WithCancel(s.ctx)
+	}
+	return context.WithDeadline(s.ctx, *req.Deadline)
+}
+
+// Handshake rejects garm controllers other than the one the provider was started for,
+// as the instances of a provider belong to a single controller. A provider served
+// without a controller ID accepts any controller.
+func (s *service) Handshake(req HandshakeRequest, reply *HandshakeResponse) error {
+	if req.ProtocolVersion != ProtocolVersion {
+		return encodeError(fmt.Errorf("unsupported protocol version %d (supported: %d)", req.ProtocolVersion, ProtocolVersion))
+	}
+	if controllerID := execution.ControllerIDFromContext(s.ctx); controllerID != "" && req.ControllerID != controllerID {
+		return encodeError(fmt.Errorf("provider is serving controller %s, not %s", controllerID, req.ControllerID))
+	}
+
+	ctx, cancel := s.requestContext(req.RequestContext)
+	defer cancel()
+	capabilities, err := execution.ProviderCapabilities(ctx, s.provider)
+	if err != nil {
+		return encodeError(fmt.Errorf("failed to get capabilities: %w", err))
+	}
+	reply.ProtocolVersion = ProtocolVersion
+	reply.InterfaceVersion = execution.ProviderInterfaceVersion(s.provider)
+	reply.Capabilities = capabilities
+	return nil
+}
+
+func (s *service) CreateInstance(req CreateInstanceRequest, reply *params.Instance) error {
+	ctx, cancel := s.requestContext(req.RequestContext)
+	defer cancel()
+	instance, err := s.provider.CreateInstance(ctx, req.BootstrapParams)
+	if err != nil {
+		return encodeError(err)
+	}
+	*reply = instance
+	return nil
+}
+
+func (s *service) DeleteInstance(req InstanceRequest, _ *Empty) error {
+	ctx, cancel := s.requestContext(req.RequestContext)
+	defer cancel()
+	return encodeError(s.provider.DeleteInstance(ctx, req.Instance))
+}
+
+func (s *service) GetInstance(req InstanceRequest, reply *params.Instance) error {
+	ctx, cancel := s.requestContext(req.RequestContext)
+	defer cancel()
+	instance, err := s.provider.GetInstance(ctx, req.Instance)
+	if err != nil {
+		return encodeError(err)
+	}
+	*reply = instance
+	return nil
+}
+
+func (s *service) ListInstances(req ListInstancesRequest, reply *[]params.Instance) error {
+	ctx, cancel := s.requestContext(req.RequestContext)
+	defer cancel()
+	instances, err := s.provider.ListInstances(ctx, req.PoolID)
+	if err != nil {
+		return encodeError(err)
+	}
+	*reply = instances
+	return nil
+}
+
+func (s *service) RemoveAllInstances(req RequestContext, _ *Empty) error {
+	ctx, cancel := s.requestContext(req)
+	defer cancel()
+	return encodeError(s.provider.RemoveAllInstances(ctx))
+}
+
+func (s *service) Stop(req StopRequest, _ *Empty) error {
+	ctx, cancel := s.requestContext(req.RequestContext)
+	defer cancel()
+	return encodeError(s.provider.Stop(ctx, req.Instance, req.Force))
+}
+
+func (s *service) Start(req InstanceRequest, _ *Empty) error {
+	ctx, cancel := s.requestContext(req.RequestContext)
+	defer cancel()
+	return encodeError(s.provider.Start(ctx, req.Instance))
+}
+
+// Health checks are optional. Providers that do not implement them are considered
+// healthy as long as they answer.
+func (s *service) Health(req RequestContext, _ *Empty) error {
+	checker, ok := s.provider.(execution.HealthChecker)
+	if !ok {
+		return nil
+	}
+	ctx, cancel := s.requestContext(req)
+	defer cancel()
+	return encodeError(checker.Health(ctx))
+}
+
+func (s *service) GetConsoleLog(req InstanceRequest, reply *string) error {
+	getter, ok := s.provider.(execution.ConsoleLogGetter)
+	if !ok {
+		return encodeError(fmt.Errorf("GetConsoleLog is not supported by this provider"))
+	}
+	ctx, cancel := s.requestContext(req.RequestContext)
+	defer cancel()
+	consoleLog, err := getter.GetConsoleLog(ctx, req.Instance)
+	if err != nil {
+		return encodeError(err)
+	}
+	*reply = consoleLog
+	return nil
+}
+
+func (s *service) ValidateImage(req ValidateImageRequest, _ *Empty) error {
+	validator, ok := s.provider.(execution.ImageValidator)
+	if !ok {
+		return encodeError(fmt.Errorf("ValidateImage is not supported by this provider"))
+	}
+	ctx, cancel := s.requestContext(req.RequestContext)
+	defer cancel()
+	return encodeError(validator.ValidateImage(ctx, req.Image, req.Flavor))
+}
+
+func (s *service) ListAllInstances(req RequestContext, reply *[]params.Instance) error {
+	lister, ok := s.provider.(execution.BulkLister)
+	if !ok {
+		return encodeError(fmt.Errorf("ListAllInstances is not supported by this provider"))
+	}
+	ctx, cancel := s.requestContext(req)
+	defer cancel()
+	instances, err := lister.ListAllInstances(ctx)
+	if err != nil {
+		return encodeError(err)
+	}
+	*reply = instances
+	return nil
+}
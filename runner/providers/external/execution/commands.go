@@ -10,4 +10,13 @@ const (
 	StartInstanceCommand      ExecutionCommand = "StartInstance"
 	StopInstanceCommand       ExecutionCommand = "StopInstance"
 	RemoveAllInstancesCommand ExecutionCommand = "RemoveAllInstances"
+	HealthCommand             ExecutionCommand = "Health"
+	GetVersionCommand         ExecutionCommand = "GetVersion"
+	GetCapabilitiesCommand    ExecutionCommand = "GetCapabilities"
+	GetConsoleLogCommand      ExecutionCommand = "GetConsoleLog"
+	ValidateImageCommand      ExecutionCommand = "ValidateImage"
+	ListAllInstancesCommand   ExecutionCommand = "ListAllInstances"
+	// ServeCommand is used when garm starts a provider in daemon mode. The provider
+	// is expected to serve requests on GARM_PROVIDER_SOCKET until it is stopped.
+	ServeCommand ExecutionCommand = "Serve"
 )
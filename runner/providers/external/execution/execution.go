@@ -20,6 +20,10 @@ func GetEnvironment() (Environment, error) {
 		PoolID:             os.Getenv("GARM_POOL_ID"),
 		ProviderConfigFile: os.Getenv("GARM_PROVIDER_CONFIG_FILE"),
 		InstanceID:         os.Getenv("GARM_INSTANCE_ID"),
+		SocketPath:         os.Getenv("GARM_PROVIDER_SOCKET"),
+		InterfaceVersion:   os.Getenv("GARM_INTERFACE_VERSION"),
+		Image:              os.Getenv("GARM_IMAGE"),
+		Flavor:             os.Getenv("GARM_FLAVOR"),
 	}
 
 	// If this is a CreateInstance command, we need to get the bootstrap params
@@ -58,6 +62,10 @@ type Environment struct {
 	PoolID             string
 	ProviderConfigFile string
 	InstanceID         string
+	SocketPath         string
+	InterfaceVersion   string
+	Image              string
+	Flavor             string
 	BootstrapParams    params.BootstrapInstance
 }
 
@@ -90,7 +98,8 @@ func (e Environment) Validate() error {
 			return fmt.Errorf("missing pool ID")
 		}
 	case DeleteInstanceCommand, GetInstanceCommand,
-		StartInstanceCommand, StopInstanceCommand:
+		StartInstanceCommand, StopInstanceCommand,
+		GetConsoleLogCommand:
 		if e.InstanceID == "" {
 			return fmt.Errorf("missing instance ID")
 		}
@@ -98,17 +107,45 @@ func (e Environment) Validate() error {
 		if e.PoolID == "" {
 			return fmt.Errorf("missing pool ID")
 		}
-	case RemoveAllInstancesCommand:
+	case RemoveAllInstancesCommand, HealthCommand,
+		ListAllInstancesCommand:
 		if e.ControllerID == "" {
 			return fmt.Errorf("missing controller ID")
 		}
+	case ServeCommand:
+		if e.SocketPath == "" {
+			return fmt.Errorf("missing GARM_PROVIDER_SOCKET")
+		}
+	case ValidateImageCommand:
+		if e.Image == "" {
+			return fmt.Errorf("missing GARM_IMAGE")
+		}
+	case GetVersionCommand, GetCapabilitiesCommand:
 	default:
 		return fmt.Errorf("unknown GARM_COMMAND: %s", e.Command)
 	}
 	return nil
 }
 
+type controllerIDKey struct{}
+
+// WithControllerID returns a copy of the context that carries the ID of the garm
+// controller the provider runs for.
+func WithControllerID(ctx context.Context, controllerID string) context.Context {
+	return context.WithValue(ctx, controllerIDKey{}, controllerID)
+}
+
+// ControllerIDFromContext returns the ID of the garm controller the provider runs for.
+// Providers use it to tag the instances they create. It is set on the context passed
+// to all operations of the provider.
+func ControllerIDFromContext(ctx context.Context) string {
+	controllerID, _ := ctx.Value(controllerIDKey{}).(string)
+	return controllerID
+}
+
 func Run(ctx context.Context, provider ExternalProvider, env Environment) (string, error) {
+	ctx = WithControllerID(ctx, env.ControllerID)
+
 	var ret string
 	switch env.Command {
 	case CreateInstanceCommand:
@@ -158,6 +195,58 @@ func Run(ctx context.Context, provider ExternalProvider, env Environment) (strin
 		if err := provider.Stop(ctx, env.InstanceID, true); err != nil {
 			return "", fmt.Errorf("failed to stop instance: %w", err)
 		}
+	case HealthCommand:
+		// Health checks are optional. Providers that do not implement them are
+		// considered healthy.
+		if checker, ok := provider.(HealthChecker); ok {
+			if err := checker.Health(ctx); err != nil {
+				return "", fmt.Errorf("provider is not healthy: %w", err)
+			}
+		}
+	case GetVersionCommand:
+		ret = ProviderInterfaceVersion(provider)
+	case GetCapabilitiesCommand:
+		capabilities, err := ProviderCapabilities(ctx, provider)
+		if err != nil {
+			return "", fmt.Errorf("failed to get capabilities: %w", err)
+		}
+		asJs, err := json.Marshal(capabilities)
+		if err != nil {
+			return "", fmt.Errorf("failed to marshal response: %w", err)
+		}
+		ret = string(asJs)
+	case GetConsoleLogCommand:
+		getter, ok := provider.(ConsoleLogGetter)
+		if !ok {
+			return "", fmt.Errorf("%s is not supported by this provider", env.Command)
+		}
+		consoleLog, err := getter.GetConsoleLog(ctx, env.InstanceID)
+		if err != nil {
+			return "", fmt.Errorf("failed to get console log: %w", err)
+		}
+		ret = consoleLog
+	case ValidateImageCommand:
+		validator, ok := provider.(ImageValidator)
+		if !ok {
+			return "", fmt.Errorf("%s is not supported by this provider", env.Command)
+		}
+		if err := validator.ValidateImage(ctx, env.Image, env.Flavor); err != nil {
+			return "", fmt.Errorf("failed to validate image: %w", err)
+		}
+	case ListAllInstancesCommand:
+		lister, ok := provider.(BulkLister)
+		if !ok {
+			return "", fmt.Errorf("%s is not supported by this provider", env.Command)
+		}
+		instances, err := lister.ListAllInstances(ctx)
+		if err != nil {
+			return "", fmt.Errorf("failed to list instances from provider: %w", err)
+		}
+		asJs, err := json.Marshal(instances)
+		if err != nil {
+			return "", fmt.Errorf("failed to marshal response: %w", err)
+		}
+		ret = string(asJs)
 	default:
 		return "", fmt.Errorf("invalid command: %s", env.Command)
 	}
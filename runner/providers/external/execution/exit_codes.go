@@ -7,20 +7,71 @@ import (
 )
 
 const (
+	// ExitCodeUnknownCommand is the exit code of a provider asked to run a command it
+	// does not know. Providers implementing InterfaceVersionV1 exit with it when asked
+	// for their version.
+	ExitCodeUnknownCommand int = 1
 	// ExitCodeNotFound is an exit code that indicates a Not Found error
 	ExitCodeNotFound int = 30
 	// ExitCodeDuplicate is an exit code that indicates a duplicate error
 	ExitCodeDuplicate int = 31
+	// ExitCodeQuotaExceeded is an exit code that indicates the provider has no room
+	// left for new instances
+	ExitCodeQuotaExceeded int = 32
+	// ExitCodeTransient is an exit code that indicates an error that is expected to
+	// go away if the operation is tried again
+	ExitCodeTransient int = 33
+	// ExitCodeInvalidRequest is an exit code that indicates a request that can never
+	// succeed
+	ExitCodeInvalidRequest int = 34
 )
 
+var faultExitCodes = map[gErrors.ProviderFaultCode]int{
+	gErrors.ProviderFaultNotFound:       ExitCodeNotFound,
+	gErrors.ProviderFaultDuplicate:      ExitCodeDuplicate,
+	gErrors.ProviderFaultQuotaExceeded:  ExitCodeQuotaExceeded,
+	gErrors.ProviderFaultTransient:      ExitCodeTransient,
+	gErrors.ProviderFaultInvalidRequest: ExitCodeInvalidRequest,
+}
+
 func ResolveErrorToExitCode(err error) int {
 	if err != nil {
-		if errors.Is(err, gErrors.ErrNotFound) {
-			return ExitCodeNotFound
-		} else if errors.Is(err, gErrors.ErrDuplicateEntity) {
-			return ExitCodeDuplicate
+		if code, ok := faultExitCodes[ResolveErrorToFault(err).Code]; ok {
+			return code
 		}
 		return 1
 	}
 	return 0
 }
+
+// ResolveErrorToFault returns the structured form of an error returned by a provider.
+// Errors that do not carry a fault are classified using the well known garm errors.
+func ResolveErrorToFault(err error) gErrors.ProviderFault {
+	if fault, ok := gErrors.ProviderFaultFromError(err); ok {
+		if fault.Message == "" {
+			fault.Message = err.Error()
+		}
+		return fault
+	}
+
+	code := gErrors.ProviderFaultUnknown
+	if errors.Is(err, gErrors.ErrNotFound) {
+		code = gErrors.ProviderFaultNotFound
+	} else if errors.Is(err, gErrors.ErrDuplicateEntity) {
+		code = gErrors.ProviderFaultDuplicate
+	} else if errors.Is(err, gErrors.ErrTimeout) {
+		code = gErrors.ProviderFaultTransient
+	}
+	return gErrors.NewProviderFault(code, err.Error())
+}
+
+// FaultFromExitCode returns the fault of a provider that exited with the exit code,
+// without printing a structured error.
+func FaultFromExitCode(exitCode int, message string) gErrors.ProviderFault {
+	for code, faultExitCode := range faultExitCodes {
+		if faultExitCode == exitCode {
+			return gErrors.NewProviderFault(code, message)
+		}
+	}
+	return gErrors.NewProviderFault(gErrors.ProviderFaultUnknown, message)
+}
@@ -0,0 +1,62 @@
+// Copyright 2023 Cloudbase Solutions SRL
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package execution
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	gErrors "github.com/cloudbase/garm/errors"
+)
+
+// PrintFault writes the structured form of an error to w, as a single line of JSON.
+// Providers print it on standard error before exiting with ResolveErrorToExitCode(err).
+func PrintFault(w io.Writer, err error) error {
+	asJs, jsErr := json.Marshal(ResolveErrorToFault(err))
+	if jsErr != nil {
+		return fmt.Errorf("failed to encode fault: %w", jsErr)
+	}
+	if _, err := fmt.Fprintf(w, "%s\n", asJs); err != nil {
+		return fmt.Errorf("failed to write fault: %w", err)
+	}
+	return nil
+}
+
+// ParseFault looks for the structured error printed by a provider in its output. The
+// whole output is tried first, then each line, starting with the last one, as providers
+// may log other messages before the error.
+func ParseFault(output []byte) (gErrors.ProviderFault, bool) {
+	if fault, ok := decodeFault(output); ok {
+		return fault, true
+	}
+
+	lines := bytes.Split(output, []byte("\n"))
+	for idx := len(lines) - 1; idx >= 0; idx-- {
+		if fault, ok := decodeFault(lines[idx]); ok {
+			return fault, true
+		}
+	}
+	return gErrors.ProviderFault{}, false
+}
+
+func decodeFault(data []byte) (gErrors.ProviderFault, bool) {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 || data[0] != '{' {
+		return gErrors.ProviderFault{}, false
+	}
+	return gErrors.DecodeProviderFault(data)
+}
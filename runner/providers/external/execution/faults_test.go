@@ -0,0 +1,153 @@
+// Copyright 2023 Cloudbase Solutions SRL
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package execution
+
+import (
+	"testing"
+
+	gErrors "github.com/cloudbase/garm/errors"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseFault(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		fault  gErrors.ProviderFault
+		found  bool
+	}{
+		{
+			name:   "Fault with all fields",
+			output: `{"code":"transient","message":"API unavailable","retryable":true,"quota_exceeded":false,"details":{"region":"east"}}`,
+			fault: gErrors.ProviderFault{
+				Code:      gErrors.ProviderFaultTransient,
+				Message:   "API unavailable",
+				Retryable: true,
+				Details:   map[string]interface{}{"region": "east"},
+			},
+			found: true,
+		},
+		{
+			name:   "Quota fault with only a code and a message",
+			output: `{"code":"quota_exceeded","message":"no cores left"}`,
+			fault: gErrors.ProviderFault{
+				Code:          gErrors.ProviderFaultQuotaExceeded,
+				Message:       "no cores left",
+				Retryable:     true,
+				QuotaExceeded: true,
+			},
+			found: true,
+		},
+		{
+			name:   "Transient fault with only a code and a message",
+			output: `{"code":"transient","message":"timed out"}`,
+			fault: gErrors.ProviderFault{
+				Code:      gErrors.ProviderFaultTransient,
+				Message:   "timed out",
+				Retryable: true,
+			},
+			found: true,
+		},
+		{
+			name:   "Invalid request with only a code and a message",
+			output: `{"code":"invalid_request","message":"no such image"}`,
+			fault: gErrors.ProviderFault{
+				Code:    gErrors.ProviderFaultInvalidRequest,
+				Message: "no such image",
+			},
+			found: true,
+		},
+		{
+			name:   "Provider overrides the classification of the code",
+			output: `{"code":"transient","message":"timed out","retryable":false}`,
+			fault: gErrors.ProviderFault{
+				Code:    gErrors.ProviderFaultTransient,
+				Message: "timed out",
+			},
+			found: true,
+		},
+		{
+			name:   "Unrecognised code",
+			output: `{"code":"rate_limited","message":"slow down"}`,
+			fault: gErrors.ProviderFault{
+				Code:      gErrors.ProviderFaultUnknown,
+				Message:   "slow down",
+				Retryable: true,
+			},
+			found: true,
+		},
+		{
+			name:   "Fault after log messages",
+			output: "creating instance\n{\"not\":\"a fault\"}\n{\"code\":\"not_found\",\"message\":\"gone\"}\n",
+			fault: gErrors.ProviderFault{
+				Code:    gErrors.ProviderFaultNotFound,
+				Message: "gone",
+			},
+			found: true,
+		},
+		{
+			name:   "Fault spread over several lines",
+			output: "{\n  \"code\": \"duplicate\",\n  \"message\": \"exists\"\n}\n",
+			fault: gErrors.ProviderFault{
+				Code:    gErrors.ProviderFaultDuplicate,
+				Message: "exists",
+			},
+			found: true,
+		},
+		{
+			name:   "JSON without a code",
+			output: `{"message":"something failed"}`,
+		},
+		{
+			name:   "Plain text",
+			output: "something failed\n",
+		},
+		{
+			name:   "No output",
+			output: "",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			fault, found := ParseFault([]byte(tc.output))
+			require.Equal(t, tc.found, found)
+			require.Equal(t, tc.fault, fault)
+		})
+	}
+}
+
+func TestFaultFromExitCode(t *testing.T) {
+	tests := []struct {
+		exitCode int
+		code     gErrors.ProviderFaultCode
+	}{
+		{exitCode: ExitCodeNotFound, code: gErrors.ProviderFaultNotFound},
+		{exitCode: ExitCodeDuplicate, code: gErrors.ProviderFaultDuplicate},
+		{exitCode: ExitCodeQuotaExceeded, code: gErrors.ProviderFaultQuotaExceeded},
+		{exitCode: ExitCodeTransient, code: gErrors.ProviderFaultTransient},
+		{exitCode: ExitCodeInvalidRequest, code: gErrors.ProviderFaultInvalidRequest},
+		{exitCode: 1, code: gErrors.ProviderFaultUnknown},
+		{exitCode: -1, code: gErrors.ProviderFaultUnknown},
+	}
+
+	for _, tc := range tests {
+		t.Run(string(tc.code), func(t *testing.T) {
+			fault := FaultFromExitCode(tc.exitCode, "provider failed")
+			require.Equal(t, gErrors.NewProviderFault(tc.code, "provider failed"), fault)
+		})
+	}
+}
@@ -25,3 +25,51 @@ type ExternalProvider interface {
 	// Start boots up an instance.
 	Start(ctx context.Context, instance string) error
 }
+
+// HealthChecker may be implemented by external providers that are able to check
+// whether they can serve requests.
+type HealthChecker interface {
+	// Health returns an error if the provider is not able to serve requests.
+	Health(ctx context.Context) error
+}
+
+// InterfaceVersionGetter may be implemented by external providers that implement an
+// older version of the interface than the one of this package.
+type InterfaceVersionGetter interface {
+	// InterfaceVersion returns the version of the interface implemented by the provider.
+	InterfaceVersion() string
+}
+
+// The following interfaces are optional. The capabilities reported to garm by
+// ProviderCapabilities() depend on which of them a provider implements.
+
+// ConsoleLogGetter may be implemented by external providers that are able to fetch
+// the console log of an instance.
+type ConsoleLogGetter interface {
+	// GetConsoleLog returns the console log of an instance.
+	GetConsoleLog(ctx context.Context, instance string) (string, error)
+}
+
+// ImageValidator may be implemented by external providers that are able to check
+// that an image and flavor can be used to create instances.
+type ImageValidator interface {
+	// ValidateImage returns an error if instances cannot be created using the
+	// image and flavor.
+	ValidateImage(ctx context.Context, image, flavor string) error
+}
+
+// BulkLister may be implemented by external providers that are able to list the
+// instances of all pools at once.
+type BulkLister interface {
+	// ListAllInstances lists the instances created by this provider in all pools.
+	// The pool ID of each instance must be set.
+	ListAllInstances(ctx context.Context) ([]params.Instance, error)
+}
+
+// CapabilitiesGetter may be implemented by external providers that need to report
+// capabilities that differ from the ones implied by the interfaces they implement.
+// For example, a provider that implements Start and Stop by returning an error.
+type CapabilitiesGetter interface {
+	// GetCapabilities returns the capabilities of the provider.
+	GetCapabilities(ctx context.Context) (params.ProviderCapabilities, error)
+}
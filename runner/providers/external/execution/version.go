@@ -0,0 +1,78 @@
+package execution
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/cloudbase/garm/params"
+)
+
+const (
+	// InterfaceVersionV1 is the original interface. Providers implementing it do
+	// not know the GetVersion command.
+	InterfaceVersionV1 = "v1"
+	// InterfaceVersionV2 adds the GetVersion and GetCapabilities commands, and the
+	// optional GetConsoleLog, ValidateImage and ListAllInstances commands.
+	InterfaceVersionV2 = "v2"
+	// InterfaceVersion is the newest version of the interface. It is passed to
+	// providers in GARM_INTERFACE_VERSION.
+	InterfaceVersion = InterfaceVersionV2
+)
+
+// SupportedInterfaceVersions lists the interface versions garm is able to use.
+var SupportedInterfaceVersions = []string{
+	InterfaceVersionV1,
+	InterfaceVersionV2,
+}
+
+// ErrUnsupportedInterfaceVersion is returned for providers that implement a version
+// of the interface that garm does not support.
+var ErrUnsupportedInterfaceVersion = errors.New("unsupported interface version")
+
+// ValidateInterfaceVersion returns an error if garm does not support the interface
+// version implemented by a provider.
+func ValidateInterfaceVersion(version string) error {
+	for _, supported := range SupportedInterfaceVersions {
+		if version == supported {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w %q (supported versions: %s)", ErrUnsupportedInterfaceVersion, version, strings.Join(SupportedInterfaceVersions, ", "))
+}
+
+// ProviderInterfaceVersion returns the version of the interface implemented by a
+// provider. It is the newest version, unless the provider declares another one.
+func ProviderInterfaceVersion(provider ExternalProvider) string {
+	if getter, ok := provider.(InterfaceVersionGetter); ok {
+		return getter.InterfaceVersion()
+	}
+	return InterfaceVersion
+}
+
+// LegacyCapabilities returns the capabilities of providers that implement
+// InterfaceVersionV1.
+func LegacyCapabilities() params.ProviderCapabilities {
+	return params.ProviderCapabilities{
+		StartStop: true,
+	}
+}
+
+// ProviderCapabilities returns the capabilities of a provider, based on the optional
+// interfaces it implements.
+func ProviderCapabilities(ctx context.Context, provider ExternalProvider) (params.ProviderCapabilities, error) {
+	if getter, ok := provider.(CapabilitiesGetter); ok {
+		return getter.GetCapabilities(ctx)
+	}
+
+	_, consoleLogs := provider.(ConsoleLogGetter)
+	_, imageValidation := provider.(ImageValidator)
+	_, bulkList := provider.(BulkLister)
+	return params.ProviderCapabilities{
+		StartStop:       true,
+		ConsoleLogs:     consoleLogs,
+		ImageValidation: imageValidation,
+		BulkList:        bulkList,
+	}, nil
+}
@@ -5,7 +5,9 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
-	"os/exec"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/cloudbase/garm/config"
 	garmErrors "github.com/cloudbase/garm/errors"
@@ -20,21 +22,43 @@ import (
 
 var _ common.Provider = (*external)(nil)
 
+// negotiateTimeout is the time we wait for the provider to report its version and
+// capabilities when it is loaded.
+const negotiateTimeout = 30 * time.Second
+
 func NewProvider(ctx context.Context, cfg *config.Provider, controllerID string) (common.Provider, error) {
 	if cfg.ProviderType != params.ExternalProvider {
 		return nil, garmErrors.NewBadRequestError("invalid provider config")
 	}
 
+	if cfg.External.ProviderMode() == config.ExternalDaemonMode {
+		return newDaemonProvider(ctx, cfg, controllerID)
+	}
+
 	execPath, err := cfg.External.ExecutablePath()
 	if err != nil {
 		return nil, errors.Wrap(err, "fetching executable path")
 	}
-	return &external{
-		ctx:          ctx,
-		controllerID: controllerID,
-		cfg:          cfg,
-		execPath:     execPath,
-	}, nil
+	e := &external{
+		ctx:              ctx,
+		controllerID:     controllerID,
+		cfg:              cfg,
+		execPath:         execPath,
+		interfaceVersion: execution.InterfaceVersion,
+	}
+	ctx, cancel := context.WithTimeout(ctx, negotiateTimeout)
+	defer cancel()
+	if err := e.negotiate(ctx); err != nil {
+		if errors.Is(err, execution.ErrUnsupportedInterfaceVersion) {
+			return nil, errors.Wrapf(err, "negotiating interface version with provider %s", cfg.Name)
+		}
+		// The provider may fail for reasons that go away, like a cloud API that is not
+		// reachable yet. It is loaded as degraded, and negotiated with again by the next
+		// health check.
+		log.Printf("failed to negotiate interface version with provider %s: %s", cfg.Name, err)
+		e.MarkDegraded(errors.Wrap(err, "negotiating interface version"))
+	}
+	return e, nil
 }
 
 type external struct {
@@ -42,9 +66,108 @@ type external struct {
 	controllerID string
 	cfg          *config.Provider
 	execPath     string
+
+	// interfaceVersion and capabilities are reported by the provider when we negotiate
+	// with it. Until then, negotiated is false.
+	infoMux          sync.Mutex
+	negotiated       bool
+	interfaceVersion string
+	capabilities     params.ProviderCapabilities
+	listCache        bulkListCache
+
+	common.HealthTracker
 }
 
-func (e *external) validateResult(inst params.Instance) error {
+// isUnknownCommand returns true if the provider binary failed because it does not know
+// the command it was asked to run. Providers that know about structured errors know
+// all the commands garm asks for when negotiating.
+func isUnknownCommand(err error) bool {
+	var execErr *garmExec.Error
+	if !errors.As(err, &execErr) || execErr.ExitCode() != execution.ExitCodeUnknownCommand {
+		return false
+	}
+	_, ok := execution.ParseFault(execErr.Stderr)
+	return !ok
+}
+
+// negotiate asks the provider which version of the interface it implements, and
+// which optional features it supports. Providers that do not know the GetVersion
+// command implement the first version of the interface.
+func (e *external) negotiate(ctx context.Context) error {
+	asEnv := []string{
+		fmt.Sprintf("GARM_COMMAND=%s", execution.GetVersionCommand),
+		fmt.Sprintf("GARM_CONTROLLER_ID=%s", e.controllerID),
+		fmt.Sprintf("GARM_PROVIDER_CONFIG_FILE=%s", e.cfg.External.ConfigFile),
+		fmt.Sprintf("GARM_INTERFACE_VERSION=%s", execution.InterfaceVersion),
+	}
+	out, err := garmExec.Exec(ctx, e.execPath, nil, asEnv)
+	if err != nil {
+		if !isUnknownCommand(err) {
+			return errors.Wrapf(err, "running %s", execution.GetVersionCommand)
+		}
+		log.Printf("provider %s does not implement %s, assuming interface version %s: %s", e.cfg.Name, execution.GetVersionCommand, execution.InterfaceVersionV1, err)
+		e.setProviderInfo(execution.InterfaceVersionV1, execution.LegacyCapabilities())
+		return nil
+	}
+
+	version := strings.TrimSpace(string(out))
+	if err := execution.ValidateInterfaceVersion(version); err != nil {
+		return err
+	}
+	if version == execution.InterfaceVersionV1 {
+		e.setProviderInfo(version, execution.LegacyCapabilities())
+		return nil
+	}
+
+	asEnv = []string{
+		fmt.Sprintf("GARM_COMMAND=%s", execution.GetCapabilitiesCommand),
+		fmt.Sprintf("GARM_CONTROLLER_ID=%s", e.controllerID),
+		fmt.Sprintf("GARM_PROVIDER_CONFIG_FILE=%s", e.cfg.External.ConfigFile),
+		fmt.Sprintf("GARM_INTERFACE_VERSION=%s", version),
+	}
+	out, err = garmExec.Exec(ctx, e.execPath, nil, asEnv)
+	if err != nil {
+		return errors.Wrap(err, "fetching capabilities")
+	}
+	var capabilities params.ProviderCapabilities
+	if err := json.Unmarshal(out, &capabilities); err != nil {
+		return errors.Wrap(err, "decoding capabilities")
+	}
+	e.setProviderInfo(version, capabilities)
+	return nil
+}
+
+func (e *external) setProviderInfo(version string, capabilities params.ProviderCapabilities) {
+	e.infoMux.Lock()
+	defer e.infoMux.Unlock()
+
+	e.negotiated = true
+	e.interfaceVersion = version
+	e.capabilities = capabilities
+}
+
+func (e *external) isNegotiated() bool {
+	e.infoMux.Lock()
+	defer e.infoMux.Unlock()
+
+	return e.negotiated
+}
+
+func (e *external) providerInterfaceVersion() string {
+	e.infoMux.Lock()
+	defer e.infoMux.Unlock()
+
+	return e.interfaceVersion
+}
+
+func (e *external) providerCapabilities() params.ProviderCapabilities {
+	e.infoMux.Lock()
+	defer e.infoMux.Unlock()
+
+	return e.capabilities
+}
+
+func validateResult(inst params.Instance) error {
 	if inst.ProviderID == "" {
 		return garmErrors.NewProviderError("missing provider ID")
 	}
@@ -64,13 +187,42 @@ func (e *external) validateResult(inst params.Instance) error {
 	return nil
 }
 
+// execFault returns the fault of a failed run of the provider binary. The structured
+// error printed by the provider is used if there is one, and the exit code otherwise.
+func execFault(err error) garmErrors.ProviderFault {
+	var execErr *garmExec.Error
+	if !errors.As(err, &execErr) {
+		return execution.ResolveErrorToFault(err)
+	}
+
+	if fault, ok := execution.ParseFault(execErr.Stderr); ok {
+		return fault
+	}
+	if fault, ok := execution.ParseFault(execErr.Stdout); ok {
+		return fault
+	}
+
+	message := strings.TrimSpace(string(execErr.Stderr))
+	if message == "" {
+		message = err.Error()
+	}
+	return execution.FaultFromExitCode(execErr.ExitCode(), message)
+}
+
+func (e *external) providerError(err error) error {
+	return garmErrors.NewProviderFaultError(execFault(err), "provider binary %s returned error: %s", e.execPath, err)
+}
+
 // CreateInstance creates a new compute instance in the provider.
 func (e *external) CreateInstance(ctx context.Context, bootstrapParams params.BootstrapInstance) (params.Instance, error) {
+	defer e.listCache.invalidate()
+
 	asEnv := []string{
 		fmt.Sprintf("GARM_COMMAND=%s", execution.CreateInstanceCommand),
 		fmt.Sprintf("GARM_CONTROLLER_ID=%s", e.controllerID),
 		fmt.Sprintf("GARM_POOL_ID=%s", bootstrapParams.PoolID),
 		fmt.Sprintf("GARM_PROVIDER_CONFIG_FILE=%s", e.cfg.External.ConfigFile),
+		fmt.Sprintf("GARM_INTERFACE_VERSION=%s", e.providerInterfaceVersion()),
 	}
 
 	asJs, err := json.Marshal(bootstrapParams)
@@ -80,7 +232,7 @@ func (e *external) CreateInstance(ctx context.Context, bootstrapParams params.Bo
 
 	out, err := garmExec.Exec(ctx, e.execPath, asJs, asEnv)
 	if err != nil {
-		return params.Instance{}, garmErrors.NewProviderError("provider binary %s returned error: %s", e.execPath, err)
+		return params.Instance{}, e.providerError(err)
 	}
 
 	var param params.Instance
@@ -88,7 +240,7 @@ func (e *external) CreateInstance(ctx context.Context, bootstrapParams params.Bo
 		return params.Instance{}, garmErrors.NewProviderError("failed to decode response from binary: %s", err)
 	}
 
-	if err := e.validateResult(param); err != nil {
+	if err := validateResult(param); err != nil {
 		return params.Instance{}, garmErrors.NewProviderError("failed to validate result: %s", err)
 	}
 
@@ -99,20 +251,19 @@ func (e *external) CreateInstance(ctx context.Context, bootstrapParams params.Bo
 
 // Delete instance will delete the instance in a provider.
 func (e *external) DeleteInstance(ctx context.Context, instance string) error {
+	defer e.listCache.invalidate()
+
 	asEnv := []string{
 		fmt.Sprintf("GARM_COMMAND=%s", execution.DeleteInstanceCommand),
 		fmt.Sprintf("GARM_CONTROLLER_ID=%s", e.controllerID),
 		fmt.Sprintf("GARM_INSTANCE_ID=%s", instance),
 		fmt.Sprintf("GARM_PROVIDER_CONFIG_FILE=%s", e.cfg.External.ConfigFile),
+		fmt.Sprintf("GARM_INTERFACE_VERSION=%s", e.providerInterfaceVersion()),
 	}
 
 	_, err := garmExec.Exec(ctx, e.execPath, nil, asEnv)
-	if err != nil {
-		var exitErr *exec.ExitError
-		if !errors.As(err, &exitErr) || exitErr.ExitCode() != execution.ExitCodeNotFound {
-			return garmErrors.NewProviderError("provider binary %s returned error: %s", e.execPath, err)
-		}
-
+	if err != nil && execFault(err).Code != garmErrors.ProviderFaultNotFound {
+		return e.providerError(err)
 	}
 	return nil
 }
@@ -124,13 +275,14 @@ func (e *external) GetInstance(ctx context.Context, instance string) (params.Ins
 		fmt.Sprintf("GARM_CONTROLLER_ID=%s", e.controllerID),
 		fmt.Sprintf("GARM_INSTANCE_ID=%s", instance),
 		fmt.Sprintf("GARM_PROVIDER_CONFIG_FILE=%s", e.cfg.External.ConfigFile),
+		fmt.Sprintf("GARM_INTERFACE_VERSION=%s", e.providerInterfaceVersion()),
 	}
 
 	// TODO(gabriel-samfira): handle error types. Of particular insterest is to
 	// know when the error is ErrNotFound.
 	out, err := garmExec.Exec(ctx, e.execPath, nil, asEnv)
 	if err != nil {
-		return params.Instance{}, garmErrors.NewProviderError("provider binary %s returned error: %s", e.execPath, err)
+		return params.Instance{}, e.providerError(err)
 	}
 
 	var param params.Instance
@@ -138,25 +290,32 @@ func (e *external) GetInstance(ctx context.Context, instance string) (params.Ins
 		return params.Instance{}, garmErrors.NewProviderError("failed to decode response from binary: %s", err)
 	}
 
-	if err := e.validateResult(param); err != nil {
+	if err := validateResult(param); err != nil {
 		return params.Instance{}, garmErrors.NewProviderError("failed to validate result: %s", err)
 	}
 
 	return param, nil
 }
 
-// ListInstances will list all instances for a provider.
+// ListInstances will list all instances for a provider. Providers that support bulk
+// listing are asked for the instances of all pools at once, and the result is shared
+// by all pools for a short while.
 func (e *external) ListInstances(ctx context.Context, poolID string) ([]params.Instance, error) {
+	if e.providerCapabilities().BulkList {
+		return e.listCache.list(ctx, poolID, e.listAllInstances)
+	}
+
 	asEnv := []string{
 		fmt.Sprintf("GARM_COMMAND=%s", execution.ListInstancesCommand),
 		fmt.Sprintf("GARM_CONTROLLER_ID=%s", e.controllerID),
 		fmt.Sprintf("GARM_POOL_ID=%s", poolID),
 		fmt.Sprintf("GARM_PROVIDER_CONFIG_FILE=%s", e.cfg.External.ConfigFile),
+		fmt.Sprintf("GARM_INTERFACE_VERSION=%s", e.providerInterfaceVersion()),
 	}
 
 	out, err := garmExec.Exec(ctx, e.execPath, nil, asEnv)
 	if err != nil {
-		return []params.Instance{}, garmErrors.NewProviderError("provider binary %s returned error: %s", e.execPath, err)
+		return []params.Instance{}, e.providerError(err)
 	}
 
 	var param []params.Instance
@@ -165,23 +324,51 @@ func (e *external) ListInstances(ctx context.Context, poolID string) ([]params.I
 	}
 
 	for _, inst := range param {
-		if err := e.validateResult(inst); err != nil {
+		if err := validateResult(inst); err != nil {
 			return []params.Instance{}, garmErrors.NewProviderError("failed to validate result: %s", err)
 		}
 	}
 	return param, nil
 }
 
+// listAllInstances lists the instances of all pools.
+func (e *external) listAllInstances(ctx context.Context) ([]params.Instance, error) {
+	asEnv := []string{
+		fmt.Sprintf("GARM_COMMAND=%s", execution.ListAllInstancesCommand),
+		fmt.Sprintf("GARM_CONTROLLER_ID=%s", e.controllerID),
+		fmt.Sprintf("GARM_PROVIDER_CONFIG_FILE=%s", e.cfg.External.ConfigFile),
+		fmt.Sprintf("GARM_INTERFACE_VERSION=%s", e.providerInterfaceVersion()),
+	}
+
+	out, err := garmExec.Exec(ctx, e.execPath, nil, asEnv)
+	if err != nil {
+		return nil, e.providerError(err)
+	}
+
+	var param []params.Instance
+	if err := json.Unmarshal(out, &param); err != nil {
+		return nil, garmErrors.NewProviderError("failed to decode response from binary: %s", err)
+	}
+
+	for _, inst := range param {
+		if err := validateResult(inst); err != nil {
+			return nil, garmErrors.NewProviderError("failed to validate result: %s", err)
+		}
+	}
+	return param, nil
+}
+
 // RemoveAllInstances will remove all instances created by this provider.
 func (e *external) RemoveAllInstances(ctx context.Context) error {
 	asEnv := []string{
 		fmt.Sprintf("GARM_COMMAND=%s", execution.RemoveAllInstancesCommand),
 		fmt.Sprintf("GARM_CONTROLLER_ID=%s", e.controllerID),
 		fmt.Sprintf("GARM_PROVIDER_CONFIG_FILE=%s", e.cfg.External.ConfigFile),
+		fmt.Sprintf("GARM_INTERFACE_VERSION=%s", e.providerInterfaceVersion()),
 	}
 	_, err := garmExec.Exec(ctx, e.execPath, nil, asEnv)
 	if err != nil {
-		return garmErrors.NewProviderError("provider binary %s returned error: %s", e.execPath, err)
+		return e.providerError(err)
 	}
 	return nil
 }
@@ -193,10 +380,11 @@ func (e *external) Stop(ctx context.Context, instance string, force bool) error
 		fmt.Sprintf("GARM_CONTROLLER_ID=%s", e.controllerID),
 		fmt.Sprintf("GARM_INSTANCE_ID=%s", instance),
 		fmt.Sprintf("GARM_PROVIDER_CONFIG_FILE=%s", e.cfg.External.ConfigFile),
+		fmt.Sprintf("GARM_INTERFACE_VERSION=%s", e.providerInterfaceVersion()),
 	}
 	_, err := garmExec.Exec(ctx, e.execPath, nil, asEnv)
 	if err != nil {
-		return garmErrors.NewProviderError("provider binary %s returned error: %s", e.execPath, err)
+		return e.providerError(err)
 	}
 	return nil
 }
@@ -208,18 +396,93 @@ func (e *external) Start(ctx context.Context, instance string) error {
 		fmt.Sprintf("GARM_CONTROLLER_ID=%s", e.controllerID),
 		fmt.Sprintf("GARM_INSTANCE_ID=%s", instance),
 		fmt.Sprintf("GARM_PROVIDER_CONFIG_FILE=%s", e.cfg.External.ConfigFile),
+		fmt.Sprintf("GARM_INTERFACE_VERSION=%s", e.providerInterfaceVersion()),
+	}
+	_, err := garmExec.Exec(ctx, e.execPath, nil, asEnv)
+	if err != nil {
+		return e.providerError(err)
+	}
+	return nil
+}
+
+// Health runs the Health command of the provider binary. Older binaries do not implement
+// this command, so it is only run if health checks are enabled for the provider. Providers
+// we could not negotiate with when they were loaded are negotiated with first.
+func (e *external) Health(ctx context.Context) error {
+	if !e.isNegotiated() {
+		if err := e.negotiate(ctx); err != nil {
+			return e.MarkDegraded(errors.Wrap(err, "negotiating interface version"))
+		}
+		if !e.cfg.External.HealthCheck {
+			return e.RecordHealth(nil)
+		}
+	}
+	if !e.cfg.External.HealthCheck {
+		return nil
+	}
+
+	asEnv := []string{
+		fmt.Sprintf("GARM_COMMAND=%s", execution.HealthCommand),
+		fmt.Sprintf("GARM_CONTROLLER_ID=%s", e.controllerID),
+		fmt.Sprintf("GARM_PROVIDER_CONFIG_FILE=%s", e.cfg.External.ConfigFile),
+		fmt.Sprintf("GARM_INTERFACE_VERSION=%s", e.providerInterfaceVersion()),
+	}
+	_, err := garmExec.Exec(ctx, e.execPath, nil, asEnv)
+	if err != nil {
+		return e.RecordHealth(e.providerError(err))
+	}
+	return e.RecordHealth(nil)
+}
+
+// GetConsoleLog returns the console log of an instance.
+func (e *external) GetConsoleLog(ctx context.Context, instance string) (string, error) {
+	if !e.providerCapabilities().ConsoleLogs {
+		return "", garmErrors.NewProviderError("provider %s does not support console logs", e.cfg.Name)
+	}
+
+	asEnv := []string{
+		fmt.Sprintf("GARM_COMMAND=%s", execution.GetConsoleLogCommand),
+		fmt.Sprintf("GARM_CONTROLLER_ID=%s", e.controllerID),
+		fmt.Sprintf("GARM_INSTANCE_ID=%s", instance),
+		fmt.Sprintf("GARM_PROVIDER_CONFIG_FILE=%s", e.cfg.External.ConfigFile),
+		fmt.Sprintf("GARM_INTERFACE_VERSION=%s", e.providerInterfaceVersion()),
+	}
+	out, err := garmExec.Exec(ctx, e.execPath, nil, asEnv)
+	if err != nil {
+		return "", e.providerError(err)
+	}
+	return string(out), nil
+}
+
+// ValidateImage checks that instances can be created using the image and flavor.
+func (e *external) ValidateImage(ctx context.Context, image, flavor string) error {
+	if !e.providerCapabilities().ImageValidation {
+		return nil
+	}
+
+	asEnv := []string{
+		fmt.Sprintf("GARM_COMMAND=%s", execution.ValidateImageCommand),
+		fmt.Sprintf("GARM_CONTROLLER_ID=%s", e.controllerID),
+		fmt.Sprintf("GARM_IMAGE=%s", image),
+		fmt.Sprintf("GARM_FLAVOR=%s", flavor),
+		fmt.Sprintf("GARM_PROVIDER_CONFIG_FILE=%s", e.cfg.External.ConfigFile),
+		fmt.Sprintf("GARM_INTERFACE_VERSION=%s", e.providerInterfaceVersion()),
 	}
 	_, err := garmExec.Exec(ctx, e.execPath, nil, asEnv)
 	if err != nil {
-		return garmErrors.NewProviderError("provider binary %s returned error: %s", e.execPath, err)
+		return e.providerError(err)
 	}
 	return nil
 }
 
 func (e *external) AsParams() params.Provider {
 	return params.Provider{
-		Name:         e.cfg.Name,
-		Description:  e.cfg.Description,
-		ProviderType: e.cfg.ProviderType,
+		Name:             e.cfg.Name,
+		Description:      e.cfg.Description,
+		ProviderType:     e.cfg.ProviderType,
+		Limits:           e.cfg.Limits(),
+		Health:           e.ProviderHealth(),
+		InterfaceVersion: e.providerInterfaceVersion(),
+		Capabilities:     e.providerCapabilities(),
 	}
 }
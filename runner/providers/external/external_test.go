@@ -0,0 +1,104 @@
+package external
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cloudbase/garm/config"
+	"github.com/cloudbase/garm/params"
+	"github.com/cloudbase/garm/runner/providers/external/execution"
+
+	"github.com/stretchr/testify/require"
+)
+
+// providerScript answers GetVersion with the contents of the version file next to it,
+// or fails if the file does not exist.
+const providerScript = `#!/bin/sh
+dir=$(dirname "$0")
+case "$GARM_COMMAND" in
+GetVersion)
+	if [ ! -f "$dir/version" ]; then
+		echo "cloud is not reachable" >&2
+		exit 2
+	fi
+	cat "$dir/version"
+	;;
+GetCapabilities)
+	echo '{"start_stop": true, "console_logs": true}'
+	;;
+*)
+	exit 1
+	;;
+esac
+`
+
+func newTestProvider(t *testing.T, version string) (*config.Provider, string) {
+	dir := t.TempDir()
+	execPath := filepath.Join(dir, "garm-external-provider")
+	require.Nil(t, os.WriteFile(execPath, []byte(providerScript), 0o700))
+	versionFile := filepath.Join(dir, "version")
+	if version != "" {
+		require.Nil(t, os.WriteFile(versionFile, []byte(version), 0o600))
+	}
+
+	cfg := &config.Provider{
+		Name:         "test-provider",
+		ProviderType: params.ExternalProvider,
+		External: config.External{
+			ProviderExecutable: execPath,
+		},
+	}
+	return cfg, versionFile
+}
+
+func TestNewProviderNegotiates(t *testing.T) {
+	cfg, _ := newTestProvider(t, execution.InterfaceVersionV2)
+
+	provider, err := NewProvider(context.Background(), cfg, "test-controller")
+	require.Nil(t, err)
+
+	asParams := provider.AsParams()
+	require.Equal(t, execution.InterfaceVersionV2, asParams.InterfaceVersion)
+	require.Equal(t, params.ProviderCapabilities{StartStop: true, ConsoleLogs: true}, asParams.Capabilities)
+	require.False(t, asParams.Health.IsDegraded())
+}
+
+func TestNewProviderDeclaredV1(t *testing.T) {
+	cfg, _ := newTestProvider(t, execution.InterfaceVersionV1)
+
+	provider, err := NewProvider(context.Background(), cfg, "test-controller")
+	require.Nil(t, err)
+
+	asParams := provider.AsParams()
+	require.Equal(t, execution.InterfaceVersionV1, asParams.InterfaceVersion)
+	require.Equal(t, execution.LegacyCapabilities(), asParams.Capabilities)
+}
+
+func TestNewProviderUnsupportedVersion(t *testing.T) {
+	cfg, _ := newTestProvider(t, "v99")
+
+	_, err := NewProvider(context.Background(), cfg, "test-controller")
+	require.ErrorIs(t, err, execution.ErrUnsupportedInterfaceVersion)
+}
+
+func TestNewProviderRenegotiatesOnHealthCheck(t *testing.T) {
+	cfg, versionFile := newTestProvider(t, "")
+
+	provider, err := NewProvider(context.Background(), cfg, "test-controller")
+	require.Nil(t, err)
+	require.True(t, provider.AsParams().Health.IsDegraded())
+	require.Equal(t, params.ProviderCapabilities{}, provider.AsParams().Capabilities)
+
+	require.NotNil(t, provider.Health(context.Background()))
+	require.True(t, provider.AsParams().Health.IsDegraded())
+
+	require.Nil(t, os.WriteFile(versionFile, []byte(execution.InterfaceVersionV2), 0o600))
+	require.Nil(t, provider.Health(context.Background()))
+
+	asParams := provider.AsParams()
+	require.False(t, asParams.Health.IsDegraded())
+	require.Equal(t, execution.InterfaceVersionV2, asParams.InterfaceVersion)
+	require.Equal(t, params.ProviderCapabilities{StartStop: true, ConsoleLogs: true}, asParams.Capabilities)
+}
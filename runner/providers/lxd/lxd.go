@@ -17,6 +17,7 @@ package lxd
 import (
 	"context"
 	"fmt"
+	"io"
 	"log"
 	"sync"
 	"time"
@@ -118,6 +119,8 @@ type LXD struct {
 	controllerID string
 
 	mux sync.Mutex
+
+	common.HealthTracker
 }
 
 func (l *LXD) getCLI() (lxd.InstanceServer, error) {
@@ -273,7 +276,27 @@ func (l *LXD) AsParams() params.Provider {
 		Name:         l.cfg.Name,
 		ProviderType: l.cfg.ProviderType,
 		Description:  l.cfg.Description,
+		Limits:       l.cfg.Limits(),
+		Health:       l.ProviderHealth(),
+		Capabilities: params.ProviderCapabilities{
+			StartStop:       true,
+			ConsoleLogs:     true,
+			ImageValidation: true,
+		},
+	}
+}
+
+// Health pings the LXD server.
+func (l *LXD) Health(ctx context.Context) error {
+	cli, err := l.getCLI()
+	if err != nil {
+		return l.RecordHealth(errors.Wrap(err, "fetching client"))
 	}
+
+	if _, _, err := cli.GetServer(); err != nil {
+		return l.RecordHealth(errors.Wrap(err, "fetching server info"))
+	}
+	return l.RecordHealth(nil)
 }
 
 func (l *LXD) launchInstance(createArgs api.InstancesPost) error {
@@ -316,20 +339,25 @@ func (l *LXD) launchInstance(createArgs api.InstancesPost) error {
 func (l *LXD) CreateInstance(ctx context.Context, bootstrapParams params.BootstrapInstance) (params.Instance, error) {
 	extraSpecs, err := parseExtraSpecsFromBootstrapParams(bootstrapParams)
 	if err != nil {
-		return params.Instance{}, errors.Wrap(err, "parsing extra specs")
+		return params.Instance{}, withFault(errors.Wrap(err, "parsing extra specs"), runnerErrors.ProviderFaultInvalidRequest)
 	}
 	args, err := l.getCreateInstanceArgs(bootstrapParams, extraSpecs)
 	if err != nil {
-		return params.Instance{}, errors.Wrap(err, "fetching create args")
+		// A missing profile or image will still be missing if we try again.
+		code := runnerErrors.ProviderFaultUnknown
+		if isNotFoundError(err) || errors.Is(err, runnerErrors.ErrNotFound) {
+			code = runnerErrors.ProviderFaultInvalidRequest
+		}
+		return params.Instance{}, withFault(errors.Wrap(err, "fetching create args"), code)
 	}
 
 	if err := l.launchInstance(args); err != nil {
-		return params.Instance{}, errors.Wrap(err, "creating instance")
+		return params.Instance{}, withFault(errors.Wrap(err, "creating instance"), runnerErrors.ProviderFaultUnknown)
 	}
 
 	ret, err := l.waitInstanceHasIP(ctx, args.Name)
 	if err != nil {
-		return params.Instance{}, errors.Wrap(err, "fetching instance")
+		return params.Instance{}, withFault(errors.Wrap(err, "fetching instance"), runnerErrors.ProviderFaultUnknown)
 	}
 
 	return ret, nil
@@ -519,3 +547,36 @@ func (l *LXD) Stop(ctx context.Context, instance string, force bool) error {
 func (l *LXD) Start(ctx context.Context, instance string) error {
 	return l.setState(instance, "start", false)
 }
+
+// GetConsoleLog returns the console log of an instance.
+func (l *LXD) GetConsoleLog(ctx context.Context, instance string) (string, error) {
+	cli, err := l.getCLI()
+	if err != nil {
+		return "", errors.Wrap(err, "fetching client")
+	}
+
+	consoleLog, err := cli.GetInstanceConsoleLog(instance, &lxd.InstanceConsoleLogArgs{})
+	if err != nil {
+		return "", errors.Wrapf(err, "fetching console log of %s", instance)
+	}
+	defer consoleLog.Close()
+
+	data, err := io.ReadAll(consoleLog)
+	if err != nil {
+		return "", errors.Wrapf(err, "reading console log of %s", instance)
+	}
+	return string(data), nil
+}
+
+// ValidateImage checks that the image uses a configured remote, and that a profile
+// exists for the flavor.
+func (l *LXD) ValidateImage(ctx context.Context, image, flavor string) error {
+	if _, _, err := l.imageManager.parseImageName(image); err != nil {
+		return errors.Wrapf(err, "looking for remote of image %s", image)
+	}
+
+	if _, err := l.getProfiles(flavor); err != nil {
+		return errors.Wrap(err, "fetching profiles")
+	}
+	return nil
+}
@@ -26,6 +26,7 @@ import (
 	"time"
 
 	"github.com/cloudbase/garm/config"
+	runnerErrors "github.com/cloudbase/garm/errors"
 	"github.com/cloudbase/garm/params"
 	"github.com/cloudbase/garm/runner/providers/common"
 	"github.com/cloudbase/garm/util"
@@ -46,6 +47,13 @@ var httpResponseErrors = map[int][]error{
 	http.StatusNotFound: {os.ErrNotExist, sql.ErrNoRows},
 }
 
+// quotaErrors holds fragments of the errors LXD returns when a limit of the project,
+// or the storage pool, does not leave room for a new instance.
+var quotaErrors = []string{
+	"reached maximum",
+	"no space left on device",
+}
+
 // isNotFoundError returns true if the error is considered a Not Found error.
 func isNotFoundError(err error) bool {
 	if api.StatusErrorCheck(err, http.StatusNotFound) {
@@ -61,6 +69,36 @@ func isNotFoundError(err error) bool {
 	return false
 }
 
+// lxdFaultCode classifies an error returned by LXD. Errors that are neither caused by
+// a quota, nor transient, get the fallback code.
+func lxdFaultCode(err error, fallback runnerErrors.ProviderFaultCode) runnerErrors.ProviderFaultCode {
+	msg := strings.ToLower(err.Error())
+	for _, fragment := range quotaErrors {
+		if strings.Contains(msg, fragment) {
+			return runnerErrors.ProviderFaultQuotaExceeded
+		}
+	}
+
+	if errors.Is(err, runnerErrors.ErrTimeout) || errors.Is(err, context.DeadlineExceeded) {
+		return runnerErrors.ProviderFaultTransient
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return runnerErrors.ProviderFaultTransient
+	}
+	if api.StatusErrorCheck(err, http.StatusTooManyRequests, http.StatusServiceUnavailable, http.StatusGatewayTimeout) {
+		return runnerErrors.ProviderFaultTransient
+	}
+	return fallback
+}
+
+// withFault returns a ProviderError that carries the fault of an error returned by LXD,
+// so the pool manager knows whether to retry the operation.
+func withFault(err error, fallback runnerErrors.ProviderFaultCode) error {
+	fault := runnerErrors.NewProviderFault(lxdFaultCode(err, fallback), err.Error())
+	return runnerErrors.NewProviderFaultError(fault, "%s", err)
+}
+
 func lxdInstanceToAPIInstance(instance *api.InstanceFull) params.Instance {
 	lxdOS, ok := instance.ExpandedConfig["image.os"]
 	if !ok {
@@ -24,6 +24,7 @@ import (
 	runnerErrors "github.com/cloudbase/garm/errors"
 	"github.com/cloudbase/garm/params"
 	"github.com/cloudbase/garm/runner/common"
+	"github.com/cloudbase/garm/util"
 	"github.com/cloudbase/garm/util/appdefaults"
 
 	"github.com/pkg/errors"
@@ -38,12 +39,16 @@ func (r *Runner) CreateRepository(ctx context.Context, param params.CreateRepoPa
 		return params.Repository{}, errors.Wrap(err, "validating params")
 	}
 
-	creds, ok := r.credentials[param.CredentialsName]
-	if !ok {
-		return params.Repository{}, runnerErrors.NewBadRequestError("credentials %s not defined", param.CredentialsName)
+	creds, err := r.getGithubCredentials(ctx, param.CredentialsName)
+	if err != nil {
+		if errors.Is(err, runnerErrors.ErrNotFound) {
+			return params.Repository{}, runnerErrors.NewBadRequestError("credentials %s not defined", param.CredentialsName)
+		}
+		return params.Repository{}, errors.Wrap(err, "fetching credentials")
 	}
 
-	_, err = r.store.GetRepository(ctx, param.Owner, param.Name)
+	endpoint := util.GithubEndpoint(creds.BaseURL)
+	_, err = r.store.GetRepository(ctx, endpoint, param.Owner, param.Name)
 	if err != nil {
 		if !errors.Is(err, runnerErrors.ErrNotFound) {
 			return params.Repository{}, errors.Wrap(err, "fetching repo")
@@ -52,18 +57,54 @@ func (r *Runner) CreateRepository(ctx context.Context, param params.CreateRepoPa
 		return params.Repository{}, runnerErrors.NewConflictError("repository %s/%s already exists", param.Owner, param.Name)
 	}
 
-	repo, err = r.store.CreateRepository(ctx, param.Owner, param.Name, creds.Name, param.WebhookSecret)
+	if param.ManageWebhook && r.config.Default.WebhookURL == "" {
+		return params.Repository{}, runnerErrors.NewBadRequestError("webhook_url must be configured in order to manage webhooks")
+	}
+
+	// The secret may only be missing if garm manages the webhook or polls for jobs.
+	// Generate one, to be used by the managed webhook or by one set up later on.
+	if param.WebhookSecret == "" {
+		param.WebhookSecret, err = util.GetRandomString(webhookSecretLength)
+		if err != nil {
+			return params.Repository{}, errors.Wrap(err, "generating webhook secret")
+		}
+	}
+
+	repo, err = r.store.CreateRepository(ctx, endpoint, param.Owner, param.Name, creds.Name, param.WebhookSecret)
 	if err != nil {
 		return params.Repository{}, errors.Wrap(err, "creating repository")
 	}
 
-	defer func() {
+	// The ID is passed as an argument, as the named return value is overwritten
+	// by the time the deferred function runs.
+	defer func(id string) {
 		if err != nil {
-			if deleteErr := r.store.DeleteRepository(ctx, repo.ID); deleteErr != nil {
+			if deleteErr := r.store.DeleteRepository(ctx, id); deleteErr != nil {
 				log.Printf("failed to delete repository: %s", deleteErr)
 			}
 		}
-	}()
+	}(repo.ID)
+
+	if param.PollingEnabled || param.PollingInterval != 0 {
+		pollingParams := params.UpdateEntityParams{
+			PollingEnabled:  &param.PollingEnabled,
+			PollingInterval: &param.PollingInterval,
+		}
+		repo, err = r.store.UpdateRepository(ctx, repo.ID, pollingParams)
+		if err != nil {
+			return params.Repository{}, errors.Wrap(err, "setting polling options")
+		}
+	}
+
+	if param.PoolSelectionStrategy != "" {
+		strategyParams := params.UpdateEntityParams{
+			PoolSelectionStrategy: param.PoolSelectionStrategy,
+		}
+		repo, err = r.store.UpdateRepository(ctx, repo.ID, strategyParams)
+		if err != nil {
+			return params.Repository{}, errors.Wrap(err, "setting pool selection strategy")
+		}
+	}
 
 	poolMgr, err := r.poolManagerCtrl.CreateRepoPoolManager(r.ctx, repo, r.providers, r.store)
 	if err != nil {
@@ -75,6 +116,16 @@ func (r *Runner) CreateRepository(ctx context.Context, param params.CreateRepoPa
 		}
 		return params.Repository{}, errors.Wrap(err, "starting repo pool manager")
 	}
+
+	if param.ManageWebhook {
+		if _, err := poolMgr.InstallWebhook(params.InstallWebhookParams{}); err != nil {
+			if deleteErr := r.poolManagerCtrl.DeleteRepoPoolManager(repo); deleteErr != nil {
+				log.Printf("failed to cleanup pool manager for repo %s", repo.ID)
+			}
+			return params.Repository{}, errors.Wrap(err, "installing webhook")
+		}
+	}
+	repo.WebhookURL = util.EntityWebhookURL(r.config.Default.WebhookURL, repo.ID)
 	return repo, nil
 }
 
@@ -98,6 +149,7 @@ func (r *Runner) ListRepositories(ctx context.Context) ([]params.Repository, err
 		} else {
 			repo.PoolManagerStatus = poolMgr.Status()
 		}
+		repo.WebhookURL = util.EntityWebhookURL(r.config.Default.WebhookURL, repo.ID)
 		allRepos = append(allRepos, repo)
 	}
 
@@ -120,6 +172,7 @@ func (r *Runner) GetRepositoryByID(ctx context.Context, repoID string) (params.R
 		repo.PoolManagerStatus.FailureReason = fmt.Sprintf("failed to get pool manager: %q", err)
 	}
 	repo.PoolManagerStatus = poolMgr.Status()
+	repo.WebhookURL = util.EntityWebhookURL(r.config.Default.WebhookURL, repo.ID)
 	return repo, nil
 }
 
@@ -147,6 +200,18 @@ func (r *Runner) DeleteRepository(ctx context.Context, repoID string) error {
 		return runnerErrors.NewBadRequestError("repo has pools defined (%s)", strings.Join(poolIds, ", "))
 	}
 
+	if r.config.Default.WebhookURL != "" {
+		poolMgr, err := r.poolManagerCtrl.GetRepoPoolManager(repo)
+		if err != nil {
+			return errors.Wrap(err, "fetching repo pool manager")
+		}
+		// Remove the webhook garm installed, if any. Failing to remove the webhook
+		// should not prevent the repository from being deleted.
+		if err := poolMgr.UninstallWebhook(); err != nil && !errors.Is(err, runnerErrors.ErrNotFound) {
+			log.Printf("failed to uninstall webhook for repo %s/%s: %s", repo.Owner, repo.Name, err)
+		}
+	}
+
 	if err := r.poolManagerCtrl.DeleteRepoPoolManager(repo); err != nil {
 		return errors.Wrap(err, "deleting repo pool manager")
 	}
@@ -162,6 +227,14 @@ func (r *Runner) UpdateRepository(ctx context.Context, repoID string, param para
 		return params.Repository{}, runnerErrors.ErrUnauthorized
 	}
 
+	if err := param.Validate(); err != nil {
+		return params.Repository{}, errors.Wrap(err, "validating params")
+	}
+
+	if param.JobSchedulingStrategy != "" {
+		return params.Repository{}, runnerErrors.NewBadRequestError("job scheduling strategy can only be set on organizations and enterprises")
+	}
+
 	r.mux.Lock()
 	defer r.mux.Unlock()
 
@@ -170,10 +243,27 @@ func (r *Runner) UpdateRepository(ctx context.Context, repoID string, param para
 		return params.Repository{}, errors.Wrap(err, "fetching repo")
 	}
 
+	var endpoint string
 	if param.CredentialsName != "" {
 		// Check that credentials are set before saving to db
-		if _, ok := r.credentials[param.CredentialsName]; !ok {
-			return params.Repository{}, runnerErrors.NewBadRequestError("invalid credentials (%s) for repo %s/%s", param.CredentialsName, repo.Owner, repo.Name)
+		creds, err := r.getGithubCredentials(ctx, param.CredentialsName)
+		if err != nil {
+			if errors.Is(err, runnerErrors.ErrNotFound) {
+				return params.Repository{}, runnerErrors.NewBadRequestError("invalid credentials (%s) for repo %s/%s", param.CredentialsName, repo.Owner, repo.Name)
+			}
+			return params.Repository{}, errors.Wrap(err, "fetching credentials")
+		}
+		// The endpoint is part of the identity of the repo. It can't be moved to another
+		// github server by switching credentials.
+		endpoint = util.GithubEndpoint(creds.BaseURL)
+		if repo.Endpoint != "" && repo.Endpoint != endpoint {
+			return params.Repository{}, runnerErrors.NewBadRequestError("credentials %s are for %s, but repo %s/%s is on %s", param.CredentialsName, endpoint, repo.Owner, repo.Name, repo.Endpoint)
+		}
+	}
+
+	if repo.Endpoint == "" && endpoint != "" {
+		if err := r.store.UpdateEntityEndpoint(ctx, params.RepositoryPool, repoID, endpoint); err != nil {
+			return params.Repository{}, errors.Wrap(err, "updating repo endpoint")
 		}
 	}
 
@@ -187,7 +277,13 @@ func (r *Runner) UpdateRepository(ctx context.Context, repoID string, param para
 		return params.Repository{}, fmt.Errorf("failed to update pool manager: %w", err)
 	}
 
+	if param.WebhookSecret != "" {
+		// Make sure the webhook garm manages uses the new secret.
+		r.refreshManagedWebhook(poolMgr)
+	}
+
 	repo.PoolManagerStatus = poolMgr.Status()
+	repo.WebhookURL = util.EntityWebhookURL(r.config.Default.WebhookURL, repo.ID)
 	return repo, nil
 }
 
@@ -208,7 +304,7 @@ func (r *Runner) CreateRepoPool(ctx context.Context, repoID string, param params
 		return params.Pool{}, runnerErrors.ErrNotFound
 	}
 
-	createPoolParams, err := r.appendTagsToCreatePoolParams(param)
+	createPoolParams, err := r.appendTagsToCreatePoolParams(ctx, param)
 	if err != nil {
 		return params.Pool{}, errors.Wrap(err, "fetching pool params")
 	}
@@ -303,6 +399,7 @@ func (r *Runner) UpdateRepoPool(ctx context.Context, repoID, poolID string, para
 
 	maxRunners := pool.MaxRunners
 	minIdleRunners := pool.MinIdleRunners
+	warmRunners := pool.WarmRunners
 
 	if param.MaxRunners != nil {
 		maxRunners = *param.MaxRunners
@@ -310,11 +407,32 @@ func (r *Runner) UpdateRepoPool(ctx context.Context, repoID, poolID string, para
 	if param.MinIdleRunners != nil {
 		minIdleRunners = *param.MinIdleRunners
 	}
+	if param.WarmRunners != nil {
+		warmRunners = *param.WarmRunners
+	}
 
 	if minIdleRunners > maxRunners {
 		return params.Pool{}, runnerErrors.NewBadRequestError("min_idle_runners cannot be larger than max_runners")
 	}
 
+	if minIdleRunners+warmRunners > maxRunners {
+		return params.Pool{}, runnerErrors.NewBadRequestError("min_idle_runners plus warm_runners cannot be larger than max_runners")
+	}
+
+	if err := validateScalingSchedules(poolWithUpdate(pool, param)); err != nil {
+		return params.Pool{}, err
+	}
+
+	if param.FallbackProviders != nil {
+		if err := r.validateFallbackProviders(pool.ProviderName, param.FallbackProviders); err != nil {
+			return params.Pool{}, err
+		}
+	}
+
+	if err := r.validateProviderCapabilities(ctx, poolWithUpdate(pool, param), imagesChanged(param)); err != nil {
+		return params.Pool{}, err
+	}
+
 	newPool, err := r.store.UpdateRepositoryPool(ctx, repoID, poolID, param)
 	if err != nil {
 		return params.Pool{}, errors.Wrap(err, "updating pool")
@@ -334,11 +452,11 @@ func (r *Runner) ListRepoInstances(ctx context.Context, repoID string) ([]params
 	return instances, nil
 }
 
-func (r *Runner) findRepoPoolManager(owner, name string) (common.PoolManager, error) {
+func (r *Runner) findRepoPoolManager(endpoint, owner, name string) (common.PoolManager, error) {
 	r.mux.Lock()
 	defer r.mux.Unlock()
 
-	repo, err := r.store.GetRepository(r.ctx, owner, name)
+	repo, err := r.store.GetRepository(r.ctx, endpoint, owner, name)
 	if err != nil {
 		return nil, errors.Wrap(err, "fetching repo")
 	}
@@ -349,3 +467,80 @@ func (r *Runner) findRepoPoolManager(owner, name string) (common.PoolManager, er
 	}
 	return poolManager, nil
 }
+
+func (r *Runner) InstallRepoWebhook(ctx context.Context, repoID string, param params.InstallWebhookParams) (params.HookInfo, error) {
+	if !auth.IsAdmin(ctx) {
+		return params.HookInfo{}, runnerErrors.ErrUnauthorized
+	}
+
+	if r.config.Default.WebhookURL == "" {
+		return params.HookInfo{}, runnerErrors.NewBadRequestError("webhook_url must be configured in order to manage webhooks")
+	}
+
+	repo, err := r.store.GetRepositoryByID(ctx, repoID)
+	if err != nil {
+		return params.HookInfo{}, errors.Wrap(err, "fetching repo")
+	}
+
+	poolMgr, err := r.poolManagerCtrl.GetRepoPoolManager(repo)
+	if err != nil {
+		return params.HookInfo{}, errors.Wrap(err, "fetching repo pool manager")
+	}
+
+	info, err := poolMgr.InstallWebhook(param)
+	if err != nil {
+		return params.HookInfo{}, errors.Wrap(err, "installing webhook")
+	}
+	return info, nil
+}
+
+func (r *Runner) GetRepoWebhookInfo(ctx context.Context, repoID string) (params.HookInfo, error) {
+	if !auth.IsAdmin(ctx) {
+		return params.HookInfo{}, runnerErrors.ErrUnauthorized
+	}
+
+	if r.config.Default.WebhookURL == "" {
+		return params.HookInfo{}, runnerErrors.NewBadRequestError("webhook_url must be configured in order to manage webhooks")
+	}
+
+	repo, err := r.store.GetRepositoryByID(ctx, repoID)
+	if err != nil {
+		return params.HookInfo{}, errors.Wrap(err, "fetching repo")
+	}
+
+	poolMgr, err := r.poolManagerCtrl.GetRepoPoolManager(repo)
+	if err != nil {
+		return params.HookInfo{}, errors.Wrap(err, "fetching repo pool manager")
+	}
+
+	info, err := poolMgr.GetWebhookInfo()
+	if err != nil {
+		return params.HookInfo{}, errors.Wrap(err, "fetching webhook info")
+	}
+	return info, nil
+}
+
+func (r *Runner) UninstallRepoWebhook(ctx context.Context, repoID string) error {
+	if !auth.IsAdmin(ctx) {
+		return runnerErrors.ErrUnauthorized
+	}
+
+	if r.config.Default.WebhookURL == "" {
+		return runnerErrors.NewBadRequestError("webhook_url must be configured in order to manage webhooks")
+	}
+
+	repo, err := r.store.GetRepositoryByID(ctx, repoID)
+	if err != nil {
+		return errors.Wrap(err, "fetching repo")
+	}
+
+	poolMgr, err := r.poolManagerCtrl.GetRepoPoolManager(repo)
+	if err != nil {
+		return errors.Wrap(err, "fetching repo pool manager")
+	}
+
+	if err := poolMgr.UninstallWebhook(); err != nil {
+		return errors.Wrap(err, "uninstalling webhook")
+	}
+	return nil
+}
@@ -29,6 +29,7 @@ import (
 	"github.com/cloudbase/garm/runner/common"
 	runnerCommonMocks "github.com/cloudbase/garm/runner/common/mocks"
 	runnerMocks "github.com/cloudbase/garm/runner/mocks"
+	providerCommon "github.com/cloudbase/garm/runner/providers/common"
 
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/suite"
@@ -74,6 +75,7 @@ func (s *RepoTestSuite) SetupTest() {
 		name := fmt.Sprintf("test-repo-%v", i)
 		repo, err := db.CreateRepository(
 			adminCtx,
+			"https://github.com",
 			fmt.Sprintf("test-owner-%v", i),
 			name,
 			fmt.Sprintf("test-creds-%v", i),
@@ -89,6 +91,10 @@ func (s *RepoTestSuite) SetupTest() {
 	var maxRunners uint = 40
 	var minIdleRunners uint = 20
 	providerMock := runnerCommonMocks.NewProvider(s.T())
+	providerMock.On("AsParams").Return(params.Provider{
+		Name:         "test-provider",
+		Capabilities: params.ProviderCapabilities{StartStop: true},
+	}).Maybe()
 	fixtures := &RepoTestFixtures{
 		AdminContext: auth.GetAdminContext(),
 		Store:        db,
@@ -102,6 +108,12 @@ func (s *RepoTestSuite) SetupTest() {
 				Description: "test-creds-description",
 				OAuth2Token: "test-creds-oauth2-token",
 			},
+			"test-ghes-creds": {
+				Name:        "test-ghes-creds-name",
+				Description: "test-ghes-creds-description",
+				OAuth2Token: "test-ghes-creds-oauth2-token",
+				BaseURL:     "https://ghes.example.com/",
+			},
 		},
 		CreateRepoParams: params.CreateRepoParams{
 			Owner:           "test-owner-create",
@@ -202,6 +214,21 @@ func (s *RepoTestSuite) TestCreateRepositoryAlreadyExists() {
 	s.Require().Equal(runnerErrors.NewConflictError("repository %s/%s already exists", s.Fixtures.CreateRepoParams.Owner, s.Fixtures.CreateRepoParams.Name), err)
 }
 
+func (s *RepoTestSuite) TestCreateRepositorySameNameOtherEndpoint() {
+	s.Fixtures.PoolMgrMock.On("Start").Return(nil)
+	s.Fixtures.PoolMgrCtrlMock.On("CreateRepoPoolManager", s.Fixtures.AdminContext, mock.AnythingOfType("params.Repository"), s.Fixtures.Providers, s.Fixtures.Store).Return(s.Fixtures.PoolMgrMock, nil)
+	// this is already created on github.com in `SetupTest()`
+	s.Fixtures.CreateRepoParams.Owner = "test-owner-1"
+	s.Fixtures.CreateRepoParams.Name = "test-repo-1"
+	s.Fixtures.CreateRepoParams.CredentialsName = "test-ghes-creds"
+
+	repo, err := s.Runner.CreateRepository(s.Fixtures.AdminContext, s.Fixtures.CreateRepoParams)
+
+	s.Require().Nil(err)
+	s.Require().Equal("https://ghes.example.com", repo.Endpoint)
+	s.Require().NotEqual(s.Fixtures.StoreRepos["test-repo-1"].ID, repo.ID)
+}
+
 func (s *RepoTestSuite) TestCreateRepositoryPoolMgrFailed() {
 	s.Fixtures.PoolMgrCtrlMock.On("CreateRepoPoolManager", s.Fixtures.AdminContext, mock.AnythingOfType("params.Repository"), s.Fixtures.Providers, s.Fixtures.Store).Return(s.Fixtures.PoolMgrMock, s.Fixtures.ErrMock)
 
@@ -224,6 +251,85 @@ func (s *RepoTestSuite) TestCreateRepositoryStartPoolMgrFailed() {
 	s.Require().Equal(fmt.Sprintf("starting repo pool manager: %s", s.Fixtures.ErrMock.Error()), err.Error())
 }
 
+func (s *RepoTestSuite) TestCreateRepositoryManageWebhook() {
+	s.Runner.config.Default.WebhookURL = "https://garm.example.com/webhooks"
+	s.Fixtures.CreateRepoParams.WebhookSecret = ""
+	s.Fixtures.CreateRepoParams.ManageWebhook = true
+	s.Fixtures.PoolMgrMock.On("Start").Return(nil)
+	s.Fixtures.PoolMgrMock.On("InstallWebhook", params.InstallWebhookParams{}).Return(params.HookInfo{}, nil)
+	s.Fixtures.PoolMgrCtrlMock.On("CreateRepoPoolManager", s.Fixtures.AdminContext, mock.AnythingOfType("params.Repository"), s.Fixtures.Providers, s.Fixtures.Store).Return(s.Fixtures.PoolMgrMock, nil)
+
+	repo, err := s.Runner.CreateRepository(s.Fixtures.AdminContext, s.Fixtures.CreateRepoParams)
+
+	s.Fixtures.PoolMgrMock.AssertExpectations(s.T())
+	s.Fixtures.PoolMgrCtrlMock.AssertExpectations(s.T())
+	s.Require().Nil(err)
+	s.Require().Len(repo.WebhookSecret, webhookSecretLength)
+}
+
+func (s *RepoTestSuite) TestCreateRepositoryManageWebhookMissingWebhookURL() {
+	s.Fixtures.CreateRepoParams.ManageWebhook = true
+
+	_, err := s.Runner.CreateRepository(s.Fixtures.AdminContext, s.Fixtures.CreateRepoParams)
+
+	s.Require().Equal(runnerErrors.NewBadRequestError("webhook_url must be configured in order to manage webhooks"), err)
+}
+
+func (s *RepoTestSuite) TestCreateRepositoryInstallWebhookFailed() {
+	s.Runner.config.Default.WebhookURL = "https://garm.example.com/webhooks"
+	s.Fixtures.CreateRepoParams.ManageWebhook = true
+	s.Fixtures.PoolMgrMock.On("Start").Return(nil)
+	s.Fixtures.PoolMgrMock.On("InstallWebhook", params.InstallWebhookParams{}).Return(params.HookInfo{}, s.Fixtures.ErrMock)
+	s.Fixtures.PoolMgrCtrlMock.On("CreateRepoPoolManager", s.Fixtures.AdminContext, mock.AnythingOfType("params.Repository"), s.Fixtures.Providers, s.Fixtures.Store).Return(s.Fixtures.PoolMgrMock, nil)
+	s.Fixtures.PoolMgrCtrlMock.On("DeleteRepoPoolManager", mock.AnythingOfType("params.Repository")).Return(nil)
+
+	_, err := s.Runner.CreateRepository(s.Fixtures.AdminContext, s.Fixtures.CreateRepoParams)
+
+	s.Fixtures.PoolMgrMock.AssertExpectations(s.T())
+	s.Fixtures.PoolMgrCtrlMock.AssertExpectations(s.T())
+	s.Require().Equal(fmt.Sprintf("installing webhook: %s", s.Fixtures.ErrMock.Error()), err.Error())
+	_, err = s.Fixtures.Store.GetRepository(s.Fixtures.AdminContext, "https://github.com", s.Fixtures.CreateRepoParams.Owner, s.Fixtures.CreateRepoParams.Name)
+	s.Require().ErrorIs(err, runnerErrors.ErrNotFound)
+}
+
+func (s *RepoTestSuite) TestCreateRepositoryPollingEnabled() {
+	s.Fixtures.CreateRepoParams.WebhookSecret = ""
+	s.Fixtures.CreateRepoParams.PollingEnabled = true
+	s.Fixtures.CreateRepoParams.PollingInterval = 30
+	s.Fixtures.PoolMgrMock.On("Start").Return(nil)
+	s.Fixtures.PoolMgrCtrlMock.On("CreateRepoPoolManager", s.Fixtures.AdminContext, mock.AnythingOfType("params.Repository"), s.Fixtures.Providers, s.Fixtures.Store).Return(s.Fixtures.PoolMgrMock, nil)
+
+	repo, err := s.Runner.CreateRepository(s.Fixtures.AdminContext, s.Fixtures.CreateRepoParams)
+
+	s.Fixtures.PoolMgrMock.AssertExpectations(s.T())
+	s.Fixtures.PoolMgrCtrlMock.AssertExpectations(s.T())
+	s.Require().Nil(err)
+	s.Require().True(repo.PollingEnabled)
+	s.Require().Equal(uint(30), repo.PollingInterval)
+}
+
+func (s *RepoTestSuite) TestCreateRepositoryPollingIntervalTooLow() {
+	s.Fixtures.CreateRepoParams.PollingEnabled = true
+	s.Fixtures.CreateRepoParams.PollingInterval = 1
+
+	_, err := s.Runner.CreateRepository(s.Fixtures.AdminContext, s.Fixtures.CreateRepoParams)
+
+	s.Require().Equal("validating params: polling interval must be at least 10 seconds", err.Error())
+}
+
+func (s *RepoTestSuite) TestCreateRepositoryPoolSelectionStrategy() {
+	s.Fixtures.CreateRepoParams.PoolSelectionStrategy = params.PoolSelectionPriority
+	s.Fixtures.PoolMgrMock.On("Start").Return(nil)
+	s.Fixtures.PoolMgrCtrlMock.On("CreateRepoPoolManager", s.Fixtures.AdminContext, mock.AnythingOfType("params.Repository"), s.Fixtures.Providers, s.Fixtures.Store).Return(s.Fixtures.PoolMgrMock, nil)
+
+	repo, err := s.Runner.CreateRepository(s.Fixtures.AdminContext, s.Fixtures.CreateRepoParams)
+
+	s.Fixtures.PoolMgrMock.AssertExpectations(s.T())
+	s.Fixtures.PoolMgrCtrlMock.AssertExpectations(s.T())
+	s.Require().Nil(err)
+	s.Require().Equal(params.PoolSelectionPriority, repo.PoolSelectionStrategy)
+}
+
 func (s *RepoTestSuite) TestListRepositories() {
 	s.Fixtures.PoolMgrCtrlMock.On("GetRepoPoolManager", mock.AnythingOfType("params.Repository")).Return(s.Fixtures.PoolMgrMock, nil)
 	s.Fixtures.PoolMgrMock.On("Status").Return(params.PoolManagerStatus{IsRunning: true}, nil)
@@ -266,6 +372,20 @@ func (s *RepoTestSuite) TestDeleteRepository() {
 	s.Require().Equal("fetching repo: not found", err.Error())
 }
 
+func (s *RepoTestSuite) TestDeleteRepositoryUninstallWebhook() {
+	s.Runner.config.Default.WebhookURL = "https://garm.example.com/webhooks"
+	s.Fixtures.PoolMgrMock.On("UninstallWebhook").Return(s.Fixtures.ErrMock)
+	s.Fixtures.PoolMgrCtrlMock.On("GetRepoPoolManager", mock.AnythingOfType("params.Repository")).Return(s.Fixtures.PoolMgrMock, nil)
+	s.Fixtures.PoolMgrCtrlMock.On("DeleteRepoPoolManager", mock.AnythingOfType("params.Repository")).Return(nil)
+
+	err := s.Runner.DeleteRepository(s.Fixtures.AdminContext, s.Fixtures.StoreRepos["test-repo-1"].ID)
+
+	// Failing to remove the webhook does not prevent the repository from being deleted.
+	s.Fixtures.PoolMgrMock.AssertExpectations(s.T())
+	s.Fixtures.PoolMgrCtrlMock.AssertExpectations(s.T())
+	s.Require().Nil(err)
+}
+
 func (s *RepoTestSuite) TestDeleteRepositoryErrUnauthorized() {
 	err := s.Runner.DeleteRepository(context.Background(), "dummy-repo-id")
 
@@ -319,6 +439,93 @@ func (s *RepoTestSuite) TestUpdateRepositoryInvalidCreds() {
 	s.Require().Equal(runnerErrors.NewBadRequestError("invalid credentials (%s) for repo %s/%s", s.Fixtures.UpdateRepoParams.CredentialsName, s.Fixtures.StoreRepos["test-repo-1"].Owner, s.Fixtures.StoreRepos["test-repo-1"].Name), err)
 }
 
+func (s *RepoTestSuite) TestUpdateRepositoryCredsOtherEndpoint() {
+	s.Fixtures.UpdateRepoParams.CredentialsName = "test-ghes-creds"
+
+	_, err := s.Runner.UpdateRepository(s.Fixtures.AdminContext, s.Fixtures.StoreRepos["test-repo-1"].ID, s.Fixtures.UpdateRepoParams)
+
+	s.Require().Equal(runnerErrors.NewBadRequestError("credentials %s are for %s, but repo %s/%s is on %s", "test-ghes-creds", "https://ghes.example.com", "test-owner-1", "test-repo-1", "https://github.com"), err)
+}
+
+func (s *RepoTestSuite) TestMigrateEntityEndpoints() {
+	repo, err := s.Fixtures.Store.CreateRepository(s.Fixtures.AdminContext, "", "test-owner-migrate", "test-repo-migrate", "test-ghes-creds", "test-webhook-secret")
+	if err != nil {
+		s.FailNow(fmt.Sprintf("failed to create repo: %s", err))
+	}
+
+	err = s.Runner.migrateEntityEndpoints()
+
+	s.Require().Nil(err)
+	repo, err = s.Fixtures.Store.GetRepositoryByID(s.Fixtures.AdminContext, repo.ID)
+	s.Require().Nil(err)
+	s.Require().Equal("https://ghes.example.com", repo.Endpoint)
+	// Repos that already have an endpoint, or use unknown credentials, are left untouched.
+	repo, err = s.Fixtures.Store.GetRepositoryByID(s.Fixtures.AdminContext, s.Fixtures.StoreRepos["test-repo-1"].ID)
+	s.Require().Nil(err)
+	s.Require().Equal("https://github.com", repo.Endpoint)
+}
+
+func (s *RepoTestSuite) TestUpdateRepositoryPolling() {
+	pollingEnabled := true
+	pollingInterval := uint(30)
+	s.Fixtures.UpdateRepoParams.PollingEnabled = &pollingEnabled
+	s.Fixtures.UpdateRepoParams.PollingInterval = &pollingInterval
+	s.Fixtures.PoolMgrCtrlMock.On("UpdateRepoPoolManager", s.Fixtures.AdminContext, mock.AnythingOfType("params.Repository")).Return(s.Fixtures.PoolMgrMock, nil)
+	s.Fixtures.PoolMgrMock.On("Status").Return(params.PoolManagerStatus{IsRunning: true}, nil)
+
+	repo, err := s.Runner.UpdateRepository(s.Fixtures.AdminContext, s.Fixtures.StoreRepos["test-repo-1"].ID, s.Fixtures.UpdateRepoParams)
+
+	s.Fixtures.PoolMgrCtrlMock.AssertExpectations(s.T())
+	s.Require().Nil(err)
+	s.Require().True(repo.PollingEnabled)
+	s.Require().Equal(pollingInterval, repo.PollingInterval)
+}
+
+func (s *RepoTestSuite) TestUpdateRepositoryPollingIntervalTooLow() {
+	pollingInterval := uint(1)
+	s.Fixtures.UpdateRepoParams.PollingInterval = &pollingInterval
+
+	_, err := s.Runner.UpdateRepository(s.Fixtures.AdminContext, s.Fixtures.StoreRepos["test-repo-1"].ID, s.Fixtures.UpdateRepoParams)
+
+	s.Require().Equal("validating params: polling interval must be at least 10 seconds", err.Error())
+}
+
+func (s *RepoTestSuite) TestUpdateRepositoryPoolSelectionStrategy() {
+	s.Fixtures.UpdateRepoParams.PoolSelectionStrategy = params.PoolSelectionLeastLoaded
+	s.Fixtures.PoolMgrCtrlMock.On("UpdateRepoPoolManager", s.Fixtures.AdminContext, mock.AnythingOfType("params.Repository")).Return(s.Fixtures.PoolMgrMock, nil)
+	s.Fixtures.PoolMgrMock.On("Status").Return(params.PoolManagerStatus{IsRunning: true}, nil)
+
+	repo, err := s.Runner.UpdateRepository(s.Fixtures.AdminContext, s.Fixtures.StoreRepos["test-repo-1"].ID, s.Fixtures.UpdateRepoParams)
+
+	s.Fixtures.PoolMgrCtrlMock.AssertExpectations(s.T())
+	s.Require().Nil(err)
+	s.Require().Equal(params.PoolSelectionLeastLoaded, repo.PoolSelectionStrategy)
+}
+
+func (s *RepoTestSuite) TestUpdateRepositoryInvalidPoolSelectionStrategy() {
+	s.Fixtures.UpdateRepoParams.PoolSelectionStrategy = "cheapest"
+
+	_, err := s.Runner.UpdateRepository(s.Fixtures.AdminContext, s.Fixtures.StoreRepos["test-repo-1"].ID, s.Fixtures.UpdateRepoParams)
+
+	s.Require().Equal("validating params: invalid pool selection strategy: cheapest", err.Error())
+}
+
+func (s *RepoTestSuite) TestUpdateRepositoryJobSchedulingStrategy() {
+	s.Fixtures.UpdateRepoParams.JobSchedulingStrategy = params.JobSchedulingFairShare
+
+	_, err := s.Runner.UpdateRepository(s.Fixtures.AdminContext, s.Fixtures.StoreRepos["test-repo-1"].ID, s.Fixtures.UpdateRepoParams)
+
+	s.Require().Equal(runnerErrors.NewBadRequestError("job scheduling strategy can only be set on organizations and enterprises"), err)
+}
+
+func (s *RepoTestSuite) TestUpdateRepositoryInvalidJobSchedulingStrategy() {
+	s.Fixtures.UpdateRepoParams.JobSchedulingStrategy = "lifo"
+
+	_, err := s.Runner.UpdateRepository(s.Fixtures.AdminContext, s.Fixtures.StoreRepos["test-repo-1"].ID, s.Fixtures.UpdateRepoParams)
+
+	s.Require().Equal("validating params: invalid job scheduling strategy: lifo", err.Error())
+}
+
 func (s *RepoTestSuite) TestUpdateRepositoryPoolMgrFailed() {
 	s.Fixtures.PoolMgrCtrlMock.On("UpdateRepoPoolManager", s.Fixtures.AdminContext, mock.AnythingOfType("params.Repository")).Return(s.Fixtures.PoolMgrMock, s.Fixtures.ErrMock)
 
@@ -385,6 +592,74 @@ func (s *RepoTestSuite) TestCreateRepoPoolFetchPoolParamsFailed() {
 	s.Require().Regexp("fetching pool params: no such provider", err.Error())
 }
 
+func (s *RepoTestSuite) TestCreateRepoPoolFallbackProviders() {
+	s.Fixtures.CreatePoolParams.FallbackProviders = []params.PoolProvider{
+		{
+			ProviderName: "not-existent-provider-name",
+		},
+	}
+
+	s.Fixtures.PoolMgrCtrlMock.On("GetRepoPoolManager", mock.AnythingOfType("params.Repository")).Return(s.Fixtures.PoolMgrMock, nil)
+
+	_, err := s.Runner.CreateRepoPool(s.Fixtures.AdminContext, s.Fixtures.StoreRepos["test-repo-1"].ID, s.Fixtures.CreatePoolParams)
+
+	s.Fixtures.PoolMgrMock.AssertExpectations(s.T())
+	s.Fixtures.PoolMgrCtrlMock.AssertExpectations(s.T())
+	s.Require().Regexp("fetching pool params: no such provider not-existent-provider-name", err.Error())
+}
+
+func (s *RepoTestSuite) TestCreateRepoPoolDuplicateFallbackProvider() {
+	s.Fixtures.CreatePoolParams.FallbackProviders = []params.PoolProvider{
+		{
+			ProviderName: s.Fixtures.CreatePoolParams.ProviderName,
+		},
+	}
+
+	s.Fixtures.PoolMgrCtrlMock.On("GetRepoPoolManager", mock.AnythingOfType("params.Repository")).Return(s.Fixtures.PoolMgrMock, nil)
+
+	_, err := s.Runner.CreateRepoPool(s.Fixtures.AdminContext, s.Fixtures.StoreRepos["test-repo-1"].ID, s.Fixtures.CreatePoolParams)
+
+	s.Fixtures.PoolMgrMock.AssertExpectations(s.T())
+	s.Fixtures.PoolMgrCtrlMock.AssertExpectations(s.T())
+	s.Require().Regexp("provider test-provider is used more than once", err.Error())
+}
+
+func (s *RepoTestSuite) TestCreateRepoPoolImageValidationFailed() {
+	providerMock := runnerCommonMocks.NewProvider(s.T())
+	providerMock.On("AsParams").Return(params.Provider{
+		Name:         "validating-provider",
+		Capabilities: params.ProviderCapabilities{ImageValidation: true},
+	})
+	providerMock.On("ValidateImage", mock.Anything, s.Fixtures.CreatePoolParams.Image, s.Fixtures.CreatePoolParams.Flavor).Return(fmt.Errorf("mock error"))
+	s.Runner.providers["validating-provider"] = providerMock
+	s.Fixtures.CreatePoolParams.ProviderName = "validating-provider"
+
+	s.Fixtures.PoolMgrCtrlMock.On("GetRepoPoolManager", mock.AnythingOfType("params.Repository")).Return(s.Fixtures.PoolMgrMock, nil)
+
+	_, err := s.Runner.CreateRepoPool(s.Fixtures.AdminContext, s.Fixtures.StoreRepos["test-repo-1"].ID, s.Fixtures.CreatePoolParams)
+
+	s.Fixtures.PoolMgrMock.AssertExpectations(s.T())
+	s.Fixtures.PoolMgrCtrlMock.AssertExpectations(s.T())
+	providerMock.AssertExpectations(s.T())
+	s.Require().Regexp("provider validating-provider cannot create instances using image .* mock error", err.Error())
+}
+
+func (s *RepoTestSuite) TestCreateRepoPoolWarmRunnersNotSupported() {
+	providerMock := runnerCommonMocks.NewProvider(s.T())
+	providerMock.On("AsParams").Return(params.Provider{Name: "no-start-stop-provider"})
+	s.Runner.providers["no-start-stop-provider"] = providerMock
+	s.Fixtures.CreatePoolParams.ProviderName = "no-start-stop-provider"
+	s.Fixtures.CreatePoolParams.WarmRunners = 1
+
+	s.Fixtures.PoolMgrCtrlMock.On("GetRepoPoolManager", mock.AnythingOfType("params.Repository")).Return(s.Fixtures.PoolMgrMock, nil)
+
+	_, err := s.Runner.CreateRepoPool(s.Fixtures.AdminContext, s.Fixtures.StoreRepos["test-repo-1"].ID, s.Fixtures.CreatePoolParams)
+
+	s.Fixtures.PoolMgrMock.AssertExpectations(s.T())
+	s.Fixtures.PoolMgrCtrlMock.AssertExpectations(s.T())
+	s.Require().Regexp("provider no-start-stop-provider cannot stop and start instances", err.Error())
+}
+
 func (s *RepoTestSuite) TestGetRepoPoolByID() {
 	repoPool, err := s.Fixtures.Store.CreateRepositoryPool(s.Fixtures.AdminContext, s.Fixtures.StoreRepos["test-repo-1"].ID, s.Fixtures.CreatePoolParams)
 	if err != nil {
@@ -552,7 +827,7 @@ func (s *RepoTestSuite) TestListRepoInstancesErrUnauthorized() {
 func (s *RepoTestSuite) TestFindRepoPoolManager() {
 	s.Fixtures.PoolMgrCtrlMock.On("GetRepoPoolManager", mock.AnythingOfType("params.Repository")).Return(s.Fixtures.PoolMgrMock, nil)
 
-	poolManager, err := s.Runner.findRepoPoolManager(s.Fixtures.StoreRepos["test-repo-1"].Owner, s.Fixtures.StoreRepos["test-repo-1"].Name)
+	poolManager, err := s.Runner.findRepoPoolManager(s.Fixtures.StoreRepos["test-repo-1"].Endpoint, s.Fixtures.StoreRepos["test-repo-1"].Owner, s.Fixtures.StoreRepos["test-repo-1"].Name)
 
 	s.Fixtures.PoolMgrMock.AssertExpectations(s.T())
 	s.Fixtures.PoolMgrCtrlMock.AssertExpectations(s.T())
@@ -563,13 +838,151 @@ func (s *RepoTestSuite) TestFindRepoPoolManager() {
 func (s *RepoTestSuite) TestFindRepoPoolManagerFetchPoolMgrFailed() {
 	s.Fixtures.PoolMgrCtrlMock.On("GetRepoPoolManager", mock.AnythingOfType("params.Repository")).Return(s.Fixtures.PoolMgrMock, s.Fixtures.ErrMock)
 
-	_, err := s.Runner.findRepoPoolManager(s.Fixtures.StoreRepos["test-repo-1"].Owner, s.Fixtures.StoreRepos["test-repo-1"].Name)
+	_, err := s.Runner.findRepoPoolManager(s.Fixtures.StoreRepos["test-repo-1"].Endpoint, s.Fixtures.StoreRepos["test-repo-1"].Owner, s.Fixtures.StoreRepos["test-repo-1"].Name)
 
 	s.Fixtures.PoolMgrMock.AssertExpectations(s.T())
 	s.Fixtures.PoolMgrCtrlMock.AssertExpectations(s.T())
 	s.Require().Regexp("fetching pool manager for repo", err.Error())
 }
 
+func (s *RepoTestSuite) TestInstallRepoWebhook() {
+	s.Runner.config.Default.WebhookURL = "https://garm.example.com/webhooks"
+	hookInfo := params.HookInfo{
+		ID:     1,
+		URL:    s.Runner.config.Default.WebhookURL,
+		Events: []string{"workflow_job"},
+		Active: true,
+	}
+	installParams := params.InstallWebhookParams{InsecureSSL: true}
+	s.Fixtures.PoolMgrMock.On("InstallWebhook", installParams).Return(hookInfo, nil)
+	s.Fixtures.PoolMgrCtrlMock.On("GetRepoPoolManager", mock.AnythingOfType("params.Repository")).Return(s.Fixtures.PoolMgrMock, nil)
+
+	info, err := s.Runner.InstallRepoWebhook(s.Fixtures.AdminContext, s.Fixtures.StoreRepos["test-repo-1"].ID, installParams)
+
+	s.Fixtures.PoolMgrMock.AssertExpectations(s.T())
+	s.Fixtures.PoolMgrCtrlMock.AssertExpectations(s.T())
+	s.Require().Nil(err)
+	s.Require().Equal(hookInfo, info)
+}
+
+func (s *RepoTestSuite) TestInstallRepoWebhookErrUnauthorized() {
+	_, err := s.Runner.InstallRepoWebhook(context.Background(), "dummy-repo-id", params.InstallWebhookParams{})
+
+	s.Require().Equal(runnerErrors.ErrUnauthorized, err)
+}
+
+func (s *RepoTestSuite) TestInstallRepoWebhookMissingWebhookURL() {
+	_, err := s.Runner.InstallRepoWebhook(s.Fixtures.AdminContext, s.Fixtures.StoreRepos["test-repo-1"].ID, params.InstallWebhookParams{})
+
+	s.Require().Equal(runnerErrors.NewBadRequestError("webhook_url must be configured in order to manage webhooks"), err)
+}
+
+func (s *RepoTestSuite) TestGetRepoWebhookInfo() {
+	s.Runner.config.Default.WebhookURL = "https://garm.example.com/webhooks"
+	hookInfo := params.HookInfo{
+		ID:  1,
+		URL: s.Runner.config.Default.WebhookURL,
+	}
+	s.Fixtures.PoolMgrMock.On("GetWebhookInfo").Return(hookInfo, nil)
+	s.Fixtures.PoolMgrCtrlMock.On("GetRepoPoolManager", mock.AnythingOfType("params.Repository")).Return(s.Fixtures.PoolMgrMock, nil)
+
+	info, err := s.Runner.GetRepoWebhookInfo(s.Fixtures.AdminContext, s.Fixtures.StoreRepos["test-repo-1"].ID)
+
+	s.Fixtures.PoolMgrMock.AssertExpectations(s.T())
+	s.Fixtures.PoolMgrCtrlMock.AssertExpectations(s.T())
+	s.Require().Nil(err)
+	s.Require().Equal(hookInfo, info)
+}
+
+func (s *RepoTestSuite) TestUninstallRepoWebhook() {
+	s.Runner.config.Default.WebhookURL = "https://garm.example.com/webhooks"
+	s.Fixtures.PoolMgrMock.On("UninstallWebhook").Return(nil)
+	s.Fixtures.PoolMgrCtrlMock.On("GetRepoPoolManager", mock.AnythingOfType("params.Repository")).Return(s.Fixtures.PoolMgrMock, nil)
+
+	err := s.Runner.UninstallRepoWebhook(s.Fixtures.AdminContext, s.Fixtures.StoreRepos["test-repo-1"].ID)
+
+	s.Fixtures.PoolMgrMock.AssertExpectations(s.T())
+	s.Fixtures.PoolMgrCtrlMock.AssertExpectations(s.T())
+	s.Require().Nil(err)
+}
+
+func (s *RepoTestSuite) TestUninstallRepoWebhookPoolMgrFailed() {
+	s.Runner.config.Default.WebhookURL = "https://garm.example.com/webhooks"
+	s.Fixtures.PoolMgrCtrlMock.On("GetRepoPoolManager", mock.AnythingOfType("params.Repository")).Return(s.Fixtures.PoolMgrMock, s.Fixtures.ErrMock)
+
+	err := s.Runner.UninstallRepoWebhook(s.Fixtures.AdminContext, s.Fixtures.StoreRepos["test-repo-1"].ID)
+
+	s.Fixtures.PoolMgrCtrlMock.AssertExpectations(s.T())
+	s.Require().Equal(fmt.Sprintf("fetching repo pool manager: %s", s.Fixtures.ErrMock.Error()), err.Error())
+}
+
+func (s *RepoTestSuite) createRepoInstance() params.Instance {
+	pool, err := s.Fixtures.Store.CreateRepositoryPool(s.Fixtures.AdminContext, s.Fixtures.StoreRepos["test-repo-1"].ID, s.Fixtures.CreatePoolParams)
+	if err != nil {
+		s.FailNow(fmt.Sprintf("cannot create repo pool: %s", err))
+	}
+	instanceParams := s.Fixtures.CreateInstanceParams
+	instanceParams.RunnerStatus = providerCommon.RunnerPending
+	instance, err := s.Fixtures.Store.CreateInstance(s.Fixtures.AdminContext, pool.ID, instanceParams)
+	if err != nil {
+		s.FailNow(fmt.Sprintf("cannot create instance: %s", err))
+	}
+	return instance
+}
+
+func (s *RepoTestSuite) TestGetInstanceGithubJITConfig() {
+	instance := s.createRepoInstance()
+	s.Fixtures.PoolMgrCtrlMock.On("GetRepoPoolManager", mock.AnythingOfType("params.Repository")).Return(s.Fixtures.PoolMgrMock, nil)
+	s.Fixtures.PoolMgrMock.On("GithubRunnerJITConfig", mock.AnythingOfType("params.Instance")).Return("test-jit-config", nil)
+
+	jitConfig, err := s.Runner.GetInstanceGithubJITConfig(auth.PopulateInstanceContext(s.Fixtures.AdminContext, instance))
+
+	s.Fixtures.PoolMgrMock.AssertExpectations(s.T())
+	s.Fixtures.PoolMgrCtrlMock.AssertExpectations(s.T())
+	s.Require().Nil(err)
+	s.Require().Equal("test-jit-config", jitConfig)
+
+	instance, err = s.Fixtures.Store.GetInstanceByName(s.Fixtures.AdminContext, instance.Name)
+	s.Require().Nil(err)
+	s.Require().True(instance.TokenFetched)
+}
+
+func (s *RepoTestSuite) TestGetInstanceGithubJITConfigTokenAlreadyFetched() {
+	instance := s.createRepoInstance()
+	instance.TokenFetched = true
+
+	_, err := s.Runner.GetInstanceGithubJITConfig(auth.PopulateInstanceContext(s.Fixtures.AdminContext, instance))
+
+	s.Require().Equal(runnerErrors.ErrUnauthorized, err)
+}
+
+func (s *RepoTestSuite) TestGetInstanceGithubJITConfigFallbackToRegistrationToken() {
+	instance := s.createRepoInstance()
+	instanceCtx := auth.PopulateInstanceContext(s.Fixtures.AdminContext, instance)
+	s.Fixtures.PoolMgrCtrlMock.On("GetRepoPoolManager", mock.AnythingOfType("params.Repository")).Return(s.Fixtures.PoolMgrMock, nil)
+	s.Fixtures.PoolMgrMock.On("GithubRunnerJITConfig", mock.AnythingOfType("params.Instance")).Return("", s.Fixtures.ErrMock)
+	s.Fixtures.PoolMgrMock.On("GithubRunnerRegistrationToken").Return("test-registration-token", nil)
+
+	_, err := s.Runner.GetInstanceGithubJITConfig(instanceCtx)
+	s.Require().Equal("fetching runner JIT config: mock error", err.Error())
+
+	// A failed JIT config request does not use up the token of the instance.
+	dbInstance, err := s.Fixtures.Store.GetInstanceByName(s.Fixtures.AdminContext, instance.Name)
+	s.Require().Nil(err)
+	s.Require().False(dbInstance.TokenFetched)
+
+	token, err := s.Runner.GetInstanceGithubRegistrationToken(instanceCtx)
+
+	s.Fixtures.PoolMgrMock.AssertExpectations(s.T())
+	s.Fixtures.PoolMgrCtrlMock.AssertExpectations(s.T())
+	s.Require().Nil(err)
+	s.Require().Equal("test-registration-token", token)
+
+	dbInstance, err = s.Fixtures.Store.GetInstanceByName(s.Fixtures.AdminContext, instance.Name)
+	s.Require().Nil(err)
+	s.Require().True(dbInstance.TokenFetched)
+}
+
 func TestRepoTestSuite(t *testing.T) {
 	t.Parallel()
 	suite.Run(t, new(RepoTestSuite))
@@ -39,6 +39,7 @@ import (
 	"github.com/cloudbase/garm/runner/providers"
 	providerCommon "github.com/cloudbase/garm/runner/providers/common"
 	"github.com/cloudbase/garm/util"
+	"github.com/cloudbase/garm/util/appdefaults"
 	"golang.org/x/sync/errgroup"
 
 	"github.com/google/uuid"
@@ -68,6 +69,7 @@ func NewRunner(ctx context.Context, cfg config.Config, db dbCommon.Store) (*Runn
 		controllerID:  ctrlId.ControllerID.String(),
 		config:        cfg,
 		credentials:   creds,
+		store:         db,
 		repositories:  map[string]common.PoolManager{},
 		organizations: map[string]common.PoolManager{},
 		enterprises:   map[string]common.PoolManager{},
@@ -80,6 +82,11 @@ func NewRunner(ctx context.Context, cfg config.Config, db dbCommon.Store) (*Runn
 		providers:       providers,
 		credentials:     creds,
 		controllerID:    ctrlId.ControllerID,
+		deliveries:      make(chan string, webhookDeliveryWorkers),
+	}
+
+	if err := runner.migrateEntityEndpoints(); err != nil {
+		return nil, errors.Wrap(err, "migrating github endpoints")
 	}
 
 	if err := runner.loadReposOrgsAndEnterprises(); err != nil {
@@ -95,6 +102,7 @@ type poolManagerCtrl struct {
 	controllerID string
 	config       config.Config
 	credentials  map[string]config.Github
+	store        dbCommon.Store
 
 	repositories  map[string]common.PoolManager
 	organizations map[string]common.PoolManager
@@ -105,7 +113,7 @@ func (p *poolManagerCtrl) CreateRepoPoolManager(ctx context.Context, repo params
 	p.mux.Lock()
 	defer p.mux.Unlock()
 
-	cfgInternal, err := p.getInternalConfig(repo.CredentialsName)
+	cfgInternal, err := p.getInternalConfig(ctx, repo.CredentialsName)
 	if err != nil {
 		return nil, errors.Wrap(err, "fetching internal config")
 	}
@@ -126,14 +134,17 @@ func (p *poolManagerCtrl) UpdateRepoPoolManager(ctx context.Context, repo params
 		return nil, errors.Wrapf(runnerErrors.ErrNotFound, "repository %s/%s pool manager not loaded", repo.Owner, repo.Name)
 	}
 
-	internalCfg, err := p.getInternalConfig(repo.CredentialsName)
+	internalCfg, err := p.getInternalConfig(ctx, repo.CredentialsName)
 	if err != nil {
 		return nil, errors.Wrap(err, "fetching internal config")
 	}
 
 	newState := params.UpdatePoolStateParams{
-		WebhookSecret:  repo.WebhookSecret,
-		InternalConfig: &internalCfg,
+		WebhookSecret:         repo.WebhookSecret,
+		PollingEnabled:        repo.PollingEnabled,
+		PollingInterval:       repo.PollingInterval,
+		PoolSelectionStrategy: repo.PoolSelectionStrategy,
+		InternalConfig:        &internalCfg,
 	}
 
 	if err := poolMgr.RefreshState(newState); err != nil {
@@ -171,7 +182,7 @@ func (p *poolManagerCtrl) CreateOrgPoolManager(ctx context.Context, org params.O
 	p.mux.Lock()
 	defer p.mux.Unlock()
 
-	cfgInternal, err := p.getInternalConfig(org.CredentialsName)
+	cfgInternal, err := p.getInternalConfig(ctx, org.CredentialsName)
 	if err != nil {
 		return nil, errors.Wrap(err, "fetching internal config")
 	}
@@ -192,14 +203,18 @@ func (p *poolManagerCtrl) UpdateOrgPoolManager(ctx context.Context, org params.O
 		return nil, errors.Wrapf(runnerErrors.ErrNotFound, "org %s pool manager not loaded", org.Name)
 	}
 
-	internalCfg, err := p.getInternalConfig(org.CredentialsName)
+	internalCfg, err := p.getInternalConfig(ctx, org.CredentialsName)
 	if err != nil {
 		return nil, errors.Wrap(err, "fetching internal config")
 	}
 
 	newState := params.UpdatePoolStateParams{
-		WebhookSecret:  org.WebhookSecret,
-		InternalConfig: &internalCfg,
+		WebhookSecret:         org.WebhookSecret,
+		PollingEnabled:        org.PollingEnabled,
+		PollingInterval:       org.PollingInterval,
+		PoolSelectionStrategy: org.PoolSelectionStrategy,
+		JobSchedulingStrategy: org.JobSchedulingStrategy,
+		InternalConfig:        &internalCfg,
 	}
 
 	if err := poolMgr.RefreshState(newState); err != nil {
@@ -237,7 +252,7 @@ func (p *poolManagerCtrl) CreateEnterprisePoolManager(ctx context.Context, enter
 	p.mux.Lock()
 	defer p.mux.Unlock()
 
-	cfgInternal, err := p.getInternalConfig(enterprise.CredentialsName)
+	cfgInternal, err := p.getInternalConfig(ctx, enterprise.CredentialsName)
 	if err != nil {
 		return nil, errors.Wrap(err, "fetching internal config")
 	}
@@ -258,14 +273,16 @@ func (p *poolManagerCtrl) UpdateEnterprisePoolManager(ctx context.Context, enter
 		return nil, errors.Wrapf(runnerErrors.ErrNotFound, "enterprise %s pool manager not loaded", enterprise.Name)
 	}
 
-	internalCfg, err := p.getInternalConfig(enterprise.CredentialsName)
+	internalCfg, err := p.getInternalConfig(ctx, enterprise.CredentialsName)
 	if err != nil {
 		return nil, errors.Wrap(err, "fetching internal config")
 	}
 
 	newState := params.UpdatePoolStateParams{
-		WebhookSecret:  enterprise.WebhookSecret,
-		InternalConfig: &internalCfg,
+		WebhookSecret:         enterprise.WebhookSecret,
+		PoolSelectionStrategy: enterprise.PoolSelectionStrategy,
+		JobSchedulingStrategy: enterprise.JobSchedulingStrategy,
+		InternalConfig:        &internalCfg,
 	}
 
 	if err := poolMgr.RefreshState(newState); err != nil {
@@ -299,31 +316,23 @@ func (p *poolManagerCtrl) GetEnterprisePoolManagers() (map[string]common.PoolMan
 	return p.enterprises, nil
 }
 
-func (p *poolManagerCtrl) getInternalConfig(credsName string) (params.Internal, error) {
-	creds, ok := p.credentials[credsName]
-	if !ok {
-		return params.Internal{}, runnerErrors.NewBadRequestError("invalid credential name (%s)", credsName)
-	}
-
-	caBundle, err := creds.CACertBundle()
+func (p *poolManagerCtrl) getInternalConfig(ctx context.Context, credsName string) (params.Internal, error) {
+	creds, err := getGithubCredentials(ctx, credsName, p.credentials, p.store)
 	if err != nil {
-		return params.Internal{}, fmt.Errorf("fetching CA bundle for creds: %w", err)
+		if errors.Is(err, runnerErrors.ErrNotFound) {
+			return params.Internal{}, runnerErrors.NewBadRequestError("invalid credential name (%s)", credsName)
+		}
+		return params.Internal{}, errors.Wrap(err, "fetching github credentials")
 	}
 
 	return params.Internal{
-		OAuth2Token:         creds.OAuth2Token,
-		ControllerID:        p.controllerID,
-		InstanceCallbackURL: p.config.Default.CallbackURL,
-		InstanceMetadataURL: p.config.Default.MetadataURL,
-		JWTSecret:           p.config.JWTAuth.Secret,
-		GithubCredentialsDetails: params.GithubCredentials{
-			Name:          creds.Name,
-			Description:   creds.Description,
-			BaseURL:       creds.BaseEndpoint(),
-			APIBaseURL:    creds.APIEndpoint(),
-			UploadBaseURL: creds.UploadEndpoint(),
-			CABundle:      caBundle,
-		},
+		OAuth2Token:              creds.OAuth2Token,
+		ControllerID:             p.controllerID,
+		InstanceCallbackURL:      p.config.Default.CallbackURL,
+		InstanceMetadataURL:      p.config.Default.MetadataURL,
+		JWTSecret:                p.config.JWTAuth.Secret,
+		WebhookURL:               p.config.Default.WebhookURL,
+		GithubCredentialsDetails: creds,
 	}, nil
 }
 
@@ -341,6 +350,13 @@ type Runner struct {
 
 	controllerInfo params.ControllerInfo
 	controllerID   uuid.UUID
+
+	// deliveries is used to hand webhook deliveries over to the workers that process them.
+	deliveries chan string
+	// deliveriesInFlight holds the IDs of the webhook deliveries that were handed
+	// over to the workers, and were not yet processed.
+	deliveriesInFlight map[string]struct{}
+	deliveriesMux      sync.Mutex
 }
 
 // GetControllerInfo returns the controller id and the hostname.
@@ -381,34 +397,121 @@ func (r *Runner) GetControllerInfo(ctx context.Context) (params.ControllerInfo,
 	}, nil
 }
 
-func (r *Runner) ListCredentials(ctx context.Context) ([]params.GithubCredentials, error) {
+func (r *Runner) ListProviders(ctx context.Context) ([]params.Provider, error) {
 	if !auth.IsAdmin(ctx) {
 		return nil, runnerErrors.ErrUnauthorized
 	}
-	ret := []params.GithubCredentials{}
+	drains, err := r.store.ListProviderDrains(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "fetching provider drains")
+	}
 
-	for _, val := range r.config.Github {
-		ret = append(ret, params.GithubCredentials{
-			Name:          val.Name,
-			Description:   val.Description,
-			BaseURL:       val.BaseEndpoint(),
-			APIBaseURL:    val.APIEndpoint(),
-			UploadBaseURL: val.UploadEndpoint(),
-		})
+	ret := []params.Provider{}
+	for _, val := range r.providers {
+		provider, err := r.withProviderUsage(ctx, withProviderDrain(val.AsParams(), drains))
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, provider)
 	}
 	return ret, nil
 }
 
-func (r *Runner) ListProviders(ctx context.Context) ([]params.Provider, error) {
-	if !auth.IsAdmin(ctx) {
-		return nil, runnerErrors.ErrUnauthorized
+// withProviderUsage sets the resources used by the runners of a provider, across all pools.
+func (r *Runner) withProviderUsage(ctx context.Context, provider params.Provider) (params.Provider, error) {
+	counts, err := r.store.ProviderInstanceCountByFlavor(ctx, provider.Name)
+	if err != nil {
+		return params.Provider{}, errors.Wrap(err, "fetching provider usage")
 	}
-	ret := []params.Provider{}
+	provider.Usage = provider.Limits.Usage(counts)
+	return provider, nil
+}
 
-	for _, val := range r.providers {
-		ret = append(ret, val.AsParams())
+// refreshManagedWebhook updates the webhook garm installed on the entity managed by
+// the given pool manager, if there is one. This is needed whenever the webhook secret
+// of the entity changes.
+func (r *Runner) refreshManagedWebhook(poolMgr common.PoolManager) {
+	if r.config.Default.WebhookURL == "" {
+		return
 	}
-	return ret, nil
+
+	info, err := poolMgr.GetWebhookInfo()
+	if err != nil {
+		if !errors.Is(err, runnerErrors.ErrNotFound) {
+			log.Printf("failed to fetch webhook info: %s", err)
+		}
+		return
+	}
+
+	if _, err := poolMgr.InstallWebhook(params.InstallWebhookParams{InsecureSSL: info.InsecureSSL}); err != nil {
+		log.Printf("failed to update webhook %d: %s", info.ID, err)
+	}
+}
+
+// setEntityEndpoint records the github endpoint of an entity created before endpoints
+// were part of the identity of entities. The endpoint is taken from its credentials.
+func (r *Runner) setEntityEndpoint(entityType params.PoolType, entityID, credentialsName string) error {
+	creds, err := r.getGithubCredentials(r.ctx, credentialsName)
+	if err != nil {
+		if errors.Is(err, runnerErrors.ErrNotFound) {
+			// The pool manager of this entity will fail to start anyway. The endpoint
+			// is set once the entity is updated to use valid credentials.
+			log.Printf("credentials %s of %s %s not found; not setting github endpoint", credentialsName, entityType, entityID)
+			return nil
+		}
+		return errors.Wrap(err, "fetching credentials")
+	}
+
+	endpoint := util.GithubEndpoint(creds.BaseURL)
+	log.Printf("setting github endpoint of %s %s to %s", entityType, entityID, endpoint)
+	if err := r.store.UpdateEntityEndpoint(r.ctx, entityType, entityID, endpoint); err != nil {
+		return errors.Wrap(err, "updating endpoint")
+	}
+	return nil
+}
+
+// migrateEntityEndpoints sets the github endpoint of repositories, organizations and
+// enterprises that do not have one.
+func (r *Runner) migrateEntityEndpoints() error {
+	repos, err := r.store.ListRepositories(r.ctx)
+	if err != nil {
+		return errors.Wrap(err, "fetching repositories")
+	}
+	for _, repo := range repos {
+		if repo.Endpoint != "" {
+			continue
+		}
+		if err := r.setEntityEndpoint(params.RepositoryPool, repo.ID, repo.CredentialsName); err != nil {
+			return errors.Wrapf(err, "migrating repo %s/%s", repo.Owner, repo.Name)
+		}
+	}
+
+	orgs, err := r.store.ListOrganizations(r.ctx)
+	if err != nil {
+		return errors.Wrap(err, "fetching organizations")
+	}
+	for _, org := range orgs {
+		if org.Endpoint != "" {
+			continue
+		}
+		if err := r.setEntityEndpoint(params.OrganizationPool, org.ID, org.CredentialsName); err != nil {
+			return errors.Wrapf(err, "migrating org %s", org.Name)
+		}
+	}
+
+	enterprises, err := r.store.ListEnterprises(r.ctx)
+	if err != nil {
+		return errors.Wrap(err, "fetching enterprises")
+	}
+	for _, enterprise := range enterprises {
+		if enterprise.Endpoint != "" {
+			continue
+		}
+		if err := r.setEntityEndpoint(params.EnterprisePool, enterprise.ID, enterprise.CredentialsName); err != nil {
+			return errors.Wrapf(err, "migrating enterprise %s", enterprise.Name)
+		}
+	}
+	return nil
 }
 
 func (r *Runner) loadReposOrgsAndEnterprises() error {
@@ -508,6 +611,12 @@ func (r *Runner) Start() error {
 	if err := r.waitForErrorGroupOrTimeout(g); err != nil {
 		return fmt.Errorf("failed to start pool managers: %w", err)
 	}
+
+	for i := 0; i < webhookDeliveryWorkers; i++ {
+		go r.webhookDeliveryWorker()
+	}
+	go r.webhookDeliveryLoop()
+	go r.providerHealthLoop()
 	return nil
 }
 
@@ -687,53 +796,179 @@ func (r *Runner) validateHookBody(signature, secret string, body []byte) error {
 	return nil
 }
 
-func (r *Runner) DispatchWorkflowJob(hookTargetType, signature string, jobData []byte) error {
+// validateWebhook finds the pool manager that should handle a webhook and validates the
+// webhook signature using the secret of that pool manager. If entityID is set, the webhook
+// was sent to the webhook URL of that entity. Otherwise, the entity is looked up using the
+// payload.
+func (r *Runner) validateWebhook(entityID, hookTargetType, signature string, body []byte) (common.PoolManager, params.WebhookEntity, error) {
+	poolManager, entity, err := r.findWebhookPoolManager(entityID, hookTargetType, body)
+	if err != nil {
+		return nil, params.WebhookEntity{}, err
+	}
+
+	// We found a pool. Validate the webhook. If a secret is configured,
+	// we make sure that the source of this webhook is valid.
+	secret := poolManager.WebhookSecret()
+	if err := r.validateHookBody(signature, secret, body); err != nil {
+		return nil, params.WebhookEntity{}, errors.Wrap(err, "validating webhook data")
+	}
+	return poolManager, entity, nil
+}
+
+// recordWebhookSeen records that a valid webhook was received for the entity managed
+// by the given pool manager. Failing to do so does not prevent the webhook from being
+// handled.
+func (r *Runner) recordWebhookSeen(entity params.WebhookEntity, verified bool) {
+	if err := r.store.RecordWebhookSeen(r.ctx, entity.Type, entity.ID, verified); err != nil {
+		log.Printf("failed to record webhook for %s %s: %s", entity.Type, entity.Name, err)
+	}
+}
+
+func decodeWorkflowJob(jobData []byte) (params.WorkflowJob, error) {
 	if len(jobData) == 0 {
-		return runnerErrors.NewBadRequestError("missing job data")
+		return params.WorkflowJob{}, runnerErrors.NewBadRequestError("missing job data")
 	}
 
 	var job params.WorkflowJob
 	if err := json.Unmarshal(jobData, &job); err != nil {
-		return errors.Wrapf(runnerErrors.ErrBadRequest, "invalid job data: %s", err)
+		return params.WorkflowJob{}, errors.Wrapf(runnerErrors.ErrBadRequest, "invalid job data: %s", err)
+	}
+	return job, nil
+}
+
+// findWebhookPoolManager finds the pool manager that should handle a webhook. If entityID
+// is set, the pool manager of that entity is returned. Otherwise, the entity is looked
+// up using the payload.
+func (r *Runner) findWebhookPoolManager(entityID, hookTargetType string, body []byte) (common.PoolManager, params.WebhookEntity, error) {
+	if entityID != "" {
+		return r.poolManagerForEntity(entityID)
+	}
+	return r.poolManagerForWebhook(hookTargetType, body)
+}
+
+// poolManagerForEntity finds the pool manager of the repository, organization or
+// enterprise with the given ID.
+func (r *Runner) poolManagerForEntity(entityID string) (common.PoolManager, params.WebhookEntity, error) {
+	if _, err := uuid.Parse(entityID); err != nil {
+		return nil, params.WebhookEntity{}, runnerErrors.NewBadRequestError("invalid entity ID %s", entityID)
+	}
+
+	// Webhooks for all entities go through here. The entity is looked up without holding
+	// the lock, which is only needed to read the pool managers.
+	repo, err := r.store.GetRepositoryByID(r.ctx, entityID)
+	if err == nil {
+		r.mux.Lock()
+		poolManager, err := r.poolManagerCtrl.GetRepoPoolManager(repo)
+		r.mux.Unlock()
+		if err != nil {
+			return nil, params.WebhookEntity{}, errors.Wrap(err, "fetching pool manager for repo")
+		}
+		return poolManager, params.WebhookEntity{
+			ID:   repo.ID,
+			Type: params.RepositoryPool,
+			Name: fmt.Sprintf("%s/%s", repo.Owner, repo.Name),
+		}, nil
+	} else if !errors.Is(err, runnerErrors.ErrNotFound) {
+		return nil, params.WebhookEntity{}, errors.Wrap(err, "fetching repo")
+	}
+
+	org, err := r.store.GetOrganizationByID(r.ctx, entityID)
+	if err == nil {
+		r.mux.Lock()
+		poolManager, err := r.poolManagerCtrl.GetOrgPoolManager(org)
+		r.mux.Unlock()
+		if err != nil {
+			return nil, params.WebhookEntity{}, errors.Wrap(err, "fetching pool manager for org")
+		}
+		return poolManager, params.WebhookEntity{
+			ID:   org.ID,
+			Type: params.OrganizationPool,
+			Name: org.Name,
+		}, nil
+	} else if !errors.Is(err, runnerErrors.ErrNotFound) {
+		return nil, params.WebhookEntity{}, errors.Wrap(err, "fetching org")
+	}
+
+	enterprise, err := r.store.GetEnterpriseByID(r.ctx, entityID)
+	if err != nil {
+		return nil, params.WebhookEntity{}, errors.Wrap(err, "fetching enterprise")
+	}
+	r.mux.Lock()
+	poolManager, err := r.poolManagerCtrl.GetEnterprisePoolManager(enterprise)
+	r.mux.Unlock()
+	if err != nil {
+		return nil, params.WebhookEntity{}, errors.Wrap(err, "fetching pool manager for enterprise")
+	}
+	return poolManager, params.WebhookEntity{
+		ID:   enterprise.ID,
+		Type: params.EnterprisePool,
+		Name: enterprise.Name,
+	}, nil
+}
+
+// poolManagerForWebhook finds the pool manager of the repository, organization or
+// enterprise a webhook was sent for, using the payload of the webhook.
+func (r *Runner) poolManagerForWebhook(hookTargetType string, body []byte) (common.PoolManager, params.WebhookEntity, error) {
+	if len(body) == 0 {
+		return nil, params.WebhookEntity{}, runnerErrors.NewBadRequestError("missing webhook data")
+	}
+
+	var target params.WebhookTarget
+	if err := json.Unmarshal(body, &target); err != nil {
+		return nil, params.WebhookEntity{}, errors.Wrapf(runnerErrors.ErrBadRequest, "invalid webhook data: %s", err)
 	}
 
 	var poolManager common.PoolManager
+	var entity params.WebhookEntity
 	var err error
 
+	endpoint := webhookEndpoint(target)
 	switch HookTargetType(hookTargetType) {
 	case RepoHook:
-		log.Printf("got hook for repo %s/%s", util.SanitizeLogEntry(job.Repository.Owner.Login), util.SanitizeLogEntry(job.Repository.Name))
-		poolManager, err = r.findRepoPoolManager(job.Repository.Owner.Login, job.Repository.Name)
+		log.Printf("got hook for repo %s/%s on %s", util.SanitizeLogEntry(target.Repository.Owner.Login), util.SanitizeLogEntry(target.Repository.Name), util.SanitizeLogEntry(endpoint))
+		poolManager, err = r.findRepoPoolManager(endpoint, target.Repository.Owner.Login, target.Repository.Name)
+		entity = params.WebhookEntity{
+			Type: params.RepositoryPool,
+			Name: fmt.Sprintf("%s/%s", target.Repository.Owner.Login, target.Repository.Name),
+		}
 	case OrganizationHook:
-		log.Printf("got hook for org %s", util.SanitizeLogEntry(job.Organization.Login))
-		poolManager, err = r.findOrgPoolManager(job.Organization.Login)
+		log.Printf("got hook for org %s on %s", util.SanitizeLogEntry(target.Organization.Login), util.SanitizeLogEntry(endpoint))
+		poolManager, err = r.findOrgPoolManager(endpoint, target.Organization.Login)
+		entity = params.WebhookEntity{
+			Type: params.OrganizationPool,
+			Name: target.Organization.Login,
+		}
 	case EnterpriseHook:
-		poolManager, err = r.findEnterprisePoolManager(job.Enterprise.Slug)
+		poolManager, err = r.findEnterprisePoolManager(endpoint, target.Enterprise.Slug)
+		entity = params.WebhookEntity{
+			Type: params.EnterprisePool,
+			Name: target.Enterprise.Slug,
+		}
 	default:
-		return runnerErrors.NewBadRequestError("cannot handle hook target type %s", hookTargetType)
+		return nil, params.WebhookEntity{}, runnerErrors.NewBadRequestError("cannot handle hook target type %s", hookTargetType)
 	}
 
 	if err != nil {
-		// We don't have a repository or organization configured that
-		// can handle this workflow job.
-		return errors.Wrap(err, "fetching poolManager")
-	}
-
-	// We found a pool. Validate the webhook job. If a secret is configured,
-	// we make sure that the source of this workflow job is valid.
-	secret := poolManager.WebhookSecret()
-	if err := r.validateHookBody(signature, secret, jobData); err != nil {
-		return errors.Wrap(err, "validating webhook data")
+		// We don't have a repository, organization or enterprise configured that
+		// can handle this webhook.
+		return nil, params.WebhookEntity{}, errors.Wrap(err, "fetching poolManager")
 	}
+	entity.ID = poolManager.ID()
+	return poolManager, entity, nil
+}
 
-	if err := poolManager.HandleWorkflowJob(job); err != nil {
-		return errors.Wrap(err, "handling workflow job")
+// webhookEndpoint returns the github endpoint that sent a webhook. The endpoint is taken
+// from the HTML URLs in the payload, which point to the github server that sent it.
+func webhookEndpoint(target params.WebhookTarget) string {
+	for _, htmlURL := range []string{target.Repository.HTMLURL, target.Enterprise.HTMLURL, target.Sender.HTMLURL} {
+		if htmlURL != "" {
+			return util.GithubEndpoint(htmlURL)
+		}
 	}
-
-	return nil
+	return util.GithubEndpoint(appdefaults.DefaultGithubURL)
 }
 
-func (r *Runner) appendTagsToCreatePoolParams(param params.CreatePoolParams) (params.CreatePoolParams, error) {
+func (r *Runner) appendTagsToCreatePoolParams(ctx context.Context, param params.CreatePoolParams) (params.CreatePoolParams, error) {
 	if err := param.Validate(); err != nil {
 		return params.CreatePoolParams{}, errors.Wrapf(runnerErrors.ErrBadRequest, "validating params: %s", err)
 	}
@@ -751,6 +986,22 @@ func (r *Runner) appendTagsToCreatePoolParams(param params.CreatePoolParams) (pa
 		return params.CreatePoolParams{}, runnerErrors.NewBadRequestError("no such provider %s", param.ProviderName)
 	}
 
+	if err := r.validateFallbackProviders(param.ProviderName, param.FallbackProviders); err != nil {
+		return params.CreatePoolParams{}, err
+	}
+
+	pool := params.Pool{
+		ProviderName:      param.ProviderName,
+		Image:             param.Image,
+		Flavor:            param.Flavor,
+		ExtraSpecs:        param.ExtraSpecs,
+		WarmRunners:       param.WarmRunners,
+		FallbackProviders: param.FallbackProviders,
+	}
+	if err := r.validateProviderCapabilities(ctx, pool, true); err != nil {
+		return params.CreatePoolParams{}, err
+	}
+
 	newTags, err := r.processTags(string(param.OSArch), param.OSType, param.Tags)
 	if err != nil {
 		return params.CreatePoolParams{}, errors.Wrap(err, "processing tags")
@@ -761,6 +1012,85 @@ func (r *Runner) appendTagsToCreatePoolParams(param params.CreatePoolParams) (pa
 	return param, nil
 }
 
+// validateFallbackProviders checks that the fallback providers of a pool are valid
+// and configured.
+func (r *Runner) validateFallbackProviders(providerName string, fallbacks []params.PoolProvider) error {
+	if err := params.ValidateFallbackProviders(providerName, fallbacks); err != nil {
+		return runnerErrors.NewBadRequestError("%s", err)
+	}
+
+	for _, fallback := range fallbacks {
+		if _, ok := r.providers[fallback.ProviderName]; !ok {
+			return runnerErrors.NewBadRequestError("no such provider %s", fallback.ProviderName)
+		}
+	}
+	return nil
+}
+
+// validateScalingSchedules validates the scaling schedules of a pool against its warm
+// runners. For updates, it is given the pool as it will be once the update is applied, so
+// the schedules the update leaves in place are checked against the new warm runners too.
+func validateScalingSchedules(pool params.Pool) error {
+	for _, schedule := range pool.ScalingSchedules {
+		if err := schedule.Validate(pool.WarmRunners); err != nil {
+			return runnerErrors.NewBadRequestError("%s", err)
+		}
+	}
+	return nil
+}
+
+// poolWithUpdate returns the pool as it will be once the update is applied, as far as
+// the validation of the update is concerned.
+func poolWithUpdate(pool params.Pool, param params.UpdatePoolParams) params.Pool {
+	if param.Image != "" {
+		pool.Image = param.Image
+	}
+	if param.Flavor != "" {
+		pool.Flavor = param.Flavor
+	}
+	if param.WarmRunners != nil {
+		pool.WarmRunners = *param.WarmRunners
+	}
+	if param.FallbackProviders != nil {
+		pool.FallbackProviders = param.FallbackProviders
+	}
+	if param.ScalingSchedules != nil {
+		pool.ScalingSchedules = param.ScalingSchedules
+	}
+	return pool
+}
+
+// imagesChanged returns true if the update changes the image or flavor used by any of
+// the providers of a pool.
+func imagesChanged(param params.UpdatePoolParams) bool {
+	return param.Image != "" || param.Flavor != "" || param.FallbackProviders != nil
+}
+
+// validateProviderCapabilities checks that the providers of a pool support the features
+// used by the pool. If validateImages is set, providers that are able to validate images
+// are asked whether they can create instances using the image and flavor of the pool.
+func (r *Runner) validateProviderCapabilities(ctx context.Context, pool params.Pool, validateImages bool) error {
+	for idx, candidate := range pool.Providers() {
+		provider, ok := r.providers[candidate.ProviderName]
+		if !ok {
+			continue
+		}
+		capabilities := provider.AsParams().Capabilities
+
+		// Warm runners are only kept on the provider of the pool.
+		if idx == 0 && pool.WarmRunners > 0 && !capabilities.StartStop {
+			return runnerErrors.NewBadRequestError("provider %s cannot stop and start instances, which is needed for warm runners", candidate.ProviderName)
+		}
+
+		if validateImages && capabilities.ImageValidation {
+			if err := provider.ValidateImage(ctx, candidate.Image, candidate.Flavor); err != nil {
+				return runnerErrors.NewBadRequestError("provider %s cannot create instances using image %s and flavor %s: %s", candidate.ProviderName, candidate.Image, candidate.Flavor, err)
+			}
+		}
+	}
+	return nil
+}
+
 func (r *Runner) processTags(osArch string, osType params.OSType, tags []string) ([]string, error) {
 	// github automatically adds the "self-hosted" tag as well as the OS type (linux, windows, etc)
 	// and architecture (arm, x64, etc) to all self hosted runners. When a workflow job comes in, we try
@@ -836,13 +1166,35 @@ func (r *Runner) AddInstanceStatusMessage(ctx context.Context, param params.Inst
 		updateParams.AgentID = *param.AgentID
 	}
 
-	if _, err := r.store.UpdateInstance(r.ctx, instanceID, updateParams); err != nil {
+	instance, err := r.store.UpdateInstance(r.ctx, instanceID, updateParams)
+	if err != nil {
 		return errors.Wrap(err, "updating runner state")
 	}
 
+	if param.Status == providerCommon.RunnerIdle {
+		// The runner came online. Provisioning works again for this pool.
+		r.closePoolCircuitBreaker(instance.PoolID)
+	}
+
 	return nil
 }
 
+// closePoolCircuitBreaker resets the circuit breaker of a pool, if it counted any
+// provisioning failures.
+func (r *Runner) closePoolCircuitBreaker(poolID string) {
+	pool, err := r.store.GetPoolByID(r.ctx, poolID)
+	if err != nil {
+		log.Printf("failed to fetch pool %s: %s", poolID, err)
+		return
+	}
+	if pool.CircuitBreaker.ConsecutiveFailures == 0 && pool.CircuitBreaker.OpenUntil == nil {
+		return
+	}
+	if _, err := r.store.SetPoolCircuitBreaker(r.ctx, poolID, params.CircuitBreaker{}); err != nil {
+		log.Printf("failed to reset circuit breaker of pool %s: %s", poolID, err)
+	}
+}
+
 func (r *Runner) GetInstanceGithubRegistrationToken(ctx context.Context) (string, error) {
 	instanceName := auth.InstanceName(ctx)
 	if instanceName == "" {
@@ -892,6 +1244,57 @@ func (r *Runner) GetInstanceGithubRegistrationToken(ctx context.Context) (string
 	return token, nil
 }
 
+// GetInstanceGithubJITConfig returns a just-in-time runner configuration for the instance
+// making the request. Just like registration tokens, a JIT config can only be fetched once.
+// Unlike registration tokens, a JIT config can only be used to register this one runner.
+func (r *Runner) GetInstanceGithubJITConfig(ctx context.Context) (string, error) {
+	instanceName := auth.InstanceName(ctx)
+	if instanceName == "" {
+		return "", runnerErrors.ErrUnauthorized
+	}
+
+	if auth.InstanceTokenFetched(ctx) {
+		return "", runnerErrors.ErrUnauthorized
+	}
+
+	status := auth.InstanceRunnerStatus(ctx)
+	if status != providerCommon.RunnerPending && status != providerCommon.RunnerInstalling {
+		return "", runnerErrors.ErrUnauthorized
+	}
+
+	instance, err := r.store.GetInstanceByName(ctx, instanceName)
+	if err != nil {
+		return "", errors.Wrap(err, "fetching instance")
+	}
+
+	poolMgr, err := r.getPoolManagerFromInstance(ctx, instance)
+	if err != nil {
+		return "", errors.Wrap(err, "fetching pool manager for instance")
+	}
+
+	// If this fails, we don't mark the token as fetched, so the instance can fall back
+	// to using a registration token.
+	jitConfig, err := poolMgr.GithubRunnerJITConfig(instance)
+	if err != nil {
+		return "", errors.Wrap(err, "fetching runner JIT config")
+	}
+
+	tokenFetched := true
+	updateParams := params.UpdateInstanceParams{
+		TokenFetched: &tokenFetched,
+	}
+
+	if _, err := r.store.UpdateInstance(r.ctx, instance.ID, updateParams); err != nil {
+		return "", errors.Wrap(err, "setting token_fetched for instance")
+	}
+
+	if err := r.store.AddInstanceEvent(ctx, instance.ID, params.FetchTokenEvent, params.EventInfo, "runner JIT config was retrieved"); err != nil {
+		return "", errors.Wrap(err, "recording event")
+	}
+
+	return jitConfig, nil
+}
+
 func (r *Runner) getPoolManagerFromInstance(ctx context.Context, instance params.Instance) (common.PoolManager, error) {
 	pool, err := r.store.GetPoolByID(ctx, instance.PoolID)
 	if err != nil {
@@ -905,7 +1308,7 @@ func (r *Runner) getPoolManagerFromInstance(ctx context.Context, instance params
 		if err != nil {
 			return nil, errors.Wrap(err, "fetching repo")
 		}
-		poolMgr, err = r.findRepoPoolManager(repo.Owner, repo.Name)
+		poolMgr, err = r.findRepoPoolManager(repo.Endpoint, repo.Owner, repo.Name)
 		if err != nil {
 			return nil, errors.Wrapf(err, "fetching pool manager for repo %s", pool.RepoName)
 		}
@@ -914,7 +1317,7 @@ func (r *Runner) getPoolManagerFromInstance(ctx context.Context, instance params
 		if err != nil {
 			return nil, errors.Wrap(err, "fetching org")
 		}
-		poolMgr, err = r.findOrgPoolManager(org.Name)
+		poolMgr, err = r.findOrgPoolManager(org.Endpoint, org.Name)
 		if err != nil {
 			return nil, errors.Wrapf(err, "fetching pool manager for org %s", pool.OrgName)
 		}
@@ -923,7 +1326,7 @@ func (r *Runner) getPoolManagerFromInstance(ctx context.Context, instance params
 		if err != nil {
 			return nil, errors.Wrap(err, "fetching enterprise")
 		}
-		poolMgr, err = r.findEnterprisePoolManager(enterprise.Name)
+		poolMgr, err = r.findEnterprisePoolManager(enterprise.Endpoint, enterprise.Name)
 		if err != nil {
 			return nil, errors.Wrapf(err, "fetching pool manager for enterprise %s", pool.EnterpriseName)
 		}
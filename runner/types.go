@@ -14,7 +14,11 @@
 
 package runner
 
-import "github.com/cloudbase/garm/params"
+import (
+	"time"
+
+	"github.com/cloudbase/garm/params"
+)
 
 type HookTargetType string
 
@@ -24,6 +28,34 @@ const (
 	EnterpriseHook   HookTargetType = "business"
 )
 
+// webhookSecretLength is the length of the webhook secrets garm generates
+// for the webhooks it manages.
+const webhookSecretLength = 32
+
+const (
+	// webhookDeliveryWorkers is the number of workers processing webhook deliveries.
+	webhookDeliveryWorkers = 10
+	// webhookDeliveryMaxAttempts is the number of times we try to process a webhook
+	// delivery before marking it as failed.
+	webhookDeliveryMaxAttempts = 5
+	// webhookDeliveryRetryInterval is the base interval we wait before retrying to
+	// process a webhook delivery. The wait grows with each failed attempt.
+	webhookDeliveryRetryInterval = 10 * time.Second
+	// webhookDeliveryPollInterval is the interval at which we look for pending webhook
+	// deliveries in the database.
+	webhookDeliveryPollInterval = 5 * time.Second
+	// webhookDeliveryRetention is the amount of time processed webhook deliveries are
+	// kept in the database, for inspection.
+	webhookDeliveryRetention = 72 * time.Hour
+)
+
+const (
+	// providerHealthCheckInterval is the interval at which the health of providers is checked.
+	providerHealthCheckInterval = time.Minute
+	// providerHealthCheckTimeout is the time a provider has to answer a health check.
+	providerHealthCheckTimeout = 30 * time.Second
+)
+
 var (
 	supportedOSType map[params.OSType]struct{} = map[params.OSType]struct{}{
 		params.Linux:   {},
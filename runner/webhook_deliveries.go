@@ -0,0 +1,308 @@
+// Copyright 2023 Cloudbase Solutions SRL
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package runner
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/cloudbase/garm/auth"
+	runnerErrors "github.com/cloudbase/garm/errors"
+	"github.com/cloudbase/garm/params"
+	"github.com/cloudbase/garm/util"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+)
+
+// EnqueueWorkflowJob validates a workflow job webhook and records it in the database.
+// The job is processed asynchronously by the webhook delivery workers. Github may
+// deliver the same webhook more than once. Deliveries we have already recorded are
+// ignored. The entityID is set if the webhook was sent to the webhook URL of an entity.
+func (r *Runner) EnqueueWorkflowJob(entityID, deliveryID, hookTargetType, signature string, jobData []byte) error {
+	// Validate the webhook before recording it. We don't want to store payloads
+	// that were not sent by github.
+	_, entity, err := r.validateWebhook(entityID, hookTargetType, signature, jobData)
+	if err != nil {
+		return err
+	}
+	r.recordWebhookSeen(entity, false)
+
+	if deliveryID == "" {
+		// Github always sets a delivery ID. Generate one if the header is missing, so
+		// the webhook can still be processed, but it can't be deduplicated.
+		deliveryID = uuid.New().String()
+	}
+
+	_, err = r.store.CreateWebhookDelivery(r.ctx, params.WebhookDelivery{
+		ID:             deliveryID,
+		Event:          params.WorkflowJobEvent,
+		EntityID:       entityID,
+		HookTargetType: hookTargetType,
+		Payload:        jobData,
+		Status:         params.WebhookDeliveryPending,
+		NextAttemptAt:  time.Now().UTC(),
+	})
+	if err != nil {
+		var conflictErr *runnerErrors.ConflictError
+		if errors.As(err, &conflictErr) {
+			log.Printf("ignoring duplicate webhook delivery %s", util.SanitizeLogEntry(deliveryID))
+			return nil
+		}
+		return errors.Wrap(err, "recording webhook delivery")
+	}
+
+	r.queueWebhookDelivery(deliveryID)
+	return nil
+}
+
+func (r *Runner) ListWebhookDeliveries(ctx context.Context) ([]params.WebhookDelivery, error) {
+	if !auth.IsAdmin(ctx) {
+		return nil, runnerErrors.ErrUnauthorized
+	}
+
+	deliveries, err := r.store.ListWebhookDeliveries(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "fetching webhook deliveries")
+	}
+
+	// Payloads can be large. Use GetWebhookDelivery to view the payload.
+	for idx := range deliveries {
+		deliveries[idx].Payload = nil
+	}
+	return deliveries, nil
+}
+
+func (r *Runner) GetWebhookDelivery(ctx context.Context, deliveryID string) (params.WebhookDelivery, error) {
+	if !auth.IsAdmin(ctx) {
+		return params.WebhookDelivery{}, runnerErrors.ErrUnauthorized
+	}
+
+	delivery, err := r.store.GetWebhookDelivery(ctx, deliveryID)
+	if err != nil {
+		return params.WebhookDelivery{}, errors.Wrap(err, "fetching webhook delivery")
+	}
+	return delivery, nil
+}
+
+// ReplayWebhookDelivery resets a webhook delivery to pending, regardless of its current
+// status, and queues it for processing.
+func (r *Runner) ReplayWebhookDelivery(ctx context.Context, deliveryID string) (params.WebhookDelivery, error) {
+	if !auth.IsAdmin(ctx) {
+		return params.WebhookDelivery{}, runnerErrors.ErrUnauthorized
+	}
+
+	status := params.WebhookDeliveryPending
+	attempts := uint(0)
+	lastError := ""
+	nextAttempt := time.Now().UTC()
+	delivery, err := r.store.UpdateWebhookDelivery(ctx, deliveryID, params.UpdateWebhookDeliveryParams{
+		Status:        &status,
+		Attempts:      &attempts,
+		LastError:     &lastError,
+		NextAttemptAt: &nextAttempt,
+	})
+	if err != nil {
+		return params.WebhookDelivery{}, errors.Wrap(err, "updating webhook delivery")
+	}
+
+	r.queueWebhookDelivery(deliveryID)
+	return delivery, nil
+}
+
+// markDeliveryInFlight records a delivery as handed over to the workers. It returns
+// false if the delivery was already handed over and is not yet processed.
+func (r *Runner) markDeliveryInFlight(deliveryID string) bool {
+	r.deliveriesMux.Lock()
+	defer r.deliveriesMux.Unlock()
+
+	if r.deliveriesInFlight == nil {
+		r.deliveriesInFlight = map[string]struct{}{}
+	}
+	if _, ok := r.deliveriesInFlight[deliveryID]; ok {
+		return false
+	}
+	r.deliveriesInFlight[deliveryID] = struct{}{}
+	return true
+}
+
+func (r *Runner) unmarkDeliveryInFlight(deliveryID string) {
+	r.deliveriesMux.Lock()
+	defer r.deliveriesMux.Unlock()
+
+	delete(r.deliveriesInFlight, deliveryID)
+}
+
+// queueWebhookDelivery hands a delivery over to the workers without blocking. If
+// all workers are busy, the delivery will be picked up from the database by
+// webhookDeliveryLoop.
+func (r *Runner) queueWebhookDelivery(deliveryID string) {
+	if !r.markDeliveryInFlight(deliveryID) {
+		return
+	}
+
+	select {
+	case r.deliveries <- deliveryID:
+	default:
+		r.unmarkDeliveryInFlight(deliveryID)
+	}
+}
+
+// webhookDeliveryLoop periodically hands pending deliveries over to the workers. This
+// takes care of retries, deliveries we could not queue right away and deliveries left
+// pending when garm was stopped. It also removes old deliveries from the database.
+func (r *Runner) webhookDeliveryLoop() {
+	ticker := time.NewTicker(webhookDeliveryPollInterval)
+	defer ticker.Stop()
+	cleanupTicker := time.NewTicker(time.Hour)
+	defer cleanupTicker.Stop()
+
+	r.queuePendingWebhookDeliveries()
+	for {
+		select {
+		case <-ticker.C:
+			r.queuePendingWebhookDeliveries()
+		case <-cleanupTicker.C:
+			if err := r.store.DeleteWebhookDeliveriesOlderThan(r.ctx, time.Now().UTC().Add(-webhookDeliveryRetention)); err != nil {
+				log.Printf("failed to remove old webhook deliveries: %s", err)
+			}
+		case <-r.ctx.Done():
+			return
+		}
+	}
+}
+
+func (r *Runner) queuePendingWebhookDeliveries() {
+	deliveries, err := r.store.ListWebhookDeliveriesByStatus(r.ctx, params.WebhookDeliveryPending)
+	if err != nil {
+		log.Printf("failed to list pending webhook deliveries: %s", err)
+		return
+	}
+
+	now := time.Now().UTC()
+	for _, delivery := range deliveries {
+		if delivery.NextAttemptAt.After(now) {
+			continue
+		}
+		if !r.markDeliveryInFlight(delivery.ID) {
+			continue
+		}
+
+		select {
+		case r.deliveries <- delivery.ID:
+		case <-r.ctx.Done():
+			return
+		}
+	}
+}
+
+func (r *Runner) webhookDeliveryWorker() {
+	for {
+		select {
+		case deliveryID := <-r.deliveries:
+			if err := r.processWebhookDelivery(deliveryID); err != nil {
+				log.Printf("failed to process webhook delivery %s: %s", deliveryID, err)
+			}
+			r.unmarkDeliveryInFlight(deliveryID)
+		case <-r.ctx.Done():
+			return
+		}
+	}
+}
+
+// processWebhookDelivery processes one pending webhook delivery and records the outcome.
+// Deliveries that fail are retried, unless the error is not transient or we ran out
+// of attempts.
+func (r *Runner) processWebhookDelivery(deliveryID string) error {
+	delivery, err := r.store.GetWebhookDelivery(r.ctx, deliveryID)
+	if err != nil {
+		return errors.Wrap(err, "fetching webhook delivery")
+	}
+
+	if delivery.Status != params.WebhookDeliveryPending {
+		return nil
+	}
+
+	attempts := delivery.Attempts + 1
+	status := params.WebhookDeliveryCompleted
+	lastError := ""
+	update := params.UpdateWebhookDeliveryParams{
+		Attempts:  &attempts,
+		Status:    &status,
+		LastError: &lastError,
+	}
+
+	entity, err := r.handleWebhookDelivery(delivery)
+	if err != nil {
+		lastError = err.Error()
+		if !isRetryableDeliveryError(err) || attempts >= webhookDeliveryMaxAttempts {
+			status = params.WebhookDeliveryFailed
+		} else {
+			status = params.WebhookDeliveryPending
+			nextAttempt := time.Now().UTC().Add(time.Duration(attempts) * webhookDeliveryRetryInterval)
+			update.NextAttemptAt = &nextAttempt
+		}
+		log.Printf("failed to handle webhook delivery %s (attempt %d): %s", deliveryID, attempts, err)
+	}
+
+	if _, err := r.store.UpdateWebhookDelivery(r.ctx, deliveryID, update); err != nil {
+		return errors.Wrap(err, "updating webhook delivery")
+	}
+
+	// The pool managers use the high-water mark to recover deliveries we missed while
+	// garm was down. Deliveries that are still retried are not processed yet.
+	if entity.ID != "" && status != params.WebhookDeliveryPending {
+		if err := r.store.UpdateWebhookDeliveryHighWaterMark(r.ctx, entity.Type, entity.ID, delivery.CreatedAt); err != nil {
+			return errors.Wrap(err, "updating webhook delivery high-water mark")
+		}
+	}
+	return nil
+}
+
+// handleWebhookDelivery handles a webhook delivery and returns the entity it was sent
+// for. The entity is empty if the delivery could not be matched to one.
+func (r *Runner) handleWebhookDelivery(delivery params.WebhookDelivery) (params.WebhookEntity, error) {
+	switch delivery.Event {
+	case params.WorkflowJobEvent:
+		job, err := decodeWorkflowJob(delivery.Payload)
+		if err != nil {
+			return params.WebhookEntity{}, err
+		}
+
+		poolManager, entity, err := r.findWebhookPoolManager(delivery.EntityID, delivery.HookTargetType, delivery.Payload)
+		if err != nil {
+			return params.WebhookEntity{}, err
+		}
+
+		if err := poolManager.HandleWorkflowJob(job); err != nil {
+			return entity, errors.Wrap(err, "handling workflow job")
+		}
+		return entity, nil
+	default:
+		return params.WebhookEntity{}, runnerErrors.NewBadRequestError("cannot handle event %s", delivery.Event)
+	}
+}
+
+// isRetryableDeliveryError returns false for errors that will not go away if we
+// process the same delivery again.
+func isRetryableDeliveryError(err error) bool {
+	if errors.Is(err, runnerErrors.ErrNotFound) {
+		return false
+	}
+
+	var badRequestErr *runnerErrors.BadRequestError
+	return !errors.As(err, &badRequestErr)
+}
@@ -0,0 +1,507 @@
+// Copyright 2023 Cloudbase Solutions SRL
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package runner
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/cloudbase/garm/auth"
+	"github.com/cloudbase/garm/database"
+	dbCommon "github.com/cloudbase/garm/database/common"
+	runnerErrors "github.com/cloudbase/garm/errors"
+	garmTesting "github.com/cloudbase/garm/internal/testing"
+	"github.com/cloudbase/garm/params"
+	runnerCommonMocks "github.com/cloudbase/garm/runner/common/mocks"
+	runnerMocks "github.com/cloudbase/garm/runner/mocks"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type WebhookDeliveryTestFixtures struct {
+	AdminContext    context.Context
+	Store           dbCommon.Store
+	Repo            params.Repository
+	JobData         []byte
+	Signature       string
+	ErrMock         error
+	PoolMgrMock     *runnerCommonMocks.PoolManager
+	PoolMgrCtrlMock *runnerMocks.PoolManagerController
+}
+
+type WebhookDeliveryTestSuite struct {
+	suite.Suite
+	Fixtures *WebhookDeliveryTestFixtures
+	Runner   *Runner
+}
+
+func (s *WebhookDeliveryTestSuite) SetupTest() {
+	adminCtx := auth.GetAdminContext()
+
+	// create testing sqlite database
+	dbCfg := garmTesting.GetTestSqliteDBConfig(s.T())
+	db, err := database.NewDatabase(adminCtx, dbCfg)
+	if err != nil {
+		s.FailNow(fmt.Sprintf("failed to create db connection: %s", err))
+	}
+
+	repo, err := db.CreateRepository(adminCtx, "https://github.com", "test-owner", "test-repo", "test-creds", "test-webhook-secret")
+	if err != nil {
+		s.FailNow(fmt.Sprintf("failed to create database object (test-repo): %s", err))
+	}
+
+	jobData := []byte(`{"action": "queued", "workflow_job": {"id": 1}, "repository": {"name": "test-repo", "owner": {"login": "test-owner"}}}`)
+	mac := hmac.New(sha256.New, []byte(repo.WebhookSecret))
+	mac.Write(jobData)
+
+	fixtures := &WebhookDeliveryTestFixtures{
+		AdminContext:    adminCtx,
+		Store:           db,
+		Repo:            repo,
+		JobData:         jobData,
+		Signature:       fmt.Sprintf("sha256=%s", hex.EncodeToString(mac.Sum(nil))),
+		ErrMock:         fmt.Errorf("mock error"),
+		PoolMgrMock:     runnerCommonMocks.NewPoolManager(s.T()),
+		PoolMgrCtrlMock: runnerMocks.NewPoolManagerController(s.T()),
+	}
+	// The pool manager ID is used to identify the entity a webhook was sent for.
+	fixtures.PoolMgrMock.On("ID").Return(repo.ID).Maybe()
+	s.Fixtures = fixtures
+
+	// setup test runner
+	s.Runner = &Runner{
+		ctx:             fixtures.AdminContext,
+		store:           fixtures.Store,
+		poolManagerCtrl: fixtures.PoolMgrCtrlMock,
+	}
+}
+
+func (s *WebhookDeliveryTestSuite) sign(payload []byte) string {
+	return s.signWithSecret(s.Fixtures.Repo.WebhookSecret, payload)
+}
+
+func (s *WebhookDeliveryTestSuite) signWithSecret(secret string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+	return fmt.Sprintf("sha256=%s", hex.EncodeToString(mac.Sum(nil)))
+}
+
+// createAppCredentials creates github app credentials with the given webhook secret.
+func (s *WebhookDeliveryTestSuite) createAppCredentials(webhookSecret string) {
+	_, err := s.Fixtures.Store.CreateGithubCredentials(s.Fixtures.AdminContext, params.CreateGithubCredentialsParams{
+		Name:     "test-app-creds",
+		AuthType: params.GithubAuthTypeApp,
+		App: params.GithubApp{
+			AppID:          1,
+			InstallationID: 1,
+			PrivateKey:     []byte("test-private-key"),
+			WebhookSecret:  webhookSecret,
+		},
+	})
+	if err != nil {
+		s.FailNow(fmt.Sprintf("failed to create github credentials: %s", err))
+	}
+}
+
+func (s *WebhookDeliveryTestSuite) createDelivery(id string) params.WebhookDelivery {
+	delivery, err := s.Fixtures.Store.CreateWebhookDelivery(s.Fixtures.AdminContext, params.WebhookDelivery{
+		ID:             id,
+		Event:          params.WorkflowJobEvent,
+		HookTargetType: string(RepoHook),
+		Payload:        s.Fixtures.JobData,
+	})
+	if err != nil {
+		s.FailNow(fmt.Sprintf("failed to create webhook delivery: %s", err))
+	}
+	return delivery
+}
+
+func (s *WebhookDeliveryTestSuite) TestEnqueueWorkflowJob() {
+	s.Fixtures.PoolMgrCtrlMock.On("GetRepoPoolManager", mock.AnythingOfType("params.Repository")).Return(s.Fixtures.PoolMgrMock, nil)
+	s.Fixtures.PoolMgrMock.On("WebhookSecret").Return(s.Fixtures.Repo.WebhookSecret)
+
+	err := s.Runner.EnqueueWorkflowJob("", "test-delivery", string(RepoHook), s.Fixtures.Signature, s.Fixtures.JobData)
+
+	s.Require().Nil(err)
+	delivery, err := s.Fixtures.Store.GetWebhookDelivery(s.Fixtures.AdminContext, "test-delivery")
+	s.Require().Nil(err)
+	s.Require().Equal(params.WebhookDeliveryPending, delivery.Status)
+	s.Require().Equal(params.WorkflowJobEvent, delivery.Event)
+	s.Require().JSONEq(string(s.Fixtures.JobData), string(delivery.Payload))
+}
+
+func (s *WebhookDeliveryTestSuite) TestEnqueueWorkflowJobDuplicate() {
+	s.Fixtures.PoolMgrCtrlMock.On("GetRepoPoolManager", mock.AnythingOfType("params.Repository")).Return(s.Fixtures.PoolMgrMock, nil)
+	s.Fixtures.PoolMgrMock.On("WebhookSecret").Return(s.Fixtures.Repo.WebhookSecret)
+
+	err := s.Runner.EnqueueWorkflowJob("", "test-delivery", string(RepoHook), s.Fixtures.Signature, s.Fixtures.JobData)
+	s.Require().Nil(err)
+	err = s.Runner.EnqueueWorkflowJob("", "test-delivery", string(RepoHook), s.Fixtures.Signature, s.Fixtures.JobData)
+
+	s.Require().Nil(err)
+	deliveries, err := s.Fixtures.Store.ListWebhookDeliveries(s.Fixtures.AdminContext)
+	s.Require().Nil(err)
+	s.Require().Len(deliveries, 1)
+}
+
+func (s *WebhookDeliveryTestSuite) TestEnqueueWorkflowJobInvalidSignature() {
+	s.Fixtures.PoolMgrCtrlMock.On("GetRepoPoolManager", mock.AnythingOfType("params.Repository")).Return(s.Fixtures.PoolMgrMock, nil)
+	s.Fixtures.PoolMgrMock.On("WebhookSecret").Return("some-other-secret")
+
+	err := s.Runner.EnqueueWorkflowJob("", "test-delivery", string(RepoHook), s.Fixtures.Signature, s.Fixtures.JobData)
+
+	s.Require().Equal("validating webhook data: signature missmatch", err.Error())
+	deliveries, err := s.Fixtures.Store.ListWebhookDeliveries(s.Fixtures.AdminContext)
+	s.Require().Nil(err)
+	s.Require().Len(deliveries, 0)
+}
+
+func (s *WebhookDeliveryTestSuite) TestEnqueueWorkflowJobOwnerUnknown() {
+	err := s.Runner.EnqueueWorkflowJob("", "test-delivery", string(OrganizationHook), s.Fixtures.Signature, s.Fixtures.JobData)
+
+	s.Require().ErrorIs(err, runnerErrors.ErrNotFound)
+}
+
+func (s *WebhookDeliveryTestSuite) TestEnqueueWorkflowJobOtherEndpoint() {
+	// A repository with the same name, on another github server.
+	jobData := []byte(`{"action": "queued", "workflow_job": {"id": 1}, "repository": {"name": "test-repo", "html_url": "https://ghes.example.com/test-owner/test-repo", "owner": {"login": "test-owner"}}}`)
+
+	err := s.Runner.EnqueueWorkflowJob("", "test-delivery", string(RepoHook), s.sign(jobData), jobData)
+
+	s.Require().ErrorIs(err, runnerErrors.ErrNotFound)
+}
+
+func (s *WebhookDeliveryTestSuite) TestWebhookEndpoint() {
+	var target params.WebhookTarget
+	s.Require().Equal("https://github.com", webhookEndpoint(target))
+
+	target.Sender.HTMLURL = "https://GHES.example.com/some-user"
+	s.Require().Equal("https://ghes.example.com", webhookEndpoint(target))
+
+	target.Repository.HTMLURL = "http://ghes.example.com:8080/test-owner/test-repo"
+	s.Require().Equal("http://ghes.example.com:8080", webhookEndpoint(target))
+}
+
+func (s *WebhookDeliveryTestSuite) TestProcessWebhookDelivery() {
+	s.createDelivery("test-delivery")
+	s.Fixtures.PoolMgrCtrlMock.On("GetRepoPoolManager", mock.AnythingOfType("params.Repository")).Return(s.Fixtures.PoolMgrMock, nil)
+	s.Fixtures.PoolMgrMock.On("HandleWorkflowJob", mock.AnythingOfType("params.WorkflowJob")).Return(nil)
+
+	err := s.Runner.processWebhookDelivery("test-delivery")
+
+	s.Require().Nil(err)
+	delivery, err := s.Fixtures.Store.GetWebhookDelivery(s.Fixtures.AdminContext, "test-delivery")
+	s.Require().Nil(err)
+	s.Require().Equal(params.WebhookDeliveryCompleted, delivery.Status)
+	s.Require().Equal(uint(1), delivery.Attempts)
+	s.Require().Empty(delivery.LastError)
+	mark, err := s.Fixtures.Store.GetWebhookDeliveryHighWaterMark(s.Fixtures.AdminContext, params.RepositoryPool, s.Fixtures.Repo.ID)
+	s.Require().Nil(err)
+	s.Require().True(delivery.CreatedAt.Equal(mark))
+}
+
+func (s *WebhookDeliveryTestSuite) TestProcessWebhookDeliveryRetry() {
+	s.createDelivery("test-delivery")
+	s.Fixtures.PoolMgrCtrlMock.On("GetRepoPoolManager", mock.AnythingOfType("params.Repository")).Return(s.Fixtures.PoolMgrMock, nil)
+	s.Fixtures.PoolMgrMock.On("HandleWorkflowJob", mock.AnythingOfType("params.WorkflowJob")).Return(s.Fixtures.ErrMock)
+
+	err := s.Runner.processWebhookDelivery("test-delivery")
+
+	s.Require().Nil(err)
+	delivery, err := s.Fixtures.Store.GetWebhookDelivery(s.Fixtures.AdminContext, "test-delivery")
+	s.Require().Nil(err)
+	s.Require().Equal(params.WebhookDeliveryPending, delivery.Status)
+	s.Require().Equal(uint(1), delivery.Attempts)
+	s.Require().Equal("handling workflow job: mock error", delivery.LastError)
+	s.Require().True(delivery.NextAttemptAt.After(time.Now()))
+	mark, err := s.Fixtures.Store.GetWebhookDeliveryHighWaterMark(s.Fixtures.AdminContext, params.RepositoryPool, s.Fixtures.Repo.ID)
+	s.Require().Nil(err)
+	s.Require().True(mark.IsZero())
+}
+
+func (s *WebhookDeliveryTestSuite) TestProcessWebhookDeliveryMaxAttempts() {
+	s.createDelivery("test-delivery")
+	attempts := uint(webhookDeliveryMaxAttempts - 1)
+	_, err := s.Fixtures.Store.UpdateWebhookDelivery(s.Fixtures.AdminContext, "test-delivery", params.UpdateWebhookDeliveryParams{Attempts: &attempts})
+	if err != nil {
+		s.FailNow(fmt.Sprintf("failed to update webhook delivery: %s", err))
+	}
+	s.Fixtures.PoolMgrCtrlMock.On("GetRepoPoolManager", mock.AnythingOfType("params.Repository")).Return(s.Fixtures.PoolMgrMock, nil)
+	s.Fixtures.PoolMgrMock.On("HandleWorkflowJob", mock.AnythingOfType("params.WorkflowJob")).Return(s.Fixtures.ErrMock)
+
+	err = s.Runner.processWebhookDelivery("test-delivery")
+
+	s.Require().Nil(err)
+	delivery, err := s.Fixtures.Store.GetWebhookDelivery(s.Fixtures.AdminContext, "test-delivery")
+	s.Require().Nil(err)
+	s.Require().Equal(params.WebhookDeliveryFailed, delivery.Status)
+	s.Require().Equal(uint(webhookDeliveryMaxAttempts), delivery.Attempts)
+}
+
+func (s *WebhookDeliveryTestSuite) TestProcessWebhookDeliveryOwnerUnknown() {
+	s.createDelivery("test-delivery")
+	if err := s.Fixtures.Store.DeleteRepository(s.Fixtures.AdminContext, s.Fixtures.Repo.ID); err != nil {
+		s.FailNow(fmt.Sprintf("failed to delete repository: %s", err))
+	}
+
+	err := s.Runner.processWebhookDelivery("test-delivery")
+
+	// Errors that will not go away are not retried.
+	s.Require().Nil(err)
+	delivery, err := s.Fixtures.Store.GetWebhookDelivery(s.Fixtures.AdminContext, "test-delivery")
+	s.Require().Nil(err)
+	s.Require().Equal(params.WebhookDeliveryFailed, delivery.Status)
+	s.Require().Equal(uint(1), delivery.Attempts)
+}
+
+func (s *WebhookDeliveryTestSuite) TestProcessWebhookDeliveryNotPending() {
+	s.createDelivery("test-delivery")
+	status := params.WebhookDeliveryCompleted
+	_, err := s.Fixtures.Store.UpdateWebhookDelivery(s.Fixtures.AdminContext, "test-delivery", params.UpdateWebhookDeliveryParams{Status: &status})
+	if err != nil {
+		s.FailNow(fmt.Sprintf("failed to update webhook delivery: %s", err))
+	}
+
+	err = s.Runner.processWebhookDelivery("test-delivery")
+
+	s.Require().Nil(err)
+	delivery, err := s.Fixtures.Store.GetWebhookDelivery(s.Fixtures.AdminContext, "test-delivery")
+	s.Require().Nil(err)
+	s.Require().Equal(uint(0), delivery.Attempts)
+}
+
+func (s *WebhookDeliveryTestSuite) TestListWebhookDeliveries() {
+	s.createDelivery("test-delivery-1")
+	s.createDelivery("test-delivery-2")
+
+	deliveries, err := s.Runner.ListWebhookDeliveries(s.Fixtures.AdminContext)
+
+	s.Require().Nil(err)
+	s.Require().Len(deliveries, 2)
+	for _, delivery := range deliveries {
+		s.Require().Empty(delivery.Payload)
+	}
+}
+
+func (s *WebhookDeliveryTestSuite) TestListWebhookDeliveriesErrUnauthorized() {
+	_, err := s.Runner.ListWebhookDeliveries(context.Background())
+
+	s.Require().Equal(runnerErrors.ErrUnauthorized, err)
+}
+
+func (s *WebhookDeliveryTestSuite) TestGetWebhookDeliveryErrUnauthorized() {
+	_, err := s.Runner.GetWebhookDelivery(context.Background(), "test-delivery")
+
+	s.Require().Equal(runnerErrors.ErrUnauthorized, err)
+}
+
+func (s *WebhookDeliveryTestSuite) TestReplayWebhookDelivery() {
+	s.createDelivery("test-delivery")
+	status := params.WebhookDeliveryFailed
+	attempts := uint(webhookDeliveryMaxAttempts)
+	_, err := s.Fixtures.Store.UpdateWebhookDelivery(s.Fixtures.AdminContext, "test-delivery", params.UpdateWebhookDeliveryParams{
+		Status:   &status,
+		Attempts: &attempts,
+	})
+	if err != nil {
+		s.FailNow(fmt.Sprintf("failed to update webhook delivery: %s", err))
+	}
+
+	delivery, err := s.Runner.ReplayWebhookDelivery(s.Fixtures.AdminContext, "test-delivery")
+
+	s.Require().Nil(err)
+	s.Require().Equal(params.WebhookDeliveryPending, delivery.Status)
+	s.Require().Equal(uint(0), delivery.Attempts)
+	s.Require().Empty(delivery.LastError)
+}
+
+func (s *WebhookDeliveryTestSuite) TestReplayWebhookDeliveryErrUnauthorized() {
+	_, err := s.Runner.ReplayWebhookDelivery(context.Background(), "test-delivery")
+
+	s.Require().Equal(runnerErrors.ErrUnauthorized, err)
+}
+
+func (s *WebhookDeliveryTestSuite) TestReplayWebhookDeliveryNotFound() {
+	_, err := s.Runner.ReplayWebhookDelivery(s.Fixtures.AdminContext, "dummy-delivery-id")
+
+	s.Require().ErrorIs(err, runnerErrors.ErrNotFound)
+}
+
+func (s *WebhookDeliveryTestSuite) TestEnqueueWorkflowJobRecordsWebhookSeen() {
+	s.Fixtures.PoolMgrCtrlMock.On("GetRepoPoolManager", mock.AnythingOfType("params.Repository")).Return(s.Fixtures.PoolMgrMock, nil)
+	s.Fixtures.PoolMgrMock.On("WebhookSecret").Return(s.Fixtures.Repo.WebhookSecret)
+
+	err := s.Runner.EnqueueWorkflowJob("", "test-delivery", string(RepoHook), s.Fixtures.Signature, s.Fixtures.JobData)
+
+	s.Require().Nil(err)
+	repo, err := s.Fixtures.Store.GetRepositoryByID(s.Fixtures.AdminContext, s.Fixtures.Repo.ID)
+	s.Require().Nil(err)
+	s.Require().NotNil(repo.WebhookLastSeenAt)
+	s.Require().Nil(repo.WebhookVerifiedAt)
+}
+
+func (s *WebhookDeliveryTestSuite) TestHandlePing() {
+	ping := []byte(`{"zen": "Keep it logically awesome.", "hook_id": 1, "repository": {"name": "test-repo", "owner": {"login": "test-owner"}}}`)
+	s.Fixtures.PoolMgrCtrlMock.On("GetRepoPoolManager", mock.AnythingOfType("params.Repository")).Return(s.Fixtures.PoolMgrMock, nil)
+	s.Fixtures.PoolMgrMock.On("WebhookSecret").Return(s.Fixtures.Repo.WebhookSecret)
+
+	entity, err := s.Runner.HandlePing("", string(RepoHook), s.sign(ping), ping)
+
+	s.Require().Nil(err)
+	s.Require().Equal(params.WebhookEntity{ID: s.Fixtures.Repo.ID, Type: params.RepositoryPool, Name: "test-owner/test-repo"}, entity)
+	repo, err := s.Fixtures.Store.GetRepositoryByID(s.Fixtures.AdminContext, s.Fixtures.Repo.ID)
+	s.Require().Nil(err)
+	s.Require().NotNil(repo.WebhookLastSeenAt)
+	s.Require().NotNil(repo.WebhookVerifiedAt)
+}
+
+func (s *WebhookDeliveryTestSuite) TestHandlePingInvalidSignature() {
+	ping := []byte(`{"zen": "Keep it logically awesome.", "hook_id": 1, "repository": {"name": "test-repo", "owner": {"login": "test-owner"}}}`)
+	s.Fixtures.PoolMgrCtrlMock.On("GetRepoPoolManager", mock.AnythingOfType("params.Repository")).Return(s.Fixtures.PoolMgrMock, nil)
+	s.Fixtures.PoolMgrMock.On("WebhookSecret").Return("some-other-secret")
+
+	_, err := s.Runner.HandlePing("", string(RepoHook), s.sign(ping), ping)
+
+	s.Require().Equal("validating webhook data: signature missmatch", err.Error())
+	repo, err := s.Fixtures.Store.GetRepositoryByID(s.Fixtures.AdminContext, s.Fixtures.Repo.ID)
+	s.Require().Nil(err)
+	s.Require().Nil(repo.WebhookVerifiedAt)
+}
+
+func (s *WebhookDeliveryTestSuite) TestHandlePingOwnerUnknown() {
+	ping := []byte(`{"zen": "Keep it logically awesome.", "hook_id": 1, "repository": {"name": "other-repo", "owner": {"login": "test-owner"}}}`)
+
+	_, err := s.Runner.HandlePing("", string(RepoHook), s.sign(ping), ping)
+
+	s.Require().ErrorIs(err, runnerErrors.ErrNotFound)
+}
+
+func (s *WebhookDeliveryTestSuite) TestHandleWorkflowRun() {
+	run := []byte(`{"action": "completed", "workflow_run": {"id": 1, "conclusion": "success"}, "repository": {"name": "test-repo", "owner": {"login": "test-owner"}}}`)
+	s.Fixtures.PoolMgrCtrlMock.On("GetRepoPoolManager", mock.AnythingOfType("params.Repository")).Return(s.Fixtures.PoolMgrMock, nil)
+	s.Fixtures.PoolMgrMock.On("WebhookSecret").Return(s.Fixtures.Repo.WebhookSecret)
+
+	workflowRun, entity, err := s.Runner.HandleWorkflowRun("", string(RepoHook), s.sign(run), run)
+
+	s.Require().Nil(err)
+	s.Require().Equal("completed", workflowRun.Action)
+	s.Require().Equal("success", workflowRun.WorkflowRun.Conclusion)
+	s.Require().Equal(s.Fixtures.Repo.ID, entity.ID)
+}
+
+func (s *WebhookDeliveryTestSuite) TestHandleInstallationEvent() {
+	s.createAppCredentials("test-app-webhook-secret")
+	installation := []byte(`{"action": "deleted", "installation": {"id": 1, "account": {"login": "test-owner"}}}`)
+
+	err := s.Runner.HandleInstallationEvent(params.InstallationEvent, s.signWithSecret("test-app-webhook-secret", installation), installation)
+
+	s.Require().Nil(err)
+}
+
+func (s *WebhookDeliveryTestSuite) TestHandleInstallationEventInvalidData() {
+	s.createAppCredentials("test-app-webhook-secret")
+	installation := []byte("invalid")
+
+	err := s.Runner.HandleInstallationEvent(params.InstallationEvent, s.signWithSecret("test-app-webhook-secret", installation), installation)
+
+	s.Require().ErrorIs(err, runnerErrors.ErrBadRequest)
+}
+
+func (s *WebhookDeliveryTestSuite) TestHandleInstallationEventInvalidSignature() {
+	s.createAppCredentials("test-app-webhook-secret")
+	installation := []byte("invalid")
+
+	err := s.Runner.HandleInstallationEvent(params.InstallationEvent, s.sign(installation), installation)
+
+	var unauthorizedErr *runnerErrors.UnauthorizedError
+	s.Require().ErrorAs(err, &unauthorizedErr)
+}
+
+func (s *WebhookDeliveryTestSuite) TestHandleInstallationEventMissingSecret() {
+	s.createAppCredentials("")
+	installation := []byte("invalid")
+
+	err := s.Runner.HandleInstallationEvent(params.InstallationEvent, s.sign(installation), installation)
+
+	var missingSecretErr *runnerErrors.MissingSecretError
+	s.Require().ErrorAs(err, &missingSecretErr)
+}
+
+func (s *WebhookDeliveryTestSuite) TestEnqueueWorkflowJobEntityURL() {
+	s.Fixtures.PoolMgrCtrlMock.On("GetRepoPoolManager", mock.AnythingOfType("params.Repository")).Return(s.Fixtures.PoolMgrMock, nil)
+	s.Fixtures.PoolMgrMock.On("WebhookSecret").Return(s.Fixtures.Repo.WebhookSecret)
+
+	// The hook target type is not needed when the webhook is sent to the entity URL.
+	err := s.Runner.EnqueueWorkflowJob(s.Fixtures.Repo.ID, "test-delivery", "", s.Fixtures.Signature, s.Fixtures.JobData)
+
+	s.Require().Nil(err)
+	delivery, err := s.Fixtures.Store.GetWebhookDelivery(s.Fixtures.AdminContext, "test-delivery")
+	s.Require().Nil(err)
+	s.Require().Equal(s.Fixtures.Repo.ID, delivery.EntityID)
+}
+
+func (s *WebhookDeliveryTestSuite) TestEnqueueWorkflowJobEntityURLNotFound() {
+	err := s.Runner.EnqueueWorkflowJob("d7f1c3e2-7a4b-4b7e-9a57-7b1c1f2b9a10", "test-delivery", "", s.Fixtures.Signature, s.Fixtures.JobData)
+
+	s.Require().ErrorIs(err, runnerErrors.ErrNotFound)
+}
+
+func (s *WebhookDeliveryTestSuite) TestEnqueueWorkflowJobEntityURLInvalidID() {
+	err := s.Runner.EnqueueWorkflowJob("invalid-id", "test-delivery", "", s.Fixtures.Signature, s.Fixtures.JobData)
+
+	s.Require().Equal(runnerErrors.NewBadRequestError("invalid entity ID invalid-id"), err)
+}
+
+func (s *WebhookDeliveryTestSuite) TestProcessWebhookDeliveryEntityURL() {
+	_, err := s.Fixtures.Store.CreateWebhookDelivery(s.Fixtures.AdminContext, params.WebhookDelivery{
+		ID:       "test-delivery",
+		Event:    params.WorkflowJobEvent,
+		EntityID: s.Fixtures.Repo.ID,
+		Payload:  s.Fixtures.JobData,
+	})
+	if err != nil {
+		s.FailNow(fmt.Sprintf("failed to create webhook delivery: %s", err))
+	}
+	s.Fixtures.PoolMgrCtrlMock.On("GetRepoPoolManager", mock.AnythingOfType("params.Repository")).Return(s.Fixtures.PoolMgrMock, nil)
+	s.Fixtures.PoolMgrMock.On("HandleWorkflowJob", mock.AnythingOfType("params.WorkflowJob")).Return(nil)
+
+	err = s.Runner.processWebhookDelivery("test-delivery")
+
+	s.Require().Nil(err)
+	delivery, err := s.Fixtures.Store.GetWebhookDelivery(s.Fixtures.AdminContext, "test-delivery")
+	s.Require().Nil(err)
+	s.Require().Equal(params.WebhookDeliveryCompleted, delivery.Status)
+}
+
+func (s *WebhookDeliveryTestSuite) TestHandlePingEntityURL() {
+	ping := []byte(`{"zen": "Keep it logically awesome.", "hook_id": 1}`)
+	s.Fixtures.PoolMgrCtrlMock.On("GetRepoPoolManager", mock.AnythingOfType("params.Repository")).Return(s.Fixtures.PoolMgrMock, nil)
+	s.Fixtures.PoolMgrMock.On("WebhookSecret").Return(s.Fixtures.Repo.WebhookSecret)
+
+	entity, err := s.Runner.HandlePing(s.Fixtures.Repo.ID, "", s.sign(ping), ping)
+
+	s.Require().Nil(err)
+	s.Require().Equal(params.WebhookEntity{ID: s.Fixtures.Repo.ID, Type: params.RepositoryPool, Name: "test-owner/test-repo"}, entity)
+}
+
+func TestWebhookDeliveryTestSuite(t *testing.T) {
+	suite.Run(t, new(WebhookDeliveryTestSuite))
+}
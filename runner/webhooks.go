@@ -0,0 +1,166 @@
+// Copyright 2023 Cloudbase Solutions SRL
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package runner
+
+import (
+	"encoding/json"
+	"log"
+
+	runnerErrors "github.com/cloudbase/garm/errors"
+	"github.com/cloudbase/garm/params"
+	"github.com/cloudbase/garm/util"
+
+	"github.com/pkg/errors"
+)
+
+// HandlePing validates a ping sent by github against the secret of the entity the
+// webhook was created for, and records that the webhook was verified. The entityID is
+// set if the ping was sent to the webhook URL of an entity.
+func (r *Runner) HandlePing(entityID, hookTargetType, signature string, body []byte) (params.WebhookEntity, error) {
+	_, entity, err := r.validateWebhook(entityID, hookTargetType, signature, body)
+	if err != nil {
+		return params.WebhookEntity{}, err
+	}
+
+	var ping params.Ping
+	if err := json.Unmarshal(body, &ping); err != nil {
+		return params.WebhookEntity{}, errors.Wrapf(runnerErrors.ErrBadRequest, "invalid ping data: %s", err)
+	}
+
+	log.Printf("webhook %d verified for %s %s", ping.HookID, entity.Type, util.SanitizeLogEntry(entity.Name))
+	r.recordWebhookSeen(entity, true)
+	return entity, nil
+}
+
+// HandleWorkflowRun validates a workflow_run webhook. Garm does not act on workflow runs,
+// but they are counted in the metrics.
+func (r *Runner) HandleWorkflowRun(entityID, hookTargetType, signature string, body []byte) (params.WorkflowRun, params.WebhookEntity, error) {
+	_, entity, err := r.validateWebhook(entityID, hookTargetType, signature, body)
+	if err != nil {
+		return params.WorkflowRun{}, params.WebhookEntity{}, err
+	}
+	r.recordWebhookSeen(entity, false)
+
+	var run params.WorkflowRun
+	if err := json.Unmarshal(body, &run); err != nil {
+		return params.WorkflowRun{}, params.WebhookEntity{}, errors.Wrapf(runnerErrors.ErrBadRequest, "invalid workflow run data: %s", err)
+	}
+	return run, entity, nil
+}
+
+// HandleInstallationEvent logs installation and installation_repositories webhooks, sent to
+// github app webhooks. The webhooks are validated against the webhook secrets of the github
+// app credentials, before we look at their contents.
+func (r *Runner) HandleInstallationEvent(event params.Event, signature string, body []byte) error {
+	if err := r.validateAppWebhook(signature, body); err != nil {
+		return err
+	}
+
+	if len(body) == 0 {
+		return runnerErrors.NewBadRequestError("missing webhook data")
+	}
+
+	var installation params.Installation
+	if err := json.Unmarshal(body, &installation); err != nil {
+		return errors.Wrapf(runnerErrors.ErrBadRequest, "invalid installation data: %s", err)
+	}
+
+	log.Printf(
+		"got %s event for github app installation %d on %s: %s",
+		event, installation.Installation.ID,
+		util.SanitizeLogEntry(installation.Installation.Account.Login),
+		util.SanitizeLogEntry(installation.Action))
+	for _, repo := range installation.RepositoriesAdded {
+		log.Printf("repository %s was added to installation %d", util.SanitizeLogEntry(repo.FullName), installation.Installation.ID)
+	}
+	for _, repo := range installation.RepositoriesRemoved {
+		log.Printf("repository %s was removed from installation %d", util.SanitizeLogEntry(repo.FullName), installation.Installation.ID)
+	}
+
+	switch installation.Action {
+	case "deleted", "suspend":
+	default:
+		return nil
+	}
+
+	creds, err := r.installationCredentials(installation.Installation.ID)
+	if err != nil {
+		return err
+	}
+	for _, cred := range creds {
+		log.Printf("github app installation %d was %s; credentials %s will stop working", installation.Installation.ID, util.SanitizeLogEntry(installation.Action), cred.Name)
+	}
+	return nil
+}
+
+// validateAppWebhook validates the signature of a webhook sent by a github app. Github
+// does not tell us which app sent the webhook, so the signature is checked against the
+// webhook secrets of all github app credentials.
+func (r *Runner) validateAppWebhook(signature string, body []byte) error {
+	secrets, err := r.appWebhookSecrets()
+	if err != nil {
+		return errors.Wrap(err, "fetching github app webhook secrets")
+	}
+	if len(secrets) == 0 {
+		return runnerErrors.NewMissingSecretError("no github app credentials have a webhook secret")
+	}
+
+	for _, secret := range secrets {
+		err = r.validateHookBody(signature, secret, body)
+		if err == nil {
+			return nil
+		}
+	}
+	return errors.Wrap(err, "validating webhook data")
+}
+
+// appWebhookSecrets returns the webhook secrets of the github app credentials defined in
+// the config file or in the database.
+func (r *Runner) appWebhookSecrets() ([]string, error) {
+	secrets := []string{}
+	for _, val := range r.config.Github {
+		if val.GetAuthType() == params.GithubAuthTypeApp && val.App.WebhookSecret != "" {
+			secrets = append(secrets, val.App.WebhookSecret)
+		}
+	}
+
+	dbCreds, err := r.store.ListGithubCredentials(r.ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "fetching github credentials")
+	}
+	for _, val := range dbCreds {
+		if val.AuthType == params.GithubAuthTypeApp && val.App.WebhookSecret != "" {
+			secrets = append(secrets, val.App.WebhookSecret)
+		}
+	}
+	return secrets, nil
+}
+
+// installationCredentials returns the github app credentials, defined either in the config
+// file or in the database, that use the installation.
+func (r *Runner) installationCredentials(installationID int64) ([]params.GithubCredentials, error) {
+	creds, err := r.listAllCredentials(r.ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := []params.GithubCredentials{}
+	for _, cred := range creds {
+		if cred.AuthType == params.GithubAuthTypeApp && cred.App.InstallationID == installationID {
+			ret = append(ret, cred)
+		}
+	}
+	return ret, nil
+}
@@ -12,6 +12,26 @@ const (
 	// of time and no new updates have been made to it's state, it will be removed.
 	DefaultRunnerBootstrapTimeout = 20
 
+	// DefaultIdleTimeout is the default time in minutes a runner needs to be idle before
+	// it is considered for scale down.
+	DefaultIdleTimeout = 2
+
+	// DefaultCircuitBreakerThreshold is the default number of consecutive provisioning
+	// failures after which a pool stops creating runners.
+	DefaultCircuitBreakerThreshold = 5
+
+	// DefaultCircuitBreakerWindow is the default time in minutes in which provisioning
+	// failures need to happen to be considered consecutive.
+	DefaultCircuitBreakerWindow = 30
+
+	// CircuitBreakerBaseBackoff is the time a pool stops creating runners the first time
+	// its circuit breaker opens. The time is doubled every time the breaker opens again.
+	CircuitBreakerBaseBackoff time.Duration = 5 * time.Minute
+
+	// CircuitBreakerMaxBackoff is the maximum time a pool stops creating runners when
+	// its circuit breaker opens.
+	CircuitBreakerMaxBackoff time.Duration = 2 * time.Hour
+
 	// DefaultGithubURL is the default URL where Github or Github Enterprise can be accessed.
 	DefaultGithubURL = "https://github.com"
 
@@ -27,6 +47,14 @@ const (
 	// DefaultPoolQueueSize is the default size for a pool queue.
 	DefaultPoolQueueSize = 10
 
+	// DefaultPollingInterval is the default interval, in seconds, at which a pool manager
+	// polls the github API for workflow jobs, when polling is enabled for an entity.
+	DefaultPollingInterval = 60
+
+	// MinimumPollingInterval is the minimum interval, in seconds, that can be set
+	// when polling the github API for workflow jobs.
+	MinimumPollingInterval = 10
+
 	// GithubDefaultBaseURL is the default URL for the github API.
 	GithubDefaultBaseURL = "https://api.github.com/"
 
@@ -3,11 +3,36 @@ package exec
 import (
 	"bytes"
 	"context"
+	"errors"
+	"fmt"
 	"os/exec"
-
-	"github.com/pkg/errors"
 )
 
+// Error is returned by Exec when the binary fails. It holds the output of the binary,
+// which may describe the error.
+type Error struct {
+	Err    error
+	Stdout []byte
+	Stderr []byte
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("provider binary failed with stdout: %s; stderr: %s: %s", e.Stdout, e.Stderr, e.Err)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// ExitCode returns the exit code of the binary, or -1 if it did not exit normally.
+func (e *Error) ExitCode() int {
+	var exitErr *exec.ExitError
+	if !errors.As(e.Err, &exitErr) {
+		return -1
+	}
+	return exitErr.ExitCode()
+}
+
 func Exec(ctx context.Context, providerBin string, stdinData []byte, environ []string) ([]byte, error) {
 	stdout := &bytes.Buffer{}
 	stderr := &bytes.Buffer{}
@@ -18,7 +43,11 @@ func Exec(ctx context.Context, providerBin string, stdinData []byte, environ []s
 	c.Stderr = stderr
 
 	if err := c.Run(); err != nil {
-		return nil, errors.Wrapf(err, "provider binary failed with stdout: %s; stderr: %s", stdout.String(), stderr.String())
+		return nil, &Error{
+			Err:    err,
+			Stdout: stdout.Bytes(),
+			Stderr: stderr.Bytes(),
+		}
 	}
 
 	return stdout.Bytes(), nil
@@ -0,0 +1,126 @@
+// Copyright 2023 Cloudbase Solutions SRL
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package util
+
+import (
+	"context"
+	"crypto/rsa"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/cloudbase/garm/params"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/google/go-github/v53/github"
+	"github.com/pkg/errors"
+	"golang.org/x/oauth2"
+)
+
+const (
+	// githubAppJWTValidity is the lifetime of the JWT we use to authenticate as
+	// the GitHub App. GitHub allows a maximum of 10 minutes.
+	githubAppJWTValidity = 9 * time.Minute
+	// githubAppTokenEarlyExpiry is the amount of time before the installation token
+	// expires, at which we mint a new one.
+	githubAppTokenEarlyExpiry = 5 * time.Minute
+)
+
+// githubAppJWTTransport authenticates requests as the GitHub App itself. The only
+// requests we make using this transport are the ones that mint installation tokens.
+type githubAppJWTTransport struct {
+	appID int64
+	key   *rsa.PrivateKey
+	base  http.RoundTripper
+}
+
+func (g *githubAppJWTTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	now := time.Now()
+	claims := jwt.StandardClaims{
+		// Account for clock drift, as recommended by GitHub.
+		IssuedAt:  now.Add(-60 * time.Second).Unix(),
+		ExpiresAt: now.Add(githubAppJWTValidity).Unix(),
+		Issuer:    strconv.FormatInt(g.appID, 10),
+	}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodRS256, claims).SignedString(g.key)
+	if err != nil {
+		return nil, errors.Wrap(err, "signing github app JWT")
+	}
+
+	newReq := req.Clone(req.Context())
+	newReq.Header.Set("Authorization", "Bearer "+token)
+	return g.base.RoundTrip(newReq)
+}
+
+// githubAppTokenSource is an oauth2.TokenSource that mints GitHub App installation
+// tokens. It is meant to be wrapped in a reuse token source, which will call Token()
+// whenever the current installation token is about to expire.
+type githubAppTokenSource struct {
+	ctx            context.Context
+	installationID int64
+	client         *github.Client
+}
+
+func (g *githubAppTokenSource) Token() (*oauth2.Token, error) {
+	tk, _, err := g.client.Apps.CreateInstallationToken(g.ctx, g.installationID, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "creating installation token")
+	}
+
+	if tk.Token == nil {
+		return nil, fmt.Errorf("github returned an empty installation token")
+	}
+
+	return &oauth2.Token{
+		AccessToken: tk.GetToken(),
+		Expiry:      tk.GetExpiresAt().Time,
+	}, nil
+}
+
+func newGithubAppTokenSource(ctx context.Context, httpClient *http.Client, credsDetails params.GithubCredentials) (oauth2.TokenSource, error) {
+	if credsDetails.App.AppID == 0 || credsDetails.App.InstallationID == 0 {
+		return nil, fmt.Errorf("missing github app ID or installation ID")
+	}
+
+	key, err := jwt.ParseRSAPrivateKeyFromPEM(credsDetails.App.PrivateKey)
+	if err != nil {
+		return nil, errors.Wrap(err, "parsing github app private key")
+	}
+
+	base := httpClient.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	appClient := &http.Client{
+		Transport: &githubAppJWTTransport{
+			appID: credsDetails.App.AppID,
+			key:   key,
+			base:  base,
+		},
+	}
+
+	ghClient, err := github.NewEnterpriseClient(credsDetails.APIBaseURL, credsDetails.UploadBaseURL, appClient)
+	if err != nil {
+		return nil, errors.Wrap(err, "fetching github app client")
+	}
+
+	src := &githubAppTokenSource{
+		ctx:            ctx,
+		installationID: credsDetails.App.InstallationID,
+		client:         ghClient,
+	}
+	return oauth2.ReuseTokenSourceWithExpiry(nil, src, githubAppTokenEarlyExpiry), nil
+}
@@ -0,0 +1,92 @@
+// Copyright 2023 Cloudbase Solutions SRL
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package util
+
+import (
+	"context"
+
+	"github.com/google/go-github/v53/github"
+)
+
+// githubClient implements common.GithubClient. Most of the functionality we need
+// is exposed by the actions service. Webhooks are managed through the repositories
+// and organizations services, which use the same method names for both entity
+// types, so we wrap those explicitly.
+type githubClient struct {
+	*github.ActionsService
+
+	repos *github.RepositoriesService
+	orgs  *github.OrganizationsService
+}
+
+func (g *githubClient) ListHooks(ctx context.Context, owner, repo string, opts *github.ListOptions) ([]*github.Hook, *github.Response, error) {
+	return g.repos.ListHooks(ctx, owner, repo, opts)
+}
+
+func (g *githubClient) CreateHook(ctx context.Context, owner, repo string, hook *github.Hook) (*github.Hook, *github.Response, error) {
+	return g.repos.CreateHook(ctx, owner, repo, hook)
+}
+
+func (g *githubClient) EditHook(ctx context.Context, owner, repo string, id int64, hook *github.Hook) (*github.Hook, *github.Response, error) {
+	return g.repos.EditHook(ctx, owner, repo, id, hook)
+}
+
+func (g *githubClient) DeleteHook(ctx context.Context, owner, repo string, id int64) (*github.Response, error) {
+	return g.repos.DeleteHook(ctx, owner, repo, id)
+}
+
+func (g *githubClient) PingHook(ctx context.Context, owner, repo string, id int64) (*github.Response, error) {
+	return g.repos.PingHook(ctx, owner, repo, id)
+}
+
+func (g *githubClient) ListHookDeliveries(ctx context.Context, owner, repo string, id int64, opts *github.ListCursorOptions) ([]*github.HookDelivery, *github.Response, error) {
+	return g.repos.ListHookDeliveries(ctx, owner, repo, id, opts)
+}
+
+func (g *githubClient) GetHookDelivery(ctx context.Context, owner, repo string, hookID, deliveryID int64) (*github.HookDelivery, *github.Response, error) {
+	return g.repos.GetHookDelivery(ctx, owner, repo, hookID, deliveryID)
+}
+
+func (g *githubClient) ListOrgHooks(ctx context.Context, org string, opts *github.ListOptions) ([]*github.Hook, *github.Response, error) {
+	return g.orgs.ListHooks(ctx, org, opts)
+}
+
+func (g *githubClient) CreateOrgHook(ctx context.Context, org string, hook *github.Hook) (*github.Hook, *github.Response, error) {
+	return g.orgs.CreateHook(ctx, org, hook)
+}
+
+func (g *githubClient) EditOrgHook(ctx context.Context, org string, id int64, hook *github.Hook) (*github.Hook, *github.Response, error) {
+	return g.orgs.EditHook(ctx, org, id, hook)
+}
+
+func (g *githubClient) DeleteOrgHook(ctx context.Context, org string, id int64) (*github.Response, error) {
+	return g.orgs.DeleteHook(ctx, org, id)
+}
+
+func (g *githubClient) PingOrgHook(ctx context.Context, org string, id int64) (*github.Response, error) {
+	return g.orgs.PingHook(ctx, org, id)
+}
+
+func (g *githubClient) ListOrgHookDeliveries(ctx context.Context, org string, id int64, opts *github.ListCursorOptions) ([]*github.HookDelivery, *github.Response, error) {
+	return g.orgs.ListHookDeliveries(ctx, org, id, opts)
+}
+
+func (g *githubClient) GetOrgHookDelivery(ctx context.Context, org string, hookID, deliveryID int64) (*github.HookDelivery, *github.Response, error) {
+	return g.orgs.GetHookDelivery(ctx, org, hookID, deliveryID)
+}
+
+func (g *githubClient) ListOrgRepos(ctx context.Context, org string, opts *github.RepositoryListByOrgOptions) ([]*github.Repository, *github.Response, error) {
+	return g.repos.ListByOrg(ctx, org, opts)
+}
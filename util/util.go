@@ -29,6 +29,7 @@ import (
 	"io"
 	"math/big"
 	"net/http"
+	"net/url"
 	"os"
 	"path"
 	"regexp"
@@ -212,9 +213,19 @@ func GithubClient(ctx context.Context, token string, credsDetails params.GithubC
 	httpClient := &http.Client{Transport: httpTransport}
 	ctx = context.WithValue(ctx, oauth2.HTTPClient, httpClient)
 
-	ts := oauth2.StaticTokenSource(
-		&oauth2.Token{AccessToken: token},
-	)
+	var ts oauth2.TokenSource
+	switch credsDetails.AuthType {
+	case params.GithubAuthTypeApp:
+		var err error
+		ts, err = newGithubAppTokenSource(ctx, httpClient, credsDetails)
+		if err != nil {
+			return nil, nil, errors.Wrap(err, "creating github app token source")
+		}
+	default:
+		ts = oauth2.StaticTokenSource(
+			&oauth2.Token{AccessToken: token},
+		)
+	}
 	tc := oauth2.NewClient(ctx, ts)
 
 	ghClient, err := github.NewEnterpriseClient(credsDetails.APIBaseURL, credsDetails.UploadBaseURL, tc)
@@ -222,7 +233,12 @@ func GithubClient(ctx context.Context, token string, credsDetails params.GithubC
 		return nil, nil, errors.Wrap(err, "fetching github client")
 	}
 
-	return ghClient.Actions, ghClient.Enterprise, nil
+	cli := &githubClient{
+		ActionsService: ghClient.Actions,
+		repos:          ghClient.Repositories,
+		orgs:           ghClient.Organizations,
+	}
+	return cli, ghClient.Enterprise, nil
 }
 
 func GetCloudConfig(bootstrapParams params.BootstrapInstance, tools github.RunnerApplicationDownload, runnerName string) (string, error) {
@@ -422,6 +438,34 @@ func NewLoggingMiddleware(writer io.Writer) func(http.Handler) http.Handler {
 	}
 }
 
+// EntityWebhookURL returns the URL github should use to send webhooks meant for a single
+// repository, organization or enterprise. An empty string is returned if the garm
+// webhook URL is not set.
+func EntityWebhookURL(webhookURL, entityID string) string {
+	if webhookURL == "" {
+		return ""
+	}
+	return fmt.Sprintf("%s/%s", strings.TrimRight(webhookURL, "/"), entityID)
+}
+
+// GithubEndpoint returns the github endpoint a base URL points to. The endpoint is the
+// scheme and host of the base URL, in lower case. It is used to tell apart entities
+// with the same name that live on different github servers.
+func GithubEndpoint(baseURL string) string {
+	if baseURL == "" {
+		baseURL = appdefaults.DefaultGithubURL
+	}
+	parsed, err := url.Parse(baseURL)
+	if err != nil || parsed.Host == "" {
+		return strings.ToLower(strings.TrimRight(baseURL, "/"))
+	}
+	scheme := parsed.Scheme
+	if scheme == "" {
+		scheme = "https"
+	}
+	return strings.ToLower(fmt.Sprintf("%s://%s", scheme, parsed.Host))
+}
+
 func SanitizeLogEntry(entry string) string {
 	return strings.Replace(strings.Replace(entry, "\n", "", -1), "\r", "", -1)
 }